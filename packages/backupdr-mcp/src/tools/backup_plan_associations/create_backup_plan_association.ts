@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
 import { protos } from '@google-cloud/backupdr';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -85,6 +86,7 @@ export const registerCreateBackupPlanAssociationTool = (server: McpServer) => {
     {
       description: 'Creates a new backup plan association.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     createBackupPlanAssociation,
   );
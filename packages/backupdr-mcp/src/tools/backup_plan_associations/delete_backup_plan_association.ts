@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -80,6 +81,7 @@ export const registerDeleteBackupPlanAssociationTool = (server: McpServer) => {
     {
       description: 'Deletes a backup plan association.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     deleteBackupPlanAssociation,
   );
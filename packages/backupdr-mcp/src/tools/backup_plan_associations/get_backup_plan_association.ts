@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -65,6 +66,7 @@ export const registerGetBackupPlanAssociationTool = (server: McpServer) => {
     {
       description: 'Gets a backup plan association.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     getBackupPlanAssociation,
   );
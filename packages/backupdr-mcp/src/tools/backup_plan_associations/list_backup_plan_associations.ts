@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
 import { protos } from '@google-cloud/backupdr';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -96,6 +97,7 @@ export const registerListBackupPlanAssociationsTool = (server: McpServer) => {
     {
       description: 'Lists all backup plan associations for a given backup plan.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     listBackupPlanAssociations,
   );
@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
 import { protos } from '@google-cloud/backupdr';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -71,6 +72,7 @@ export const registerTriggerBackupTool = (server: McpServer) => {
     {
       description: 'Triggers a backup for a given backup plan association.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     triggerBackup,
   );
@@ -20,6 +20,7 @@ import { protos } from '@google-cloud/backupdr';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const dayOfWeekEnum = z.enum([
   'MONDAY',
@@ -236,6 +237,7 @@ export const registerCreateBackupPlanTool = (server: McpServer) => {
     {
       description: 'Creates a new backup plan.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     createBackupPlan,
   );
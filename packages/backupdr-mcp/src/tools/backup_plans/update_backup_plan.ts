@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
 import { protos } from '@google-cloud/backupdr';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const dayOfWeekEnum = z.enum([
   'MONDAY',
@@ -251,6 +252,7 @@ export const registerUpdateBackupPlanTool = (server: McpServer) => {
     {
       description: 'Updates a backup plan.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     updateBackupPlan,
   );
@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
 import { protos } from '@google-cloud/backupdr';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -83,6 +84,7 @@ export const registerCreateBackupVaultTool = (server: McpServer) => {
     {
       description: 'Creates a new backup vault.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     createBackupVault,
   );
@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project: z.string().describe('Required. The project ID of the Cloud SQL instance.'),
@@ -82,6 +83,7 @@ export function registerCsqlRestoreTool(server: McpServer) {
     {
       description: 'Restores a Cloud SQL backup to a Cloud SQL instance.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     csqlRestore,
   );
@@ -88,7 +88,7 @@ describe('registerDeleteBackupTool', () => {
 
     expect(mockServer.registerTool).toHaveBeenCalledWith(
       'delete_backup',
-      expect.any(Object),
+      expect.objectContaining({ annotations: { readOnlyHint: false, destructiveHint: true } }),
       deleteBackup,
     );
   });
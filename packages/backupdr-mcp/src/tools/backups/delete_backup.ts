@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
 import { protos } from '@google-cloud/backupdr';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -69,6 +70,7 @@ export const registerDeleteBackupTool = (server: McpServer) => {
     {
       description: 'Deletes a backup.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     deleteBackup,
   );
@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { googleCloudHttpClient } from '../../utility/gcp_http_client.js';
 import { log } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project: z.string().describe('Required. The project ID of the Cloud SQL instance.'),
@@ -96,6 +97,7 @@ export function registerGetCsqlOperationTool(server: McpServer) {
     {
       description: 'Gets the status of a Cloud SQL operation.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     getCsqlOperation,
   );
@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
 import { protos } from '@google-cloud/backupdr';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   name: z
@@ -111,6 +112,7 @@ export function registerGetOperationTool(server: McpServer) {
     {
       description: 'Gets the status of a BackupDR operation.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     getOperation,
   );
@@ -102,7 +102,7 @@ describe('registerListBackupsTool', () => {
 
     expect(mockServer.registerTool).toHaveBeenCalledWith(
       'list_backups',
-      expect.any(Object),
+      expect.objectContaining({ annotations: { readOnlyHint: true } }),
       listBackups,
     );
   });
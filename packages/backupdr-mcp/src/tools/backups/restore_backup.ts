@@ -20,6 +20,7 @@ import type { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
 import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { log } from '../../utility/logger.js';
 import { protos } from '@google-cloud/backupdr';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 // --- Shared Schemas ---
 
@@ -446,6 +447,7 @@ export const registerRestoreBackupTool = (server: McpServer) => {
       description:
         'Restores a Backup resource to a target environment. Supports Compute Instances and Disks.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     restoreBackup,
   );
@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/api_client_factory.js';
 import { log } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -98,6 +99,7 @@ export const registerFindProtectableResourcesTool = (server: McpServer) => {
       description:
         'Lists protectable resources in a project. This includes Cloud SQL instances, Compute VMs, and Compute Disks.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     findProtectableResources,
   );
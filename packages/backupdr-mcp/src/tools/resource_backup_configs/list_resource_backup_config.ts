@@ -22,6 +22,7 @@ import {
   ListResourceBackupConfigsParams,
 } from '../../utility/gcp_http_client.js';
 import { log } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -92,6 +93,7 @@ export function registerListResourceBackupConfigsTool(server: McpServer) {
     {
       description: 'Lists all resource backup configs in a given project and location.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     listResourceBackupConfigs,
   );
@@ -0,0 +1,146 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { CommandHistory, createCommandHistory } from './command_history.js';
+import {
+  MAX_OUTPUT_CHARS,
+  expandTemplate,
+  projectsInScope,
+  runAcrossProjects,
+  validateTemplate,
+} from './across_projects.js';
+import { successfulTextResult } from './tools/tool_result.js';
+
+vi.mock('./gcloud.js');
+
+describe('expandTemplate', () => {
+  test('replaces the placeholder or adds the project flag', () => {
+    expect(expandTemplate(['storage', 'ls', 'gs://{project}-logs'], 'p')).toEqual([
+      'storage',
+      'ls',
+      'gs://p-logs',
+    ]);
+    expect(expandTemplate(['compute', 'instances', 'list'], 'p')).toEqual([
+      'compute',
+      'instances',
+      'list',
+      '--project=p',
+    ]);
+  });
+
+  test('rejects templates that set a fixed project', () => {
+    expect(validateTemplate(['compute', 'instances', 'list', '--project=prod'])).toContain(
+      'same project',
+    );
+    expect(
+      validateTemplate(['compute', 'instances', 'list', '--project={project}']),
+    ).toBeUndefined();
+  });
+});
+
+describe('projectsInScope', () => {
+  test('lists the active projects below a folder', async () => {
+    const mockedGcloud: gcloud.GcloudExecutable = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({
+        code: 0,
+        stdout: JSON.stringify([
+          { state: 'ACTIVE', additionalAttributes: { projectId: 'web-prod' } },
+          { state: 'DELETE_REQUESTED', additionalAttributes: { projectId: 'old' } },
+          { state: 'ACTIVE', additionalAttributes: { projectId: 'api-prod' } },
+        ]),
+        stderr: '',
+      }),
+    };
+
+    const projects = await projectsInScope(mockedGcloud, { folder: '123' });
+
+    expect(projects).toEqual(['api-prod', 'web-prod']);
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(
+      expect.arrayContaining(['--scope=folders/123']),
+    );
+  });
+});
+
+describe('runAcrossProjects', () => {
+  let history: CommandHistory;
+  const run = vi.fn();
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+  });
+
+  test('isolates failures and keeps the project order', async () => {
+    run.mockImplementation(async (args: string[]) => {
+      // Later projects finish first.
+      await new Promise((resolve) => setTimeout(resolve, args.includes('--project=a') ? 10 : 0));
+      const code = args.includes('--project=b') ? 1 : 0;
+      history.record(args, code);
+      return successfulTextResult(code === 0 ? '[]' : '\nSTDERR:\nPERMISSION_DENIED');
+    });
+
+    const results = await runAcrossProjects(
+      run,
+      history,
+      ['compute', 'instances', 'list'],
+      ['a', 'b', 'c'],
+    );
+
+    expect(results.map(({ project, status, exitCode }) => [project, status, exitCode])).toEqual([
+      ['a', 'succeeded', 0],
+      ['b', 'failed', 1],
+      ['c', 'succeeded', 0],
+    ]);
+  });
+
+  test('runs at most the given number of commands at once', async () => {
+    let inFlight = 0;
+    let maxInFlight = 0;
+    run.mockImplementation(async (args: string[]) => {
+      inFlight++;
+      maxInFlight = Math.max(maxInFlight, inFlight);
+      await new Promise((resolve) => setTimeout(resolve, 1));
+      inFlight--;
+      history.record(args, 0);
+      return successfulTextResult('ok');
+    });
+
+    await runAcrossProjects(
+      run,
+      history,
+      ['projects', 'describe', '{project}'],
+      ['a', 'b', 'c', 'd'],
+      2,
+    );
+
+    expect(run).toHaveBeenCalledTimes(4);
+    expect(maxInFlight).toBe(2);
+  });
+
+  test('truncates long output', async () => {
+    run.mockImplementation(async (args: string[]) => {
+      history.record(args, 0);
+      return successfulTextResult('x'.repeat(MAX_OUTPUT_CHARS + 10));
+    });
+
+    const [result] = await runAcrossProjects(run, history, ['compute', 'instances', 'list'], ['a']);
+
+    expect(result?.output).toContain('[truncated 10 characters]');
+  });
+});
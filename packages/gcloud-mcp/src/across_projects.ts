@@ -0,0 +1,135 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { CommandHistory } from './command_history.js';
+import { GcloudCommandRunner, runRecorded } from './tools/run_gcloud_command.js';
+
+export const PROJECT_PLACEHOLDER = '{project}';
+export const DEFAULT_CONCURRENCY = 5;
+export const MAX_CONCURRENCY = 20;
+// Sweeps beyond this many projects are refused; split them by folder instead.
+export const MAX_PROJECTS = 500;
+// Per-project output is truncated so a sweep fits in a single tool result.
+export const MAX_OUTPUT_CHARS = 4000;
+
+export type ProjectScope = { folder: string } | { organization: string };
+
+export interface ProjectRunResult {
+  project: string;
+  command: string;
+  /** The exit code, or null if the command did not run, e.g. because it is denied. */
+  exitCode: number | null;
+  status: 'succeeded' | 'failed';
+  output: string;
+}
+
+/**
+ * Lists the active projects anywhere below a folder or organization with
+ * Cloud Asset Inventory, which includes projects in nested folders.
+ */
+export const projectsInScope = async (
+  gcloud: GcloudExecutable,
+  scope: ProjectScope,
+): Promise<string[]> => {
+  const parent =
+    'folder' in scope ? `folders/${scope.folder}` : `organizations/${scope.organization}`;
+  const args = [
+    'asset',
+    'search-all-resources',
+    `--scope=${parent}`,
+    '--asset-types=cloudresourcemanager.googleapis.com/Project',
+    '--format=json(state,additionalAttributes.projectId)',
+  ];
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  return z
+    .array(
+      z.object({
+        state: z.string().nullish(),
+        additionalAttributes: z.object({ projectId: z.string().nullish() }).nullish(),
+      }),
+    )
+    .parse(JSON.parse(stdout))
+    .filter(({ state }) => (state ?? 'ACTIVE') === 'ACTIVE')
+    .map(({ additionalAttributes }) => additionalAttributes?.projectId)
+    .filter((projectId): projectId is string => !!projectId)
+    .sort();
+};
+
+/** Returns an error message if a command template can not target each project. */
+export const validateTemplate = (template: string[]): string | undefined => {
+  const hasPlaceholder = template.some((arg) => arg.includes(PROJECT_PLACEHOLDER));
+  const setsProject = template.some((arg) => arg === '--project' || arg.startsWith('--project='));
+  if (!hasPlaceholder && setsProject) {
+    return `The command sets --project, so every run would target the same project. Use ${PROJECT_PLACEHOLDER} in its value instead.`;
+  }
+  return undefined;
+};
+
+/**
+ * Returns the arguments of a command template for a project. Templates
+ * without the placeholder get a `--project` flag.
+ */
+export const expandTemplate = (template: string[], project: string): string[] =>
+  template.some((arg) => arg.includes(PROJECT_PLACEHOLDER))
+    ? template.map((arg) => arg.split(PROJECT_PLACEHOLDER).join(project))
+    : [...template, `--project=${project}`];
+
+/**
+ * Runs a command template in each project through the command runner, with at
+ * most `concurrency` commands in flight. A failure in one project does not stop
+ * the others.
+ */
+export const runAcrossProjects = async (
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+  template: string[],
+  projects: string[],
+  concurrency: number = DEFAULT_CONCURRENCY,
+): Promise<ProjectRunResult[]> => {
+  const results: ProjectRunResult[] = new Array(projects.length);
+  let next = 0;
+  const worker = async () => {
+    while (next < projects.length) {
+      const i = next++;
+      const project = projects[i] ?? '';
+      const args = expandTemplate(template, project);
+      let exitCode: number | null = null;
+      let output: string;
+      try {
+        ({ exitCode, output } = await runRecorded(run, history, args));
+      } catch (e: unknown) {
+        output = e instanceof Error ? e.message : String(e);
+      }
+      results[i] = {
+        project,
+        command: `gcloud ${args.join(' ')}`,
+        exitCode,
+        status: exitCode === 0 ? 'succeeded' : 'failed',
+        output:
+          output.length > MAX_OUTPUT_CHARS
+            ? `${output.slice(0, MAX_OUTPUT_CHARS)}\n[truncated ${output.length - MAX_OUTPUT_CHARS} characters]`
+            : output,
+      };
+    }
+  };
+  await Promise.all(Array.from({ length: Math.min(concurrency, projects.length) }, worker));
+  return results;
+};
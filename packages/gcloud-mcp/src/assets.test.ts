@@ -0,0 +1,113 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import {
+  searchIamPoliciesArgs,
+  searchResourcesArgs,
+  summarizeIamPolicies,
+  summarizeResources,
+} from './assets.js';
+
+describe('searchResourcesArgs', () => {
+  test('sets only the given flags', () => {
+    expect(
+      searchResourcesArgs({
+        scope: 'organizations/123',
+        query: 'externalIPs:*',
+        assetTypes: ['compute.googleapis.com/Instance'],
+        limit: 10,
+      }),
+    ).toEqual([
+      'asset',
+      'search-all-resources',
+      '--scope=organizations/123',
+      '--query=externalIPs:*',
+      '--asset-types=compute.googleapis.com/Instance',
+      '--limit=10',
+      '--format=json',
+    ]);
+    expect(searchIamPoliciesArgs({ scope: 'folders/1' })).toEqual([
+      'asset',
+      'search-all-iam-policies',
+      '--scope=folders/1',
+      '--format=json',
+    ]);
+  });
+
+  test('rejects invalid scopes', () => {
+    expect(() => searchResourcesArgs({ scope: 'my-project' })).toThrow(
+      'Invalid scope "my-project"',
+    );
+  });
+});
+
+describe('summarizeResources', () => {
+  test('keeps the fields of each resource', () => {
+    const name = '//compute.googleapis.com/projects/p/zones/us-central1-a/instances/vm-1';
+    expect(
+      summarizeResources([
+        {
+          name,
+          assetType: 'compute.googleapis.com/Instance',
+          displayName: 'vm-1',
+          project: 'projects/123',
+          location: 'us-central1-a',
+          state: 'RUNNING',
+          labels: { env: 'prod' },
+          parentFullResourceName: '//cloudresourcemanager.googleapis.com/projects/p',
+          additionalAttributes: { externalIPs: ['34.1.2.3'] },
+        },
+      ]),
+    ).toEqual([
+      {
+        name,
+        assetType: 'compute.googleapis.com/Instance',
+        displayName: 'vm-1',
+        project: 'projects/123',
+        location: 'us-central1-a',
+        state: 'RUNNING',
+        labels: { env: 'prod' },
+        networkTags: [],
+        createTime: null,
+        updateTime: null,
+        parent: '//cloudresourcemanager.googleapis.com/projects/p',
+      },
+    ]);
+  });
+});
+
+describe('summarizeIamPolicies', () => {
+  test('keeps the bindings of each policy', () => {
+    expect(
+      summarizeIamPolicies([
+        {
+          resource: '//storage.googleapis.com/public-bucket',
+          assetType: 'storage.googleapis.com/Bucket',
+          project: 'projects/123',
+          policy: { bindings: [{ role: 'roles/storage.objectViewer', members: ['allUsers'] }] },
+        },
+      ]),
+    ).toEqual([
+      {
+        resource: '//storage.googleapis.com/public-bucket',
+        assetType: 'storage.googleapis.com/Bucket',
+        project: 'projects/123',
+        bindings: [{ role: 'roles/storage.objectViewer', members: ['allUsers'] }],
+      },
+    ]);
+  });
+});
@@ -0,0 +1,366 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { isZone } from './gcloud_json.js';
+
+export interface AssetType {
+  /** The command group of the resource, as used by the naming policy. */
+  resourceType: string;
+  /** Returns the arguments of the command that adds or updates labels. */
+  updateLabelsArgs: (name: string, location: string, labels: string) => string[];
+  /** Returns the arguments of the command that deletes the resource without prompting. */
+  deleteArgs: (name: string, location: string) => string[];
+  /** Returns the arguments of the command that describes the resource. */
+  describeArgs: (name: string, location: string) => string[];
+  /** Returns the full resource name used by Cloud Asset Inventory. */
+  fullName: (project: string, name: string, location: string) => string;
+}
+
+const scopeFlag = (location: string) =>
+  isZone(location) ? `--zone=${location}` : `--region=${location}`;
+
+// Cloud Asset Inventory types of commonly managed resources.
+export const ASSET_TYPES: Record<string, AssetType> = {
+  'compute.googleapis.com/Instance': {
+    resourceType: 'compute instances',
+    updateLabelsArgs: (name, location, labels) => [
+      'compute',
+      'instances',
+      'add-labels',
+      name,
+      `--zone=${location}`,
+      `--labels=${labels}`,
+    ],
+    deleteArgs: (name, location) => [
+      'compute',
+      'instances',
+      'delete',
+      name,
+      `--zone=${location}`,
+      '--quiet',
+    ],
+    describeArgs: (name, location) => [
+      'compute',
+      'instances',
+      'describe',
+      name,
+      `--zone=${location}`,
+    ],
+    fullName: (project, name, location) =>
+      `//compute.googleapis.com/projects/${project}/zones/${location}/instances/${name}`,
+  },
+  'compute.googleapis.com/Disk': {
+    resourceType: 'compute disks',
+    updateLabelsArgs: (name, location, labels) => [
+      'compute',
+      'disks',
+      'add-labels',
+      name,
+      scopeFlag(location),
+      `--labels=${labels}`,
+    ],
+    deleteArgs: (name, location) => [
+      'compute',
+      'disks',
+      'delete',
+      name,
+      scopeFlag(location),
+      '--quiet',
+    ],
+    describeArgs: (name, location) => ['compute', 'disks', 'describe', name, scopeFlag(location)],
+    fullName: (project, name, location) =>
+      `//compute.googleapis.com/projects/${project}/${isZone(location) ? 'zones' : 'regions'}/${location}/disks/${name}`,
+  },
+  'compute.googleapis.com/Snapshot': {
+    resourceType: 'compute snapshots',
+    updateLabelsArgs: (name, _location, labels) => [
+      'compute',
+      'snapshots',
+      'add-labels',
+      name,
+      `--labels=${labels}`,
+    ],
+    deleteArgs: (name) => ['compute', 'snapshots', 'delete', name, '--quiet'],
+    describeArgs: (name) => ['compute', 'snapshots', 'describe', name],
+    fullName: (project, name) =>
+      `//compute.googleapis.com/projects/${project}/global/snapshots/${name}`,
+  },
+  'storage.googleapis.com/Bucket': {
+    resourceType: 'storage buckets',
+    updateLabelsArgs: (name, _location, labels) => [
+      'storage',
+      'buckets',
+      'update',
+      `gs://${name}`,
+      `--update-labels=${labels}`,
+    ],
+    // Deleting a bucket fails unless it is empty, which keeps objects from being lost.
+    deleteArgs: (name) => ['storage', 'buckets', 'delete', `gs://${name}`],
+    describeArgs: (name) => ['storage', 'buckets', 'describe', `gs://${name}`],
+    fullName: (_project, name) => `//storage.googleapis.com/${name}`,
+  },
+  'sqladmin.googleapis.com/Instance': {
+    resourceType: 'sql instances',
+    updateLabelsArgs: (name, _location, labels) => [
+      'sql',
+      'instances',
+      'patch',
+      name,
+      `--update-labels=${labels}`,
+    ],
+    deleteArgs: (name) => ['sql', 'instances', 'delete', name, '--quiet'],
+    describeArgs: (name) => ['sql', 'instances', 'describe', name],
+    fullName: (project, name) => `//cloudsql.googleapis.com/projects/${project}/instances/${name}`,
+  },
+  'container.googleapis.com/Cluster': {
+    resourceType: 'container clusters',
+    updateLabelsArgs: (name, location, labels) => [
+      'container',
+      'clusters',
+      'update',
+      name,
+      `--location=${location}`,
+      `--update-labels=${labels}`,
+    ],
+    deleteArgs: (name, location) => [
+      'container',
+      'clusters',
+      'delete',
+      name,
+      `--location=${location}`,
+      '--quiet',
+    ],
+    describeArgs: (name, location) => [
+      'container',
+      'clusters',
+      'describe',
+      name,
+      `--location=${location}`,
+    ],
+    fullName: (project, name, location) =>
+      `//container.googleapis.com/projects/${project}/locations/${location}/clusters/${name}`,
+  },
+  'run.googleapis.com/Service': {
+    resourceType: 'run services',
+    updateLabelsArgs: (name, location, labels) => [
+      'run',
+      'services',
+      'update',
+      name,
+      `--region=${location}`,
+      `--update-labels=${labels}`,
+    ],
+    deleteArgs: (name, location) => [
+      'run',
+      'services',
+      'delete',
+      name,
+      `--region=${location}`,
+      '--quiet',
+    ],
+    describeArgs: (name, location) => ['run', 'services', 'describe', name, `--region=${location}`],
+    fullName: (project, name, location) =>
+      `//run.googleapis.com/projects/${project}/locations/${location}/services/${name}`,
+  },
+  'pubsub.googleapis.com/Topic': {
+    resourceType: 'pubsub topics',
+    updateLabelsArgs: (name, _location, labels) => [
+      'pubsub',
+      'topics',
+      'update',
+      name,
+      `--update-labels=${labels}`,
+    ],
+    deleteArgs: (name) => ['pubsub', 'topics', 'delete', name],
+    describeArgs: (name) => ['pubsub', 'topics', 'describe', name],
+    fullName: (project, name) => `//pubsub.googleapis.com/projects/${project}/topics/${name}`,
+  },
+};
+
+// There are more fields in each search result, but only these are used.
+const AssetSchema = z.object({
+  name: z.string(),
+  assetType: z.string(),
+  location: z.string().nullish(),
+  labels: z.record(z.string()).nullish(),
+  createTime: z.string().nullish(),
+});
+
+export interface Asset {
+  project: string;
+  assetType: string;
+  /** The short name of the resource, e.g. `vm-1`. */
+  name: string;
+  location: string;
+  labels: Record<string, string>;
+  createTime: string | null;
+}
+
+/**
+ * Searches a project for resources of the known asset types with Cloud Asset
+ * Inventory, so every service is covered by a single call.
+ */
+export const searchAssets = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  assetTypes: string[] = Object.keys(ASSET_TYPES),
+): Promise<Asset[]> => {
+  const args = [
+    'asset',
+    'search-all-resources',
+    `--scope=projects/${project}`,
+    `--asset-types=${assetTypes.join(',')}`,
+    '--format=json(name,assetType,location,labels,createTime)',
+  ];
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  return z
+    .array(AssetSchema)
+    .parse(JSON.parse(stdout))
+    .map((asset) => ({
+      project,
+      assetType: asset.assetType,
+      // Full resource names, e.g. `//compute.googleapis.com/projects/p/zones/z/instances/vm`.
+      name: asset.name.split('/').at(-1) ?? asset.name,
+      location: asset.location ?? 'global',
+      labels: asset.labels ?? {},
+      createTime: asset.createTime ?? null,
+    }));
+};
+
+export interface AssetSearch {
+  /** The project, folder or organization to search, e.g. `organizations/123`. */
+  scope: string;
+  /** A Cloud Asset Inventory query, e.g. `state:RUNNING` or `policy:allUsers`. */
+  query?: string | undefined;
+  assetTypes?: string[] | undefined;
+  limit?: number | undefined;
+}
+
+const SCOPE_PATTERN = /^(projects\/[a-z0-9.:-]+|folders\/\d+|organizations\/\d+)$/;
+
+const searchFlags = (search: AssetSearch) => {
+  if (!SCOPE_PATTERN.test(search.scope)) {
+    throw new Error(
+      `Invalid scope "${search.scope}". Use projects/PROJECT_ID, folders/FOLDER_ID or organizations/ORGANIZATION_ID.`,
+    );
+  }
+  return [
+    `--scope=${search.scope}`,
+    ...(search.query ? [`--query=${search.query}`] : []),
+    ...(search.assetTypes?.length ? [`--asset-types=${search.assetTypes.join(',')}`] : []),
+    ...(search.limit !== undefined ? [`--limit=${search.limit}`] : []),
+    '--format=json',
+  ];
+};
+
+export const searchResourcesArgs = (search: AssetSearch) => [
+  'asset',
+  'search-all-resources',
+  ...searchFlags(search),
+];
+
+export const searchIamPoliciesArgs = (search: AssetSearch) => [
+  'asset',
+  'search-all-iam-policies',
+  ...searchFlags(search),
+];
+
+// Projects are returned as `projects/123`, by number.
+const ResourceSearchResultSchema = z.object({
+  name: z.string(),
+  assetType: z.string(),
+  displayName: z.string().nullish(),
+  project: z.string().nullish(),
+  location: z.string().nullish(),
+  state: z.string().nullish(),
+  labels: z.record(z.string()).nullish(),
+  networkTags: z.array(z.string()).nullish(),
+  createTime: z.string().nullish(),
+  updateTime: z.string().nullish(),
+  parentFullResourceName: z.string().nullish(),
+});
+
+export const ResourceSummarySchema = z.object({
+  name: z.string(),
+  assetType: z.string(),
+  displayName: z.string().nullable(),
+  project: z.string().nullable(),
+  location: z.string().nullable(),
+  state: z.string().nullable(),
+  labels: z.record(z.string()),
+  networkTags: z.array(z.string()),
+  createTime: z.string().nullable(),
+  updateTime: z.string().nullable(),
+  parent: z.string().nullable(),
+});
+export type ResourceSummary = z.infer<typeof ResourceSummarySchema>;
+
+/** Summarizes the output of `gcloud asset search-all-resources --format=json`. */
+export const summarizeResources = (json: unknown): ResourceSummary[] =>
+  z
+    .array(ResourceSearchResultSchema)
+    .parse(json)
+    .map((resource) => ({
+      name: resource.name,
+      assetType: resource.assetType,
+      displayName: resource.displayName ?? null,
+      project: resource.project ?? null,
+      location: resource.location ?? null,
+      state: resource.state ?? null,
+      labels: resource.labels ?? {},
+      networkTags: resource.networkTags ?? [],
+      createTime: resource.createTime ?? null,
+      updateTime: resource.updateTime ?? null,
+      parent: resource.parentFullResourceName ?? null,
+    }));
+
+const IamPolicySearchResultSchema = z.object({
+  resource: z.string(),
+  assetType: z.string().nullish(),
+  project: z.string().nullish(),
+  policy: z
+    .object({
+      bindings: z
+        .array(z.object({ role: z.string(), members: z.array(z.string()).default([]) }))
+        .default([]),
+    })
+    .nullish(),
+});
+
+export const PolicySummarySchema = z.object({
+  resource: z.string(),
+  assetType: z.string().nullable(),
+  project: z.string().nullable(),
+  bindings: z.array(z.object({ role: z.string(), members: z.array(z.string()) })),
+});
+export type PolicySummary = z.infer<typeof PolicySummarySchema>;
+
+/** Summarizes the output of `gcloud asset search-all-iam-policies --format=json`. */
+export const summarizeIamPolicies = (json: unknown): PolicySummary[] =>
+  z
+    .array(IamPolicySearchResultSchema)
+    .parse(json)
+    .map((result) => ({
+      resource: result.resource,
+      assetType: result.assetType ?? null,
+      project: result.project ?? null,
+      bindings: result.policy?.bindings ?? [],
+    }));
@@ -0,0 +1,175 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import { AuditEntry, auditTools, createAuditLog, withAuditLog } from './audit_log.js';
+
+vi.mock('./gcloud.js');
+
+const caller = { principal: 'alice@example.com', serviceAccount: 'mcp@p.iam.gserviceaccount.com' };
+
+const readEntries = (file: string): AuditEntry[] =>
+  fs
+    .readFileSync(file, 'utf-8')
+    .trim()
+    .split('\n')
+    .map((line) => JSON.parse(line) as AuditEntry);
+
+describe('createAuditLog', () => {
+  let dir: string;
+
+  beforeEach(() => {
+    dir = fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-audit-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(dir, { recursive: true, force: true });
+  });
+
+  test('appends entries to the file across sessions', () => {
+    const file = path.join(dir, 'audit.jsonl');
+    const entry = {
+      kind: 'tool' as const,
+      name: 'gcloud_context',
+      caller,
+      arguments: {},
+      ok: true,
+      durationMs: 5,
+      outputBytes: 10,
+    };
+    createAuditLog({ file }).record(entry);
+    createAuditLog({ file }).record(entry);
+
+    const entries = readEntries(file);
+    expect(entries).toHaveLength(2);
+    expect(entries[0]).toEqual({ timestamp: expect.any(String), ...entry });
+  });
+
+  test('writes entries to Cloud Logging', async () => {
+    const api: GoogleApiClient = { get: vi.fn(), post: vi.fn().mockResolvedValue({}) };
+    createAuditLog({ loggingProject: 'ops' }, api).record({
+      kind: 'command',
+      name: 'gcloud',
+      caller,
+      argv: ['compute', 'instances', 'delete', 'vm-1'],
+      exitCode: 1,
+      ok: false,
+      durationMs: 900,
+      outputBytes: 42,
+    });
+
+    await vi.waitFor(() => expect(api.post).toHaveBeenCalledOnce());
+    const [url, body] = vi.mocked(api.post).mock.calls[0]!;
+    expect(url).toBe('https://logging.googleapis.com/v2/entries:write');
+    expect(body).toMatchObject({
+      logName: 'projects/ops/logs/gcloud-mcp-audit',
+      entries: [
+        {
+          severity: 'WARNING',
+          labels: { kind: 'command', name: 'gcloud', principal: 'alice@example.com' },
+          jsonPayload: { argv: ['compute', 'instances', 'delete', 'vm-1'], exitCode: 1 },
+        },
+      ],
+    });
+  });
+});
+
+describe('auditTools', () => {
+  test('records the arguments, outcome and output size of tool calls', async () => {
+    const registerTool = vi.fn();
+    const server = { registerTool } as unknown as McpServer;
+    const audit = { record: vi.fn() };
+    auditTools(server, audit, caller);
+
+    server.registerTool('run_gcloud_command', {}, async () => ({
+      content: [{ type: 'text' as const, text: 'héllo' }],
+    }));
+    await (registerTool as Mock).mock.calls[0]![2]({ args: ['config', 'list'] });
+
+    expect(audit.record).toHaveBeenCalledWith({
+      kind: 'tool',
+      name: 'run_gcloud_command',
+      caller,
+      arguments: { args: ['config', 'list'] },
+      ok: true,
+      durationMs: expect.any(Number),
+      outputBytes: 6,
+    });
+  });
+});
+
+describe('withAuditLog', () => {
+  test('records every gcloud command with its exit code', async () => {
+    const invoke = vi.fn().mockResolvedValue({ code: 2, stdout: 'out', stderr: 'error' });
+    const audit = { record: vi.fn() };
+    const gcloudExecutable = { lint: vi.fn(), invoke } as gcloud.GcloudExecutable;
+    const audited = withAuditLog(gcloudExecutable, audit, caller);
+
+    const result = await audited.invoke(['projects', 'list'], { CLOUDSDK_CORE_PROJECT: 'p' });
+
+    expect(result.code).toBe(2);
+    expect(invoke).toHaveBeenCalledWith(['projects', 'list'], { CLOUDSDK_CORE_PROJECT: 'p' });
+    expect(audit.record).toHaveBeenCalledWith({
+      kind: 'command',
+      name: 'gcloud',
+      caller,
+      argv: ['projects', 'list'],
+      project: 'p',
+      exitCode: 2,
+      ok: false,
+      durationMs: expect.any(Number),
+      outputBytes: 8,
+    });
+  });
+
+  test('records the argv and project the wrappers ran the command with', async () => {
+    const invoke = vi
+      .fn()
+      .mockResolvedValueOnce({
+        code: 0,
+        stdout: '',
+        stderr: '',
+        argv: ['compute', 'instances', 'list', '--project=prod'],
+        envProject: 'staging',
+      })
+      .mockResolvedValueOnce({ code: 0, stdout: '', stderr: '', envProject: 'staging' })
+      .mockResolvedValueOnce({ code: 0, stdout: '', stderr: '' });
+    const audit = { record: vi.fn() };
+    const gcloudExecutable = { lint: vi.fn(), invoke } as gcloud.GcloudExecutable;
+    const audited = withAuditLog(gcloudExecutable, audit, caller);
+
+    await audited.invoke(['compute', 'instances', 'list']);
+    await audited.invoke(['compute', 'instances', 'list']);
+    await audited.invoke(['compute', 'instances', 'list']);
+
+    const entries = audit.record.mock.calls.map(([entry]) => entry);
+    expect(entries[0]).toMatchObject({
+      argv: ['compute', 'instances', 'list', '--project=prod'],
+      project: 'prod',
+    });
+    expect(entries[1]).toMatchObject({
+      argv: ['compute', 'instances', 'list'],
+      project: 'staging',
+    });
+    expect(entries[2]).not.toHaveProperty('project');
+  });
+});
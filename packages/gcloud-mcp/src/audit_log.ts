@@ -0,0 +1,221 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { GcloudExecutable } from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import { log } from './utility/logger.js';
+
+export const LOGGING_URL = 'https://logging.googleapis.com/v2';
+export const DEFAULT_AUDIT_LOG_NAME = 'gcloud-mcp-audit';
+
+export interface AuditConfig {
+  /** A file audit entries are appended to as JSON Lines. Must be absolute. */
+  file?: string;
+  /** A project audit entries are written to with Cloud Logging. */
+  loggingProject?: string;
+  /** The name of the Cloud Logging log. Defaults to `gcloud-mcp-audit`. */
+  logName?: string;
+}
+
+/** Who a tool call or gcloud command was made for. */
+export interface AuditCaller {
+  /** The authenticated principal of a remote session, or `local:<user>` over stdio. */
+  principal: string;
+  /** The service account gcloud impersonated for the caller, if any. */
+  serviceAccount?: string;
+}
+
+/**
+ * A tool call or a gcloud command run by one. Unlike usage events, audit
+ * entries include the full arguments.
+ */
+export interface AuditEntry {
+  timestamp: string;
+  kind: 'tool' | 'command';
+  /** The tool name, or `gcloud` for commands. */
+  name: string;
+  caller: AuditCaller;
+  /** The arguments of a tool call. */
+  arguments?: unknown;
+  /** The full argv of a gcloud command, without the leading `gcloud`. */
+  argv?: string[];
+  /** The project a gcloud command ran in, unless it was left to the gcloud configuration. */
+  project?: string;
+  /** The exit code of a gcloud command, or null if it was killed. */
+  exitCode?: number | null;
+  ok: boolean;
+  durationMs: number;
+  /** The size of the output in bytes: the content of a tool result, or stdout and stderr. */
+  outputBytes: number;
+}
+
+export type AuditLog = ReturnType<typeof createAuditLog>;
+
+export const validateAuditConfig = (config: AuditConfig): string | undefined => {
+  if (!config.file && !config.loggingProject) {
+    return 'The audit log needs a "file", a "loggingProject", or both.';
+  }
+  if (config.file && !path.isAbsolute(config.file)) {
+    return `Audit log file path must be absolute: ${config.file}`;
+  }
+  if (config.logName !== undefined && !/^[\w./-]{1,512}$/.test(config.logName)) {
+    return `Invalid audit log name: "${config.logName}".`;
+  }
+  return undefined;
+};
+
+/** Returns the caller of a session over stdio: the user the server runs as. */
+export const localCaller = (): AuditCaller => ({ principal: `local:${os.userInfo().username}` });
+
+/**
+ * Creates the audit log. Entries are appended to the file, which is never
+ * truncated or rewritten, and written to Cloud Logging with a logging project.
+ */
+export const createAuditLog = (config: AuditConfig, api?: GoogleApiClient) => {
+  const logName = config.logName ?? DEFAULT_AUDIT_LOG_NAME;
+
+  const writeToCloudLogging = async (entry: AuditEntry, project: string) => {
+    await api?.post(`${LOGGING_URL}/entries:write`, {
+      logName: `projects/${project}/logs/${encodeURIComponent(logName)}`,
+      resource: { type: 'global', labels: { project_id: project } },
+      entries: [
+        {
+          timestamp: entry.timestamp,
+          severity: entry.ok ? 'NOTICE' : 'WARNING',
+          labels: { kind: entry.kind, name: entry.name, principal: entry.caller.principal },
+          jsonPayload: entry,
+        },
+      ],
+    });
+  };
+
+  return {
+    record: (event: Omit<AuditEntry, 'timestamp'>): AuditEntry => {
+      const entry: AuditEntry = { timestamp: new Date().toISOString(), ...event };
+      if (config.file) {
+        try {
+          fs.appendFileSync(config.file, JSON.stringify(entry) + '\n', { mode: 0o600 });
+        } catch (e: unknown) {
+          // Unlike telemetry, every lost audit entry is reported.
+          log.error(
+            `Unable to write the audit log to ${config.file}`,
+            e instanceof Error ? e : new Error(String(e)),
+            { entry },
+          );
+        }
+      }
+      if (config.loggingProject) {
+        writeToCloudLogging(entry, config.loggingProject).catch((e: unknown) =>
+          log.error(
+            'Unable to write the audit log to Cloud Logging',
+            e instanceof Error ? e : new Error(String(e)),
+            { entry },
+          ),
+        );
+      }
+      return entry;
+    },
+  };
+};
+
+/** Returns the size of the content of a tool result in bytes. */
+const outputBytesOf = (result: unknown): number =>
+  ((result as { content?: unknown[] } | undefined)?.content ?? []).reduce<number>(
+    (total, item) => {
+      const text = (item as { text?: unknown }).text;
+      return total + Buffer.byteLength(typeof text === 'string' ? text : JSON.stringify(item));
+    },
+    0,
+  );
+
+type ToolCallback = (...args: unknown[]) => unknown;
+
+/** Records every call of the tools registered on the server from now on. */
+export const auditTools = (server: McpServer, audit: AuditLog, caller: AuditCaller): McpServer => {
+  const registerTool = server.registerTool.bind(server) as unknown as ToolCallback;
+  const audited = (name: string, config: unknown, callback: ToolCallback) =>
+    registerTool(name, config, async (...args: unknown[]) => {
+      const start = Date.now();
+      const record = (ok: boolean, outputBytes: number) =>
+        audit.record({
+          kind: 'tool',
+          name,
+          caller,
+          arguments: args[0],
+          ok,
+          durationMs: Date.now() - start,
+          outputBytes,
+        });
+      try {
+        const result = await callback(...args);
+        const isError = (result as { isError?: boolean } | undefined)?.isError === true;
+        record(!isError, outputBytesOf(result));
+        return result;
+      } catch (e: unknown) {
+        record(false, 0);
+        throw e;
+      }
+    });
+  server.registerTool = audited as unknown as typeof server.registerTool;
+  return server;
+};
+
+/** Returns the value of the last --project flag of gcloud arguments, if any. */
+const projectFlagOf = (argv: string[]): string | undefined => {
+  let project: string | undefined;
+  argv.forEach((arg, i) => {
+    if (arg.startsWith('--project=')) {
+      project = arg.slice('--project='.length);
+    } else if (arg === '--project') {
+      project = argv[i + 1];
+    }
+  });
+  return project;
+};
+
+/**
+ * Wraps gcloud so that every command it runs is recorded in the audit log,
+ * with the arguments and project the wrappers of the executable ran it with.
+ */
+export const withAuditLog = (
+  gcloud: GcloudExecutable,
+  audit: AuditLog,
+  caller: AuditCaller,
+): GcloudExecutable => ({
+  ...gcloud,
+  invoke: async (args, env, options) => {
+    const start = Date.now();
+    const result = await (options ? gcloud.invoke(args, env, options) : gcloud.invoke(args, env));
+    const argv = result.argv ?? args;
+    const project = projectFlagOf(argv) ?? result.envProject ?? env?.['CLOUDSDK_CORE_PROJECT'];
+    audit.record({
+      kind: 'command',
+      name: 'gcloud',
+      caller,
+      argv: [...argv],
+      ...(project && { project }),
+      exitCode: result.code,
+      ok: result.code === 0,
+      durationMs: Date.now() - start,
+      outputBytes: Buffer.byteLength(result.stdout) + Buffer.byteLength(result.stderr),
+    });
+    return result;
+  },
+});
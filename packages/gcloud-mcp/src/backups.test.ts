@@ -0,0 +1,242 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import {
+  backupNameOf,
+  createBackupArgs,
+  listBackups,
+  validateBackupTarget,
+  verifyBackupRecency,
+} from './backups.js';
+
+vi.mock('./gcloud.js');
+
+const NOW = new Date('2025-01-02T00:00:00Z');
+
+const listed = (items: unknown[]) => ({ code: 0, stdout: JSON.stringify(items), stderr: '' });
+
+const fakeGcloud = (responses: Record<string, unknown[]>): gcloud.GcloudExecutable => ({
+  lint: vi.fn(),
+  invoke: vi.fn(async (args: string[]) => {
+    const key = Object.keys(responses).find((prefix) => args.join(' ').startsWith(prefix));
+    return key
+      ? listed(responses[key] ?? [])
+      : { code: 1, stdout: '', stderr: `unexpected: ${args.join(' ')}` };
+  }),
+});
+
+describe('listBackups', () => {
+  test('lists backups of every kind newest first', async () => {
+    const gcloud = fakeGcloud({
+      'compute snapshots list': [
+        {
+          name: 'web-snap',
+          creationTimestamp: '2025-01-01T00:00:00Z',
+          storageBytes: String(2 * 1024 ** 3),
+          sourceDisk: 'https://compute.googleapis.com/compute/v1/projects/p/zones/us-central1-a/disks/web',
+          status: 'READY',
+          storageLocations: ['us'],
+        },
+      ],
+      'compute resource-policies list': [
+        {
+          name: 'daily',
+          region: 'https://compute.googleapis.com/compute/v1/projects/p/regions/us-central1',
+          snapshotSchedulePolicy: {
+            schedule: { dailySchedule: { daysInCycle: 1 } },
+            retentionPolicy: { maxRetentionDays: 14 },
+          },
+        },
+      ],
+      'sql instances list': [{ name: 'db' }],
+      'sql backups list': [
+        { id: '1700', endTime: '2025-01-01T12:00:00Z', status: 'SUCCESSFUL', location: 'us' },
+      ],
+      'filestore backups list': [
+        {
+          name: 'projects/p/locations/us-central1/backups/files-backup',
+          createTime: '2024-12-31T00:00:00Z',
+          sourceInstance: 'projects/p/locations/us-central1-a/instances/files',
+          state: 'READY',
+        },
+      ],
+    });
+
+    const inventory = await listBackups(
+      gcloud,
+      ['p'],
+      ['disk-snapshots', 'snapshot-schedules', 'sql-backups', 'filestore-backups'],
+      NOW,
+    );
+
+    expect(inventory.backups.map(({ kind, name }) => [kind, name])).toEqual([
+      ['sql-backups', '1700'],
+      ['disk-snapshots', 'web-snap'],
+      ['filestore-backups', 'files-backup'],
+    ]);
+    expect(inventory.backups[1]).toMatchObject({
+      source: 'web',
+      location: 'us',
+      ageHours: 24,
+      sizeGb: 2,
+    });
+    expect(inventory.backups[2]).toMatchObject({ source: 'files', location: 'us-central1' });
+    expect(inventory.schedules).toEqual([
+      {
+        project: 'p',
+        name: 'daily',
+        region: 'us-central1',
+        schedule: 'every 1 days',
+        retentionDays: 14,
+      },
+    ]);
+    expect(inventory.errors).toEqual([]);
+  });
+
+  test('reports kinds that fail', async () => {
+    const inventory = await listBackups(fakeGcloud({}), ['p'], ['filestore-backups'], NOW);
+
+    expect(inventory.errors).toEqual([
+      {
+        project: 'p',
+        kind: 'filestore-backups',
+        message: expect.stringContaining('gcloud filestore backups list'),
+      },
+    ]);
+  });
+});
+
+describe('createBackupArgs', () => {
+  test('creates snapshots of zonal and regional disks', () => {
+    expect(
+      createBackupArgs(
+        { kind: 'disk', name: 'web', project: 'p', location: 'us-central1-a' },
+        'Before resizing',
+        NOW,
+      ),
+    ).toEqual([
+      'compute',
+      'snapshots',
+      'create',
+      'web-backup-20250102000000',
+      '--source-disk=web',
+      '--source-disk-zone=us-central1-a',
+      '--description=Before resizing',
+      '--project=p',
+    ]);
+    expect(
+      createBackupArgs({ kind: 'disk', name: 'web', project: 'p', location: 'us-central1' }, 'x'),
+    ).toContain('--source-disk-region=us-central1');
+  });
+
+  test('creates Cloud SQL and Filestore backups', () => {
+    expect(createBackupArgs({ kind: 'sql-instance', name: 'db', project: 'p' }, 'x')).toEqual([
+      'sql',
+      'backups',
+      'create',
+      '--instance=db',
+      '--description=x',
+      '--project=p',
+    ]);
+    expect(
+      createBackupArgs(
+        {
+          kind: 'filestore-instance',
+          name: 'files',
+          project: 'p',
+          location: 'us-central1-a',
+          fileShare: 'vol1',
+        },
+        'x',
+        NOW,
+      ),
+    ).toEqual([
+      'filestore',
+      'backups',
+      'create',
+      'files-backup-20250102000000',
+      '--instance=files',
+      '--instance-location=us-central1-a',
+      '--file-share=vol1',
+      '--region=us-central1',
+      '--description=x',
+      '--project=p',
+    ]);
+  });
+});
+
+describe('backupNameOf', () => {
+  test('keeps names within 63 characters', () => {
+    expect(backupNameOf('A'.repeat(80), NOW)).toHaveLength(63);
+    expect(backupNameOf('Web_1', NOW)).toBe('web-1-backup-20250102000000');
+  });
+});
+
+describe('validateBackupTarget', () => {
+  test('requires locations and file shares', () => {
+    expect(validateBackupTarget({ kind: 'disk', name: 'web', project: 'p' })).toContain(
+      'requires its location',
+    );
+    expect(
+      validateBackupTarget({
+        kind: 'filestore-instance',
+        name: 'files',
+        project: 'p',
+        location: 'us-central1-a',
+      }),
+    ).toContain('requires the file share');
+    expect(validateBackupTarget({ kind: 'sql-instance', name: 'db', project: 'p' })).toBe(
+      undefined,
+    );
+  });
+});
+
+describe('verifyBackupRecency', () => {
+  test('checks the latest successful backup', async () => {
+    const gcloud = fakeGcloud({
+      'sql backups list': [
+        { id: '3', endTime: '2025-01-01T23:00:00Z', status: 'FAILED' },
+        { id: '2', endTime: '2025-01-01T20:00:00Z', status: 'SUCCESSFUL' },
+        { id: '1', endTime: '2024-12-30T00:00:00Z', status: 'SUCCESSFUL' },
+      ],
+    });
+
+    const target = { kind: 'sql-instance' as const, name: 'db', project: 'p' };
+    const recent = await verifyBackupRecency(gcloud, target, 24, NOW);
+    const stale = await verifyBackupRecency(gcloud, target, 2, NOW);
+
+    expect(recent).toMatchObject({ latestBackup: { name: '2', ageHours: 4 }, recent: true });
+    expect(stale.recent).toBe(false);
+  });
+
+  test('filters snapshots by disk and location', async () => {
+    const gcloud = fakeGcloud({ 'compute snapshots list': [] });
+
+    const recency = await verifyBackupRecency(
+      gcloud,
+      { kind: 'disk', name: 'web', project: 'p', location: 'us-central1-a' },
+      24,
+      NOW,
+    );
+
+    expect(recency).toMatchObject({ latestBackup: null, recent: false });
+    expect(vi.mocked(gcloud.invoke).mock.calls[0]![0]).toContain(
+      '--filter=sourceDisk~/us-central1-a/disks/web$',
+    );
+  });
+});
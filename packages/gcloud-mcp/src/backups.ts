@@ -0,0 +1,450 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson, isZone, lastSegment } from './gcloud_json.js';
+
+const HOUR_MS = 60 * 60 * 1000;
+
+export const BACKUP_KINDS = [
+  'disk-snapshots',
+  'snapshot-schedules',
+  'sql-backups',
+  'filestore-backups',
+] as const;
+
+export type BackupKind = (typeof BACKUP_KINDS)[number];
+
+/** The gcloud commands listing each kind runs. */
+export const BACKUP_KIND_COMMANDS: Record<BackupKind, string[]> = {
+  'disk-snapshots': ['compute snapshots list'],
+  'snapshot-schedules': ['compute resource-policies list'],
+  'sql-backups': ['sql instances list', 'sql backups list'],
+  'filestore-backups': ['filestore backups list'],
+};
+
+export const BACKUP_TARGETS = ['disk', 'sql-instance', 'filestore-instance'] as const;
+
+export type BackupTargetKind = (typeof BACKUP_TARGETS)[number];
+
+/** The gcloud command that lists the backups of each kind of resource. */
+export const BACKUP_TARGET_COMMANDS: Record<BackupTargetKind, string> = {
+  disk: 'compute snapshots list',
+  'sql-instance': 'sql backups list',
+  'filestore-instance': 'filestore backups list',
+};
+
+export interface BackupTarget {
+  kind: BackupTargetKind;
+  /** The disk, Cloud SQL instance or Filestore instance. */
+  name: string;
+  project: string;
+  /** The zone or region of a disk or Filestore instance. */
+  location?: string;
+  /** The file share of a Filestore instance to back up. */
+  fileShare?: string;
+}
+
+export interface Backup {
+  kind: Exclude<BackupKind, 'snapshot-schedules'>;
+  project: string;
+  name: string;
+  /** The disk or instance the backup was taken of. */
+  source: string | null;
+  location: string | null;
+  status: string | null;
+  createdAt: string | null;
+  ageHours: number | null;
+  sizeGb: number | null;
+}
+
+export interface SnapshotSchedule {
+  project: string;
+  name: string;
+  region: string;
+  schedule: string | null;
+  retentionDays: number | null;
+}
+
+export interface BackupInventory {
+  backups: Backup[];
+  schedules: SnapshotSchedule[];
+  errors: Array<{ project: string; kind: BackupKind; message: string }>;
+}
+
+export interface BackupRecency {
+  target: Omit<BackupTarget, 'fileShare'>;
+  latestBackup: Backup | null;
+  maxAgeHours: number;
+  /** True if the latest successful backup is at most maxAgeHours old. */
+  recent: boolean;
+}
+
+const SnapshotSchema = z.object({
+  name: z.string(),
+  creationTimestamp: z.string().nullish(),
+  storageBytes: z.string().nullish(),
+  sourceDisk: z.string().nullish(),
+  status: z.string().nullish(),
+  storageLocations: z.array(z.string()).nullish(),
+});
+
+const ResourcePolicySchema = z.object({
+  name: z.string(),
+  region: z.string(),
+  snapshotSchedulePolicy: z
+    .object({
+      schedule: z
+        .object({
+          hourlySchedule: z.object({ hoursInCycle: z.number().nullish() }).nullish(),
+          dailySchedule: z.object({ daysInCycle: z.number().nullish() }).nullish(),
+          weeklySchedule: z
+            .object({ dayOfWeeks: z.array(z.object({ day: z.string() })).nullish() })
+            .nullish(),
+        })
+        .nullish(),
+      retentionPolicy: z.object({ maxRetentionDays: z.number().nullish() }).nullish(),
+    })
+    .nullish(),
+});
+
+const SqlInstanceSchema = z.object({ name: z.string(), region: z.string().nullish() });
+
+const SqlBackupSchema = z.object({
+  id: z.string(),
+  endTime: z.string().nullish(),
+  windowStartTime: z.string().nullish(),
+  status: z.string().nullish(),
+  location: z.string().nullish(),
+});
+
+const FilestoreBackupSchema = z.object({
+  name: z.string(),
+  createTime: z.string().nullish(),
+  capacityGb: z.string().nullish(),
+  storageBytes: z.string().nullish(),
+  sourceInstance: z.string().nullish(),
+  state: z.string().nullish(),
+});
+
+const SUCCESSFUL_STATUSES = ['READY', 'SUCCESSFUL'];
+
+const regionOf = (location: string) => location.replace(/-[a-z]$/, '');
+
+const gigabytes = (bytes: string | null | undefined) =>
+  bytes ? Math.round((Number(bytes) / 1024 ** 3) * 100) / 100 : null;
+
+const ageHoursOf = (createdAt: string | null | undefined, now: Date) =>
+  createdAt ? Math.round(((now.getTime() - Date.parse(createdAt)) / HOUR_MS) * 10) / 10 : null;
+
+const listSnapshots = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  now: Date,
+  filter?: string,
+): Promise<Backup[]> => {
+  const snapshots = z
+    .array(SnapshotSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'compute',
+        'snapshots',
+        'list',
+        `--project=${project}`,
+        ...(filter ? [`--filter=${filter}`] : []),
+        '--format=json(name,creationTimestamp,storageBytes,sourceDisk,status,storageLocations)',
+      ]),
+    );
+  return snapshots.map((snapshot) => ({
+    kind: 'disk-snapshots',
+    project,
+    name: snapshot.name,
+    source: snapshot.sourceDisk ? lastSegment(snapshot.sourceDisk) : null,
+    location: snapshot.storageLocations?.join(',') || null,
+    status: snapshot.status ?? null,
+    createdAt: snapshot.creationTimestamp ?? null,
+    ageHours: ageHoursOf(snapshot.creationTimestamp, now),
+    sizeGb: gigabytes(snapshot.storageBytes),
+  }));
+};
+
+const scheduleOf = (
+  policy: NonNullable<z.infer<typeof ResourcePolicySchema>['snapshotSchedulePolicy']>,
+) => {
+  const schedule = policy.schedule;
+  if (schedule?.hourlySchedule) {
+    return `every ${schedule.hourlySchedule.hoursInCycle ?? 1} hours`;
+  }
+  if (schedule?.dailySchedule) {
+    return `every ${schedule.dailySchedule.daysInCycle ?? 1} days`;
+  }
+  if (schedule?.weeklySchedule) {
+    const days = (schedule.weeklySchedule.dayOfWeeks ?? []).map(({ day }) => day.toLowerCase());
+    return `weekly on ${days.join(', ')}`;
+  }
+  return null;
+};
+
+const listSnapshotSchedules = async (
+  gcloud: GcloudExecutable,
+  project: string,
+): Promise<SnapshotSchedule[]> => {
+  const policies = z
+    .array(ResourcePolicySchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'compute',
+        'resource-policies',
+        'list',
+        `--project=${project}`,
+        '--filter=snapshotSchedulePolicy:*',
+        '--format=json(name,region,snapshotSchedulePolicy)',
+      ]),
+    );
+  return policies.flatMap(({ name, region, snapshotSchedulePolicy }) =>
+    snapshotSchedulePolicy
+      ? [
+          {
+            project,
+            name,
+            region: lastSegment(region),
+            schedule: scheduleOf(snapshotSchedulePolicy),
+            retentionDays: snapshotSchedulePolicy.retentionPolicy?.maxRetentionDays ?? null,
+          },
+        ]
+      : [],
+  );
+};
+
+const listSqlBackups = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  instance: string,
+  now: Date,
+): Promise<Backup[]> => {
+  const backups = z
+    .array(SqlBackupSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'sql',
+        'backups',
+        'list',
+        `--instance=${instance}`,
+        `--project=${project}`,
+        '--format=json(id,endTime,windowStartTime,status,location)',
+      ]),
+    );
+  return backups.map((backup) => {
+    const createdAt = backup.endTime ?? backup.windowStartTime ?? null;
+    return {
+      kind: 'sql-backups',
+      project,
+      name: backup.id,
+      source: instance,
+      location: backup.location ?? null,
+      status: backup.status ?? null,
+      createdAt,
+      ageHours: ageHoursOf(createdAt, now),
+      // Cloud SQL does not report the size of backups.
+      sizeGb: null,
+    };
+  });
+};
+
+const listAllSqlBackups = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  now: Date,
+): Promise<Backup[]> => {
+  const instances = z
+    .array(SqlInstanceSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'sql',
+        'instances',
+        'list',
+        `--project=${project}`,
+        '--format=json(name,region)',
+      ]),
+    );
+  const backups = await Promise.all(
+    instances.map(({ name }) => listSqlBackups(gcloud, project, name, now)),
+  );
+  return backups.flat();
+};
+
+const listFilestoreBackups = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  now: Date,
+  filter?: string,
+): Promise<Backup[]> => {
+  const backups = z
+    .array(FilestoreBackupSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'filestore',
+        'backups',
+        'list',
+        `--project=${project}`,
+        ...(filter ? [`--filter=${filter}`] : []),
+        '--format=json(name,createTime,capacityGb,storageBytes,sourceInstance,state)',
+      ]),
+    );
+  return backups.map((backup) => ({
+    kind: 'filestore-backups',
+    project,
+    name: lastSegment(backup.name),
+    source: backup.sourceInstance ? lastSegment(backup.sourceInstance) : null,
+    // Backup names look like projects/p/locations/us-central1/backups/b.
+    location: backup.name.split('/')[3] ?? null,
+    status: backup.state ?? null,
+    createdAt: backup.createTime ?? null,
+    ageHours: ageHoursOf(backup.createTime, now),
+    sizeGb: gigabytes(backup.storageBytes),
+  }));
+};
+
+/** Lists backups and snapshot schedules, newest backups first. */
+export const listBackups = async (
+  gcloud: GcloudExecutable,
+  projects: string[],
+  kinds: readonly BackupKind[],
+  now = new Date(),
+): Promise<BackupInventory> => {
+  const inventory: BackupInventory = { backups: [], schedules: [], errors: [] };
+  const listers: Record<BackupKind, (project: string) => Promise<void>> = {
+    'disk-snapshots': async (project) => {
+      inventory.backups.push(...(await listSnapshots(gcloud, project, now)));
+    },
+    'snapshot-schedules': async (project) => {
+      inventory.schedules.push(...(await listSnapshotSchedules(gcloud, project)));
+    },
+    'sql-backups': async (project) => {
+      inventory.backups.push(...(await listAllSqlBackups(gcloud, project, now)));
+    },
+    'filestore-backups': async (project) => {
+      inventory.backups.push(...(await listFilestoreBackups(gcloud, project, now)));
+    },
+  };
+  await Promise.all(
+    projects.flatMap((project) =>
+      kinds.map(async (kind) => {
+        try {
+          await listers[kind](project);
+        } catch (e: unknown) {
+          inventory.errors.push({
+            project,
+            kind,
+            message: e instanceof Error ? e.message : String(e),
+          });
+        }
+      }),
+    ),
+  );
+  inventory.backups.sort((a, b) => (b.createdAt ?? '').localeCompare(a.createdAt ?? ''));
+  return inventory;
+};
+
+/** Returns a backup name that is unique to the second, e.g. `db-backup-20250101120000`. */
+export const backupNameOf = (resource: string, now = new Date()) => {
+  const stamp = now.toISOString().replace(/[-:T]/g, '').slice(0, 14);
+  const base = resource
+    .toLowerCase()
+    .replace(/[^a-z0-9-]/g, '-')
+    .slice(0, 63 - '-backup-'.length - stamp.length);
+  return `${base}-backup-${stamp}`;
+};
+
+/** Returns an error message if a backup of the target can not be created. */
+export const validateBackupTarget = (target: BackupTarget): string | undefined => {
+  if (target.kind !== 'sql-instance' && !target.location) {
+    return `Backing up a ${target.kind} requires its location.`;
+  }
+  if (target.kind === 'filestore-instance' && !target.fileShare) {
+    return 'Backing up a Filestore instance requires the file share.';
+  }
+  return undefined;
+};
+
+/** Returns the command that creates an on-demand backup of the target. */
+export const createBackupArgs = (
+  target: BackupTarget,
+  description: string,
+  now = new Date(),
+): string[] => {
+  const location = target.location ?? '';
+  const argsOf: Record<BackupTargetKind, () => string[]> = {
+    disk: () => [
+      'compute',
+      'snapshots',
+      'create',
+      backupNameOf(target.name, now),
+      `--source-disk=${target.name}`,
+      isZone(location) ? `--source-disk-zone=${location}` : `--source-disk-region=${location}`,
+    ],
+    'sql-instance': () => ['sql', 'backups', 'create', `--instance=${target.name}`],
+    'filestore-instance': () => [
+      'filestore',
+      'backups',
+      'create',
+      backupNameOf(target.name, now),
+      `--instance=${target.name}`,
+      `--instance-location=${location}`,
+      `--file-share=${target.fileShare ?? ''}`,
+      `--region=${regionOf(location)}`,
+    ],
+  };
+  return [...argsOf[target.kind](), `--description=${description}`, `--project=${target.project}`];
+};
+
+/** Finds the latest successful backup of a resource and checks that it is recent enough. */
+export const verifyBackupRecency = async (
+  gcloud: GcloudExecutable,
+  target: Omit<BackupTarget, 'fileShare'>,
+  maxAgeHours: number,
+  now = new Date(),
+): Promise<BackupRecency> => {
+  const listers: Record<BackupTargetKind, () => Promise<Backup[]>> = {
+    disk: () => {
+      // Disks in different zones can share a name.
+      const scope = target.location ? `/${target.location}` : '';
+      const filter = `sourceDisk~${scope}/disks/${target.name}$`;
+      return listSnapshots(gcloud, target.project, now, filter);
+    },
+    'sql-instance': () => listSqlBackups(gcloud, target.project, target.name, now),
+    'filestore-instance': () =>
+      listFilestoreBackups(
+        gcloud,
+        target.project,
+        now,
+        `sourceInstance~/instances/${target.name}$`,
+      ),
+  };
+  const backups = (await listers[target.kind]()).filter(({ status }) =>
+    SUCCESSFUL_STATUSES.includes(status ?? ''),
+  );
+  const latestBackup =
+    backups.sort((a, b) => (b.createdAt ?? '').localeCompare(a.createdAt ?? ''))[0] ?? null;
+  const ageHours = latestBackup?.ageHours ?? null;
+  return {
+    target,
+    latestBackup,
+    maxAgeHours,
+    recent: ageHours !== null && ageHours <= maxAgeHours,
+  };
+};
@@ -0,0 +1,231 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from './google_api.js';
+import { decodeRows, estimateQuery, runQuery } from './bigquery.js';
+
+const GIB = 2 ** 30;
+
+const dryRun = (bytes: number, statementType = 'SELECT') => ({
+  statistics: {
+    totalBytesProcessed: String(bytes),
+    query: {
+      statementType,
+      schema: { fields: [{ name: 'name', type: 'STRING', mode: 'NULLABLE' }] },
+      referencedTables: [{ projectId: 'p', datasetId: 'd', tableId: 't' }],
+    },
+  },
+});
+
+const options = {
+  project: 'p',
+  query: 'SELECT name FROM d.t',
+  dryRun: false,
+  maxRows: 100,
+  timeoutMs: 60000,
+};
+
+describe('decodeRows', () => {
+  test('converts values to their types, including nested and repeated fields', () => {
+    const schema = [
+      { name: 'id', type: 'INTEGER', mode: 'NULLABLE' },
+      { name: 'big', type: 'INT64', mode: 'NULLABLE' },
+      { name: 'ratio', type: 'FLOAT', mode: 'NULLABLE' },
+      { name: 'active', type: 'BOOLEAN', mode: 'NULLABLE' },
+      { name: 'at', type: 'TIMESTAMP', mode: 'NULLABLE' },
+      { name: 'tags', type: 'STRING', mode: 'REPEATED' },
+      {
+        name: 'owner',
+        type: 'RECORD',
+        mode: 'NULLABLE',
+        fields: [{ name: 'email', type: 'STRING', mode: 'NULLABLE' }],
+      },
+      { name: 'missing', type: 'STRING', mode: 'NULLABLE' },
+    ];
+
+    const rows = decodeRows(schema, [
+      {
+        f: [
+          { v: '42' },
+          { v: '9007199254740993' },
+          { v: '0.5' },
+          { v: 'true' },
+          { v: '1.7356896E9' },
+          { v: [{ v: 'a' }, { v: 'b' }] },
+          { v: { f: [{ v: 'me@example.com' }] } },
+          { v: null },
+        ],
+      },
+    ]);
+
+    expect(rows).toEqual([
+      {
+        id: 42,
+        big: '9007199254740993',
+        ratio: 0.5,
+        active: true,
+        at: '2025-01-01T00:00:00.000Z',
+        tags: ['a', 'b'],
+        owner: { email: 'me@example.com' },
+        missing: null,
+      },
+    ]);
+  });
+});
+
+describe('estimateQuery', () => {
+  test('estimates the on-demand cost of the bytes scanned', async () => {
+    const api: GoogleApiClient = { get: vi.fn(), post: vi.fn().mockResolvedValue(dryRun(2 ** 40)) };
+
+    const estimate = await estimateQuery(api, { pricePerTib: 5 }, options);
+
+    expect(vi.mocked(api.post).mock.calls[0]).toEqual([
+      'https://bigquery.googleapis.com/bigquery/v2/projects/p/jobs',
+      {
+        configuration: {
+          dryRun: true,
+          query: { query: 'SELECT name FROM d.t', useLegacySql: false },
+        },
+      },
+    ]);
+    expect(estimate).toEqual({
+      statementType: 'SELECT',
+      totalBytesProcessed: 2 ** 40,
+      estimatedCostUsd: 5,
+      referencedTables: ['p.d.t'],
+      schema: [{ name: 'name', type: 'STRING', mode: 'NULLABLE' }],
+    });
+  });
+});
+
+describe('runQuery', () => {
+  let api: GoogleApiClient;
+
+  beforeEach(() => {
+    api = { get: vi.fn(), post: vi.fn() };
+  });
+
+  test('only estimates a dry run', async () => {
+    vi.mocked(api.post).mockResolvedValue(dryRun(GIB));
+
+    const result = await runQuery(api, {}, { ...options, dryRun: true });
+
+    expect(api.post).toHaveBeenCalledOnce();
+    expect(result).toMatchObject({
+      dryRun: true,
+      estimate: { totalBytesProcessed: GIB },
+      maxBytesBilled: 10 * GIB,
+      schema: [{ name: 'name' }],
+    });
+    expect(result.rows).toBeUndefined();
+  });
+
+  test('runs the query with the bytes billed capped', async () => {
+    vi.mocked(api.post)
+      .mockResolvedValueOnce(dryRun(GIB))
+      .mockResolvedValueOnce({
+        jobComplete: true,
+        jobReference: { jobId: 'job-1', location: 'US' },
+        schema: { fields: [{ name: 'name', type: 'STRING' }] },
+        rows: [{ f: [{ v: 'a' }] }, { f: [{ v: 'b' }] }],
+        totalRows: '5',
+        totalBytesBilled: String(GIB),
+        cacheHit: false,
+      });
+
+    const result = await runQuery(api, { maxBytesBilled: 5 * GIB }, options);
+
+    expect(vi.mocked(api.post).mock.calls[1]).toEqual([
+      'https://bigquery.googleapis.com/bigquery/v2/projects/p/queries',
+      {
+        query: 'SELECT name FROM d.t',
+        useLegacySql: false,
+        maximumBytesBilled: String(5 * GIB),
+        maxResults: 100,
+        timeoutMs: 60000,
+      },
+    ]);
+    expect(result).toMatchObject({
+      schema: [{ name: 'name', type: 'STRING', mode: 'NULLABLE' }],
+      rows: [{ name: 'a' }, { name: 'b' }],
+      totalRows: 5,
+      truncated: true,
+      totalBytesBilled: GIB,
+      jobId: 'job-1',
+    });
+  });
+
+  test('does not raise the configured cap', async () => {
+    vi.mocked(api.post).mockResolvedValue(dryRun(GIB));
+
+    const result = await runQuery(
+      api,
+      { maxBytesBilled: GIB },
+      { ...options, dryRun: true, maxBytesBilled: 100 * GIB },
+    );
+
+    expect(result.maxBytesBilled).toBe(GIB);
+  });
+
+  test('does not run a query estimated to exceed the cap', async () => {
+    vi.mocked(api.post).mockResolvedValue(dryRun(20 * GIB));
+
+    await expect(runQuery(api, {}, options)).rejects.toThrow('more than the cap');
+    expect(api.post).toHaveBeenCalledOnce();
+  });
+
+  test('only runs SELECT statements when read-only', async () => {
+    vi.mocked(api.post).mockResolvedValue(dryRun(0, 'DELETE'));
+
+    await expect(runQuery(api, {}, { ...options, readOnly: true })).rejects.toThrow(
+      'only runs SELECT statements, not DELETE',
+    );
+    expect(api.post).toHaveBeenCalledOnce();
+  });
+
+  test('does not run statements that are not approved', async () => {
+    vi.mocked(api.post).mockResolvedValue(dryRun(0, 'DELETE'));
+    const approve = vi.fn().mockResolvedValue('The user declined to run this statement.');
+
+    await expect(runQuery(api, {}, { ...options, approve })).rejects.toThrow(
+      'The user declined to run this statement.',
+    );
+    expect(approve).toHaveBeenCalledWith('DELETE');
+    expect(api.post).toHaveBeenCalledOnce();
+  });
+
+  test('waits for queries that outlast the first request', async () => {
+    vi.mocked(api.post)
+      .mockResolvedValueOnce(dryRun(GIB))
+      .mockResolvedValueOnce({ jobComplete: false, jobReference: { jobId: 'job-1' } });
+    vi.mocked(api.get).mockResolvedValue({
+      jobComplete: true,
+      jobReference: { jobId: 'job-1' },
+      schema: { fields: [{ name: 'name', type: 'STRING' }] },
+      rows: [{ f: [{ v: 'a' }] }],
+      totalRows: '1',
+    });
+
+    const result = await runQuery(api, {}, options);
+
+    expect(vi.mocked(api.get).mock.calls[0]![0]).toMatch(
+      /^https:\/\/bigquery.googleapis.com\/bigquery\/v2\/projects\/p\/queries\/job-1\?maxResults=100&timeoutMs=\d+$/,
+    );
+    expect(result.rows).toEqual([{ name: 'a' }]);
+    expect(result.truncated).toBe(false);
+  });
+});
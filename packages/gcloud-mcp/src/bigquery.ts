@@ -0,0 +1,295 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GoogleApiClient } from './google_api.js';
+
+const BIGQUERY_URL = 'https://bigquery.googleapis.com/bigquery/v2';
+const TIB = 2 ** 40;
+
+export const DEFAULT_MAX_BYTES_BILLED = 10 * 2 ** 30;
+// The on-demand list price in US multi-region, as of 2025.
+export const DEFAULT_PRICE_PER_TIB = 6.25;
+
+export interface BigQueryConfig {
+  /** Queries estimated to scan more are not run, and BigQuery fails any that would bill more. */
+  maxBytesBilled?: number;
+  /** The on-demand price per TiB scanned in USD, used to estimate the cost of queries. */
+  pricePerTib?: number;
+}
+
+export interface QueryOptions {
+  project: string;
+  query: string;
+  /** Only estimate the query without running it. */
+  dryRun: boolean;
+  /** Lowers the configured cap for this query. */
+  maxBytesBilled?: number;
+  /** The maximum number of rows to return. */
+  maxRows: number;
+  /** Rejects statements other than SELECT, e.g. DML and DDL. */
+  readOnly?: boolean;
+  /**
+   * Approves a statement before it runs, e.g. by asking the user. Returns why
+   * it may not run, if it may not.
+   */
+  approve?: (statementType: string | null) => Promise<string | undefined>;
+  location?: string;
+  timeoutMs: number;
+}
+
+export interface FieldSchema {
+  name: string;
+  type: string;
+  mode: string;
+  fields?: FieldSchema[];
+}
+
+export interface QueryEstimate {
+  statementType: string | null;
+  totalBytesProcessed: number;
+  estimatedCostUsd: number;
+  referencedTables: string[];
+}
+
+export interface QueryResult {
+  project: string;
+  dryRun: boolean;
+  estimate: QueryEstimate;
+  maxBytesBilled: number;
+  schema: FieldSchema[];
+  rows?: Array<Record<string, unknown>>;
+  totalRows?: number;
+  /** Set if there are more rows than were returned. */
+  truncated?: boolean;
+  totalBytesBilled?: number | null;
+  cacheHit?: boolean;
+  jobId?: string;
+}
+
+/** Returns an error message if the BigQuery configuration is invalid. */
+export const validateBigQueryConfig = (config: BigQueryConfig): string | undefined => {
+  if (config.maxBytesBilled !== undefined && !(config.maxBytesBilled > 0)) {
+    return '"bigquery.maxBytesBilled" must be a positive number of bytes.';
+  }
+  if (config.pricePerTib !== undefined && !(config.pricePerTib >= 0)) {
+    return '"bigquery.pricePerTib" must not be negative.';
+  }
+  return undefined;
+};
+
+type RawField = {
+  name: string;
+  type: string;
+  mode?: string | undefined;
+  fields?: RawField[] | undefined;
+};
+
+const RawFieldSchema: z.ZodType<RawField> = z.lazy(() =>
+  z.object({
+    name: z.string(),
+    type: z.string(),
+    mode: z.string().optional(),
+    fields: z.array(RawFieldSchema).optional(),
+  }),
+);
+
+const TableSchema = z.object({ fields: z.array(RawFieldSchema).default([]) }).optional();
+
+const DryRunResponseSchema = z.object({
+  statistics: z.object({
+    totalBytesProcessed: z.string().optional(),
+    query: z
+      .object({
+        statementType: z.string().optional(),
+        schema: TableSchema,
+        referencedTables: z
+          .array(z.object({ projectId: z.string(), datasetId: z.string(), tableId: z.string() }))
+          .optional(),
+      })
+      .optional(),
+  }),
+});
+
+type Cell = { v?: unknown };
+
+const QueryResponseSchema = z.object({
+  jobComplete: z.boolean(),
+  jobReference: z.object({ jobId: z.string(), location: z.string().optional() }),
+  schema: TableSchema,
+  rows: z.array(z.object({ f: z.array(z.object({ v: z.unknown() })) })).default([]),
+  totalRows: z.string().optional(),
+  totalBytesBilled: z.string().optional(),
+  cacheHit: z.boolean().optional(),
+});
+
+const simplifySchema = (fields: RawField[]): FieldSchema[] =>
+  fields.map((field) => ({
+    name: field.name,
+    type: field.type,
+    mode: field.mode ?? 'NULLABLE',
+    ...(field.fields && { fields: simplifySchema(field.fields) }),
+  }));
+
+const decodeScalar = (type: string, value: string): unknown => {
+  switch (type) {
+    case 'INTEGER':
+    case 'INT64': {
+      // Larger integers would lose precision as numbers.
+      const number = Number(value);
+      return Number.isSafeInteger(number) ? number : value;
+    }
+    case 'FLOAT':
+    case 'FLOAT64':
+      return Number(value);
+    case 'BOOLEAN':
+    case 'BOOL':
+      return value === 'true';
+    case 'TIMESTAMP':
+      // Timestamps are returned as seconds since the epoch.
+      return new Date(Number(value) * 1000).toISOString();
+    default:
+      return value;
+  }
+};
+
+const decodeValue = (field: FieldSchema, value: unknown): unknown => {
+  if (value === null || value === undefined) {
+    return null;
+  }
+  if (field.mode === 'REPEATED') {
+    return (value as Cell[]).map((item) => decodeValue({ ...field, mode: 'NULLABLE' }, item.v));
+  }
+  if (field.fields) {
+    return decodeRow(field.fields, (value as { f: Cell[] }).f);
+  }
+  return decodeScalar(field.type, String(value));
+};
+
+const decodeRow = (fields: FieldSchema[], cells: Cell[]): Record<string, unknown> =>
+  Object.fromEntries(fields.map((field, i) => [field.name, decodeValue(field, cells[i]?.v)]));
+
+/** Converts rows in the `f`/`v` format of the BigQuery API into objects keyed by column. */
+export const decodeRows = (schema: FieldSchema[], rows: Array<{ f: Cell[] }>) =>
+  rows.map((row) => decodeRow(schema, row.f));
+
+const round = (value: number) => Math.round(value * 10000) / 10000;
+
+/** Returns the bytes a query would scan and what that costs on demand, without running it. */
+export const estimateQuery = async (
+  api: GoogleApiClient,
+  config: BigQueryConfig,
+  options: Pick<QueryOptions, 'project' | 'query' | 'location'>,
+): Promise<QueryEstimate & { schema: FieldSchema[] }> => {
+  const response = DryRunResponseSchema.parse(
+    await api.post(`${BIGQUERY_URL}/projects/${options.project}/jobs`, {
+      configuration: { dryRun: true, query: { query: options.query, useLegacySql: false } },
+      ...(options.location && { jobReference: { location: options.location } }),
+    }),
+  );
+  const bytes = Number(response.statistics.totalBytesProcessed ?? 0);
+  const pricePerTib = config.pricePerTib ?? DEFAULT_PRICE_PER_TIB;
+  return {
+    statementType: response.statistics.query?.statementType ?? null,
+    totalBytesProcessed: bytes,
+    estimatedCostUsd: round((bytes / TIB) * pricePerTib),
+    referencedTables: (response.statistics.query?.referencedTables ?? []).map(
+      (table) => `${table.projectId}.${table.datasetId}.${table.tableId}`,
+    ),
+    schema: simplifySchema(response.statistics.query?.schema?.fields ?? []),
+  };
+};
+
+/**
+ * Estimates a Standard SQL query with a dry run and, unless only the estimate
+ * is asked for, runs it with the bytes billed capped.
+ *
+ * The query is not run if the estimate exceeds the cap, so no bytes are billed
+ * for it. BigQuery enforces the cap as well, as the estimate can be low.
+ */
+export const runQuery = async (
+  api: GoogleApiClient,
+  config: BigQueryConfig,
+  options: QueryOptions,
+): Promise<QueryResult> => {
+  const configuredCap = config.maxBytesBilled ?? DEFAULT_MAX_BYTES_BILLED;
+  const maxBytesBilled = Math.min(options.maxBytesBilled ?? configuredCap, configuredCap);
+  const { schema, ...estimate } = await estimateQuery(api, config, options);
+
+  if (options.readOnly && estimate.statementType !== 'SELECT') {
+    throw new Error(
+      `The server is read-only and only runs SELECT statements, not ${estimate.statementType ?? 'this statement'}.`,
+    );
+  }
+  const result = { project: options.project, dryRun: options.dryRun, estimate, maxBytesBilled };
+  if (options.dryRun) {
+    return { ...result, schema };
+  }
+  if (estimate.totalBytesProcessed > maxBytesBilled) {
+    throw new Error(
+      `The query would scan ${estimate.totalBytesProcessed} bytes (about $${estimate.estimatedCostUsd}), more than the cap of ${maxBytesBilled} bytes. Narrow it down, e.g. by filtering on partition columns or selecting fewer columns.`,
+    );
+  }
+  const rejection = await options.approve?.(estimate.statementType);
+  if (rejection) {
+    throw new Error(rejection);
+  }
+
+  const deadline = Date.now() + options.timeoutMs;
+  let response = QueryResponseSchema.parse(
+    await api.post(`${BIGQUERY_URL}/projects/${options.project}/queries`, {
+      query: options.query,
+      useLegacySql: false,
+      maximumBytesBilled: String(maxBytesBilled),
+      maxResults: options.maxRows,
+      timeoutMs: options.timeoutMs,
+      ...(options.location && { location: options.location }),
+    }),
+  );
+  // Queries that take longer than timeoutMs keep running as jobs.
+  while (!response.jobComplete) {
+    const remainingMs = deadline - Date.now();
+    if (remainingMs <= 0) {
+      throw new Error(
+        `The query did not complete within ${options.timeoutMs / 1000} seconds and is still running as job ${response.jobReference.jobId}.`,
+      );
+    }
+    const { jobId, location } = response.jobReference;
+    const params = new URLSearchParams({
+      maxResults: String(options.maxRows),
+      timeoutMs: String(remainingMs),
+      ...(location && { location }),
+    });
+    response = QueryResponseSchema.parse(
+      await api.get(`${BIGQUERY_URL}/projects/${options.project}/queries/${jobId}?${params}`),
+    );
+  }
+
+  const resultSchema = simplifySchema(response.schema?.fields ?? []);
+  const rows = decodeRows(resultSchema, response.rows);
+  const totalRows = Number(response.totalRows ?? rows.length);
+  return {
+    ...result,
+    schema: resultSchema,
+    rows,
+    totalRows,
+    truncated: totalRows > rows.length,
+    totalBytesBilled:
+      response.totalBytesBilled !== undefined ? Number(response.totalBytesBilled) : null,
+    cacheHit: response.cacheHit ?? false,
+    jobId: response.jobReference.jobId,
+  };
+};
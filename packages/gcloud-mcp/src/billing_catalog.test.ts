@@ -0,0 +1,91 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from './google_api.js';
+import { createBillingCatalog, unitPriceOf } from './billing_catalog.js';
+
+const sku = (skuId: string) => ({
+  skuId,
+  description: `SKU ${skuId}`,
+  category: { resourceFamily: 'Compute', usageType: 'OnDemand' },
+  serviceRegions: ['us-central1'],
+  pricingInfo: [
+    {
+      pricingExpression: {
+        usageUnit: 'h',
+        tieredRates: [
+          { startUsageAmount: 0, unitPrice: { currencyCode: 'USD', units: '0', nanos: 0 } },
+          {
+            startUsageAmount: 10,
+            unitPrice: { currencyCode: 'USD', units: '1', nanos: 500000000 },
+          },
+        ],
+      },
+    },
+  ],
+});
+
+describe('unitPriceOf', () => {
+  test('uses the rate of the last tier', () => {
+    expect(unitPriceOf(sku('A'))).toEqual({ price: 1.5, unit: 'h' });
+  });
+});
+
+describe('createBillingCatalog', () => {
+  let api: GoogleApiClient;
+
+  beforeEach(() => {
+    api = { get: vi.fn(), post: vi.fn() };
+  });
+
+  test('follows page tokens', async () => {
+    vi.mocked(api.get)
+      .mockResolvedValueOnce({ skus: [sku('A')], nextPageToken: 'next' })
+      .mockResolvedValueOnce({ skus: [sku('B')] });
+    const catalog = createBillingCatalog(api);
+
+    const skus = await catalog.skus('6F81-5844-456A');
+
+    expect(skus.map((s) => s.skuId)).toEqual(['A', 'B']);
+    expect(api.get).toHaveBeenCalledTimes(2);
+    expect(vi.mocked(api.get).mock.calls[0]![0]).toBe(
+      'https://cloudbilling.googleapis.com/v1/services/6F81-5844-456A/skus?currencyCode=USD&pageSize=5000',
+    );
+    expect(vi.mocked(api.get).mock.calls[1]![0]).toContain('pageToken=next');
+  });
+
+  test('caches the SKUs of a service', async () => {
+    vi.mocked(api.get).mockResolvedValue({ skus: [sku('A')] });
+    const catalog = createBillingCatalog(api);
+
+    await catalog.skus('6F81-5844-456A');
+    await catalog.skus('6F81-5844-456A');
+
+    expect(api.get).toHaveBeenCalledOnce();
+    expect(catalog.cachedServices()).toEqual(['6F81-5844-456A']);
+  });
+
+  test('does not cache failed requests', async () => {
+    vi.mocked(api.get)
+      .mockRejectedValueOnce(new Error('Request failed with 503'))
+      .mockResolvedValueOnce({ skus: [sku('A')] });
+    const catalog = createBillingCatalog(api);
+
+    await expect(catalog.skus('6F81-5844-456A')).rejects.toThrow('failed with 503');
+    await expect(catalog.skus('6F81-5844-456A')).resolves.toHaveLength(1);
+  });
+});
@@ -0,0 +1,132 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GoogleApiClient } from './google_api.js';
+
+const CATALOG_URL = 'https://cloudbilling.googleapis.com/v1';
+
+// Service IDs in the Cloud Billing Catalog, see `gcloud billing services list`
+// or https://cloud.google.com/skus.
+export const CATALOG_SERVICES = {
+  compute: '6F81-5844-456A',
+  gke: 'CCD8-9BF1-090E',
+  cloudSql: '9662-B51E-5089',
+} as const;
+
+// There are more fields in each SKU, but only these are used.
+const SkuSchema = z.object({
+  skuId: z.string(),
+  description: z.string(),
+  category: z.object({
+    resourceFamily: z.string().nullish(),
+    resourceGroup: z.string().nullish(),
+    usageType: z.string().nullish(),
+  }),
+  serviceRegions: z.array(z.string()).default([]),
+  pricingInfo: z
+    .array(
+      z.object({
+        pricingExpression: z.object({
+          usageUnit: z.string(),
+          tieredRates: z
+            .array(
+              z.object({
+                startUsageAmount: z.number().default(0),
+                unitPrice: z.object({
+                  currencyCode: z.string(),
+                  units: z.string().default('0'),
+                  nanos: z.number().default(0),
+                }),
+              }),
+            )
+            .default([]),
+        }),
+      }),
+    )
+    .default([]),
+});
+
+const SkuPageSchema = z.object({
+  skus: z.array(SkuSchema).default([]),
+  nextPageToken: z.string().nullish(),
+});
+
+export type Sku = z.infer<typeof SkuSchema>;
+
+export interface BillingCatalog {
+  skus: (serviceId: string) => Promise<Sku[]>;
+}
+
+export interface CachedBillingCatalog extends BillingCatalog {
+  /** Returns the services whose SKUs are cached or being fetched. */
+  cachedServices: () => string[];
+}
+
+/**
+ * Returns the price per usage unit of a SKU, e.g. per hour for `h` or per GiB
+ * and month for `GiBy.mo`.
+ *
+ * Tiered SKUs use the rate of the last tier, which applies to sustained usage
+ * beyond any free or discounted allowance.
+ */
+export const unitPriceOf = (sku: Sku): { price: number; unit: string } | undefined => {
+  const expression = sku.pricingInfo[0]?.pricingExpression;
+  const rate = expression?.tieredRates.at(-1);
+  if (!expression || !rate) {
+    return undefined;
+  }
+  return {
+    price: Number(rate.unitPrice.units) + rate.unitPrice.nanos / 1e9,
+    unit: expression.usageUnit,
+  };
+};
+
+/** Creates a client for the Cloud Billing Catalog API. SKUs are cached for the session. */
+export const createBillingCatalog = (api: GoogleApiClient): CachedBillingCatalog => {
+  const cache = new Map<string, Promise<Sku[]>>();
+
+  const listSkus = async (serviceId: string): Promise<Sku[]> => {
+    const skus: Sku[] = [];
+    let pageToken: string | undefined;
+    do {
+      const params = new URLSearchParams({ currencyCode: 'USD', pageSize: '5000' });
+      if (pageToken) {
+        params.set('pageToken', pageToken);
+      }
+      const page = SkuPageSchema.parse(
+        await api.get(`${CATALOG_URL}/services/${serviceId}/skus?${params}`),
+      );
+      skus.push(...page.skus);
+      pageToken = page.nextPageToken || undefined;
+    } while (pageToken);
+    return skus;
+  };
+
+  return {
+    skus: (serviceId) => {
+      let skus = cache.get(serviceId);
+      if (!skus) {
+        skus = listSkus(serviceId);
+        // Failed requests are retried on the next call instead of being cached.
+        skus.catch(() => cache.delete(serviceId));
+        cache.set(serviceId, skus);
+      }
+      return skus;
+    },
+    cachedServices: () => [...cache.keys()],
+  };
+};
@@ -0,0 +1,161 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from './google_api.js';
+import { costBreakdownQuery, getCostBreakdown, getMonthToDateSpend } from './billing_export.js';
+
+const TABLE = 'billing-project.billing.gcp_billing_export_v1_0000';
+
+const row = (...values: Array<string | null>) => ({ f: values.map((v) => ({ v })) });
+
+describe('costBreakdownQuery', () => {
+  test('filters projects only when requested', () => {
+    expect(costBreakdownQuery(TABLE, 'service', false)).not.toContain('@projects');
+    expect(costBreakdownQuery(TABLE, 'service', true)).toContain(
+      'AND project.id IN UNNEST(@projects)',
+    );
+  });
+
+  test('groups labels by the label_key parameter', () => {
+    expect(costBreakdownQuery(TABLE, 'label', false)).toContain(
+      '(SELECT value FROM UNNEST(labels) WHERE key = @label_key) AS key',
+    );
+  });
+});
+
+describe('getCostBreakdown', () => {
+  let api: GoogleApiClient;
+
+  beforeEach(() => {
+    api = { get: vi.fn(), post: vi.fn() };
+  });
+
+  test('compares the current and previous periods', async () => {
+    vi.mocked(api.post).mockResolvedValue({
+      jobComplete: true,
+      rows: [
+        row('Cloud Storage', 'USD', '10.5', '10'),
+        row('Compute Engine', 'USD', '150', '100'),
+        row('BigQuery', 'USD', '0', '20'),
+        row('Cloud Run', 'USD', '5', '0'),
+      ],
+    });
+
+    const breakdown = await getCostBreakdown(
+      api,
+      { table: TABLE },
+      { groupBy: 'service', days: 7, end: new Date('2025-01-15T00:00:00Z'), limit: 3 },
+    );
+
+    const [url, body] = vi.mocked(api.post).mock.calls[0]!;
+    expect(url).toBe(
+      'https://bigquery.googleapis.com/bigquery/v2/projects/billing-project/queries',
+    );
+    expect(body).toMatchObject({
+      useLegacySql: false,
+      queryParameters: [
+        { name: 'previous_start', parameterValue: { value: '2025-01-01T00:00:00.000Z' } },
+        { name: 'current_start', parameterValue: { value: '2025-01-08T00:00:00.000Z' } },
+        { name: 'end', parameterValue: { value: '2025-01-15T00:00:00.000Z' } },
+      ],
+    });
+    expect(breakdown.currency).toBe('USD');
+    expect(breakdown.total).toEqual({
+      key: null,
+      current: 165.5,
+      previous: 130,
+      delta: 35.5,
+      deltaPercent: 27.31,
+    });
+    expect(breakdown.groups).toEqual([
+      { key: 'Compute Engine', current: 150, previous: 100, delta: 50, deltaPercent: 50 },
+      { key: 'BigQuery', current: 0, previous: 20, delta: -20, deltaPercent: -100 },
+      { key: 'Cloud Run', current: 5, previous: 0, delta: 5, deltaPercent: null },
+    ]);
+  });
+
+  test('passes the label key and projects as parameters', async () => {
+    vi.mocked(api.post).mockResolvedValue({ jobComplete: true });
+
+    const breakdown = await getCostBreakdown(
+      api,
+      { table: TABLE, queryProject: 'finops' },
+      { groupBy: 'label', labelKey: 'team', days: 1, projects: ['p1'], limit: 10 },
+    );
+
+    const [url, body] = vi.mocked(api.post).mock.calls[0]!;
+    expect(url).toContain('/projects/finops/queries');
+    expect((body as { queryParameters: unknown[] }).queryParameters).toEqual(
+      expect.arrayContaining([
+        expect.objectContaining({ name: 'label_key', parameterValue: { value: 'team' } }),
+        expect.objectContaining({
+          name: 'projects',
+          parameterValue: { arrayValues: [{ value: 'p1' }] },
+        }),
+      ]),
+    );
+    expect(breakdown.groupBy).toBe('label:team');
+    expect(breakdown.groups).toEqual([]);
+  });
+
+  test('requires a label key to group by label', async () => {
+    await expect(
+      getCostBreakdown(api, { table: TABLE }, { groupBy: 'label', days: 7, limit: 10 }),
+    ).rejects.toThrow('Set labelKey');
+    expect(api.post).not.toHaveBeenCalled();
+  });
+
+  test('fails if the query does not complete', async () => {
+    vi.mocked(api.post).mockResolvedValue({ jobComplete: false });
+
+    await expect(
+      getCostBreakdown(api, { table: TABLE }, { groupBy: 'project', days: 7, limit: 10 }),
+    ).rejects.toThrow('did not complete');
+  });
+});
+
+describe('getMonthToDateSpend', () => {
+  let api: GoogleApiClient;
+
+  beforeEach(() => {
+    api = { get: vi.fn(), post: vi.fn() };
+  });
+
+  test('sums the invoice month for the given projects and services', async () => {
+    vi.mocked(api.post).mockResolvedValue({ jobComplete: true, rows: [row('42.123', 'EUR')] });
+
+    const spend = await getMonthToDateSpend(
+      api,
+      { table: TABLE },
+      { projectNumbers: ['123'], services: ['6F81-5844-456A'] },
+      new Date('2025-03-10T12:00:00Z'),
+    );
+
+    expect(spend).toEqual({ spend: 42.12, currency: 'EUR' });
+    const { query, queryParameters } = vi.mocked(api.post).mock.calls[0]![1] as {
+      query: string;
+      queryParameters: unknown[];
+    };
+    expect(query).toContain(
+      'WHERE invoice.month = @invoice_month\n  AND project.number IN UNNEST(@project_numbers)\n  AND service.id IN UNNEST(@services)',
+    );
+    expect(queryParameters[0]).toMatchObject({
+      name: 'invoice_month',
+      parameterValue: { value: '202503' },
+    });
+  });
+});
@@ -0,0 +1,250 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GoogleApiClient } from './google_api.js';
+
+const BIGQUERY_URL = 'https://bigquery.googleapis.com/bigquery/v2';
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+export interface BillingExportConfig {
+  /** The billing export table, e.g. `my-project.billing.gcp_billing_export_v1_XXXXXX`. */
+  table: string;
+  /** The project queries run and are billed in. Defaults to the project of the table. */
+  queryProject?: string;
+  /** The dataset GKE usage metering exports to, e.g. `my-project.gke_usage`. */
+  gkeUsageMeteringDataset?: string;
+}
+
+export type CostDimension = 'project' | 'service' | 'sku' | 'label';
+
+export interface CostBreakdownOptions {
+  groupBy: CostDimension;
+  /** The label to group by when `groupBy` is `label`. */
+  labelKey?: string;
+  /** The length of the period in days. */
+  days: number;
+  /** The exclusive end of the current period. Defaults to now. */
+  end?: Date;
+  projects?: string[];
+  limit: number;
+}
+
+export interface CostChange {
+  key: string | null;
+  current: number;
+  previous: number;
+  delta: number;
+  /** The change relative to the previous period, or null if nothing was spent then. */
+  deltaPercent: number | null;
+}
+
+export interface CostBreakdown {
+  table: string;
+  groupBy: string;
+  currency: string | null;
+  periods: {
+    current: { start: string; end: string };
+    previous: { start: string; end: string };
+  };
+  total: CostChange;
+  /** The groups with the largest changes first. */
+  groups: CostChange[];
+}
+
+// Project, dataset and table IDs. The table can not be passed as a query
+// parameter, so anything else is rejected to keep it out of the SQL.
+const TABLE_PATTERN = /^[a-z][a-z0-9-]*(:[a-z][a-z0-9-]*)?\.\w+\.\w+$/i;
+const DATASET_PATTERN = /^[a-z][a-z0-9-]*(:[a-z][a-z0-9-]*)?\.\w+$/i;
+
+const DIMENSION_EXPRESSIONS: Record<CostDimension, string> = {
+  project: 'project.id',
+  service: 'service.description',
+  sku: "CONCAT(service.description, ': ', sku.description)",
+  label: '(SELECT value FROM UNNEST(labels) WHERE key = @label_key)',
+};
+
+/** Returns an error message if the billing export configuration is invalid. */
+export const validateBillingExport = (config: BillingExportConfig): string | undefined => {
+  if (!TABLE_PATTERN.test(config.table)) {
+    return `Invalid billing export table "${config.table}". Use the form PROJECT.DATASET.TABLE.`;
+  }
+  const dataset = config.gkeUsageMeteringDataset;
+  if (dataset !== undefined && !DATASET_PATTERN.test(dataset)) {
+    return `Invalid GKE usage metering dataset "${dataset}". Use the form PROJECT.DATASET.`;
+  }
+  return undefined;
+};
+
+/** Returns the Standard SQL comparing net cost, after credits, across two periods. */
+export const costBreakdownQuery = (
+  table: string,
+  groupBy: CostDimension,
+  filterProjects: boolean,
+): string => {
+  const projectFilter = filterProjects ? '\n    AND project.id IN UNNEST(@projects)' : '';
+  return `SELECT
+  ${DIMENSION_EXPRESSIONS[groupBy]} AS key,
+  ANY_VALUE(currency) AS currency,
+  SUM(IF(usage_start_time >= @current_start, net_cost, 0)) AS current_cost,
+  SUM(IF(usage_start_time < @current_start, net_cost, 0)) AS previous_cost
+FROM (
+  SELECT *, cost + IFNULL((SELECT SUM(c.amount) FROM UNNEST(credits) c), 0) AS net_cost
+  FROM \`${table}\`
+  WHERE usage_start_time >= @previous_start AND usage_start_time < @end${projectFilter}
+)
+GROUP BY key`;
+};
+
+const QueryResponseSchema = z.object({
+  jobComplete: z.boolean(),
+  rows: z.array(z.object({ f: z.array(z.object({ v: z.string().nullable() })) })).default([]),
+});
+
+const round = (value: number) => Math.round(value * 100) / 100;
+
+const change = (key: string | null, current: number, previous: number): CostChange => ({
+  key,
+  current: round(current),
+  previous: round(previous),
+  delta: round(current - previous),
+  deltaPercent: previous !== 0 ? round(((current - previous) / Math.abs(previous)) * 100) : null,
+});
+
+export const timestampParameter = (name: string, date: Date) => ({
+  name,
+  parameterType: { type: 'TIMESTAMP' },
+  parameterValue: { value: date.toISOString() },
+});
+
+export const stringParameter = (name: string, value: string | undefined) => ({
+  name,
+  parameterType: { type: 'STRING' },
+  parameterValue: { value },
+});
+
+export const stringArrayParameter = (name: string, values: string[]) => ({
+  name,
+  parameterType: { type: 'ARRAY', arrayType: { type: 'STRING' } },
+  parameterValue: { arrayValues: values.map((value) => ({ value })) },
+});
+
+/** Runs a query with named parameters and returns the values of each row. */
+export const queryBillingExport = async (
+  api: GoogleApiClient,
+  config: BillingExportConfig,
+  query: string,
+  queryParameters: unknown[],
+) => {
+  const queryProject = config.queryProject ?? config.table.split('.')[0]?.split(':')[0];
+  const response = QueryResponseSchema.parse(
+    await api.post(`${BIGQUERY_URL}/projects/${queryProject}/queries`, {
+      query,
+      useLegacySql: false,
+      timeoutMs: 60000,
+      parameterMode: 'NAMED',
+      queryParameters,
+    }),
+  );
+  if (!response.jobComplete) {
+    throw new Error('The billing export query did not complete within 60 seconds.');
+  }
+  return response.rows.map((row) => row.f.map((field) => field.v));
+};
+
+/** Compares spend in the billing export over two consecutive periods of the same length. */
+export const getCostBreakdown = async (
+  api: GoogleApiClient,
+  config: BillingExportConfig,
+  options: CostBreakdownOptions,
+): Promise<CostBreakdown> => {
+  if (options.groupBy === 'label' && !options.labelKey) {
+    throw new Error('Set labelKey to group costs by label.');
+  }
+  const end = options.end ?? new Date();
+  const currentStart = new Date(end.getTime() - options.days * DAY_MS);
+  const previousStart = new Date(currentStart.getTime() - options.days * DAY_MS);
+  const filterProjects = (options.projects?.length ?? 0) > 0;
+
+  const result = await queryBillingExport(
+    api,
+    config,
+    costBreakdownQuery(config.table, options.groupBy, filterProjects),
+    [
+      timestampParameter('previous_start', previousStart),
+      timestampParameter('current_start', currentStart),
+      timestampParameter('end', end),
+      ...(options.groupBy === 'label' ? [stringParameter('label_key', options.labelKey)] : []),
+      ...(filterProjects ? [stringArrayParameter('projects', options.projects ?? [])] : []),
+    ],
+  );
+  const rows = result.map(([key, currency, current, previous]) => ({
+    key: key ?? null,
+    currency: currency ?? null,
+    current: Number(current ?? 0),
+    previous: Number(previous ?? 0),
+  }));
+  const groups = rows
+    .map((row) => change(row.key, row.current, row.previous))
+    .sort((a, b) => Math.abs(b.delta) - Math.abs(a.delta));
+  return {
+    table: config.table,
+    groupBy: options.groupBy === 'label' ? `label:${options.labelKey}` : options.groupBy,
+    currency: rows.find((row) => row.currency)?.currency ?? null,
+    periods: {
+      current: { start: currentStart.toISOString(), end: end.toISOString() },
+      previous: { start: previousStart.toISOString(), end: currentStart.toISOString() },
+    },
+    total: change(
+      null,
+      rows.reduce((sum, row) => sum + row.current, 0),
+      rows.reduce((sum, row) => sum + row.previous, 0),
+    ),
+    groups: groups.slice(0, options.limit),
+  };
+};
+
+/**
+ * Returns the net spend in the current invoice month, optionally limited to
+ * projects (by number) and services (by billing service ID), as budgets are.
+ */
+export const getMonthToDateSpend = async (
+  api: GoogleApiClient,
+  config: BillingExportConfig,
+  filter: { projectNumbers?: string[]; services?: string[] },
+  now: Date = new Date(),
+): Promise<{ spend: number; currency: string | null }> => {
+  // Invoice months are named like 202501.
+  const invoiceMonth = now.toISOString().slice(0, 7).replace('-', '');
+  const projectNumbers = filter.projectNumbers ?? [];
+  const services = filter.services ?? [];
+  const filters = [
+    ...(projectNumbers.length > 0 ? ['project.number IN UNNEST(@project_numbers)'] : []),
+    ...(services.length > 0 ? ['service.id IN UNNEST(@services)'] : []),
+  ];
+  const query = `SELECT
+  SUM(cost + IFNULL((SELECT SUM(c.amount) FROM UNNEST(credits) c), 0)) AS net_cost,
+  ANY_VALUE(currency) AS currency
+FROM \`${config.table}\`
+WHERE ${['invoice.month = @invoice_month', ...filters].join('\n  AND ')}`;
+
+  const [row] = await queryBillingExport(api, config, query, [
+    stringParameter('invoice_month', invoiceMonth),
+    ...(projectNumbers.length > 0 ? [stringArrayParameter('project_numbers', projectNumbers)] : []),
+    ...(services.length > 0 ? [stringArrayParameter('services', services)] : []),
+  ]);
+  return { spend: round(Number(row?.[0] ?? 0)), currency: row?.[1] ?? null };
+};
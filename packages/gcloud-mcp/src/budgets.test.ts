@@ -0,0 +1,168 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import {
+  Budget,
+  budgetStatus,
+  createBudgetArgs,
+  elapsedMonthFraction,
+  listBudgetStatuses,
+} from './budgets.js';
+
+vi.mock('./gcloud.js');
+
+// Half of a 30 day month has passed.
+const NOW = new Date('2025-04-16T00:00:00Z');
+
+const BUDGET: Budget = {
+  name: 'billingAccounts/012345-6789AB-CDEF01/budgets/b1',
+  displayName: 'Dev budget',
+  amount: { specifiedAmount: { currencyCode: 'USD', units: '1000', nanos: 0 } },
+  thresholdRules: [
+    { thresholdPercent: 0.9, spendBasis: 'CURRENT_SPEND' },
+    { thresholdPercent: 0.5, spendBasis: 'CURRENT_SPEND' },
+    { thresholdPercent: 1, spendBasis: 'FORECASTED_SPEND' },
+  ],
+  budgetFilter: { projects: ['projects/123'], services: [] },
+};
+
+describe('elapsedMonthFraction', () => {
+  test('returns the fraction of the month that has passed', () => {
+    expect(elapsedMonthFraction(NOW)).toBe(0.5);
+  });
+});
+
+describe('budgetStatus', () => {
+  test('forecasts spend and finds crossed and next thresholds', () => {
+    const status = budgetStatus(BUDGET, { spend: 600, currency: 'USD' }, NOW);
+
+    expect(status).toMatchObject({
+      amount: 1000,
+      currency: 'USD',
+      spend: 600,
+      forecast: 1200,
+      percentUsed: 60,
+      forecastPercent: 120,
+      nextThreshold: { percent: 0.9, basis: 'current', amount: 900, remaining: 300 },
+    });
+    expect(status.crossedThresholds.map((t) => [t.percent, t.basis])).toEqual([
+      [0.5, 'current'],
+      [1, 'forecasted'],
+    ]);
+  });
+
+  test('reports budgets without spend', () => {
+    const status = budgetStatus({ ...BUDGET, amount: { lastPeriodAmount: {} } }, null, NOW);
+
+    expect(status).toMatchObject({
+      amount: null,
+      spend: null,
+      forecast: null,
+      percentUsed: null,
+      crossedThresholds: [],
+      nextThreshold: { percent: 0.5, amount: null, remaining: null },
+    });
+  });
+});
+
+describe('listBudgetStatuses', () => {
+  let mockedGcloud: gcloud.GcloudExecutable;
+  let api: GoogleApiClient;
+
+  beforeEach(() => {
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: JSON.stringify([BUDGET]), stderr: '' }),
+    };
+    api = { get: vi.fn(), post: vi.fn() };
+  });
+
+  test('adds spend from the billing export', async () => {
+    vi.mocked(api.post).mockResolvedValue({
+      jobComplete: true,
+      rows: [{ f: [{ v: '250' }, { v: 'USD' }] }],
+    });
+
+    const [status] = await listBudgetStatuses(
+      mockedGcloud,
+      '012345-6789AB-CDEF01',
+      { api, config: { table: 'p.billing.export' } },
+      NOW,
+    );
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'billing',
+      'budgets',
+      'list',
+      '--billing-account=012345-6789AB-CDEF01',
+      '--format=json',
+    ]);
+    expect(JSON.stringify(vi.mocked(api.post).mock.calls[0]![1])).toContain('"value":"123"');
+    expect(status).toMatchObject({ spend: 250, forecast: 500 });
+  });
+
+  test('explains missing spend without a billing export', async () => {
+    const [status] = await listBudgetStatuses(mockedGcloud, '012345-6789AB-CDEF01');
+
+    expect(status?.spend).toBeNull();
+    expect(status?.note).toContain('billingExport');
+  });
+
+  test('fails if the budgets can not be listed', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 1,
+      stdout: '',
+      stderr: 'PERMISSION_DENIED',
+    });
+
+    await expect(listBudgetStatuses(mockedGcloud, '012345-6789AB-CDEF01')).rejects.toThrow(
+      'Unable to list budgets: PERMISSION_DENIED',
+    );
+  });
+});
+
+describe('createBudgetArgs', () => {
+  test('builds the gcloud command for a budget spec', () => {
+    expect(
+      createBudgetArgs({
+        billingAccount: '012345-6789AB-CDEF01',
+        displayName: 'Team budget',
+        amount: 500,
+        currency: 'USD',
+        thresholds: [
+          { percent: 0.5, basis: 'current' },
+          { percent: 1, basis: 'forecasted' },
+        ],
+        projects: ['dev', 'staging'],
+        calendarPeriod: 'month',
+      }),
+    ).toEqual([
+      'billing',
+      'budgets',
+      'create',
+      '--billing-account=012345-6789AB-CDEF01',
+      '--display-name=Team budget',
+      '--budget-amount=500USD',
+      '--calendar-period=month',
+      '--filter-projects=projects/dev,projects/staging',
+      '--threshold-rule=percent=0.5',
+      '--threshold-rule=percent=1,basis=forecasted-spend',
+    ]);
+  });
+});
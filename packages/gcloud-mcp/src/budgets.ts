@@ -0,0 +1,246 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import { BillingExportConfig, getMonthToDateSpend } from './billing_export.js';
+import { sessionProject } from './session_context.js';
+
+// There are more fields in each budget, but only these are used.
+const BudgetSchema = z.object({
+  name: z.string(),
+  displayName: z.string().nullish(),
+  amount: z
+    .object({
+      specifiedAmount: z
+        .object({
+          currencyCode: z.string().nullish(),
+          units: z.string().default('0'),
+          nanos: z.number().default(0),
+        })
+        .nullish(),
+      lastPeriodAmount: z.object({}).nullish(),
+    })
+    .default({}),
+  thresholdRules: z
+    .array(
+      z.object({
+        thresholdPercent: z.number(),
+        spendBasis: z.enum(['CURRENT_SPEND', 'FORECASTED_SPEND']).default('CURRENT_SPEND'),
+      }),
+    )
+    .default([]),
+  budgetFilter: z
+    .object({
+      projects: z.array(z.string()).default([]),
+      services: z.array(z.string()).default([]),
+      calendarPeriod: z.string().nullish(),
+      customPeriod: z.unknown().optional(),
+    })
+    .default({}),
+});
+
+export type Budget = z.infer<typeof BudgetSchema>;
+
+export interface BudgetThreshold {
+  percent: number;
+  basis: 'current' | 'forecasted';
+  amount: number | null;
+}
+
+export interface BudgetStatus {
+  name: string;
+  displayName: string | null;
+  /** Null for budgets set to the previous period's spend. */
+  amount: number | null;
+  currency: string | null;
+  spend: number | null;
+  /** The spend projected to the end of the month at the current rate. */
+  forecast: number | null;
+  percentUsed: number | null;
+  forecastPercent: number | null;
+  crossedThresholds: BudgetThreshold[];
+  /** The lowest threshold not crossed yet, and how much more spend crosses it. */
+  nextThreshold: (BudgetThreshold & { remaining: number | null }) | null;
+  note?: string;
+}
+
+export interface BudgetSpec {
+  billingAccount: string;
+  displayName: string;
+  amount: number;
+  currency: string;
+  thresholds: Array<{ percent: number; basis: 'current' | 'forecasted' }>;
+  /** Project IDs the budget applies to. All projects on the account if empty. */
+  projects?: string[] | undefined;
+  /** Billing service IDs, e.g. `6F81-5844-456A` for Compute Engine. */
+  services?: string[] | undefined;
+  calendarPeriod: 'month' | 'quarter' | 'year';
+}
+
+const round = (value: number) => Math.round(value * 100) / 100;
+
+const percentOf = (value: number | null, amount: number | null) =>
+  value !== null && amount ? round((value / amount) * 100) : null;
+
+/** Returns the fraction of the current calendar month that has passed. */
+export const elapsedMonthFraction = (now: Date): number => {
+  const start = Date.UTC(now.getUTCFullYear(), now.getUTCMonth(), 1);
+  const end = Date.UTC(now.getUTCFullYear(), now.getUTCMonth() + 1, 1);
+  return (now.getTime() - start) / (end - start);
+};
+
+/**
+ * Combines a budget with its month-to-date spend.
+ *
+ * The forecast extrapolates the spend linearly to the end of the month, so it
+ * is rough early in the month and for bursty workloads.
+ */
+export const budgetStatus = (
+  budget: Budget,
+  spend: { spend: number; currency: string | null } | null,
+  now: Date = new Date(),
+): BudgetStatus => {
+  const specified = budget.amount.specifiedAmount;
+  const amount = specified ? round(Number(specified.units) + specified.nanos / 1e9) : null;
+  const fraction = elapsedMonthFraction(now);
+  const current = spend?.spend ?? null;
+  const forecast = current !== null && fraction > 0 ? round(current / fraction) : null;
+
+  const thresholds = budget.thresholdRules
+    .map(({ thresholdPercent, spendBasis }) => ({
+      percent: thresholdPercent,
+      basis: spendBasis === 'FORECASTED_SPEND' ? ('forecasted' as const) : ('current' as const),
+      amount: amount !== null ? round(amount * thresholdPercent) : null,
+    }))
+    .sort((a, b) => a.percent - b.percent);
+  const crossed = (threshold: BudgetThreshold) => {
+    const value = threshold.basis === 'forecasted' ? forecast : current;
+    return threshold.amount !== null && value !== null && value >= threshold.amount;
+  };
+  const next = thresholds.find((threshold) => !crossed(threshold));
+  const nextValue = next?.basis === 'forecasted' ? forecast : current;
+
+  return {
+    name: budget.name,
+    displayName: budget.displayName ?? null,
+    amount,
+    currency: specified?.currencyCode ?? spend?.currency ?? null,
+    spend: current,
+    forecast,
+    percentUsed: percentOf(current, amount),
+    forecastPercent: percentOf(forecast, amount),
+    crossedThresholds: thresholds.filter(crossed),
+    nextThreshold: next
+      ? {
+          ...next,
+          remaining:
+            next.amount !== null && nextValue !== null ? round(next.amount - nextValue) : null,
+        }
+      : null,
+  };
+};
+
+const isMonthly = (budget: Budget) =>
+  !budget.budgetFilter.customPeriod &&
+  (budget.budgetFilter.calendarPeriod ?? 'MONTH') === 'MONTH';
+
+/** Lists the budgets of a billing account with their spend, when a billing export is configured. */
+export const listBudgetStatuses = async (
+  gcloud: GcloudExecutable,
+  billingAccount: string,
+  billingExport?: { api: GoogleApiClient; config: BillingExportConfig },
+  now: Date = new Date(),
+): Promise<BudgetStatus[]> => {
+  const { code, stdout, stderr } = await gcloud.invoke([
+    'billing',
+    'budgets',
+    'list',
+    `--billing-account=${billingAccount}`,
+    '--format=json',
+  ]);
+  if (code !== 0) {
+    throw new Error(`Unable to list budgets: ${stderr}`);
+  }
+  const budgets = z.array(BudgetSchema).parse(JSON.parse(stdout));
+  return Promise.all(
+    budgets.map(async (budget): Promise<BudgetStatus> => {
+      if (!billingExport) {
+        return {
+          ...budgetStatus(budget, null, now),
+          note: 'Spend is only reported when "billingExport" is configured.',
+        };
+      }
+      if (!isMonthly(budget)) {
+        return {
+          ...budgetStatus(budget, null, now),
+          note: 'Spend is only reported for monthly budgets.',
+        };
+      }
+      const { projects, services } = budget.budgetFilter;
+      const spend = await getMonthToDateSpend(
+        billingExport.api,
+        billingExport.config,
+        {
+          projectNumbers: projects.map((project) => project.replace(/^projects\//, '')),
+          services: services.map((service) => service.replace(/^services\//, '')),
+        },
+        now,
+      );
+      return budgetStatus(budget, spend, now);
+    }),
+  );
+};
+
+/** Returns the gcloud arguments that create a budget. */
+export const createBudgetArgs = (spec: BudgetSpec): string[] => [
+  'billing',
+  'budgets',
+  'create',
+  `--billing-account=${spec.billingAccount}`,
+  `--display-name=${spec.displayName}`,
+  `--budget-amount=${spec.amount}${spec.currency}`,
+  `--calendar-period=${spec.calendarPeriod}`,
+  ...(spec.projects?.length
+    ? [`--filter-projects=${spec.projects.map((project) => `projects/${project}`).join(',')}`]
+    : []),
+  ...(spec.services?.length
+    ? [`--filter-services=${spec.services.map((service) => `services/${service}`).join(',')}`]
+    : []),
+  ...spec.thresholds.map(({ percent, basis }) =>
+    basis === 'forecasted'
+      ? `--threshold-rule=percent=${percent},basis=forecasted-spend`
+      : `--threshold-rule=percent=${percent}`,
+  ),
+];
+
+/** Returns the billing account of the session's default project. */
+export const defaultBillingAccount = async (gcloud: GcloudExecutable): Promise<string | null> => {
+  const project = await sessionProject(gcloud);
+  if (!project) {
+    return null;
+  }
+  const { code, stdout } = await gcloud.invoke([
+    'billing',
+    'projects',
+    'describe',
+    project,
+    '--format=value(billingAccountName)',
+  ]);
+  const account = stdout.trim().replace(/^billingAccounts\//, '');
+  return code === 0 && account ? account : null;
+};
@@ -0,0 +1,202 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { CommandHistory, createCommandHistory } from './command_history.js';
+import {
+  CleanupItem,
+  executeCleanup,
+  planCleanup,
+  validateCleanupCriteria,
+} from './cleanup.js';
+import { successfulTextResult } from './tools/tool_result.js';
+
+vi.mock('./gcloud.js');
+
+const NOW = new Date('2025-06-01T00:00:00Z');
+
+const ASSETS = [
+  {
+    name: '//compute.googleapis.com/projects/p/zones/us-central1-a/disks/tmp-old',
+    assetType: 'compute.googleapis.com/Disk',
+    location: 'us-central1-a',
+    labels: { env: 'tmp' },
+    createTime: '2025-01-01T00:00:00Z',
+  },
+  {
+    name: '//compute.googleapis.com/projects/p/zones/us-central1-a/disks/tmp-new',
+    assetType: 'compute.googleapis.com/Disk',
+    location: 'us-central1-a',
+    labels: { env: 'tmp' },
+    createTime: '2025-05-30T00:00:00Z',
+  },
+  {
+    name: '//storage.googleapis.com/prod-data',
+    assetType: 'storage.googleapis.com/Bucket',
+    location: 'us',
+    labels: { env: 'prod' },
+    createTime: '2024-01-01T00:00:00Z',
+  },
+];
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+describe('validateCleanupCriteria', () => {
+  test('requires a selection criterion', () => {
+    expect(validateCleanupCriteria({})).toContain('Select resources');
+    expect(validateCleanupCriteria({ resourceTypes: ['compute disks'] })).toContain(
+      'Select resources',
+    );
+    expect(validateCleanupCriteria({ labels: { env: 'tmp' } })).toBeUndefined();
+  });
+
+  test('rejects invalid patterns and unknown resource types', () => {
+    expect(validateCleanupCriteria({ namePattern: '(' })).toContain('Invalid name pattern');
+    expect(
+      validateCleanupCriteria({ olderThanDays: 1, resourceTypes: ['compute widgets'] }),
+    ).toContain('Unknown resource types: compute widgets');
+  });
+});
+
+describe('planCleanup', () => {
+  beforeEach(() => {
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: JSON.stringify(ASSETS), stderr: '' }),
+    };
+  });
+
+  test('selects resources matching every criterion', async () => {
+    const plan = await planCleanup(
+      mockedGcloud,
+      ['p'],
+      { labels: { env: 'tmp' }, olderThanDays: 30, namePattern: '^tmp-' },
+      NOW,
+    );
+
+    expect(plan.items).toEqual([
+      {
+        project: 'p',
+        resourceType: 'compute disks',
+        name: 'tmp-old',
+        location: 'us-central1-a',
+        createTime: '2025-01-01T00:00:00Z',
+        labels: { env: 'tmp' },
+        args: [
+          'compute',
+          'disks',
+          'delete',
+          'tmp-old',
+          '--zone=us-central1-a',
+          '--quiet',
+          '--project=p',
+        ],
+        consoleUrl:
+          'https://console.cloud.google.com/compute/disksDetail/zones/us-central1-a/disks/tmp-old?project=p',
+      },
+    ]);
+    expect(plan.planHash).toMatch(/^[0-9a-f]{16}$/);
+  });
+
+  test('only searches the requested resource types', async () => {
+    await planCleanup(mockedGcloud, ['p'], { olderThanDays: 30, resourceTypes: ['compute disks'] });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(
+      expect.arrayContaining(['--asset-types=compute.googleapis.com/Disk']),
+    );
+  });
+
+  test('changes the plan hash when the selection changes', async () => {
+    const before = await planCleanup(mockedGcloud, ['p'], { labels: { env: 'tmp' } }, NOW);
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 0,
+      stdout: JSON.stringify(ASSETS.slice(1)),
+      stderr: '',
+    });
+    const after = await planCleanup(mockedGcloud, ['p'], { labels: { env: 'tmp' } }, NOW);
+
+    expect(after.planHash).not.toBe(before.planHash);
+  });
+});
+
+describe('executeCleanup', () => {
+  let history: CommandHistory;
+  const run = vi.fn();
+
+  const item = (name: string): CleanupItem => ({
+    project: 'p',
+    resourceType: 'compute disks',
+    name,
+    location: 'us-central1-a',
+    createTime: null,
+    labels: {},
+    args: ['compute', 'disks', 'delete', name, '--zone=us-central1-a', '--quiet', '--project=p'],
+    consoleUrl: null,
+  });
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+    run.mockImplementation(async (args: string[]) => {
+      history.record(args, args[3] === 'bad' ? 1 : 0);
+      return successfulTextResult('ok');
+    });
+  });
+
+  test('stops at the first failure', async () => {
+    const { results, stopped } = await executeCleanup(
+      [item('a'), item('bad'), item('c')],
+      run,
+      history,
+      { stopOnError: true },
+    );
+
+    expect(results.map(({ name, status }) => [name, status])).toEqual([
+      ['a', 'deleted'],
+      ['bad', 'failed'],
+      ['c', 'not-run'],
+    ]);
+    expect(stopped).toContain('"bad" failed');
+    expect(run).toHaveBeenCalledTimes(2);
+  });
+
+  test('continues after failures unless told to stop', async () => {
+    const { results, stopped } = await executeCleanup([item('bad'), item('b')], run, history, {
+      stopOnError: false,
+    });
+
+    expect(results.map(({ status }) => status)).toEqual(['failed', 'deleted']);
+    expect(stopped).toBeNull();
+  });
+
+  test('stops after a batch or when cancelled', async () => {
+    const batch = await executeCleanup([item('a'), item('b')], run, history, {
+      stopOnError: true,
+      batchSize: 1,
+    });
+    const controller = new AbortController();
+    controller.abort();
+    const cancelled = await executeCleanup([item('a')], run, history, {
+      stopOnError: true,
+      signal: controller.signal,
+    });
+
+    expect(batch.results.map(({ status }) => status)).toEqual(['deleted', 'not-run']);
+    expect(cancelled.results.map(({ status }) => status)).toEqual(['not-run']);
+    expect(cancelled.stopped).toBe('The request was cancelled.');
+  });
+});
@@ -0,0 +1,189 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { createHash } from 'crypto';
+import { GcloudExecutable } from './gcloud.js';
+import { ASSET_TYPES, Asset, searchAssets } from './assets.js';
+import { consoleUrlOf } from './console_links.js';
+import { CommandHistory } from './command_history.js';
+import { GcloudCommandRunner, runRecorded } from './tools/run_gcloud_command.js';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+// Larger selections are refused, so a loose pattern can not wipe out a project.
+export const MAX_CLEANUP_ITEMS = 100;
+
+export interface CleanupCriteria {
+  /** Resource types to consider, e.g. `compute disks`. Defaults to every known type. */
+  resourceTypes?: string[] | undefined;
+  /** Labels the resources must have, with these exact values. */
+  labels?: Record<string, string> | undefined;
+  /** The minimum age of the resources, by creation time. */
+  olderThanDays?: number | undefined;
+  /** A regular expression resource names must match. */
+  namePattern?: string | undefined;
+}
+
+export interface CleanupItem {
+  project: string;
+  resourceType: string;
+  name: string;
+  location: string;
+  createTime: string | null;
+  labels: Record<string, string>;
+  args: string[];
+  consoleUrl: string | null;
+}
+
+export interface CleanupPlan {
+  /** Identifies the exact set of commands; it must be confirmed to run them. */
+  planHash: string;
+  items: CleanupItem[];
+  errors: Array<{ project: string; message: string }>;
+}
+
+export interface CleanupResult {
+  project: string;
+  resourceType: string;
+  name: string;
+  command: string;
+  status: 'deleted' | 'failed' | 'not-run';
+  output?: string;
+}
+
+/** Returns an error message if the criteria could select every resource or are invalid. */
+export const validateCleanupCriteria = (criteria: CleanupCriteria): string | undefined => {
+  const { labels, olderThanDays, namePattern, resourceTypes } = criteria;
+  if (!namePattern && olderThanDays === undefined && Object.keys(labels ?? {}).length === 0) {
+    return 'Select resources by labels, olderThanDays, or namePattern.';
+  }
+  if (namePattern) {
+    try {
+      new RegExp(namePattern);
+    } catch {
+      return `Invalid name pattern: ${namePattern}`;
+    }
+  }
+  const known = Object.values(ASSET_TYPES).map(({ resourceType }) => resourceType);
+  const unknown = (resourceTypes ?? []).filter((type) => !known.includes(type));
+  if (unknown.length > 0) {
+    return `Unknown resource types: ${unknown.join(', ')}. Known types are: ${known.join(', ')}.`;
+  }
+  return undefined;
+};
+
+const matches = (asset: Asset, criteria: CleanupCriteria, now: Date): boolean => {
+  const { labels, olderThanDays, namePattern } = criteria;
+  if (namePattern && !new RegExp(namePattern).test(asset.name)) {
+    return false;
+  }
+  if (labels && Object.entries(labels).some(([key, value]) => asset.labels[key] !== value)) {
+    return false;
+  }
+  if (olderThanDays !== undefined) {
+    // Resources without a creation time can not be shown to be old enough.
+    const created = asset.createTime ? new Date(asset.createTime).getTime() : NaN;
+    if (!(now.getTime() - created >= olderThanDays * DAY_MS)) {
+      return false;
+    }
+  }
+  return true;
+};
+
+/** Returns the hash that identifies the commands of a plan, in order. */
+export const cleanupPlanHash = (items: CleanupItem[]): string =>
+  createHash('sha256')
+    .update(JSON.stringify(items.map(({ project, args }) => [project, args])))
+    .digest('hex')
+    .slice(0, 16);
+
+/** Selects the resources matching the criteria and plans their deletion. */
+export const planCleanup = async (
+  gcloud: GcloudExecutable,
+  projects: string[],
+  criteria: CleanupCriteria,
+  now: Date = new Date(),
+): Promise<CleanupPlan> => {
+  const assetTypes = Object.entries(ASSET_TYPES)
+    .filter(([, { resourceType }]) => criteria.resourceTypes?.includes(resourceType) ?? true)
+    .map(([assetType]) => assetType);
+  const items: CleanupItem[] = [];
+  const errors: CleanupPlan['errors'] = [];
+  for (const project of projects) {
+    let assets: Asset[];
+    try {
+      assets = await searchAssets(gcloud, project, assetTypes);
+    } catch (e: unknown) {
+      errors.push({ project, message: e instanceof Error ? e.message : String(e) });
+      continue;
+    }
+    for (const asset of assets.filter((a) => matches(a, criteria, now))) {
+      const type = ASSET_TYPES[asset.assetType];
+      if (!type) {
+        continue;
+      }
+      items.push({
+        project,
+        resourceType: type.resourceType,
+        name: asset.name,
+        location: asset.location,
+        createTime: asset.createTime,
+        labels: asset.labels,
+        args: [...type.deleteArgs(asset.name, asset.location), `--project=${project}`],
+        consoleUrl: consoleUrlOf(asset),
+      });
+    }
+  }
+  return { planHash: cleanupPlanHash(items), items, errors };
+};
+
+/**
+ * Deletes the planned resources one at a time through the command runner.
+ *
+ * Execution stops at the first failure if `stopOnError` is set, after
+ * `batchSize` items, or when the request is cancelled. Items that were not
+ * attempted are reported as `not-run`, so a new plan picks them up.
+ */
+export const executeCleanup = async (
+  items: CleanupItem[],
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+  options: { stopOnError: boolean; batchSize?: number | undefined; signal?: AbortSignal },
+): Promise<{ results: CleanupResult[]; stopped: string | null }> => {
+  const results: CleanupResult[] = [];
+  let stopped: string | null = null;
+  for (const [i, item] of items.entries()) {
+    const { project, resourceType, name } = item;
+    const command = `gcloud ${item.args.join(' ')}`;
+    if (!stopped && options.signal?.aborted) {
+      stopped = 'The request was cancelled.';
+    }
+    if (!stopped && options.batchSize !== undefined && i >= options.batchSize) {
+      stopped = `The batch of ${options.batchSize} items is done.`;
+    }
+    if (stopped) {
+      results.push({ project, resourceType, name, command, status: 'not-run' });
+      continue;
+    }
+    const { exitCode, output } = await runRecorded(run, history, item.args);
+    const status = exitCode === 0 ? 'deleted' : 'failed';
+    results.push({ project, resourceType, name, command, status, output });
+    if (status === 'failed' && options.stopOnError) {
+      stopped = `Deleting ${resourceType} "${name}" failed.`;
+    }
+  }
+  return { results, stopped };
+};
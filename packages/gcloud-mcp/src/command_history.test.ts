@@ -0,0 +1,124 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import { applyFlagOverrides, createCommandHistory, toShellScript } from './command_history.js';
+
+describe('createCommandHistory', () => {
+  test('records commands with increasing indices', () => {
+    vi.setSystemTime(new Date('2025-01-01T00:00:00.000Z'));
+    const history = createCommandHistory(() => ({ CLOUDSDK_CORE_PROJECT: 'dev' }));
+
+    history.record(['config', 'list'], 0);
+    history.record(['compute', 'instances', 'list'], 1, { CLOUDSDK_CORE_PROJECT: 'prod' });
+
+    expect(history.list()).toEqual([
+      {
+        index: 1,
+        args: ['config', 'list'],
+        exitCode: 0,
+        timestamp: '2025-01-01T00:00:00.000Z',
+        env: { CLOUDSDK_CORE_PROJECT: 'dev' },
+      },
+      {
+        index: 2,
+        args: ['compute', 'instances', 'list'],
+        exitCode: 1,
+        timestamp: '2025-01-01T00:00:00.000Z',
+        env: { CLOUDSDK_CORE_PROJECT: 'prod' },
+      },
+    ]);
+    vi.useRealTimers();
+  });
+
+  test('drops the oldest entries beyond the maximum size', () => {
+    const history = createCommandHistory(undefined, 2);
+    history.record(['a'], 0);
+    history.record(['b'], 0);
+    history.record(['c'], 0);
+
+    expect(history.list().map((entry) => entry.index)).toEqual([2, 3]);
+    expect(history.get(1)).toBeUndefined();
+    expect(history.get(3)?.args).toEqual(['c']);
+  });
+});
+
+describe('applyFlagOverrides', () => {
+  const args = ['compute', 'instances', 'list', '--zone', 'us-east1-b', '--format=json'];
+
+  test('replaces flags in both forms', () => {
+    expect(applyFlagOverrides(args, { '--zone': 'europe-west1-b', '--format': 'yaml' })).toEqual([
+      'compute',
+      'instances',
+      'list',
+      '--zone=europe-west1-b',
+      '--format=yaml',
+    ]);
+  });
+
+  test('removes flags set to null', () => {
+    expect(applyFlagOverrides(args, { '--zone': null })).toEqual([
+      'compute',
+      'instances',
+      'list',
+      '--format=json',
+    ]);
+  });
+
+  test('appends flags that are not present', () => {
+    const overrides = { '--all': true as const, '--verbosity': 'debug' };
+    expect(applyFlagOverrides(['config', 'list'], overrides)).toEqual([
+      'config',
+      'list',
+      '--all',
+      '--verbosity=debug',
+    ]);
+  });
+});
+
+describe('toShellScript', () => {
+  test('renders a script with quoted arguments and session environment', () => {
+    const script = toShellScript([
+      {
+        index: 1,
+        args: ['logging', 'read', 'severity>=ERROR', '--limit=10'],
+        exitCode: 0,
+        timestamp: '2025-01-01T00:00:00.000Z',
+        env: { CLOUDSDK_CORE_PROJECT: 'dev' },
+      },
+    ]);
+
+    expect(script).toBe(
+      [
+        '#!/usr/bin/env bash',
+        '# Commands executed by the gcloud MCP server.',
+        'set -euo pipefail',
+        '',
+        '# [1] 2025-01-01T00:00:00.000Z (exit code 0)',
+        "CLOUDSDK_CORE_PROJECT=dev gcloud logging read 'severity>=ERROR' --limit=10",
+        '',
+      ].join('\n'),
+    );
+  });
+
+  test('does not stop on errors when failed commands are included', () => {
+    const entry = { index: 1, args: ["it's"], exitCode: 1, timestamp: 't', env: {} };
+    const script = toShellScript([entry]);
+
+    expect(script).toContain('set -uo pipefail');
+    expect(script).toContain(`gcloud 'it'\\''s'`);
+  });
+});
@@ -0,0 +1,137 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+export interface CommandHistoryEntry {
+  /** 1-based position in the session history. */
+  index: number;
+  /** The command without flags and positionals, e.g. `compute instances create`. */
+  command?: string;
+  args: string[];
+  exitCode: number | null;
+  timestamp: string;
+  /** Session environment overrides in effect when the command ran. */
+  env: NodeJS.ProcessEnv;
+}
+
+export type CommandHistory = ReturnType<typeof createCommandHistory>;
+
+// Oldest entries are dropped beyond this size to bound memory use.
+export const MAX_HISTORY_ENTRIES = 1000;
+
+/**
+ * Creates the history of commands executed in this session.
+ *
+ * @param contextEnv Returns the session environment overrides to record with each command.
+ */
+export const createCommandHistory = (
+  contextEnv: () => NodeJS.ProcessEnv = () => ({}),
+  maxEntries: number = MAX_HISTORY_ENTRIES,
+) => {
+  const entries: CommandHistoryEntry[] = [];
+  let nextIndex = 1;
+
+  return {
+    record: (
+      args: string[],
+      exitCode: number | null,
+      env?: NodeJS.ProcessEnv,
+      command?: string,
+    ): CommandHistoryEntry => {
+      const entry = {
+        index: nextIndex++,
+        ...(command && { command }),
+        args: [...args],
+        exitCode,
+        timestamp: new Date().toISOString(),
+        env: { ...contextEnv(), ...env },
+      };
+      entries.push(entry);
+      if (entries.length > maxEntries) {
+        entries.shift();
+      }
+      return entry;
+    },
+    list: (): CommandHistoryEntry[] => [...entries],
+    get: (index: number): CommandHistoryEntry | undefined =>
+      entries.find((entry) => entry.index === index),
+  };
+};
+
+/**
+ * Applies flag overrides to the arguments of a command.
+ *
+ * Flags are matched in both `--flag=value` and `--flag value` form. A null
+ * value removes the flag, `true` sets it without a value, and flags that are
+ * not present are appended.
+ */
+export const applyFlagOverrides = (
+  args: string[],
+  overrides: Record<string, string | true | null>,
+): string[] => {
+  const result: string[] = [];
+  const applied = new Set<string>();
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]!;
+    const name = arg.split('=')[0]!;
+    if (!arg.startsWith('--') || !(name in overrides)) {
+      result.push(arg);
+      continue;
+    }
+    applied.add(name);
+    const next = args[i + 1];
+    if (!arg.includes('=') && next !== undefined && !next.startsWith('-')) {
+      i++; // Skip the separate value of the overridden flag.
+    }
+    const value = overrides[name];
+    if (value === true) {
+      result.push(name);
+    } else if (value !== null && value !== undefined) {
+      result.push(`${name}=${value}`);
+    }
+  }
+  for (const [name, value] of Object.entries(overrides)) {
+    if (applied.has(name) || value === null) {
+      continue;
+    }
+    result.push(value === true ? name : `${name}=${value}`);
+  }
+  return result;
+};
+
+const shellQuote = (arg: string): string =>
+  /^[\w./:=@%+,-]+$/.test(arg) ? arg : `'${arg.replace(/'/g, `'\\''`)}'`;
+
+/** Renders history entries as a reproducible bash script. */
+export const toShellScript = (entries: CommandHistoryEntry[]): string => {
+  // Only stop on the first failure if every recorded command succeeded.
+  const succeeded = entries.every((entry) => entry.exitCode === 0);
+  const lines = [
+    '#!/usr/bin/env bash',
+    '# Commands executed by the gcloud MCP server.',
+    succeeded ? 'set -euo pipefail' : 'set -uo pipefail',
+  ];
+  for (const entry of entries) {
+    const env = Object.entries(entry.env).map(
+      ([key, value]) => `${key}=${shellQuote(value ?? '')}`,
+    );
+    lines.push(
+      '',
+      `# [${entry.index}] ${entry.timestamp} (exit code ${entry.exitCode})`,
+      [...env, 'gcloud', ...entry.args.map(shellQuote)].join(' '),
+    );
+  }
+  return lines.join('\n') + '\n';
+};
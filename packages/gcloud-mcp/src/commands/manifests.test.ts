@@ -0,0 +1,50 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, test, expect } from 'vitest';
+import { generateManifest } from './manifests.js';
+
+const options = {
+  name: 'gcloud-mcp',
+  image: 'us-docker.pkg.dev/ops/mcp/gcloud-mcp:1',
+  serviceAccount: 'mcp-runtime@ops.iam.gserviceaccount.com',
+  namespace: 'mcp',
+  version: '0.5.0',
+};
+
+describe('generateManifest', () => {
+  test('generates a single-instance Cloud Run service', () => {
+    const manifest = generateManifest('cloud-run', options);
+
+    expect(manifest).toContain('kind: Service');
+    expect(manifest).toContain(`image: ${options.image}`);
+    expect(manifest).toContain(`serviceAccountName: ${options.serviceAccount}`);
+    expect(manifest).toContain("autoscaling.knative.dev/maxScale: '1'");
+    expect(manifest).toContain('path: /healthz');
+  });
+
+  test('generates a Kubernetes deployment using Workload Identity', () => {
+    const manifest = generateManifest('kubernetes', options);
+
+    expect(manifest).toContain(`iam.gke.io/gcp-service-account: ${options.serviceAccount}`);
+    expect(manifest).toContain('namespace: mcp');
+    expect(manifest).toContain('path: /healthz');
+  });
+
+  test('pins the server version in the Dockerfile', () => {
+    expect(generateManifest('dockerfile', options)).toContain('@google-cloud/gcloud-mcp@0.5.0');
+  });
+});
@@ -0,0 +1,223 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Argv, ArgumentsCamelCase, CommandModule } from 'yargs';
+import pkg from '../../package.json' with { type: 'json' };
+import { DEFAULT_PORT, HEALTH_PATH } from '../remote_server.js';
+
+export const MANIFEST_TARGETS = ['cloud-run', 'kubernetes', 'dockerfile'] as const;
+
+export type ManifestTarget = (typeof MANIFEST_TARGETS)[number];
+
+export interface ManifestOptions {
+  name: string;
+  /** The container image, built from the generated Dockerfile. */
+  image: string;
+  /** The service account the server runs as. It only needs to impersonate the per-user accounts. */
+  serviceAccount: string;
+  namespace: string;
+  version: string;
+}
+
+// The remote configuration is mounted from a secret or config map at this path.
+const CONFIG_DIR = '/etc/gcloud-mcp';
+
+const serverArgs = () =>
+  ['--transport=http', '--host=0.0.0.0', `--config=${CONFIG_DIR}/config.json`]
+    .map((arg) => `            - ${arg}`)
+    .join('\n');
+
+const cloudRunManifest = ({ name, image, serviceAccount }: ManifestOptions) => `# Deploy with: gcloud run services replace service.yaml
+# Create the "${name}-config" secret with the remote configuration first:
+#   gcloud secrets create ${name}-config --data-file=config.json
+# Sessions are held in memory, so the service runs a single instance.
+apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: ${name}
+  annotations:
+    run.googleapis.com/ingress: internal-and-cloud-load-balancing
+spec:
+  template:
+    metadata:
+      annotations:
+        autoscaling.knative.dev/maxScale: '1'
+        run.googleapis.com/sessionAffinity: 'true'
+    spec:
+      serviceAccountName: ${serviceAccount}
+      containers:
+        - image: ${image}
+          args:
+${serverArgs()}
+          ports:
+            - containerPort: ${DEFAULT_PORT}
+          startupProbe:
+            httpGet:
+              path: ${HEALTH_PATH}
+          livenessProbe:
+            httpGet:
+              path: ${HEALTH_PATH}
+          volumeMounts:
+            - name: config
+              mountPath: ${CONFIG_DIR}
+      volumes:
+        - name: config
+          secret:
+            secretName: ${name}-config
+            items:
+              - key: latest
+                path: config.json
+`;
+
+const kubernetesManifest = ({ name, image, serviceAccount, namespace }: ManifestOptions) => `# Apply with: kubectl apply -f ${name}.yaml
+# Create the "${name}-config" config map with the remote configuration first:
+#   kubectl create configmap ${name}-config --namespace=${namespace} --from-file=config.json
+# The Kubernetes service account uses Workload Identity, so grant it
+# roles/iam.workloadIdentityUser on ${serviceAccount}.
+# Sessions are held in memory, so the deployment runs a single replica.
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: ${name}
+  namespace: ${namespace}
+  annotations:
+    iam.gke.io/gcp-service-account: ${serviceAccount}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ${name}
+  namespace: ${namespace}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: ${name}
+  template:
+    metadata:
+      labels:
+        app: ${name}
+    spec:
+      serviceAccountName: ${name}
+      containers:
+        - name: ${name}
+          image: ${image}
+          args:
+${serverArgs()}
+          ports:
+            - containerPort: ${DEFAULT_PORT}
+          readinessProbe:
+            httpGet:
+              path: ${HEALTH_PATH}
+              port: ${DEFAULT_PORT}
+          livenessProbe:
+            httpGet:
+              path: ${HEALTH_PATH}
+              port: ${DEFAULT_PORT}
+          volumeMounts:
+            - name: config
+              mountPath: ${CONFIG_DIR}
+              readOnly: true
+      volumes:
+        - name: config
+          configMap:
+            name: ${name}-config
+---
+# Expose the service through an Ingress with Identity-Aware Proxy enabled.
+apiVersion: v1
+kind: Service
+metadata:
+  name: ${name}
+  namespace: ${namespace}
+spec:
+  selector:
+    app: ${name}
+  ports:
+    - port: 80
+      targetPort: ${DEFAULT_PORT}
+`;
+
+const dockerfile = ({ version }: ManifestOptions) => `# The gcloud CLI image pins the SDK the server runs with.
+FROM gcr.io/google.com/cloudsdktool/google-cloud-cli:stable
+RUN apt-get update \\
+    && apt-get install -y --no-install-recommends nodejs npm \\
+    && rm -rf /var/lib/apt/lists/* \\
+    && npm install --global @google-cloud/gcloud-mcp@${version}
+EXPOSE ${DEFAULT_PORT}
+ENTRYPOINT ["gcloud-mcp"]
+`;
+
+const GENERATORS: Record<ManifestTarget, (options: ManifestOptions) => string> = {
+  'cloud-run': cloudRunManifest,
+  kubernetes: kubernetesManifest,
+  dockerfile,
+};
+
+/** Returns the deployment manifest of the server in remote mode for a target. */
+export const generateManifest = (target: ManifestTarget, options: ManifestOptions): string =>
+  GENERATORS[target](options);
+
+interface ManifestArgs {
+  target: ManifestTarget;
+  name: string;
+  image: string;
+  serviceAccount: string;
+  namespace: string;
+}
+
+export const manifests: CommandModule<object, ManifestArgs> = {
+  command: 'manifests',
+  describe: 'Print deployment manifests for running the server in remote mode.',
+  builder: (yargs: Argv) =>
+    yargs
+      .option('target', {
+        describe: 'The platform to deploy to.',
+        type: 'string',
+        choices: MANIFEST_TARGETS,
+        demandOption: true,
+      })
+      .option('name', {
+        describe: 'The name of the service.',
+        type: 'string',
+        default: 'gcloud-mcp',
+      })
+      .option('image', {
+        describe: 'The container image of the server.',
+        type: 'string',
+        default: `gcloud-mcp:${pkg.version}`,
+      })
+      .option('service-account', {
+        describe: 'The service account the server runs as.',
+        type: 'string',
+        default: 'gcloud-mcp@PROJECT_ID.iam.gserviceaccount.com',
+      })
+      .option('namespace', {
+        describe: 'The Kubernetes namespace.',
+        type: 'string',
+        default: 'default',
+      }) as unknown as Argv<ManifestArgs>,
+  handler: (argv: ArgumentsCamelCase<ManifestArgs>) => {
+    process.stdout.write(
+      generateManifest(argv.target, {
+        name: argv.name,
+        image: argv.image,
+        serviceAccount: argv.serviceAccount,
+        namespace: argv.namespace,
+        version: pkg.version,
+      }),
+    );
+  },
+};
@@ -0,0 +1,173 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import { analyzeCommitments, commitmentFamily } from './commitments.js';
+
+vi.mock('./gcloud.js');
+
+const NOW = new Date('2025-06-01T00:00:00Z');
+
+const COMMITMENTS = [
+  {
+    name: 'cud-1',
+    region: 'https://www.googleapis.com/compute/v1/projects/p/regions/us-central1',
+    type: 'GENERAL_PURPOSE_N2',
+    plan: 'TWELVE_MONTH',
+    status: 'ACTIVE',
+    endTimestamp: '2025-06-11T00:00:00Z',
+    resources: [
+      { type: 'VCPU', amount: '16' },
+      { type: 'MEMORY', amount: '65536' },
+    ],
+  },
+];
+
+const RECOMMENDATIONS = [
+  {
+    name: 'projects/p/locations/us-central1/recommenders/r/recommendations/1',
+    description: 'Purchase a 3 year commitment',
+    primaryImpact: {
+      costProjection: { cost: { currencyCode: 'USD', units: '-300' }, duration: '2592000s' },
+    },
+    content: {
+      operationGroups: [
+        {
+          operations: [
+            {
+              action: 'insert',
+              value: {
+                plan: 'THIRTY_SIX_MONTH',
+                type: 'GENERAL_PURPOSE_N2',
+                resources: [
+                  { type: 'VCPU', amount: '8' },
+                  { type: 'MEMORY', amount: '32768' },
+                ],
+              },
+            },
+          ],
+        },
+      ],
+    },
+    stateInfo: { state: 'ACTIVE' },
+  },
+  { name: 'dismissed', stateInfo: { state: 'DISMISSED' } },
+];
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+describe('commitmentFamily', () => {
+  test('returns the machine family of a commitment type', () => {
+    expect(commitmentFamily('GENERAL_PURPOSE_E2')).toBe('E2');
+    expect(commitmentFamily('COMPUTE_OPTIMIZED_C2')).toBe('C2');
+    expect(commitmentFamily('GENERAL_PURPOSE')).toBe('N1');
+  });
+});
+
+describe('analyzeCommitments', () => {
+  beforeEach(() => {
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(async (args: string[]) => ({
+        code: 0,
+        stdout: JSON.stringify(args[0] === 'compute' ? COMMITMENTS : RECOMMENDATIONS),
+        stderr: '',
+      })),
+    };
+  });
+
+  test('reports utilization, coverage, and recommendations', async () => {
+    const api: GoogleApiClient = {
+      get: vi.fn(),
+      // 12 vCPUs and 96 GB on average over 30 days.
+      post: vi.fn().mockResolvedValue({
+        jobComplete: true,
+        rows: [{ f: [{ v: 'us-central1' }, { v: 'N2' }, { v: '8640' }, { v: '69120' }] }],
+      }),
+    };
+
+    const analysis = await analyzeCommitments(
+      mockedGcloud,
+      { project: 'p', days: 30, now: NOW },
+      { api, config: { table: 'p.billing.export' } },
+    );
+
+    expect(analysis.commitments).toEqual([
+      {
+        name: 'cud-1',
+        region: 'us-central1',
+        family: 'N2',
+        plan: 'TWELVE_MONTH',
+        vcpus: 16,
+        memoryGb: 64,
+        endTimestamp: '2025-06-11T00:00:00Z',
+        daysRemaining: 10,
+      },
+    ]);
+    expect(analysis.usage).toEqual([
+      {
+        region: 'us-central1',
+        family: 'N2',
+        committedVcpus: 16,
+        committedMemoryGb: 64,
+        averageVcpus: 12,
+        averageMemoryGb: 96,
+        vcpuUtilization: 0.75,
+        vcpuCoverage: 1,
+        memoryUtilization: 1,
+        memoryCoverage: 0.67,
+      },
+    ]);
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'recommender',
+      'recommendations',
+      'list',
+      '--project=p',
+      '--location=us-central1',
+      '--recommender=google.compute.commitment.UsageCommitmentRecommender',
+      '--format=json',
+    ]);
+    expect(analysis.recommendations).toEqual([
+      {
+        name: 'projects/p/locations/us-central1/recommenders/r/recommendations/1',
+        region: 'us-central1',
+        description: 'Purchase a 3 year commitment',
+        plan: 'THIRTY_SIX_MONTH',
+        family: 'N2',
+        vcpus: 8,
+        memoryGb: 32,
+        monthlySavings: 300,
+        currency: 'USD',
+        breakEvenUtilization: 0.45,
+      },
+    ]);
+  });
+
+  test('omits utilization without a billing export', async () => {
+    const analysis = await analyzeCommitments(mockedGcloud, {
+      project: 'p',
+      regions: ['europe-west1'],
+      days: 30,
+      now: NOW,
+    });
+
+    expect(analysis.usage[0]).toMatchObject({ averageVcpus: null, vcpuUtilization: null });
+    expect(analysis.notes).toEqual([expect.stringContaining('billingExport')]);
+    expect(analysis.recommendations[0]?.region).toBe('europe-west1');
+  });
+});
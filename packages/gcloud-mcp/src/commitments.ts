@@ -0,0 +1,279 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson } from './gcloud_json.js';
+import { GoogleApiClient } from './google_api.js';
+import { BillingExportConfig, queryBillingExport, timestampParameter } from './billing_export.js';
+import {
+  Recommendation,
+  listRecommendations,
+  monthlySavings,
+  operationsOf,
+} from './recommender.js';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const COMMITMENT_RECOMMENDER = 'google.compute.commitment.UsageCommitmentRecommender';
+
+// Published resource-based discounts for general-purpose machine families. A
+// commitment pays off while its utilization stays above 1 - discount.
+const PLAN_DISCOUNTS: Record<string, number> = {
+  TWELVE_MONTH: 0.37,
+  THIRTY_SIX_MONTH: 0.55,
+};
+
+const ResourceSchema = z.object({ type: z.string(), amount: z.string() });
+
+// There are more fields in each commitment, but only these are used.
+const CommitmentSchema = z.object({
+  name: z.string(),
+  region: z.string(),
+  type: z.string().nullish(),
+  plan: z.string(),
+  status: z.string(),
+  endTimestamp: z.string().nullish(),
+  resources: z.array(ResourceSchema).default([]),
+});
+
+export interface CommitmentUsage {
+  region: string;
+  family: string;
+  committedVcpus: number;
+  committedMemoryGb: number;
+  averageVcpus: number | null;
+  averageMemoryGb: number | null;
+  /** The share of committed vCPUs in use, on average. */
+  vcpuUtilization: number | null;
+  /** The share of vCPUs in use, on average, that commitments cover. */
+  vcpuCoverage: number | null;
+  memoryUtilization: number | null;
+  memoryCoverage: number | null;
+}
+
+export interface CommitmentRecommendation {
+  name: string;
+  region: string;
+  description: string | null;
+  plan: string | null;
+  family: string | null;
+  vcpus: number;
+  memoryGb: number;
+  monthlySavings: number | null;
+  currency: string | null;
+  /** The utilization below which the commitment costs more than on-demand usage. */
+  breakEvenUtilization: number | null;
+}
+
+export interface CommitmentAnalysis {
+  project: string;
+  periodDays: number;
+  commitments: Array<{
+    name: string;
+    region: string;
+    family: string;
+    plan: string;
+    vcpus: number;
+    memoryGb: number;
+    endTimestamp: string | null;
+    daysRemaining: number | null;
+  }>;
+  usage: CommitmentUsage[];
+  recommendations: CommitmentRecommendation[];
+  notes: string[];
+}
+
+const round = (value: number, digits = 2) => Math.round(value * 10 ** digits) / 10 ** digits;
+
+const ratio = (part: number, whole: number) => (whole > 0 ? round(part / whole) : null);
+
+/**
+ * Returns the machine family of a commitment type, e.g. `N2` for
+ * `GENERAL_PURPOSE_N2`. `GENERAL_PURPOSE` commitments cover N1.
+ */
+export const commitmentFamily = (type: string | null | undefined): string => {
+  if (!type || type === 'GENERAL_PURPOSE') {
+    return 'N1';
+  }
+  return type.split('_').at(-1) ?? type;
+};
+
+const amountsOf = (resources: Array<z.infer<typeof ResourceSchema>>) => ({
+  vcpus: resources
+    .filter((resource) => resource.type === 'VCPU')
+    .reduce((sum, resource) => sum + Number(resource.amount), 0),
+  // Memory is committed in MB.
+  memoryGb: round(
+    resources
+      .filter((resource) => resource.type === 'MEMORY')
+      .reduce((sum, resource) => sum + Number(resource.amount), 0) / 1024,
+  ),
+});
+
+/** Returns the query for average vCPU and memory usage by region and machine family. */
+export const averageUsageQuery = (table: string): string => `SELECT
+  location.region AS region,
+  REGEXP_EXTRACT(sku.description, r'^(\\w+) ') AS family,
+  SUM(IF(sku.description LIKE '% Instance Core %', usage.amount_in_pricing_units, 0)) AS vcpu_hours,
+  SUM(IF(sku.description LIKE '% Instance Ram %', usage.amount_in_pricing_units, 0)) AS memory_gb_hours
+FROM \`${table}\`
+WHERE service.description = 'Compute Engine'
+  AND project.id = @project
+  AND usage_start_time >= @start AND usage_start_time < @end
+  AND REGEXP_CONTAINS(sku.description, r'^\\w+ (Predefined )?Instance (Core|Ram) running in ')
+GROUP BY region, family`;
+
+const InsertedCommitmentSchema = z.object({
+  plan: z.string().nullish(),
+  type: z.string().nullish(),
+  resources: z.array(ResourceSchema).default([]),
+});
+
+const recommendationOf = (
+  region: string,
+  recommendation: Recommendation,
+): CommitmentRecommendation => {
+  const operation = operationsOf(recommendation).find(({ action }) => action === 'insert');
+  const insert = operation?.value ? InsertedCommitmentSchema.parse(operation.value) : undefined;
+  const savings = monthlySavings(recommendation);
+  const discount = insert?.plan ? PLAN_DISCOUNTS[insert.plan] : undefined;
+  return {
+    name: recommendation.name,
+    region,
+    description: recommendation.description ?? null,
+    plan: insert?.plan ?? null,
+    family: insert?.type ? commitmentFamily(insert.type) : null,
+    ...amountsOf(insert?.resources ?? []),
+    monthlySavings: savings?.amount ?? null,
+    currency: savings?.currency ?? null,
+    breakEvenUtilization: discount !== undefined ? round(1 - discount) : null,
+  };
+};
+
+/**
+ * Reports the utilization and coverage of a project's committed use discounts
+ * and recommended additional commitments.
+ *
+ * Usage comes from the billing export, so utilization and coverage are only
+ * reported when one is configured.
+ */
+export const analyzeCommitments = async (
+  gcloud: GcloudExecutable,
+  options: { project: string; regions?: string[]; days: number; now?: Date },
+  billingExport?: { api: GoogleApiClient; config: BillingExportConfig },
+): Promise<CommitmentAnalysis> => {
+  const { project, days } = options;
+  const now = options.now ?? new Date();
+  const notes: string[] = [];
+
+  const commitments = z
+    .array(CommitmentSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'compute',
+        'commitments',
+        'list',
+        `--project=${project}`,
+        '--filter=status=ACTIVE',
+        '--format=json',
+      ]),
+    )
+    .map((commitment) => {
+      const end = commitment.endTimestamp ? new Date(commitment.endTimestamp) : null;
+      return {
+        name: commitment.name,
+        // Commitments list the region as a URL.
+        region: commitment.region.split('/').at(-1) ?? commitment.region,
+        family: commitmentFamily(commitment.type),
+        plan: commitment.plan,
+        ...amountsOf(commitment.resources),
+        endTimestamp: commitment.endTimestamp ?? null,
+        daysRemaining: end
+          ? Math.max(0, Math.ceil((end.getTime() - now.getTime()) / DAY_MS))
+          : null,
+      };
+    });
+
+  const averages = new Map<string, { vcpus: number; memoryGb: number }>();
+  if (billingExport) {
+    const hours = days * 24;
+    const rows = await queryBillingExport(
+      billingExport.api,
+      billingExport.config,
+      averageUsageQuery(billingExport.config.table),
+      [
+        { name: 'project', parameterType: { type: 'STRING' }, parameterValue: { value: project } },
+        timestampParameter('start', new Date(now.getTime() - days * DAY_MS)),
+        timestampParameter('end', now),
+      ],
+    );
+    for (const [region, family, vcpuHours, memoryGbHours] of rows) {
+      averages.set(`${region}/${family}`, {
+        vcpus: round(Number(vcpuHours ?? 0) / hours),
+        memoryGb: round(Number(memoryGbHours ?? 0) / hours),
+      });
+    }
+  } else {
+    notes.push('Utilization and coverage are only reported when "billingExport" is configured.');
+  }
+
+  const keys = new Set([
+    ...commitments.map((commitment) => `${commitment.region}/${commitment.family}`),
+    ...averages.keys(),
+  ]);
+  const usage = [...keys].sort().map((key): CommitmentUsage => {
+    const [region = '', family = ''] = key.split('/');
+    const committed = commitments.filter(
+      (commitment) => commitment.region === region && commitment.family === family,
+    );
+    const committedVcpus = committed.reduce((sum, commitment) => sum + commitment.vcpus, 0);
+    const committedMemoryGb = committed.reduce((sum, commitment) => sum + commitment.memoryGb, 0);
+    const average = billingExport ? (averages.get(key) ?? { vcpus: 0, memoryGb: 0 }) : undefined;
+    // Usage up to the committed amount is covered by the commitment.
+    const coveredVcpus = average && Math.min(average.vcpus, committedVcpus);
+    const coveredMemoryGb = average && Math.min(average.memoryGb, committedMemoryGb);
+    return {
+      region,
+      family,
+      committedVcpus,
+      committedMemoryGb,
+      averageVcpus: average?.vcpus ?? null,
+      averageMemoryGb: average?.memoryGb ?? null,
+      vcpuUtilization: average ? ratio(coveredVcpus ?? 0, committedVcpus) : null,
+      vcpuCoverage: average ? ratio(coveredVcpus ?? 0, average.vcpus) : null,
+      memoryUtilization: average ? ratio(coveredMemoryGb ?? 0, committedMemoryGb) : null,
+      memoryCoverage: average ? ratio(coveredMemoryGb ?? 0, average.memoryGb) : null,
+    };
+  });
+
+  const regions = options.regions?.length
+    ? options.regions
+    : [...new Set(usage.map((entry) => entry.region))];
+  if (regions.length === 0) {
+    notes.push('No commitments or usage found. Pass regions to get recommendations.');
+  }
+  const recommendations = (
+    await Promise.all(
+      regions.map(async (region) =>
+        (await listRecommendations(gcloud, project, region, COMMITMENT_RECOMMENDER)).map(
+          (recommendation) => recommendationOf(region, recommendation),
+        ),
+      ),
+    )
+  ).flat();
+
+  return { project, periodDays: days, commitments, usage, recommendations, notes };
+};
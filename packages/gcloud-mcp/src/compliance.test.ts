@@ -0,0 +1,201 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { matchesSubnet, runComplianceScan, validateCompliance } from './compliance.js';
+
+vi.mock('./gcloud.js');
+
+const ZONE = 'https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a';
+const SUBNET = 'https://www.googleapis.com/compute/v1/projects/p/regions/us-central1/subnetworks';
+
+const INSTANCES = [
+  {
+    name: 'web-1',
+    zone: ZONE,
+    serviceAccounts: [{ email: '123-compute@developer.gserviceaccount.com' }],
+    networkInterfaces: [
+      {
+        name: 'nic0',
+        subnetwork: `${SUBNET}/private`,
+        accessConfigs: [{ name: 'External NAT', natIP: '203.0.113.1' }],
+      },
+    ],
+  },
+  {
+    name: 'web-2',
+    zone: ZONE,
+    metadata: { items: [{ key: 'enable-oslogin', value: 'false' }] },
+    serviceAccounts: [{ email: 'web@p.iam.gserviceaccount.com' }],
+    networkInterfaces: [
+      {
+        name: 'nic0',
+        subnetwork: `${SUBNET}/public`,
+        accessConfigs: [{ name: 'External NAT', natIP: '203.0.113.2' }],
+      },
+    ],
+  },
+];
+
+const OUTPUTS: Record<string, unknown> = {
+  'compute instances list': INSTANCES,
+  'storage buckets list': [
+    { name: 'logs', uniform_bucket_level_access: true },
+    { name: 'legacy', uniform_bucket_level_access: false },
+  ],
+  'compute project-info describe': {
+    commonInstanceMetadata: { items: [{ key: 'enable-oslogin', value: 'TRUE' }] },
+  },
+  'resource-manager org-policies describe': { booleanPolicy: {} },
+};
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+beforeEach(() => {
+  mockedGcloud = {
+    lint: vi.fn(),
+    invoke: vi.fn(async (args: string[]) => {
+      const command = Object.keys(OUTPUTS).find((c) => args.join(' ').startsWith(c));
+      return command
+        ? { code: 0, stdout: JSON.stringify(OUTPUTS[command]), stderr: '' }
+        : { code: 1, stdout: '', stderr: 'unexpected command' };
+    }),
+  };
+});
+
+describe('runComplianceScan', () => {
+  const scan = (rules: Parameters<typeof runComplianceScan>[2]['rules']) =>
+    runComplianceScan(
+      mockedGcloud,
+      {
+        privateSubnets: ['private'],
+        requiredOrgPolicies: ['constraints/iam.disableServiceAccountKeyCreation'],
+      },
+      { projects: ['p'], rules },
+    );
+
+  test('reports buckets without uniform access', async () => {
+    const { results } = await scan(['uniform-bucket-access']);
+
+    expect(results).toEqual([
+      {
+        project: 'p',
+        rule: 'uniform-bucket-access',
+        status: 'fail',
+        findings: [
+          {
+            resource: 'gs://legacy',
+            detail: 'Uniform bucket-level access is disabled.',
+            remediation:
+              'gcloud storage buckets update gs://legacy --uniform-bucket-level-access --project=p',
+          },
+        ],
+      },
+    ]);
+  });
+
+  test('reports instances running as the default service account', async () => {
+    const { results } = await scan(['no-default-service-account']);
+
+    expect(results[0]?.findings.map(({ resource }) => resource)).toEqual(['us-central1-a/web-1']);
+  });
+
+  test('reports instances that disable OS Login', async () => {
+    const { results } = await scan(['os-login']);
+
+    expect(results[0]?.findings).toEqual([
+      expect.objectContaining({
+        resource: 'us-central1-a/web-2',
+        remediation:
+          'gcloud compute instances remove-metadata web-2 --zone=us-central1-a --keys=enable-oslogin --project=p',
+      }),
+    ]);
+  });
+
+  test('reports external IPs on private subnets only', async () => {
+    const { results } = await scan(['no-public-ips']);
+
+    expect(results[0]?.findings).toEqual([
+      expect.objectContaining({
+        resource: 'us-central1-a/web-1',
+        remediation:
+          'gcloud compute instances delete-access-config web-1 --zone=us-central1-a --network-interface=nic0 --access-config-name="External NAT" --project=p',
+      }),
+    ]);
+  });
+
+  test('reports org policies that are not enforced', async () => {
+    const { results } = await scan(['required-org-policies']);
+
+    expect(results[0]).toMatchObject({
+      status: 'fail',
+      findings: [
+        {
+          remediation:
+            'gcloud resource-manager org-policies enable-enforce constraints/iam.disableServiceAccountKeyCreation --project=p',
+        },
+      ],
+    });
+  });
+
+  test('lists the instances of a project once', async () => {
+    await scan(['no-default-service-account', 'os-login', 'no-public-ips']);
+
+    const listings = vi
+      .mocked(mockedGcloud.invoke)
+      .mock.calls.filter(([args]) => args.join(' ').startsWith('compute instances list'));
+    expect(listings).toHaveLength(1);
+  });
+
+  test('reports unconfigured rules as not applicable', async () => {
+    const report = await runComplianceScan(
+      mockedGcloud,
+      {},
+      { projects: ['p'], rules: ['no-public-ips'] },
+    );
+
+    expect(report.results[0]?.status).toBe('not-applicable');
+    expect(report.summary).toEqual({ pass: 0, fail: 0, 'not-applicable': 1, error: 0 });
+  });
+
+  test('reports rules that fail to run as errors', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 1,
+      stdout: '',
+      stderr: 'API not enabled',
+    });
+
+    const { results } = await scan(['uniform-bucket-access']);
+
+    expect(results[0]).toMatchObject({ status: 'error', message: expect.stringContaining('API') });
+  });
+});
+
+describe('matchesSubnet', () => {
+  test('matches subnets by name or by region and name', () => {
+    expect(matchesSubnet(`${SUBNET}/private`, ['private'])).toBe(true);
+    expect(matchesSubnet(`${SUBNET}/private`, ['us-central1/private'])).toBe(true);
+    expect(matchesSubnet(`${SUBNET}/private`, ['us-east1/private'])).toBe(false);
+  });
+});
+
+describe('validateCompliance', () => {
+  test('rejects unknown rules', () => {
+    expect(validateCompliance({ rules: ['no-root' as 'os-login'] })).toContain('Unknown');
+    expect(validateCompliance({ rules: ['os-login'] })).toBe(undefined);
+  });
+});
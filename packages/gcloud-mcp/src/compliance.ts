@@ -0,0 +1,324 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson, lastSegment } from './gcloud_json.js';
+
+export const COMPLIANCE_RULES = [
+  'uniform-bucket-access',
+  'no-default-service-account',
+  'os-login',
+  'no-public-ips',
+  'required-org-policies',
+] as const;
+
+export type ComplianceRule = (typeof COMPLIANCE_RULES)[number];
+
+/** The gcloud commands each rule runs. */
+export const COMPLIANCE_RULE_COMMANDS: Record<ComplianceRule, string[]> = {
+  'uniform-bucket-access': ['storage buckets list'],
+  'no-default-service-account': ['compute instances list'],
+  'os-login': ['compute project-info describe', 'compute instances list'],
+  'no-public-ips': ['compute instances list'],
+  'required-org-policies': ['resource-manager org-policies describe'],
+};
+
+export interface ComplianceConfig {
+  /** The rules checked when a scan does not name any. Defaults to every rule. */
+  rules?: ComplianceRule[];
+  /** Subnets, as `SUBNET` or `REGION/SUBNET`, on which instances must not have external IPs. */
+  privateSubnets?: string[];
+  /**
+   * Boolean org policy constraints that must be enforced, e.g.
+   * `constraints/compute.requireOsLogin`.
+   */
+  requiredOrgPolicies?: string[];
+}
+
+export type ComplianceStatus = 'pass' | 'fail' | 'not-applicable' | 'error';
+
+export interface ComplianceFinding {
+  resource: string;
+  detail: string;
+  /** The command that fixes the finding. It is not run by the scan. */
+  remediation: string;
+}
+
+export interface RuleResult {
+  project: string;
+  rule: ComplianceRule;
+  status: ComplianceStatus;
+  findings: ComplianceFinding[];
+  message?: string;
+}
+
+export interface ComplianceReport {
+  projects: string[];
+  summary: Record<ComplianceStatus, number>;
+  results: RuleResult[];
+}
+
+export const validateCompliance = (config: ComplianceConfig): string | undefined => {
+  const unknown = (config.rules ?? []).find((rule) => !COMPLIANCE_RULES.includes(rule));
+  if (unknown) {
+    return `Unknown compliance rule "${unknown}". Valid rules are: ${COMPLIANCE_RULES.join(', ')}.`;
+  }
+  const constraint = (config.requiredOrgPolicies ?? []).find(
+    (name) => !name.startsWith('constraints/'),
+  );
+  if (constraint) {
+    return `Required org policies must be constraint names like "constraints/compute.requireOsLogin": ${constraint}`;
+  }
+  return undefined;
+};
+
+const MetadataSchema = z
+  .object({ items: z.array(z.object({ key: z.string(), value: z.string().nullish() })).nullish() })
+  .nullish();
+
+const InstanceSchema = z.object({
+  name: z.string(),
+  zone: z.string(),
+  metadata: MetadataSchema,
+  serviceAccounts: z.array(z.object({ email: z.string() })).nullish(),
+  networkInterfaces: z
+    .array(
+      z.object({
+        name: z.string(),
+        subnetwork: z.string().nullish(),
+        accessConfigs: z
+          .array(z.object({ name: z.string().nullish(), natIP: z.string().nullish() }))
+          .nullish(),
+      }),
+    )
+    .nullish(),
+});
+
+type Instance = z.infer<typeof InstanceSchema>;
+
+const BucketSchema = z.object({
+  name: z.string(),
+  uniform_bucket_level_access: z.boolean().nullish(),
+});
+
+const OrgPolicySchema = z.object({
+  booleanPolicy: z.object({ enforced: z.boolean().nullish() }).nullish(),
+});
+
+const osLoginOf = (metadata: z.infer<typeof MetadataSchema>): boolean | undefined => {
+  const value = metadata?.items?.find(({ key }) => key === 'enable-oslogin')?.value;
+  return value === undefined || value === null ? undefined : value.toUpperCase() === 'TRUE';
+};
+
+const isDefaultServiceAccount = (email: string) =>
+  email.endsWith('-compute@developer.gserviceaccount.com');
+
+/** Returns whether a subnetwork URL is one of the configured subnets. */
+export const matchesSubnet = (subnetwork: string, subnets: string[]) =>
+  subnets.some((subnet) => {
+    const [region, name] = subnet.includes('/') ? subnet.split('/') : [undefined, subnet];
+    return region
+      ? subnetwork.endsWith(`/regions/${region}/subnetworks/${name}`)
+      : lastSegment(subnetwork) === name;
+  });
+
+type RuleCheck = (project: string) => Promise<ComplianceFinding[] | { notApplicable: string }>;
+
+/**
+ * Checks projects against the compliance rules and returns a result per rule
+ * per project, with the command that fixes each finding. Nothing is changed.
+ */
+export const runComplianceScan = async (
+  gcloud: GcloudExecutable,
+  config: ComplianceConfig,
+  options: { projects: string[]; rules: readonly ComplianceRule[] },
+): Promise<ComplianceReport> => {
+  const { projects, rules } = options;
+  // Several rules inspect the instances of a project, so they are listed once.
+  const instanceLists = new Map<string, Promise<Instance[]>>();
+  const instancesOf = (project: string) => {
+    let instances = instanceLists.get(project);
+    if (!instances) {
+      instances = invokeJson(gcloud, [
+        'compute',
+        'instances',
+        'list',
+        `--project=${project}`,
+        '--format=json',
+      ]).then((value) => z.array(InstanceSchema).parse(value));
+      instanceLists.set(project, instances);
+    }
+    return instances;
+  };
+
+  const checks: Record<ComplianceRule, RuleCheck> = {
+    'uniform-bucket-access': async (project) => {
+      const buckets = z
+        .array(BucketSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'storage',
+            'buckets',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,uniform_bucket_level_access)',
+          ]),
+        );
+      return buckets
+        .filter((bucket) => !bucket.uniform_bucket_level_access)
+        .map(({ name }) => ({
+          resource: `gs://${name}`,
+          detail: 'Uniform bucket-level access is disabled.',
+          remediation: `gcloud storage buckets update gs://${name} --uniform-bucket-level-access --project=${project}`,
+        }));
+    },
+    'no-default-service-account': async (project) =>
+      (await instancesOf(project)).flatMap((instance) => {
+        const email = instance.serviceAccounts?.find((sa) => isDefaultServiceAccount(sa.email));
+        if (!email) {
+          return [];
+        }
+        const zone = lastSegment(instance.zone);
+        return [
+          {
+            resource: `${zone}/${instance.name}`,
+            detail: `Runs as the default compute service account ${email.email}.`,
+            remediation: `gcloud compute instances set-service-account ${instance.name} --zone=${zone} --service-account=SERVICE_ACCOUNT_EMAIL --project=${project}`,
+          },
+        ];
+      }),
+    'os-login': async (project) => {
+      const info = z
+        .object({ commonInstanceMetadata: MetadataSchema })
+        .parse(
+          await invokeJson(gcloud, [
+            'compute',
+            'project-info',
+            'describe',
+            `--project=${project}`,
+            '--format=json(commonInstanceMetadata)',
+          ]),
+        );
+      const findings: ComplianceFinding[] = [];
+      if (!osLoginOf(info.commonInstanceMetadata)) {
+        findings.push({
+          resource: `projects/${project}`,
+          detail: 'OS Login is not enabled in the project metadata.',
+          remediation: `gcloud compute project-info add-metadata --metadata=enable-oslogin=TRUE --project=${project}`,
+        });
+      }
+      // Instance metadata overrides the project metadata.
+      for (const instance of await instancesOf(project)) {
+        if (osLoginOf(instance.metadata) === false) {
+          const zone = lastSegment(instance.zone);
+          findings.push({
+            resource: `${zone}/${instance.name}`,
+            detail: 'OS Login is disabled in the instance metadata.',
+            remediation: `gcloud compute instances remove-metadata ${instance.name} --zone=${zone} --keys=enable-oslogin --project=${project}`,
+          });
+        }
+      }
+      return findings;
+    },
+    'no-public-ips': async (project) => {
+      const subnets = config.privateSubnets ?? [];
+      if (subnets.length === 0) {
+        return { notApplicable: 'No private subnets are configured in compliance.privateSubnets.' };
+      }
+      return (await instancesOf(project)).flatMap((instance) =>
+        (instance.networkInterfaces ?? [])
+          .filter((nic) => nic.subnetwork && matchesSubnet(nic.subnetwork, subnets))
+          .flatMap((nic) =>
+            (nic.accessConfigs ?? [])
+              .filter((access) => access.natIP)
+              .map((access) => {
+                const zone = lastSegment(instance.zone);
+                return {
+                  resource: `${zone}/${instance.name}`,
+                  detail: `Has the external IP ${access.natIP} on subnet ${lastSegment(nic.subnetwork ?? '')}.`,
+                  remediation: `gcloud compute instances delete-access-config ${instance.name} --zone=${zone} --network-interface=${nic.name} --access-config-name="${access.name ?? 'external-nat'}" --project=${project}`,
+                };
+              }),
+          ),
+      );
+    },
+    'required-org-policies': async (project) => {
+      const constraints = config.requiredOrgPolicies ?? [];
+      if (constraints.length === 0) {
+        return {
+          notApplicable: 'No org policies are configured in compliance.requiredOrgPolicies.',
+        };
+      }
+      const policies = await Promise.all(
+        constraints.map(async (constraint) => ({
+          constraint,
+          policy: OrgPolicySchema.parse(
+            await invokeJson(gcloud, [
+              'resource-manager',
+              'org-policies',
+              'describe',
+              constraint,
+              `--project=${project}`,
+              '--effective',
+              '--format=json',
+            ]),
+          ),
+        })),
+      );
+      return policies
+        .filter(({ policy }) => !policy.booleanPolicy?.enforced)
+        .map(({ constraint }) => ({
+          resource: `projects/${project}`,
+          detail: `The constraint ${constraint} is not enforced.`,
+          remediation: `gcloud resource-manager org-policies enable-enforce ${constraint} --project=${project}`,
+        }));
+    },
+  };
+
+  const results = await Promise.all(
+    projects.flatMap((project) =>
+      rules.map(async (rule): Promise<RuleResult> => {
+        try {
+          const outcome = await checks[rule](project);
+          if (!Array.isArray(outcome)) {
+            return {
+              project,
+              rule,
+              status: 'not-applicable',
+              findings: [],
+              message: outcome.notApplicable,
+            };
+          }
+          return { project, rule, status: outcome.length > 0 ? 'fail' : 'pass', findings: outcome };
+        } catch (e: unknown) {
+          const message = e instanceof Error ? e.message : String(e);
+          return { project, rule, status: 'error', findings: [], message };
+        }
+      }),
+    ),
+  );
+  const summary: Record<ComplianceStatus, number> = {
+    pass: 0,
+    fail: 0,
+    'not-applicable': 0,
+    error: 0,
+  };
+  for (const { status } of results) {
+    summary[status] += 1;
+  }
+  return { projects, summary, results };
+};
@@ -0,0 +1,92 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, test, expect, vi } from 'vitest';
+import { GcloudExecutable } from './gcloud.js';
+import { listComponents, missingComponents, requiredComponents } from './components.js';
+
+const COMPONENTS_JSON = JSON.stringify([
+  {
+    id: 'kubectl',
+    name: 'kubectl',
+    state: { name: 'Installed' },
+    current_version_string: '1.30.5',
+    latest_version_string: '1.30.5',
+  },
+  {
+    id: 'gke-gcloud-auth-plugin',
+    name: 'gke-gcloud-auth-plugin',
+    state: { name: 'Not Installed' },
+    current_version_string: null,
+    latest_version_string: '0.5.9',
+  },
+  {
+    id: 'beta',
+    name: 'gcloud Beta Commands',
+    state: { name: 'Update Available' },
+    current_version_string: '2024.10.01',
+    latest_version_string: '2024.11.08',
+  },
+]);
+
+describe('listComponents', () => {
+  test('parses the component states', async () => {
+    const gcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: COMPONENTS_JSON, stderr: '' }),
+    } as unknown as GcloudExecutable;
+
+    const components = await listComponents(gcloud);
+
+    expect(gcloud.invoke).toHaveBeenCalledWith(['components', 'list', '--format=json']);
+    expect(components.map(({ id, state }) => [id, state])).toEqual([
+      ['kubectl', 'installed'],
+      ['gke-gcloud-auth-plugin', 'not-installed'],
+      ['beta', 'update-available'],
+    ]);
+    expect(components[1]).toMatchObject({ currentVersion: null, latestVersion: '0.5.9' });
+  });
+});
+
+describe('requiredComponents', () => {
+  test('detects the components of gcloud command groups', () => {
+    expect(requiredComponents('gcloud container clusters get-credentials c --zone=z')).toEqual([
+      'gke-gcloud-auth-plugin',
+      'kubectl',
+    ]);
+    expect(requiredComponents('beta emulators pubsub start')).toEqual(['beta', 'pubsub-emulator']);
+    expect(requiredComponents('compute instances list')).toEqual([]);
+  });
+
+  test('detects the components of other executables', () => {
+    expect(requiredComponents('kubectl get pods')).toEqual(['kubectl']);
+    expect(requiredComponents('bq query "SELECT 1"')).toEqual(['bq']);
+  });
+});
+
+describe('missingComponents', () => {
+  test('returns required components that are not installed', async () => {
+    const gcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: COMPONENTS_JSON, stderr: '' }),
+    } as unknown as GcloudExecutable;
+    const components = await listComponents(gcloud);
+
+    expect(missingComponents(components, ['gke-gcloud-auth-plugin', 'kubectl', 'beta'])).toEqual([
+      'gke-gcloud-auth-plugin',
+    ]);
+  });
+});
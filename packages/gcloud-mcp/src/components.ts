@@ -0,0 +1,119 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+
+export type ComponentState = 'installed' | 'not-installed' | 'update-available';
+
+export interface Component {
+  id: string;
+  name: string;
+  state: ComponentState;
+  currentVersion: string | null;
+  latestVersion: string | null;
+}
+
+const ComponentListSchema = z.array(
+  z.object({
+    id: z.string(),
+    name: z.string(),
+    state: z.object({ name: z.string() }),
+    current_version_string: z.string().nullable().optional(),
+    latest_version_string: z.string().nullable().optional(),
+  }),
+);
+
+const STATES: Record<string, ComponentState> = {
+  Installed: 'installed',
+  'Not Installed': 'not-installed',
+  'Update Available': 'update-available',
+};
+
+export const COMPONENT_ID = /^[a-z0-9][a-z0-9-]*$/;
+
+// Components needed by gcloud command groups, after any release track.
+const COMMAND_COMPONENTS: Array<{ prefix: string[]; components: string[] }> = [
+  {
+    prefix: ['container', 'clusters', 'get-credentials'],
+    components: ['gke-gcloud-auth-plugin', 'kubectl'],
+  },
+  { prefix: ['emulators', 'bigtable'], components: ['bigtable'] },
+  { prefix: ['emulators', 'datastore'], components: ['cloud-datastore-emulator'] },
+  { prefix: ['emulators', 'firestore'], components: ['cloud-firestore-emulator'] },
+  { prefix: ['emulators', 'pubsub'], components: ['pubsub-emulator'] },
+  { prefix: ['emulators', 'spanner'], components: ['cloud-spanner-emulator'] },
+];
+
+// Tools shipped as components with their own executables.
+const EXECUTABLE_COMPONENTS: Record<string, string> = {
+  bq: 'bq',
+  cbt: 'cbt',
+  'gke-gcloud-auth-plugin': 'gke-gcloud-auth-plugin',
+  gsutil: 'gsutil',
+  kubectl: 'kubectl',
+  skaffold: 'skaffold',
+};
+
+/** Lists the components of the gcloud installation and their state. */
+export const listComponents = async (gcloud: GcloudExecutable): Promise<Component[]> => {
+  const args = ['components', 'list', '--format=json'];
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  return ComponentListSchema.parse(JSON.parse(stdout)).map((component) => ({
+    id: component.id,
+    name: component.name,
+    state: STATES[component.state.name] ?? 'not-installed',
+    currentVersion: component.current_version_string ?? null,
+    latestVersion: component.latest_version_string ?? null,
+  }));
+};
+
+/**
+ * Returns the components a command needs, for gcloud commands with or without
+ * the `gcloud` prefix and for the commands of tools such as kubectl or bq.
+ */
+export const requiredComponents = (command: string): string[] => {
+  const words = command.trim().split(/\s+/);
+  const executable = EXECUTABLE_COMPONENTS[words[0] ?? ''];
+  if (executable) {
+    return [executable];
+  }
+  const groups = (words[0] === 'gcloud' ? words.slice(1) : words).filter(
+    (word) => !word.startsWith('-'),
+  );
+  const required = new Set<string>();
+  // Release tracks are components themselves, and prefix the other groups.
+  const track = groups[0] === 'alpha' || groups[0] === 'beta' ? groups[0] : undefined;
+  if (track) {
+    required.add(track);
+  }
+  const rest = track ? groups.slice(1) : groups;
+  for (const { prefix, components } of COMMAND_COMPONENTS) {
+    if (prefix.every((word, i) => rest[i] === word)) {
+      components.forEach((component) => required.add(component));
+    }
+  }
+  return [...required];
+};
+
+/** Returns the required components that are not installed. */
+export const missingComponents = (components: Component[], required: string[]): string[] =>
+  required.filter(
+    (id) => components.find((component) => component.id === id)?.state === 'not-installed',
+  );
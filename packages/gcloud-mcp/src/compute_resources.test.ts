@@ -0,0 +1,192 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import {
+  detailInstance,
+  listInstancesArgs,
+  listServicesArgs,
+  summarizeClusters,
+  summarizeInstances,
+  summarizeServices,
+} from './compute_resources.js';
+
+const COMPUTE = 'https://www.googleapis.com/compute/v1/projects/p';
+
+const INSTANCE = {
+  name: 'vm-1',
+  zone: `${COMPUTE}/zones/us-central1-a`,
+  status: 'RUNNING',
+  machineType: `${COMPUTE}/zones/us-central1-a/machineTypes/e2-medium`,
+  cpuPlatform: 'Intel Broadwell',
+  creationTimestamp: '2025-01-01T00:00:00.000-07:00',
+  labels: { env: 'prod' },
+  tags: { items: ['http-server'] },
+  scheduling: { provisioningModel: 'SPOT' },
+  networkInterfaces: [
+    {
+      network: `${COMPUTE}/global/networks/default`,
+      subnetwork: `${COMPUTE}/regions/us-central1/subnetworks/default`,
+      networkIP: '10.0.0.2',
+      accessConfigs: [{ natIP: '34.1.2.3' }],
+    },
+  ],
+  disks: [{ deviceName: 'vm-1', boot: true, diskSizeGb: '10', source: `${COMPUTE}/disks/vm-1` }],
+  serviceAccounts: [{ email: 'sa@p.iam.gserviceaccount.com' }],
+};
+
+describe('listInstancesArgs', () => {
+  test('sets only the given flags', () => {
+    expect(listInstancesArgs({ filter: 'status=RUNNING', limit: 5 })).toEqual([
+      'compute',
+      'instances',
+      'list',
+      '--filter=status=RUNNING',
+      '--limit=5',
+      '--format=json',
+    ]);
+  });
+
+  test('narrows down to a zone', () => {
+    expect(listInstancesArgs({ project: 'p', location: 'us-central1-a' })).toEqual([
+      'compute',
+      'instances',
+      'list',
+      '--project=p',
+      '--zones=us-central1-a',
+      '--format=json',
+    ]);
+  });
+});
+
+describe('listServicesArgs', () => {
+  test('narrows down to a region', () => {
+    expect(listServicesArgs({ location: 'europe-west1' })).toContain('--region=europe-west1');
+  });
+});
+
+describe('summarizeInstances', () => {
+  test('returns the names of URLs and the first IP addresses', () => {
+    expect(summarizeInstances([INSTANCE, { name: 'vm-2' }])).toEqual([
+      {
+        name: 'vm-1',
+        zone: 'us-central1-a',
+        status: 'RUNNING',
+        machineType: 'e2-medium',
+        internalIp: '10.0.0.2',
+        externalIp: '34.1.2.3',
+        labels: { env: 'prod' },
+      },
+      {
+        name: 'vm-2',
+        zone: null,
+        status: null,
+        machineType: null,
+        internalIp: null,
+        externalIp: null,
+        labels: {},
+      },
+    ]);
+  });
+});
+
+describe('detailInstance', () => {
+  test('includes network interfaces, disks and service accounts', () => {
+    expect(detailInstance(INSTANCE)).toMatchObject({
+      cpuPlatform: 'Intel Broadwell',
+      provisioningModel: 'SPOT',
+      tags: ['http-server'],
+      networkInterfaces: [
+        {
+          network: 'default',
+          subnetwork: 'default',
+          internalIp: '10.0.0.2',
+          externalIp: '34.1.2.3',
+        },
+      ],
+      disks: [{ name: 'vm-1', deviceName: 'vm-1', boot: true, sizeGb: 10 }],
+      serviceAccounts: ['sa@p.iam.gserviceaccount.com'],
+    });
+  });
+});
+
+describe('summarizeServices', () => {
+  test('reads the Knative resource of a service', () => {
+    const service = {
+      metadata: {
+        name: 'api',
+        labels: { 'cloud.googleapis.com/location': 'us-central1' },
+        annotations: { 'serving.knative.dev/lastModifier': 'dev@example.com' },
+      },
+      spec: { template: { spec: { containers: [{ image: 'gcr.io/p/api:1' }] } } },
+      status: {
+        url: 'https://api-abc.a.run.app',
+        latestReadyRevisionName: 'api-00002',
+        conditions: [{ type: 'Ready', status: 'False' }],
+      },
+    };
+
+    expect(summarizeServices([service])).toEqual([
+      {
+        name: 'api',
+        region: 'us-central1',
+        url: 'https://api-abc.a.run.app',
+        ready: false,
+        latestReadyRevision: 'api-00002',
+        image: 'gcr.io/p/api:1',
+        lastModifier: 'dev@example.com',
+      },
+    ]);
+  });
+});
+
+describe('summarizeClusters', () => {
+  test('reports Autopilot clusters and their release channel', () => {
+    const cluster = {
+      name: 'prod',
+      location: 'us-central1',
+      status: 'RUNNING',
+      currentMasterVersion: '1.30.5-gke.1',
+      currentNodeCount: 3,
+      endpoint: '10.1.0.2',
+      autopilot: { enabled: true },
+      releaseChannel: { channel: 'REGULAR' },
+    };
+
+    expect(summarizeClusters([cluster, { name: 'dev' }])).toEqual([
+      {
+        name: 'prod',
+        location: 'us-central1',
+        status: 'RUNNING',
+        version: '1.30.5-gke.1',
+        nodeCount: 3,
+        autopilot: true,
+        releaseChannel: 'REGULAR',
+        endpoint: '10.1.0.2',
+      },
+      {
+        name: 'dev',
+        location: null,
+        status: null,
+        version: null,
+        nodeCount: null,
+        autopilot: false,
+        releaseChannel: null,
+        endpoint: null,
+      },
+    ]);
+  });
+});
@@ -0,0 +1,271 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+
+/** Lists resources of a kind, optionally narrowed down to a zone or region. */
+export interface ListQuery {
+  project?: string | undefined;
+  location?: string | undefined;
+  filter?: string | undefined;
+  limit?: number | undefined;
+}
+
+// Resources are returned as full URLs, e.g. of the zone or machine type.
+const basename = (url: string | null | undefined) => (url ? (url.split('/').pop() ?? url) : null);
+
+const InstanceSchema = z.object({
+  name: z.string(),
+  zone: z.string().nullish(),
+  status: z.string().nullish(),
+  machineType: z.string().nullish(),
+  cpuPlatform: z.string().nullish(),
+  creationTimestamp: z.string().nullish(),
+  labels: z.record(z.string()).nullish(),
+  tags: z.object({ items: z.array(z.string()).nullish() }).nullish(),
+  scheduling: z.object({ provisioningModel: z.string().nullish() }).nullish(),
+  networkInterfaces: z
+    .array(
+      z.object({
+        network: z.string().nullish(),
+        subnetwork: z.string().nullish(),
+        networkIP: z.string().nullish(),
+        accessConfigs: z.array(z.object({ natIP: z.string().nullish() })).nullish(),
+      }),
+    )
+    .nullish(),
+  disks: z
+    .array(
+      z.object({
+        deviceName: z.string().nullish(),
+        boot: z.boolean().nullish(),
+        diskSizeGb: z.string().nullish(),
+        source: z.string().nullish(),
+      }),
+    )
+    .nullish(),
+  serviceAccounts: z.array(z.object({ email: z.string() })).nullish(),
+});
+type Instance = z.infer<typeof InstanceSchema>;
+
+const ServiceSchema = z.object({
+  metadata: z.object({
+    name: z.string(),
+    labels: z.record(z.string()).nullish(),
+    annotations: z.record(z.string()).nullish(),
+  }),
+  spec: z
+    .object({
+      template: z
+        .object({
+          spec: z.object({ containers: z.array(z.object({ image: z.string() })).nullish() }),
+        })
+        .nullish(),
+    })
+    .nullish(),
+  status: z
+    .object({
+      url: z.string().nullish(),
+      latestReadyRevisionName: z.string().nullish(),
+      conditions: z.array(z.object({ type: z.string(), status: z.string() })).nullish(),
+    })
+    .nullish(),
+});
+
+const ClusterSchema = z.object({
+  name: z.string(),
+  location: z.string().nullish(),
+  status: z.string().nullish(),
+  currentMasterVersion: z.string().nullish(),
+  currentNodeCount: z.number().nullish(),
+  endpoint: z.string().nullish(),
+  autopilot: z.object({ enabled: z.boolean().nullish() }).nullish(),
+  releaseChannel: z.object({ channel: z.string().nullish() }).nullish(),
+});
+
+export const InstanceSummarySchema = z.object({
+  name: z.string(),
+  zone: z.string().nullable(),
+  status: z.string().nullable(),
+  machineType: z.string().nullable(),
+  internalIp: z.string().nullable(),
+  externalIp: z.string().nullable(),
+  labels: z.record(z.string()),
+});
+export type InstanceSummary = z.infer<typeof InstanceSummarySchema>;
+
+export const InstanceDetailsSchema = InstanceSummarySchema.extend({
+  cpuPlatform: z.string().nullable(),
+  createdAt: z.string().nullable(),
+  provisioningModel: z.string().nullable(),
+  tags: z.array(z.string()),
+  networkInterfaces: z.array(
+    z.object({
+      network: z.string().nullable(),
+      subnetwork: z.string().nullable(),
+      internalIp: z.string().nullable(),
+      externalIp: z.string().nullable(),
+    }),
+  ),
+  disks: z.array(
+    z.object({
+      name: z.string().nullable(),
+      deviceName: z.string().nullable(),
+      boot: z.boolean(),
+      sizeGb: z.number().nullable(),
+    }),
+  ),
+  serviceAccounts: z.array(z.string()),
+});
+export type InstanceDetails = z.infer<typeof InstanceDetailsSchema>;
+
+export const ServiceSummarySchema = z.object({
+  name: z.string(),
+  region: z.string().nullable(),
+  url: z.string().nullable(),
+  ready: z.boolean().nullable(),
+  latestReadyRevision: z.string().nullable(),
+  image: z.string().nullable(),
+  lastModifier: z.string().nullable(),
+});
+export type ServiceSummary = z.infer<typeof ServiceSummarySchema>;
+
+export const ClusterSummarySchema = z.object({
+  name: z.string(),
+  location: z.string().nullable(),
+  status: z.string().nullable(),
+  version: z.string().nullable(),
+  nodeCount: z.number().nullable(),
+  autopilot: z.boolean(),
+  releaseChannel: z.string().nullable(),
+  endpoint: z.string().nullable(),
+});
+export type ClusterSummary = z.infer<typeof ClusterSummarySchema>;
+
+const queryFlags = (query: ListQuery, locationFlag: string) => [
+  ...(query.project ? [`--project=${query.project}`] : []),
+  ...(query.location ? [`${locationFlag}=${query.location}`] : []),
+  ...(query.filter ? [`--filter=${query.filter}`] : []),
+  ...(query.limit !== undefined ? [`--limit=${query.limit}`] : []),
+  '--format=json',
+];
+
+export const listInstancesArgs = (query: ListQuery) => [
+  'compute',
+  'instances',
+  'list',
+  ...queryFlags(query, '--zones'),
+];
+
+export const describeInstanceArgs = (name: string, zone: string, project?: string) => [
+  'compute',
+  'instances',
+  'describe',
+  name,
+  `--zone=${zone}`,
+  ...(project ? [`--project=${project}`] : []),
+  '--format=json',
+];
+
+export const listServicesArgs = (query: ListQuery) => [
+  'run',
+  'services',
+  'list',
+  ...queryFlags(query, '--region'),
+];
+
+export const listClustersArgs = (query: ListQuery) => [
+  'container',
+  'clusters',
+  'list',
+  ...queryFlags(query, '--location'),
+];
+
+const summarizeInstance = (instance: Instance): InstanceSummary => {
+  const nic = instance.networkInterfaces?.[0];
+  return {
+    name: instance.name,
+    zone: basename(instance.zone),
+    status: instance.status ?? null,
+    machineType: basename(instance.machineType),
+    internalIp: nic?.networkIP ?? null,
+    externalIp: nic?.accessConfigs?.find((config) => config.natIP)?.natIP ?? null,
+    labels: instance.labels ?? {},
+  };
+};
+
+/** Summarizes the output of `gcloud compute instances list --format=json`. */
+export const summarizeInstances = (json: unknown): InstanceSummary[] =>
+  z.array(InstanceSchema).parse(json).map(summarizeInstance);
+
+/** Summarizes the output of `gcloud compute instances describe --format=json`. */
+export const detailInstance = (json: unknown): InstanceDetails => {
+  const instance = InstanceSchema.parse(json);
+  return {
+    ...summarizeInstance(instance),
+    cpuPlatform: instance.cpuPlatform ?? null,
+    createdAt: instance.creationTimestamp ?? null,
+    provisioningModel: instance.scheduling?.provisioningModel ?? null,
+    tags: instance.tags?.items ?? [],
+    networkInterfaces: (instance.networkInterfaces ?? []).map((nic) => ({
+      network: basename(nic.network),
+      subnetwork: basename(nic.subnetwork),
+      internalIp: nic.networkIP ?? null,
+      externalIp: nic.accessConfigs?.find((config) => config.natIP)?.natIP ?? null,
+    })),
+    disks: (instance.disks ?? []).map((disk) => ({
+      name: basename(disk.source),
+      deviceName: disk.deviceName ?? null,
+      boot: disk.boot ?? false,
+      sizeGb: disk.diskSizeGb ? Number(disk.diskSizeGb) : null,
+    })),
+    serviceAccounts: (instance.serviceAccounts ?? []).map((account) => account.email),
+  };
+};
+
+/** Summarizes the output of `gcloud run services list --format=json`. */
+export const summarizeServices = (json: unknown): ServiceSummary[] =>
+  z
+    .array(ServiceSchema)
+    .parse(json)
+    .map(({ metadata, spec, status }) => {
+      const ready = status?.conditions?.find((condition) => condition.type === 'Ready');
+      return {
+        name: metadata.name,
+        region: metadata.labels?.['cloud.googleapis.com/location'] ?? null,
+        url: status?.url ?? null,
+        ready: ready ? ready.status === 'True' : null,
+        latestReadyRevision: status?.latestReadyRevisionName ?? null,
+        image: spec?.template?.spec.containers?.[0]?.image ?? null,
+        lastModifier: metadata.annotations?.['serving.knative.dev/lastModifier'] ?? null,
+      };
+    });
+
+/** Summarizes the output of `gcloud container clusters list --format=json`. */
+export const summarizeClusters = (json: unknown): ClusterSummary[] =>
+  z
+    .array(ClusterSchema)
+    .parse(json)
+    .map((cluster) => ({
+      name: cluster.name,
+      location: cluster.location ?? null,
+      status: cluster.status ?? null,
+      version: cluster.currentMasterVersion ?? null,
+      nodeCount: cluster.currentNodeCount ?? null,
+      autopilot: cluster.autopilot?.enabled ?? false,
+      releaseChannel: cluster.releaseChannel?.channel ?? null,
+      endpoint: cluster.endpoint ?? null,
+    }));
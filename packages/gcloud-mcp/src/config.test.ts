@@ -0,0 +1,343 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import { envConfig, flagConfig, mergeConfigs, userConfigPath, validateConfig } from './config.js';
+import { PromptPolicy } from './interactive_prompts.js';
+
+describe('userConfigPath', () => {
+  test('uses XDG_CONFIG_HOME when set', () => {
+    expect(userConfigPath({ XDG_CONFIG_HOME: '/xdg' })).toBe('/xdg/gcloud-mcp/config.json');
+  });
+});
+
+describe('envConfig', () => {
+  test('reads defaults and allowed projects', () => {
+    expect(
+      envConfig({
+        GCLOUD_MCP_ALLOWED_PROJECTS: 'dev, staging,',
+        GCLOUD_MCP_DEFAULT_PROJECT: 'dev',
+      }),
+    ).toEqual({ allowedProjects: ['dev', 'staging'], defaults: { project: 'dev' } });
+  });
+
+  test('opts in to telemetry with a file', () => {
+    expect(envConfig({ GCLOUD_MCP_TELEMETRY_FILE: '/tmp/usage.jsonl' })).toEqual({
+      telemetry: { file: '/tmp/usage.jsonl' },
+    });
+  });
+
+  test('exports traces to the standard OTLP endpoint', () => {
+    expect(envConfig({ OTEL_EXPORTER_OTLP_ENDPOINT: 'http://localhost:4318' })).toEqual({
+      openTelemetry: { otlpEndpoint: 'http://localhost:4318' },
+    });
+  });
+
+  test('writes the audit log to a file', () => {
+    expect(envConfig({ GCLOUD_MCP_AUDIT_LOG: '/var/log/gcloud-mcp.jsonl' })).toEqual({
+      audit: { file: '/var/log/gcloud-mcp.jsonl' },
+    });
+  });
+
+  test('impersonates a service account', () => {
+    const serviceAccount = 'mcp-reader@ops.iam.gserviceaccount.com';
+    expect(envConfig({ GCLOUD_MCP_IMPERSONATE_SERVICE_ACCOUNT: serviceAccount })).toEqual({
+      impersonation: { serviceAccount },
+    });
+  });
+
+  test('runs gcloud in a container of the image', () => {
+    const image = 'gcr.io/google.com/cloudsdktool/google-cloud-cli:499.0.0-stable';
+    expect(envConfig({ GCLOUD_MCP_CONTAINER_IMAGE: image })).toEqual({ container: { image } });
+  });
+
+  test('reads access control lists', () => {
+    const deny = 'sql, compute instances create --network-tier=premium';
+    expect(envConfig({ GCLOUD_MCP_DENY: deny })).toEqual({
+      deny: ['sql', 'compute instances create --network-tier=premium'],
+    });
+    expect(envConfig({ GCLOUD_MCP_ALLOW: 'compute instances list' })).toEqual({
+      allow: ['compute instances list'],
+    });
+  });
+
+  test('is empty without variables', () => {
+    expect(envConfig({})).toEqual({});
+  });
+});
+
+describe('flagConfig', () => {
+  test('only includes flags that are set', () => {
+    expect(flagConfig({ zone: 'us-east1-b' })).toEqual({ defaults: { zone: 'us-east1-b' } });
+    expect(flagConfig({ profile: 'dev' })).toEqual({ defaultProfile: 'dev' });
+    expect(flagConfig({ readOnly: true })).toEqual({ readOnly: true });
+    expect(flagConfig({ readOnly: false })).toEqual({});
+    expect(flagConfig({ confirmMutations: true })).toEqual({ confirmMutations: true });
+    expect(flagConfig({ timeoutSeconds: 300 })).toEqual({ timeoutSeconds: 300 });
+    expect(flagConfig({ promptPolicy: 'default' })).toEqual({ promptPolicy: 'default' });
+    expect(flagConfig({ allowSecrets: true })).toEqual({ allowSecrets: true });
+    expect(flagConfig({ isolatedConfig: true })).toEqual({ isolatedConfig: {} });
+    expect(flagConfig({ maxConcurrentCommands: 4 })).toEqual({
+      sessionLimits: { maxConcurrentCommands: 4 },
+    });
+    expect(flagConfig({ cacheTtlSeconds: 30 })).toEqual({ readCache: { ttlSeconds: 30 } });
+    expect(flagConfig({ impersonateServiceAccount: 'mcp@p.iam.gserviceaccount.com' })).toEqual({
+      impersonation: { serviceAccount: 'mcp@p.iam.gserviceaccount.com' },
+    });
+    expect(flagConfig({ auditLog: '/tmp/audit.jsonl' })).toEqual({
+      audit: { file: '/tmp/audit.jsonl' },
+    });
+    expect(flagConfig({})).toEqual({});
+  });
+});
+
+describe('mergeConfigs', () => {
+  test('later layers take precedence and record their origin', () => {
+    const { config, origins } = mergeConfigs([
+      {
+        name: 'user',
+        path: '/home/me/.config/gcloud-mcp/config.json',
+        config: {
+          deny: ['compute instances delete'],
+          rateLimits: { compute: { qps: 1, burst: 2 } },
+          defaults: { project: 'dev', region: 'us-east1' },
+        },
+      },
+      {
+        name: 'project',
+        path: '/repo/.gcloud-mcp.json',
+        config: { deny: ['sql'], rateLimits: { logging: { qps: 2, burst: 2 } } },
+      },
+      { name: 'flags', config: { defaults: { project: 'staging' } } },
+    ]);
+
+    expect(config).toEqual({
+      deny: ['sql'],
+      rateLimits: { compute: { qps: 1, burst: 2 }, logging: { qps: 2, burst: 2 } },
+      defaults: { project: 'staging', region: 'us-east1' },
+    });
+    expect(origins).toEqual({
+      deny: 'project (/repo/.gcloud-mcp.json)',
+      'rateLimits.compute': 'user (/home/me/.config/gcloud-mcp/config.json)',
+      'rateLimits.logging': 'project (/repo/.gcloud-mcp.json)',
+      'defaults.project': 'flags',
+      'defaults.region': 'user (/home/me/.config/gcloud-mcp/config.json)',
+    });
+  });
+});
+
+describe('validateConfig', () => {
+  test('rejects both allow and deny lists', () => {
+    expect(validateConfig({ allow: ['a'], deny: ['b'] })).toContain('"allow" and "deny"');
+  });
+
+  test('rejects allowlist entries with flags', () => {
+    expect(validateConfig({ allow: ['compute instances list --zone=us-east1-b'] })).toContain(
+      'Allowlist entries can not include flags',
+    );
+  });
+
+  test('rejects a timeout that is not a positive integer', () => {
+    expect(validateConfig({ timeoutSeconds: 0 })).toContain('must be a positive integer');
+    expect(validateConfig({ timeoutSeconds: 300 })).toBe(undefined);
+  });
+
+  test('rejects an unknown prompt policy', () => {
+    expect(validateConfig({ promptPolicy: 'yes' as PromptPolicy })).toBe(
+      'Invalid prompt policy "yes": use one of abort, default.',
+    );
+    expect(validateConfig({ promptPolicy: 'abort' })).toBe(undefined);
+  });
+
+  test('rejects invalid rate limits', () => {
+    expect(validateConfig({ rateLimits: { compute: { qps: 1, burst: 0 } } })).toContain(
+      'Invalid rate limit for "compute"',
+    );
+  });
+
+  test('rejects invalid profiles', () => {
+    expect(
+      validateConfig({ profiles: { prod: { projects: ['p'], mutations: 'sometimes' as 'deny' } } }),
+    ).toContain('Profile "prod" has an invalid "mutations" policy');
+    expect(validateConfig({ profiles: { dev: { projects: [], mutations: 'allow' } } })).toContain(
+      'at least one project',
+    );
+  });
+
+  test('rejects an undefined default profile', () => {
+    expect(validateConfig({ defaultProfile: 'prod' })).toContain('"prod" is not defined');
+  });
+
+  test('rejects invalid billing export tables', () => {
+    expect(validateConfig({ billingExport: { table: 'billing; DROP TABLE x' } })).toContain(
+      'Invalid billing export table',
+    );
+    expect(validateConfig({ billingExport: { table: 'my-project.billing.export_v1' } })).toBe(
+      undefined,
+    );
+  });
+
+  test('rejects invalid GKE usage metering datasets', () => {
+    const table = 'my-project.billing.export_v1';
+    expect(
+      validateConfig({ billingExport: { table, gkeUsageMeteringDataset: 'my-project' } }),
+    ).toContain('Invalid GKE usage metering dataset');
+    expect(
+      validateConfig({ billingExport: { table, gkeUsageMeteringDataset: 'my-project.gke_usage' } }),
+    ).toBe(undefined);
+  });
+
+  test('rejects a BigQuery cap that is not positive', () => {
+    expect(validateConfig({ bigquery: { maxBytesBilled: 0 } })).toContain('must be a positive');
+    expect(validateConfig({ bigquery: { maxBytesBilled: 2 ** 30, pricePerTib: 5 } })).toBe(
+      undefined,
+    );
+  });
+
+  test('rejects telemetry without a destination or with a relative file', () => {
+    expect(validateConfig({ telemetry: {} })).toContain('needs a "file"');
+    expect(validateConfig({ telemetry: { file: 'usage.jsonl' } })).toContain('must be absolute');
+    expect(validateConfig({ telemetry: { monitoringProject: 'ops' } })).toBe(undefined);
+  });
+
+  test('rejects an audit log without a destination or with a relative file', () => {
+    expect(validateConfig({ audit: {} })).toContain('needs a "file"');
+    expect(validateConfig({ audit: { file: 'audit.jsonl' } })).toContain('must be absolute');
+    expect(validateConfig({ audit: { loggingProject: 'ops', logName: 'mcp audit' } })).toContain(
+      'Invalid audit log name',
+    );
+    expect(validateConfig({ audit: { loggingProject: 'ops' } })).toBe(undefined);
+  });
+
+  test('rejects remote mode without impersonation or with an invalid service account', () => {
+    const remote = { auth: 'iap' as const, audience: '/projects/1/global/backendServices/2' };
+    expect(validateConfig({ remote: { ...remote, impersonation: {} } })).toContain(
+      'requires "impersonation"',
+    );
+    expect(
+      validateConfig({ remote: { ...remote, impersonation: { '*@example.com': 'ops' } } }),
+    ).toContain('Invalid service account "ops"');
+    expect(
+      validateConfig({
+        remote: {
+          ...remote,
+          impersonation: { '*@example.com': 'mcp@ops.iam.gserviceaccount.com' },
+        },
+      }),
+    ).toBe(undefined);
+  });
+
+  test('rejects invalid session limits', () => {
+    expect(validateConfig({ sessionLimits: { maxConcurrentCommands: 0 } })).toContain(
+      '"maxConcurrentCommands" must be a positive integer',
+    );
+    expect(validateConfig({ sessionLimits: { rateLimit: { qps: 0, burst: 1 } } })).toContain(
+      'Invalid session rate limit',
+    );
+    expect(
+      validateConfig({
+        sessionLimits: { maxConcurrentCommands: 4, rateLimit: { qps: 2, burst: 10 } },
+      }),
+    ).toBe(undefined);
+  });
+
+  test('rejects OpenTelemetry without an exporter or with an invalid sample ratio', () => {
+    expect(validateConfig({ openTelemetry: {} })).toContain('OpenTelemetry needs');
+    expect(validateConfig({ openTelemetry: { traceProject: 'ops', sampleRatio: 2 } })).toContain(
+      '"sampleRatio" must be between 0 and 1',
+    );
+    expect(validateConfig({ openTelemetry: { otlpEndpoint: 'localhost:4318' } })).toContain(
+      'Invalid OTLP endpoint',
+    );
+    expect(validateConfig({ openTelemetry: { traceProject: 'ops', sampleRatio: 0.1 } })).toBe(
+      undefined,
+    );
+  });
+
+  test('rejects an invalid read cache TTL', () => {
+    expect(validateConfig({ readCache: { ttlSeconds: 1.5 } })).toContain('"ttlSeconds"');
+    expect(validateConfig({ readCache: {} })).toBe(undefined);
+  });
+
+  test('rejects invalid impersonated service accounts and impersonation in remote mode', () => {
+    const serviceAccount = 'mcp-reader@ops.iam.gserviceaccount.com';
+    expect(validateConfig({ impersonation: { allowedServiceAccounts: ['ops'] } })).toContain(
+      'Invalid service account "ops"',
+    );
+    expect(
+      validateConfig({
+        impersonation: { serviceAccount },
+        remote: {
+          auth: 'iap',
+          audience: '/projects/1/global/backendServices/2',
+          impersonation: { '*@example.com': serviceAccount },
+        },
+      }),
+    ).toContain('can not be combined with "remote"');
+    expect(validateConfig({ impersonation: { serviceAccount } })).toBe(undefined);
+  });
+
+  test('rejects container images that are not pinned', () => {
+    const image = 'gcr.io/google.com/cloudsdktool/google-cloud-cli';
+    expect(validateConfig({ container: { image: `${image}:stable` } })).toContain('must be pinned');
+    expect(validateConfig({ container: { image } })).toContain('must be pinned');
+    expect(validateConfig({ container: { image: `${image}:499.0.0-stable` } })).toBe(undefined);
+    expect(validateConfig({ container: { image: `${image}@sha256:${'a'.repeat(64)}` } })).toBe(
+      undefined,
+    );
+  });
+
+  test('rejects an offline cache file that is not absolute', () => {
+    expect(validateConfig({ offline: { cacheFile: 'cache.json' } })).toContain('must be absolute');
+    expect(validateConfig({ offline: {} })).toBe(undefined);
+  });
+
+  test('rejects org policies that are not constraint names', () => {
+    const constraint = 'compute.requireOsLogin';
+    expect(validateConfig({ compliance: { requiredOrgPolicies: [constraint] } })).toContain(
+      'must be constraint names',
+    );
+    expect(
+      validateConfig({ compliance: { requiredOrgPolicies: [`constraints/${constraint}`] } }),
+    ).toBe(undefined);
+  });
+
+  test('rejects invalid schedules', () => {
+    const job = { tool: 'find_idle_resources', schedule: '0 6 * * 1' };
+    expect(validateConfig({ schedules: { weekly: { ...job, schedule: 'weekly' } } })).toContain(
+      'is not a cron expression',
+    );
+    expect(validateConfig({ schedules: { weekly: job } })).toBe(undefined);
+  });
+
+  test('rejects a runbook directory that is not absolute', () => {
+    expect(validateConfig({ runbooks: { directory: 'runbooks' } })).toContain('must be absolute');
+    expect(validateConfig({ runbooks: { directory: '/etc/gcloud-mcp/runbooks' } })).toBe(undefined);
+  });
+
+  test('rejects an output chunk size that is not a positive integer', () => {
+    const error = 'must be a positive integer';
+    expect(validateConfig({ outputChunks: { chunkKb: 0 } })).toContain(error);
+    expect(validateConfig({ outputChunks: { chunkKb: 1.5 } })).toContain(error);
+    expect(validateConfig({ outputChunks: { chunkKb: 128 } })).toBe(undefined);
+  });
+
+  test('accepts valid configurations', () => {
+    expect(validateConfig({ deny: ['a'], rateLimits: { compute: { qps: 1, burst: 1 } } })).toBe(
+      undefined,
+    );
+  });
+});
@@ -0,0 +1,346 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { RateLimit } from './rate_limiter.js';
+import { SessionContextValues } from './session_context.js';
+import { Profile, validateProfile } from './profiles.js';
+import { NamingPolicyConfig, validateNamingPolicy } from './naming_policy.js';
+import { BillingExportConfig, validateBillingExport } from './billing_export.js';
+import { BigQueryConfig, validateBigQueryConfig } from './bigquery.js';
+import { TelemetryConfig, validateTelemetry } from './telemetry.js';
+import { OpenTelemetryConfig, validateOpenTelemetry } from './tracing.js';
+import { AuditConfig, validateAuditConfig } from './audit_log.js';
+import { RemoteConfig, validateRemoteConfig } from './remote_auth.js';
+import { ContainerConfig, validateContainerConfig } from './container.js';
+import { IsolatedConfig, validateIsolatedConfig } from './isolated_config.js';
+import { SessionLimitsConfig, validateSessionLimits } from './session_limits.js';
+import { ImpersonationConfig, validateImpersonation } from './impersonation.js';
+import { OfflineConfig, validateOffline } from './response_cache.js';
+import { ReadCacheConfig, validateReadCache } from './read_cache.js';
+import { ComplianceConfig, validateCompliance } from './compliance.js';
+import { ScheduledJobConfig, validateSchedules } from './scheduler.js';
+import { RunbookConfig, validateRunbookConfig } from './runbooks.js';
+import { validateAccessControlRules } from './denylist.js';
+import { OutputChunkConfig, validateOutputChunkConfig } from './output_chunks.js';
+import { PROMPT_POLICIES, PromptPolicy } from './interactive_prompts.js';
+
+export interface McpConfig {
+  allow?: string[];
+  deny?: string[];
+  /** Rejects every gcloud command that is not known to only read state. */
+  readOnly?: boolean;
+  /** Asks the user to confirm every gcloud command that is not known to only read state. */
+  confirmMutations?: boolean;
+  /** Stops gcloud commands that run longer, unless a call sets its own timeout. */
+  timeoutSeconds?: number;
+  /** Whether commands that ask for input are stopped, or run with --quiet. Defaults to abort. */
+  promptPolicy?: PromptPolicy;
+  /** Returns tokens, private keys and secret payloads in tool output instead of masking them. */
+  allowSecrets?: boolean;
+  rateLimits?: Record<string, RateLimit>;
+  rateLimitMaxQueueMs?: number;
+  /** Limits the gcloud commands each session runs at the same time and per second. */
+  sessionLimits?: SessionLimitsConfig;
+  allowedProjects?: string[];
+  defaults?: SessionContextValues;
+  profiles?: Record<string, Profile>;
+  /** The profile selected when the server starts. */
+  defaultProfile?: string;
+  namingPolicy?: NamingPolicyConfig;
+  billingExport?: BillingExportConfig;
+  /** Limits on what run_bigquery_query may scan. */
+  bigquery?: BigQueryConfig;
+  /** Opts in to recording usage. Nothing is recorded without it. */
+  telemetry?: TelemetryConfig;
+  /** Exports traces and metrics of tool calls, gcloud processes and API requests. */
+  openTelemetry?: OpenTelemetryConfig;
+  /** Records every tool call and gcloud command with its arguments and caller. */
+  audit?: AuditConfig;
+  /** Authentication and impersonation for the HTTP transport. */
+  remote?: RemoteConfig;
+  /** Runs gcloud in a container of a pinned SDK image instead of from the host. */
+  container?: ContainerConfig;
+  /** Runs gcloud with its own configuration directory instead of the operator's. */
+  isolatedConfig?: IsolatedConfig;
+  /** The service account commands run as and those a tool call may elevate to. */
+  impersonation?: ImpersonationConfig;
+  /** Serves cached read results when the network or the credentials are unavailable. */
+  offline?: OfflineConfig;
+  /** Serves read commands run again within a TTL from a cache of each session. */
+  readCache?: ReadCacheConfig;
+  /** The rule set of compliance_scan. */
+  compliance?: ComplianceConfig;
+  /** Tools the server runs on a schedule, by job name. */
+  schedules?: Record<string, ScheduledJobConfig>;
+  /** Where the runbooks exposed by run_runbook and as prompts are defined. */
+  runbooks?: RunbookConfig;
+  /** How run_gcloud_command splits outputs that are too large to return at once. */
+  outputChunks?: OutputChunkConfig;
+}
+
+export interface ConfigLayer {
+  name: 'user' | 'project' | 'file' | 'env' | 'flags';
+  path?: string;
+  config: McpConfig;
+}
+
+export interface EffectiveConfig {
+  config: McpConfig;
+  /** The layer that set each key, e.g. `{"deny": "project (/repo/.gcloud-mcp.json)"}`. */
+  origins: Record<string, string>;
+}
+
+// Keys whose object values are merged across layers instead of replaced.
+const MERGED_KEYS: Array<keyof McpConfig> = [
+  'rateLimits',
+  'sessionLimits',
+  'defaults',
+  'profiles',
+  'isolatedConfig',
+  'impersonation',
+  'readCache',
+  'openTelemetry',
+];
+
+export const PROJECT_CONFIG_FILE = '.gcloud-mcp.json';
+
+/** Returns the path of the per-user configuration file. */
+export const userConfigPath = (env: NodeJS.ProcessEnv = process.env): string => {
+  const configHome = env['XDG_CONFIG_HOME'] || path.join(os.homedir(), '.config');
+  return path.join(configHome, 'gcloud-mcp', 'config.json');
+};
+
+export const readConfigFile = (file: string): McpConfig =>
+  JSON.parse(fs.readFileSync(file, 'utf-8')) as McpConfig;
+
+/** Reads a configuration file that is not required to exist. */
+export const readOptionalConfigFile = (file: string): McpConfig | undefined =>
+  fs.existsSync(file) ? readConfigFile(file) : undefined;
+
+const list = (value: string | undefined): string[] | undefined =>
+  value
+    ?.split(',')
+    .map((item) => item.trim())
+    .filter((item) => item.length > 0);
+
+const definedValues = <T extends object>(values: T): T =>
+  Object.fromEntries(Object.entries(values).filter(([, value]) => value !== undefined)) as T;
+
+/** Returns the configuration set with GCLOUD_MCP_* environment variables. */
+export const envConfig = (env: NodeJS.ProcessEnv = process.env): McpConfig => {
+  const defaults = definedValues({
+    project: env['GCLOUD_MCP_DEFAULT_PROJECT'],
+    region: env['GCLOUD_MCP_DEFAULT_REGION'],
+    zone: env['GCLOUD_MCP_DEFAULT_ZONE'],
+  });
+  const allowedProjects = list(env['GCLOUD_MCP_ALLOWED_PROJECTS']);
+  const defaultProfile = env['GCLOUD_MCP_PROFILE'];
+  const telemetryFile = env['GCLOUD_MCP_TELEMETRY_FILE'];
+  const otlpEndpoint = env['OTEL_EXPORTER_OTLP_ENDPOINT'];
+  const auditFile = env['GCLOUD_MCP_AUDIT_LOG'];
+  const containerImage = env['GCLOUD_MCP_CONTAINER_IMAGE'];
+  const serviceAccount = env['GCLOUD_MCP_IMPERSONATE_SERVICE_ACCOUNT'];
+  const allow = list(env['GCLOUD_MCP_ALLOW']);
+  const deny = list(env['GCLOUD_MCP_DENY']);
+  return {
+    ...(allow && { allow }),
+    ...(deny && { deny }),
+    ...(allowedProjects && { allowedProjects }),
+    ...(defaultProfile && { defaultProfile }),
+    ...(telemetryFile && { telemetry: { file: telemetryFile } }),
+    ...(otlpEndpoint && { openTelemetry: { otlpEndpoint } }),
+    ...(auditFile && { audit: { file: auditFile } }),
+    ...(containerImage && { container: { image: containerImage } }),
+    ...(serviceAccount && { impersonation: { serviceAccount } }),
+    ...(Object.keys(defaults).length > 0 && { defaults }),
+  };
+};
+
+/** Returns the configuration set with command line flags. */
+export const flagConfig = (flags: {
+  project?: string;
+  region?: string;
+  zone?: string;
+  profile?: string;
+  readOnly?: boolean;
+  confirmMutations?: boolean;
+  timeoutSeconds?: number;
+  promptPolicy?: PromptPolicy;
+  allowSecrets?: boolean;
+  auditLog?: string;
+  isolatedConfig?: boolean;
+  impersonateServiceAccount?: string;
+  maxConcurrentCommands?: number;
+  cacheTtlSeconds?: number;
+}): McpConfig => {
+  const defaults = definedValues({
+    project: flags.project,
+    region: flags.region,
+    zone: flags.zone,
+  });
+  return {
+    ...(Object.keys(defaults).length > 0 && { defaults }),
+    ...(flags.profile && { defaultProfile: flags.profile }),
+    ...(flags.readOnly && { readOnly: true }),
+    ...(flags.confirmMutations && { confirmMutations: true }),
+    ...(flags.timeoutSeconds !== undefined && { timeoutSeconds: flags.timeoutSeconds }),
+    ...(flags.promptPolicy && { promptPolicy: flags.promptPolicy }),
+    ...(flags.allowSecrets && { allowSecrets: true }),
+    ...(flags.auditLog && { audit: { file: flags.auditLog } }),
+    ...(flags.isolatedConfig && { isolatedConfig: {} }),
+    ...(flags.maxConcurrentCommands !== undefined && {
+      sessionLimits: { maxConcurrentCommands: flags.maxConcurrentCommands },
+    }),
+    ...(flags.cacheTtlSeconds !== undefined && {
+      readCache: { ttlSeconds: flags.cacheTtlSeconds },
+    }),
+    ...(flags.impersonateServiceAccount && {
+      impersonation: { serviceAccount: flags.impersonateServiceAccount },
+    }),
+  };
+};
+
+const layerLabel = (layer: ConfigLayer) =>
+  layer.path ? `${layer.name} (${layer.path})` : layer.name;
+
+/** Merges layers in order, so that later layers take precedence. */
+export const mergeConfigs = (layers: ConfigLayer[]): EffectiveConfig => {
+  const config: Record<string, unknown> = {};
+  const origins: Record<string, string> = {};
+  for (const layer of layers) {
+    for (const [key, value] of Object.entries(layer.config)) {
+      if (value === undefined) {
+        continue;
+      }
+      if (MERGED_KEYS.includes(key as keyof McpConfig)) {
+        config[key] = { ...(config[key] as object | undefined), ...value };
+        for (const subKey of Object.keys(value)) {
+          origins[`${key}.${subKey}`] = layerLabel(layer);
+        }
+      } else {
+        config[key] = value;
+        origins[key] = layerLabel(layer);
+      }
+    }
+  }
+  return { config: config as McpConfig, origins };
+};
+
+/** Returns an error message if the configuration is invalid. */
+export const validateConfig = (config: McpConfig): string | undefined => {
+  if (config.allow && config.deny) {
+    return 'Configuration can not specify both "allow" and "deny" lists. Please choose one.';
+  }
+  const { timeoutSeconds } = config;
+  if (timeoutSeconds !== undefined && !(Number.isInteger(timeoutSeconds) && timeoutSeconds > 0)) {
+    return `Invalid timeout ${timeoutSeconds}: "timeoutSeconds" must be a positive integer.`;
+  }
+  if (config.promptPolicy !== undefined && !PROMPT_POLICIES.includes(config.promptPolicy)) {
+    return `Invalid prompt policy "${config.promptPolicy}": use one of ${PROMPT_POLICIES.join(', ')}.`;
+  }
+  const accessControlError = validateAccessControlRules(config.allow, config.deny);
+  if (accessControlError) {
+    return accessControlError;
+  }
+  for (const [apiFamily, limit] of Object.entries(config.rateLimits ?? {})) {
+    if (!(limit.qps > 0) || !(limit.burst >= 1)) {
+      return `Invalid rate limit for "${apiFamily}": "qps" must be greater than 0 and "burst" at least 1.`;
+    }
+  }
+  const sessionLimitsError = config.sessionLimits && validateSessionLimits(config.sessionLimits);
+  if (sessionLimitsError) {
+    return sessionLimitsError;
+  }
+  for (const [name, profile] of Object.entries(config.profiles ?? {})) {
+    const profileError = validateProfile(name, profile);
+    if (profileError) {
+      return profileError;
+    }
+  }
+  if (config.defaultProfile && !config.profiles?.[config.defaultProfile]) {
+    return `The default profile "${config.defaultProfile}" is not defined in "profiles".`;
+  }
+  const policyError = config.namingPolicy && validateNamingPolicy(config.namingPolicy);
+  if (policyError) {
+    return policyError;
+  }
+  const billingExportError = config.billingExport && validateBillingExport(config.billingExport);
+  if (billingExportError) {
+    return billingExportError;
+  }
+  const bigqueryError = config.bigquery && validateBigQueryConfig(config.bigquery);
+  if (bigqueryError) {
+    return bigqueryError;
+  }
+  const telemetryError = config.telemetry && validateTelemetry(config.telemetry);
+  if (telemetryError) {
+    return telemetryError;
+  }
+  const openTelemetryError = config.openTelemetry && validateOpenTelemetry(config.openTelemetry);
+  if (openTelemetryError) {
+    return openTelemetryError;
+  }
+  const auditError = config.audit && validateAuditConfig(config.audit);
+  if (auditError) {
+    return auditError;
+  }
+  const remoteError = config.remote && validateRemoteConfig(config.remote);
+  if (remoteError) {
+    return remoteError;
+  }
+  const containerError = config.container && validateContainerConfig(config.container);
+  if (containerError) {
+    return containerError;
+  }
+  const isolatedError = config.isolatedConfig && validateIsolatedConfig(config.isolatedConfig);
+  if (isolatedError) {
+    return isolatedError;
+  }
+  const impersonationError = config.impersonation && validateImpersonation(config.impersonation);
+  if (impersonationError) {
+    return impersonationError;
+  }
+  if (config.impersonation && config.remote) {
+    return '"impersonation" can not be combined with "remote", which impersonates a service account per user.';
+  }
+  const offlineError = config.offline && validateOffline(config.offline);
+  if (offlineError) {
+    return offlineError;
+  }
+  const readCacheError = config.readCache && validateReadCache(config.readCache);
+  if (readCacheError) {
+    return readCacheError;
+  }
+  const complianceError = config.compliance && validateCompliance(config.compliance);
+  if (complianceError) {
+    return complianceError;
+  }
+  const schedulesError = config.schedules && validateSchedules(config.schedules);
+  if (schedulesError) {
+    return schedulesError;
+  }
+  const runbooksError = config.runbooks && validateRunbookConfig(config.runbooks);
+  if (runbooksError) {
+    return runbooksError;
+  }
+  if (config.outputChunks) {
+    return validateOutputChunkConfig(config.outputChunks);
+  }
+  return undefined;
+};
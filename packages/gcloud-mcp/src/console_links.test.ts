@@ -0,0 +1,136 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import {
+  consoleUrlOf,
+  logsExplorerUrlOfCommand,
+  parseDuration,
+  resourceOfSelfLink,
+  withConsoleUrls,
+} from './console_links.js';
+
+const COMPUTE_API = 'https://www.googleapis.com/compute/v1/projects/p';
+const CONSOLE = 'https://console.cloud.google.com';
+
+describe('consoleUrlOf', () => {
+  test('returns the details page of a resource', () => {
+    expect(
+      consoleUrlOf({
+        project: 'p',
+        assetType: 'compute.googleapis.com/Disk',
+        name: 'data',
+        location: 'us-central1',
+      }),
+    ).toBe(`${CONSOLE}/compute/disksDetail/regions/us-central1/disks/data?project=p`);
+  });
+
+  test('returns null for unknown asset types', () => {
+    expect(
+      consoleUrlOf({ project: 'p', assetType: 'iam.googleapis.com/Role', name: 'r', location: '' }),
+    ).toBeNull();
+  });
+});
+
+describe('resourceOfSelfLink', () => {
+  test('parses zonal, global and non-compute self links', () => {
+    expect(resourceOfSelfLink(`${COMPUTE_API}/zones/us-east1-b/instances/vm`)).toEqual({
+      project: 'p',
+      assetType: 'compute.googleapis.com/Instance',
+      name: 'vm',
+      location: 'us-east1-b',
+    });
+    expect(resourceOfSelfLink(`${COMPUTE_API}/global/snapshots/s`)).toMatchObject({
+      assetType: 'compute.googleapis.com/Snapshot',
+      location: 'global',
+    });
+    expect(
+      resourceOfSelfLink('https://sqladmin.googleapis.com/sql/v1beta4/projects/p/instances/db'),
+    ).toMatchObject({ assetType: 'sqladmin.googleapis.com/Instance', name: 'db' });
+  });
+
+  test('returns null for unknown collections', () => {
+    expect(resourceOfSelfLink(`${COMPUTE_API}/global/networks/default`)).toBeNull();
+    expect(resourceOfSelfLink('not a url')).toBeNull();
+  });
+});
+
+describe('withConsoleUrls', () => {
+  test('adds links to the resources of a list', () => {
+    expect(
+      withConsoleUrls([
+        { name: 'vm', selfLink: `${COMPUTE_API}/zones/z-a/instances/vm` },
+        { name: 'bucket', storage_url: 'gs://bucket/' },
+        { name: 'other' },
+      ]),
+    ).toEqual([
+      {
+        name: 'vm',
+        selfLink: `${COMPUTE_API}/zones/z-a/instances/vm`,
+        consoleUrl: `${CONSOLE}/compute/instancesDetail/zones/z-a/instances/vm?project=p`,
+      },
+      {
+        name: 'bucket',
+        storage_url: 'gs://bucket/',
+        consoleUrl: `${CONSOLE}/storage/browser/bucket`,
+      },
+      { name: 'other' },
+    ]);
+  });
+
+  test('returns null without recognized resources', () => {
+    expect(withConsoleUrls([{ name: 'other' }])).toBeNull();
+    expect(withConsoleUrls('text')).toBeNull();
+  });
+});
+
+describe('parseDuration', () => {
+  test('parses gcloud durations', () => {
+    expect(parseDuration('1h30m')).toBe(90 * 60 * 1000);
+    expect(parseDuration('2d')).toBe(2 * 24 * 60 * 60 * 1000);
+    expect(parseDuration('soon')).toBeNull();
+  });
+});
+
+describe('logsExplorerUrlOfCommand', () => {
+  const now = new Date('2025-06-01T12:00:00Z');
+
+  test('links the filter and time range of the query', () => {
+    const args = [
+      'logging',
+      'read',
+      'severity>=ERROR AND resource.type="gce_instance"',
+      '--freshness=2h',
+      '--project',
+      'p',
+    ];
+
+    expect(logsExplorerUrlOfCommand(args, now)).toBe(
+      `${CONSOLE}/logs/query;query=severity%3E%3DERROR%20AND%20resource.type%3D%22gce_instance%22` +
+        ';startTime=2025-06-01T10:00:00.000Z;endTime=2025-06-01T12:00:00.000Z?project=p',
+    );
+  });
+
+  test('uses the default freshness of one day', () => {
+    expect(logsExplorerUrlOfCommand(['logging', 'read', 'severity>=ERROR'], now)).toContain(
+      ';startTime=2025-05-31T12:00:00.000Z;',
+    );
+  });
+
+  test('returns null for other commands', () => {
+    expect(logsExplorerUrlOfCommand(['logging', 'logs', 'list'], now)).toBeNull();
+  });
+});
@@ -0,0 +1,218 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+export const CONSOLE_URL = 'https://console.cloud.google.com';
+
+// gcloud reads log entries from the last day unless --freshness is set.
+export const DEFAULT_LOG_FRESHNESS_MS = 24 * 60 * 60 * 1000;
+
+export interface ConsoleResource {
+  /** The project of the resource, if it is known. */
+  project?: string | undefined;
+  assetType: string;
+  name: string;
+  location: string;
+}
+
+const scopePath = (location: string) =>
+  /-[a-z]$/.test(location) ? `zones/${location}` : `regions/${location}`;
+
+// Console pages of resources, keyed by Cloud Asset Inventory type.
+const CONSOLE_PAGES: Record<
+  string,
+  (name: string, location: string, project: string | undefined) => string | null
+> = {
+  'compute.googleapis.com/Instance': (name, location) =>
+    `/compute/instancesDetail/zones/${location}/instances/${name}`,
+  'compute.googleapis.com/Disk': (name, location) =>
+    `/compute/disksDetail/${scopePath(location)}/disks/${name}`,
+  'compute.googleapis.com/Snapshot': (name, _location, project) =>
+    project ? `/compute/snapshotsDetail/projects/${project}/global/snapshots/${name}` : null,
+  // Addresses have no page of their own.
+  'compute.googleapis.com/Address': () => '/networking/addresses/list',
+  'storage.googleapis.com/Bucket': (name) => `/storage/browser/${name}`,
+  'sqladmin.googleapis.com/Instance': (name) => `/sql/instances/${name}/overview`,
+  'container.googleapis.com/Cluster': (name, location) =>
+    `/kubernetes/clusters/details/${location}/${name}/details`,
+  'run.googleapis.com/Service': (name, location) => `/run/detail/${location}/${name}`,
+  'pubsub.googleapis.com/Topic': (name) => `/cloudpubsub/topic/detail/${name}`,
+};
+
+const withProject = (path: string, project: string | undefined) =>
+  `${CONSOLE_URL}${path}${project ? `?project=${encodeURIComponent(project)}` : ''}`;
+
+/** Returns the Cloud Console page of a resource, or null for unknown types. */
+export const consoleUrlOf = (resource: ConsoleResource): string | null => {
+  const { project, assetType, name, location } = resource;
+  const path = CONSOLE_PAGES[assetType]?.(name, location, project);
+  return path ? withProject(path, project) : null;
+};
+
+// Asset types of the collections in self links, keyed by API and collection.
+const SELF_LINK_ASSET_TYPES: Record<string, string> = {
+  'compute instances': 'compute.googleapis.com/Instance',
+  'compute disks': 'compute.googleapis.com/Disk',
+  'compute snapshots': 'compute.googleapis.com/Snapshot',
+  'compute addresses': 'compute.googleapis.com/Address',
+  'container clusters': 'container.googleapis.com/Cluster',
+  'sqladmin instances': 'sqladmin.googleapis.com/Instance',
+};
+
+/**
+ * Returns the resource a self link points to, e.g.
+ * `https://www.googleapis.com/compute/v1/projects/p/zones/z/instances/vm`.
+ */
+export const resourceOfSelfLink = (selfLink: string): ConsoleResource | null => {
+  let url: URL;
+  try {
+    url = new URL(selfLink);
+  } catch {
+    return null;
+  }
+  const host = url.hostname.split('.')[0];
+  const segments = url.pathname.split('/').filter((segment) => segment !== '');
+  // Compute self links use the shared www.googleapis.com host.
+  const api = host === 'www' ? segments[0] : host;
+  const projectIndex = segments.indexOf('projects');
+  const project = segments[projectIndex + 1];
+  if (projectIndex === -1 || !project) {
+    return null;
+  }
+  let rest = segments.slice(projectIndex + 2);
+  let location = 'global';
+  if (['zones', 'regions', 'locations'].includes(rest[0] ?? '') && rest[1]) {
+    location = rest[1];
+    rest = rest.slice(2);
+  } else if (rest[0] === 'global') {
+    rest = rest.slice(1);
+  }
+  const [collection, name] = rest;
+  const assetType = SELF_LINK_ASSET_TYPES[`${api} ${collection}`];
+  if (rest.length !== 2 || !assetType || !name) {
+    return null;
+  }
+  return { project, assetType, name, location };
+};
+
+const consoleUrlOfOutput = (value: Record<string, unknown>): string | null => {
+  if (typeof value['selfLink'] === 'string') {
+    const resource = resourceOfSelfLink(value['selfLink']);
+    return resource && consoleUrlOf(resource);
+  }
+  // Buckets listed by 'gcloud storage' have no self link.
+  const storageUrl = value['storage_url'];
+  const bucket = typeof storageUrl === 'string' && storageUrl.match(/^gs:\/\/([^/]+)\/?$/)?.[1];
+  return bucket
+    ? consoleUrlOf({ assetType: 'storage.googleapis.com/Bucket', name: bucket, location: '' })
+    : null;
+};
+
+const isRecord = (value: unknown): value is Record<string, unknown> =>
+  typeof value === 'object' && value !== null && !Array.isArray(value);
+
+/**
+ * Adds a `consoleUrl` field to the resources in the JSON output of a gcloud
+ * command, i.e. to the output itself or to the items of a list. Returns null
+ * if no resource was recognized.
+ */
+export const withConsoleUrls = (output: unknown): unknown => {
+  const link = (value: unknown): Record<string, unknown> | null => {
+    if (!isRecord(value) || 'consoleUrl' in value) {
+      return null;
+    }
+    const consoleUrl = consoleUrlOfOutput(value);
+    return consoleUrl ? { ...value, consoleUrl } : null;
+  };
+  if (Array.isArray(output)) {
+    const linked = output.map(link);
+    return linked.some((value) => value !== null)
+      ? linked.map((value, i) => value ?? output[i])
+      : null;
+  }
+  return link(output);
+};
+
+const DURATION_UNITS_MS: Record<string, number> = {
+  s: 1000,
+  m: 60 * 1000,
+  h: 60 * 60 * 1000,
+  d: 24 * 60 * 60 * 1000,
+  w: 7 * 24 * 60 * 60 * 1000,
+};
+
+/** Parses a gcloud duration such as `1d` or `1h30m` into milliseconds. */
+export const parseDuration = (duration: string): number | null => {
+  const parts = duration.toLowerCase().match(/\d+[smhdw]/g);
+  if (!parts || parts.join('') !== duration.toLowerCase()) {
+    return null;
+  }
+  return parts.reduce(
+    (total, part) => total + Number(part.slice(0, -1)) * (DURATION_UNITS_MS[part.slice(-1)] ?? 0),
+    0,
+  );
+};
+
+// The Logs Explorer separates its parameters with semicolons, so these must be
+// escaped along with the characters encodeURIComponent leaves alone.
+const encodeLogQuery = (query: string) =>
+  encodeURIComponent(query).replace(
+    /[!'()*]/g,
+    (c) => `%${c.charCodeAt(0).toString(16).toUpperCase()}`,
+  );
+
+/** Returns a Logs Explorer link with the filter and time range filled in. */
+export const logsExplorerUrl = (
+  filter: string,
+  range: { start: Date; end: Date },
+  project?: string,
+): string =>
+  withProject(
+    `/logs/query;query=${encodeLogQuery(filter)}` +
+      `;startTime=${range.start.toISOString()};endTime=${range.end.toISOString()}`,
+    project,
+  );
+
+/**
+ * Returns the Logs Explorer link for the query of a 'gcloud logging read'
+ * command, or null for other commands.
+ */
+export const logsExplorerUrlOfCommand = (args: string[], now = new Date()): string | null => {
+  if (args[0] !== 'logging' || args[1] !== 'read') {
+    return null;
+  }
+  const flags: Record<string, string> = {};
+  const positionals: string[] = [];
+  for (let i = 2; i < args.length; i++) {
+    const arg = args[i] ?? '';
+    if (!arg.startsWith('--')) {
+      positionals.push(arg);
+      continue;
+    }
+    const separator = arg.indexOf('=');
+    if (separator === -1) {
+      flags[arg.slice(2)] = args[++i] ?? '';
+    } else {
+      flags[arg.slice(2, separator)] = arg.slice(separator + 1);
+    }
+  }
+  const freshness =
+    (flags['freshness'] && parseDuration(flags['freshness'])) || DEFAULT_LOG_FRESHNESS_MS;
+  return logsExplorerUrl(
+    positionals[0] ?? '',
+    { start: new Date(now.getTime() - freshness), end: now },
+    flags['project'],
+  );
+};
@@ -0,0 +1,93 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import { containerExecArgs, containerKillArgs } from './container.js';
+
+describe('containerExecArgs', () => {
+  beforeEach(() => {
+    vi.stubEnv('CLOUDSDK_CONFIG', '/home/mcp/.config/gcloud');
+    vi.stubEnv('CLOUDSDK_CORE_PROJECT', '');
+  });
+
+  afterEach(() => {
+    vi.unstubAllEnvs();
+  });
+
+  test('passes the gcloud properties of the environment', () => {
+    expect(
+      containerExecArgs('gcloud-mcp-1', ['projects', 'list'], { CLOUDSDK_CORE_PROJECT: 'dev' }),
+    ).toEqual([
+      'exec',
+      '--env',
+      'CLOUDSDK_CORE_PROJECT=dev',
+      'gcloud-mcp-1',
+      'gcloud',
+      'projects',
+      'list',
+    ]);
+  });
+
+  test('maps a configuration directory in the mounted one to its path in the container', () => {
+    const env = { CLOUDSDK_CONFIG: '/home/mcp/.config/gcloud/mcp-sessions/alice' };
+
+    expect(containerExecArgs('gcloud-mcp-1', ['projects', 'list'], env)).toEqual([
+      'exec',
+      '--env',
+      'CLOUDSDK_CONFIG=/root/.config/gcloud/mcp-sessions/alice',
+      'gcloud-mcp-1',
+      'gcloud',
+      'projects',
+      'list',
+    ]);
+  });
+
+  test('does not pass a configuration directory outside the mounted one', () => {
+    const env = { CLOUDSDK_CONFIG: '/tmp/other' };
+
+    expect(containerExecArgs('gcloud-mcp-1', ['projects', 'list'], env)).not.toContainEqual(
+      expect.stringContaining('CLOUDSDK_CONFIG'),
+    );
+  });
+
+  test('runs gcloud in a session recorded in the pid file', () => {
+    expect(containerExecArgs('gcloud-mcp-1', ['projects', 'list'], {}, '/tmp/1.pid')).toEqual([
+      'exec',
+      'gcloud-mcp-1',
+      'setsid',
+      '--wait',
+      'sh',
+      '-c',
+      'echo $$ > "$0"; gcloud "$@"; status=$?; rm -f "$0"; exit $status',
+      '/tmp/1.pid',
+      'projects',
+      'list',
+    ]);
+  });
+});
+
+describe('containerKillArgs', () => {
+  test('kills the session recorded in the pid file', () => {
+    expect(containerKillArgs('gcloud-mcp-1', '/tmp/1.pid')).toEqual([
+      'exec',
+      'gcloud-mcp-1',
+      'sh',
+      '-c',
+      'kill -KILL -- -"$(cat "$0")"; rm -f "$0"',
+      '/tmp/1.pid',
+    ]);
+  });
+});
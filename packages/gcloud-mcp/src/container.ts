@@ -0,0 +1,130 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import os from 'os';
+import path from 'path';
+import { randomUUID } from 'crypto';
+
+export type ContainerRuntime = 'docker' | 'podman';
+
+export interface ContainerConfig {
+  /**
+   * The gcloud image, pinned to an SDK version or digest, e.g.
+   * `gcr.io/google.com/cloudsdktool/google-cloud-cli:499.0.0-stable`.
+   */
+  image: string;
+  /** Defaults to `docker`. */
+  runtime?: ContainerRuntime;
+}
+
+export const CONTAINER_RUNTIMES: ContainerRuntime[] = ['docker', 'podman'];
+// Where the host's gcloud configuration, and so its credentials, is mounted.
+export const CONTAINER_CONFIG_DIR = '/root/.config/gcloud';
+
+// Tags such as `stable` or `latest` move between SDK releases, which would
+// defeat the point of pinning.
+const PINNED_IMAGE = /(@sha256:[0-9a-f]{64}|:[^/:@]*\d+\.\d+\.\d+[^/:@]*)$/;
+
+export const validateContainerConfig = (config: ContainerConfig): string | undefined => {
+  if (config.runtime && !CONTAINER_RUNTIMES.includes(config.runtime)) {
+    return `Invalid container runtime "${config.runtime}". Use ${CONTAINER_RUNTIMES.join(' or ')}.`;
+  }
+  if (typeof config.image !== 'string' || !PINNED_IMAGE.test(config.image)) {
+    return `The container image "${config.image}" must be pinned to an SDK version tag, such as ":499.0.0-stable", or a digest.`;
+  }
+  return undefined;
+};
+
+/** Returns the gcloud configuration directory of the host. */
+export const hostConfigDir = (env: NodeJS.ProcessEnv = process.env): string =>
+  env['CLOUDSDK_CONFIG'] ?? path.join(os.homedir(), '.config', 'gcloud');
+
+export const containerName = (): string => `gcloud-mcp-${randomUUID().slice(0, 8)}`;
+
+/** Returns a file in the container to record the session of a command that may be killed in. */
+export const containerPidFile = (): string => `/tmp/gcloud-mcp-${randomUUID()}.pid`;
+
+// Runs gcloud in the session setsid started, whose ID is the shell's PID, and
+// records it in the file passed as $0 while gcloud runs.
+const SESSION_SCRIPT = 'echo $$ > "$0"; gcloud "$@"; status=$?; rm -f "$0"; exit $status';
+
+/** Arguments starting the long-lived container every command is executed in. */
+export const containerRunArgs = (config: ContainerConfig, name: string, configDir: string) => [
+  'run',
+  '--detach',
+  '--rm',
+  '--name',
+  name,
+  '--volume',
+  `${configDir}:${CONTAINER_CONFIG_DIR}`,
+  '--entrypoint',
+  'sleep',
+  config.image,
+  'infinity',
+];
+
+/**
+ * Returns the path in the container of a configuration directory of the host,
+ * if it is in the mounted directory, e.g. that of a remote session.
+ */
+const containerConfigDirOf = (configDir: string): string | undefined => {
+  const relative = path.relative(hostConfigDir(), configDir);
+  if (relative === '' || relative.startsWith('..') || path.isAbsolute(relative)) {
+    return undefined;
+  }
+  return path.posix.join(CONTAINER_CONFIG_DIR, ...relative.split(path.sep));
+};
+
+/**
+ * Arguments executing gcloud in the container. The container does not inherit
+ * the server's environment, so the gcloud properties set on the host and the
+ * overrides are passed explicitly. CLOUDSDK_CONFIG is a host path and is
+ * replaced by the mount, or the path in it of an override.
+ *
+ * Killing the runtime client does not stop the command in the container, so
+ * with a `pidFile` gcloud runs in a session of its own recorded in that file,
+ * which `containerKillArgs` kills.
+ */
+export const containerExecArgs = (
+  name: string,
+  args: string[],
+  env?: NodeJS.ProcessEnv,
+  pidFile?: string,
+) => {
+  const properties = Object.entries({ ...process.env, ...env }).filter(
+    ([key, value]) => key.startsWith('CLOUDSDK_') && key !== 'CLOUDSDK_CONFIG' && value,
+  );
+  const overriddenConfigDir = env?.['CLOUDSDK_CONFIG'];
+  const configDir = overriddenConfigDir && containerConfigDirOf(overriddenConfigDir);
+  return [
+    'exec',
+    ...properties.flatMap(([key, value]) => ['--env', `${key}=${value}`]),
+    ...(configDir ? ['--env', `CLOUDSDK_CONFIG=${configDir}`] : []),
+    name,
+    ...(pidFile ? ['setsid', '--wait', 'sh', '-c', SESSION_SCRIPT, pidFile] : ['gcloud']),
+    ...args,
+  ];
+};
+
+/** Arguments killing gcloud, and the processes it started, in the session recorded in a file. */
+export const containerKillArgs = (name: string, pidFile: string) => [
+  'exec',
+  name,
+  'sh',
+  '-c',
+  'kill -KILL -- -"$(cat "$0")"; rm -f "$0"',
+  pidFile,
+];
@@ -0,0 +1,178 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { BillingCatalog, CATALOG_SERVICES, Sku } from './billing_catalog.js';
+import { ResourceSpecSchema, estimateCosts } from './cost_estimate.js';
+
+vi.mock('./gcloud.js');
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const sku = (
+  description: string,
+  price: number,
+  usageUnit: string,
+  usageType = 'OnDemand',
+  serviceRegions = ['us-central1'],
+): Sku => ({
+  skuId: description,
+  description,
+  category: { resourceFamily: 'Compute', usageType },
+  serviceRegions,
+  pricingInfo: [
+    {
+      pricingExpression: {
+        usageUnit,
+        tieredRates: [
+          {
+            startUsageAmount: 0,
+            unitPrice: { currencyCode: 'USD', units: '0', nanos: Math.round(price * 1e9) },
+          },
+        ],
+      },
+    },
+  ],
+});
+
+const SKUS: Record<string, Sku[]> = {
+  [CATALOG_SERVICES.compute]: [
+    sku('N2 Instance Core running in Americas', 0.03, 'h'),
+    sku('N2 Custom Instance Core running in Americas', 0.05, 'h'),
+    sku('N2 Instance Ram running in Americas', 0.004, 'GiBy.h'),
+    sku('Commitment v1: N2 Cpu in Americas for 1 Year', 0.02, 'h', 'Commit1Yr'),
+    sku('Commitment v1: N2 Ram in Americas for 1 Year', 0.003, 'GiBy.h', 'Commit1Yr'),
+    sku('N2 Instance Core running in Europe', 0.04, 'h', 'OnDemand', ['europe-west1']),
+    sku('Balanced PD Capacity in Americas', 0.1, 'GiBy.mo'),
+  ],
+  [CATALOG_SERVICES.gke]: [sku('Zonal Kubernetes Clusters', 0.1, 'h', 'OnDemand', ['global'])],
+  [CATALOG_SERVICES.cloudSql]: [
+    sku('Cloud SQL for PostgreSQL: Zonal - vCPU in Americas', 0.04, 'h'),
+    sku('Cloud SQL for PostgreSQL: Zonal - RAM in Americas', 0.007, 'GiBy.h'),
+    sku('Cloud SQL for PostgreSQL: Zonal - Standard storage in Americas', 0.17, 'GiBy.mo'),
+  ],
+};
+
+const catalog: BillingCatalog = {
+  skus: async (serviceId) => SKUS[serviceId] ?? [],
+};
+
+const estimate = (resources: unknown[]) =>
+  estimateCosts(mockedGcloud, catalog, ResourceSpecSchema.array().parse(resources));
+
+describe('estimateCosts', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({
+        code: 0,
+        stdout: JSON.stringify([{ guestCpus: 4, memoryMb: 16384 }]),
+        stderr: '',
+      }),
+    };
+  });
+
+  test('prices the vCPUs and memory of a machine type', async () => {
+    const result = await estimate([
+      { type: 'compute-instance', region: 'us-central1', machineType: 'n2-standard-4', count: 2 },
+    ]);
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'compute',
+      'machine-types',
+      'list',
+      '--filter=name=n2-standard-4 AND zone~^us-central1-',
+      '--limit=1',
+      '--format=json(guestCpus,memoryMb)',
+    ]);
+    const [instance] = result.resources;
+    expect(instance?.lineItems.map((item) => [item.description, item.quantity])).toEqual([
+      ['N2 Instance Core running in Americas', 8],
+      ['N2 Instance Ram running in Americas', 32],
+    ]);
+    // 8 vCPUs * 0.03 + 32 GiB * 0.004, for 730 hours.
+    expect(result.totalMonthly).toBe(268.64);
+  });
+
+  test('uses commitment SKUs and explicit shapes', async () => {
+    const result = await estimate([
+      {
+        type: 'compute-instance',
+        region: 'us-central1',
+        vcpus: 2,
+        memoryGb: 8,
+        commitment: '1-year',
+      },
+    ]);
+
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+    expect(result.resources[0]?.lineItems.map((item) => item.skuId)).toEqual([
+      'Commitment v1: N2 Cpu in Americas for 1 Year',
+      'Commitment v1: N2 Ram in Americas for 1 Year',
+    ]);
+  });
+
+  test('prices disks per GiB and month', async () => {
+    const result = await estimate([
+      { type: 'disk', region: 'us-central1', diskType: 'pd-balanced', sizeGb: 500 },
+    ]);
+
+    expect(result.totalMonthly).toBe(50);
+  });
+
+  test('prices GKE nodes, boot disks, and the management fee', async () => {
+    const result = await estimate([
+      { type: 'gke-cluster', region: 'us-central1', machineType: 'n2-standard-4', nodeCount: 3 },
+    ]);
+
+    const [cluster] = result.resources;
+    expect(cluster?.lineItems.map((item) => [item.description, item.quantity])).toEqual([
+      ['N2 Instance Core running in Americas', 12],
+      ['N2 Instance Ram running in Americas', 48],
+      ['Balanced PD Capacity in Americas', 300],
+      ['Zonal Kubernetes Clusters', 1],
+    ]);
+    expect(cluster?.warnings).toEqual([expect.stringContaining('free tier')]);
+  });
+
+  test('prices custom Cloud SQL tiers', async () => {
+    const result = await estimate([
+      { type: 'cloud-sql', region: 'us-central1', tier: 'db-custom-2-7680', storageGb: 100 },
+    ]);
+
+    expect(result.resources[0]?.lineItems.map((item) => item.quantity)).toEqual([2, 7.5, 100]);
+  });
+
+  test('warns about resources without a matching SKU', async () => {
+    const result = await estimate([
+      { type: 'disk', region: 'asia-east1', diskType: 'pd-ssd', sizeGb: 10, name: 'scratch' },
+    ]);
+
+    expect(result.resources[0]).toMatchObject({
+      name: 'scratch',
+      monthly: 0,
+      warnings: ['No price found for pd-ssd disks; it is not included in the estimate.'],
+    });
+  });
+
+  test('rejects unsupported Cloud SQL tiers', async () => {
+    await expect(
+      estimate([{ type: 'cloud-sql', region: 'us-central1', tier: 'db-f1-micro' }]),
+    ).rejects.toThrow('Only custom Cloud SQL tiers');
+  });
+});
@@ -0,0 +1,324 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { BillingCatalog, CATALOG_SERVICES, Sku, unitPriceOf } from './billing_catalog.js';
+
+export const HOURS_PER_MONTH = 730;
+
+const COMMITMENT_USAGE_TYPES = {
+  'on-demand': 'OnDemand',
+  spot: 'Preemptible',
+  '1-year': 'Commit1Yr',
+  '3-year': 'Commit3Yr',
+} as const;
+
+export const DISK_SKU_DESCRIPTIONS = {
+  'pd-standard': 'Storage PD Capacity',
+  'pd-balanced': 'Balanced PD Capacity',
+  'pd-ssd': 'SSD backed PD Capacity',
+} as const;
+
+const MachineShapeSchema = {
+  machineType: z
+    .string()
+    .optional()
+    .describe(
+      'The machine type, e.g. "n2-standard-4". The vCPUs and memory are looked up unless vcpus and memoryGb are set. Defaults to the N2 family.',
+    ),
+  vcpus: z.number().positive().optional(),
+  memoryGb: z.number().positive().optional(),
+  commitment: z.enum(['on-demand', 'spot', '1-year', '3-year']).default('on-demand'),
+};
+
+const CommonSchema = {
+  name: z.string().optional().describe('A label for the resource in the estimate.'),
+  region: z.string().describe('The region, e.g. "us-central1".'),
+};
+
+export const ResourceSpecSchema = z.discriminatedUnion('type', [
+  z.object({
+    type: z.literal('compute-instance'),
+    ...CommonSchema,
+    ...MachineShapeSchema,
+    count: z.number().int().positive().default(1),
+  }),
+  z.object({
+    type: z.literal('disk'),
+    ...CommonSchema,
+    diskType: z.enum(['pd-standard', 'pd-balanced', 'pd-ssd']).default('pd-balanced'),
+    sizeGb: z.number().positive(),
+    count: z.number().int().positive().default(1),
+  }),
+  z.object({
+    type: z.literal('gke-cluster'),
+    ...CommonSchema,
+    ...MachineShapeSchema,
+    nodeCount: z.number().int().positive(),
+    bootDiskGb: z.number().positive().default(100),
+    regional: z.boolean().default(false),
+  }),
+  z.object({
+    type: z.literal('cloud-sql'),
+    ...CommonSchema,
+    tier: z.string().describe('A custom tier, e.g. "db-custom-2-7680".'),
+    engine: z.enum(['mysql', 'postgres']).default('postgres'),
+    highAvailability: z.boolean().default(false),
+    storageGb: z.number().positive().default(10),
+  }),
+]);
+
+export type ResourceSpec = z.infer<typeof ResourceSpecSchema>;
+type MachineSpec = Extract<ResourceSpec, { type: 'compute-instance' | 'gke-cluster' }>;
+
+export interface CostLineItem {
+  description: string;
+  skuId: string;
+  quantity: number;
+  unit: string;
+  unitPrice: number;
+  monthly: number;
+}
+
+export interface CostEstimate {
+  name: string | null;
+  type: ResourceSpec['type'];
+  monthly: number;
+  lineItems: CostLineItem[];
+  warnings: string[];
+}
+
+const cents = (value: number) => Math.round(value * 100) / 100;
+
+/** Prices a SKU for a quantity, e.g. vCPUs, over a month of usage. */
+const lineItem = (sku: Sku, quantity: number): CostLineItem | undefined => {
+  const unitPrice = unitPriceOf(sku);
+  if (!unitPrice) {
+    return undefined;
+  }
+  // Hourly units such as `h` and `GiBy.h` are billed for every hour of the month.
+  const hours = unitPrice.unit.endsWith('h') ? HOURS_PER_MONTH : 1;
+  return {
+    description: sku.description,
+    skuId: sku.skuId,
+    quantity,
+    unit: unitPrice.unit,
+    unitPrice: unitPrice.price,
+    monthly: cents(unitPrice.price * quantity * hours),
+  };
+};
+
+interface Estimate {
+  lineItems: CostLineItem[];
+  warnings: string[];
+  add: (skus: Sku[], predicate: (sku: Sku) => boolean, quantity: number, what: string) => void;
+  /** Adds the line items of another estimate, multiplied by a count. */
+  merge: (other: Estimate, times: number) => void;
+}
+
+const createEstimate = (): Estimate => {
+  const lineItems: CostLineItem[] = [];
+  const warnings: string[] = [];
+  return {
+    lineItems,
+    warnings,
+    add: (skus, predicate, quantity, what) => {
+      const sku = skus.find(predicate);
+      const item = sku && lineItem(sku, quantity);
+      if (item) {
+        lineItems.push(item);
+      } else {
+        warnings.push(`No price found for ${what}; it is not included in the estimate.`);
+      }
+    },
+    merge: (other, times) => {
+      for (const item of other.lineItems) {
+        lineItems.push({
+          ...item,
+          quantity: item.quantity * times,
+          monthly: cents(item.monthly * times),
+        });
+      }
+      warnings.push(...other.warnings);
+    },
+  };
+};
+
+const inRegion = (sku: Sku, region: string) => sku.serviceRegions.includes(region);
+
+/** Looks up the vCPUs and memory of a machine type in one of the region's zones. */
+const machineShape = async (
+  gcloud: GcloudExecutable,
+  spec: MachineSpec,
+): Promise<{ vcpus: number; memoryGb: number }> => {
+  if (spec.vcpus && spec.memoryGb) {
+    return { vcpus: spec.vcpus, memoryGb: spec.memoryGb };
+  }
+  if (!spec.machineType) {
+    throw new Error('Set either machineType or both vcpus and memoryGb.');
+  }
+  const { code, stdout, stderr } = await gcloud.invoke([
+    'compute',
+    'machine-types',
+    'list',
+    `--filter=name=${spec.machineType} AND zone~^${spec.region}-`,
+    '--limit=1',
+    '--format=json(guestCpus,memoryMb)',
+  ]);
+  if (code !== 0) {
+    throw new Error(`Unable to look up machine type ${spec.machineType}: ${stderr}`);
+  }
+  const [shape] = JSON.parse(stdout) as Array<{ guestCpus: number; memoryMb: number }>;
+  if (!shape) {
+    throw new Error(`Machine type ${spec.machineType} is not available in ${spec.region}.`);
+  }
+  return { vcpus: shape.guestCpus, memoryGb: shape.memoryMb / 1024 };
+};
+
+/**
+ * Prices the vCPUs and memory of one VM.
+ *
+ * Compute SKUs are named after the machine family, e.g. "N2 Instance Core
+ * running in Americas" or "Commitment v1: N2 Cpu in Americas for 1 Year".
+ */
+const estimateMachine = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  spec: MachineSpec,
+): Promise<Estimate> => {
+  const { vcpus, memoryGb } = await machineShape(gcloud, spec);
+  const family = (spec.machineType ?? 'n2').split('-')[0]?.toUpperCase() ?? 'N2';
+  const custom = spec.machineType?.includes('custom') ?? false;
+  const usageType = COMMITMENT_USAGE_TYPES[spec.commitment];
+  const familyPattern = new RegExp(`(^|: )${family} `);
+  const matches = (sku: Sku, resource: RegExp) =>
+    sku.category.resourceFamily === 'Compute' &&
+    sku.category.usageType === usageType &&
+    inRegion(sku, spec.region) &&
+    familyPattern.test(sku.description) &&
+    resource.test(sku.description) &&
+    /Custom/.test(sku.description) === custom &&
+    !/Sole Tenancy|Extended|Premium/.test(sku.description);
+
+  const skus = await catalog.skus(CATALOG_SERVICES.compute);
+  const estimate = createEstimate();
+  estimate.add(skus, (sku) => matches(sku, /Core|Cpu/), vcpus, `${family} vCPUs`);
+  estimate.add(skus, (sku) => matches(sku, /Ram/), memoryGb, `${family} memory`);
+  return estimate;
+};
+
+const estimateDisk = async (
+  catalog: BillingCatalog,
+  region: string,
+  diskType: keyof typeof DISK_SKU_DESCRIPTIONS,
+  sizeGb: number,
+): Promise<Estimate> => {
+  const skus = await catalog.skus(CATALOG_SERVICES.compute);
+  const estimate = createEstimate();
+  const description = DISK_SKU_DESCRIPTIONS[diskType];
+  estimate.add(
+    skus,
+    (sku) =>
+      sku.description.startsWith(description) &&
+      sku.category.usageType === 'OnDemand' &&
+      inRegion(sku, region),
+    sizeGb,
+    `${diskType} disks`,
+  );
+  return estimate;
+};
+
+const estimateResource = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  spec: ResourceSpec,
+): Promise<Estimate> => {
+  const estimate = createEstimate();
+  switch (spec.type) {
+    case 'compute-instance':
+      estimate.merge(await estimateMachine(gcloud, catalog, spec), spec.count);
+      break;
+    case 'disk':
+      estimate.merge(
+        await estimateDisk(catalog, spec.region, spec.diskType, spec.sizeGb),
+        spec.count,
+      );
+      break;
+    case 'gke-cluster': {
+      const node = await estimateMachine(gcloud, catalog, spec);
+      node.merge(await estimateDisk(catalog, spec.region, 'pd-balanced', spec.bootDiskGb), 1);
+      estimate.merge(node, spec.nodeCount);
+      const kind = spec.regional ? 'Regional' : 'Zonal';
+      const gkeSkus = await catalog.skus(CATALOG_SERVICES.gke);
+      estimate.add(
+        gkeSkus,
+        (sku) => sku.description.startsWith(`${kind} Kubernetes Clusters`),
+        1,
+        'the cluster management fee',
+      );
+      if (!spec.regional) {
+        estimate.warnings.push(
+          'The GKE free tier credit covers the management fee of one zonal cluster per billing account.',
+        );
+      }
+      break;
+    }
+    case 'cloud-sql': {
+      const tier = /^db-custom-(\d+)-(\d+)$/.exec(spec.tier);
+      if (!tier) {
+        throw new Error('Only custom Cloud SQL tiers like db-custom-2-7680 are supported.');
+      }
+      const engine = spec.engine === 'mysql' ? 'MySQL' : 'PostgreSQL';
+      const prefix = `Cloud SQL for ${engine}: ${spec.highAvailability ? 'Regional' : 'Zonal'} - `;
+      const matches = (resource: string) => (sku: Sku) =>
+        sku.description.startsWith(prefix + resource) && inRegion(sku, spec.region);
+      const skus = await catalog.skus(CATALOG_SERVICES.cloudSql);
+      estimate.add(skus, matches('vCPU'), Number(tier[1]), 'Cloud SQL vCPUs');
+      estimate.add(skus, matches('RAM'), Number(tier[2]) / 1024, 'Cloud SQL memory');
+      estimate.add(skus, matches('Standard storage'), spec.storageGb, 'Cloud SQL storage');
+      break;
+    }
+    default:
+      break;
+  }
+  return estimate;
+};
+
+/** Estimates the monthly list price of planned resources. */
+export const estimateCosts = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  specs: ResourceSpec[],
+): Promise<{ currency: string; totalMonthly: number; resources: CostEstimate[] }> => {
+  const resources = await Promise.all(
+    specs.map(async (spec): Promise<CostEstimate> => {
+      const { lineItems, warnings } = await estimateResource(gcloud, catalog, spec);
+      return {
+        name: spec.name ?? null,
+        type: spec.type,
+        monthly: cents(lineItems.reduce((sum, item) => sum + item.monthly, 0)),
+        lineItems,
+        warnings,
+      };
+    }),
+  );
+  return {
+    currency: 'USD',
+    totalMonthly: cents(resources.reduce((sum, resource) => sum + resource.monthly, 0)),
+    resources,
+  };
+};
@@ -15,7 +15,12 @@
  */
 
 import { describe, expect, it } from 'vitest';
-import { allowCommands, denyCommands } from './denylist.js';
+import {
+  allowCommands,
+  createAccessControlList,
+  denyCommands,
+  validateAccessControlRules,
+} from './denylist.js';
 
 describe('allowCommands', () => {
   it('returns true if the allowlist is empty', () => {
@@ -119,4 +124,76 @@ describe('denyCommands', () => {
     expect(denylist.matches('  storAGE ')).toBe(true);
     expect(denylist.matches('compute')).toBe(false);
   });
+
+  it('only denylists commands that use the flags of a rule', () => {
+    const denylist = denyCommands(['compute instances create --network-tier=premium']);
+    const command = 'compute instances create';
+    expect(denylist.matches(command, ['compute', 'instances', 'create', 'vm'])).toBe(false);
+    expect(denylist.matches(command, ['--network-tier=standard'])).toBe(false);
+    expect(denylist.matches(command, ['--network-tier=PREMIUM'])).toBe(true);
+    expect(denylist.matches(command, ['--network-tier', 'premium'])).toBe(true);
+    expect(denylist.matches('compute instances list', ['--network-tier=premium'])).toBe(false);
+  });
+
+  it('matches flag values that contain =', () => {
+    const denylist = denyCommands(['compute instances create --metadata=k=v']);
+    const command = 'compute instances create';
+    expect(denylist.matches(command, ['--metadata=k=v'])).toBe(true);
+    expect(denylist.matches(command, ['--metadata', 'k=v'])).toBe(true);
+    expect(denylist.matches(command, ['--metadata=k'])).toBe(false);
+    expect(denylist.matches(command, ['--metadata', 'k'])).toBe(false);
+    expect(denylist.matches(command, ['--metadata=k=other'])).toBe(false);
+  });
+
+  it('denylists a flag regardless of its value or the command', () => {
+    const denylist = denyCommands(['--impersonate-service-account']);
+    expect(denylist.matches('storage ls')).toBe(false);
+    expect(denylist.matches('storage ls', ['--impersonate-service-account=sa'])).toBe(true);
+    expect(denylist.matches('storage ls', ['--impersonate-service-account', 'sa'])).toBe(true);
+    expect(denylist.matches('storage ls', ['--impersonate-service-accounts'])).toBe(false);
+  });
+
+  it('returns the matched rule', () => {
+    const denylist = denyCommands(['sql', 'compute ssh']);
+    expect(denylist.matching('beta compute ssh')).toBe('compute ssh');
+    expect(denylist.matching('compute instances list')).toBe(undefined);
+  });
+});
+
+describe('createAccessControlList', () => {
+  it('returns the normalized allow and deny lists', () => {
+    const acl = createAccessControlList(['Compute Instances'], ['beta', 'compute ssh']);
+    expect(acl.lists()).toEqual({
+      allow: ['compute instances'],
+      deny: ['beta', 'compute ssh'],
+    });
+  });
+
+  it('includes the matched rule when a command is denied', () => {
+    const acl = createAccessControlList([], ['compute instances delete --delete-disks']);
+    const result = acl.check('compute instances delete', ['vm', '--delete-disks=all']);
+    expect(result).toEqual({
+      permitted: false,
+      message: expect.stringContaining('matched rule: "compute instances delete --delete-disks"'),
+      rule: 'compute instances delete --delete-disks',
+    });
+    expect(acl.check('compute instances delete', ['vm']).permitted).toBe(true);
+  });
+
+  it('prints both lists', () => {
+    const acl = createAccessControlList(['compute'], ['compute ssh']);
+    const output = acl.print();
+    expect(output).toContain('## Denylisted commands');
+    expect(output).toContain('## Allowlisted commands');
+  });
+});
+
+describe('validateAccessControlRules', () => {
+  it('rejects flags in the allowlist and empty entries', () => {
+    expect(validateAccessControlRules(['compute --zone=us-east1-b'])).toContain(
+      'can not include flags',
+    );
+    expect(validateAccessControlRules([], [' '])).toContain('can not be empty');
+    expect(validateAccessControlRules([], ['compute --zone=us-east1-b'])).toBe(undefined);
+  });
 });
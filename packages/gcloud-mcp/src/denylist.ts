@@ -23,13 +23,15 @@ export type AccessControlResult =
   | {
       permitted: false;
       message: string;
+      /** The denylist rule the command matched. */
+      rule?: string;
     };
 
 const notAllowedMessage = `Execution denied: This command is not on the access control's allowlist.
 * Do not attempt to run this command again - it will always fail. 
 * Instead, proceed a different way or ask the user for clarification.`;
 
-const deniedMessage = `Execution denied: This command is on the access control's denylist.
+const deniedMessage = (rule: string) => `Execution denied: This command is on the access control's denylist (matched rule: "${rule}").
 * Do not attempt to run this command again - it will always fail.
 * Instead, proceed a different way or ask the user for clarification.
 
@@ -37,7 +39,8 @@ const deniedMessage = `Execution denied: This command is on the access control's
 * The denylist is ALWAYS active, blocking potentially interactive or sensitive commands.
 * Command matching is based on prefix.
 * Commands are normalized to ensure only full command groups are matched (e.g., \`app\` matches \`app deploy\` but not \`apphub\`).
-* When a GA (General Availability) command is on the denylist, all its release tracks (e.g., alpha, beta) are also denied.`;
+* When a GA (General Availability) command is on the denylist, all its release tracks (e.g., alpha, beta) are also denied.
+* Rules with flags, e.g. \`compute instances create --network-tier=premium\`, only deny commands that use those flags.`;
 
 export type AccessControlList = ReturnType<typeof createAccessControlList>;
 
@@ -45,15 +48,24 @@ export const createAccessControlList = (allow: string[] = [], deny: string[] = [
   const allowlist = allowCommands(preprocess(allow));
   const denylist = denyCommands(preprocess(deny));
   return {
-    check: (candidate: string): AccessControlResult => {
-      if (denylist.matches(candidate)) {
-        return { permitted: false, message: deniedMessage };
+    /**
+     * Checks a command, e.g. `compute instances create`. Rules with flags
+     * only apply if the arguments of the command are given.
+     */
+    check: (candidate: string, args: string[] = []): AccessControlResult => {
+      const rule = denylist.matching(candidate, args);
+      if (rule) {
+        return { permitted: false, message: deniedMessage(rule), rule };
       }
       if (!allowlist.matches(candidate)) {
         return { permitted: false, message: notAllowedMessage };
       }
       return { permitted: true };
     },
+    lists: () => ({
+      allow: allowlist.get().map((c) => c.trim()),
+      deny: denylist.get().map((c) => c.trim()),
+    }),
     print: () => {
       const hasDenylist = denylist.get().length > 0;
       const hasAllowlist = allowlist.get().length > 0;
@@ -63,6 +75,7 @@ export const createAccessControlList = (allow: string[] = [], deny: string[] = [
 * Command matching is based on prefix.
 * Commands are normalized to ensure only full command groups are matched (e.g., \`app\` matches \`app deploy\` but not \`apphub\`).
 * When a GA (General Availability) command is on the denylist, all its release tracks (e.g., alpha, beta) are also denied.
+* Denylist rules with flags only deny commands that use those flags.
 `;
       if (hasDenylist && hasAllowlist) {
         output += '* The denylist takes precedence over the allowlist.\n';
@@ -75,7 +88,7 @@ export const createAccessControlList = (allow: string[] = [], deny: string[] = [
           .join('\n');
       }
       if (hasAllowlist) {
-        output = '\n## Allowlisted commands:\n\n';
+        output += '\n## Allowlisted commands:\n\n';
         output += allowlist
           .get()
           .map((c) => `- ${c}`)
@@ -95,6 +108,52 @@ const preprocess = (list: string[] = []) =>
 // For example: app and apphub
 const normalizeForComparison = (s: string): string => s.toLowerCase().trim() + ' ';
 
+interface Rule {
+  /** The normalized command prefix, empty if the rule applies to every command. */
+  command: string;
+  /** Flags, e.g. `--network-tier` or `--network-tier=premium`, that must all be used. */
+  flags: string[];
+}
+
+const parseRule = (rule: string): Rule => {
+  const tokens = rule.toLowerCase().trim().split(/\s+/);
+  return {
+    command: tokens.filter((token) => !token.startsWith('--')).join(' '),
+    flags: tokens.filter((token) => token.startsWith('--')),
+  };
+};
+
+/** Returns true if the arguments use the flag, with its value if the flag has one. */
+const usesFlag = (args: string[], flag: string): boolean => {
+  // Only the first = separates the name, as values may contain =, e.g. --metadata=k=v.
+  const separator = flag.indexOf('=');
+  const name = separator === -1 ? flag : flag.slice(0, separator);
+  const value = separator === -1 ? undefined : flag.slice(separator + 1);
+  return args.some((arg, i) => {
+    const normalized = arg.toLowerCase();
+    if (value === undefined) {
+      return normalized === name || normalized.startsWith(`${name}=`);
+    }
+    return normalized === flag || (normalized === name && args[i + 1]?.toLowerCase() === value);
+  });
+};
+
+/** Returns an error message if an access control rule can not be applied. */
+export const validateAccessControlRules = (
+  allow: string[] = [],
+  deny: string[] = [],
+): string | undefined => {
+  const flagged = allow.find((rule) => parseRule(rule).flags.length > 0);
+  if (flagged) {
+    return `Allowlist entries can not include flags: "${flagged.trim()}". Use a denylist rule for flags.`;
+  }
+  const empty = [...allow, ...deny].find((rule) => rule.trim() === '');
+  if (empty !== undefined) {
+    return 'Access control entries can not be empty.';
+  }
+  return undefined;
+};
+
 export const allowCommands = (allow: string[] = []) => ({
   get: () => allow,
   matches: (command: string): boolean => {
@@ -112,24 +171,36 @@ export const allowCommands = (allow: string[] = []) => ({
   },
 });
 
-export const denyCommands = (deny: string[] = []) => ({
-  get: () => deny,
-  matches: (command: string): boolean => {
-    if (deny.length === 0) {
-      return false; // No deny = all commands allowed
-    }
-
+export const denyCommands = (deny: string[] = []) => {
+  /** Returns the first rule that denies the command, if any. */
+  const matching = (command: string, args: string[] = []): string | undefined => {
     // Deny'ing a GA command denies all release tracks.
     // Deny'ing a pre-GA command only denies the specified release track.
     const cmd = normalizeForComparison(command);
     for (const deniedCommand of deny) {
+      const rule = parseRule(deniedCommand);
+      if (rule.command === '' && rule.flags.length === 0) {
+        continue;
+      }
+      if (!rule.flags.every((flag) => usesFlag(args, flag))) {
+        continue;
+      }
+      if (rule.command === '') {
+        return deniedCommand.trim();
+      }
       for (const release of ['', ...PRERELEASE_TRACKS_PRIORITIZED]) {
         // Adds GA release track.
-        if (cmd.startsWith(normalizeForComparison(`${release} ${deniedCommand}`))) {
-          return true;
+        if (cmd.startsWith(normalizeForComparison(`${release} ${rule.command}`))) {
+          return deniedCommand.trim();
         }
       }
     }
-    return false;
-  },
-});
+    return undefined;
+  };
+  return {
+    get: () => deny,
+    matching,
+    matches: (command: string, args: string[] = []): boolean =>
+      matching(command, args) !== undefined,
+  };
+};
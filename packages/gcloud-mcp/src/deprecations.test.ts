@@ -0,0 +1,224 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { DeprecationCheck, assessDeprecations, sqlEndOfLife } from './deprecations.js';
+
+vi.mock('./gcloud.js');
+
+const NOW = new Date('2025-06-01T00:00:00Z');
+
+const fakeGcloud = (responses: Record<string, unknown>): gcloud.GcloudExecutable => ({
+  lint: vi.fn(),
+  invoke: vi.fn(async (args: string[]) => {
+    const command = args.join(' ');
+    const key = Object.keys(responses).find((prefix) => command.startsWith(prefix));
+    return key
+      ? { code: 0, stdout: JSON.stringify(responses[key]), stderr: '' }
+      : { code: 1, stdout: '', stderr: `unexpected: ${command}` };
+  }),
+});
+
+const assess = (gcloud: gcloud.GcloudExecutable, ...checks: DeprecationCheck[]) =>
+  assessDeprecations(gcloud, { projects: ['p'], checks, horizonDays: 365, now: NOW });
+
+describe('sqlEndOfLife', () => {
+  test('matches database versions by the longest prefix', () => {
+    expect(sqlEndOfLife('MYSQL_8_0_31')).toBe('2026-04-30');
+    expect(sqlEndOfLife('POSTGRES_9_6')).toBe('2021-11-11');
+    expect(sqlEndOfLife('SQLSERVER_2019_STANDARD')).toBe('2030-01-08');
+    expect(sqlEndOfLife('POSTGRES_1')).toBeNull();
+  });
+});
+
+describe('assessDeprecations', () => {
+  test('compares GKE versions with the versions still offered', async () => {
+    const gcloud = fakeGcloud({
+      'container clusters list': [
+        {
+          name: 'prod',
+          location: 'us-central1',
+          currentMasterVersion: '1.27.3-gke.100',
+          currentNodeVersion: '1.28.1-gke.200',
+        },
+        {
+          name: 'dev',
+          location: 'us-central1-a',
+          currentMasterVersion: '1.29.1-gke.100',
+          currentNodeVersion: '1.29.1-gke.100',
+        },
+      ],
+      'container get-server-config': {
+        validMasterVersions: ['1.29.1-gke.100', '1.28.5-gke.300'],
+        validNodeVersions: ['1.29.1-gke.100', '1.28.5-gke.300', '1.28.1-gke.200'],
+      },
+    });
+
+    const report = await assess(gcloud, 'gke-versions');
+
+    expect(report.findings).toMatchObject([
+      { resource: 'prod', current: '1.27.3-gke.100', status: 'end-of-life', date: null },
+      { resource: 'prod', current: '1.28.1-gke.200', status: 'upcoming', date: null },
+    ]);
+    expect(vi.mocked(gcloud.invoke).mock.calls.map(([args]) => args[2])).toEqual([
+      'list',
+      '--region=us-central1',
+      '--zone=us-central1-a',
+    ]);
+  });
+
+  test('reports Cloud Functions runtimes by their lifecycle dates', async () => {
+    const gcloud = fakeGcloud({
+      'functions list': [
+        {
+          name: 'projects/p/locations/us-east1/functions/api',
+          buildConfig: { runtime: 'nodejs16' },
+        },
+        { name: 'projects/p/locations/us-east1/functions/jobs', runtime: 'python38' },
+        { name: 'projects/p/locations/us-east1/functions/web', buildConfig: { runtime: 'go122' } },
+      ],
+      'functions runtimes list': [
+        {
+          name: 'nodejs16',
+          stage: 'DEPRECATED',
+          deprecationDate: { year: 2024, month: 1, day: 30 },
+          decommissionDate: { year: 2025, month: 1, day: 30 },
+        },
+        { name: 'python38', stage: 'GA', deprecationDate: '2025-10-14' },
+        { name: 'go122', stage: 'GA' },
+      ],
+    });
+
+    const report = await assess(gcloud, 'function-runtimes');
+
+    expect(report.findings).toMatchObject([
+      { resource: 'api', location: 'us-east1', status: 'end-of-life', date: '2025-01-30' },
+      { resource: 'jobs', status: 'upcoming', date: '2025-10-14', daysUntil: 135 },
+    ]);
+    expect(gcloud.invoke).toHaveBeenCalledTimes(2);
+  });
+
+  test('skips projects without an App Engine application', async () => {
+    const gcloud: gcloud.GcloudExecutable = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({
+        code: 1,
+        stdout: '',
+        stderr:
+          'ERROR: (gcloud.app.versions.list) Project [p] does not contain an App Engine application.',
+      }),
+    };
+
+    const report = await assess(gcloud, 'app-engine-runtimes');
+
+    expect(report.findings).toEqual([]);
+    expect(report.errors).toEqual([]);
+  });
+
+  test('reports App Engine versions on deprecated runtimes', async () => {
+    const gcloud = fakeGcloud({
+      'app versions list': [{ id: 'v1', service: 'default', version: { runtime: 'python27' } }],
+      'app runtimes list': [{ name: 'python27', stage: 'DEPRECATED' }],
+    });
+
+    const report = await assess(gcloud, 'app-engine-runtimes');
+
+    expect(report.findings).toMatchObject([
+      { resource: 'default/v1', current: 'python27', status: 'deprecated' },
+    ]);
+  });
+
+  test('reports Cloud SQL versions past or near their end of life', async () => {
+    const gcloud = fakeGcloud({
+      'sql instances list': [
+        { name: 'billing', region: 'us-central1', databaseVersion: 'MYSQL_8_0_31' },
+        { name: 'orders', region: 'us-central1', databaseVersion: 'POSTGRES_12' },
+        { name: 'users', region: 'us-central1', databaseVersion: 'POSTGRES_16' },
+      ],
+    });
+
+    const report = await assess(gcloud, 'sql-versions');
+
+    expect(report.summary).toEqual({ 'end-of-life': 1, deprecated: 0, upcoming: 1 });
+    expect(report.findings).toMatchObject([
+      { resource: 'orders', status: 'end-of-life', date: '2024-11-21', daysUntil: -192 },
+      { resource: 'billing', status: 'upcoming', date: '2026-04-30', daysUntil: 333 },
+    ]);
+  });
+
+  test('reports disks created from deprecated images', async () => {
+    const gcloud = fakeGcloud({
+      'compute disks list': [
+        {
+          name: 'web-boot',
+          zone: 'https://compute.googleapis.com/compute/v1/projects/p/zones/us-central1-a',
+          sourceImage:
+            'https://compute.googleapis.com/compute/v1/projects/debian-cloud/global/images/debian-10-buster-v20240110',
+          users: ['instances/web'],
+        },
+        {
+          name: 'spare',
+          sourceImage: 'projects/debian-cloud/global/images/debian-10-buster-v20240110',
+        },
+      ],
+      'compute images describe': {
+        deprecated: {
+          state: 'DEPRECATED',
+          replacement:
+            'https://compute.googleapis.com/compute/v1/projects/debian-cloud/global/images/debian-12-bookworm-v20240110',
+        },
+      },
+    });
+
+    const report = await assess(gcloud, 'images');
+
+    expect(report.findings).toMatchObject([
+      {
+        resource: 'web-boot',
+        location: 'us-central1-a',
+        current: 'debian-10-buster-v20240110',
+        status: 'deprecated',
+        recommendation: 'Rebuild the VM from the replacement image debian-12-bookworm-v20240110.',
+      },
+    ]);
+    expect(vi.mocked(gcloud.invoke).mock.calls[1]![0]).toContain('--project=debian-cloud');
+  });
+
+  test('describes each machine type once', async () => {
+    const gcloud = fakeGcloud({
+      'compute instances list': [
+        { name: 'a', zone: 'zones/us-central1-a', machineType: 'machineTypes/n1-standard-1' },
+        { name: 'b', zone: 'zones/us-central1-a', machineType: 'machineTypes/n1-standard-1' },
+        { name: 'c', zone: 'zones/us-central1-a', machineType: 'machineTypes/custom-2-4096' },
+      ],
+      'compute machine-types describe': {},
+    });
+
+    const report = await assess(gcloud, 'machine-types');
+
+    expect(report.findings).toEqual([]);
+    expect(gcloud.invoke).toHaveBeenCalledTimes(2);
+  });
+
+  test('reports failed checks as errors', async () => {
+    const report = await assess(fakeGcloud({}), 'sql-versions');
+
+    expect(report.errors).toEqual([
+      { project: 'p', check: 'sql-versions', message: expect.stringContaining('unexpected') },
+    ]);
+  });
+});
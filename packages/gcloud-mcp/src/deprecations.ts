@@ -0,0 +1,594 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson, isZone, lastSegment } from './gcloud_json.js';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+export const DEPRECATION_CHECKS = [
+  'gke-versions',
+  'function-runtimes',
+  'app-engine-runtimes',
+  'sql-versions',
+  'images',
+  'machine-types',
+] as const;
+
+export type DeprecationCheck = (typeof DEPRECATION_CHECKS)[number];
+
+/** The gcloud commands each check runs. */
+export const DEPRECATION_CHECK_COMMANDS: Record<DeprecationCheck, string[]> = {
+  'gke-versions': ['container clusters list', 'container get-server-config'],
+  'function-runtimes': ['functions list', 'functions runtimes list'],
+  'app-engine-runtimes': ['app versions list', 'app runtimes list'],
+  'sql-versions': ['sql instances list'],
+  images: ['compute disks list', 'compute images describe'],
+  'machine-types': ['compute instances list', 'compute machine-types describe'],
+};
+
+// Community end-of-life dates from the MySQL, PostgreSQL and SQL Server
+// (extended support) lifecycle policies. Cloud SQL versions are matched by
+// the longest prefix, e.g. MYSQL_8_0_31 by MYSQL_8_0.
+export const SQL_END_OF_LIFE: Record<string, string> = {
+  MYSQL_5_6: '2021-02-05',
+  MYSQL_5_7: '2023-10-31',
+  MYSQL_8_0: '2026-04-30',
+  POSTGRES_9_6: '2021-11-11',
+  POSTGRES_10: '2022-11-10',
+  POSTGRES_11: '2023-11-09',
+  POSTGRES_12: '2024-11-21',
+  POSTGRES_13: '2025-11-13',
+  POSTGRES_14: '2026-11-12',
+  POSTGRES_15: '2027-11-11',
+  POSTGRES_16: '2028-11-09',
+  SQLSERVER_2017: '2027-10-12',
+  SQLSERVER_2019: '2030-01-08',
+};
+
+export const DEPRECATION_STATUSES = ['end-of-life', 'deprecated', 'upcoming'] as const;
+
+export type DeprecationStatus = (typeof DEPRECATION_STATUSES)[number];
+
+export interface DeprecationFinding {
+  project: string;
+  check: DeprecationCheck;
+  resource: string;
+  location: string;
+  /** The version, runtime, image or machine type in use. */
+  current: string;
+  /** Past end of life, deprecated, or reaching either within the horizon. */
+  status: DeprecationStatus;
+  /** The date the next stage is reached, or was reached for end of life. */
+  date: string | null;
+  daysUntil: number | null;
+  detail: string;
+  recommendation: string;
+}
+
+export interface DeprecationReport {
+  projects: string[];
+  horizonDays: number;
+  summary: Record<DeprecationStatus, number>;
+  /** End of life first, then by date. */
+  findings: DeprecationFinding[];
+  errors: Array<{ project: string; check: DeprecationCheck; message: string }>;
+}
+
+export interface DeprecationOptions {
+  projects: string[];
+  checks: readonly DeprecationCheck[];
+  /** Dates further away than this are not reported. */
+  horizonDays: number;
+  now?: Date;
+}
+
+// Newer APIs return dates as strings, older ones as google.type.Date.
+const DateSchema = z
+  .union([z.string(), z.object({ year: z.number(), month: z.number(), day: z.number() })])
+  .nullish();
+
+const ClusterSchema = z.object({
+  name: z.string(),
+  location: z.string(),
+  currentMasterVersion: z.string().nullish(),
+  currentNodeVersion: z.string().nullish(),
+});
+
+const ServerConfigSchema = z.object({
+  validMasterVersions: z.array(z.string()).nullish(),
+  validNodeVersions: z.array(z.string()).nullish(),
+});
+
+const FunctionSchema = z.object({
+  name: z.string(),
+  runtime: z.string().nullish(),
+  buildConfig: z.object({ runtime: z.string().nullish() }).nullish(),
+});
+
+const RuntimeSchema = z.object({
+  name: z.string(),
+  stage: z.string().nullish(),
+  deprecationDate: DateSchema,
+  decommissionDate: DateSchema,
+  decommissionedDate: DateSchema,
+  endOfSupportDate: DateSchema,
+});
+
+const AppVersionSchema = z.object({
+  id: z.string(),
+  service: z.string(),
+  version: z.object({ runtime: z.string().nullish(), env: z.string().nullish() }).nullish(),
+});
+
+const SqlInstanceSchema = z.object({
+  name: z.string(),
+  region: z.string().nullish(),
+  databaseVersion: z.string().nullish(),
+});
+
+const DeprecatedSchema = z
+  .object({
+    state: z.string().nullish(),
+    replacement: z.string().nullish(),
+    deprecated: z.string().nullish(),
+    obsolete: z.string().nullish(),
+    deleted: z.string().nullish(),
+  })
+  .nullish();
+
+const DiskSchema = z.object({
+  name: z.string(),
+  zone: z.string().nullish(),
+  region: z.string().nullish(),
+  sourceImage: z.string().nullish(),
+  users: z.array(z.string()).nullish(),
+});
+
+const InstanceSchema = z.object({ name: z.string(), zone: z.string(), machineType: z.string() });
+
+// The deprecation states of images and machine types.
+const RESOURCE_STATUSES: Record<string, DeprecationStatus> = {
+  DEPRECATED: 'deprecated',
+  OBSOLETE: 'end-of-life',
+  DELETED: 'end-of-life',
+};
+
+const isoDateOf = (date: z.infer<typeof DateSchema>): string | null => {
+  if (!date) {
+    return null;
+  }
+  if (typeof date === 'string') {
+    return date.slice(0, 10);
+  }
+  const pad = (value: number) => String(value).padStart(2, '0');
+  return `${date.year}-${pad(date.month)}-${pad(date.day)}`;
+};
+
+// Versions look like 1.29.4-gke.1043002.
+const minorOf = (version: string) => version.split('.').slice(0, 2).join('.');
+
+const compareMinors = (a: string, b: string) => {
+  const [aMajor = 0, aMinor = 0] = a.split('.').map(Number);
+  const [bMajor = 0, bMinor = 0] = b.split('.').map(Number);
+  return aMajor - bMajor || aMinor - bMinor;
+};
+
+/** Returns the Cloud SQL end-of-life date of a database version, if it is known. */
+export const sqlEndOfLife = (databaseVersion: string): string | null => {
+  const key = Object.keys(SQL_END_OF_LIFE)
+    .filter((prefix) => databaseVersion === prefix || databaseVersion.startsWith(`${prefix}_`))
+    .sort((a, b) => b.length - a.length)[0];
+  return key ? (SQL_END_OF_LIFE[key] ?? null) : null;
+};
+
+type DeprecationIssue = Omit<DeprecationFinding, 'project' | 'check' | 'daysUntil'>;
+
+type DeprecationCheckRunner = (project: string) => Promise<DeprecationIssue[]>;
+
+/**
+ * Inventories the versions and runtimes in use and reports those past end of
+ * life, deprecated, or reaching either within the horizon: GKE versions,
+ * Cloud Functions and App Engine runtimes, Cloud SQL database versions, and
+ * the images and machine types of VMs.
+ */
+export const assessDeprecations = async (
+  gcloud: GcloudExecutable,
+  options: DeprecationOptions,
+): Promise<DeprecationReport> => {
+  const { projects, checks, horizonDays } = options;
+  const now = options.now ?? new Date();
+  const horizon = now.getTime() + horizonDays * DAY_MS;
+  const isPast = (date: string) => Date.parse(date) <= now.getTime();
+  const isWithinHorizon = (date: string) => Date.parse(date) <= horizon;
+
+  /** Classifies a runtime by its lifecycle dates, or returns null if it is not affected yet. */
+  const runtimeStatus = (
+    runtime: z.infer<typeof RuntimeSchema> | undefined,
+  ): { status: DeprecationStatus; date: string | null } | null => {
+    if (!runtime) {
+      return null;
+    }
+    const deprecation = isoDateOf(runtime.deprecationDate ?? runtime.endOfSupportDate);
+    const decommission = isoDateOf(runtime.decommissionDate ?? runtime.decommissionedDate);
+    if ((decommission && isPast(decommission)) || runtime.stage === 'DECOMMISSIONED') {
+      return { status: 'end-of-life', date: decommission };
+    }
+    if ((deprecation && isPast(deprecation)) || runtime.stage === 'DEPRECATED') {
+      return { status: 'deprecated', date: decommission };
+    }
+    if (deprecation && isWithinHorizon(deprecation)) {
+      return { status: 'upcoming', date: deprecation };
+    }
+    if (decommission && isWithinHorizon(decommission)) {
+      return { status: 'upcoming', date: decommission };
+    }
+    return null;
+  };
+
+  /** Classifies a deprecated image or machine type, or returns null if it is not deprecated. */
+  const resourceStatus = (
+    deprecated: z.infer<typeof DeprecatedSchema>,
+  ): { status: DeprecationStatus; date: string | null } | null => {
+    const status = deprecated?.state ? RESOURCE_STATUSES[deprecated.state] : undefined;
+    return status ? { status, date: isoDateOf(deprecated?.obsolete ?? deprecated?.deleted) } : null;
+  };
+
+  const runners: Record<DeprecationCheck, DeprecationCheckRunner> = {
+    'gke-versions': async (project) => {
+      const clusters = z
+        .array(ClusterSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'container',
+            'clusters',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,location,currentMasterVersion,currentNodeVersion)',
+          ]),
+        );
+      const configs = new Map<string, Promise<z.infer<typeof ServerConfigSchema>>>();
+      const configOf = (location: string) => {
+        let config = configs.get(location);
+        if (!config) {
+          config = invokeJson(gcloud, [
+            'container',
+            'get-server-config',
+            isZone(location) ? `--zone=${location}` : `--region=${location}`,
+            `--project=${project}`,
+            '--format=json(validMasterVersions,validNodeVersions)',
+          ]).then((value) => ServerConfigSchema.parse(value));
+          configs.set(location, config);
+        }
+        return config;
+      };
+      const issues: DeprecationIssue[] = [];
+      for (const cluster of clusters) {
+        const config = await configOf(cluster.location);
+        const targets = [
+          {
+            part: 'control plane',
+            version: cluster.currentMasterVersion,
+            valid: config.validMasterVersions,
+          },
+          { part: 'nodes', version: cluster.currentNodeVersion, valid: config.validNodeVersions },
+        ];
+        for (const { part, version, valid } of targets) {
+          const minors = [...new Set((valid ?? []).map(minorOf))].sort(compareMinors);
+          const oldest = minors[0];
+          if (!version || !oldest) {
+            continue;
+          }
+          const minor = minorOf(version);
+          if (compareMinors(minor, oldest) < 0) {
+            issues.push({
+              resource: cluster.name,
+              location: cluster.location,
+              current: version,
+              status: 'end-of-life',
+              date: null,
+              detail: `GKE ${minor}, used by the ${part}, is no longer offered in ${cluster.location}; GKE upgrades unsupported versions automatically.`,
+              recommendation: `Upgrade the ${part} to ${minors.at(-1) ?? oldest} or another supported version at a time of your choosing, after checking for deprecated Kubernetes APIs.`,
+            });
+          } else if (minor === oldest) {
+            issues.push({
+              resource: cluster.name,
+              location: cluster.location,
+              current: version,
+              status: 'upcoming',
+              date: null,
+              detail: `GKE ${minor}, used by the ${part}, is the oldest version offered in ${cluster.location}, so it is the next to reach end of support.`,
+              recommendation: `Plan an upgrade of the ${part} to a newer minor version, or enroll the cluster in a release channel.`,
+            });
+          }
+        }
+      }
+      return issues;
+    },
+    'function-runtimes': async (project) => {
+      const functions = z
+        .array(FunctionSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'functions',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,runtime,buildConfig.runtime)',
+          ]),
+        );
+      const runtimeLists = new Map<string, Promise<Array<z.infer<typeof RuntimeSchema>>>>();
+      const runtimesOf = (region: string) => {
+        let runtimes = runtimeLists.get(region);
+        if (!runtimes) {
+          runtimes = invokeJson(gcloud, [
+            'functions',
+            'runtimes',
+            'list',
+            `--region=${region}`,
+            `--project=${project}`,
+            '--format=json',
+          ]).then((value) => z.array(RuntimeSchema).parse(value));
+          runtimeLists.set(region, runtimes);
+        }
+        return runtimes;
+      };
+      const issues: DeprecationIssue[] = [];
+      for (const fn of functions) {
+        // Functions are named projects/PROJECT/locations/REGION/functions/NAME.
+        const region = fn.name.split('/')[3] ?? '';
+        const runtime = fn.buildConfig?.runtime ?? fn.runtime;
+        if (!runtime || !region) {
+          continue;
+        }
+        const known = (await runtimesOf(region)).find(({ name }) => name === runtime);
+        const status = runtimeStatus(known);
+        if (status) {
+          const stage = status.status === 'upcoming' ? 'about to be deprecated' : status.status;
+          issues.push({
+            resource: lastSegment(fn.name),
+            location: region,
+            current: runtime,
+            ...status,
+            detail: `The function runs on ${runtime}, which is ${stage}. Decommissioned runtimes can not be deployed to.`,
+            recommendation: `Redeploy the function on a supported runtime with "gcloud functions deploy ${lastSegment(fn.name)} --runtime=RUNTIME --region=${region}".`,
+          });
+        }
+      }
+      return issues;
+    },
+    'app-engine-runtimes': async (project) => {
+      const args = ['app', 'versions', 'list', `--project=${project}`, '--format=json'];
+      const { code, stdout, stderr } = await gcloud.invoke(args);
+      if (code !== 0) {
+        // Most projects have no App Engine application, which is not an error here.
+        if (/does not contain an App Engine application/i.test(stderr)) {
+          return [];
+        }
+        throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+      }
+      const versions = z.array(AppVersionSchema).parse(JSON.parse(stdout));
+      const runtimes =
+        versions.length > 0
+          ? z
+              .array(RuntimeSchema)
+              .parse(
+                await invokeJson(gcloud, [
+                  'app',
+                  'runtimes',
+                  'list',
+                  '--environment=standard',
+                  `--project=${project}`,
+                  '--format=json',
+                ]),
+              )
+          : [];
+      return versions.flatMap((version): DeprecationIssue[] => {
+        const runtime = version.version?.runtime;
+        const known = runtimes.find(({ name }) => name === runtime);
+        const status = runtime ? runtimeStatus(known) : null;
+        if (!runtime || !status) {
+          return [];
+        }
+        const stage =
+          status.status === 'upcoming' ? 'about to reach end of support' : status.status;
+        return [
+          {
+            resource: `${version.service}/${version.id}`,
+            location: 'global',
+            current: runtime,
+            ...status,
+            detail: `The version runs on ${runtime}, which is ${stage}.`,
+            recommendation: `Migrate the ${version.service} service to a supported runtime and deploy a new version.`,
+          },
+        ];
+      });
+    },
+    'sql-versions': async (project) => {
+      const instances = z
+        .array(SqlInstanceSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'sql',
+            'instances',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,region,databaseVersion)',
+          ]),
+        );
+      return instances.flatMap((instance): DeprecationIssue[] => {
+        const endOfLife = instance.databaseVersion && sqlEndOfLife(instance.databaseVersion);
+        if (!instance.databaseVersion || !endOfLife || !isWithinHorizon(endOfLife)) {
+          return [];
+        }
+        const past = isPast(endOfLife);
+        return [
+          {
+            resource: instance.name,
+            location: instance.region ?? '',
+            current: instance.databaseVersion,
+            status: past ? 'end-of-life' : 'upcoming',
+            date: endOfLife,
+            detail: past
+              ? `${instance.databaseVersion} reached its community end of life on ${endOfLife}. Cloud SQL keeps it running under extended support, which is charged.`
+              : `${instance.databaseVersion} reaches its community end of life on ${endOfLife}, after which Cloud SQL charges for extended support.`,
+            recommendation: `Upgrade the major version in place with "gcloud sql instances patch ${instance.name} --database-version=VERSION" after testing on a clone.`,
+          },
+        ];
+      });
+    },
+    images: async (project) => {
+      const disks = z
+        .array(DiskSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'compute',
+            'disks',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,zone,region,sourceImage,users)',
+          ]),
+        )
+        .filter((disk) => disk.sourceImage && (disk.users?.length ?? 0) > 0);
+      const images = new Map<string, Promise<z.infer<typeof DeprecatedSchema>>>();
+      const issues: DeprecationIssue[] = [];
+      for (const disk of disks) {
+        // Images are URLs like .../projects/debian-cloud/global/images/NAME.
+        const image = disk.sourceImage ?? '';
+        let deprecated = images.get(image);
+        if (!deprecated) {
+          const imageProject = image.match(/projects\/([^/]+)\//)?.[1] ?? project;
+          deprecated = invokeJson(gcloud, [
+            'compute',
+            'images',
+            'describe',
+            lastSegment(image),
+            `--project=${imageProject}`,
+            '--format=json(deprecated)',
+          ]).then((value) => z.object({ deprecated: DeprecatedSchema }).parse(value).deprecated);
+          images.set(image, deprecated);
+        }
+        const description = await deprecated;
+        const status = resourceStatus(description);
+        if (status) {
+          issues.push({
+            resource: disk.name,
+            location: lastSegment(disk.zone ?? disk.region ?? ''),
+            current: lastSegment(image),
+            ...status,
+            detail: `The disk was created from ${lastSegment(image)}, which is ${status.status}; it no longer gets security updates from new images.`,
+            recommendation: description?.replacement
+              ? `Rebuild the VM from the replacement image ${lastSegment(description.replacement)}.`
+              : 'Rebuild the VM from the latest image of a supported image family.',
+          });
+        }
+      }
+      return issues;
+    },
+    'machine-types': async (project) => {
+      const instances = z
+        .array(InstanceSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'compute',
+            'instances',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,zone,machineType)',
+          ]),
+        );
+      const machineTypes = new Map<string, Promise<z.infer<typeof DeprecatedSchema>>>();
+      const issues: DeprecationIssue[] = [];
+      for (const instance of instances) {
+        const zone = lastSegment(instance.zone);
+        const machineType = lastSegment(instance.machineType);
+        // Custom machine types are never deprecated on their own.
+        if (machineType.includes('custom')) {
+          continue;
+        }
+        const key = `${zone}/${machineType}`;
+        let deprecated = machineTypes.get(key);
+        if (!deprecated) {
+          deprecated = invokeJson(gcloud, [
+            'compute',
+            'machine-types',
+            'describe',
+            machineType,
+            `--zone=${zone}`,
+            `--project=${project}`,
+            '--format=json(deprecated)',
+          ]).then((value) => z.object({ deprecated: DeprecatedSchema }).parse(value).deprecated);
+          machineTypes.set(key, deprecated);
+        }
+        const description = await deprecated;
+        const status = resourceStatus(description);
+        if (status) {
+          const replacement = description?.replacement
+            ? lastSegment(description.replacement)
+            : 'MACHINE_TYPE';
+          issues.push({
+            resource: instance.name,
+            location: zone,
+            current: machineType,
+            ...status,
+            detail: `The VM runs on ${machineType}, which is ${status.status} in ${zone}.`,
+            recommendation: `Stop the VM and change its machine type with "gcloud compute instances set-machine-type ${instance.name} --zone=${zone} --machine-type=${replacement}".`,
+          });
+        }
+      }
+      return issues;
+    },
+  };
+
+  const report: DeprecationReport = {
+    projects,
+    horizonDays,
+    summary: { 'end-of-life': 0, deprecated: 0, upcoming: 0 },
+    findings: [],
+    errors: [],
+  };
+  await Promise.all(
+    projects.flatMap((project) =>
+      checks.map(async (check) => {
+        try {
+          const issues = await runners[check](project);
+          report.findings.push(
+            ...issues.map((issue) => ({
+              project,
+              check,
+              ...issue,
+              daysUntil: issue.date
+                ? Math.ceil((Date.parse(issue.date) - now.getTime()) / DAY_MS)
+                : null,
+            })),
+          );
+        } catch (e: unknown) {
+          const message = e instanceof Error ? e.message : String(e);
+          report.errors.push({ project, check, message });
+        }
+      }),
+    ),
+  );
+  report.findings.sort(
+    (a, b) =>
+      DEPRECATION_STATUSES.indexOf(a.status) - DEPRECATION_STATUSES.indexOf(b.status) ||
+      (a.date ?? '').localeCompare(b.date ?? ''),
+  );
+  for (const { status } of report.findings) {
+    report.summary[status] += 1;
+  }
+  return report;
+};
@@ -0,0 +1,134 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { DR_CHECKS, assessDrReadiness } from './dr_readiness.js';
+
+vi.mock('./gcloud.js');
+
+const ZONE_URL = 'https://compute.googleapis.com/compute/v1/projects/p/zones/us-central1-a';
+
+const fakeGcloud = (responses: Record<string, unknown[]>): gcloud.GcloudExecutable => ({
+  lint: vi.fn(),
+  invoke: vi.fn(async (args: string[]) => {
+    const key = Object.keys(responses).find((prefix) => args.join(' ').startsWith(prefix));
+    return key
+      ? { code: 0, stdout: JSON.stringify(responses[key]), stderr: '' }
+      : { code: 1, stdout: '', stderr: 'API not enabled' };
+  }),
+});
+
+const responses = {
+  'compute instances list': [
+    { name: 'standalone', zone: ZONE_URL },
+    { name: 'web-abcd', zone: ZONE_URL, metadata: { items: [{ key: 'created-by' }] } },
+  ],
+  'compute instance-groups managed list': [
+    { name: 'web', zone: ZONE_URL },
+    { name: 'api', region: 'us-central1' },
+  ],
+  'compute disks list': [
+    { name: 'data', zone: ZONE_URL, users: ['vm'] },
+    { name: 'scheduled', zone: ZONE_URL, users: ['vm'], resourcePolicies: ['daily'] },
+    { name: 'detached', zone: ZONE_URL },
+  ],
+  'sql instances list': [
+    { name: 'db', region: 'us-central1', settings: { availabilityType: 'ZONAL' } },
+    { name: 'orders', region: 'us-central1', settings: { availabilityType: 'REGIONAL' } },
+    {
+      name: 'orders-replica',
+      region: 'us-east1',
+      instanceType: 'READ_REPLICA_INSTANCE',
+      masterInstanceName: 'p:orders',
+    },
+  ],
+  'storage buckets list': [
+    { name: 'logs', location: 'US-CENTRAL1', location_type: 'region' },
+    {
+      name: 'ledger',
+      location: 'US-CENTRAL1',
+      location_type: 'region',
+      labels: { 'data-class': 'critical' },
+    },
+    { name: 'assets', location: 'US', location_type: 'multi-region' },
+  ],
+  'container clusters list': [
+    { name: 'zonal', location: 'us-central1-a' },
+    { name: 'regional', location: 'us-central1' },
+  ],
+};
+
+describe('assessDrReadiness', () => {
+  test('reports single points of failure by severity', async () => {
+    const report = await assessDrReadiness(fakeGcloud(responses), {
+      projects: ['p'],
+      checks: DR_CHECKS,
+      criticalBucketLabels: { 'data-class': 'critical' },
+    });
+
+    const found = (check: string) =>
+      report.findings.filter((f) => f.check === check).map(({ resource }) => resource);
+    expect(found('zonal-compute').sort()).toEqual(['standalone', 'web']);
+    expect(found('unscheduled-disks')).toEqual(['data']);
+    expect(found('sql-high-availability')).toEqual(['db']);
+    expect(found('sql-cross-region-replicas')).toEqual(['db']);
+    expect(found('single-region-buckets').sort()).toEqual(['gs://ledger', 'gs://logs']);
+    expect(found('zonal-gke-clusters')).toEqual(['zonal']);
+    expect(report.findings.find((f) => f.resource === 'gs://ledger')?.severity).toBe('high');
+    expect(report.findings.find((f) => f.resource === 'data')?.recommendation).toContain(
+      '--zone=us-central1-a',
+    );
+    expect(report.summary).toEqual({ high: 3, medium: 3, low: 2 });
+    expect(report.findings.map(({ severity }) => severity)).toEqual([
+      'high',
+      'high',
+      'high',
+      'medium',
+      'medium',
+      'medium',
+      'low',
+      'low',
+    ]);
+    expect(report.errors).toEqual([]);
+  });
+
+  test('lists Cloud SQL instances once per project', async () => {
+    const gcloud = fakeGcloud(responses);
+
+    await assessDrReadiness(gcloud, {
+      projects: ['p'],
+      checks: ['sql-high-availability', 'sql-cross-region-replicas'],
+    });
+
+    expect(gcloud.invoke).toHaveBeenCalledOnce();
+  });
+
+  test('reports checks that fail', async () => {
+    const report = await assessDrReadiness(fakeGcloud({}), {
+      projects: ['p'],
+      checks: ['zonal-gke-clusters'],
+    });
+
+    expect(report.errors).toEqual([
+      {
+        project: 'p',
+        check: 'zonal-gke-clusters',
+        message: expect.stringContaining('API not enabled'),
+      },
+    ]);
+  });
+});
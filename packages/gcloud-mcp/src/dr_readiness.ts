@@ -0,0 +1,328 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson, isZone, lastSegment } from './gcloud_json.js';
+
+export const DR_CHECKS = [
+  'zonal-compute',
+  'unscheduled-disks',
+  'sql-high-availability',
+  'sql-cross-region-replicas',
+  'single-region-buckets',
+  'zonal-gke-clusters',
+] as const;
+
+export type DrCheck = (typeof DR_CHECKS)[number];
+
+/** The gcloud commands each check runs. */
+export const DR_CHECK_COMMANDS: Record<DrCheck, string[]> = {
+  'zonal-compute': ['compute instances list', 'compute instance-groups managed list'],
+  'unscheduled-disks': ['compute disks list'],
+  'sql-high-availability': ['sql instances list'],
+  'sql-cross-region-replicas': ['sql instances list'],
+  'single-region-buckets': ['storage buckets list'],
+  'zonal-gke-clusters': ['container clusters list'],
+};
+
+export type DrSeverity = 'high' | 'medium' | 'low';
+
+export interface DrFinding {
+  project: string;
+  check: DrCheck;
+  severity: DrSeverity;
+  resource: string;
+  location: string;
+  detail: string;
+  /** How to remove the single point of failure. Nothing is run by the report. */
+  recommendation: string;
+}
+
+export interface DrReadinessReport {
+  projects: string[];
+  summary: Record<DrSeverity, number>;
+  /** Findings with the highest severity first. */
+  findings: DrFinding[];
+  errors: Array<{ project: string; check: DrCheck; message: string }>;
+}
+
+export interface DrReadinessOptions {
+  projects: string[];
+  checks: readonly DrCheck[];
+  /** Buckets with any of these label values hold critical data, e.g. `{"data": "critical"}`. */
+  criticalBucketLabels?: Record<string, string>;
+}
+
+const SEVERITY_ORDER: DrSeverity[] = ['high', 'medium', 'low'];
+
+const InstanceSchema = z.object({
+  name: z.string(),
+  zone: z.string(),
+  metadata: z.object({ items: z.array(z.object({ key: z.string() })).nullish() }).nullish(),
+});
+
+const ManagedGroupSchema = z.object({
+  name: z.string(),
+  zone: z.string().nullish(),
+  region: z.string().nullish(),
+});
+
+const DiskSchema = z.object({
+  name: z.string(),
+  zone: z.string().nullish(),
+  region: z.string().nullish(),
+  users: z.array(z.string()).nullish(),
+  resourcePolicies: z.array(z.string()).nullish(),
+});
+
+const SqlInstanceSchema = z.object({
+  name: z.string(),
+  region: z.string().nullish(),
+  instanceType: z.string().nullish(),
+  masterInstanceName: z.string().nullish(),
+  settings: z.object({ availabilityType: z.string().nullish() }).nullish(),
+});
+
+type SqlInstance = z.infer<typeof SqlInstanceSchema>;
+
+const BucketSchema = z.object({
+  name: z.string(),
+  location: z.string().nullish(),
+  location_type: z.string().nullish(),
+  labels: z.record(z.string()).nullish(),
+});
+
+const ClusterSchema = z.object({ name: z.string(), location: z.string() });
+
+// Cloud SQL refers to primaries as PROJECT:INSTANCE.
+const primaryNameOf = (replica: SqlInstance) =>
+  replica.masterInstanceName?.split(':').at(-1) ?? null;
+
+const isPrimary = (instance: SqlInstance) =>
+  (instance.instanceType ?? 'CLOUD_SQL_INSTANCE') === 'CLOUD_SQL_INSTANCE';
+
+type DrIssue = Omit<DrFinding, 'project' | 'check'>;
+
+type DrCheckRunner = (project: string) => Promise<DrIssue[]>;
+
+/**
+ * Assesses the disaster recovery posture of projects: resources pinned to a
+ * single zone or region, disks without snapshot schedules, and Cloud SQL
+ * instances without high availability or cross-region replicas.
+ */
+export const assessDrReadiness = async (
+  gcloud: GcloudExecutable,
+  options: DrReadinessOptions,
+): Promise<DrReadinessReport> => {
+  const { projects, checks, criticalBucketLabels = {} } = options;
+  // Both Cloud SQL checks inspect the instances of a project, so they are listed once.
+  const sqlInstanceLists = new Map<string, Promise<SqlInstance[]>>();
+  const sqlInstancesOf = (project: string) => {
+    let instances = sqlInstanceLists.get(project);
+    if (!instances) {
+      instances = invokeJson(gcloud, [
+        'sql',
+        'instances',
+        'list',
+        `--project=${project}`,
+        '--format=json(name,region,instanceType,masterInstanceName,settings.availabilityType)',
+      ]).then((value) => z.array(SqlInstanceSchema).parse(value));
+      sqlInstanceLists.set(project, instances);
+    }
+    return instances;
+  };
+
+  const runners: Record<DrCheck, DrCheckRunner> = {
+    'zonal-compute': async (project) => {
+      const [instances, groups] = await Promise.all([
+        invokeJson(gcloud, [
+          'compute',
+          'instances',
+          'list',
+          `--project=${project}`,
+          '--format=json(name,zone,metadata.items)',
+        ]).then((value) => z.array(InstanceSchema).parse(value)),
+        invokeJson(gcloud, [
+          'compute',
+          'instance-groups',
+          'managed',
+          'list',
+          `--project=${project}`,
+          '--format=json(name,zone,region)',
+        ]).then((value) => z.array(ManagedGroupSchema).parse(value)),
+      ]);
+      // Instances of managed groups are recreated by the group and reported with it.
+      const standalone = instances.filter(
+        ({ metadata }) => !metadata?.items?.some(({ key }) => key === 'created-by'),
+      );
+      return [
+        ...standalone.map(({ name, zone }): DrIssue => ({
+          severity: 'medium',
+          resource: name,
+          location: lastSegment(zone),
+          detail: 'The VM is not part of a managed instance group, so a zone outage stops it.',
+          recommendation:
+            'Run the workload in a regional managed instance group, or keep a machine image and snapshot schedule to recreate the VM in another zone.',
+        })),
+        ...groups
+          .filter(({ zone }) => zone)
+          .map(({ name, zone }): DrIssue => ({
+            severity: 'low',
+            resource: name,
+            location: lastSegment(zone ?? ''),
+            detail: 'The managed instance group is zonal.',
+            recommendation:
+              'Use a regional managed instance group to spread the instances across zones.',
+          })),
+      ];
+    },
+    'unscheduled-disks': async (project) => {
+      const disks = z
+        .array(DiskSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'compute',
+            'disks',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,zone,region,users,resourcePolicies)',
+          ]),
+        );
+      // Detached disks are reported by find_idle_resources instead.
+      return disks
+        .filter(({ users, resourcePolicies }) => users?.length && !resourcePolicies?.length)
+        .map(({ name, zone, region }): DrIssue => {
+          const location = lastSegment(zone ?? region ?? '');
+          const scope = isZone(location) ? `--zone=${location}` : `--region=${location}`;
+          return {
+            severity: 'high',
+            resource: name,
+            location,
+            detail: 'The disk is in use and has no snapshot schedule.',
+            recommendation: `gcloud compute disks add-resource-policies ${name} --resource-policies=SCHEDULE ${scope} --project=${project}`,
+          };
+        });
+    },
+    'sql-high-availability': async (project) =>
+      (await sqlInstancesOf(project))
+        .filter(
+          (instance) => isPrimary(instance) && instance.settings?.availabilityType !== 'REGIONAL',
+        )
+        .map(({ name, region }): DrIssue => ({
+          severity: 'high',
+          resource: name,
+          location: region ?? '',
+          detail: 'The instance is not highly available, so a zone outage takes it down.',
+          recommendation: `gcloud sql instances patch ${name} --availability-type=REGIONAL --project=${project}`,
+        })),
+    'sql-cross-region-replicas': async (project) => {
+      const instances = await sqlInstancesOf(project);
+      return instances
+        .filter(isPrimary)
+        .filter(
+          (primary) =>
+            !instances.some(
+              (replica) =>
+                primaryNameOf(replica) === primary.name && replica.region !== primary.region,
+            ),
+        )
+        .map(({ name, region }): DrIssue => ({
+          severity: 'medium',
+          resource: name,
+          location: region ?? '',
+          detail: 'The instance has no read replica in another region to fail over to.',
+          recommendation: `gcloud sql instances create ${name}-replica --master-instance-name=${name} --region=OTHER_REGION --project=${project}`,
+        }));
+    },
+    'single-region-buckets': async (project) => {
+      const buckets = z
+        .array(BucketSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'storage',
+            'buckets',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,location,location_type,labels)',
+          ]),
+        );
+      const isCritical = (labels: Record<string, string> | null | undefined) =>
+        Object.entries(criticalBucketLabels).some(([key, value]) => labels?.[key] === value);
+      return buckets
+        .filter(({ location_type }) => location_type?.toLowerCase() === 'region')
+        .map(({ name, location, labels }): DrIssue => ({
+          severity: isCritical(labels) ? 'high' : 'low',
+          resource: `gs://${name}`,
+          location: location?.toLowerCase() ?? '',
+          detail: isCritical(labels)
+            ? 'The bucket holds critical data in a single region.'
+            : 'The bucket is in a single region.',
+          recommendation: `Copy the data to a dual-region or multi-region bucket, e.g. gcloud storage buckets create gs://${name}-dr --location=US --project=${project} && gcloud storage rsync gs://${name} gs://${name}-dr --recursive`,
+        }));
+    },
+    'zonal-gke-clusters': async (project) => {
+      const clusters = z
+        .array(ClusterSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'container',
+            'clusters',
+            'list',
+            `--project=${project}`,
+            '--format=json(name,location)',
+          ]),
+        );
+      return clusters
+        .filter(({ location }) => isZone(location))
+        .map(({ name, location }): DrIssue => ({
+          severity: 'medium',
+          resource: name,
+          location,
+          detail: 'The cluster is zonal, so its control plane is down during a zone outage.',
+          recommendation:
+            'Create a regional cluster and migrate the workloads; a zonal cluster can not be made regional.',
+        }));
+    },
+  };
+
+  const report: DrReadinessReport = {
+    projects,
+    summary: { high: 0, medium: 0, low: 0 },
+    findings: [],
+    errors: [],
+  };
+  await Promise.all(
+    projects.flatMap((project) =>
+      checks.map(async (check) => {
+        try {
+          const findings = await runners[check](project);
+          report.findings.push(...findings.map((finding) => ({ project, check, ...finding })));
+        } catch (e: unknown) {
+          const message = e instanceof Error ? e.message : String(e);
+          report.errors.push({ project, check, message });
+        }
+      }),
+    ),
+  );
+  report.findings.sort(
+    (a, b) => SEVERITY_ORDER.indexOf(a.severity) - SEVERITY_ORDER.indexOf(b.severity),
+  );
+  for (const { severity } of report.findings) {
+    report.summary[severity] += 1;
+  }
+  return report;
+};
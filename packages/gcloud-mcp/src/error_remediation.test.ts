@@ -0,0 +1,175 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import { findRemediation, roleForPermission } from './error_remediation.js';
+
+describe('roleForPermission', () => {
+  test('returns explicitly mapped roles', () => {
+    expect(roleForPermission('iam.serviceAccounts.actAs')).toBe('roles/iam.serviceAccountUser');
+  });
+
+  test('falls back to the service viewer role for read permissions', () => {
+    expect(roleForPermission('run.services.list')).toBe('roles/run.viewer');
+  });
+
+  test('falls back to the service admin role for write permissions', () => {
+    expect(roleForPermission('pubsub.topics.create')).toBe('roles/pubsub.admin');
+  });
+
+  test('returns undefined for unknown services', () => {
+    expect(roleForPermission('unknown.things.list')).toBeUndefined();
+  });
+});
+
+describe('findRemediation', () => {
+  test('maps permission denied errors to a role binding', () => {
+    const remediation = findRemediation(
+      "ERROR: (gcloud.pubsub.topics.create) PERMISSION_DENIED: Permission 'pubsub.topics.create' denied on resource 'projects/my-project/topics/t' (or it may not exist).",
+    );
+    expect(remediation).toEqual({
+      reason: 'PERMISSION_DENIED',
+      summary: expect.stringContaining('pubsub.topics.create'),
+      missingPermission: 'pubsub.topics.create',
+      role: 'roles/pubsub.admin',
+      fixCommand:
+        'gcloud projects add-iam-policy-binding my-project --member=<PRINCIPAL> --role=roles/pubsub.admin',
+    });
+  });
+
+  test('parses storage style permission errors', () => {
+    const remediation = findRemediation(
+      'ERROR: (gcloud.storage.ls) [me@example.com] does not have permission to access b instance [my-bucket] (or it may not exist): me@example.com does not have storage.objects.list access to the Google Cloud Storage bucket.',
+    );
+    expect(remediation?.missingPermission).toBe('storage.objects.list');
+    expect(remediation?.role).toBe('roles/storage.objectViewer');
+    expect(remediation?.fixCommand).toContain('<PROJECT_ID>');
+  });
+
+  test('omits the fix when the permission is unknown', () => {
+    const remediation = findRemediation(
+      'ERROR: (gcloud.foo.list) PERMISSION_DENIED: The caller does not have permission',
+    );
+    expect(remediation).toEqual({
+      reason: 'PERMISSION_DENIED',
+      summary: expect.any(String),
+    });
+  });
+
+  test('maps disabled APIs to services enable', () => {
+    const remediation = findRemediation(
+      'ERROR: (gcloud.run.services.list) PERMISSION_DENIED: Cloud Run Admin API has not been used in project 1234 before or it is disabled. Enable it by visiting https://console.developers.google.com/apis/api/run.googleapis.com/overview?project=1234 then retry.',
+    );
+    expect(remediation).toEqual({
+      reason: 'API_NOT_ENABLED',
+      summary: expect.stringContaining('run.googleapis.com'),
+      missingPermission: 'serviceusage.services.enable',
+      role: 'roles/serviceusage.serviceUsageAdmin',
+      fixCommand: 'gcloud services enable run.googleapis.com --project=1234',
+      fixArgs: ['services', 'enable', 'run.googleapis.com', '--project=1234'],
+    });
+  });
+
+  test('maps regional quota errors to a quota lookup', () => {
+    const remediation = findRemediation(
+      "ERROR: (gcloud.compute.instances.create) Could not fetch resource:\n - Quota 'CPUS' exceeded.  Limit: 24.0 in region us-central1.",
+    );
+    expect(remediation).toEqual({
+      reason: 'QUOTA_EXCEEDED',
+      summary: expect.stringContaining('CPUS'),
+      fixCommand:
+        'gcloud compute regions describe us-central1 --project=<PROJECT_ID> --format="json(quotas)"',
+    });
+  });
+
+  test('maps billing errors to billing projects link', () => {
+    const remediation = findRemediation(
+      'ERROR: (gcloud.services.enable) FAILED_PRECONDITION: Billing account for project [my-project] is not found. Billing must be enabled for activation of service(s). BILLING_DISABLED',
+    );
+    expect(remediation).toEqual({
+      reason: 'BILLING_DISABLED',
+      summary: expect.any(String),
+      missingPermission: 'billing.resourceAssociations.create',
+      role: 'roles/billing.user',
+      fixCommand: 'gcloud billing projects link my-project --billing-account=<BILLING_ACCOUNT_ID>',
+    });
+  });
+
+  test('maps expired credentials to auth login', () => {
+    const remediation = findRemediation(
+      'ERROR: (gcloud.compute.instances.list) There was a problem refreshing your current auth tokens: Reauthentication failed. cannot prompt during non-interactive execution.\nPlease run:\n\n  $ gcloud auth login\n\nto obtain new credentials.\n\nIf you have already logged in with a different account, run:\n\n  $ gcloud config set account ACCOUNT\n\nto select an already authenticated account to use.',
+    );
+    expect(remediation).toEqual({
+      reason: 'AUTH_EXPIRED',
+      summary: expect.stringContaining('ask the user'),
+      fixCommand: 'gcloud auth login',
+    });
+    expect(
+      findRemediation(
+        'ERROR: (gcloud.storage.ls) Your current active account [me@example.com] does not have any valid credentials',
+      )?.fixCommand,
+    ).toBe('gcloud auth login me@example.com');
+  });
+
+  test('renames unknown flags to the flags gcloud suggests', () => {
+    const remediation = findRemediation(
+      "ERROR: (gcloud.compute.instances.list) unrecognized arguments: --zome=us-east1-b (did you mean '--zone'?) \n\nTo search the help text of gcloud commands, run:\n  gcloud help -- SEARCH_TERMS",
+      ['compute', 'instances', 'list', '--zome=us-east1-b', '--limit=5'],
+    );
+    expect(remediation).toEqual({
+      reason: 'UNKNOWN_FLAG',
+      summary: expect.stringContaining('--zome'),
+      unknownFlags: [{ flag: '--zome', suggestion: '--zone' }],
+      fixCommand: 'gcloud compute instances list --zone=us-east1-b --limit=5',
+      fixArgs: ['compute', 'instances', 'list', '--zone=us-east1-b', '--limit=5'],
+    });
+  });
+
+  test('points to the help of unknown flags without a suggestion', () => {
+    const remediation = findRemediation(
+      "ERROR: (gcloud.compute.instances.list) unrecognized arguments:\n  --zome (did you mean '--zone'?)\n  --frobnicate \n\nTo search the help text of gcloud commands, run:\n  gcloud help -- SEARCH_TERMS",
+      ['compute', 'instances', 'list', '--zome', 'us-east1-b', '--frobnicate'],
+    );
+    expect(remediation).toEqual({
+      reason: 'UNKNOWN_FLAG',
+      summary: expect.stringContaining('--zome, --frobnicate'),
+      unknownFlags: [{ flag: '--zome', suggestion: '--zone' }, { flag: '--frobnicate' }],
+      fixCommand: 'gcloud compute instances list --help',
+    });
+  });
+
+  test('maps missing components to components install', () => {
+    expect(
+      findRemediation(
+        'ERROR: (gcloud) You do not currently have this command group installed.  Using it requires the installation of components: [beta]',
+      ),
+    ).toEqual({
+      reason: 'COMPONENT_NOT_INSTALLED',
+      summary:
+        'The beta gcloud component is not installed. Install it with the install_components tool after the user approves.',
+      fixCommand: 'gcloud components install beta',
+    });
+    expect(
+      findRemediation(
+        'ERROR: (gcloud.components.install) You cannot perform this action because the Google Cloud CLI component manager is disabled for this installation.',
+      )?.reason,
+    ).toBe('COMPONENT_MANAGER_DISABLED');
+  });
+
+  test('returns undefined for unrecognized failures', () => {
+    expect(findRemediation('ERROR: (gcloud.compute) Invalid choice: instancez')).toBeUndefined();
+  });
+});
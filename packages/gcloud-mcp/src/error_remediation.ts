@@ -0,0 +1,302 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+export type FailureReason =
+  | 'PERMISSION_DENIED'
+  | 'API_NOT_ENABLED'
+  | 'QUOTA_EXCEEDED'
+  | 'BILLING_DISABLED'
+  | 'AUTH_EXPIRED'
+  | 'UNKNOWN_FLAG'
+  | 'COMPONENT_NOT_INSTALLED'
+  | 'COMPONENT_MANAGER_DISABLED';
+
+export interface Remediation {
+  reason: FailureReason;
+  summary: string;
+  missingPermission?: string;
+  role?: string;
+  fixCommand?: string;
+  /**
+   * The arguments of the fix to pass to run_gcloud_command, set only if the
+   * fix is complete and needs no input from the user.
+   */
+  fixArgs?: string[];
+  unknownFlags?: Array<{ flag: string; suggestion?: string }>;
+}
+
+const PROJECT_PLACEHOLDER = '<PROJECT_ID>';
+const PRINCIPAL_PLACEHOLDER = '<PRINCIPAL>';
+const BILLING_ACCOUNT_PLACEHOLDER = '<BILLING_ACCOUNT_ID>';
+
+// Predefined roles for permissions whose service-level fallback would be too
+// broad or does not exist.
+const PERMISSION_ROLES: Record<string, string> = {
+  'iam.serviceAccounts.actAs': 'roles/iam.serviceAccountUser',
+  'iam.serviceAccounts.getAccessToken': 'roles/iam.serviceAccountTokenCreator',
+  'resourcemanager.projects.get': 'roles/browser',
+  'resourcemanager.projects.getIamPolicy': 'roles/iam.securityReviewer',
+  'resourcemanager.projects.setIamPolicy': 'roles/resourcemanager.projectIamAdmin',
+  'serviceusage.services.enable': 'roles/serviceusage.serviceUsageAdmin',
+  'serviceusage.services.list': 'roles/serviceusage.serviceUsageConsumer',
+  'billing.resourceAssociations.create': 'roles/billing.user',
+  'compute.instances.create': 'roles/compute.instanceAdmin.v1',
+  'compute.instances.delete': 'roles/compute.instanceAdmin.v1',
+  'compute.instances.start': 'roles/compute.instanceAdmin.v1',
+  'compute.instances.stop': 'roles/compute.instanceAdmin.v1',
+  'logging.logEntries.list': 'roles/logging.viewer',
+  'monitoring.timeSeries.list': 'roles/monitoring.viewer',
+  'storage.objects.get': 'roles/storage.objectViewer',
+  'storage.objects.list': 'roles/storage.objectViewer',
+  'storage.objects.create': 'roles/storage.objectCreator',
+};
+
+// Predefined roles granting read-only and full access to each service.
+const SERVICE_ROLES: Record<string, { viewer: string; admin: string }> = {
+  bigquery: { viewer: 'roles/bigquery.dataViewer', admin: 'roles/bigquery.admin' },
+  cloudbuild: {
+    viewer: 'roles/cloudbuild.builds.viewer',
+    admin: 'roles/cloudbuild.builds.editor',
+  },
+  cloudsql: { viewer: 'roles/cloudsql.viewer', admin: 'roles/cloudsql.admin' },
+  compute: { viewer: 'roles/compute.viewer', admin: 'roles/compute.admin' },
+  container: { viewer: 'roles/container.viewer', admin: 'roles/container.admin' },
+  iam: { viewer: 'roles/iam.roleViewer', admin: 'roles/iam.roleAdmin' },
+  logging: { viewer: 'roles/logging.viewer', admin: 'roles/logging.admin' },
+  monitoring: { viewer: 'roles/monitoring.viewer', admin: 'roles/monitoring.admin' },
+  pubsub: { viewer: 'roles/pubsub.viewer', admin: 'roles/pubsub.admin' },
+  run: { viewer: 'roles/run.viewer', admin: 'roles/run.admin' },
+  secretmanager: { viewer: 'roles/secretmanager.viewer', admin: 'roles/secretmanager.admin' },
+  storage: { viewer: 'roles/storage.objectViewer', admin: 'roles/storage.admin' },
+};
+
+const READ_VERBS = ['get', 'list', 'getIamPolicy'];
+
+/** Returns the predefined role that grants a permission, if known. */
+export const roleForPermission = (permission: string): string | undefined => {
+  const explicit = PERMISSION_ROLES[permission];
+  if (explicit) {
+    return explicit;
+  }
+  const [service, , verb] = permission.split('.');
+  const roles = service ? SERVICE_ROLES[service] : undefined;
+  if (!roles || !verb) {
+    return undefined;
+  }
+  return READ_VERBS.includes(verb) ? roles.viewer : roles.admin;
+};
+
+const parseProject = (stderr: string): string | undefined =>
+  stderr.match(/projects\/([a-z0-9-]+)/)?.[1] ??
+  stderr.match(/project \[([a-z0-9-]+)\]/i)?.[1] ??
+  stderr.match(/in project ([a-z0-9-]+)/i)?.[1] ??
+  stderr.match(/[?&]project=([a-z0-9-]+)/)?.[1];
+
+const parsePermission = (stderr: string): string | undefined =>
+  stderr.match(/Required '([\w.]+)' permission/)?.[1] ??
+  stderr.match(/Permission '([\w.]+)' denied/i)?.[1] ??
+  stderr.match(/does not have (\w+\.\w+\.\w+) access/)?.[1] ??
+  stderr.match(/permission "?([a-z]+\.[a-zA-Z]+\.[a-zA-Z]+)"?/)?.[1];
+
+const parseService = (stderr: string): string | undefined =>
+  stderr.match(/([a-z0-9-]+\.googleapis\.com)/)?.[1];
+
+// The fix is only machine-readable if it has no placeholders left to fill in.
+const fixOf = (args: string[]): Pick<Remediation, 'fixCommand' | 'fixArgs'> => ({
+  fixCommand: ['gcloud', ...args].join(' '),
+  ...(!args.some((arg) => arg.includes('<')) && { fixArgs: args }),
+});
+
+const PERMISSION_DENIED_PATTERNS = [
+  /PERMISSION_DENIED/,
+  /does not have permission/i,
+  /Permission '[\w.]+' denied/i,
+  /Required '[\w.]+' permission/,
+  /does not have [\w.]+ access/,
+];
+
+const permissionDenied = (stderr: string, project: string): Remediation | undefined => {
+  if (!PERMISSION_DENIED_PATTERNS.some((pattern) => pattern.test(stderr))) {
+    return undefined;
+  }
+  const missingPermission = parsePermission(stderr);
+  const role = missingPermission ? roleForPermission(missingPermission) : undefined;
+  if (!missingPermission || !role) {
+    return {
+      reason: 'PERMISSION_DENIED',
+      summary:
+        'The active account is missing a permission. Ask the user which role to grant, or check the account with `gcloud auth list`.',
+      ...(missingPermission && { missingPermission }),
+    };
+  }
+  return {
+    reason: 'PERMISSION_DENIED',
+    summary: `The active account is missing the "${missingPermission}" permission, which is granted by "${role}".`,
+    missingPermission,
+    role,
+    fixCommand: `gcloud projects add-iam-policy-binding ${project} --member=${PRINCIPAL_PLACEHOLDER} --role=${role}`,
+  };
+};
+
+const apiNotEnabled = (stderr: string, project: string): Remediation | undefined => {
+  if (!/SERVICE_DISABLED|has not been used in project|API \[[\w.-]+\] not enabled/i.test(stderr)) {
+    return undefined;
+  }
+  const service = parseService(stderr) ?? '<SERVICE>.googleapis.com';
+  return {
+    reason: 'API_NOT_ENABLED',
+    summary: `The ${service} API is not enabled on the project. Ask the user before enabling it.`,
+    missingPermission: 'serviceusage.services.enable',
+    role: 'roles/serviceusage.serviceUsageAdmin',
+    ...fixOf(['services', 'enable', service, `--project=${project}`]),
+  };
+};
+
+const billingDisabled = (stderr: string, project: string): Remediation | undefined => {
+  if (!/BILLING_DISABLED|billing (account|to be enabled)|billing is disabled/i.test(stderr)) {
+    return undefined;
+  }
+  return {
+    reason: 'BILLING_DISABLED',
+    summary: 'Billing is not enabled on the project. A billing account must be linked first.',
+    missingPermission: 'billing.resourceAssociations.create',
+    role: 'roles/billing.user',
+    fixCommand: `gcloud billing projects link ${project} --billing-account=${BILLING_ACCOUNT_PLACEHOLDER}`,
+  };
+};
+
+const quotaExceeded = (stderr: string, project: string): Remediation | undefined => {
+  if (!/QUOTA_EXCEEDED|RESOURCE_EXHAUSTED|Quota '?[\w-]+'? exceeded|Quota exceeded/i.test(stderr)) {
+    return undefined;
+  }
+  const metric = stderr.match(/Quota '([\w-]+)' exceeded/)?.[1];
+  const region = stderr.match(/in region ([a-z0-9-]+)/)?.[1];
+  if (region) {
+    return {
+      reason: 'QUOTA_EXCEEDED',
+      summary: `The ${metric ?? 'resource'} quota is exhausted in ${region}. Free up capacity, use another region, or request a quota increase.`,
+      fixCommand: `gcloud compute regions describe ${region} --project=${project} --format="json(quotas)"`,
+    };
+  }
+  return {
+    reason: 'QUOTA_EXCEEDED',
+    summary:
+      'A quota or rate limit was exceeded. Retry later with fewer requests, or request a quota increase.',
+  };
+};
+
+const AUTH_EXPIRED_PATTERNS = [
+  /Reauthentication (failed|required)/i,
+  /problem refreshing your current auth tokens/i,
+  /invalid_grant/,
+  /does not have any valid credentials/i,
+  /do not currently have an active account selected/i,
+  /\$ gcloud auth login/,
+];
+
+const authExpired = (stderr: string): Remediation | undefined => {
+  if (!AUTH_EXPIRED_PATTERNS.some((pattern) => pattern.test(stderr))) {
+    return undefined;
+  }
+  const account = stderr.match(/account \[([^\]\s]+@[^\]\s]+)\]/)?.[1];
+  return {
+    reason: 'AUTH_EXPIRED',
+    summary:
+      'The credentials of the active account expired or are missing. Logging in opens a browser, so ask the user to run the fix command in a terminal, then retry.',
+    fixCommand: account ? `gcloud auth login ${account}` : 'gcloud auth login',
+  };
+};
+
+/**
+ * Parses the flags gcloud rejected with "unrecognized arguments", each with
+ * the flag gcloud suggests instead, if any.
+ */
+const parseUnknownFlags = (stderr: string): Array<{ flag: string; suggestion?: string }> => {
+  const section = stderr.split(/unrecognized arguments:/)[1]?.split(/\n\s*\n/)[0] ?? '';
+  return [
+    ...section.matchAll(/(--[\w-]+)(?:=\S*)?\s*(?:\(did you mean '(--[\w-]+)'\?\))?/g),
+  ].map(([, flag, suggestion]) => ({ flag: flag!, ...(suggestion && { suggestion }) }));
+};
+
+const unknownFlag = (stderr: string, args: string[] | undefined): Remediation | undefined => {
+  const unknownFlags = parseUnknownFlags(stderr);
+  if (unknownFlags.length === 0) {
+    return undefined;
+  }
+  const command = stderr.match(/\(gcloud\.([\w.-]+)\)/)?.[1]?.split('.') ?? [];
+  const flags = unknownFlags.map(({ flag }) => flag).join(', ');
+  const suggestions = new Map(unknownFlags.map(({ flag, suggestion }) => [flag, suggestion]));
+  // The command can be fixed in place if gcloud suggests a flag for each.
+  const fixed =
+    args && unknownFlags.every(({ suggestion }) => suggestion)
+      ? args.map((arg) => {
+          const [flag = '', ...value] = arg.split('=');
+          const suggestion = suggestions.get(flag);
+          return suggestion ? [suggestion, ...value].join('=') : arg;
+        })
+      : undefined;
+  return {
+    reason: 'UNKNOWN_FLAG',
+    summary: fixed
+      ? `gcloud does not recognize ${flags}. The fix renames them to the flags gcloud suggests.`
+      : `gcloud does not recognize ${flags}. Look up the flags of the command with the fix command or validate_gcloud_command.`,
+    unknownFlags,
+    ...(fixed ? fixOf(fixed) : { fixCommand: ['gcloud', ...command, '--help'].join(' ') }),
+  };
+};
+
+const componentNotInstalled = (stderr: string): Remediation | undefined => {
+  if (/component manager is disabled/i.test(stderr)) {
+    return {
+      reason: 'COMPONENT_MANAGER_DISABLED',
+      summary:
+        'gcloud was installed with a package manager, so components must be installed with it, e.g. `sudo apt-get install google-cloud-cli-<COMPONENT>`. Ask the user to install them.',
+    };
+  }
+  const listed = stderr.match(/requires the installation of components?: \[([\w\s,-]+)\]/i)?.[1];
+  const named =
+    stderr.match(/`?([\w-]+)`? component (?:is not installed|to be installed)/i)?.[1] ??
+    (/gke-gcloud-auth-plugin.*not found/i.test(stderr) ? 'gke-gcloud-auth-plugin' : undefined);
+  const components = listed?.split(',').map((c) => c.trim()) ?? (named ? [named] : []);
+  if (components.length === 0) {
+    return undefined;
+  }
+  return {
+    reason: 'COMPONENT_NOT_INSTALLED',
+    summary: `The ${components.join(', ')} gcloud component is not installed. Install it with the install_components tool after the user approves.`,
+    fixCommand: `gcloud components install ${components.join(' ')}`,
+  };
+};
+
+/**
+ * Maps a failed gcloud invocation to the next action needed to fix it. The
+ * arguments of the invocation, if given, let flag errors be fixed in place.
+ *
+ * Billing is checked before the other failure modes because billing errors are
+ * often reported as PERMISSION_DENIED or SERVICE_DISABLED.
+ */
+export const findRemediation = (stderr: string, args?: string[]): Remediation | undefined => {
+  const project = parseProject(stderr) ?? PROJECT_PLACEHOLDER;
+  return (
+    billingDisabled(stderr, project) ??
+    apiNotEnabled(stderr, project) ??
+    quotaExceeded(stderr, project) ??
+    authExpired(stderr) ??
+    permissionDenied(stderr, project) ??
+    unknownFlag(stderr, args) ??
+    componentNotInstalled(stderr)
+  );
+};
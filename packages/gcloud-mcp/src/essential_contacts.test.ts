@@ -0,0 +1,164 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import {
+  NotificationCategory,
+  auditContacts,
+  listContacts,
+  setContactArgs,
+} from './essential_contacts.js';
+
+vi.mock('./gcloud.js');
+
+const contact = (id: string, email: string, categories: string[]) => ({
+  name: `projects/p/contacts/${id}`,
+  email,
+  notificationCategorySubscriptions: categories,
+  languageTag: 'en',
+  validationState: 'VALID',
+});
+
+const fakeGcloud = (responses: Record<string, unknown[]>): gcloud.GcloudExecutable => ({
+  lint: vi.fn(),
+  invoke: vi.fn(async (args: string[]) => {
+    const project = args.find((arg) => arg.startsWith('--project='))?.split('=')[1] ?? '';
+    const listed = responses[project];
+    return listed
+      ? { code: 0, stdout: JSON.stringify(listed), stderr: '' }
+      : { code: 1, stdout: '', stderr: 'PERMISSION_DENIED' };
+  }),
+});
+
+describe('listContacts', () => {
+  test('lists contacts with their categories as gcloud spells them', async () => {
+    const gcloud = fakeGcloud({
+      p: [contact('1', 'sec@example.com', ['SECURITY', 'PRODUCT_UPDATES'])],
+    });
+
+    expect(await listContacts(gcloud, { project: 'p' })).toEqual([
+      {
+        id: '1',
+        email: 'sec@example.com',
+        categories: ['security', 'product-updates'],
+        language: 'en',
+        validationState: 'VALID',
+      },
+    ]);
+    expect(gcloud.invoke).toHaveBeenCalledWith([
+      'essential-contacts',
+      'list',
+      '--project=p',
+      '--format=json',
+    ]);
+  });
+});
+
+describe('setContactArgs', () => {
+  const existing = [
+    {
+      id: '42',
+      email: 'Sec@example.com',
+      categories: ['security'],
+      language: 'en',
+      validationState: 'VALID',
+    },
+  ];
+
+  test('creates a contact for a new address', () => {
+    expect(
+      setContactArgs({ folder: '123' }, existing, 'billing@example.com', ['billing'], 'en'),
+    ).toEqual([
+      'essential-contacts',
+      'create',
+      '--email=billing@example.com',
+      '--notification-categories=billing',
+      '--language=en',
+      '--folder=123',
+    ]);
+  });
+
+  test('updates the contact of an existing address', () => {
+    const categories: NotificationCategory[] = ['security', 'legal'];
+    const parent = { organization: '9' };
+    expect(setContactArgs(parent, existing, 'sec@example.com', categories, 'ja')).toEqual([
+      'essential-contacts',
+      'update',
+      '42',
+      '--notification-categories=security,legal',
+      '--language=ja',
+      '--organization=9',
+    ]);
+  });
+});
+
+describe('auditContacts', () => {
+  test('reports projects missing contacts first, with remediation commands', async () => {
+    const gcloud = fakeGcloud({
+      covered: [contact('1', 'all@example.com', ['ALL'])],
+      partial: [contact('2', 'sec@example.com', ['SECURITY'])],
+      empty: [],
+    });
+
+    const audit = await auditContacts(
+      gcloud,
+      ['covered', 'partial', 'empty', 'denied'],
+      ['security', 'billing'],
+      'cloud-admins@example.com',
+    );
+
+    expect(audit.summary).toEqual({ projects: 3, compliant: 1, missingContacts: 2 });
+    expect(audit.projects).toEqual([
+      {
+        project: 'empty',
+        contacts: [],
+        missing: ['security', 'billing'],
+        remediation:
+          'gcloud essential-contacts create --email=cloud-admins@example.com --notification-categories=security,billing --language=en --project=empty',
+      },
+      {
+        project: 'partial',
+        contacts: [{ email: 'sec@example.com', categories: ['security'] }],
+        missing: ['billing'],
+        remediation:
+          'gcloud essential-contacts create --email=cloud-admins@example.com --notification-categories=billing --language=en --project=partial',
+      },
+      {
+        project: 'covered',
+        contacts: [{ email: 'all@example.com', categories: ['all'] }],
+        missing: [],
+        remediation: null,
+      },
+    ]);
+    expect(audit.errors).toEqual([
+      { project: 'denied', error: expect.stringContaining('PERMISSION_DENIED') },
+    ]);
+    expect(vi.mocked(gcloud.invoke).mock.calls[0]![0]).toEqual([
+      'essential-contacts',
+      'compute',
+      '--notification-categories=security,billing',
+      '--project=covered',
+      '--format=json',
+    ]);
+  });
+
+  test('uses a placeholder without a remediation address', async () => {
+    const audit = await auditContacts(fakeGcloud({ p: [] }), ['p'], ['security']);
+
+    expect(audit.projects[0]?.remediation).toContain('--email=CONTACT_EMAIL');
+  });
+});
@@ -0,0 +1,199 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson } from './gcloud_json.js';
+
+/** The notification categories as gcloud spells them. */
+export const NOTIFICATION_CATEGORIES = [
+  'all',
+  'billing',
+  'legal',
+  'product-updates',
+  'security',
+  'suspension',
+  'technical',
+  'technical-incidents',
+] as const;
+
+export type NotificationCategory = (typeof NOTIFICATION_CATEGORIES)[number];
+
+/** The categories every project should have a contact for. */
+export const DEFAULT_REQUIRED_CATEGORIES: NotificationCategory[] = ['security', 'billing'];
+
+// Remediation commands need an address to send notifications to; without one
+// they name this placeholder for the user to replace.
+export const EMAIL_PLACEHOLDER = 'CONTACT_EMAIL';
+
+export type ContactParent = { project: string } | { folder: string } | { organization: string };
+
+export interface EssentialContact {
+  /** The contact ID used by `gcloud essential-contacts update` and `delete`. */
+  id: string;
+  email: string;
+  categories: string[];
+  language: string | null;
+  validationState: string | null;
+}
+
+export interface ProjectContactAudit {
+  project: string;
+  /** The contacts that apply, including those inherited from folders and the organization. */
+  contacts: Array<{ email: string; categories: string[] }>;
+  missing: NotificationCategory[];
+  /** The command that adds a contact for the missing categories. */
+  remediation: string | null;
+}
+
+export interface ContactAudit {
+  requiredCategories: NotificationCategory[];
+  summary: { projects: number; compliant: number; missingContacts: number };
+  /** The projects missing contacts first. */
+  projects: ProjectContactAudit[];
+  errors: Array<{ project: string; error: string }>;
+}
+
+const ContactSchema = z.object({
+  name: z.string(),
+  email: z.string(),
+  notificationCategorySubscriptions: z.array(z.string()).nullish(),
+  languageTag: z.string().nullish(),
+  validationState: z.string().nullish(),
+});
+
+export const parentFlag = (parent: ContactParent): string => {
+  if ('project' in parent) {
+    return `--project=${parent.project}`;
+  }
+  return 'folder' in parent ? `--folder=${parent.folder}` : `--organization=${parent.organization}`;
+};
+
+// The API reports categories like PRODUCT_UPDATES.
+const categoryOf = (subscription: string) => subscription.toLowerCase().replaceAll('_', '-');
+
+const contactOf = (contact: z.infer<typeof ContactSchema>): EssentialContact => ({
+  id: contact.name.split('/').at(-1) ?? contact.name,
+  email: contact.email,
+  categories: (contact.notificationCategorySubscriptions ?? []).map(categoryOf),
+  language: contact.languageTag ?? null,
+  validationState: contact.validationState ?? null,
+});
+
+/** Lists the Essential Contacts set directly on a project, folder or organization. */
+export const listContacts = async (
+  gcloud: GcloudExecutable,
+  parent: ContactParent,
+): Promise<EssentialContact[]> =>
+  z
+    .array(ContactSchema)
+    .parse(
+      await invokeJson(gcloud, ['essential-contacts', 'list', parentFlag(parent), '--format=json']),
+    )
+    .map(contactOf);
+
+/**
+ * Returns the command that subscribes an address to exactly the given
+ * categories, updating the existing contact for it if there is one.
+ */
+export const setContactArgs = (
+  parent: ContactParent,
+  existing: EssentialContact[],
+  email: string,
+  categories: NotificationCategory[],
+  language: string,
+): string[] => {
+  const categoriesFlag = `--notification-categories=${categories.join(',')}`;
+  const contact = existing.find((c) => c.email.toLowerCase() === email.toLowerCase());
+  if (contact) {
+    return [
+      'essential-contacts',
+      'update',
+      contact.id,
+      categoriesFlag,
+      `--language=${language}`,
+      parentFlag(parent),
+    ];
+  }
+  return [
+    'essential-contacts',
+    'create',
+    `--email=${email}`,
+    categoriesFlag,
+    `--language=${language}`,
+    parentFlag(parent),
+  ];
+};
+
+const covers = (categories: string[], category: NotificationCategory) =>
+  categories.includes(category) || categories.includes('all');
+
+/**
+ * Checks that every project has a contact for the required categories. The
+ * contacts are computed the way notifications are sent, so contacts set on a
+ * folder or the organization count for the projects below it.
+ */
+export const auditContacts = async (
+  gcloud: GcloudExecutable,
+  projects: string[],
+  requiredCategories: NotificationCategory[],
+  remediationEmail?: string,
+): Promise<ContactAudit> => {
+  const email = remediationEmail ?? EMAIL_PLACEHOLDER;
+  const audits: ProjectContactAudit[] = [];
+  const errors: ContactAudit['errors'] = [];
+  for (const project of projects) {
+    try {
+      const contacts = z
+        .array(ContactSchema)
+        .parse(
+          await invokeJson(gcloud, [
+            'essential-contacts',
+            'compute',
+            `--notification-categories=${requiredCategories.join(',')}`,
+            `--project=${project}`,
+            '--format=json',
+          ]),
+        )
+        .map(contactOf);
+      const missing = requiredCategories.filter(
+        (category) => !contacts.some((contact) => covers(contact.categories, category)),
+      );
+      audits.push({
+        project,
+        contacts: contacts.map(({ email, categories }) => ({ email, categories })),
+        missing,
+        remediation:
+          missing.length > 0
+            ? `gcloud ${setContactArgs({ project }, [], email, missing, 'en').join(' ')}`
+            : null,
+      });
+    } catch (e: unknown) {
+      errors.push({ project, error: e instanceof Error ? e.message : String(e) });
+    }
+  }
+  const missingContacts = audits.filter((audit) => audit.missing.length > 0).length;
+  return {
+    requiredCategories,
+    summary: {
+      projects: audits.length,
+      compliant: audits.length - missingContacts,
+      missingContacts,
+    },
+    projects: audits.sort((a, b) => b.missing.length - a.missing.length),
+    errors,
+  };
+};
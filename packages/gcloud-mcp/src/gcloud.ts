@@ -15,18 +15,31 @@
  */
 
 import { z } from 'zod';
-import { findExecutable } from './gcloud_executor.js';
+import { ExecutionOptions, findExecutable } from './gcloud_executor.js';
+import { ContainerConfig } from './container.js';
 
 export interface GcloudExecutable {
-  invoke: (args: string[]) => Promise<GcloudInvocationResult>;
+  invoke: (
+    args: string[],
+    env?: NodeJS.ProcessEnv,
+    options?: ExecutionOptions,
+  ) => Promise<GcloudInvocationResult>;
   lint: (command: string) => Promise<ParsedGcloudLintResult>;
+  /** Stops the container gcloud runs in, if any. */
+  dispose?: () => Promise<void>;
 }
 
-export const create = async (): Promise<GcloudExecutable> => {
-  const gcloud = await findExecutable();
+export const create = async (container?: ContainerConfig): Promise<GcloudExecutable> => {
+  const gcloud = await findExecutable(container);
 
   return {
-    invoke: gcloud.execute,
+    ...(gcloud.dispose && { dispose: gcloud.dispose }),
+    // The wrappers of the executable may add arguments, e.g. --project, so the
+    // result reports the arguments gcloud actually ran with.
+    invoke: async (...invocation: Parameters<GcloudExecutable['invoke']>) => ({
+      ...(await gcloud.execute(...invocation)),
+      argv: invocation[0],
+    }),
     lint: async (command: string): Promise<ParsedGcloudLintResult> => {
       const { code, stdout, stderr } = await gcloud.execute([
         'meta',
@@ -70,6 +83,16 @@ export interface GcloudInvocationResult {
   code: number | null;
   stdout: string;
   stderr: string;
+  /** Set if gcloud was stopped because it did not finish in time. */
+  timedOut?: boolean;
+  /** The prompt gcloud was stopped at because it waited for input. */
+  prompt?: string;
+  /** The arguments gcloud ran with, after the wrappers of the executable added theirs. */
+  argv?: string[];
+  /** The project the session set for gcloud in its environment, if any. */
+  envProject?: string;
+  /** Set if gcloud was not run because the limits of the session were exceeded. */
+  throttled?: { limit: 'concurrency' | 'rate'; retryAfterMs: number };
 }
 
 // There are more fields in this object, but we're only parsing the ones currently in use.
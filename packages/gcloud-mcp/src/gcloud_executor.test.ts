@@ -101,6 +101,22 @@ describe('gcloud_executor', () => {
       });
     });
 
+    it('should layer environment overrides over the process environment', async () => {
+      Object.defineProperty(process, 'platform', {
+        value: 'linux',
+      });
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0)); // for isAvailable
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0));
+
+      const executor = await findExecutable();
+      await executor.execute(['config', 'list'], { CLOUDSDK_CORE_PROJECT: 'my-project' });
+
+      expect(spawnSpy).toHaveBeenLastCalledWith('gcloud', ['config', 'list'], {
+        stdio: ['ignore', 'pipe', 'pipe'],
+        env: expect.objectContaining({ CLOUDSDK_CORE_PROJECT: 'my-project' }),
+      });
+    });
+
     it('should create a Windows executor when on Windows', async () => {
       Object.defineProperty(process, 'platform', {
         value: 'win32',
@@ -131,8 +147,77 @@ describe('gcloud_executor', () => {
       expect(spawnSpy).toHaveBeenCalledWith(
         'C:\\Python\\python.exe',
         ['C:\\gcloud\\gcloud.py', 'projects', 'list'],
+        {
+          stdio: ['ignore', 'pipe', 'pipe'],
+          env: expect.objectContaining({ PYTHONIOENCODING: 'utf-8', PYTHONUTF8: '1' }),
+        },
+      );
+    });
+
+    it('should run gcloud in a container when one is configured', async () => {
+      Object.defineProperty(process, 'platform', {
+        value: 'linux',
+      });
+      const execFile = (_file: string, _args: string[], callback: (error: null) => void) =>
+        callback(null);
+      const execFileSpy = vi
+        .spyOn(child_process, 'execFile')
+        .mockImplementation(execFile as unknown as typeof child_process.execFile);
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0)); // for isAvailable
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('499.0.0', '', 0));
+
+      const executor = await findExecutable({
+        image: 'gcr.io/google.com/cloudsdktool/google-cloud-cli:499.0.0-stable',
+      });
+      const result = await executor.execute(['version'], { CLOUDSDK_CORE_PROJECT: 'my-project' });
+      await executor.dispose?.();
+
+      expect(result.stdout).toBe('499.0.0');
+      expect(spawnSpy).toHaveBeenNthCalledWith(1, 'which', ['docker']);
+      const runArgs = execFileSpy.mock.calls[0]?.[1] as string[];
+      const name = runArgs[4];
+      expect(runArgs).toEqual(
+        expect.arrayContaining([
+          'run',
+          'gcr.io/google.com/cloudsdktool/google-cloud-cli:499.0.0-stable',
+        ]),
+      );
+      expect(spawnSpy).toHaveBeenLastCalledWith(
+        'docker',
+        expect.arrayContaining(['--env', 'CLOUDSDK_CORE_PROJECT=my-project', name, 'gcloud']),
         { stdio: ['ignore', 'pipe', 'pipe'] },
       );
+      expect(execFileSpy).toHaveBeenLastCalledWith(
+        'docker',
+        ['rm', '--force', name],
+        expect.any(Function),
+      );
+    });
+
+    it('should run gcloud.cmd through cmd.exe when gcloud.py can not be run', async () => {
+      Object.defineProperty(process, 'platform', {
+        value: 'win32',
+      });
+      vi.mocked(windows_gcloud_utils.getWindowsCloudSDKSettingsAsync).mockResolvedValue({
+        noWorkingPythonFound: true,
+        gcloudCmdPath: 'C:\\Program Files\\gcloud\\bin\\gcloud.cmd',
+      } as windows_gcloud_utils.WindowsCloudSDKSettings);
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0)); // for isAvailable
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('[]', '', 0));
+
+      const executor = await findExecutable();
+      await executor.execute(['compute', 'instances', 'list', '--filter=name:"my vm"']);
+
+      expect(spawnSpy).toHaveBeenLastCalledWith(
+        expect.stringMatching(/cmd\.exe$/i),
+        [
+          '/d',
+          '/s',
+          '/c',
+          '"chcp 65001 >NUL & ^"C:\\Program^ Files\\gcloud\\bin\\gcloud.cmd^" ^^^"compute^^^" ^^^"instances^^^" ^^^"list^^^" ^^^"--filter=name:\\^^^"my^^^ vm\\^^^"^^^""',
+        ],
+        expect.objectContaining({ windowsVerbatimArguments: true }),
+      );
     });
 
     it('should throw an error if gcloud is not available', async () => {
@@ -180,5 +265,111 @@ describe('gcloud_executor', () => {
         stderr: '',
       });
     });
+
+    it('should report each stderr line', async () => {
+      Object.defineProperty(process, 'platform', {
+        value: 'linux',
+      });
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0)); // for isAvailable
+      spawnSpy.mockReturnValueOnce(
+        createMockChildProcess('', 'Creating instance...\r\n\nCreating instance...done.', 0),
+      );
+      const onStderrLine = vi.fn();
+
+      const executor = await findExecutable();
+      await executor.execute(['compute', 'instances', 'create', 'vm'], undefined, { onStderrLine });
+
+      expect(onStderrLine.mock.calls).toEqual([
+        ['Creating instance...'],
+        ['Creating instance...done.'],
+      ]);
+    });
+
+    it('should kill the process group of gcloud when it times out', async () => {
+      Object.defineProperty(process, 'platform', {
+        value: 'linux',
+      });
+      const hung = new FakeChildProcess();
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0)); // for isAvailable
+      spawnSpy.mockReturnValueOnce(hung as unknown as ChildProcess);
+      const killSpy = vi.spyOn(process, 'kill').mockImplementation(() => {
+        hung.kill('SIGKILL');
+        return true;
+      });
+
+      const executor = await findExecutable();
+      const result = await executor.execute(['container', 'clusters', 'create'], undefined, {
+        timeoutMs: 1,
+      });
+
+      expect(spawnSpy).toHaveBeenLastCalledWith('gcloud', ['container', 'clusters', 'create'], {
+        stdio: ['ignore', 'pipe', 'pipe'],
+        detached: true,
+      });
+      expect(killSpy).toHaveBeenCalledWith(-12345, 'SIGKILL');
+      expect(result).toEqual({ code: null, stdout: '', stderr: '', timedOut: true });
+    });
+
+    it('should kill gcloud in the container when it times out', async () => {
+      Object.defineProperty(process, 'platform', {
+        value: 'linux',
+      });
+      const execFile = (_file: string, _args: string[], callback: (error: null) => void) =>
+        callback(null);
+      const execFileSpy = vi
+        .spyOn(child_process, 'execFile')
+        .mockImplementation(execFile as unknown as typeof child_process.execFile);
+      const hung = new FakeChildProcess();
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0)); // for isAvailable
+      spawnSpy.mockReturnValueOnce(hung as unknown as ChildProcess);
+
+      const executor = await findExecutable({
+        image: 'gcr.io/google.com/cloudsdktool/google-cloud-cli:499.0.0-stable',
+      });
+      const result = await executor.execute(['container', 'clusters', 'create'], undefined, {
+        timeoutMs: 1,
+      });
+
+      const name = (execFileSpy.mock.calls[0]?.[1] as string[])[4];
+      const execArgs = spawnSpy.mock.lastCall?.[1] as string[];
+      const pidFile = execArgs[execArgs.indexOf('-c') + 2];
+      expect(execArgs).toEqual(
+        expect.arrayContaining([name, 'setsid', '--wait', 'container', 'clusters', 'create']),
+      );
+      expect(execFileSpy).toHaveBeenLastCalledWith(
+        'docker',
+        ['exec', name, 'sh', '-c', 'kill -KILL -- -"$(cat "$0")"; rm -f "$0"', pidFile],
+        expect.any(Function),
+      );
+      expect(hung.killed).toBe(true);
+      expect(result).toEqual({ code: null, stdout: '', stderr: '', timedOut: true });
+    });
+
+    it('should stop gcloud when it waits for input', async () => {
+      Object.defineProperty(process, 'platform', {
+        value: 'linux',
+      });
+      const waiting = new FakeChildProcess();
+      spawnSpy.mockReturnValueOnce(createMockChildProcess('', '', 0)); // for isAvailable
+      spawnSpy.mockReturnValueOnce(waiting as unknown as ChildProcess);
+      const killSpy = vi.spyOn(process, 'kill').mockImplementation(() => {
+        waiting.kill('SIGKILL');
+        return true;
+      });
+      waiting.stderr.push('Do you want to continue (Y/n)?  ');
+
+      const executor = await findExecutable();
+      const result = await executor.execute(['compute', 'instances', 'delete', 'vm-1'], undefined, {
+        promptWaitMs: 1,
+      });
+
+      expect(killSpy).toHaveBeenCalledWith(-12345, 'SIGKILL');
+      expect(result).toEqual({
+        code: null,
+        stdout: '',
+        stderr: 'Do you want to continue (Y/n)?  ',
+        prompt: 'Do you want to continue (Y/n)?',
+      });
+    });
   });
 });
@@ -15,7 +15,19 @@
  */
 
 import * as child_process from 'child_process';
+import { Readable } from 'stream';
 import { getWindowsCloudSDKSettingsAsync } from './windows_gcloud_utils.js';
+import { pendingPrompt } from './interactive_prompts.js';
+import { WINDOWS_UTF8_ENV, cmdShimCommandLine } from './windows_command_line.js';
+import {
+  ContainerConfig,
+  containerExecArgs,
+  containerKillArgs,
+  containerName,
+  containerPidFile,
+  containerRunArgs,
+  hostConfigDir,
+} from './container.js';
 
 export const isWindows = (): boolean => process.platform === 'win32';
 
@@ -23,40 +35,138 @@ export interface GcloudExecutionResult {
   code: number | null;
   stdout: string;
   stderr: string;
+  /** Set if gcloud was stopped because it did not finish in time. */
+  timedOut?: boolean;
+  /** The prompt gcloud was stopped at because it waited for input. */
+  prompt?: string;
 }
 
+export interface ExecutionOptions {
+  /** Called with each line gcloud writes to stderr, e.g. the progress of an operation. */
+  onStderrLine?: (line: string) => void;
+  /** Stops gcloud and the processes it started if it does not finish in time. */
+  timeoutMs?: number;
+  /** Stops gcloud if its output ends with a prompt and nothing follows for this long. */
+  promptWaitMs?: number;
+}
+
+/**
+ * Kills a process and its children. On Linux and macOS the process must lead
+ * its own process group, i.e. be spawned detached.
+ */
+const killProcessTree = (child: child_process.ChildProcess) => {
+  if (child.pid === undefined) {
+    return;
+  }
+  if (isWindows()) {
+    child_process.spawn('taskkill', ['/pid', String(child.pid), '/T', '/F'], { stdio: 'ignore' });
+    return;
+  }
+  try {
+    process.kill(-child.pid, 'SIGKILL');
+  } catch {
+    // The process does not lead a process group.
+    child.kill('SIGKILL');
+  }
+};
+
 export interface GcloudExecutor {
-  execute: (args: string[]) => Promise<GcloudExecutionResult>;
+  execute: (
+    args: string[],
+    env?: NodeJS.ProcessEnv,
+    options?: ExecutionOptions,
+  ) => Promise<GcloudExecutionResult>;
+  /** Releases what the executor manages, such as its container. */
+  dispose?: () => Promise<void>;
 }
 
-export const findExecutable = async (): Promise<GcloudExecutor> => {
-  const executor = await createExecutor();
+/**
+ * Finds the gcloud executable, or starts the container gcloud runs in when a
+ * container is configured.
+ */
+export const findExecutable = async (container?: ContainerConfig): Promise<GcloudExecutor> => {
+  const executor = await createExecutor(container);
+  const kill = executor.kill ?? killProcessTree;
   return {
-    execute: async (args: string[]): Promise<GcloudExecutionResult> =>
+    ...(executor.dispose && { dispose: executor.dispose }),
+    execute: async (
+      args: string[],
+      env?: NodeJS.ProcessEnv,
+      options: ExecutionOptions = {},
+    ): Promise<GcloudExecutionResult> =>
       new Promise((resolve, reject) => {
         let stdout = '';
         let stderr = '';
+        // The stderr after the last complete line.
+        let partialLine = '';
+        const { onStderrLine, timeoutMs, promptWaitMs } = options;
 
-        let gcloud;
+        let gcloud: child_process.ChildProcessByStdio<null, Readable, Readable>;
         try {
-          gcloud = executor.execute(args);
+          const killable = timeoutMs !== undefined || promptWaitMs !== undefined;
+          gcloud = executor.execute(args, env, killable);
         } catch (err) {
           reject(err);
           return;
         }
+        let timedOut = false;
+        const timer =
+          timeoutMs === undefined
+            ? undefined
+            : setTimeout(() => {
+                timedOut = true;
+                kill(gcloud);
+              }, timeoutMs);
+        // Any output after a prompt means gcloud did not wait for the answer.
+        let prompt: string | undefined;
+        let promptTimer: NodeJS.Timeout | undefined;
+        const watchForPrompt = (output: string) => {
+          if (promptWaitMs === undefined) {
+            return;
+          }
+          clearTimeout(promptTimer);
+          const pending = pendingPrompt(output);
+          if (pending) {
+            promptTimer = setTimeout(() => {
+              prompt = pending;
+              kill(gcloud);
+            }, promptWaitMs);
+          }
+        };
 
         gcloud.stdout.on('data', (data) => {
           stdout += data.toString().replace(/\r/g, '');
+          watchForPrompt(stdout);
         });
         gcloud.stderr.on('data', (data) => {
-          stderr += data.toString().replace(/\r/g, '');
+          const text = data.toString().replace(/\r/g, '');
+          stderr += text;
+          watchForPrompt(stderr);
+          if (onStderrLine) {
+            const lines = (partialLine + text).split('\n');
+            partialLine = lines.pop() ?? '';
+            lines.filter((line) => line.trim()).forEach((line) => onStderrLine(line));
+          }
         });
 
         gcloud.on('close', (code) => {
+          clearTimeout(timer);
+          clearTimeout(promptTimer);
+          if (onStderrLine && partialLine.trim()) {
+            onStderrLine(partialLine);
+          }
           // All responses from gcloud, including non-zero codes.
-          resolve({ code, stdout, stderr });
+          resolve({
+            code,
+            stdout,
+            stderr,
+            ...(timedOut && { timedOut }),
+            ...(prompt !== undefined && { prompt }),
+          });
         });
         gcloud.on('error', (err) => {
+          clearTimeout(timer);
+          clearTimeout(promptTimer);
           // Process failed to start. gcloud isn't able to be invoked.
           reject(err);
         });
@@ -64,9 +174,9 @@ export const findExecutable = async (): Promise<GcloudExecutor> => {
   };
 };
 
-export const isAvailable = (): Promise<boolean> =>
+export const isAvailable = (executable = 'gcloud'): Promise<boolean> =>
   new Promise((resolve) => {
-    const which = child_process.spawn(isWindows() ? 'where.exe' : 'which', ['gcloud']);
+    const which = child_process.spawn(isWindows() ? 'where.exe' : 'which', [executable]);
     which.on('close', (code) => {
       resolve(code === 0);
     });
@@ -75,7 +185,10 @@ export const isAvailable = (): Promise<boolean> =>
     });
   });
 
-const createExecutor = async () => {
+const createExecutor = async (container?: ContainerConfig): Promise<Executor> => {
+  if (container) {
+    return await createContainerExecutor(container);
+  }
   if (!(await isAvailable())) {
     throw Error('gcloud executable not found');
   }
@@ -85,17 +198,39 @@ const createExecutor = async () => {
   return createDirectExecutor();
 };
 
+interface Executor {
+  /** `killable` asks for a process whose children can be killed with it. */
+  execute: (
+    args: string[],
+    env?: NodeJS.ProcessEnv,
+    killable?: boolean,
+  ) => child_process.ChildProcessByStdio<null, Readable, Readable>;
+  /** Kills a killable process and its children. Defaults to killing its process group. */
+  kill?: (child: child_process.ChildProcess) => void;
+  dispose?: () => Promise<void>;
+}
+
+// Environment overrides are layered over the server's own environment, which
+// child processes otherwise inherit as is.
+const envOption = (env?: NodeJS.ProcessEnv) => (env ? { env: { ...process.env, ...env } } : {});
+
 /** Creates an executor that directly invokes the gcloud binary on the current PATH. */
-const createDirectExecutor = () => ({
-  execute: (args: string[]) =>
+const createDirectExecutor = (): Executor => ({
+  execute: (args: string[], env?: NodeJS.ProcessEnv, killable = false) =>
     child_process.spawn('gcloud', args, {
       stdio: ['ignore', 'pipe', 'pipe'],
+      ...envOption(env),
+      // A detached process leads a process group that can be killed as a whole.
+      ...(killable && { detached: true }),
     }),
 });
 
-const createWindowsExecutor = async () => {
+const createWindowsExecutor = async (): Promise<Executor> => {
   const settings = await getWindowsCloudSDKSettingsAsync();
 
+  if (settings?.gcloudCmdPath) {
+    return createCmdShimExecutor(settings.gcloudCmdPath);
+  }
   if (settings == null || settings.noWorkingPythonFound) {
     throw Error('no working Python installation found for Windows gcloud execution.');
   }
@@ -103,13 +238,88 @@ const createWindowsExecutor = async () => {
   const pythonPath = settings.cloudSdkPython;
 
   return {
-    execute: (args: string[]) =>
+    execute: (args: string[], env?: NodeJS.ProcessEnv) =>
       child_process.spawn(
         pythonPath,
         [...settings.cloudSdkPythonArgsList, settings.gcloudPyPath, ...args],
         {
           stdio: ['ignore', 'pipe', 'pipe'],
+          env: { ...process.env, ...WINDOWS_UTF8_ENV, ...env },
         },
       ),
   };
 };
+
+/**
+ * Creates an executor running gcloud.cmd through cmd.exe. Node can't quote
+ * arguments for batch files safely, so the command line is built verbatim.
+ */
+const createCmdShimExecutor = (gcloudCmdPath: string): Executor => ({
+  execute: (args: string[], env?: NodeJS.ProcessEnv) =>
+    child_process.spawn(
+      process.env['ComSpec'] ?? 'cmd.exe',
+      ['/d', '/s', '/c', cmdShimCommandLine(gcloudCmdPath, args)],
+      {
+        stdio: ['ignore', 'pipe', 'pipe'],
+        env: { ...process.env, ...WINDOWS_UTF8_ENV, ...env },
+        windowsVerbatimArguments: true,
+      },
+    ),
+});
+
+/** Runs a container runtime command to completion, failing on a non-zero exit. */
+const runContainerCommand = (runtime: string, args: string[]): Promise<string> =>
+  new Promise((resolve, reject) => {
+    child_process.execFile(runtime, args, (error, stdout, stderr) => {
+      if (error) {
+        reject(new Error(`${runtime} ${args[0]} failed: ${stderr || error.message}`));
+        return;
+      }
+      resolve(stdout);
+    });
+  });
+
+/**
+ * Creates an executor that runs gcloud in a container of a pinned SDK image,
+ * so commands behave the same regardless of the gcloud installed on the host.
+ * One container is started with the host's gcloud configuration mounted, and
+ * every command is executed in it. Commands that may be killed are killed in
+ * the container, not only the runtime client executing them.
+ */
+const createContainerExecutor = async (container: ContainerConfig): Promise<Executor> => {
+  const runtime = container.runtime ?? 'docker';
+  if (!(await isAvailable(runtime))) {
+    throw Error(`${runtime} executable not found`);
+  }
+  const name = containerName();
+  await runContainerCommand(runtime, containerRunArgs(container, name, hostConfigDir()));
+
+  const pidFiles = new WeakMap<child_process.ChildProcess, string>();
+
+  return {
+    execute: (args: string[], env?: NodeJS.ProcessEnv, killable = false) => {
+      const pidFile = killable ? containerPidFile() : undefined;
+      const child = child_process.spawn(runtime, containerExecArgs(name, args, env, pidFile), {
+        stdio: ['ignore', 'pipe', 'pipe'],
+      });
+      if (pidFile) {
+        pidFiles.set(child, pidFile);
+      }
+      return child;
+    },
+    // Killing the runtime client leaves gcloud running in the container, so
+    // its session is killed there too.
+    kill: (child: child_process.ChildProcess) => {
+      const pidFile = pidFiles.get(child);
+      if (pidFile) {
+        runContainerCommand(runtime, containerKillArgs(name, pidFile)).catch(() => {
+          // gcloud finished before it could be killed.
+        });
+      }
+      child.kill('SIGKILL');
+    },
+    dispose: async () => {
+      await runContainerCommand(runtime, ['rm', '--force', name]);
+    },
+  };
+};
@@ -0,0 +1,58 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import { GcloudExecutable, GcloudInvocationResult } from './gcloud.js';
+import { invokeJson, isZone, lastSegment } from './gcloud_json.js';
+
+const gcloudReturning = (result: GcloudInvocationResult): GcloudExecutable => ({
+  lint: vi.fn(),
+  invoke: vi.fn().mockResolvedValue(result),
+});
+
+describe('invokeJson', () => {
+  test('returns the parsed output', async () => {
+    const gcloud = gcloudReturning({ code: 0, stdout: '[{"name":"vm-1"}]', stderr: '' });
+
+    expect(await invokeJson(gcloud, ['compute', 'instances', 'list', '--format=json'])).toEqual([
+      { name: 'vm-1' },
+    ]);
+  });
+
+  test('throws the error of a failed command', async () => {
+    const gcloud = gcloudReturning({ code: 1, stdout: '', stderr: 'ERROR: permission denied' });
+
+    await expect(invokeJson(gcloud, ['projects', 'list', '--format=json'])).rejects.toThrow(
+      'gcloud projects list --format=json failed: ERROR: permission denied',
+    );
+  });
+});
+
+describe('lastSegment', () => {
+  test('returns the name a resource URL ends with', () => {
+    expect(lastSegment('https://www.googleapis.com/compute/v1/projects/p/zones/us-east1-b')).toBe(
+      'us-east1-b',
+    );
+    expect(lastSegment('us-east1')).toBe('us-east1');
+  });
+});
+
+describe('isZone', () => {
+  test('tells zones from regions', () => {
+    expect(isZone('us-east1-b')).toBe(true);
+    expect(isZone('us-east1')).toBe(false);
+  });
+});
@@ -0,0 +1,32 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { GcloudExecutable } from './gcloud.js';
+
+/** Runs a command with JSON output and returns the parsed output. Throws if it fails. */
+export const invokeJson = async (gcloud: GcloudExecutable, args: string[]): Promise<unknown> => {
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  return JSON.parse(stdout);
+};
+
+/** Returns the name a resource URL of the output ends with, e.g. the zone of an instance. */
+export const lastSegment = (url: string) => url.split('/').at(-1) ?? url;
+
+/** Returns whether a location of the output is a zone, e.g. `us-east1-b`, not a region. */
+export const isZone = (location: string) => /-[a-z]$/.test(location);
@@ -0,0 +1,193 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from './google_api.js';
+import { getGkeCosts, gkeCostQuery, gkeUsageQuery } from './gke_costs.js';
+
+const TABLE = 'billing-project.billing.gcp_billing_export_v1_0000';
+const DATASET = 'gke-project.gke_usage';
+
+const row = (...values: Array<string | null>) => ({ f: values.map((v) => ({ v })) });
+
+const filters = { projects: false, cluster: false, workload: false };
+
+describe('gkeCostQuery', () => {
+  test('groups by the workload label only when requested', () => {
+    expect(gkeCostQuery(TABLE, filters)).toContain('CAST(NULL AS STRING) AS workload');
+    expect(gkeCostQuery(TABLE, { ...filters, workload: true })).toContain(
+      '(SELECT value FROM UNNEST(labels) WHERE key = @workload_label) AS workload',
+    );
+  });
+
+  test('filters projects and clusters only when requested', () => {
+    expect(gkeCostQuery(TABLE, filters)).not.toContain('@projects');
+    expect(gkeCostQuery(TABLE, filters)).not.toContain('@cluster');
+    const query = gkeCostQuery(TABLE, { ...filters, projects: true, cluster: true });
+    expect(query).toContain('AND project.id IN UNNEST(@projects)');
+    expect(query).toContain("WHERE key = 'goog-k8s-cluster-name') = @cluster");
+  });
+});
+
+describe('gkeUsageQuery', () => {
+  test('reads requests and consumption from the usage metering tables', () => {
+    const query = gkeUsageQuery(DATASET, filters);
+    expect(query).toContain(`FROM \`${DATASET}.gke_cluster_resource_usage\``);
+    expect(query).toContain(`FROM \`${DATASET}.gke_cluster_resource_consumption\``);
+  });
+});
+
+describe('getGkeCosts', () => {
+  let api: GoogleApiClient;
+  const config = { table: TABLE, gkeUsageMeteringDataset: DATASET };
+  const byNamespace = {
+    days: 30,
+    end: new Date('2025-01-31T00:00:00Z'),
+    utilizationThreshold: 0.5,
+    limit: 10,
+  };
+  const options = { ...byNamespace, workloadLabel: 'app' };
+
+  beforeEach(() => {
+    api = { get: vi.fn(), post: vi.fn() };
+  });
+
+  test('reports costs by workload with their utilization', async () => {
+    vi.mocked(api.post)
+      .mockResolvedValueOnce({
+        jobComplete: true,
+        rows: [
+          row('prod', 'shop', 'frontend', 'USD', '40'),
+          row('prod', 'shop', 'checkout', 'USD', '100.5'),
+          row('dev', 'default', null, 'USD', '9.5'),
+        ],
+      })
+      .mockResolvedValueOnce({
+        jobComplete: true,
+        rows: [
+          row('prod', 'shop', 'frontend', 'cpu', '1000', '800'),
+          row('prod', 'shop', 'frontend', 'memory', '1000', '700'),
+          row('prod', 'shop', 'checkout', 'cpu', '1000', '100'),
+          row('prod', 'shop', 'checkout', 'memory', '1000', '900'),
+        ],
+      });
+
+    const report = await getGkeCosts(api, config, options);
+
+    const [, costQuery] = vi.mocked(api.post).mock.calls[0]!;
+    expect(costQuery).toMatchObject({
+      queryParameters: [
+        { name: 'start', parameterValue: { value: '2025-01-01T00:00:00.000Z' } },
+        { name: 'end', parameterValue: { value: '2025-01-31T00:00:00.000Z' } },
+        { name: 'workload_label', parameterValue: { value: 'k8s-label/app' } },
+      ],
+    });
+    const [, usageQuery] = vi.mocked(api.post).mock.calls[1]!;
+    expect(usageQuery).toMatchObject({
+      queryParameters: [
+        { name: 'start' },
+        { name: 'end' },
+        { name: 'workload_label', parameterValue: { value: 'app' } },
+      ],
+    });
+    expect(report.currency).toBe('USD');
+    expect(report.total).toBe(150);
+    expect(report.clusters).toEqual([
+      { cluster: 'prod', cost: 140.5 },
+      { cluster: 'dev', cost: 9.5 },
+    ]);
+    expect(report.workloads).toEqual([
+      {
+        cluster: 'prod',
+        namespace: 'shop',
+        workload: 'checkout',
+        cost: 100.5,
+        cpuUtilization: 0.1,
+        memoryUtilization: 0.9,
+        overprovisioned: true,
+      },
+      {
+        cluster: 'prod',
+        namespace: 'shop',
+        workload: 'frontend',
+        cost: 40,
+        cpuUtilization: 0.8,
+        memoryUtilization: 0.7,
+        overprovisioned: false,
+      },
+      {
+        cluster: 'dev',
+        namespace: 'default',
+        workload: null,
+        cost: 9.5,
+        cpuUtilization: null,
+        memoryUtilization: null,
+        overprovisioned: false,
+      },
+    ]);
+    expect(report.notes).toEqual([]);
+  });
+
+  test('only reports costs without a usage metering dataset', async () => {
+    vi.mocked(api.post).mockResolvedValue({
+      jobComplete: true,
+      rows: [row('prod', 'shop', null, 'USD', '40')],
+    });
+
+    const report = await getGkeCosts(api, { table: TABLE }, byNamespace);
+
+    expect(api.post).toHaveBeenCalledOnce();
+    expect(report.workloads[0]).toMatchObject({ namespace: 'shop', cpuUtilization: null });
+    expect(report.notes).toEqual([
+      'Set workloadLabel to break namespaces down by workload.',
+      'Utilization is only reported when "billingExport.gkeUsageMeteringDataset" is configured.',
+    ]);
+  });
+
+  test('does not report utilization without resource consumption metering', async () => {
+    vi.mocked(api.post)
+      .mockResolvedValueOnce({ jobComplete: true, rows: [row('prod', 'shop', 'web', 'USD', '40')] })
+      .mockResolvedValueOnce({
+        jobComplete: true,
+        rows: [row('prod', 'shop', 'web', 'cpu', '1000', '0')],
+      });
+
+    const report = await getGkeCosts(api, config, options);
+
+    expect(report.workloads[0]).toMatchObject({ cpuUtilization: null, overprovisioned: false });
+    expect(report.notes[0]).toContain('Enable resource consumption metering');
+  });
+
+  test('reports costs when the usage metering query fails', async () => {
+    vi.mocked(api.post)
+      .mockResolvedValueOnce({ jobComplete: true, rows: [row('prod', 'shop', 'web', 'USD', '40')] })
+      .mockRejectedValueOnce(new Error('Not found: Table gke-project:gke_usage.consumption'));
+
+    const report = await getGkeCosts(api, config, options);
+
+    expect(report.total).toBe(40);
+    expect(report.notes[0]).toContain('Utilization could not be read');
+  });
+
+  test('notes when no GKE costs are labeled', async () => {
+    vi.mocked(api.post).mockResolvedValue({ jobComplete: true, rows: [] });
+
+    const report = await getGkeCosts(api, { table: TABLE }, options);
+
+    expect(report.workloads).toEqual([]);
+    expect(report.notes[0]).toContain('Enable GKE cost allocation');
+  });
+});
@@ -0,0 +1,244 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { GoogleApiClient } from './google_api.js';
+import {
+  BillingExportConfig,
+  queryBillingExport,
+  stringArrayParameter,
+  stringParameter,
+  timestampParameter,
+} from './billing_export.js';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+export interface GkeCostOptions {
+  /** The length of the period in days. */
+  days: number;
+  /** The exclusive end of the period. Defaults to now. */
+  end?: Date;
+  projects?: string[];
+  cluster?: string;
+  /** The pod label that names workloads, e.g. `app`. Without it costs are split by namespace. */
+  workloadLabel?: string;
+  /** Workloads using less than this fraction of their CPU or memory requests are flagged. */
+  utilizationThreshold: number;
+  limit: number;
+}
+
+export interface WorkloadCost {
+  cluster: string;
+  namespace: string | null;
+  workload: string | null;
+  cost: number;
+  /** Consumed over requested CPU, or null without usage metering data. */
+  cpuUtilization: number | null;
+  /** Consumed over requested memory, or null without usage metering data. */
+  memoryUtilization: number | null;
+  overprovisioned: boolean;
+}
+
+export interface GkeCostReport {
+  table: string;
+  period: { start: string; end: string };
+  currency: string | null;
+  total: number;
+  clusters: Array<{ cluster: string; cost: number }>;
+  /** The most expensive workloads first. */
+  workloads: WorkloadCost[];
+  notes: string[];
+}
+
+interface Filters {
+  projects: boolean;
+  cluster: boolean;
+  workload: boolean;
+}
+
+const labelValue = (key: string) => `(SELECT value FROM UNNEST(labels) WHERE key = ${key})`;
+
+// GKE cost allocation labels billing export rows with the cluster, the
+// namespace and the pod labels, the latter prefixed with "k8s-label/".
+const CLUSTER_LABEL = labelValue("'goog-k8s-cluster-name'");
+
+/** Returns the Standard SQL summing net cost, after credits, by cluster, namespace and workload. */
+export const gkeCostQuery = (table: string, filters: Filters): string => {
+  const conditions = [
+    'usage_start_time >= @start AND usage_start_time < @end',
+    `${CLUSTER_LABEL} IS NOT NULL`,
+    ...(filters.projects ? ['project.id IN UNNEST(@projects)'] : []),
+    ...(filters.cluster ? [`${CLUSTER_LABEL} = @cluster`] : []),
+  ];
+  return `SELECT
+  ${CLUSTER_LABEL} AS cluster,
+  ${labelValue("'k8s-namespace'")} AS namespace,
+  ${filters.workload ? labelValue('@workload_label') : 'CAST(NULL AS STRING)'} AS workload,
+  ANY_VALUE(currency) AS currency,
+  SUM(cost + IFNULL((SELECT SUM(c.amount) FROM UNNEST(credits) c), 0)) AS net_cost
+FROM \`${table}\`
+WHERE ${conditions.join('\n  AND ')}
+GROUP BY cluster, namespace, workload`;
+};
+
+/**
+ * Returns the Standard SQL comparing requested and consumed CPU and memory
+ * from the GKE usage metering tables in a dataset.
+ */
+export const gkeUsageQuery = (dataset: string, filters: Filters): string => {
+  const workload = filters.workload ? labelValue('@workload_label') : 'CAST(NULL AS STRING)';
+  const source = (name: string, table: string) =>
+    `SELECT '${name}' AS source, project.id AS project_id, cluster_name, namespace, ${workload} AS workload, resource_name, usage.amount AS amount, start_time
+  FROM \`${dataset}.${table}\``;
+  const conditions = [
+    'start_time >= @start AND start_time < @end',
+    "resource_name IN ('cpu', 'memory')",
+    ...(filters.projects ? ['project_id IN UNNEST(@projects)'] : []),
+    ...(filters.cluster ? ['cluster_name = @cluster'] : []),
+  ];
+  return `SELECT
+  cluster_name,
+  namespace,
+  workload,
+  resource_name,
+  SUM(IF(source = 'requested', amount, 0)) AS requested,
+  SUM(IF(source = 'consumed', amount, 0)) AS consumed
+FROM (
+  ${source('requested', 'gke_cluster_resource_usage')}
+  UNION ALL
+  ${source('consumed', 'gke_cluster_resource_consumption')}
+)
+WHERE ${conditions.join('\n  AND ')}
+GROUP BY cluster_name, namespace, workload, resource_name`;
+};
+
+const round = (value: number) => Math.round(value * 100) / 100;
+
+const keyOf = (cluster: string | null, namespace: string | null, workload: string | null) =>
+  JSON.stringify([cluster, namespace, workload]);
+
+/**
+ * Breaks down GKE spend in the billing export by cluster, namespace and
+ * workload, and flags workloads whose requests are far above their usage.
+ *
+ * Costs need GKE cost allocation on the clusters. Utilization needs usage
+ * metering, with resource consumption metering, and `gkeUsageMeteringDataset`.
+ */
+export const getGkeCosts = async (
+  api: GoogleApiClient,
+  config: BillingExportConfig,
+  options: GkeCostOptions,
+): Promise<GkeCostReport> => {
+  const end = options.end ?? new Date();
+  const start = new Date(end.getTime() - options.days * DAY_MS);
+  const projects = options.projects ?? [];
+  const filters = {
+    projects: projects.length > 0,
+    cluster: !!options.cluster,
+    workload: !!options.workloadLabel,
+  };
+  const parameters = (workloadLabel: string | undefined) => [
+    timestampParameter('start', start),
+    timestampParameter('end', end),
+    ...(filters.projects ? [stringArrayParameter('projects', projects)] : []),
+    ...(filters.cluster ? [stringParameter('cluster', options.cluster)] : []),
+    ...(filters.workload ? [stringParameter('workload_label', workloadLabel)] : []),
+  ];
+  const notes: string[] = [];
+
+  const costRows = await queryBillingExport(
+    api,
+    config,
+    gkeCostQuery(config.table, filters),
+    parameters(options.workloadLabel && `k8s-label/${options.workloadLabel}`),
+  );
+  if (costRows.length === 0) {
+    notes.push(
+      'No GKE costs were found. Enable GKE cost allocation on the clusters to label their costs in the billing export.',
+    );
+  }
+  if (!options.workloadLabel) {
+    notes.push('Set workloadLabel to break namespaces down by workload.');
+  }
+
+  const usage = new Map<string, { cpu?: number; memory?: number }>();
+  const dataset = config.gkeUsageMeteringDataset;
+  if (!dataset) {
+    notes.push(
+      'Utilization is only reported when "billingExport.gkeUsageMeteringDataset" is configured.',
+    );
+  } else {
+    try {
+      const usageRows = await queryBillingExport(
+        api,
+        config,
+        gkeUsageQuery(dataset, filters),
+        parameters(options.workloadLabel),
+      );
+      // Without resource consumption metering nothing is ever consumed, which
+      // says nothing about utilization.
+      if (usageRows.some(([, , , , , consumed]) => Number(consumed ?? 0) > 0)) {
+        for (const [cluster, namespace, workload, resource, requested, consumed] of usageRows) {
+          const key = keyOf(cluster ?? null, namespace ?? null, workload ?? null);
+          const entry = usage.get(key) ?? {};
+          if (Number(requested ?? 0) > 0 && (resource === 'cpu' || resource === 'memory')) {
+            entry[resource] = round(Number(consumed ?? 0) / Number(requested));
+          }
+          usage.set(key, entry);
+        }
+      } else {
+        notes.push(
+          'No resource consumption was found. Enable resource consumption metering on the clusters to report utilization.',
+        );
+      }
+    } catch (e: unknown) {
+      const msg = e instanceof Error ? e.message : String(e);
+      notes.push(`Utilization could not be read from the usage metering dataset: ${msg}`);
+    }
+  }
+
+  const workloads = costRows.map(([cluster, namespace, workload, , cost]): WorkloadCost => {
+    const utilization = usage.get(keyOf(cluster ?? null, namespace ?? null, workload ?? null));
+    const cpuUtilization = utilization?.cpu ?? null;
+    const memoryUtilization = utilization?.memory ?? null;
+    return {
+      cluster: cluster ?? '',
+      namespace: namespace ?? null,
+      workload: workload ?? null,
+      cost: round(Number(cost ?? 0)),
+      cpuUtilization,
+      memoryUtilization,
+      overprovisioned: [cpuUtilization, memoryUtilization].some(
+        (value) => value !== null && value < options.utilizationThreshold,
+      ),
+    };
+  });
+  const clusterCosts = new Map<string, number>();
+  for (const workload of workloads) {
+    clusterCosts.set(workload.cluster, (clusterCosts.get(workload.cluster) ?? 0) + workload.cost);
+  }
+
+  return {
+    table: config.table,
+    period: { start: start.toISOString(), end: end.toISOString() },
+    currency: costRows.find((row) => row[3])?.[3] ?? null,
+    total: round(workloads.reduce((sum, workload) => sum + workload.cost, 0)),
+    clusters: [...clusterCosts]
+      .map(([cluster, cost]) => ({ cluster, cost: round(cost) }))
+      .sort((a, b) => b.cost - a.cost),
+    workloads: workloads.sort((a, b) => b.cost - a.cost).slice(0, options.limit),
+    notes,
+  };
+};
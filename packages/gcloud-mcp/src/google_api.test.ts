@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { createGoogleApiClient } from './google_api.js';
+
+vi.mock('./gcloud.js');
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const jsonResponse = (body: unknown, status = 200) =>
+  ({
+    ok: status === 200,
+    status,
+    json: async () => body,
+    text: async () => JSON.stringify(body),
+  }) as Response;
+
+describe('createGoogleApiClient', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: 'token\n', stderr: '' }),
+    };
+  });
+
+  test('authorizes requests with the gcloud access token', async () => {
+    const fetchFn = vi.fn().mockResolvedValue(jsonResponse({ name: 'value' }));
+    const api = createGoogleApiClient(mockedGcloud, fetchFn);
+
+    await expect(api.get('https://example.googleapis.com/v1/things')).resolves.toEqual({
+      name: 'value',
+    });
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(['auth', 'print-access-token']);
+    expect(fetchFn).toHaveBeenCalledWith('https://example.googleapis.com/v1/things', {
+      headers: { Authorization: 'Bearer token' },
+    });
+  });
+
+  test('sends JSON bodies', async () => {
+    const fetchFn = vi.fn().mockResolvedValue(jsonResponse({}));
+    const api = createGoogleApiClient(mockedGcloud, fetchFn);
+
+    await api.post('https://example.googleapis.com/v1/things', { a: 1 });
+
+    expect(fetchFn).toHaveBeenCalledWith('https://example.googleapis.com/v1/things', {
+      method: 'POST',
+      body: '{"a":1}',
+      headers: { Authorization: 'Bearer token', 'Content-Type': 'application/json' },
+    });
+  });
+
+  test('sends multipart uploads', async () => {
+    const fetchFn = vi.fn().mockResolvedValue(jsonResponse({}));
+    const api = createGoogleApiClient(mockedGcloud, fetchFn);
+
+    await api.upload(
+      'https://example.googleapis.com/upload/v1/files',
+      { name: 'a.txt' },
+      'hello',
+      'text/plain',
+    );
+
+    const [url, init] = fetchFn.mock.calls[0]!;
+    expect(url).toBe('https://example.googleapis.com/upload/v1/files?uploadType=multipart');
+    expect(init.headers['Content-Type']).toMatch(/^multipart\/related; boundary=/);
+    expect(init.body.split('\r\n')).toEqual([
+      expect.stringMatching(/^--/),
+      'Content-Type: application/json; charset=UTF-8',
+      '',
+      '{"name":"a.txt"}',
+      expect.stringMatching(/^--/),
+      'Content-Type: text/plain',
+      '',
+      'hello',
+      expect.stringMatching(/--$/),
+    ]);
+  });
+
+  test('fails on error responses', async () => {
+    const fetchFn = vi.fn().mockResolvedValue(jsonResponse({ error: 'unavailable' }, 503));
+    const api = createGoogleApiClient(mockedGcloud, fetchFn);
+
+    await expect(api.get('https://example.googleapis.com/v1/things')).rejects.toThrow(
+      'failed with 503',
+    );
+  });
+
+  test('fails if gcloud can not print an access token', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 1,
+      stdout: '',
+      stderr: 'ERROR: You do not currently have an active account selected.',
+    });
+    const api = createGoogleApiClient(mockedGcloud, vi.fn());
+
+    await expect(api.get('https://example.googleapis.com/v1/things')).rejects.toThrow(
+      'Unable to get an access token',
+    );
+  });
+});
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { GcloudExecutable } from './gcloud.js';
+
+export interface GoogleApiClient {
+  get: (url: string) => Promise<unknown>;
+  post: (url: string, body: unknown) => Promise<unknown>;
+}
+
+/** A client that can also upload files, for the few APIs that take them. */
+export interface GoogleApiUploadClient extends GoogleApiClient {
+  /** Sends a multipart upload of JSON metadata and the content of a file. */
+  upload: (
+    url: string,
+    metadata: unknown,
+    content: string,
+    contentType: string,
+  ) => Promise<unknown>;
+}
+
+// Separates the metadata and media parts of a multipart upload.
+const UPLOAD_BOUNDARY = 'gcloud_mcp_upload_boundary';
+
+/**
+ * Creates a client for Google Cloud REST APIs that gcloud has no commands for.
+ *
+ * Requests are authorized with the access token of the active gcloud account,
+ * so they run with the same identity as every other command.
+ */
+export const createGoogleApiClient = (
+  gcloud: GcloudExecutable,
+  fetchFn: typeof fetch = fetch,
+): GoogleApiUploadClient => {
+  const accessToken = async (): Promise<string> => {
+    const { code, stdout, stderr } = await gcloud.invoke(['auth', 'print-access-token']);
+    if (code !== 0) {
+      throw new Error(`Unable to get an access token from gcloud: ${stderr}`);
+    }
+    return stdout.trim();
+  };
+
+  const request = async (
+    url: string,
+    init: RequestInit = {},
+    contentType = 'application/json',
+  ): Promise<unknown> => {
+    const response = await fetchFn(url, {
+      ...init,
+      headers: {
+        Authorization: `Bearer ${await accessToken()}`,
+        ...(init.body !== undefined && { 'Content-Type': contentType }),
+      },
+    });
+    if (!response.ok) {
+      throw new Error(`Request to ${url} failed with ${response.status}: ${await response.text()}`);
+    }
+    return response.json();
+  };
+
+  return {
+    get: (url) => request(url),
+    post: (url, body) => request(url, { method: 'POST', body: JSON.stringify(body) }),
+    upload: (url, metadata, content, contentType) =>
+      request(
+        `${url}${url.includes('?') ? '&' : '?'}uploadType=multipart`,
+        {
+          method: 'POST',
+          body: [
+            `--${UPLOAD_BOUNDARY}`,
+            'Content-Type: application/json; charset=UTF-8',
+            '',
+            JSON.stringify(metadata),
+            `--${UPLOAD_BOUNDARY}`,
+            `Content-Type: ${contentType}`,
+            '',
+            content,
+            `--${UPLOAD_BOUNDARY}--`,
+          ].join('\r\n'),
+        },
+        `multipart/related; boundary=${UPLOAD_BOUNDARY}`,
+      ),
+  };
+};
@@ -0,0 +1,113 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { createCommandHistory } from './command_history.js';
+import { checkHealth } from './health.js';
+
+vi.mock('./gcloud.js');
+
+const RESPONSES: Record<string, { code: number; stdout: string; stderr: string }> = {
+  version: { code: 0, stdout: '{"Google Cloud SDK": "530.0.0"}', stderr: '' },
+  'auth list': { code: 0, stdout: '[{"account": "dev@example.com"}]', stderr: '' },
+  'auth print-access-token': { code: 0, stdout: 'ya29.secret\n', stderr: '' },
+  'projects list': { code: 0, stdout: '[{"projectId": "p"}]', stderr: '' },
+};
+
+let responses: typeof RESPONSES;
+let mockedGcloud: gcloud.GcloudExecutable;
+
+describe('checkHealth', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    responses = { ...RESPONSES };
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(async (args: string[]) => {
+        const key = args[0] === 'version' ? 'version' : args.slice(0, 2).join(' ');
+        return responses[key] ?? { code: 1, stdout: '', stderr: 'unexpected command' };
+      }),
+    };
+  });
+
+  test('reports a healthy server', async () => {
+    const report = await checkHealth(mockedGcloud, {
+      serverVersion: '1.2.3',
+      history: createCommandHistory(),
+    });
+
+    expect(report).toMatchObject({
+      status: 'healthy',
+      serverVersion: '1.2.3',
+      gcloud: { ok: true, version: '530.0.0' },
+      auth: { ok: true, account: 'dev@example.com' },
+      apis: { 'cloudresourcemanager.googleapis.com': { ok: true } },
+      cache: { billingCatalogServices: 0 },
+      queueDepth: {},
+    });
+    expect(JSON.stringify(report)).not.toContain('ya29');
+  });
+
+  test('reports expired credentials as degraded', async () => {
+    responses['auth print-access-token'] = {
+      code: 1,
+      stdout: '',
+      stderr: 'ERROR: (gcloud.auth.print-access-token) Reauthentication failed.\nmore',
+    };
+
+    const report = await checkHealth(mockedGcloud, {
+      serverVersion: '1.2.3',
+      history: createCommandHistory(),
+    });
+
+    expect(report.status).toBe('degraded');
+    expect(report.auth).toMatchObject({
+      ok: false,
+      error: 'ERROR: (gcloud.auth.print-access-token) Reauthentication failed.',
+    });
+  });
+
+  test('counts recent failed commands by API family', async () => {
+    const history = createCommandHistory();
+    history.record(['compute', 'instances', 'list'], 1, {}, 'compute instances list');
+    history.record(['beta', 'run', 'deploy'], 1, {}, 'beta run deploy');
+    history.record(['compute', 'disks', 'list'], 0, {}, 'compute disks list');
+
+    const report = await checkHealth(mockedGcloud, { serverVersion: '1.2.3', history });
+
+    expect(report.recentErrors).toEqual({
+      windowMinutes: 60,
+      commands: 3,
+      failed: 2,
+      byApiFamily: { compute: 1, run: 1 },
+    });
+  });
+
+  test('ignores failures outside the window', async () => {
+    const history = createCommandHistory();
+    history.record(['compute', 'instances', 'list'], 1);
+    const later = new Date(history.list()[0]!.timestamp).getTime() + 2 * 60 * 60 * 1000;
+
+    const report = await checkHealth(
+      mockedGcloud,
+      { serverVersion: '1.2.3', history },
+      () => later,
+    );
+
+    expect(report.recentErrors).toMatchObject({ commands: 0, failed: 0 });
+  });
+});
@@ -0,0 +1,131 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { CommandHistory } from './command_history.js';
+import { RateLimiter, apiFamilyOf } from './rate_limiter.js';
+import { CachedBillingCatalog } from './billing_catalog.js';
+
+// Failed commands are counted over this window.
+export const RECENT_ERRORS_WINDOW_MS = 60 * 60 * 1000;
+
+export interface HealthProbe {
+  ok: boolean;
+  latencyMs: number;
+  /** The first line of the error output if the probe failed. */
+  error?: string;
+}
+
+export interface HealthReport {
+  status: 'healthy' | 'degraded';
+  serverVersion: string;
+  uptimeSeconds: number;
+  gcloud: HealthProbe & { version: string | null };
+  auth: HealthProbe & { account: string | null };
+  apis: Record<string, HealthProbe>;
+  cache: { billingCatalogServices: number };
+  queueDepth: Record<string, number>;
+  recentErrors: {
+    windowMinutes: number;
+    commands: number;
+    failed: number;
+    byApiFamily: Record<string, number>;
+  };
+}
+
+export interface HealthSources {
+  serverVersion: string;
+  history: CommandHistory;
+  rateLimiter?: RateLimiter | undefined;
+  catalog?: CachedBillingCatalog | undefined;
+}
+
+/** Runs a gcloud command and times it. The output is only passed to `parse`. */
+const probe = async <T>(
+  gcloud: GcloudExecutable,
+  args: string[],
+  parse: (stdout: string) => T,
+): Promise<{ probe: HealthProbe; value: T | null }> => {
+  const start = Date.now();
+  try {
+    const { code, stdout, stderr } = await gcloud.invoke(args);
+    const latencyMs = Date.now() - start;
+    if (code !== 0) {
+      const error = stderr.trim().split('\n')[0] || `exit code ${code}`;
+      return { probe: { ok: false, latencyMs, error }, value: null };
+    }
+    return { probe: { ok: true, latencyMs }, value: parse(stdout) };
+  } catch (e: unknown) {
+    const error = e instanceof Error ? e.message : String(e);
+    return { probe: { ok: false, latencyMs: Date.now() - start, error }, value: null };
+  }
+};
+
+const recentErrorsOf = (history: CommandHistory, now: number): HealthReport['recentErrors'] => {
+  const recent = history
+    .list()
+    .filter((entry) => now - new Date(entry.timestamp).getTime() <= RECENT_ERRORS_WINDOW_MS);
+  const failed = recent.filter((entry) => entry.exitCode !== 0);
+  const byApiFamily: Record<string, number> = {};
+  for (const entry of failed) {
+    const family = apiFamilyOf(entry.command ?? entry.args.join(' '));
+    byApiFamily[family] = (byApiFamily[family] ?? 0) + 1;
+  }
+  return {
+    windowMinutes: RECENT_ERRORS_WINDOW_MS / 60000,
+    commands: recent.length,
+    failed: failed.length,
+    byApiFamily,
+  };
+};
+
+/**
+ * Checks whether the server can do its job: gcloud runs, the active account
+ * has valid credentials, and Google Cloud APIs are reachable. The probes run
+ * concurrently and never expose credentials.
+ */
+export const checkHealth = async (
+  gcloud: GcloudExecutable,
+  sources: HealthSources,
+  now: () => number = Date.now,
+): Promise<HealthReport> => {
+  const [version, account, token, resourceManager] = await Promise.all([
+    probe(gcloud, ['version', '--format=json'], (stdout) =>
+      z.record(z.string()).parse(JSON.parse(stdout)),
+    ),
+    probe(gcloud, ['auth', 'list', '--filter=status:ACTIVE', '--format=json(account)'], (stdout) =>
+      z.array(z.object({ account: z.string() })).parse(JSON.parse(stdout)),
+    ),
+    // The token itself is discarded; only whether it could be minted matters.
+    probe(gcloud, ['auth', 'print-access-token'], () => true),
+    probe(gcloud, ['projects', 'list', '--limit=1', '--format=json(projectId)'], () => true),
+  ]);
+  const apis = { 'cloudresourcemanager.googleapis.com': resourceManager.probe };
+  const healthy = [version.probe, token.probe, ...Object.values(apis)].every(({ ok }) => ok);
+
+  return {
+    status: healthy ? 'healthy' : 'degraded',
+    serverVersion: sources.serverVersion,
+    uptimeSeconds: Math.round(process.uptime()),
+    gcloud: { ...version.probe, version: version.value?.['Google Cloud SDK'] ?? null },
+    auth: { ...token.probe, account: account.value?.[0]?.account ?? null },
+    apis,
+    cache: { billingCatalogServices: sources.catalog?.cachedServices().length ?? 0 },
+    queueDepth: sources.rateLimiter?.queueDepth() ?? {},
+    recentErrors: recentErrorsOf(sources.history, now()),
+  };
+};
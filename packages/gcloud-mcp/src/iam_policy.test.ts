@@ -0,0 +1,232 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from './google_api.js';
+import {
+  analyzeIamPolicyArgs,
+  getIamPolicyArgs,
+  parseIamResource,
+  summarizeAnalysis,
+  summarizePolicy,
+  testPermissions,
+} from './iam_policy.js';
+
+describe('parseIamResource', () => {
+  test('parses short and full resource names', () => {
+    expect(parseIamResource('projects/my-project')).toEqual({ kind: 'project', id: 'my-project' });
+    expect(parseIamResource('//cloudresourcemanager.googleapis.com/folders/123')).toEqual({
+      kind: 'folder',
+      id: '123',
+    });
+    expect(parseIamResource('organizations/456')).toEqual({ kind: 'organization', id: '456' });
+    expect(parseIamResource('gs://my-bucket')).toEqual({ kind: 'bucket', id: 'my-bucket' });
+    expect(parseIamResource('//storage.googleapis.com/my-bucket')).toEqual({
+      kind: 'bucket',
+      id: 'my-bucket',
+    });
+    expect(parseIamResource('projects/p/serviceAccounts/sa@p.iam.gserviceaccount.com')).toEqual({
+      kind: 'serviceAccount',
+      id: 'sa@p.iam.gserviceaccount.com',
+    });
+  });
+
+  test('rejects unsupported resources', () => {
+    expect(parseIamResource('projects/p/zones/z/instances/vm')).toBeUndefined();
+    expect(parseIamResource('folders/not-a-number')).toBeUndefined();
+  });
+});
+
+describe('getIamPolicyArgs', () => {
+  test('uses the command of the resource kind', () => {
+    expect(getIamPolicyArgs({ kind: 'bucket', id: 'b' })).toEqual([
+      'storage',
+      'buckets',
+      'get-iam-policy',
+      'gs://b',
+      '--format=json',
+    ]);
+    expect(getIamPolicyArgs({ kind: 'folder', id: '1' })).toEqual([
+      'resource-manager',
+      'folders',
+      'get-iam-policy',
+      '1',
+      '--format=json',
+    ]);
+  });
+});
+
+describe('analyzeIamPolicyArgs', () => {
+  test('analyzes access to a resource', () => {
+    expect(
+      analyzeIamPolicyArgs({
+        scope: 'organizations/123',
+        resource: { kind: 'bucket', id: 'b' },
+        permission: 'storage.buckets.delete',
+        expandGroups: true,
+      }),
+    ).toEqual([
+      'asset',
+      'analyze-iam-policy',
+      '--organization=123',
+      '--full-resource-name=//storage.googleapis.com/b',
+      '--permissions=storage.buckets.delete',
+      '--expand-groups',
+      '--format=json',
+    ]);
+  });
+
+  test('expands resources without a resource', () => {
+    expect(analyzeIamPolicyArgs({ scope: 'projects/p', role: 'roles/owner' })).toEqual([
+      'asset',
+      'analyze-iam-policy',
+      '--project=p',
+      '--roles=roles/owner',
+      '--expand-resources',
+      '--format=json',
+    ]);
+  });
+
+  test('rejects invalid scopes', () => {
+    expect(() => analyzeIamPolicyArgs({ scope: 'p', role: 'roles/owner' })).toThrow(
+      'Invalid scope "p"',
+    );
+  });
+});
+
+describe('summarizePolicy', () => {
+  test('lists the roles of each member', () => {
+    const summary = summarizePolicy({
+      etag: 'BwX',
+      bindings: [
+        { role: 'roles/owner', members: ['user:a@example.com'] },
+        {
+          role: 'roles/viewer',
+          members: ['user:a@example.com', 'group:g@example.com'],
+          condition: { title: 'temp', expression: 'request.time < timestamp("2026-01-01")' },
+        },
+      ],
+    });
+
+    expect(summary.etag).toBe('BwX');
+    expect(summary.bindings[0]!.condition).toBeNull();
+    expect(summary.bindings[1]!.condition).toEqual({
+      title: 'temp',
+      expression: 'request.time < timestamp("2026-01-01")',
+    });
+    expect(summary.rolesByMember).toEqual({
+      'user:a@example.com': ['roles/owner', 'roles/viewer'],
+      'group:g@example.com': ['roles/viewer'],
+    });
+  });
+});
+
+describe('summarizeAnalysis', () => {
+  test('lists a grant per principal, preferring expanded group members', () => {
+    const analysis = summarizeAnalysis({
+      mainAnalysis: {
+        fullyExplored: true,
+        analysisResults: [
+          {
+            attachedResourceFullName: '//cloudresourcemanager.googleapis.com/projects/p',
+            iamBinding: { role: 'roles/storage.admin', members: ['group:ops@example.com'] },
+            accessControlLists: [
+              { resources: [{ fullResourceName: '//storage.googleapis.com/b' }] },
+              { resources: [{ fullResourceName: '//storage.googleapis.com/b' }] },
+            ],
+            identityList: {
+              identities: [{ name: 'user:b@example.com' }, { name: 'user:a@example.com' }],
+            },
+          },
+          {
+            attachedResourceFullName: '//storage.googleapis.com/b',
+            iamBinding: {
+              role: 'roles/storage.legacyBucketOwner',
+              members: ['projectOwner:p'],
+              condition: { expression: 'true' },
+            },
+          },
+        ],
+      },
+    });
+
+    expect(analysis).toEqual({
+      principals: ['projectOwner:p', 'user:a@example.com', 'user:b@example.com'],
+      grants: [
+        {
+          principal: 'user:b@example.com',
+          role: 'roles/storage.admin',
+          grantedOn: '//cloudresourcemanager.googleapis.com/projects/p',
+          resources: ['//storage.googleapis.com/b'],
+          condition: null,
+        },
+        {
+          principal: 'user:a@example.com',
+          role: 'roles/storage.admin',
+          grantedOn: '//cloudresourcemanager.googleapis.com/projects/p',
+          resources: ['//storage.googleapis.com/b'],
+          condition: null,
+        },
+        {
+          principal: 'projectOwner:p',
+          role: 'roles/storage.legacyBucketOwner',
+          grantedOn: '//storage.googleapis.com/b',
+          resources: [],
+          condition: 'true',
+        },
+      ],
+      fullyExplored: true,
+    });
+  });
+});
+
+describe('testPermissions', () => {
+  test('tests project permissions with Resource Manager', async () => {
+    const api: GoogleApiClient = {
+      get: vi.fn(),
+      post: vi.fn().mockResolvedValue({ permissions: ['resourcemanager.projects.get'] }),
+    };
+
+    const check = await testPermissions(api, { kind: 'project', id: 'p' }, [
+      'resourcemanager.projects.get',
+      'resourcemanager.projects.delete',
+    ]);
+
+    expect(api.post).toHaveBeenCalledWith(
+      'https://cloudresourcemanager.googleapis.com/v3/projects/p:testIamPermissions',
+      { permissions: ['resourcemanager.projects.get', 'resourcemanager.projects.delete'] },
+    );
+    expect(check).toEqual({
+      resource: '//cloudresourcemanager.googleapis.com/projects/p',
+      granted: ['resourcemanager.projects.get'],
+      denied: ['resourcemanager.projects.delete'],
+      allGranted: false,
+    });
+  });
+
+  test('tests bucket permissions with Cloud Storage', async () => {
+    const api: GoogleApiClient = { get: vi.fn().mockResolvedValue({}), post: vi.fn() };
+
+    const check = await testPermissions(api, { kind: 'bucket', id: 'b' }, [
+      'storage.buckets.delete',
+    ]);
+
+    expect(api.get).toHaveBeenCalledWith(
+      'https://storage.googleapis.com/storage/v1/b/b/iam/testPermissions?permissions=storage.buckets.delete',
+    );
+    expect(check.denied).toEqual(['storage.buckets.delete']);
+  });
+});
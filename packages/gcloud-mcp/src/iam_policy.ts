@@ -0,0 +1,293 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GoogleApiClient } from './google_api.js';
+
+export type IamResourceKind = 'project' | 'folder' | 'organization' | 'bucket' | 'serviceAccount';
+
+/** A resource with an IAM policy, e.g. `{ kind: 'bucket', id: 'my-bucket' }`. */
+export interface IamResource {
+  kind: IamResourceKind;
+  id: string;
+}
+
+const RESOURCE_PATTERNS: Array<[RegExp, IamResourceKind]> = [
+  [/^(?:\/\/cloudresourcemanager\.googleapis\.com\/)?projects\/([^/]+)$/, 'project'],
+  [/^(?:\/\/cloudresourcemanager\.googleapis\.com\/)?folders\/(\d+)$/, 'folder'],
+  [/^(?:\/\/cloudresourcemanager\.googleapis\.com\/)?organizations\/(\d+)$/, 'organization'],
+  [/^(?:gs:\/|\/\/storage\.googleapis\.com)\/([^/]+)\/?$/, 'bucket'],
+  [
+    /^(?:(?:\/\/iam\.googleapis\.com\/)?projects\/[^/]+\/serviceAccounts\/)?([^/@]+@[^/]+)$/,
+    'serviceAccount',
+  ],
+];
+
+export const RESOURCE_FORMATS =
+  'projects/PROJECT_ID, folders/FOLDER_ID, organizations/ORGANIZATION_ID, gs://BUCKET or a service account email';
+
+/** Parses the name of a resource with an IAM policy, or returns undefined. */
+export const parseIamResource = (resource: string): IamResource | undefined => {
+  for (const [pattern, kind] of RESOURCE_PATTERNS) {
+    const match = resource.trim().match(pattern);
+    if (match) {
+      return { kind, id: match[1]! };
+    }
+  }
+  return undefined;
+};
+
+/** Returns the full resource name used by Cloud Asset Inventory. */
+export const fullResourceName = ({ kind, id }: IamResource): string => {
+  switch (kind) {
+    case 'project':
+      return `//cloudresourcemanager.googleapis.com/projects/${id}`;
+    case 'folder':
+      return `//cloudresourcemanager.googleapis.com/folders/${id}`;
+    case 'organization':
+      return `//cloudresourcemanager.googleapis.com/organizations/${id}`;
+    case 'bucket':
+      return `//storage.googleapis.com/${id}`;
+    case 'serviceAccount':
+      return `//iam.googleapis.com/projects/-/serviceAccounts/${id}`;
+  }
+};
+
+export const getIamPolicyArgs = ({ kind, id }: IamResource): string[] => {
+  const command = {
+    project: ['projects', 'get-iam-policy', id],
+    folder: ['resource-manager', 'folders', 'get-iam-policy', id],
+    organization: ['organizations', 'get-iam-policy', id],
+    bucket: ['storage', 'buckets', 'get-iam-policy', `gs://${id}`],
+    serviceAccount: ['iam', 'service-accounts', 'get-iam-policy', id],
+  }[kind];
+  return [...command, '--format=json'];
+};
+
+export interface AnalyzeQuery {
+  /** The project, folder or organization whose policies are analyzed, e.g. `organizations/123`. */
+  scope: string;
+  resource?: IamResource | undefined;
+  role?: string | undefined;
+  permission?: string | undefined;
+  /** Lists the members of groups instead of the groups. */
+  expandGroups?: boolean | undefined;
+}
+
+const SCOPE_FLAGS: Record<string, string> = {
+  projects: 'project',
+  folders: 'folder',
+  organizations: 'organization',
+};
+
+export const analyzeIamPolicyArgs = (query: AnalyzeQuery): string[] => {
+  const [scopeKind = '', scopeId] = query.scope.split('/');
+  const scopeFlag = SCOPE_FLAGS[scopeKind];
+  if (!scopeFlag || !scopeId) {
+    throw new Error(
+      `Invalid scope "${query.scope}". Use projects/PROJECT_ID, folders/FOLDER_ID or organizations/ORGANIZATION_ID.`,
+    );
+  }
+  return [
+    'asset',
+    'analyze-iam-policy',
+    `--${scopeFlag}=${scopeId}`,
+    ...(query.resource ? [`--full-resource-name=${fullResourceName(query.resource)}`] : []),
+    ...(query.role ? [`--roles=${query.role}`] : []),
+    ...(query.permission ? [`--permissions=${query.permission}`] : []),
+    ...(query.expandGroups ? ['--expand-groups'] : []),
+    // Resources below the given one, e.g. the buckets of a project, inherit its policy.
+    ...(query.resource ? [] : ['--expand-resources']),
+    '--format=json',
+  ];
+};
+
+const ConditionSchema = z.object({
+  title: z.string().nullish(),
+  expression: z.string(),
+});
+
+const PolicySchema = z.object({
+  etag: z.string().nullish(),
+  bindings: z
+    .array(
+      z.object({
+        role: z.string(),
+        members: z.array(z.string()).default([]),
+        condition: ConditionSchema.nullish(),
+      }),
+    )
+    .default([]),
+});
+
+export const IamBindingSchema = z.object({
+  role: z.string(),
+  members: z.array(z.string()),
+  condition: z.object({ title: z.string().nullable(), expression: z.string() }).nullable(),
+});
+export type IamBinding = z.infer<typeof IamBindingSchema>;
+
+export const IamPolicySummarySchema = z.object({
+  etag: z.string().nullable(),
+  bindings: z.array(IamBindingSchema),
+  /** The roles of each member, e.g. `{"user:a@example.com": ["roles/viewer"]}`. */
+  rolesByMember: z.record(z.array(z.string())),
+});
+export type IamPolicySummary = z.infer<typeof IamPolicySummarySchema>;
+
+/** Summarizes the output of `gcloud ... get-iam-policy --format=json`. */
+export const summarizePolicy = (json: unknown): IamPolicySummary => {
+  const policy = PolicySchema.parse(json);
+  const bindings = policy.bindings.map((binding) => ({
+    role: binding.role,
+    members: binding.members,
+    condition: binding.condition
+      ? { title: binding.condition.title ?? null, expression: binding.condition.expression }
+      : null,
+  }));
+  const rolesByMember: Record<string, string[]> = {};
+  for (const binding of bindings) {
+    for (const member of binding.members) {
+      rolesByMember[member] = [...(rolesByMember[member] ?? []), binding.role];
+    }
+  }
+  return { etag: policy.etag ?? null, bindings, rolesByMember };
+};
+
+// There are more fields in each analysis result, but only these are used.
+const AnalysisSchema = z.object({
+  mainAnalysis: z
+    .object({
+      fullyExplored: z.boolean().nullish(),
+      analysisResults: z
+        .array(
+          z.object({
+            attachedResourceFullName: z.string(),
+            iamBinding: z.object({
+              role: z.string(),
+              members: z.array(z.string()).default([]),
+              condition: ConditionSchema.nullish(),
+            }),
+            accessControlLists: z
+              .array(
+                z.object({
+                  resources: z.array(z.object({ fullResourceName: z.string() })).nullish(),
+                }),
+              )
+              .nullish(),
+            identityList: z
+              .object({ identities: z.array(z.object({ name: z.string() })).nullish() })
+              .nullish(),
+          }),
+        )
+        .default([]),
+    })
+    .default({}),
+});
+
+export const AccessGrantSchema = z.object({
+  principal: z.string(),
+  role: z.string(),
+  /** The resource whose policy grants the role. Resources below it inherit the grant. */
+  grantedOn: z.string(),
+  /** The resources the principal has the access on. */
+  resources: z.array(z.string()),
+  condition: z.string().nullable(),
+});
+export type AccessGrant = z.infer<typeof AccessGrantSchema>;
+
+export const AccessAnalysisSchema = z.object({
+  principals: z.array(z.string()),
+  grants: z.array(AccessGrantSchema),
+  /** False if the analysis stopped early, so there may be more principals. */
+  fullyExplored: z.boolean(),
+});
+export type AccessAnalysis = z.infer<typeof AccessAnalysisSchema>;
+
+/** Summarizes the output of `gcloud asset analyze-iam-policy --format=json`. */
+export const summarizeAnalysis = (json: unknown): AccessAnalysis => {
+  const { mainAnalysis } = AnalysisSchema.parse(json);
+  const grants = mainAnalysis.analysisResults.flatMap((result) => {
+    const identities = result.identityList?.identities?.map((identity) => identity.name);
+    const resources = [
+      ...new Set(
+        (result.accessControlLists ?? []).flatMap((acl) =>
+          (acl.resources ?? []).map((resource) => resource.fullResourceName),
+        ),
+      ),
+    ];
+    return (identities?.length ? identities : result.iamBinding.members).map((principal) => ({
+      principal,
+      role: result.iamBinding.role,
+      grantedOn: result.attachedResourceFullName,
+      resources,
+      condition: result.iamBinding.condition?.expression ?? null,
+    }));
+  });
+  return {
+    principals: [...new Set(grants.map((grant) => grant.principal))].sort(),
+    grants,
+    fullyExplored: mainAnalysis.fullyExplored ?? true,
+  };
+};
+
+const TestPermissionsSchema = z.object({ permissions: z.array(z.string()).default([]) });
+
+export interface PermissionCheck {
+  resource: string;
+  granted: string[];
+  denied: string[];
+  allGranted: boolean;
+}
+
+/**
+ * Tests which of the permissions the active gcloud account has on a resource,
+ * with the testIamPermissions method of the service that owns it.
+ */
+export const testPermissions = async (
+  api: GoogleApiClient,
+  resource: IamResource,
+  permissions: string[],
+): Promise<PermissionCheck> => {
+  const { kind, id } = resource;
+  let response: unknown;
+  if (kind === 'bucket') {
+    const params = new URLSearchParams(
+      permissions.map((permission) => ['permissions', permission]),
+    );
+    response = await api.get(
+      `https://storage.googleapis.com/storage/v1/b/${id}/iam/testPermissions?${params}`,
+    );
+  } else if (kind === 'serviceAccount') {
+    response = await api.post(
+      `https://iam.googleapis.com/v1/projects/-/serviceAccounts/${id}:testIamPermissions`,
+      { permissions },
+    );
+  } else {
+    response = await api.post(
+      `https://cloudresourcemanager.googleapis.com/v3/${kind}s/${id}:testIamPermissions`,
+      { permissions },
+    );
+  }
+  const granted = TestPermissionsSchema.parse(response).permissions;
+  const denied = permissions.filter((permission) => !granted.includes(permission));
+  return {
+    resource: fullResourceName(resource),
+    granted,
+    denied,
+    allGranted: denied.length === 0,
+  };
+};
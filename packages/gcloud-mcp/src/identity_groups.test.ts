@@ -0,0 +1,199 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { checkGroupMembership, describeGroup, listGroupMembers } from './identity_groups.js';
+
+vi.mock('./gcloud.js');
+
+const member = (email: string, type = 'USER', roles = ['MEMBER']) => ({
+  name: `groups/x/memberships/${email}`,
+  preferredMemberKey: { id: email },
+  roles: roles.map((name) => ({ name })),
+  type,
+});
+
+// eng contains platform, which contains sre, which contains eng again.
+const MEMBERSHIPS: Record<string, unknown[]> = {
+  'eng@example.com': [
+    member('alice@example.com', 'USER', ['OWNER', 'MEMBER']),
+    member('platform@example.com', 'GROUP'),
+  ],
+  'platform@example.com': [member('bob@example.com'), member('sre@example.com', 'GROUP')],
+  'sre@example.com': [
+    member('carol@example.com'),
+    member('alice@example.com'),
+    member('eng@example.com', 'GROUP'),
+  ],
+};
+
+const fakeGcloud = (memberships: Record<string, unknown[]>): gcloud.GcloudExecutable => ({
+  lint: vi.fn(),
+  invoke: vi.fn(async (args: string[]) => {
+    const group = args.find((arg) => arg.startsWith('--group-email='))?.split('=')[1] ?? '';
+    const listed = memberships[group];
+    return listed
+      ? { code: 0, stdout: JSON.stringify(listed), stderr: '' }
+      : { code: 1, stdout: '', stderr: `PERMISSION_DENIED: ${group}` };
+  }),
+});
+
+describe('describeGroup', () => {
+  test('returns the group with its label keys', async () => {
+    const gcloud: gcloud.GcloudExecutable = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({
+        code: 0,
+        stdout: JSON.stringify({
+          name: 'groups/01abc',
+          groupKey: { id: 'eng@example.com' },
+          displayName: 'Engineering',
+          labels: { 'cloudidentity.googleapis.com/groups.security': '' },
+          createTime: '2024-01-01T00:00:00Z',
+        }),
+        stderr: '',
+      }),
+    };
+
+    expect(await describeGroup(gcloud, 'eng@example.com')).toEqual({
+      email: 'eng@example.com',
+      name: 'groups/01abc',
+      displayName: 'Engineering',
+      description: null,
+      labels: ['cloudidentity.googleapis.com/groups.security'],
+      createdAt: '2024-01-01T00:00:00Z',
+    });
+    expect(gcloud.invoke).toHaveBeenCalledWith([
+      'identity',
+      'groups',
+      'describe',
+      'eng@example.com',
+      '--format=json',
+    ]);
+  });
+});
+
+describe('listGroupMembers', () => {
+  test('lists only direct members by default', async () => {
+    const gcloud = fakeGcloud(MEMBERSHIPS);
+
+    const members = await listGroupMembers(gcloud, 'eng@example.com', 0);
+
+    expect(members.members.map((m) => m.email)).toEqual([
+      'alice@example.com',
+      'platform@example.com',
+    ]);
+    expect(members.truncated).toBe(true);
+    expect(gcloud.invoke).toHaveBeenCalledOnce();
+  });
+
+  test('expands nested groups once each, with the shortest path to every member', async () => {
+    const gcloud = fakeGcloud(MEMBERSHIPS);
+
+    const members = await listGroupMembers(gcloud, 'eng@example.com', 5);
+
+    expect(members.members).toEqual([
+      {
+        email: 'alice@example.com',
+        type: 'USER',
+        roles: ['OWNER', 'MEMBER'],
+        via: ['eng@example.com'],
+      },
+      { email: 'platform@example.com', type: 'GROUP', roles: ['MEMBER'], via: ['eng@example.com'] },
+      {
+        email: 'bob@example.com',
+        type: 'USER',
+        roles: ['MEMBER'],
+        via: ['eng@example.com', 'platform@example.com'],
+      },
+      {
+        email: 'sre@example.com',
+        type: 'GROUP',
+        roles: ['MEMBER'],
+        via: ['eng@example.com', 'platform@example.com'],
+      },
+      {
+        email: 'carol@example.com',
+        type: 'USER',
+        roles: ['MEMBER'],
+        via: ['eng@example.com', 'platform@example.com', 'sre@example.com'],
+      },
+      {
+        email: 'eng@example.com',
+        type: 'GROUP',
+        roles: ['MEMBER'],
+        via: ['eng@example.com', 'platform@example.com', 'sre@example.com'],
+      },
+    ]);
+    expect(members.expandedGroups).toEqual(['platform@example.com', 'sre@example.com']);
+    expect(members.truncated).toBe(false);
+    expect(gcloud.invoke).toHaveBeenCalledTimes(3);
+  });
+
+  test('reports nested groups that can not be listed', async () => {
+    const gcloud = fakeGcloud({ 'eng@example.com': [member('partners@other.com', 'GROUP')] });
+
+    const members = await listGroupMembers(gcloud, 'eng@example.com', 1);
+
+    expect(members.errors).toEqual([
+      { group: 'partners@other.com', error: expect.stringContaining('PERMISSION_DENIED') },
+    ]);
+  });
+
+  test('fails if the group itself can not be listed', async () => {
+    await expect(listGroupMembers(fakeGcloud({}), 'eng@example.com', 1)).rejects.toThrow(
+      'PERMISSION_DENIED',
+    );
+  });
+});
+
+describe('checkGroupMembership', () => {
+  test('finds members through nested groups', async () => {
+    const gcloud = fakeGcloud(MEMBERSHIPS);
+
+    const check = await checkGroupMembership(gcloud, 'eng@example.com', 'Carol@example.com', 5);
+
+    expect(check).toEqual({
+      group: 'eng@example.com',
+      member: 'Carol@example.com',
+      isMember: true,
+      path: ['eng@example.com', 'platform@example.com', 'sre@example.com'],
+      roles: ['MEMBER'],
+      conclusive: true,
+      errors: [],
+    });
+  });
+
+  test('stops at the first group the member is found in', async () => {
+    const gcloud = fakeGcloud(MEMBERSHIPS);
+
+    const check = await checkGroupMembership(gcloud, 'eng@example.com', 'alice@example.com', 5);
+
+    expect(check.path).toEqual(['eng@example.com']);
+    expect(gcloud.invoke).toHaveBeenCalledOnce();
+  });
+
+  test('is not conclusive when nested groups were left unchecked', async () => {
+    const gcloud = fakeGcloud(MEMBERSHIPS);
+
+    const shallow = await checkGroupMembership(gcloud, 'eng@example.com', 'dave@example.com', 1);
+    const deep = await checkGroupMembership(gcloud, 'eng@example.com', 'dave@example.com', 5);
+
+    expect(shallow).toMatchObject({ isMember: false, path: null, conclusive: false });
+    expect(deep).toMatchObject({ isMember: false, path: null, conclusive: true });
+  });
+});
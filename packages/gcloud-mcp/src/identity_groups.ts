@@ -0,0 +1,212 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson } from './gcloud_json.js';
+
+// Expanding nested groups takes one gcloud call per group, so stop somewhere.
+export const MAX_EXPANDED_GROUPS = 50;
+
+export interface GroupInfo {
+  email: string;
+  /** The resource name, e.g. `groups/01abc`. */
+  name: string;
+  displayName: string | null;
+  description: string | null;
+  labels: string[];
+  createdAt: string | null;
+}
+
+export interface GroupMember {
+  email: string;
+  type: string | null;
+  roles: string[];
+  /** The groups the membership comes through, from the requested group to the direct parent. */
+  via: string[];
+}
+
+export interface GroupMembers {
+  group: string;
+  members: GroupMember[];
+  /** The nested groups that were expanded. */
+  expandedGroups: string[];
+  /** Set when nested groups were left unexpanded because of the depth or group limits. */
+  truncated: boolean;
+  errors: Array<{ group: string; error: string }>;
+}
+
+export interface MembershipCheck {
+  group: string;
+  member: string;
+  isMember: boolean;
+  /** The groups from the requested group down to the one the member is directly in. */
+  path: string[] | null;
+  roles: string[];
+  /** False when there are groups left unchecked, so a negative result is not conclusive. */
+  conclusive: boolean;
+  errors: Array<{ group: string; error: string }>;
+}
+
+const GroupSchema = z.object({
+  name: z.string(),
+  groupKey: z.object({ id: z.string() }),
+  displayName: z.string().nullish(),
+  description: z.string().nullish(),
+  labels: z.record(z.string()).nullish(),
+  createTime: z.string().nullish(),
+});
+
+const MembershipSchema = z.object({
+  preferredMemberKey: z.object({ id: z.string() }),
+  roles: z.array(z.object({ name: z.string() })).nullish(),
+  type: z.string().nullish(),
+});
+
+/** Describes a Cloud Identity group by its email address. */
+export const describeGroup = async (
+  gcloud: GcloudExecutable,
+  email: string,
+): Promise<GroupInfo> => {
+  const group = GroupSchema.parse(
+    await invokeJson(gcloud, ['identity', 'groups', 'describe', email, '--format=json']),
+  );
+  return {
+    email: group.groupKey.id,
+    name: group.name,
+    displayName: group.displayName ?? null,
+    description: group.description ?? null,
+    // Labels mark the kind of group, e.g. a security group, and have empty values.
+    labels: Object.keys(group.labels ?? {}),
+    createdAt: group.createTime ?? null,
+  };
+};
+
+const listMemberships = async (gcloud: GcloudExecutable, group: string) =>
+  z
+    .array(MembershipSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'identity',
+        'groups',
+        'memberships',
+        'list',
+        `--group-email=${group}`,
+        '--format=json',
+      ]),
+    )
+    .map((membership) => ({
+      email: membership.preferredMemberKey.id,
+      type: membership.type ?? null,
+      roles: (membership.roles ?? []).map((role) => role.name),
+    }));
+
+interface Expansion {
+  /** The member, in lower case, to stop the walk at. */
+  until?: string;
+  maxDepth: number;
+}
+
+/**
+ * Walks a group and its nested groups breadth first, so every member is
+ * reported with the shortest path to it. Groups are expanded at most once,
+ * which also breaks cycles.
+ */
+const walkGroup = async (gcloud: GcloudExecutable, group: string, expansion: Expansion) => {
+  const members = new Map<string, GroupMember>();
+  const expanded = new Set<string>([group.toLowerCase()]);
+  const expandedGroups: string[] = [];
+  const errors: Array<{ group: string; error: string }> = [];
+  let truncated = false;
+  let queue = [{ group, via: [group] }];
+  for (let depth = 0; queue.length > 0; depth++) {
+    const next: typeof queue = [];
+    for (const current of queue) {
+      let memberships: Awaited<ReturnType<typeof listMemberships>>;
+      try {
+        memberships = await listMemberships(gcloud, current.group);
+      } catch (e: unknown) {
+        if (current.group === group) {
+          throw e;
+        }
+        errors.push({ group: current.group, error: e instanceof Error ? e.message : String(e) });
+        continue;
+      }
+      for (const membership of memberships) {
+        const key = membership.email.toLowerCase();
+        if (!members.has(key)) {
+          members.set(key, { ...membership, via: current.via });
+        }
+        if (key === expansion.until) {
+          return { members, expandedGroups, truncated: false, errors };
+        }
+        if (membership.type === 'GROUP' && !expanded.has(key)) {
+          if (depth + 1 > expansion.maxDepth || expandedGroups.length >= MAX_EXPANDED_GROUPS) {
+            truncated = true;
+            continue;
+          }
+          expanded.add(key);
+          expandedGroups.push(membership.email);
+          next.push({ group: membership.email, via: [...current.via, membership.email] });
+        }
+      }
+    }
+    queue = next;
+  }
+  return { members, expandedGroups, truncated, errors };
+};
+
+/**
+ * Lists the members of a group. With `maxDepth` above 0, the members of
+ * nested groups are listed too, each with the groups it is a member through.
+ */
+export const listGroupMembers = async (
+  gcloud: GcloudExecutable,
+  group: string,
+  maxDepth: number,
+): Promise<GroupMembers> => {
+  const walk = await walkGroup(gcloud, group, { maxDepth });
+  return {
+    group,
+    members: [...walk.members.values()],
+    expandedGroups: walk.expandedGroups,
+    truncated: walk.truncated,
+    errors: walk.errors,
+  };
+};
+
+/**
+ * Checks whether a user, service account or group is in a group, directly or
+ * through nested groups.
+ */
+export const checkGroupMembership = async (
+  gcloud: GcloudExecutable,
+  group: string,
+  member: string,
+  maxDepth: number,
+): Promise<MembershipCheck> => {
+  const walk = await walkGroup(gcloud, group, { maxDepth, until: member.toLowerCase() });
+  const found = walk.members.get(member.toLowerCase());
+  return {
+    group,
+    member,
+    isMember: !!found,
+    path: found?.via ?? null,
+    roles: found?.roles ?? [],
+    conclusive: !!found || (!walk.truncated && walk.errors.length === 0),
+    errors: walk.errors,
+  };
+};
@@ -0,0 +1,220 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { BillingCatalog, CATALOG_SERVICES, Sku } from './billing_catalog.js';
+import { IDLE_CHECKS, findIdleResources } from './idle_resources.js';
+
+vi.mock('./gcloud.js');
+
+const NOW = new Date('2025-06-01T00:00:00Z');
+
+const sku = (description: string, price: number, usageUnit: string, region: string): Sku => ({
+  skuId: description,
+  description,
+  category: { resourceFamily: 'Compute', usageType: 'OnDemand' },
+  serviceRegions: [region],
+  pricingInfo: [
+    {
+      pricingExpression: {
+        usageUnit,
+        tieredRates: [
+          {
+            startUsageAmount: 0,
+            unitPrice: { currencyCode: 'USD', units: '0', nanos: Math.round(price * 1e9) },
+          },
+        ],
+      },
+    },
+  ],
+});
+
+const catalog: BillingCatalog = {
+  skus: async (serviceId) =>
+    serviceId === CATALOG_SERVICES.compute
+      ? [
+          sku('Balanced PD Capacity in Americas', 0.1, 'GiBy.mo', 'us-central1'),
+          sku('Static Ip Charge in Americas', 0.01, 'h', 'us-central1'),
+          sku('Storage PD Snapshot in US', 0.05, 'GiBy.mo', 'us'),
+        ]
+      : [],
+};
+
+const IDLE_VM_RECOMMENDATION = {
+  name: 'projects/p/locations/us-central1-a/recommenders/r/recommendations/1',
+  description: 'Save cost by stopping idle VM "idle-vm".',
+  primaryImpact: {
+    costProjection: { cost: { currencyCode: 'USD', units: '-40' }, duration: '2592000s' },
+  },
+  content: {
+    operationGroups: [
+      {
+        operations: [
+          {
+            action: 'test',
+            resource: '//compute.googleapis.com/projects/p/zones/us-central1-a/instances/idle-vm',
+          },
+        ],
+      },
+    ],
+  },
+  stateInfo: { state: 'ACTIVE' },
+};
+
+const RESPONSES: Record<string, unknown> = {
+  'compute disks list': [
+    {
+      name: 'old-disk',
+      zone: 'https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a',
+      sizeGb: '100',
+      type: 'zones/us-central1-a/diskTypes/pd-balanced',
+      lastDetachTimestamp: '2025-01-01T00:00:00Z',
+    },
+  ],
+  'compute addresses list': [
+    {
+      name: 'unused-ip',
+      address: '203.0.113.1',
+      addressType: 'EXTERNAL',
+      region: 'https://www.googleapis.com/compute/v1/projects/p/regions/us-central1',
+    },
+    { name: 'internal-ip', address: '10.0.0.2', addressType: 'INTERNAL', region: 'us-central1' },
+  ],
+  'compute snapshots list': [
+    {
+      name: 'old-snapshot',
+      creationTimestamp: '2024-01-01T00:00:00Z',
+      storageBytes: String(20 * 1024 ** 3),
+      storageLocations: ['us'],
+    },
+  ],
+  'compute instances list': [{ zone: 'us-central1-a' }, { zone: 'us-central1-a' }],
+  'sql instances list': [],
+  'recommender recommendations list': [IDLE_VM_RECOMMENDATION],
+  'storage buckets list': [
+    { name: 'empty-bucket', location: 'US' },
+    { name: 'full-bucket', location: 'US' },
+  ],
+};
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+describe('findIdleResources', () => {
+  beforeEach(() => {
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(async (args: string[]) => {
+        if (args[0] === 'storage' && args[1] === 'ls') {
+          return {
+            code: 0,
+            stdout: args[2] === 'gs://full-bucket' ? 'gs://full-bucket/object\n' : '',
+            stderr: '',
+          };
+        }
+        const response = RESPONSES[args.slice(0, 3).join(' ')];
+        return { code: 0, stdout: JSON.stringify(response), stderr: '' };
+      }),
+    };
+  });
+
+  test('reports idle resources with their monthly waste', async () => {
+    const report = await findIdleResources(mockedGcloud, catalog, {
+      projects: ['p'],
+      checks: IDLE_CHECKS,
+      snapshotAgeDays: 90,
+      now: NOW,
+    });
+
+    expect(report.errors).toEqual([]);
+    expect(
+      report.findings.map(({ check, resource, monthlyWaste }) => [check, resource, monthlyWaste]),
+    ).toEqual([
+      ['idle-vms', 'idle-vm', 40],
+      ['unattached-disks', 'old-disk', 10],
+      ['unused-addresses', 'unused-ip', 7.3],
+      ['stale-snapshots', 'old-snapshot', 1],
+      ['empty-buckets', 'empty-bucket', 0],
+    ]);
+    expect(report.totalMonthlyWaste).toBe(58.3);
+    expect(report.findings[1]?.cleanupCommand).toBe(
+      'gcloud compute disks delete old-disk --zone=us-central1-a --project=p',
+    );
+    expect(report.findings[2]?.cleanupCommand).toBe(
+      'gcloud compute addresses delete unused-ip --region=us-central1 --project=p',
+    );
+    expect(report.findings[0]?.consoleUrl).toBe(
+      'https://console.cloud.google.com/compute/instancesDetail/zones/us-central1-a/instances/idle-vm?project=p',
+    );
+  });
+
+  test('looks up recommendations once per zone', async () => {
+    await findIdleResources(mockedGcloud, catalog, {
+      projects: ['p'],
+      checks: ['idle-vms'],
+      snapshotAgeDays: 90,
+      now: NOW,
+    });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledTimes(2);
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'recommender',
+      'recommendations',
+      'list',
+      '--project=p',
+      '--location=us-central1-a',
+      '--recommender=google.compute.instance.IdleResourceRecommender',
+      '--format=json',
+    ]);
+  });
+
+  test('filters snapshots by age', async () => {
+    await findIdleResources(mockedGcloud, catalog, {
+      projects: ['p'],
+      checks: ['stale-snapshots'],
+      snapshotAgeDays: 30,
+      now: NOW,
+    });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(
+      expect.arrayContaining(["--filter=creationTimestamp<'2025-05-02T00:00:00.000Z'"]),
+    );
+  });
+
+  test('reports failing checks without hiding other findings', async () => {
+    vi.mocked(mockedGcloud.invoke).mockImplementation(async (args: string[]) =>
+      args[0] === 'sql'
+        ? { code: 1, stdout: '', stderr: 'API [sqladmin.googleapis.com] not enabled' }
+        : { code: 0, stdout: JSON.stringify(RESPONSES['compute disks list']), stderr: '' },
+    );
+
+    const report = await findIdleResources(mockedGcloud, catalog, {
+      projects: ['p'],
+      checks: ['unattached-disks', 'idle-sql-instances'],
+      snapshotAgeDays: 90,
+      now: NOW,
+    });
+
+    expect(report.findings).toHaveLength(1);
+    expect(report.errors).toEqual([
+      {
+        project: 'p',
+        check: 'idle-sql-instances',
+        message: expect.stringContaining('not enabled'),
+      },
+    ]);
+  });
+});
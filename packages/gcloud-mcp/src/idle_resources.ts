@@ -0,0 +1,494 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson, lastSegment } from './gcloud_json.js';
+import { BillingCatalog, CATALOG_SERVICES, unitPriceOf } from './billing_catalog.js';
+import { DISK_SKU_DESCRIPTIONS, HOURS_PER_MONTH, estimateCosts } from './cost_estimate.js';
+import {
+  Recommendation,
+  listRecommendations,
+  monthlySavings,
+  operationsOf,
+} from './recommender.js';
+import { consoleUrlOf } from './console_links.js';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+const IDLE_VM_RECOMMENDER = 'google.compute.instance.IdleResourceRecommender';
+const IDLE_SQL_RECOMMENDER = 'google.cloudsql.instance.IdleRecommender';
+
+// Every bucket takes a listing to check, so large projects are sampled.
+export const MAX_BUCKETS_CHECKED = 50;
+
+export const IDLE_CHECKS = [
+  'unattached-disks',
+  'unused-addresses',
+  'idle-vms',
+  'idle-sql-instances',
+  'stale-snapshots',
+  'empty-buckets',
+] as const;
+
+export type IdleCheck = (typeof IDLE_CHECKS)[number];
+
+/** The gcloud commands each check runs. */
+export const IDLE_CHECK_COMMANDS: Record<IdleCheck, string[]> = {
+  'unattached-disks': ['compute disks list'],
+  'unused-addresses': ['compute addresses list'],
+  'idle-vms': ['compute instances list', 'recommender recommendations list'],
+  'idle-sql-instances': ['sql instances list', 'recommender recommendations list'],
+  'stale-snapshots': ['compute snapshots list'],
+  'empty-buckets': ['storage buckets list', 'storage ls'],
+};
+
+export interface IdleFinding {
+  project: string;
+  check: IdleCheck;
+  resource: string;
+  location: string;
+  details: Record<string, unknown>;
+  /** The estimated monthly cost of keeping the resource, or null if it is unknown. */
+  monthlyWaste: number | null;
+  /** The command that removes or stops the resource. It is not run by this tool. */
+  cleanupCommand: string;
+  consoleUrl: string | null;
+}
+
+export interface IdleResourceReport {
+  projects: string[];
+  currency: string;
+  totalMonthlyWaste: number;
+  /** Findings with the largest waste first. */
+  findings: IdleFinding[];
+  errors: Array<{ project: string; check: IdleCheck; message: string }>;
+  notes: string[];
+}
+
+const DiskSchema = z.object({
+  name: z.string(),
+  zone: z.string(),
+  sizeGb: z.string(),
+  type: z.string(),
+  lastDetachTimestamp: z.string().nullish(),
+});
+
+const AddressSchema = z.object({
+  name: z.string(),
+  address: z.string().nullish(),
+  addressType: z.string().nullish(),
+  region: z.string().nullish(),
+});
+
+const SnapshotSchema = z.object({
+  name: z.string(),
+  creationTimestamp: z.string(),
+  storageBytes: z.string().default('0'),
+  storageLocations: z.array(z.string()).default([]),
+  sourceDisk: z.string().nullish(),
+});
+
+const cents = (value: number) => Math.round(value * 100) / 100;
+
+const regionOfZone = (zone: string) => zone.replace(/-[a-z]$/, '');
+
+/**
+ * Returns the monthly list price of a compute SKU for a quantity, preferring
+ * the SKU for the location.
+ */
+const computeMonthlyPrice = async (
+  catalog: BillingCatalog,
+  descriptionPrefix: string,
+  location: string,
+  quantity: number,
+): Promise<number | null> => {
+  const skus = (await catalog.skus(CATALOG_SERVICES.compute)).filter((sku) =>
+    sku.description.startsWith(descriptionPrefix),
+  );
+  const sku = skus.find((s) => s.serviceRegions.includes(location)) ?? skus[0];
+  const unitPrice = sku && unitPriceOf(sku);
+  if (!unitPrice) {
+    return null;
+  }
+  const hours = unitPrice.unit.endsWith('h') ? HOURS_PER_MONTH : 1;
+  return cents(unitPrice.price * quantity * hours);
+};
+
+const findUnattachedDisks = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  project: string,
+): Promise<IdleFinding[]> => {
+  const disks = z
+    .array(DiskSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'compute',
+        'disks',
+        'list',
+        `--project=${project}`,
+        '--filter=-users:*',
+        '--format=json(name,zone,sizeGb,type,lastDetachTimestamp)',
+      ]),
+    );
+  return Promise.all(
+    disks.map(async (disk): Promise<IdleFinding> => {
+      const zone = lastSegment(disk.zone);
+      const diskType = lastSegment(disk.type);
+      const sizeGb = Number(disk.sizeGb);
+      let monthlyWaste: number | null = null;
+      if (diskType in DISK_SKU_DESCRIPTIONS && sizeGb > 0) {
+        const estimate = await estimateCosts(gcloud, catalog, [
+          {
+            type: 'disk',
+            region: regionOfZone(zone),
+            diskType: diskType as keyof typeof DISK_SKU_DESCRIPTIONS,
+            sizeGb,
+            count: 1,
+          },
+        ]);
+        monthlyWaste = estimate.resources[0]?.lineItems.length ? estimate.totalMonthly : null;
+      }
+      return {
+        project,
+        check: 'unattached-disks',
+        resource: disk.name,
+        location: zone,
+        details: { diskType, sizeGb, lastDetachTimestamp: disk.lastDetachTimestamp ?? null },
+        monthlyWaste,
+        cleanupCommand: `gcloud compute disks delete ${disk.name} --zone=${zone} --project=${project}`,
+        consoleUrl: consoleUrlOf({
+          project,
+          assetType: 'compute.googleapis.com/Disk',
+          name: disk.name,
+          location: zone,
+        }),
+      };
+    }),
+  );
+};
+
+const findUnusedAddresses = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  project: string,
+): Promise<IdleFinding[]> => {
+  const addresses = z
+    .array(AddressSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'compute',
+        'addresses',
+        'list',
+        `--project=${project}`,
+        '--filter=status=RESERVED',
+        '--format=json(name,address,addressType,region)',
+      ]),
+    );
+  // Reserved internal addresses are free.
+  const external = addresses.filter(
+    ({ addressType }) => (addressType ?? 'EXTERNAL') === 'EXTERNAL',
+  );
+  return Promise.all(
+    external.map(async (address): Promise<IdleFinding> => {
+      const region = address.region ? lastSegment(address.region) : 'global';
+      const scope = address.region ? `--region=${region}` : '--global';
+      return {
+        project,
+        check: 'unused-addresses',
+        resource: address.name,
+        location: region,
+        details: { address: address.address ?? null },
+        monthlyWaste: await computeMonthlyPrice(catalog, 'Static Ip Charge', region, 1),
+        cleanupCommand: `gcloud compute addresses delete ${address.name} ${scope} --project=${project}`,
+        consoleUrl: consoleUrlOf({
+          project,
+          assetType: 'compute.googleapis.com/Address',
+          name: address.name,
+          location: region,
+        }),
+      };
+    }),
+  );
+};
+
+const findStaleSnapshots = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  project: string,
+  olderThan: Date,
+): Promise<IdleFinding[]> => {
+  const snapshots = z
+    .array(SnapshotSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'compute',
+        'snapshots',
+        'list',
+        `--project=${project}`,
+        `--filter=creationTimestamp<'${olderThan.toISOString()}'`,
+        '--format=json(name,creationTimestamp,storageBytes,storageLocations,sourceDisk)',
+      ]),
+    );
+  return Promise.all(
+    snapshots.map(async (snapshot): Promise<IdleFinding> => {
+      const location = snapshot.storageLocations[0] ?? 'global';
+      const storageGb = cents(Number(snapshot.storageBytes) / 1024 ** 3);
+      return {
+        project,
+        check: 'stale-snapshots',
+        resource: snapshot.name,
+        location,
+        details: {
+          creationTimestamp: snapshot.creationTimestamp,
+          storageGb,
+          sourceDisk: snapshot.sourceDisk ? lastSegment(snapshot.sourceDisk) : null,
+        },
+        monthlyWaste: await computeMonthlyPrice(
+          catalog,
+          'Storage PD Snapshot',
+          location,
+          storageGb,
+        ),
+        cleanupCommand: `gcloud compute snapshots delete ${snapshot.name} --project=${project}`,
+        consoleUrl: consoleUrlOf({
+          project,
+          assetType: 'compute.googleapis.com/Snapshot',
+          name: snapshot.name,
+          location,
+        }),
+      };
+    }),
+  );
+};
+
+/** Returns the distinct locations of the resources a list command returns. */
+const locationsOf = async (gcloud: GcloudExecutable, args: string[], field: string) => {
+  const resources = z
+    .array(z.record(z.unknown()))
+    .parse(await invokeJson(gcloud, [...args, `--format=json(${field})`]));
+  return [
+    ...new Set(
+      resources
+        .map((resource) => resource[field])
+        .filter((value): value is string => typeof value === 'string')
+        .map(lastSegment),
+    ),
+  ];
+};
+
+const recommendedFindings = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  locations: string[],
+  recommender: string,
+  toFinding: (location: string, recommendation: Recommendation) => IdleFinding,
+): Promise<IdleFinding[]> =>
+  (
+    await Promise.all(
+      locations.map(async (location) =>
+        (await listRecommendations(gcloud, project, location, recommender)).map((recommendation) =>
+          toFinding(location, recommendation),
+        ),
+      ),
+    )
+  ).flat();
+
+// Recommendations name their target resource by its full resource name, e.g.
+// `//compute.googleapis.com/projects/p/zones/z/instances/vm`.
+const targetOf = (recommendation: Recommendation): string | null => {
+  const resource = operationsOf(recommendation).find((operation) => operation.resource)?.resource;
+  return resource ? lastSegment(resource) : null;
+};
+
+const findIdleVms = async (gcloud: GcloudExecutable, project: string) => {
+  const zones = await locationsOf(
+    gcloud,
+    ['compute', 'instances', 'list', `--project=${project}`],
+    'zone',
+  );
+  return recommendedFindings(
+    gcloud,
+    project,
+    zones,
+    IDLE_VM_RECOMMENDER,
+    (zone, recommendation) => {
+      const name = targetOf(recommendation) ?? recommendation.name;
+      return {
+        project,
+        check: 'idle-vms',
+        resource: name,
+        location: zone,
+        details: {
+          recommendation: recommendation.name,
+          description: recommendation.description ?? null,
+        },
+        monthlyWaste: monthlySavings(recommendation)?.amount ?? null,
+        cleanupCommand: `gcloud compute instances stop ${name} --zone=${zone} --project=${project}`,
+        consoleUrl: consoleUrlOf({
+          project,
+          assetType: 'compute.googleapis.com/Instance',
+          name,
+          location: zone,
+        }),
+      };
+    },
+  );
+};
+
+const findIdleSqlInstances = async (gcloud: GcloudExecutable, project: string) => {
+  const regions = await locationsOf(
+    gcloud,
+    ['sql', 'instances', 'list', `--project=${project}`],
+    'region',
+  );
+  return recommendedFindings(
+    gcloud,
+    project,
+    regions,
+    IDLE_SQL_RECOMMENDER,
+    (region, recommendation) => {
+      const name = targetOf(recommendation) ?? recommendation.name;
+      return {
+        project,
+        check: 'idle-sql-instances',
+        resource: name,
+        location: region,
+        details: {
+          recommendation: recommendation.name,
+          description: recommendation.description ?? null,
+        },
+        monthlyWaste: monthlySavings(recommendation)?.amount ?? null,
+        cleanupCommand: `gcloud sql instances patch ${name} --activation-policy=NEVER --project=${project}`,
+        consoleUrl: consoleUrlOf({
+          project,
+          assetType: 'sqladmin.googleapis.com/Instance',
+          name,
+          location: region,
+        }),
+      };
+    },
+  );
+};
+
+const findEmptyBuckets = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  notes: string[],
+): Promise<IdleFinding[]> => {
+  const buckets = z
+    .array(z.object({ name: z.string(), location: z.string().nullish() }))
+    .parse(
+      await invokeJson(gcloud, [
+        'storage',
+        'buckets',
+        'list',
+        `--project=${project}`,
+        '--format=json(name,location)',
+      ]),
+    );
+  if (buckets.length > MAX_BUCKETS_CHECKED) {
+    notes.push(
+      `Only the first ${MAX_BUCKETS_CHECKED} of ${buckets.length} buckets in ${project} were checked for objects.`,
+    );
+  }
+  const findings: IdleFinding[] = [];
+  for (const bucket of buckets.slice(0, MAX_BUCKETS_CHECKED)) {
+    const { code, stdout, stderr } = await gcloud.invoke(['storage', 'ls', `gs://${bucket.name}`]);
+    if (code !== 0) {
+      throw new Error(`gcloud storage ls gs://${bucket.name} failed: ${stderr}`);
+    }
+    if (stdout.trim() === '') {
+      findings.push({
+        project,
+        check: 'empty-buckets',
+        resource: bucket.name,
+        location: bucket.location?.toLowerCase() ?? 'unknown',
+        details: {},
+        // Empty buckets are free, but clutter the project and its IAM policy.
+        monthlyWaste: 0,
+        cleanupCommand: `gcloud storage buckets delete gs://${bucket.name} --project=${project}`,
+        consoleUrl: consoleUrlOf({
+          project,
+          assetType: 'storage.googleapis.com/Bucket',
+          name: bucket.name,
+          location: '',
+        }),
+      });
+    }
+  }
+  return findings;
+};
+
+/**
+ * Finds resources that cost money without being used. Each check runs on its
+ * own, so a failure, e.g. because an API is not enabled, is reported without
+ * hiding the findings of the other checks.
+ */
+export const findIdleResources = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  options: {
+    projects: string[];
+    checks: readonly IdleCheck[];
+    snapshotAgeDays: number;
+    now?: Date;
+  },
+): Promise<IdleResourceReport> => {
+  const { projects, checks, snapshotAgeDays, now = new Date() } = options;
+  const olderThan = new Date(now.getTime() - snapshotAgeDays * DAY_MS);
+  const notes: string[] = [];
+  const checkers: Record<IdleCheck, (project: string) => Promise<IdleFinding[]>> = {
+    'unattached-disks': (project) => findUnattachedDisks(gcloud, catalog, project),
+    'unused-addresses': (project) => findUnusedAddresses(gcloud, catalog, project),
+    'idle-vms': (project) => findIdleVms(gcloud, project),
+    'idle-sql-instances': (project) => findIdleSqlInstances(gcloud, project),
+    'stale-snapshots': (project) => findStaleSnapshots(gcloud, catalog, project, olderThan),
+    'empty-buckets': (project) => findEmptyBuckets(gcloud, project, notes),
+  };
+
+  const errors: IdleResourceReport['errors'] = [];
+  const findings = (
+    await Promise.all(
+      projects.flatMap((project) =>
+        checks.map(async (check) => {
+          try {
+            return await checkers[check](project);
+          } catch (e: unknown) {
+            errors.push({ project, check, message: e instanceof Error ? e.message : String(e) });
+            return [];
+          }
+        }),
+      ),
+    )
+  ).flat();
+  findings.sort((a, b) => (b.monthlyWaste ?? 0) - (a.monthlyWaste ?? 0));
+  if (checks.includes('idle-vms') || checks.includes('idle-sql-instances')) {
+    notes.push(
+      'Idle VMs and Cloud SQL instances come from Recommender, which needs several days of monitoring metrics before it reports a resource as idle.',
+    );
+  }
+  return {
+    projects,
+    currency: 'USD',
+    totalMonthlyWaste: cents(
+      findings.reduce((sum, finding) => sum + (finding.monthlyWaste ?? 0), 0),
+    ),
+    findings,
+    errors,
+    notes,
+  };
+};
@@ -0,0 +1,166 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import { z } from 'zod';
+import * as gcloud from './gcloud.js';
+import {
+  createImpersonationPolicy,
+  impersonatedAccountOf,
+  withImpersonationParameter,
+  withImpersonationPolicy,
+} from './impersonation.js';
+
+vi.mock('./gcloud.js');
+
+const READER = 'mcp-reader@ops.iam.gserviceaccount.com';
+const DEPLOYER = 'mcp-deployer@ops.iam.gserviceaccount.com';
+const OWNER = 'owner@ops.iam.gserviceaccount.com';
+
+describe('impersonatedAccountOf', () => {
+  test('reads both forms of the flag', () => {
+    expect(impersonatedAccountOf(['run', 'deploy', `--impersonate-service-account=${OWNER}`])).toBe(
+      OWNER,
+    );
+    expect(impersonatedAccountOf(['run', 'deploy', '--impersonate-service-account', OWNER])).toBe(
+      OWNER,
+    );
+    expect(impersonatedAccountOf(['run', 'deploy'])).toBeUndefined();
+  });
+});
+
+describe('createImpersonationPolicy', () => {
+  test('permits the server account and the allowed accounts', () => {
+    const policy = createImpersonationPolicy({
+      serviceAccount: READER,
+      allowedServiceAccounts: [DEPLOYER],
+    });
+
+    expect(policy.check(READER)).toBeUndefined();
+    expect(policy.check(DEPLOYER)).toBeUndefined();
+    expect(policy.check(OWNER)).toMatchObject({
+      error: 'IMPERSONATION_DENIED',
+      policy: 'impersonation.allowedServiceAccounts',
+      serviceAccount: OWNER,
+      allowedServiceAccounts: [DEPLOYER],
+    });
+  });
+
+  test('impersonates the account of the call in progress', async () => {
+    const policy = createImpersonationPolicy({ serviceAccount: READER });
+
+    const elevated = await policy.withServiceAccount(DEPLOYER, async () => policy.env());
+
+    expect(elevated).toEqual({ CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT: DEPLOYER });
+    expect(policy.env()).toEqual({ CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT: READER });
+    expect(createImpersonationPolicy({}).env()).toEqual({});
+  });
+});
+
+describe('withImpersonationPolicy', () => {
+  let mockedGcloud: gcloud.GcloudExecutable;
+
+  beforeEach(() => {
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(),
+    };
+  });
+
+  test('runs commands as the service account of the policy', async () => {
+    const policy = createImpersonationPolicy({ serviceAccount: READER });
+    const wrapped = withImpersonationPolicy(mockedGcloud, policy);
+
+    await wrapped.invoke(['compute', 'instances', 'list'], { CLOUDSDK_CORE_PROJECT: 'p' });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(['compute', 'instances', 'list'], {
+      CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT: READER,
+      CLOUDSDK_CORE_PROJECT: 'p',
+    });
+  });
+
+  test('refuses commands that impersonate an account that is not allowed', async () => {
+    const policy = createImpersonationPolicy({ serviceAccount: READER });
+    const wrapped = withImpersonationPolicy(mockedGcloud, policy);
+
+    const result = await wrapped.invoke([
+      'projects',
+      'list',
+      `--impersonate-service-account=${OWNER}`,
+    ]);
+
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+    expect(result.code).toBe(1);
+    expect(JSON.parse(result.stderr)).toMatchObject({ error: 'IMPERSONATION_DENIED' });
+  });
+
+  test.each([
+    [['config', 'set', 'auth/impersonate_service_account', OWNER]],
+    [['config', 'unset', 'auth/impersonate_service_account']],
+    [['config', 'set', 'auth/account', 'owner@example.com']],
+    [['config', 'set', 'account', 'owner@example.com', '--quiet']],
+    [['--project', 'p', 'config', 'set', 'auth/impersonate_service_account', OWNER]],
+  ])('refuses %j, which would bypass the allowlist', async (args) => {
+    const policy = createImpersonationPolicy({
+      serviceAccount: READER,
+      allowedServiceAccounts: [DEPLOYER],
+    });
+    const wrapped = withImpersonationPolicy(mockedGcloud, policy);
+
+    const result = await wrapped.invoke(args);
+
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+    expect(result.code).toBe(1);
+    expect(result.stderr).toContain('can not be changed');
+  });
+});
+
+describe('withImpersonationParameter', () => {
+  const register = (allowedServiceAccounts: string[]) => {
+    const policy = createImpersonationPolicy({ serviceAccount: READER, allowedServiceAccounts });
+    const registerTool = vi.fn();
+    const mockServer = { registerTool } as unknown as McpServer;
+    withImpersonationParameter(mockServer, policy);
+    const callback = vi.fn(async () => policy.env());
+    mockServer.registerTool('tool', { inputSchema: { name: z.string() } }, callback);
+    const [, config, handler] = registerTool.mock.calls[0]!;
+    return { config, handler, callback };
+  };
+
+  test('runs a call as an allowed service account', async () => {
+    const { config, handler, callback } = register([DEPLOYER]);
+
+    expect(Object.keys(config.inputSchema)).toEqual(['name', 'impersonateServiceAccount']);
+    const env = await handler({ name: 'svc', impersonateServiceAccount: DEPLOYER }, {});
+
+    expect(callback).toHaveBeenCalledWith({ name: 'svc' }, {});
+    expect(env).toEqual({ CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT: DEPLOYER });
+  });
+
+  test('rejects other service accounts with the policy that denied them', async () => {
+    const { handler, callback } = register([DEPLOYER]);
+
+    const result = await handler({ name: 'svc', impersonateServiceAccount: OWNER }, {});
+
+    expect(callback).not.toHaveBeenCalled();
+    expect(result.isError).toBe(true);
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      error: 'IMPERSONATION_DENIED',
+      policy: 'impersonation.allowedServiceAccounts',
+    });
+  });
+});
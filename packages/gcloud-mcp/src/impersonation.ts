@@ -0,0 +1,194 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { AsyncLocalStorage } from 'async_hooks';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { ExecutionOptions } from './gcloud_executor.js';
+import { IMPERSONATION_ENV_VAR, credentialPropertyOf } from './remote_server.js';
+import { errorTextResult } from './tools/tool_result.js';
+
+export interface ImpersonationConfig {
+  /** The service account every command runs as, unless a call elevates to another. */
+  serviceAccount?: string;
+  /** The service accounts a single tool call, or a command's own flag, may impersonate. */
+  allowedServiceAccounts?: string[];
+}
+
+export const IMPERSONATION_FLAG = '--impersonate-service-account';
+
+export const validateImpersonation = (config: ImpersonationConfig): string | undefined => {
+  for (const account of [config.serviceAccount, ...(config.allowedServiceAccounts ?? [])]) {
+    if (account !== undefined && !/^[^@\s]+@[^@\s]+\.iam\.gserviceaccount\.com$/.test(account)) {
+      return `Invalid service account "${account}" in "impersonation".`;
+    }
+  }
+  return undefined;
+};
+
+/** Returns the service account a command impersonates with its own flag, if any. */
+export const impersonatedAccountOf = (args: string[]): string | undefined => {
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]!;
+    if (arg === IMPERSONATION_FLAG) {
+      return args[i + 1];
+    }
+    if (arg.startsWith(`${IMPERSONATION_FLAG}=`)) {
+      return arg.slice(IMPERSONATION_FLAG.length + 1);
+    }
+  }
+  return undefined;
+};
+
+export interface ImpersonationRejection {
+  error: 'IMPERSONATION_DENIED';
+  /** The configuration key that denied the service account. */
+  policy: 'impersonation.allowedServiceAccounts';
+  serviceAccount: string;
+  allowedServiceAccounts: string[];
+  message: string;
+}
+
+export type ImpersonationPolicy = ReturnType<typeof createImpersonationPolicy>;
+
+/**
+ * Creates the policy of which service accounts commands run as. Commands run
+ * as the configured service account, or the caller's credentials if there is
+ * none, and a tool call may elevate to one of the allowed service accounts.
+ */
+export const createImpersonationPolicy = (config: ImpersonationConfig) => {
+  const allowed = config.allowedServiceAccounts ?? [];
+  // The service account of the tool call in progress, if it elevates.
+  const callAccount = new AsyncLocalStorage<string>();
+
+  return {
+    allowedServiceAccounts: () => [...allowed],
+    check: (serviceAccount: string): ImpersonationRejection | undefined => {
+      if (serviceAccount === config.serviceAccount || allowed.includes(serviceAccount)) {
+        return undefined;
+      }
+      return {
+        error: 'IMPERSONATION_DENIED',
+        policy: 'impersonation.allowedServiceAccounts',
+        serviceAccount,
+        allowedServiceAccounts: [...allowed],
+        message:
+          allowed.length > 0
+            ? `The server may not impersonate ${serviceAccount}. Use one of the allowed service accounts, or run without impersonation.`
+            : `The server does not permit impersonating service accounts. Run the command without ${IMPERSONATION_FLAG}.`,
+      };
+    },
+    /** Runs a tool call as a service account. */
+    withServiceAccount: <T>(serviceAccount: string, call: () => T): T =>
+      callAccount.run(serviceAccount, call),
+    /** Returns the environment that makes gcloud impersonate the current service account. */
+    env: (): NodeJS.ProcessEnv => {
+      const serviceAccount = callAccount.getStore() ?? config.serviceAccount;
+      return serviceAccount ? { [IMPERSONATION_ENV_VAR]: serviceAccount } : {};
+    },
+  };
+};
+
+/** Formats a rejection as the text of a tool error. */
+export const rejectionMessage = (rejection: ImpersonationRejection) =>
+  JSON.stringify(rejection, null, 2);
+
+/**
+ * Wraps gcloud so every invocation runs as the service account of the policy.
+ * Commands that impersonate an account with their own flag and every other
+ * account are refused without being run, as are commands setting a property
+ * that selects the credentials, e.g. auth/impersonate_service_account: it
+ * would apply to every later command without being checked.
+ */
+export const withImpersonationPolicy = (
+  gcloud: GcloudExecutable,
+  policy: ImpersonationPolicy,
+): GcloudExecutable => ({
+  ...gcloud,
+  invoke: async (args: string[], env?: NodeJS.ProcessEnv, options?: ExecutionOptions) => {
+    const flagged = impersonatedAccountOf(args);
+    const rejection = flagged !== undefined ? policy.check(flagged) : undefined;
+    if (rejection) {
+      return { code: 1, stdout: '', stderr: rejectionMessage(rejection) };
+    }
+    const property = credentialPropertyOf(args);
+    if (property) {
+      return {
+        code: 1,
+        stdout: '',
+        stderr: `The server impersonates service accounts, so ${property} can not be changed. Pass the impersonateServiceAccount parameter of a tool call instead.`,
+      };
+    }
+    const impersonatedEnv = { ...policy.env(), ...env };
+    return options
+      ? gcloud.invoke(args, impersonatedEnv, options)
+      : gcloud.invoke(args, impersonatedEnv);
+  },
+});
+
+type ToolCallback = (...args: unknown[]) => unknown;
+
+export const IMPERSONATION_PARAMETER = z
+  .string()
+  .optional()
+  .describe(
+    'A service account to run the commands of this call as, if the server allows impersonating it.',
+  );
+
+/**
+ * Adds an optional `impersonateServiceAccount` parameter to every tool
+ * registered after this call, so that a single call can run with more
+ * privileges than the server's own identity.
+ */
+export const withImpersonationParameter = (
+  server: McpServer,
+  policy: ImpersonationPolicy,
+): McpServer => {
+  const registerTool = server.registerTool.bind(server) as unknown as (
+    name: string,
+    config: { inputSchema?: z.ZodRawShape },
+    callback: ToolCallback,
+  ) => unknown;
+  const elevated = (
+    name: string,
+    config: { inputSchema?: z.ZodRawShape },
+    callback: ToolCallback,
+  ) => {
+    if (!config.inputSchema || 'impersonateServiceAccount' in config.inputSchema) {
+      return registerTool(name, config, callback);
+    }
+    const inputSchema = {
+      ...config.inputSchema,
+      impersonateServiceAccount: IMPERSONATION_PARAMETER,
+    };
+    return registerTool(name, { ...config, inputSchema }, async (input, ...rest) => {
+      const { impersonateServiceAccount: serviceAccount, ...args } = input as {
+        impersonateServiceAccount?: string;
+      };
+      if (!serviceAccount) {
+        return callback(args, ...rest);
+      }
+      const rejection = policy.check(serviceAccount);
+      if (rejection) {
+        return errorTextResult(rejectionMessage(rejection));
+      }
+      return policy.withServiceAccount(serviceAccount, () => callback(args, ...rest));
+    });
+  };
+  server.registerTool = elevated as unknown as typeof server.registerTool;
+  return server;
+};
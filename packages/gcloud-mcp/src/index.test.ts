@@ -36,12 +36,22 @@ vi.mock('./tools/run_gcloud_command.js', () => ({
   createRunGcloudCommand: vi.fn(() => ({
     register: registerToolSpy,
   })),
+  createGcloudCommandRunner: vi.fn(),
 }));
 vi.mock('./gcloud.js');
 vi.mock('./gcloud_executor.js');
 vi.mock('fs');
 vi.mock('path');
 vi.mock('./commands/init.js');
+vi.mock('./watches.js', () => ({
+  createWatchResources: vi.fn(() => ({ register: vi.fn() })),
+}));
+vi.mock('./tools/watch_resource.js', () => ({
+  createWatchResource: vi.fn(() => ({ register: vi.fn() })),
+}));
+vi.mock('./tools/scheduled_jobs.js', () => ({
+  createScheduledJobTools: vi.fn(() => ({ register: vi.fn() })),
+}));
 
 beforeEach(() => {
   vi.clearAllMocks();
@@ -95,7 +105,7 @@ test('should start the McpServer if gcloud is available', async () => {
       name: 'gcloud-mcp-server',
       version: '9.4.1998',
     },
-    { capabilities: { tools: {} } },
+    { capabilities: { tools: {}, logging: {} } },
   );
   expect(registerToolSpy).toHaveBeenCalledWith(vi.mocked(McpServer).mock.instances[0]);
   const serverInstance = vi.mocked(McpServer).mock.instances[0];
@@ -123,6 +133,39 @@ test('should exit if load deny and allow from config file', async () => {
   expect(process.exit).toHaveBeenCalledWith(1);
 });
 
+test('should exit if config file has an invalid rate limit', async () => {
+  process.argv = ['node', 'index.js', '--config', 'rate-limits.json'];
+  const consoleErrorSpy = vi.spyOn(console, 'error').mockImplementation(() => {});
+  vi.stubGlobal('process', { ...process, exit: vi.fn(), on: vi.fn() });
+  const config = {
+    rateLimits: { compute: { qps: 0, burst: 5 } },
+  };
+  vi.spyOn(fs, 'readFileSync').mockReturnValue(JSON.stringify(config));
+  vi.spyOn(path, 'isAbsolute').mockReturnValue(true);
+
+  await import('./index.js');
+
+  expect(consoleErrorSpy).toHaveBeenCalledWith(
+    expect.stringContaining('Invalid rate limit for "compute"'),
+  );
+  expect(process.exit).toHaveBeenCalledWith(1);
+});
+
+test('should exit if the default project is not allowed', async () => {
+  process.argv = ['node', 'index.js', '--config', 'projects.json', '--project', 'prod'];
+  const consoleErrorSpy = vi.spyOn(console, 'error').mockImplementation(() => {});
+  vi.stubGlobal('process', { ...process, exit: vi.fn(), on: vi.fn() });
+  vi.spyOn(fs, 'readFileSync').mockReturnValue(JSON.stringify({ allowedProjects: ['dev'] }));
+  vi.spyOn(path, 'isAbsolute').mockReturnValue(true);
+
+  await import('./index.js');
+
+  expect(consoleErrorSpy).toHaveBeenCalledWith(
+    expect.stringContaining('Invalid default context: Project "prod" is not on the allowlist'),
+  );
+  expect(process.exit).toHaveBeenCalledWith(1);
+});
+
 test('should exit if config file is not found', async () => {
   process.argv = ['node', 'index.js', '--config', 'not-found.json'];
   vi.spyOn(fs, 'readFileSync').mockImplementation(() => {
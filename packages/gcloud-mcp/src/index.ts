@@ -19,7 +19,17 @@
 import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
 import { StdioServerTransport } from '@modelcontextprotocol/sdk/server/stdio.js';
 import pkg from '../package.json' with { type: 'json' };
-import { createRunGcloudCommand } from './tools/run_gcloud_command.js';
+import {
+  createGcloudCommandRunner,
+  createRunGcloudCommand,
+} from './tools/run_gcloud_command.js';
+import { createGcloudContext } from './tools/gcloud_context.js';
+import { createSetContext } from './tools/set_context.js';
+import { createExplainCommand } from './tools/explain_command.js';
+import { createSuggestCommand } from './tools/suggest_command.js';
+import { createValidateGcloudCommand } from './tools/validate_gcloud_command.js';
+import { createCommandHistoryTools } from './tools/command_history.js';
+import { createUndoLastChange } from './tools/undo_last_change.js';
 import * as gcloud from './gcloud.js';
 import yargs, { ArgumentsCamelCase, CommandModule } from 'yargs';
 import { hideBin } from 'yargs/helpers';
@@ -28,6 +38,101 @@ import { log } from './utility/logger.js';
 import fs from 'fs';
 import path from 'path';
 import { createAccessControlList } from './denylist.js';
+import { createRateLimiter } from './rate_limiter.js';
+import {
+  createSessionContext,
+  withProjectParameter,
+  withSessionContext,
+} from './session_context.js';
+import { createCommandHistory } from './command_history.js';
+import {
+  ConfigLayer,
+  PROJECT_CONFIG_FILE,
+  envConfig,
+  flagConfig,
+  mergeConfigs,
+  readOptionalConfigFile,
+  userConfigPath,
+  validateConfig,
+} from './config.js';
+import { createShowEffectiveConfig } from './tools/show_effective_config.js';
+import { createProfiles } from './profiles.js';
+import { createUseProfile } from './tools/use_profile.js';
+import { createBootstrapProject } from './tools/bootstrap_project.js';
+import { createNamingPolicy } from './naming_policy.js';
+import { createValidateResourceNames } from './tools/validate_resource_names.js';
+import { createCheckQuotas } from './tools/check_quotas.js';
+import { createBillingCatalog } from './billing_catalog.js';
+import { createEstimateCost } from './tools/estimate_cost.js';
+import { createVmPricingTools } from './tools/vm_pricing.js';
+import { createGoogleApiClient } from './google_api.js';
+import { createGetCostBreakdown } from './tools/get_cost_breakdown.js';
+import { createRunBigQueryQuery } from './tools/run_bigquery_query.js';
+import { createGkeCostAllocation } from './tools/gke_cost_allocation.js';
+import { createBudgetTools } from './tools/budgets.js';
+import { createAnalyzeCommitments } from './tools/analyze_commitments.js';
+import { createFindIdleResources } from './tools/find_idle_resources.js';
+import { createLabelCoverage } from './tools/label_coverage.js';
+import { createCleanupResources } from './tools/cleanup_resources.js';
+import { createRunAcrossProjects } from './tools/run_across_projects.js';
+import { createHealthCheck } from './tools/health_check.js';
+import { createTelemetry, instrumentTools } from './telemetry.js';
+import { createTracer, traceTools, withApiTracing, withGcloudTracing } from './tracing.js';
+import { createUsageReport } from './tools/usage_report.js';
+import { createClearCache } from './tools/clear_cache.js';
+import { createWorkflowPrompts } from './tools/workflow_prompts.js';
+import { createContextResources } from './tools/context_resources.js';
+import {
+  DEFAULT_PORT,
+  MCP_PATH,
+  createRemoteServer,
+  createSessionConfig,
+  withImpersonation,
+} from './remote_server.js';
+import { manifests } from './commands/manifests.js';
+import { reportSdkVersion, sdkVersionOf } from './sdk_version.js';
+import { createComponentTools } from './tools/components.js';
+import { createResponseCache, forSession } from './response_cache.js';
+import { createReadCache } from './read_cache.js';
+import { createDiffResources } from './tools/diff_resources.js';
+import { createExportResources } from './tools/export_resources.js';
+import { createComplianceScan } from './tools/compliance_scan.js';
+import { createWatchResources } from './watches.js';
+import { captureTools } from './scheduler.js';
+import { createScheduledJobTools } from './tools/scheduled_jobs.js';
+import { createBackupTools } from './tools/backups.js';
+import { createDrReadinessReport } from './tools/dr_readiness_report.js';
+import { createMigrationCenterTools } from './tools/migration_center.js';
+import { createIdentityGroupTools } from './tools/identity_groups.js';
+import { createEssentialContactsTools } from './tools/essential_contacts.js';
+import { createSupportCaseTools } from './tools/support_cases.js';
+import { createDeprecationReport } from './tools/deprecation_report.js';
+import { createWatchResource } from './tools/watch_resource.js';
+import { Runbook, loadRunbooks } from './runbooks.js';
+import { createRunbookTools } from './tools/runbooks.js';
+import { createOutputChunks } from './output_chunks.js';
+import { createMutationConfirmer } from './mutation_confirmation.js';
+import { createGetOutputChunk } from './tools/get_output_chunk.js';
+import { createComputeResourceTools } from './tools/compute_resources.js';
+import { createIamPolicyTools } from './tools/iam_policy.js';
+import { createSearchAssets } from './tools/search_assets.js';
+import { createListRecommendations } from './tools/list_recommendations.js';
+import { withRedaction } from './redaction.js';
+import { createSessionLimiter, reportQueueTime, withSessionLimits } from './session_limits.js';
+import { withPromptPolicy } from './interactive_prompts.js';
+import { createIsolatedConfig } from './isolated_config.js';
+import {
+  createImpersonationPolicy,
+  withImpersonationParameter,
+  withImpersonationPolicy,
+} from './impersonation.js';
+import {
+  AuditCaller,
+  auditTools,
+  createAuditLog,
+  localCaller,
+  withAuditLog,
+} from './audit_log.js';
 
 export const default_deny: string[] = [
   'compute start-iap-tunnel',
@@ -50,30 +155,135 @@ const exitProcessAfter = <T, U>(cmd: CommandModule<T, U>): CommandModule<T, U> =
   },
 });
 
-interface McpConfig {
-  allow?: string[];
-  deny?: string[];
-}
-
-export type { McpConfig };
+export type { McpConfig } from './config.js';
 
 const main = async () => {
   const argv = (await yargs(hideBin(process.argv))
     .command('$0', 'Run the gcloud mcp server', (yargs) =>
-      yargs.option('config', {
-        type: 'string',
-        description: 'Path to a JSON configuration file for allowlist/denylist.',
-        alias: 'c',
-      }),
+      yargs
+        .option('config', {
+          type: 'string',
+          description: 'Path to a JSON configuration file for allowlist/denylist.',
+          alias: 'c',
+        })
+        .option('project', {
+          type: 'string',
+          description: 'Default project for commands run by the server.',
+        })
+        .option('region', {
+          type: 'string',
+          description: 'Default region for commands run by the server.',
+        })
+        .option('zone', {
+          type: 'string',
+          description: 'Default zone for commands run by the server.',
+        })
+        .option('profile', {
+          type: 'string',
+          description: 'Environment profile selected when the server starts.',
+        })
+        .option('max-concurrent-commands', {
+          type: 'number',
+          description: 'The most gcloud commands each session runs at the same time.',
+        })
+        .option('cache-ttl-seconds', {
+          type: 'number',
+          description: 'Serve read commands run again within this many seconds from a cache.',
+        })
+        .option('read-only', {
+          type: 'boolean',
+          description: 'Reject every gcloud command that is not known to only read state.',
+        })
+        .option('timeout-seconds', {
+          type: 'number',
+          description: 'Stop gcloud commands that run longer than this many seconds.',
+        })
+        .option('prompt-policy', {
+          type: 'string',
+          choices: ['abort', 'default'],
+          description:
+            'Stop gcloud commands that ask for input (abort), or run every command with --quiet to take the default answers (default).',
+        })
+        .option('confirm-mutations', {
+          type: 'boolean',
+          description:
+            'Ask the user to confirm every gcloud command that is not known to only read state.',
+        })
+        .option('allow-secrets', {
+          type: 'boolean',
+          description:
+            'Return access tokens, private keys and secret payloads in tool output instead of masking them.',
+        })
+        .option('impersonate-service-account', {
+          type: 'string',
+          description:
+            'Run every gcloud command as this service account. Tool calls may elevate to the "allowedServiceAccounts" of "impersonation".',
+        })
+        .option('isolated-config', {
+          type: 'boolean',
+          description:
+            "Run gcloud with its own configuration directory instead of the operator's active configuration.",
+        })
+        .option('audit-log', {
+          type: 'string',
+          description:
+            'Absolute path of a JSON Lines file every tool call and gcloud command is appended to.',
+        })
+        .option('transport', {
+          type: 'string',
+          choices: ['stdio', 'http'],
+          default: 'stdio',
+          description: 'Serve MCP over stdio, or over HTTP for remote deployments.',
+        })
+        .option('port', {
+          type: 'number',
+          description: `Port of the HTTP transport. Defaults to $PORT or ${DEFAULT_PORT}.`,
+        })
+        .option('host', {
+          type: 'string',
+          default: '127.0.0.1',
+          description: 'Address the HTTP transport listens on.',
+        }),
     )
     .command(exitProcessAfter(init))
+    .command(exitProcessAfter(manifests))
     .version(pkg.version)
     .help()
-    .parse()) as { config?: string; [key: string]: unknown };
+    .parse()) as {
+    config?: string;
+    project?: string;
+    region?: string;
+    zone?: string;
+    profile?: string;
+    transport?: string;
+    port?: number;
+    host?: string;
+    [key: string]: unknown;
+  };
 
-  let config: McpConfig = {};
-  const configFile = argv.config;
+  // Layers are listed from lowest to highest precedence.
+  const layers: ConfigLayer[] = [];
+  const optionalFiles: Array<{ name: ConfigLayer['name']; file: string }> = [
+    { name: 'user', file: userConfigPath() },
+    { name: 'project', file: path.join(process.cwd(), PROJECT_CONFIG_FILE) },
+  ];
+  for (const { name, file } of optionalFiles) {
+    try {
+      const fileConfig = readOptionalConfigFile(file);
+      if (fileConfig) {
+        layers.push({ name, path: file, config: fileConfig });
+        log.info(`Loaded configuration from ${file}`);
+      }
+    } catch (error) {
+      log.error(
+        `Error reading or parsing config file: ${file}`,
+        error instanceof Error ? error : undefined,
+      );
+      process.exit(1);
+    }
+  }
 
+  const configFile = argv.config;
   if (configFile) {
     try {
       if (!path.isAbsolute(configFile)) {
@@ -81,14 +291,7 @@ const main = async () => {
         process.exit(1);
       }
       const configFileContent = fs.readFileSync(configFile, 'utf-8');
-      config = JSON.parse(configFileContent);
-
-      if (config.allow && config.deny) {
-        log.error(
-          'Configuration can not specify both "allow" and "deny" lists. Please choose one.',
-        );
-        process.exit(1);
-      }
+      layers.push({ name: 'file', path: configFile, config: JSON.parse(configFileContent) });
       log.info(`Loaded configuration from ${configFile}`);
     } catch (error) {
       log.error(
@@ -98,22 +301,274 @@ const main = async () => {
       process.exit(1);
     }
   }
+  layers.push({ name: 'env', config: envConfig() }, { name: 'flags', config: flagConfig(argv) });
+
+  const effectiveConfig = mergeConfigs(layers);
+  const config = effectiveConfig.config;
+  const configError = validateConfig(config);
+  if (configError) {
+    log.error(configError);
+    process.exit(1);
+  }
 
-  const server = new McpServer(
-    {
-      name: 'gcloud-mcp-server',
-      version: pkg.version,
-    },
-    { capabilities: { tools: {} } },
-  );
+  if (argv.transport === 'http' && !config.remote) {
+    log.error('The HTTP transport requires the "remote" configuration.');
+    process.exit(1);
+  }
+
+  let runbooks: Runbook[] = [];
+  if (config.runbooks) {
+    try {
+      runbooks = loadRunbooks(config.runbooks.directory);
+      log.info(`Loaded ${runbooks.length} runbooks from ${config.runbooks.directory}`);
+    } catch (error) {
+      log.error(
+        `Error loading runbooks from ${config.runbooks.directory}`,
+        error instanceof Error ? error : undefined,
+      );
+      process.exit(1);
+    }
+  }
 
   const acl = createAccessControlList(config.allow, [...default_deny, ...(config.deny ?? [])]);
+  const rateLimiter = createRateLimiter(config.rateLimits, config.rateLimitMaxQueueMs);
+  const namingPolicy = createNamingPolicy(config.namingPolicy);
+  const responseCache = config.offline && createResponseCache(config.offline);
+  const impersonation = config.impersonation && createImpersonationPolicy(config.impersonation);
+
+  // Each MCP session has its own context, profile, and history.
+  const createSessionState = () => {
+    const session = createSessionContext(config.allowedProjects);
+    const defaultsResult = session.update(config.defaults ?? {});
+    if (!defaultsResult.success) {
+      return { error: `Invalid default context: ${defaultsResult.error}` };
+    }
+    const profiles = createProfiles(config.profiles ?? {}, session);
+    if (config.defaultProfile) {
+      const profileResult = profiles.use(config.defaultProfile);
+      if (!profileResult.success) {
+        return { error: `Invalid default profile: ${profileResult.error}` };
+      }
+    }
+    // Commands are replayed, and cached results kept apart, by the service
+    // account they ran as.
+    const contextEnv = () => ({ ...session.env(), ...impersonation?.env() });
+    return {
+      session,
+      profiles,
+      contextEnv,
+      limiter: config.sessionLimits && createSessionLimiter(config.sessionLimits),
+      readCache: config.readCache && createReadCache(config.readCache, contextEnv),
+      history: createCommandHistory(contextEnv),
+      outputChunks: createOutputChunks(config.outputChunks),
+    };
+  };
+  type SessionState = Exclude<ReturnType<typeof createSessionState>, { error: string }>;
+  const initialState = createSessionState();
+  if ('error' in initialState) {
+    log.error(initialState.error);
+    process.exit(1);
+  }
 
+  let close = async () => {};
   try {
-    const cli = await gcloud.create();
-    createRunGcloudCommand(cli, acl).register(server);
-    await server.connect(new StdioServerTransport());
-    log.info('🚀 gcloud mcp server started');
+    // gcloud, and the container its configuration is mounted in, find the
+    // configuration directory through the environment.
+    const isolated =
+      config.isolatedConfig &&
+      createIsolatedConfig({ ...config.defaults, ...config.isolatedConfig });
+    if (isolated) {
+      process.env['CLOUDSDK_CONFIG'] = isolated.directory;
+      delete process.env['CLOUDSDK_ACTIVE_CONFIG_NAME'];
+      log.info(`Running gcloud with the configuration in ${isolated.directory}`, {
+        ...isolated.properties,
+      });
+    }
+    const executable = await gcloud.create(config.container);
+    const sdkVersion = await sdkVersionOf(executable);
+    if (config.container) {
+      log.info(`Running gcloud in ${config.container.image}`, { sdkVersion });
+    }
+    const disposeExecutable = async () => {
+      await executable.dispose?.();
+      isolated?.dispose();
+    };
+    const telemetry =
+      config.telemetry && createTelemetry(config.telemetry, createGoogleApiClient(executable));
+    const audit = config.audit && createAuditLog(config.audit, createGoogleApiClient(executable));
+    // Exports go through a client of their own, so that they are not traced themselves.
+    const tracer =
+      config.openTelemetry &&
+      createTracer(config.openTelemetry, pkg.version, createGoogleApiClient(executable));
+
+    const createServer = (
+      state: SessionState,
+      sessionGcloud: gcloud.GcloudExecutable,
+      caller: AuditCaller,
+    ) => {
+      const { session, contextEnv, limiter, readCache, profiles, history, outputChunks } = state;
+      const server = new McpServer(
+        {
+          name: 'gcloud-mcp-server',
+          version: pkg.version,
+        },
+        { capabilities: { tools: {}, logging: {} } },
+      );
+      // No command may block on a prompt that no one can answer.
+      const promptGcloud = withPromptPolicy(sessionGcloud, config.promptPolicy ?? 'abort');
+      const tracedGcloud = tracer ? withGcloudTracing(promptGcloud, tracer) : promptGcloud;
+      const limitedGcloud = limiter ? withSessionLimits(tracedGcloud, limiter) : tracedGcloud;
+      const sessionCli = withSessionContext(
+        impersonation ? withImpersonationPolicy(limitedGcloud, impersonation) : limitedGcloud,
+        session,
+      );
+      // The API client needs the access token, so only the output of tools is redacted.
+      const sessionApi = createGoogleApiClient(sessionCli);
+      const googleApi = tracer ? withApiTracing(sessionApi, tracer) : sessionApi;
+      const toolCli = config.allowSecrets ? sessionCli : withRedaction(sessionCli);
+      const cli = audit ? withAuditLog(toolCli, audit, caller) : toolCli;
+      const catalog = createBillingCatalog(googleApi);
+      const billingExport = config.billingExport && {
+        api: googleApi,
+        config: config.billingExport,
+      };
+      const runnerOptions = {
+        ...(config.readOnly && { readOnly: true }),
+        ...(config.confirmMutations && { confirmMutation: createMutationConfirmer(server) }),
+        ...(config.timeoutSeconds && { timeoutSeconds: config.timeoutSeconds }),
+        rateLimiter,
+        history,
+        profiles,
+        namingPolicy,
+        ...(impersonation && { impersonation }),
+        ...(telemetry && { telemetry }),
+        ...(responseCache && { responseCache: forSession(responseCache, contextEnv) }),
+        ...(readCache && { readCache }),
+        gcloudVersion: sdkVersion,
+      };
+      const runner = createGcloudCommandRunner(cli, acl, runnerOptions);
+      // Captured first, so that scheduled runs are instrumented like calls from clients.
+      const registry = captureTools(server);
+      const tools = [
+        createRunGcloudCommand(cli, acl, { ...runnerOptions, outputChunks }),
+        createGetOutputChunk(outputChunks),
+        createComputeResourceTools(runner),
+        createIamPolicyTools(runner, googleApi),
+        createSearchAssets(runner),
+        createListRecommendations(runner),
+        createGcloudContext(cli, session, acl, ['gcloud']),
+        createSetContext(session),
+        createExplainCommand(cli, acl),
+        createSuggestCommand(cli, acl),
+        createValidateGcloudCommand(cli, acl),
+        createCommandHistoryTools(history, runner),
+        createUndoLastChange(history, runner),
+        createShowEffectiveConfig(effectiveConfig),
+        createBootstrapProject(runner, history),
+        createValidateResourceNames(namingPolicy),
+        createCheckQuotas(cli, acl),
+        createEstimateCost(cli, catalog),
+        createVmPricingTools(cli, catalog),
+        createRunBigQueryQuery(cli, googleApi, config.bigquery, runnerOptions),
+        createBudgetTools(cli, acl, runner, billingExport),
+        createAnalyzeCommitments(cli, acl, billingExport),
+        createFindIdleResources(cli, acl, catalog),
+        createLabelCoverage(cli, acl, runner, history, namingPolicy),
+        createCleanupResources(cli, acl, runner, history),
+        createRunAcrossProjects(cli, acl, runner, history),
+        createDiffResources(cli, acl),
+        createExportResources(cli, acl, runner, history),
+        createComplianceScan(cli, acl, config.compliance),
+        createWatchResource(cli, acl),
+        createScheduledJobTools(acl, runner, registry, config.schedules, config.readOnly),
+        createBackupTools(cli, acl, runner),
+        createDrReadinessReport(cli, acl),
+        createMigrationCenterTools(cli, googleApi, acl, catalog),
+        createIdentityGroupTools(cli, acl),
+        createEssentialContactsTools(cli, acl, runner),
+        createSupportCaseTools(cli, acl, runner, history, googleApi, config.readOnly),
+        createDeprecationReport(cli, acl),
+        createHealthCheck(cli, { serverVersion: pkg.version, history, rateLimiter, catalog }),
+        createComponentTools(cli, acl, runner),
+        createWorkflowPrompts(),
+        ...(profiles.names().length > 0 ? [createUseProfile(profiles)] : []),
+        ...(config.billingExport
+          ? [
+              createGetCostBreakdown(googleApi, config.billingExport),
+              createGkeCostAllocation(googleApi, config.billingExport),
+            ]
+          : []),
+        ...(telemetry ? [createUsageReport(telemetry)] : []),
+        ...(readCache ? [createClearCache(readCache)] : []),
+        ...(runbooks.length > 0 ? [createRunbookTools(registry, runbooks)] : []),
+      ];
+      reportSdkVersion(server, sdkVersion);
+      reportQueueTime(server);
+      // Tool spans are the parents of the gcloud processes and API requests of each call.
+      if (tracer) {
+        traceTools(server, tracer);
+      }
+      if (telemetry) {
+        instrumentTools(server, telemetry);
+      }
+      if (audit) {
+        auditTools(server, audit, caller);
+      }
+      // Installed last, so telemetry and the audit log see the project of each call.
+      withProjectParameter(server, session);
+      if (impersonation && impersonation.allowedServiceAccounts().length > 0) {
+        withImpersonationParameter(server, impersonation);
+      }
+      tools.forEach((tool) => tool.register(server));
+      createWatchResources(cli, googleApi, acl).register(server);
+      createContextResources(cli, session, acl).register(server);
+      return server;
+    };
+
+    telemetry?.start();
+    tracer?.start();
+    if (argv.transport === 'http' && config.remote) {
+      const remote = createRemoteServer({
+        config: config.remote,
+        createSessionServer: (serviceAccount, principal) => {
+          const state = createSessionState();
+          if ('error' in state) {
+            throw new Error(state.error);
+          }
+          const sessionConfig = createSessionConfig(config.defaults);
+          const sessionGcloud = withImpersonation(
+            executable,
+            serviceAccount,
+            sessionConfig.directory,
+          );
+          const server = createServer(state, sessionGcloud, {
+            principal: principal.email,
+            serviceAccount,
+          });
+          const onclose = server.server.onclose;
+          server.server.onclose = () => {
+            sessionConfig.dispose();
+            onclose?.();
+          };
+          return server;
+        },
+      });
+      const port = argv.port ?? (Number(process.env['PORT']) || DEFAULT_PORT);
+      remote.httpServer.listen(port, argv.host);
+      close = async () => {
+        await remote.close();
+        await disposeExecutable();
+      };
+      log.info(`🚀 gcloud mcp server listening on ${argv.host}:${port}${MCP_PATH}`);
+    } else {
+      const server = createServer(initialState, executable, localCaller());
+      await server.connect(new StdioServerTransport());
+      close = async () => {
+        await server.close();
+        await disposeExecutable();
+      };
+      log.info('🚀 gcloud mcp server started');
+    }
   } catch (e: unknown) {
     const error = String(e);
     log.error(`Unable to start gcloud mcp server: ${error}`);
@@ -121,23 +576,23 @@ const main = async () => {
   }
 
   process.on('uncaughtException', async (err: unknown) => {
-    await server.close();
+    await close();
     const error = err instanceof Error ? err : undefined;
     log.error('❌ Uncaught exception.', error);
     process.exit(1);
   });
   process.on('unhandledRejection', async (reason: unknown, promise: Promise<unknown>) => {
-    await server.close();
+    await close();
     const error = reason instanceof Error ? reason : undefined;
     log.error(`❌ Unhandled rejection: ${promise}`, error);
     process.exit(1);
   });
   process.on('SIGINT', async () => {
-    await server.close();
+    await close();
     process.exit(0);
   });
   process.on('SIGTERM', async () => {
-    await server.close();
+    await close();
     process.exit(0);
   });
 };
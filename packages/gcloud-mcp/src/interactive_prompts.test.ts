@@ -0,0 +1,110 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import {
+  PROMPT_WAIT_MS,
+  pendingPrompt,
+  withPromptPolicy,
+  withQuietFlag,
+} from './interactive_prompts.js';
+
+vi.mock('./gcloud.js');
+
+describe('pendingPrompt', () => {
+  test('returns the prompt the output ends with', () => {
+    expect(pendingPrompt('Deleting instance vm-1.\n\nDo you want to continue (Y/n)?  ')).toBe(
+      'Do you want to continue (Y/n)?',
+    );
+    const zones = ' [1] us-east1-b\n [2] us-west1-a\nPlease enter your numeric choice:  ';
+    expect(pendingPrompt(zones)).toBe('Please enter your numeric choice:');
+  });
+
+  test('ignores prompts followed by more output', () => {
+    expect(pendingPrompt('Do you want to continue (Y/n)?  \nDeleted [vm-1].\n')).toBeUndefined();
+    expect(pendingPrompt('Listed 0 items.\n')).toBeUndefined();
+  });
+});
+
+describe('withQuietFlag', () => {
+  test('adds --quiet to commands without it', () => {
+    expect(withQuietFlag(['compute', 'instances', 'delete', 'vm-1'])).toEqual([
+      'compute',
+      'instances',
+      'delete',
+      'vm-1',
+      '--quiet',
+    ]);
+    expect(withQuietFlag(['compute', 'instances', 'delete', 'vm-1', '-q'])).toEqual([
+      'compute',
+      'instances',
+      'delete',
+      'vm-1',
+      '-q',
+    ]);
+  });
+
+  test('adds --quiet before the arguments of another program', () => {
+    expect(withQuietFlag(['compute', 'ssh', 'vm-1', '--', '-q', 'ls'])).toEqual([
+      'compute',
+      'ssh',
+      'vm-1',
+      '--quiet',
+      '--',
+      '-q',
+      'ls',
+    ]);
+  });
+});
+
+describe('withPromptPolicy', () => {
+  const createGcloud = (): gcloud.GcloudExecutable => ({
+    lint: vi.fn(),
+    invoke: vi.fn().mockResolvedValue({ code: 0, stdout: '', stderr: '' }),
+  });
+
+  test('watches for prompts without changing the command', async () => {
+    const cli = createGcloud();
+
+    await withPromptPolicy(cli, 'abort').invoke(['compute', 'instances', 'delete', 'vm-1'], {
+      CLOUDSDK_CORE_PROJECT: 'p',
+    });
+
+    expect(cli.invoke).toHaveBeenCalledWith(
+      ['compute', 'instances', 'delete', 'vm-1'],
+      { CLOUDSDK_CORE_PROJECT: 'p' },
+      { promptWaitMs: PROMPT_WAIT_MS },
+    );
+  });
+
+  test('runs commands with --quiet under the default policy', async () => {
+    const cli = createGcloud();
+
+    await withPromptPolicy(cli, 'default').invoke(
+      ['compute', 'instances', 'delete', 'vm-1'],
+      {},
+      { timeoutMs: 1000 },
+    );
+
+    expect(cli.invoke).toHaveBeenCalledWith(
+      ['compute', 'instances', 'delete', 'vm-1', '--quiet'],
+      {},
+      { timeoutMs: 1000, promptWaitMs: PROMPT_WAIT_MS },
+    );
+  });
+});
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { GcloudExecutable } from './gcloud.js';
+import { ExecutionOptions } from './gcloud_executor.js';
+
+/**
+ * What happens when a gcloud command asks for input. With "abort", gcloud is
+ * stopped and the call fails with an INTERACTIVE_INPUT_REQUIRED error. With
+ * "default", commands run with --quiet, so gcloud takes the default answer to
+ * every prompt, e.g. yes to "Do you want to continue (Y/n)?", and fails where
+ * there is none; commands that still wait for input are stopped as well.
+ */
+export const PROMPT_POLICIES = ['abort', 'default'] as const;
+export type PromptPolicy = (typeof PROMPT_POLICIES)[number];
+
+/**
+ * How long gcloud may wait on a prompt before it is stopped. gcloud prints
+ * some prompts and carries on with the default when stdin is not a terminal,
+ * so a prompt only counts once no output follows it.
+ */
+export const PROMPT_WAIT_MS = 3000;
+
+// Prompts end the output without a newline while gcloud waits for the answer.
+const PROMPT_PATTERNS = [
+  // Do you want to continue (Y/n)?
+  /\((?:Y\/n|y\/N|y\/n)\)\??\s*$/,
+  /Please enter your numeric choice:\s*$/,
+  /Please enter (?:a value|an? [\w ]+) for [^\n]*:\s*$/i,
+  /(?:passphrase|password|verification code)[^\n]*:\s*$/i,
+];
+
+/** Returns the prompt the output ends with, if any. */
+export const pendingPrompt = (output: string): string | undefined => {
+  const lastLine = output.slice(output.lastIndexOf('\n') + 1);
+  return PROMPT_PATTERNS.some((pattern) => pattern.test(lastLine)) ? lastLine.trim() : undefined;
+};
+
+const QUIET_FLAGS = ['--quiet', '-q'];
+
+/**
+ * Adds --quiet to a command that does not set it. Arguments after "--" belong
+ * to another program, e.g. ssh, so the flag goes before them.
+ */
+export const withQuietFlag = (args: string[]): string[] => {
+  const end = args.indexOf('--');
+  const gcloudArgs = end === -1 ? args : args.slice(0, end);
+  if (gcloudArgs.some((arg) => QUIET_FLAGS.includes(arg))) {
+    return args;
+  }
+  return end === -1 ? [...args, '--quiet'] : [...gcloudArgs, '--quiet', ...args.slice(end)];
+};
+
+/**
+ * Wraps gcloud so that no command blocks on a prompt: commands that wait for
+ * input are stopped, and with the "default" policy every command runs with
+ * --quiet.
+ */
+export const withPromptPolicy = (
+  gcloud: GcloudExecutable,
+  policy: PromptPolicy,
+): GcloudExecutable => ({
+  ...gcloud,
+  invoke: (args: string[], env?: NodeJS.ProcessEnv, options?: ExecutionOptions) =>
+    gcloud.invoke(policy === 'default' ? withQuietFlag(args) : args, env, {
+      ...options,
+      promptWaitMs: PROMPT_WAIT_MS,
+    }),
+});
@@ -0,0 +1,106 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import { inverseOf } from './inverse_command.js';
+
+describe('inverseOf', () => {
+  test('swaps add-tags for remove-tags', () => {
+    expect(
+      inverseOf('compute instances add-tags', [
+        'compute',
+        'instances',
+        'add-tags',
+        'vm-1',
+        '--tags=http',
+        '--zone=us-east1-b',
+      ])?.args,
+    ).toEqual(['compute', 'instances', 'remove-tags', 'vm-1', '--tags=http', '--zone=us-east1-b']);
+  });
+
+  test('swaps IAM policy binding changes', () => {
+    const args = [
+      'projects',
+      'remove-iam-policy-binding',
+      'my-project',
+      '--member=user:a@example.com',
+      '--role=roles/viewer',
+    ];
+    expect(inverseOf('projects remove-iam-policy-binding', args)?.args).toEqual([
+      'projects',
+      'add-iam-policy-binding',
+      'my-project',
+      '--member=user:a@example.com',
+      '--role=roles/viewer',
+    ]);
+  });
+
+  test('keeps the release track', () => {
+    const args = ['beta', 'compute', 'instances', 'stop', 'vm-1'];
+    expect(inverseOf('beta compute instances stop', args)?.args).toEqual([
+      'beta',
+      'compute',
+      'instances',
+      'start',
+      'vm-1',
+    ]);
+  });
+
+  test('deletes created resources in the same location', () => {
+    const args = [
+      'compute',
+      'instances',
+      'create',
+      'vm-1',
+      'vm-2',
+      '--machine-type=e2-small',
+      '--zone',
+      'us-east1-b',
+    ];
+    expect(inverseOf('compute instances create', args)).toEqual({
+      args: ['compute', 'instances', 'delete', 'vm-1', 'vm-2', '--zone=us-east1-b', '--quiet'],
+      caveat: expect.stringContaining('Deletes'),
+    });
+  });
+
+  test('removes added labels by key', () => {
+    const args = ['compute', 'instances', 'add-labels', 'vm-1', '--labels=env=dev,team=web'];
+    expect(inverseOf('compute instances add-labels', args)?.args).toEqual([
+      'compute',
+      'instances',
+      'remove-labels',
+      'vm-1',
+      '--labels=env,team',
+    ]);
+  });
+
+  test('removes added metadata by key', () => {
+    const args = ['compute', 'instances', 'add-metadata', 'vm-1', '--metadata', 'a=1,b=2'];
+    expect(inverseOf('compute instances add-metadata', args)?.args).toEqual([
+      'compute',
+      'instances',
+      'remove-metadata',
+      'vm-1',
+      '--keys=a,b',
+    ]);
+  });
+
+  test('returns null for commands without a known inverse', () => {
+    const args = ['compute', 'instances', 'delete', 'vm-1'];
+    expect(inverseOf('compute instances delete', args)).toBeNull();
+    expect(inverseOf('compute instances list', ['compute', 'instances', 'list'])).toBeNull();
+  });
+});
@@ -0,0 +1,144 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { parseReleaseTrack } from './suggest.js';
+
+export interface InverseCommand {
+  args: string[];
+  /** What the inverse does not restore, shown to the user before confirming. */
+  caveat: string;
+}
+
+// Verbs whose inverse takes exactly the same arguments.
+const SYMMETRIC_VERBS: Record<string, string> = {
+  start: 'stop',
+  stop: 'start',
+  enable: 'disable',
+  disable: 'enable',
+  'add-tags': 'remove-tags',
+  'remove-tags': 'add-tags',
+  'add-iam-policy-binding': 'remove-iam-policy-binding',
+  'remove-iam-policy-binding': 'add-iam-policy-binding',
+};
+
+// Flags that locate a resource and must be kept when deleting it.
+const SCOPE_FLAGS = ['--project', '--zone', '--region', '--location'];
+
+/** Returns the value of a flag given as `--flag=value` or `--flag value`. */
+const flagValue = (args: string[], name: string): string | undefined => {
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]!;
+    if (arg.startsWith(`${name}=`)) {
+      return arg.slice(name.length + 1);
+    }
+    if (arg === name) {
+      return args[i + 1];
+    }
+  }
+  return undefined;
+};
+
+/** Returns the keys of a `KEY=VALUE,...` flag value. */
+const keysOf = (value: string): string =>
+  value
+    .split(',')
+    .map((pair) => pair.split('=')[0])
+    .join(',');
+
+const replaceVerb = (args: string[], verb: string, inverseVerb: string): string[] => {
+  const result = [...args];
+  result[result.indexOf(verb)] = inverseVerb;
+  return result;
+};
+
+// Deletes what `create` made: keep the command path, the resource names that
+// directly follow the verb, and the flags that locate the resource.
+const deleteCreated = (args: string[], verb: string): string[] => {
+  const verbIndex = args.indexOf(verb);
+  const path = args.slice(0, verbIndex);
+  const names: string[] = [];
+  for (const arg of args.slice(verbIndex + 1)) {
+    if (arg.startsWith('-')) {
+      break;
+    }
+    names.push(arg);
+  }
+  const scope = SCOPE_FLAGS.flatMap((flag) => {
+    const value = flagValue(args, flag);
+    return value === undefined ? [] : [`${flag}=${value}`];
+  });
+  return [...path, 'delete', ...names, ...scope, '--quiet'];
+};
+
+const withKeysFlag = (
+  args: string[],
+  verb: string,
+  inverseVerb: string,
+  flag: string,
+  keysFlag: string,
+): string[] | null => {
+  const value = flagValue(args, flag);
+  if (!value) {
+    return null;
+  }
+  const verbIndex = args.indexOf(verb);
+  const rest = args.slice(verbIndex + 1).filter((arg, i, all) => {
+    const isFlag = arg === flag || arg.startsWith(`${flag}=`);
+    const isValue = all[i - 1] === flag;
+    return !isFlag && !isValue;
+  });
+  return [...args.slice(0, verbIndex), inverseVerb, ...rest, `${keysFlag}=${keysOf(value)}`];
+};
+
+/**
+ * Returns a best-effort command that reverts a successful mutating command.
+ *
+ * Returns null when no safe inverse is known, e.g. for deletions or updates
+ * whose previous state was not recorded.
+ */
+export const inverseOf = (command: string, args: string[]): InverseCommand | null => {
+  const releaseTrack = parseReleaseTrack(command);
+  const path = (releaseTrack ? command.slice(releaseTrack.length + 1) : command).split(' ');
+  const verb = path[path.length - 1] ?? '';
+  if (!args.includes(verb)) {
+    return null;
+  }
+
+  const symmetric = SYMMETRIC_VERBS[verb];
+  if (symmetric) {
+    return {
+      args: replaceVerb(args, verb, symmetric),
+      caveat: `Runs "${symmetric}" with the same arguments.`,
+    };
+  }
+  switch (verb) {
+    case 'create':
+      return {
+        args: deleteCreated(args, verb),
+        caveat: 'Deletes the created resource, including any data written to it since.',
+      };
+    case 'add-labels': {
+      const inverse = withKeysFlag(args, verb, 'remove-labels', '--labels', '--labels');
+      return inverse && { args: inverse, caveat: 'Removes the added labels.' };
+    }
+    case 'add-metadata': {
+      const inverse = withKeysFlag(args, verb, 'remove-metadata', '--metadata', '--keys');
+      return inverse && { args: inverse, caveat: 'Removes the added metadata keys.' };
+    }
+    default:
+      return null;
+  }
+};
@@ -0,0 +1,110 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { afterEach, beforeEach, describe, expect, test } from 'vitest';
+import {
+  activeProperties,
+  createIsolatedConfig,
+  parseProperties,
+  renderProperties,
+} from './isolated_config.js';
+
+const OPERATOR_CONFIG = `[core]
+account = alice@example.com
+project = alice-sandbox
+disable_usage_reporting = True
+
+[compute]
+region = europe-west1
+`;
+
+describe('parseProperties', () => {
+  test('reads the supported properties of their sections', () => {
+    expect(parseProperties(OPERATOR_CONFIG)).toEqual({
+      account: 'alice@example.com',
+      project: 'alice-sandbox',
+      region: 'europe-west1',
+    });
+    expect(parseProperties('[compute]\nproject = not-core\n')).toEqual({});
+  });
+
+  test('round trips rendered properties', () => {
+    const properties = { account: 'a@example.com', project: 'p', zone: 'us-east1-b' };
+
+    expect(parseProperties(renderProperties(properties))).toEqual(properties);
+  });
+});
+
+describe('createIsolatedConfig', () => {
+  let operatorDir: string;
+  let env: NodeJS.ProcessEnv;
+
+  beforeEach(() => {
+    operatorDir = fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-operator-'));
+    fs.mkdirSync(path.join(operatorDir, 'configurations'));
+    fs.writeFileSync(path.join(operatorDir, 'active_config'), 'work');
+    fs.writeFileSync(path.join(operatorDir, 'configurations', 'config_work'), OPERATOR_CONFIG);
+    fs.writeFileSync(path.join(operatorDir, 'credentials.db'), 'credentials');
+    env = { CLOUDSDK_CONFIG: operatorDir };
+  });
+
+  afterEach(() => {
+    fs.rmSync(operatorDir, { recursive: true, force: true });
+  });
+
+  test('seeds a temporary directory with the configured properties and credentials', () => {
+    const isolated = createIsolatedConfig({ project: 'prod-project', zone: 'us-east1-b' }, env);
+
+    try {
+      expect(isolated.directory).not.toBe(operatorDir);
+      expect(activeProperties(isolated.directory, {})).toEqual({
+        account: 'alice@example.com',
+        project: 'prod-project',
+        region: 'europe-west1',
+        zone: 'us-east1-b',
+      });
+      expect(fs.readFileSync(path.join(isolated.directory, 'credentials.db'), 'utf-8')).toBe(
+        'credentials',
+      );
+    } finally {
+      isolated.dispose();
+    }
+    expect(fs.existsSync(isolated.directory)).toBe(false);
+    // The operator's configuration is left as it was.
+    expect(activeProperties(operatorDir, {}).project).toBe('alice-sandbox');
+  });
+
+  test('keeps a configured directory and can leave credentials out', () => {
+    const directory = path.join(operatorDir, 'isolated');
+    const isolated = createIsolatedConfig(
+      { directory, account: 'mcp@p.iam.gserviceaccount.com', copyCredentials: false },
+      env,
+    );
+    isolated.dispose();
+
+    expect(fs.existsSync(path.join(directory, 'credentials.db'))).toBe(false);
+    expect(activeProperties(directory, {}).account).toBe('mcp@p.iam.gserviceaccount.com');
+  });
+
+  test("refuses to use the operator's directory", () => {
+    expect(() => createIsolatedConfig({ directory: operatorDir }, env)).toThrow(
+      "The isolated configuration directory is the operator's",
+    );
+  });
+});
@@ -0,0 +1,161 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { hostConfigDir } from './container.js';
+
+export interface IsolatedConfig {
+  /**
+   * The configuration directory gcloud runs with. Must be absolute. Defaults
+   * to a temporary directory that is removed when the server stops.
+   */
+  directory?: string;
+  /** The account to run as. Defaults to the account of the operator's active configuration. */
+  account?: string;
+  project?: string;
+  region?: string;
+  zone?: string;
+  /** Copies the operator's credentials into the directory. Defaults to true. */
+  copyCredentials?: boolean;
+}
+
+export interface ConfigProperties {
+  account?: string;
+  project?: string;
+  region?: string;
+  zone?: string;
+}
+
+// The files of a configuration directory that hold the credentials of its accounts.
+export const CREDENTIAL_FILES = [
+  'credentials.db',
+  'access_tokens.db',
+  'legacy_credentials',
+  'application_default_credentials.json',
+];
+
+const SECTIONS: Record<keyof ConfigProperties, string> = {
+  account: 'core',
+  project: 'core',
+  region: 'compute',
+  zone: 'compute',
+};
+
+export const validateIsolatedConfig = (config: IsolatedConfig): string | undefined => {
+  if (config.directory !== undefined && !path.isAbsolute(config.directory)) {
+    return `Isolated configuration directory must be absolute: ${config.directory}`;
+  }
+  return undefined;
+};
+
+/** Reads the properties of a gcloud configuration file, e.g. `configurations/config_default`. */
+export const parseProperties = (text: string): ConfigProperties => {
+  const properties: ConfigProperties = {};
+  let section = '';
+  for (const line of text.split('\n').map((l) => l.trim())) {
+    const header = line.match(/^\[(\w+)\]$/);
+    if (header) {
+      section = header[1]!;
+      continue;
+    }
+    const [, key, value] = line.match(/^(\w+)\s*=\s*(.*)$/) ?? [];
+    if (key && value && key in SECTIONS && SECTIONS[key as keyof ConfigProperties] === section) {
+      properties[key as keyof ConfigProperties] = value;
+    }
+  }
+  return properties;
+};
+
+/** Renders properties as a gcloud configuration file. */
+export const renderProperties = (properties: ConfigProperties): string =>
+  ['core', 'compute']
+    .map((section) => {
+      const lines = Object.entries(properties)
+        .filter(([key, value]) => value && SECTIONS[key as keyof ConfigProperties] === section)
+        .map(([key, value]) => `${key} = ${value}`);
+      return lines.length > 0 ? [`[${section}]`, ...lines, ''].join('\n') : '';
+    })
+    .filter(Boolean)
+    .join('\n');
+
+/** Returns the properties of the active configuration of a configuration directory. */
+export const activeProperties = (
+  configDir: string,
+  env: NodeJS.ProcessEnv = process.env,
+): ConfigProperties => {
+  const activeFile = path.join(configDir, 'active_config');
+  const name =
+    env['CLOUDSDK_ACTIVE_CONFIG_NAME'] ??
+    (fs.existsSync(activeFile) ? fs.readFileSync(activeFile, 'utf-8').trim() : 'default');
+  const file = path.join(configDir, 'configurations', `config_${name}`);
+  return fs.existsSync(file) ? parseProperties(fs.readFileSync(file, 'utf-8')) : {};
+};
+
+/**
+ * Creates the configuration directory of a server, so that `gcloud config`
+ * changes made through it never reach the operator's configuration. The
+ * directory gets a default configuration with the configured properties, or
+ * those of the operator's active configuration, and a copy of the operator's
+ * credentials.
+ */
+export const createIsolatedConfig = (
+  config: IsolatedConfig,
+  env: NodeJS.ProcessEnv = process.env,
+) => {
+  const sourceDir = hostConfigDir(env);
+  const directory =
+    config.directory ?? fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-config-'));
+  if (path.resolve(directory) === path.resolve(sourceDir)) {
+    throw new Error(`The isolated configuration directory is the operator's: ${directory}`);
+  }
+  fs.mkdirSync(path.join(directory, 'configurations'), { recursive: true, mode: 0o700 });
+
+  if (config.copyCredentials ?? true) {
+    for (const file of CREDENTIAL_FILES) {
+      const source = path.join(sourceDir, file);
+      if (fs.existsSync(source)) {
+        fs.cpSync(source, path.join(directory, file), { recursive: true });
+      }
+    }
+  }
+  const inherited = activeProperties(sourceDir, env);
+  const properties = Object.fromEntries(
+    Object.entries({
+      account: config.account ?? inherited.account,
+      project: config.project ?? inherited.project,
+      region: config.region ?? inherited.region,
+      zone: config.zone ?? inherited.zone,
+    }).filter(([, value]) => value),
+  ) as ConfigProperties;
+  fs.writeFileSync(path.join(directory, 'active_config'), 'default');
+  fs.writeFileSync(
+    path.join(directory, 'configurations', 'config_default'),
+    renderProperties(properties),
+  );
+
+  return {
+    directory,
+    properties,
+    /** Removes the directory if the server created it. */
+    dispose: () => {
+      if (!config.directory) {
+        fs.rmSync(directory, { recursive: true, force: true });
+      }
+    },
+  };
+};
@@ -0,0 +1,158 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { createNamingPolicy } from './naming_policy.js';
+import { labelCoverage, labelUpdates } from './label_coverage.js';
+
+vi.mock('./gcloud.js');
+
+const policy = createNamingPolicy({
+  requiredLabels: { '*': ['team'], 'compute instances': ['env'] },
+});
+
+const ASSETS = [
+  {
+    name: '//compute.googleapis.com/projects/p/zones/us-central1-a/instances/web-1',
+    assetType: 'compute.googleapis.com/Instance',
+    location: 'us-central1-a',
+    labels: { team: 'web', env: 'prod' },
+  },
+  {
+    name: '//compute.googleapis.com/projects/p/zones/us-central1-a/instances/web-2',
+    assetType: 'compute.googleapis.com/Instance',
+    location: 'us-central1-a',
+    labels: { team: 'web' },
+  },
+  {
+    name: '//storage.googleapis.com/logs-bucket',
+    assetType: 'storage.googleapis.com/Bucket',
+    location: 'us',
+  },
+];
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+describe('labelCoverage', () => {
+  beforeEach(() => {
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: JSON.stringify(ASSETS), stderr: '' }),
+    };
+  });
+
+  test('reports coverage by project, service, and label', async () => {
+    const report = await labelCoverage(mockedGcloud, policy, ['p']);
+
+    expect(report.summary).toEqual({ resources: 3, missingRequiredLabels: 2, coverage: 0.333 });
+    expect(report.byProject).toEqual([
+      { project: 'p', resources: 3, missingRequiredLabels: 2, coverage: 0.333 },
+    ]);
+    expect(report.byService).toEqual([
+      { service: 'compute.googleapis.com', resources: 2, missingRequiredLabels: 1, coverage: 0.5 },
+      { service: 'storage.googleapis.com', resources: 1, missingRequiredLabels: 1, coverage: 0 },
+    ]);
+    expect(report.byLabel).toEqual([
+      { label: 'env', missing: 1 },
+      { label: 'team', missing: 1 },
+    ]);
+    expect(report.unlabeled).toEqual([
+      {
+        project: 'p',
+        assetType: 'compute.googleapis.com/Instance',
+        name: 'web-2',
+        location: 'us-central1-a',
+        missing: ['env'],
+        consoleUrl:
+          'https://console.cloud.google.com/compute/instancesDetail/zones/us-central1-a/instances/web-2?project=p',
+      },
+      {
+        project: 'p',
+        assetType: 'storage.googleapis.com/Bucket',
+        name: 'logs-bucket',
+        location: 'us',
+        missing: ['team'],
+        consoleUrl: 'https://console.cloud.google.com/storage/browser/logs-bucket?project=p',
+      },
+    ]);
+  });
+
+  test('reports projects that can not be searched', async () => {
+    vi.mocked(mockedGcloud.invoke)
+      .mockResolvedValueOnce({ code: 1, stdout: '', stderr: 'PERMISSION_DENIED' })
+      .mockResolvedValueOnce({ code: 0, stdout: '[]', stderr: '' });
+
+    const report = await labelCoverage(mockedGcloud, policy, ['denied', 'empty']);
+
+    expect(report.errors).toEqual([
+      { project: 'denied', message: expect.stringContaining('PERMISSION_DENIED') },
+    ]);
+    expect(report.summary).toEqual({ resources: 0, missingRequiredLabels: 0, coverage: null });
+  });
+});
+
+describe('labelUpdates', () => {
+  test('returns update commands for labels with values', async () => {
+    const report = await labelCoverage(
+      {
+        lint: vi.fn(),
+        invoke: vi.fn().mockResolvedValue({ code: 0, stdout: JSON.stringify(ASSETS), stderr: '' }),
+      },
+      policy,
+      ['p'],
+    );
+
+    const updates = labelUpdates(report.unlabeled, { team: 'data' });
+
+    expect(updates.map(({ args }) => args)).toEqual([
+      [
+        'storage',
+        'buckets',
+        'update',
+        'gs://logs-bucket',
+        '--update-labels=team=data',
+        '--project=p',
+      ],
+    ]);
+  });
+
+  test('uses the zone or region flag of the resource', () => {
+    const updates = labelUpdates(
+      [
+        {
+          project: 'p',
+          assetType: 'compute.googleapis.com/Disk',
+          name: 'data',
+          location: 'us-central1',
+          missing: ['team', 'env'],
+          consoleUrl: null,
+        },
+      ],
+      { team: 'data', env: 'dev' },
+    );
+
+    expect(updates[0]?.args).toEqual([
+      'compute',
+      'disks',
+      'add-labels',
+      'data',
+      '--region=us-central1',
+      '--labels=team=data,env=dev',
+      '--project=p',
+    ]);
+  });
+});
@@ -0,0 +1,168 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { GcloudExecutable } from './gcloud.js';
+import { NamingPolicy } from './naming_policy.js';
+import { ASSET_TYPES, Asset, searchAssets } from './assets.js';
+import { consoleUrlOf } from './console_links.js';
+
+export interface UnlabeledResource {
+  project: string;
+  assetType: string;
+  name: string;
+  location: string;
+  missing: string[];
+  consoleUrl: string | null;
+}
+
+export interface CoverageCount {
+  resources: number;
+  missingRequiredLabels: number;
+  /** The share of resources with every required label, or null without resources. */
+  coverage: number | null;
+}
+
+export interface LabelCoverageReport {
+  summary: CoverageCount;
+  byProject: Array<{ project: string } & CoverageCount>;
+  byService: Array<{ service: string } & CoverageCount>;
+  /** How many resources miss each required label. */
+  byLabel: Array<{ label: string; missing: number }>;
+  unlabeled: UnlabeledResource[];
+  errors: Array<{ project: string; message: string }>;
+}
+
+export interface LabelUpdate {
+  resource: UnlabeledResource;
+  labels: Record<string, string>;
+  args: string[];
+}
+
+const countOf = (resources: number, missingRequiredLabels: number): CoverageCount => ({
+  resources,
+  missingRequiredLabels,
+  coverage:
+    resources > 0
+      ? Math.round(((resources - missingRequiredLabels) / resources) * 1000) / 1000
+      : null,
+});
+
+/** Returns coverage counts grouped by a key, largest groups first. */
+const groupCounts = (
+  all: Array<{ key: string; unlabeled: boolean }>,
+): Array<[string, CoverageCount]> => {
+  const groups = new Map<string, { total: number; unlabeled: number }>();
+  for (const { key, unlabeled } of all) {
+    const group = groups.get(key) ?? { total: 0, unlabeled: 0 };
+    group.total += 1;
+    group.unlabeled += unlabeled ? 1 : 0;
+    groups.set(key, group);
+  }
+  return [...groups.entries()]
+    .sort(([, a], [, b]) => b.total - a.total)
+    .map(([key, { total, unlabeled }]) => [key, countOf(total, unlabeled)]);
+};
+
+/**
+ * Reports which resources miss labels the naming policy requires, using
+ * Cloud Asset Inventory so every service is covered by a single search.
+ */
+export const labelCoverage = async (
+  gcloud: GcloudExecutable,
+  policy: NamingPolicy,
+  projects: string[],
+): Promise<LabelCoverageReport> => {
+  const errors: LabelCoverageReport['errors'] = [];
+  const checked: Array<{ project: string; service: string; missing: string[] }> = [];
+  const unlabeled: UnlabeledResource[] = [];
+  for (const project of projects) {
+    let assets: Asset[];
+    try {
+      assets = await searchAssets(gcloud, project);
+    } catch (e: unknown) {
+      errors.push({ project, message: e instanceof Error ? e.message : String(e) });
+      continue;
+    }
+    for (const asset of assets) {
+      const type = ASSET_TYPES[asset.assetType];
+      const required = type ? policy.requiredLabels(type.resourceType) : [];
+      if (required.length === 0) {
+        continue;
+      }
+      const missing = required.filter((label) => !(label in asset.labels));
+      checked.push({ project, service: asset.assetType.split('/')[0] ?? '', missing });
+      if (missing.length > 0) {
+        const { assetType, name, location } = asset;
+        const consoleUrl = consoleUrlOf({ project, assetType, name, location });
+        unlabeled.push({ project, assetType, name, location, missing, consoleUrl });
+      }
+    }
+  }
+
+  const missingCounts = new Map<string, number>();
+  for (const { missing } of unlabeled) {
+    for (const label of missing) {
+      missingCounts.set(label, (missingCounts.get(label) ?? 0) + 1);
+    }
+  }
+  return {
+    summary: countOf(checked.length, unlabeled.length),
+    byProject: groupCounts(
+      checked.map(({ project, missing }) => ({ key: project, unlabeled: missing.length > 0 })),
+    ).map(([project, count]) => ({ project, ...count })),
+    byService: groupCounts(
+      checked.map(({ service, missing }) => ({ key: service, unlabeled: missing.length > 0 })),
+    ).map(([service, count]) => ({ service, ...count })),
+    byLabel: [...missingCounts.entries()]
+      .sort(([, a], [, b]) => b - a)
+      .map(([label, missing]) => ({ label, missing })),
+    unlabeled,
+    errors,
+  };
+};
+
+/**
+ * Returns the commands that set the given label values on resources missing
+ * them. Resources are skipped if none of their missing labels has a value.
+ */
+export const labelUpdates = (
+  unlabeled: UnlabeledResource[],
+  values: Record<string, string>,
+): LabelUpdate[] =>
+  unlabeled.flatMap((resource) => {
+    const type = ASSET_TYPES[resource.assetType];
+    const labels = Object.fromEntries(
+      resource.missing
+        .filter((label) => values[label] !== undefined)
+        .map((label) => [label, values[label] ?? '']),
+    );
+    if (!type || Object.keys(labels).length === 0) {
+      return [];
+    }
+    const flag = Object.entries(labels)
+      .map(([key, value]) => `${key}=${value}`)
+      .join(',');
+    return [
+      {
+        resource,
+        labels,
+        args: [
+          ...type.updateLabelsArgs(resource.name, resource.location, flag),
+          `--project=${resource.project}`,
+        ],
+      },
+    ];
+  });
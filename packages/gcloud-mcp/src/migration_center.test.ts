@@ -0,0 +1,226 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import { BillingCatalog } from './billing_catalog.js';
+import {
+  buildFitReport,
+  candidateMachineTypes,
+  listMigrationAssets,
+  listMigrationGroups,
+  recommendMachineType,
+  requiredCapacity,
+} from './migration_center.js';
+
+vi.mock('./gcloud.js');
+
+const hourlySku = (description: string, nanos: number) => ({
+  skuId: description,
+  description,
+  category: { resourceFamily: 'Compute', usageType: 'OnDemand' },
+  serviceRegions: ['us-central1'],
+  pricingInfo: [
+    {
+      pricingExpression: {
+        usageUnit: 'h',
+        tieredRates: [
+          { startUsageAmount: 0, unitPrice: { currencyCode: 'USD', units: '0', nanos } },
+        ],
+      },
+    },
+  ],
+});
+
+const asset = (id: string, groups: string[] = []) => ({
+  name: `projects/p/locations/us-central1/assets/${id}`,
+  assignedGroups: groups.map((group) => `projects/p/locations/us-central1/groups/${group}`),
+  machineDetails: {
+    machineName: `vm-${id}`,
+    coreCount: 4,
+    memoryMb: 16384,
+    guestOs: { family: 'OS_FAMILY_LINUX' },
+    disks: { totalCapacityBytes: String(100 * 1024 ** 3) },
+  },
+  performanceData: {
+    dailyResourceUsageAggregations: [
+      {
+        cpu: { utilizationPercentage: { peak: 20 } },
+        memory: { utilizationPercentage: { peak: 50 } },
+      },
+      { cpu: { utilizationPercentage: { peak: 40 } } },
+    ],
+  },
+});
+
+describe('listMigrationAssets', () => {
+  let api: GoogleApiClient;
+
+  beforeEach(() => {
+    api = { get: vi.fn(), post: vi.fn() };
+  });
+
+  test('lists machine assets of a group across pages', async () => {
+    vi.mocked(api.get)
+      .mockResolvedValueOnce({
+        assets: [asset('a', ['wave-1']), asset('b')],
+        nextPageToken: 'next',
+      })
+      .mockResolvedValueOnce({ assets: [asset('c', ['wave-1']), { name: 'projects/p/assets/d' }] });
+
+    const { assets, truncated } = await listMigrationAssets(api, 'p', 'us-central1', 'wave-1');
+
+    expect(assets.map(({ id }) => id)).toEqual(['a', 'c']);
+    expect(assets[0]).toEqual({
+      id: 'a',
+      name: 'vm-a',
+      cpus: 4,
+      memoryGb: 16,
+      diskGb: 100,
+      os: 'OS_FAMILY_LINUX',
+      cpuPeakPercent: 40,
+      memoryPeakPercent: 50,
+      groups: ['wave-1'],
+    });
+    expect(truncated).toBe(false);
+    expect(vi.mocked(api.get).mock.calls[0]![0]).toBe(
+      'https://migrationcenter.googleapis.com/v1/projects/p/locations/us-central1/assets?view=ASSET_VIEW_FULL&pageSize=500',
+    );
+    expect(vi.mocked(api.get).mock.calls[1]![0]).toContain('pageToken=next');
+  });
+});
+
+describe('listMigrationGroups', () => {
+  test('lists groups', async () => {
+    const api = {
+      get: vi.fn().mockResolvedValue({
+        groups: [{ name: 'projects/p/locations/l/groups/wave-1', displayName: 'Wave 1' }],
+      }),
+      post: vi.fn(),
+    };
+
+    expect(await listMigrationGroups(api, 'p', 'l')).toEqual([
+      { id: 'wave-1', displayName: 'Wave 1', description: null },
+    ]);
+  });
+});
+
+describe('requiredCapacity', () => {
+  const vm = { name: 'vm', cpus: 8, memoryGb: 32, cpuPeakPercent: 25 };
+
+  test('keeps the allocation unless right-sizing', () => {
+    expect(requiredCapacity(vm, { rightSize: false, headroomPercent: 20 })).toEqual({
+      vcpus: 8,
+      memoryGb: 32,
+    });
+  });
+
+  test('sizes by peak utilization with headroom', () => {
+    expect(requiredCapacity(vm, { rightSize: true, headroomPercent: 20 })).toEqual({
+      vcpus: 3,
+      memoryGb: 32,
+    });
+  });
+});
+
+describe('recommendMachineType', () => {
+  test('picks the smallest predefined type that fits', () => {
+    expect(recommendMachineType({ vcpus: 4, memoryGb: 16 }, 'n2')).toEqual({
+      fit: 'predefined',
+      machineType: 'n2-standard-4',
+      vcpus: 4,
+      memoryGb: 16,
+    });
+    expect(recommendMachineType({ vcpus: 2, memoryGb: 14 }, 'e2')).toMatchObject({
+      machineType: 'e2-highmem-2',
+    });
+  });
+
+  test('picks a custom type if predefined types are much larger', () => {
+    expect(recommendMachineType({ vcpus: 6, memoryGb: 20 }, 'n2')).toEqual({
+      fit: 'custom',
+      machineType: 'n2-custom-6-20480',
+      vcpus: 6,
+      memoryGb: 20,
+    });
+  });
+
+  test('reports VMs that do not fit the family', () => {
+    expect(recommendMachineType({ vcpus: 64, memoryGb: 64 }, 'e2')).toEqual({
+      fit: 'no-fit',
+      machineType: null,
+      vcpus: null,
+      memoryGb: null,
+    });
+  });
+});
+
+describe('candidateMachineTypes', () => {
+  test('lists the smallest types of each family, smallest first', () => {
+    expect(
+      candidateMachineTypes({ vcpus: 4, memoryGb: 16 }, ['e2', 'n2']).map(
+        ({ machineType }) => machineType,
+      ),
+    ).toEqual(['e2-standard-4', 'n2-standard-4', 'e2-highmem-4', 'n2-highmem-4']);
+  });
+
+  test('includes a custom type of a different shape', () => {
+    expect(candidateMachineTypes({ vcpus: 6, memoryGb: 20 }, ['n2'], 1)).toEqual([
+      { family: 'n2', fit: 'custom', machineType: 'n2-custom-6-20480', vcpus: 6, memoryGb: 20 },
+      { family: 'n2', fit: 'predefined', machineType: 'n2-standard-8', vcpus: 8, memoryGb: 32 },
+    ]);
+  });
+});
+
+describe('buildFitReport', () => {
+  test('prices the recommended machine types', async () => {
+    const catalog: BillingCatalog = {
+      skus: vi
+        .fn()
+        .mockResolvedValue([
+          hourlySku('N2 Instance Core running in Americas', 30_000_000),
+          hourlySku('N2 Instance Ram running in Americas', 4_000_000),
+        ]),
+    };
+    const mockedGcloud = { lint: vi.fn(), invoke: vi.fn() } as unknown as gcloud.GcloudExecutable;
+
+    const report = await buildFitReport(
+      mockedGcloud,
+      catalog,
+      [
+        { name: 'app', cpus: 2, memoryGb: 8, diskGb: 50 },
+        { name: 'huge', cpus: 512, memoryGb: 4096 },
+      ],
+      {
+        region: 'us-central1',
+        family: 'n2',
+        commitment: 'on-demand',
+        rightSize: false,
+        headroomPercent: 20,
+      },
+    );
+
+    expect(report.summary).toEqual({ predefined: 1, custom: 0, 'no-fit': 1 });
+    expect(report.vms[0]).toMatchObject({ machineType: 'n2-standard-2', monthly: 67.16 });
+    expect(report.vms[0]?.warnings).toEqual([
+      'No price found for pd-balanced disks; it is not included in the estimate.',
+    ]);
+    expect(report.vms[1]?.warnings[0]).toContain('No n2 machine type provides 512 vCPUs');
+    expect(report.totalMonthly).toBe(67.16);
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,428 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { lastSegment } from './gcloud_json.js';
+import { GoogleApiClient } from './google_api.js';
+import { BillingCatalog } from './billing_catalog.js';
+import { ResourceSpec, estimateCosts } from './cost_estimate.js';
+
+export const MIGRATION_CENTER_URL = 'https://migrationcenter.googleapis.com/v1';
+
+// Fit reports price every VM, so they are capped to keep responses readable.
+export const MAX_FIT_VMS = 500;
+
+// Listings stop after this many assets.
+export const MAX_ASSETS = 2000;
+
+export const MACHINE_FAMILIES = ['e2', 'n2', 'n2d'] as const;
+
+export type MachineFamily = (typeof MACHINE_FAMILIES)[number];
+
+// The vCPU counts of the predefined machine types of each family.
+const FAMILY_VCPUS: Record<MachineFamily, number[]> = {
+  e2: [2, 4, 8, 16, 32],
+  n2: [2, 4, 8, 16, 32, 48, 64, 80, 96, 128],
+  n2d: [2, 4, 8, 16, 32, 48, 64, 80, 96, 128, 224],
+};
+
+// The memory per vCPU of each machine class, in GB.
+const CLASS_MEMORY_PER_VCPU = [
+  { name: 'highcpu', memoryPerVcpu: 1 },
+  { name: 'standard', memoryPerVcpu: 4 },
+  { name: 'highmem', memoryPerVcpu: 8 },
+] as const;
+
+// Custom machine types hold at most this much memory per vCPU without extended memory.
+const CUSTOM_MAX_MEMORY_PER_VCPU = 8;
+
+export interface SourceVm {
+  name: string;
+  cpus: number;
+  memoryGb: number;
+  diskGb?: number;
+  os?: string;
+  /** The peak CPU utilization observed by Migration Center, in percent. */
+  cpuPeakPercent?: number;
+  /** The peak memory utilization observed by Migration Center, in percent. */
+  memoryPeakPercent?: number;
+}
+
+export interface MigrationAsset extends SourceVm {
+  id: string;
+  groups: string[];
+}
+
+export interface MigrationGroup {
+  id: string;
+  displayName: string | null;
+  description: string | null;
+}
+
+export interface FitOptions {
+  region: string;
+  family: MachineFamily;
+  commitment: 'on-demand' | '1-year' | '3-year';
+  /** Sizes VMs by their peak utilization rather than their allocation. */
+  rightSize: boolean;
+  /** Extra capacity added on top of the peak utilization when right-sizing. */
+  headroomPercent: number;
+}
+
+export type FitStatus = 'predefined' | 'custom' | 'no-fit';
+
+export interface VmFit {
+  source: SourceVm;
+  /** The capacity the target must provide. */
+  required: { vcpus: number; memoryGb: number };
+  fit: FitStatus;
+  machineType: string | null;
+  vcpus: number | null;
+  memoryGb: number | null;
+  monthly: number | null;
+  warnings: string[];
+}
+
+export interface FitReport {
+  region: string;
+  family: MachineFamily;
+  commitment: FitOptions['commitment'];
+  currency: string;
+  totalMonthly: number;
+  summary: Record<FitStatus, number>;
+  vms: VmFit[];
+}
+
+const UsageSchema = z
+  .object({ utilizationPercentage: z.object({ peak: z.number().nullish() }).nullish() })
+  .nullish();
+
+const AggregationSchema = z.object({ cpu: UsageSchema, memory: UsageSchema });
+
+const AssetSchema = z.object({
+  name: z.string(),
+  assignedGroups: z.array(z.string()).nullish(),
+  machineDetails: z
+    .object({
+      machineName: z.string().nullish(),
+      coreCount: z.number().nullish(),
+      memoryMb: z.number().nullish(),
+      guestOs: z.object({ family: z.string().nullish(), osName: z.string().nullish() }).nullish(),
+      disks: z.object({ totalCapacityBytes: z.string().nullish() }).nullish(),
+    })
+    .nullish(),
+  performanceData: z
+    .object({
+      dailyResourceUsageAggregations: z.array(AggregationSchema).nullish(),
+    })
+    .nullish(),
+});
+
+type Asset = z.infer<typeof AssetSchema>;
+
+const AssetPageSchema = z.object({
+  assets: z.array(AssetSchema).default([]),
+  nextPageToken: z.string().nullish(),
+});
+
+const GroupPageSchema = z.object({
+  groups: z
+    .array(
+      z.object({
+        name: z.string(),
+        displayName: z.string().nullish(),
+        description: z.string().nullish(),
+      }),
+    )
+    .default([]),
+  nextPageToken: z.string().nullish(),
+});
+
+const locationUrl = (project: string, location: string) =>
+  `${MIGRATION_CENTER_URL}/projects/${project}/locations/${location}`;
+
+const peakOf = (aggregations: Array<z.infer<typeof AggregationSchema>>, key: 'cpu' | 'memory') => {
+  const peaks = aggregations
+    .map((aggregation) => aggregation[key]?.utilizationPercentage?.peak)
+    .filter((peak): peak is number => typeof peak === 'number');
+  return peaks.length > 0 ? Math.max(...peaks) : undefined;
+};
+
+const assetOf = (asset: Asset): MigrationAsset | undefined => {
+  const details = asset.machineDetails;
+  if (!details?.coreCount || !details.memoryMb) {
+    return undefined;
+  }
+  const aggregations = asset.performanceData?.dailyResourceUsageAggregations ?? [];
+  const diskBytes = Number(details.disks?.totalCapacityBytes ?? 0);
+  const os = details.guestOs?.osName ?? details.guestOs?.family;
+  const cpuPeakPercent = peakOf(aggregations, 'cpu');
+  const memoryPeakPercent = peakOf(aggregations, 'memory');
+  return {
+    id: lastSegment(asset.name),
+    name: details.machineName ?? lastSegment(asset.name),
+    cpus: details.coreCount,
+    memoryGb: Math.round((details.memoryMb / 1024) * 100) / 100,
+    ...(diskBytes > 0 && { diskGb: Math.ceil(diskBytes / 1024 ** 3) }),
+    ...(os && { os }),
+    ...(cpuPeakPercent !== undefined && { cpuPeakPercent }),
+    ...(memoryPeakPercent !== undefined && { memoryPeakPercent }),
+    groups: (asset.assignedGroups ?? []).map(lastSegment),
+  };
+};
+
+/**
+ * Lists the machine assets discovered by Migration Center, optionally only
+ * those of a group. Assets without CPU and memory details are left out.
+ */
+export const listMigrationAssets = async (
+  api: GoogleApiClient,
+  project: string,
+  location: string,
+  group?: string,
+): Promise<{ assets: MigrationAsset[]; truncated: boolean }> => {
+  const assets: MigrationAsset[] = [];
+  let pageToken: string | undefined;
+  do {
+    const params = new URLSearchParams({ view: 'ASSET_VIEW_FULL', pageSize: '500' });
+    if (pageToken) {
+      params.set('pageToken', pageToken);
+    }
+    const page = AssetPageSchema.parse(
+      await api.get(`${locationUrl(project, location)}/assets?${params}`),
+    );
+    for (const asset of page.assets.map(assetOf)) {
+      if (asset && (!group || asset.groups.includes(group))) {
+        assets.push(asset);
+      }
+    }
+    pageToken = page.nextPageToken || undefined;
+  } while (pageToken && assets.length < MAX_ASSETS);
+  return { assets: assets.slice(0, MAX_ASSETS), truncated: !!pageToken };
+};
+
+export const listMigrationGroups = async (
+  api: GoogleApiClient,
+  project: string,
+  location: string,
+): Promise<MigrationGroup[]> => {
+  const groups: MigrationGroup[] = [];
+  let pageToken: string | undefined;
+  do {
+    const params = new URLSearchParams({ pageSize: '500' });
+    if (pageToken) {
+      params.set('pageToken', pageToken);
+    }
+    const page = GroupPageSchema.parse(
+      await api.get(`${locationUrl(project, location)}/groups?${params}`),
+    );
+    groups.push(
+      ...page.groups.map(({ name, displayName, description }) => ({
+        id: lastSegment(name),
+        displayName: displayName ?? null,
+        description: description ?? null,
+      })),
+    );
+    pageToken = page.nextPageToken || undefined;
+  } while (pageToken);
+  return groups;
+};
+
+/** Returns the capacity a VM needs on Google Cloud, right-sized by its peak usage if asked to. */
+export const requiredCapacity = (
+  vm: SourceVm,
+  options: Pick<FitOptions, 'rightSize' | 'headroomPercent'>,
+) => {
+  if (!options.rightSize) {
+    return { vcpus: vm.cpus, memoryGb: vm.memoryGb };
+  }
+  const scale = (value: number, peakPercent: number | undefined) =>
+    peakPercent === undefined
+      ? value
+      : Math.min(value, (value * peakPercent * (100 + options.headroomPercent)) / 10000);
+  return {
+    vcpus: Math.max(1, Math.ceil(scale(vm.cpus, vm.cpuPeakPercent))),
+    memoryGb: Math.max(1, Math.ceil(scale(vm.memoryGb, vm.memoryPeakPercent))),
+  };
+};
+
+// Ranks machine shapes by their rough cost: a vCPU costs about as much as 4 GB of memory.
+const sizeOf = ({ vcpus, memoryGb }: { vcpus: number; memoryGb: number }) => vcpus + memoryGb / 4;
+
+export interface MachineShape {
+  machineType: string;
+  vcpus: number;
+  memoryGb: number;
+}
+
+/** Returns the predefined machine types of a family that provide the required capacity. */
+const fittingPredefinedTypes = (
+  required: { vcpus: number; memoryGb: number },
+  family: MachineFamily,
+): MachineShape[] =>
+  FAMILY_VCPUS[family]
+    .flatMap((vcpus) =>
+      CLASS_MEMORY_PER_VCPU.map(({ name, memoryPerVcpu }) => ({
+        machineType: `${family}-${name}-${vcpus}`,
+        vcpus,
+        memoryGb: vcpus * memoryPerVcpu,
+      })),
+    )
+    .filter(({ vcpus, memoryGb }) => vcpus >= required.vcpus && memoryGb >= required.memoryGb)
+    .sort((a, b) => sizeOf(a) - sizeOf(b));
+
+/** Returns the smallest custom machine type of a family that provides the capacity, if any. */
+const fittingCustomType = (
+  required: { vcpus: number; memoryGb: number },
+  family: MachineFamily,
+): MachineShape | undefined => {
+  // Custom types have an even number of vCPUs and memory in multiples of 256 MB.
+  const vcpus = Math.max(2, required.vcpus + (required.vcpus % 2));
+  const memoryMb = Math.max(vcpus * 512, Math.ceil((required.memoryGb * 1024) / 256) * 256);
+  const custom = {
+    machineType: `${family}-custom-${vcpus}-${memoryMb}`,
+    vcpus,
+    memoryGb: memoryMb / 1024,
+  };
+  const fits =
+    vcpus <= (FAMILY_VCPUS[family].at(-1) ?? 0) &&
+    custom.memoryGb <= vcpus * CUSTOM_MAX_MEMORY_PER_VCPU;
+  return fits ? custom : undefined;
+};
+
+/**
+ * Picks the smallest machine type of a family that provides the required
+ * capacity. A custom type is picked if no predefined type fits, or if every
+ * predefined type that fits is more than a quarter larger.
+ */
+export const recommendMachineType = (
+  required: { vcpus: number; memoryGb: number },
+  family: MachineFamily,
+): Pick<VmFit, 'fit' | 'machineType' | 'vcpus' | 'memoryGb'> => {
+  const predefined = fittingPredefinedTypes(required, family)[0];
+  const custom = fittingCustomType(required, family);
+  if (custom && (!predefined || sizeOf(predefined) > sizeOf(custom) * 1.25)) {
+    return { fit: 'custom', ...custom };
+  }
+  if (predefined) {
+    return { fit: 'predefined', ...predefined };
+  }
+  return { fit: 'no-fit', machineType: null, vcpus: null, memoryGb: null };
+};
+
+export interface MachineTypeCandidate extends MachineShape {
+  family: MachineFamily;
+  fit: Exclude<FitStatus, 'no-fit'>;
+}
+
+/**
+ * Lists the machine types that provide the required capacity: the smallest
+ * predefined types of each family and, if it differs, its custom type. The
+ * smallest types come first.
+ */
+export const candidateMachineTypes = (
+  required: { vcpus: number; memoryGb: number },
+  families: readonly MachineFamily[] = MACHINE_FAMILIES,
+  perFamily = 2,
+): MachineTypeCandidate[] =>
+  families
+    .flatMap((family) => {
+      const predefined = fittingPredefinedTypes(required, family).slice(0, perFamily);
+      // A custom type of the same shape as a predefined one only costs more.
+      const custom = fittingCustomType(required, family);
+      const sameShape = (type: MachineShape) =>
+        type.vcpus === custom?.vcpus && type.memoryGb === custom?.memoryGb;
+      return [
+        ...predefined.map((type) => ({ ...type, family, fit: 'predefined' as const })),
+        ...(custom && !predefined.some(sameShape)
+          ? [{ ...custom, family, fit: 'custom' as const }]
+          : []),
+      ];
+    })
+    .sort((a, b) => sizeOf(a) - sizeOf(b));
+
+const specsOf = (fit: VmFit, options: FitOptions): ResourceSpec[] => {
+  if (!fit.machineType || !fit.vcpus || !fit.memoryGb) {
+    return [];
+  }
+  const { region, commitment } = options;
+  return [
+    {
+      type: 'compute-instance',
+      name: fit.source.name,
+      region,
+      machineType: fit.machineType,
+      vcpus: fit.vcpus,
+      memoryGb: fit.memoryGb,
+      commitment,
+      count: 1,
+    },
+    ...(fit.source.diskGb
+      ? [
+          {
+            type: 'disk' as const,
+            name: fit.source.name,
+            region,
+            diskType: 'pd-balanced' as const,
+            sizeGb: fit.source.diskGb,
+            count: 1,
+          },
+        ]
+      : []),
+  ];
+};
+
+/** Maps source VMs to machine types and prices them at list prices. */
+export const buildFitReport = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  vms: SourceVm[],
+  options: FitOptions,
+): Promise<FitReport> => {
+  const fits = await Promise.all(
+    vms.map(async (source): Promise<VmFit> => {
+      const required = requiredCapacity(source, options);
+      const recommended = recommendMachineType(required, options.family);
+      const fit: VmFit = { source, required, ...recommended, monthly: null, warnings: [] };
+      if (recommended.fit === 'no-fit') {
+        fit.warnings.push(
+          `No ${options.family} machine type provides ${required.vcpus} vCPUs and ${required.memoryGb} GB.`,
+        );
+        return fit;
+      }
+      // The catalog is cached, so pricing VMs one at a time costs no extra requests.
+      const estimate = await estimateCosts(gcloud, catalog, specsOf(fit, options));
+      fit.monthly = estimate.totalMonthly;
+      fit.warnings.push(...estimate.resources.flatMap(({ warnings }) => warnings));
+      return fit;
+    }),
+  );
+  const summary: Record<FitStatus, number> = { predefined: 0, custom: 0, 'no-fit': 0 };
+  for (const { fit } of fits) {
+    summary[fit] += 1;
+  }
+  const total = fits.reduce((sum, { monthly }) => sum + (monthly ?? 0), 0);
+  return {
+    region: options.region,
+    family: options.family,
+    commitment: options.commitment,
+    currency: 'USD',
+    totalMonthly: Math.round(total * 100) / 100,
+    summary,
+    vms: fits,
+  };
+};
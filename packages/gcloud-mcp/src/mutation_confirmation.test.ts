@@ -0,0 +1,64 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { describe, expect, test, vi } from 'vitest';
+import { createMutationConfirmer } from './mutation_confirmation.js';
+
+const serverWith = (elicitation: object | undefined, elicitInput = vi.fn()) =>
+  ({
+    server: {
+      getClientCapabilities: () => (elicitation ? { elicitation } : {}),
+      elicitInput,
+    },
+  }) as unknown as McpServer;
+
+describe('createMutationConfirmer', () => {
+  test('asks the user with an elicitation request', async () => {
+    const elicitInput = vi.fn().mockResolvedValue({ action: 'accept', content: { confirm: true } });
+    const confirm = createMutationConfirmer(serverWith({}, elicitInput));
+
+    expect(await confirm('gcloud compute instances delete vm')).toBe('confirmed');
+    expect(elicitInput).toHaveBeenCalledWith(
+      expect.objectContaining({
+        message: expect.stringContaining('gcloud compute instances delete vm'),
+      }),
+    );
+  });
+
+  test('treats unchecked, declined and cancelled requests as declined', async () => {
+    const elicitInput = vi
+      .fn()
+      .mockResolvedValueOnce({ action: 'accept', content: { confirm: false } })
+      .mockResolvedValueOnce({ action: 'decline' })
+      .mockResolvedValueOnce({ action: 'cancel' });
+    const confirm = createMutationConfirmer(serverWith({}, elicitInput));
+
+    for (let i = 0; i < 3; i++) {
+      expect(await confirm('gcloud sql instances delete db')).toBe('declined');
+    }
+  });
+
+  test('is unavailable if the client does not support elicitation or fails', async () => {
+    expect(await createMutationConfirmer(serverWith(undefined))('gcloud x delete')).toBe(
+      'unavailable',
+    );
+    const failing = vi.fn().mockRejectedValue(new Error('timeout'));
+    expect(await createMutationConfirmer(serverWith({}, failing))('gcloud x delete')).toBe(
+      'unavailable',
+    );
+  });
+});
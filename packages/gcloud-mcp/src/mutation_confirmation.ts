@@ -0,0 +1,58 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { log } from './utility/logger.js';
+
+/**
+ * `confirmed` if the user approved the command, `declined` if they rejected or
+ * dismissed the request, and `unavailable` if the client can not ask them.
+ */
+export type MutationConfirmation = 'confirmed' | 'declined' | 'unavailable';
+
+/** Asks the user to approve a command that changes state. */
+export type MutationConfirmer = (command: string) => Promise<MutationConfirmation>;
+
+/** Creates a confirmer that asks the user of the server's client with an elicitation request. */
+export const createMutationConfirmer =
+  (server: McpServer): MutationConfirmer =>
+  async (command) => {
+    if (!server.server.getClientCapabilities()?.elicitation) {
+      return 'unavailable';
+    }
+    try {
+      const result = await server.server.elicitInput({
+        message: `The assistant wants to run a command that changes state:\n\n${command}\n\nRun it?`,
+        requestedSchema: {
+          type: 'object',
+          properties: {
+            confirm: {
+              type: 'boolean',
+              title: 'Run this command',
+              description: 'Select to run the command.',
+            },
+          },
+          required: ['confirm'],
+        },
+      });
+      return result.action === 'accept' && result.content?.['confirm'] === true
+        ? 'confirmed'
+        : 'declined';
+    } catch (e: unknown) {
+      log.warn('Unable to ask the user to confirm a command', { error: String(e) });
+      return 'unavailable';
+    }
+  };
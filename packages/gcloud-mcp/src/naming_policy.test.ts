@@ -0,0 +1,136 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import { createNamingPolicy, proposedResourceOf, validateNamingPolicy } from './naming_policy.js';
+
+const policy = createNamingPolicy({
+  namePatterns: {
+    '*': '^[a-z][a-z0-9-]*$',
+    'compute instances': '^(dev|prod)-',
+  },
+  requiredLabels: { '*': ['team'], 'compute instances': ['env'] },
+  labelValuePatterns: { env: '^(dev|prod)$' },
+  enforce: true,
+});
+
+describe('proposedResourceOf', () => {
+  test('returns the names and labels of a create command', () => {
+    expect(
+      proposedResourceOf('compute instances create', [
+        'compute',
+        'instances',
+        'create',
+        'vm-1',
+        'vm-2',
+        '--zone=us-central1-a',
+        '--labels=team=data,env=dev',
+      ]),
+    ).toEqual({
+      resourceType: 'compute instances',
+      names: ['vm-1', 'vm-2'],
+      labels: { team: 'data', env: 'dev' },
+    });
+  });
+
+  test('ignores the release track and reads a separate labels value', () => {
+    expect(
+      proposedResourceOf('beta storage buckets create', [
+        'beta',
+        'storage',
+        'buckets',
+        'create',
+        'gs://logs',
+        '--labels',
+        'team=ops',
+      ]),
+    ).toEqual({ resourceType: 'storage buckets', names: ['gs://logs'], labels: { team: 'ops' } });
+  });
+
+  test('returns null for commands other than create', () => {
+    expect(
+      proposedResourceOf('compute instances delete', ['compute', 'instances', 'delete', 'vm']),
+    ).toBeNull();
+  });
+});
+
+describe('createNamingPolicy', () => {
+  test('accepts a compliant proposal', () => {
+    expect(
+      policy.validate({
+        resourceType: 'compute instances',
+        names: ['dev-web-1'],
+        labels: { team: 'web', env: 'dev' },
+      }),
+    ).toEqual([]);
+  });
+
+  test('reports names that do not match the wildcard or resource pattern', () => {
+    const violations = policy.validate({
+      resourceType: 'compute instances',
+      names: ['Web_1'],
+      labels: { team: 'web', env: 'dev' },
+    });
+
+    expect(violations.map((v) => v.kind)).toEqual(['name', 'name']);
+    expect(violations[0]?.subject).toBe('Web_1');
+  });
+
+  test('reports missing labels and invalid label values', () => {
+    const violations = policy.validate({
+      resourceType: 'compute instances',
+      names: ['dev-web-1'],
+      labels: { env: 'qa' },
+    });
+
+    expect(violations).toEqual([
+      expect.objectContaining({ kind: 'missing-label', subject: 'team' }),
+      expect.objectContaining({ kind: 'label-value', subject: 'env' }),
+    ]);
+  });
+
+  test('only applies wildcard rules to other resource types', () => {
+    const violations = policy.validate({
+      resourceType: 'pubsub topics',
+      names: ['events'],
+      labels: {},
+    });
+
+    expect(violations).toEqual([
+      expect.objectContaining({ kind: 'missing-label', subject: 'team' }),
+    ]);
+  });
+
+  test('returns the required labels of a resource type', () => {
+    expect(policy.requiredLabels('compute instances')).toEqual(['team', 'env']);
+    expect(policy.requiredLabels('pubsub topics')).toEqual(['team']);
+    expect(createNamingPolicy().requiredLabels('pubsub topics')).toEqual([]);
+  });
+
+  test('is not enforced by default', () => {
+    expect(createNamingPolicy().enforced()).toBe(false);
+    expect(policy.enforced()).toBe(true);
+  });
+});
+
+describe('validateNamingPolicy', () => {
+  test('rejects invalid patterns', () => {
+    expect(validateNamingPolicy({ namePatterns: { '*': '([a-z' } })).toContain(
+      'Invalid naming policy pattern for "*"',
+    );
+    expect(validateNamingPolicy({ labelValuePatterns: { env: '^dev$' } })).toBeUndefined();
+  });
+});
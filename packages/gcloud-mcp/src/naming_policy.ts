@@ -0,0 +1,153 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { parseReleaseTrack } from './suggest.js';
+
+// Policy maps are keyed by resource type, the command group without the verb or
+// release track, or by `*` to apply to every resource type.
+export interface NamingPolicyConfig {
+  /** Patterns resource names must match, keyed by resource type (e.g. `compute instances`). */
+  namePatterns?: Record<string, string>;
+  /** Labels that must be set on new resources. */
+  requiredLabels?: Record<string, string[]>;
+  /** Patterns label values must match, keyed by label. */
+  labelValuePatterns?: Record<string, string>;
+  /** Whether run_gcloud_command blocks create commands that violate the policy. */
+  enforce?: boolean;
+}
+
+export interface ProposedResource {
+  /** The command group of the resource, e.g. `compute instances`. */
+  resourceType: string;
+  names: string[];
+  labels: Record<string, string>;
+}
+
+export interface NamingViolation {
+  kind: 'name' | 'missing-label' | 'label-value';
+  subject: string;
+  message: string;
+}
+
+export type NamingPolicy = ReturnType<typeof createNamingPolicy>;
+
+const WILDCARD = '*';
+
+/** Returns an error message if a pattern in the policy is not a valid regular expression. */
+export const validateNamingPolicy = (policy: NamingPolicyConfig): string | undefined => {
+  const patterns = { ...policy.namePatterns, ...policy.labelValuePatterns };
+  for (const [key, pattern] of Object.entries(patterns)) {
+    try {
+      new RegExp(pattern);
+    } catch {
+      return `Invalid naming policy pattern for "${key}": ${pattern}`;
+    }
+  }
+  return undefined;
+};
+
+/** Parses a `KEY=VALUE,...` flag value. */
+const parseLabels = (value: string): Record<string, string> =>
+  Object.fromEntries(
+    value
+      .split(',')
+      .filter((pair) => pair.length > 0)
+      .map((pair) => {
+        const [key, ...rest] = pair.split('=');
+        return [key ?? '', rest.join('=')];
+      }),
+  );
+
+/**
+ * Returns the resource a create command would make, or null for other commands.
+ *
+ * Resource names are the positionals that directly follow the verb.
+ */
+export const proposedResourceOf = (
+  parsedCommand: string,
+  args: string[],
+): ProposedResource | null => {
+  const releaseTrack = parseReleaseTrack(parsedCommand);
+  const path = (releaseTrack ? parsedCommand.slice(releaseTrack.length + 1) : parsedCommand).split(
+    ' ',
+  );
+  if (path.pop() !== 'create') {
+    return null;
+  }
+  const verbIndex = args.indexOf('create');
+  const names: string[] = [];
+  for (const arg of args.slice(verbIndex + 1)) {
+    if (arg.startsWith('-')) {
+      break;
+    }
+    names.push(arg);
+  }
+  let labels: Record<string, string> = {};
+  args.forEach((arg, i) => {
+    const next = args[i + 1];
+    if (arg.startsWith('--labels=')) {
+      labels = parseLabels(arg.slice('--labels='.length));
+    } else if (arg === '--labels' && next) {
+      labels = parseLabels(next);
+    }
+  });
+  return { resourceType: path.join(' '), names, labels };
+};
+
+/** Creates a validator for the organization's resource naming and label conventions. */
+export const createNamingPolicy = (policy: NamingPolicyConfig = {}) => {
+  const forType = <T>(values: Record<string, T> | undefined, resourceType: string) =>
+    [values?.[WILDCARD], values?.[resourceType]].filter((value): value is T => value !== undefined);
+
+  return {
+    enforced: () => policy.enforce === true,
+    requiredLabels: (resourceType: string): string[] => [
+      ...new Set(forType(policy.requiredLabels, resourceType).flat()),
+    ],
+    validate: ({ resourceType, names, labels }: ProposedResource): NamingViolation[] => {
+      const violations: NamingViolation[] = [];
+      for (const pattern of forType(policy.namePatterns, resourceType)) {
+        for (const name of names.filter((name) => !new RegExp(pattern).test(name))) {
+          violations.push({
+            kind: 'name',
+            subject: name,
+            message: `Name "${name}" does not match the pattern ${pattern} for ${resourceType}.`,
+          });
+        }
+      }
+      for (const label of forType(policy.requiredLabels, resourceType).flat()) {
+        if (!(label in labels)) {
+          violations.push({
+            kind: 'missing-label',
+            subject: label,
+            message: `Label "${label}" is required for ${resourceType}.`,
+          });
+        }
+      }
+      for (const [label, value] of Object.entries(labels)) {
+        const pattern = policy.labelValuePatterns?.[label];
+        if (pattern && !new RegExp(pattern).test(value)) {
+          violations.push({
+            kind: 'label-value',
+            subject: label,
+            message: `Value "${value}" of label "${label}" does not match the pattern ${pattern}.`,
+          });
+        }
+      }
+      return violations;
+    },
+  };
+};
@@ -0,0 +1,90 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import {
+  MAX_OUTPUTS_KEPT,
+  chunkBlock,
+  createOutputChunks,
+  splitIntoChunks,
+  validateOutputChunkConfig,
+} from './output_chunks.js';
+
+describe('splitIntoChunks', () => {
+  test('splits text into chunks of at most the given size', () => {
+    expect(splitIntoChunks('abcdefg', 3)).toEqual(['abc', 'def', 'g']);
+  });
+
+  test('does not split multi-byte characters', () => {
+    const chunks = splitIntoChunks('aé€b', 3);
+    expect(chunks.join('')).toBe('aé€b');
+    expect(chunks).toEqual(['aé', '€', 'b']);
+  });
+});
+
+describe('createOutputChunks', () => {
+  test('does not chunk output that fits', () => {
+    expect(createOutputChunks({ chunkKb: 1 }).store('a'.repeat(1024))).toBeUndefined();
+  });
+
+  test('returns the first chunk with the total size and keeps the rest', () => {
+    const outputChunks = createOutputChunks({ chunkKb: 1 });
+    const text = 'a'.repeat(1024) + 'b'.repeat(1024) + 'c';
+
+    const first = outputChunks.store(text);
+
+    expect(first).toEqual({
+      token: 'output-1',
+      chunk: 0,
+      chunks: 3,
+      totalBytes: 2049,
+      text: 'a'.repeat(1024),
+    });
+    expect(outputChunks.get('output-1', 2)).toMatchObject({ chunk: 2, text: 'c' });
+    expect(outputChunks.get('output-1', 3)).toBeUndefined();
+    expect(outputChunks.get('output-2', 0)).toBeUndefined();
+  });
+
+  test('drops the oldest outputs', () => {
+    const outputChunks = createOutputChunks({ chunkKb: 1 });
+    for (let i = 0; i <= MAX_OUTPUTS_KEPT; i++) {
+      outputChunks.store('a'.repeat(2048));
+    }
+    expect(outputChunks.get('output-1', 1)).toBeUndefined();
+    expect(outputChunks.get(`output-${MAX_OUTPUTS_KEPT + 1}`, 1)).toBeDefined();
+  });
+});
+
+describe('chunkBlock', () => {
+  test('includes the next chunk until the last one', () => {
+    const metadata = { token: 'output-1', chunks: 2, totalBytes: 100 };
+    const block = chunkBlock({ ...metadata, chunk: 0 });
+    expect(block.startsWith('\nOUTPUT CHUNK:\n')).toBe(true);
+    expect(JSON.parse(block.slice('\nOUTPUT CHUNK:\n'.length))).toEqual({
+      ...metadata,
+      chunk: 0,
+      nextChunk: 1,
+    });
+    expect(chunkBlock({ ...metadata, chunk: 1 })).not.toContain('nextChunk');
+  });
+});
+
+describe('validateOutputChunkConfig', () => {
+  test('rejects chunk sizes that are not positive integers', () => {
+    expect(validateOutputChunkConfig({ chunkKb: -1 })).toContain('must be a positive integer');
+    expect(validateOutputChunkConfig({})).toBe(undefined);
+  });
+});
@@ -0,0 +1,118 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Outputs larger than this are returned in chunks of this size by default.
+export const DEFAULT_CHUNK_KB = 64;
+
+// The session keeps the chunks of this many outputs, dropping the oldest first.
+export const MAX_OUTPUTS_KEPT = 20;
+
+export interface OutputChunkConfig {
+  /** The size of each chunk in KB. */
+  chunkKb?: number;
+}
+
+/** Where a chunk is in the output it belongs to. */
+export interface ChunkMetadata {
+  token: string;
+  /** The zero-based index of the chunk. */
+  chunk: number;
+  chunks: number;
+  totalBytes: number;
+}
+
+export interface OutputChunk extends ChunkMetadata {
+  text: string;
+}
+
+export type OutputChunks = ReturnType<typeof createOutputChunks>;
+
+/** Returns an error message if the chunk configuration is invalid. */
+export const validateOutputChunkConfig = (config: OutputChunkConfig): string | undefined => {
+  const { chunkKb } = config;
+  if (chunkKb !== undefined && !(Number.isInteger(chunkKb) && chunkKb > 0)) {
+    return `Invalid output chunk size ${chunkKb}: "chunkKb" must be a positive integer.`;
+  }
+  return undefined;
+};
+
+/**
+ * Splits text into chunks of at most `chunkBytes` UTF-8 bytes. Chunks never
+ * end inside a multi-byte character.
+ */
+export const splitIntoChunks = (text: string, chunkBytes: number): string[] => {
+  const bytes = Buffer.from(text, 'utf-8');
+  const chunks: string[] = [];
+  let start = 0;
+  while (start < bytes.length) {
+    let end = Math.min(start + chunkBytes, bytes.length);
+    // Continuation bytes of a multi-byte character are 0b10xxxxxx.
+    while (end < bytes.length && end > start + 1 && ((bytes[end] ?? 0) & 0xc0) === 0x80) {
+      end--;
+    }
+    chunks.push(bytes.subarray(start, end).toString('utf-8'));
+    start = end;
+  }
+  return chunks;
+};
+
+/** Describes how to retrieve the rest of a chunked output. */
+export const chunkBlock = ({ token, chunk, chunks, totalBytes }: ChunkMetadata) => {
+  const metadata = {
+    token,
+    chunk,
+    chunks,
+    totalBytes,
+    ...(chunk + 1 < chunks && { nextChunk: chunk + 1 }),
+  };
+  return `\nOUTPUT CHUNK:\n${JSON.stringify(metadata, null, 2)}`;
+};
+
+/** Creates the per-session store of outputs that are too large to return at once. */
+export const createOutputChunks = (config: OutputChunkConfig = {}) => {
+  const chunkBytes = (config.chunkKb ?? DEFAULT_CHUNK_KB) * 1024;
+  const outputs = new Map<string, { chunks: string[]; totalBytes: number }>();
+  let count = 0;
+
+  const chunkOf = (token: string, chunk: number): OutputChunk | undefined => {
+    const output = outputs.get(token);
+    const text = output?.chunks[chunk];
+    if (!output || text === undefined) {
+      return undefined;
+    }
+    return { token, chunk, chunks: output.chunks.length, totalBytes: output.totalBytes, text };
+  };
+
+  return {
+    /**
+     * Returns the first chunk of an output that is larger than the chunk size
+     * and keeps the rest, or undefined if the output fits in one chunk.
+     */
+    store: (text: string): OutputChunk | undefined => {
+      const totalBytes = Buffer.byteLength(text, 'utf-8');
+      if (totalBytes <= chunkBytes) {
+        return undefined;
+      }
+      const token = `output-${++count}`;
+      outputs.set(token, { chunks: splitIntoChunks(text, chunkBytes), totalBytes });
+      while (outputs.size > MAX_OUTPUTS_KEPT) {
+        outputs.delete(outputs.keys().next().value ?? '');
+      }
+      return chunkOf(token, 0);
+    },
+    get: chunkOf,
+  };
+};
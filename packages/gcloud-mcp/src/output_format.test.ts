@@ -0,0 +1,87 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import { formatOutput, valueAt, withJsonFormat } from './output_format.js';
+
+const INSTANCES = [
+  {
+    name: 'web-1',
+    status: 'RUNNING',
+    networkInterfaces: [{ networkIP: '10.0.0.2' }],
+    labels: { team: 'web' },
+  },
+  { name: 'web|2', status: 'TERMINATED' },
+];
+
+describe('formatOutput', () => {
+  test('converts results into a Markdown table of the top-level fields', () => {
+    expect(formatOutput(INSTANCES, { format: 'markdown' })).toBe(
+      [
+        '| name | status | networkInterfaces | labels |',
+        '| --- | --- | --- | --- |',
+        '| web-1 | RUNNING | [{"networkIP":"10.0.0.2"}] | {"team":"web"} |',
+        '| web\\|2 | TERMINATED |  |  |',
+      ].join('\n'),
+    );
+  });
+
+  test('converts selected columns into CSV', () => {
+    expect(
+      formatOutput(INSTANCES, {
+        format: 'csv',
+        columns: ['name', 'networkInterfaces.0.networkIP', 'labels'],
+      }),
+    ).toBe(
+      [
+        'name,networkInterfaces.0.networkIP,labels',
+        'web-1,10.0.0.2,"{""team"":""web""}"',
+        'web|2,,',
+      ].join('\n'),
+    );
+  });
+
+  test('treats an object as a single row and scalars as values', () => {
+    expect(formatOutput({ name: 'p' }, { format: 'csv' })).toBe('name\np');
+    expect(formatOutput(['a', 'b'], { format: 'csv' })).toBe('value\na\nb');
+  });
+});
+
+describe('valueAt', () => {
+  test('returns undefined for missing paths', () => {
+    expect(valueAt(INSTANCES[0], 'labels.team')).toBe('web');
+    expect(valueAt(INSTANCES[0], 'labels.env')).toBeUndefined();
+    expect(valueAt(INSTANCES[1], 'networkInterfaces.0.networkIP')).toBeUndefined();
+  });
+});
+
+describe('withJsonFormat', () => {
+  test('adds JSON output and keeps JSON projections', () => {
+    expect(withJsonFormat(['compute', 'instances', 'list'])).toEqual([
+      'compute',
+      'instances',
+      'list',
+      '--format=json',
+    ]);
+    expect(withJsonFormat(['compute', 'instances', 'list', '--format=json(name)'])).toEqual([
+      'compute',
+      'instances',
+      'list',
+      '--format=json(name)',
+    ]);
+    expect(withJsonFormat(['compute', 'instances', 'list', '--format', 'table(name)'])).toBeNull();
+  });
+});
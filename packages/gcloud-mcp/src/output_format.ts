@@ -0,0 +1,113 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+
+export const OutputFormatSchema = z.object({
+  format: z.enum(['markdown', 'csv']),
+  columns: z
+    .array(z.string())
+    .optional()
+    .describe(
+      'The columns to include, as dotted paths such as "name" or "networkInterfaces.0.networkIP". Defaults to the top-level fields.',
+    ),
+  asResource: z
+    .boolean()
+    .optional()
+    .describe('Return the table as an embedded resource instead of inline text.'),
+});
+
+export type OutputFormat = z.infer<typeof OutputFormatSchema>;
+
+export const MIME_TYPES: Record<OutputFormat['format'], string> = {
+  markdown: 'text/markdown',
+  csv: 'text/csv',
+};
+
+type Row = Record<string, unknown>;
+
+/** Returns the value at a dotted path, e.g. `disks.0.source`, or undefined. */
+export const valueAt = (value: unknown, path: string): unknown =>
+  path.split('.').reduce<unknown>((current, key) => {
+    if (current === null || typeof current !== 'object') {
+      return undefined;
+    }
+    return (current as Record<string, unknown>)[key];
+  }, value);
+
+/** Returns the rows of a JSON result: each element of an array, or a single object. */
+export const rowsOf = (data: unknown): Row[] => {
+  const items = Array.isArray(data) ? data : [data];
+  return items.map((item) =>
+    item !== null && typeof item === 'object' && !Array.isArray(item)
+      ? (item as Row)
+      : { value: item },
+  );
+};
+
+/** Returns the top-level fields of the rows, in order of first appearance. */
+export const columnsOf = (rows: Row[]): string[] => [
+  ...new Set(rows.flatMap((row) => Object.keys(row))),
+];
+
+const cellText = (value: unknown): string => {
+  if (value === undefined || value === null) {
+    return '';
+  }
+  return typeof value === 'object' ? JSON.stringify(value) : String(value);
+};
+
+export const toMarkdown = (rows: Row[], columns: string[]): string => {
+  const escape = (text: string) => text.replace(/\|/g, '\\|').replace(/\r?\n/g, '<br>');
+  const line = (cells: string[]) => `| ${cells.join(' | ')} |`;
+  return [
+    line(columns.map(escape)),
+    line(columns.map(() => '---')),
+    ...rows.map((row) => line(columns.map((column) => escape(cellText(valueAt(row, column)))))),
+  ].join('\n');
+};
+
+export const toCsv = (rows: Row[], columns: string[]): string => {
+  // Fields are quoted as described in RFC 4180.
+  const quote = (text: string) => (/[",\r\n]/.test(text) ? `"${text.replace(/"/g, '""')}"` : text);
+  return [
+    columns.map(quote).join(','),
+    ...rows.map((row) =>
+      columns.map((column) => quote(cellText(valueAt(row, column)))).join(','),
+    ),
+  ].join('\n');
+};
+
+/** Converts a JSON result into a Markdown table or CSV. */
+export const formatOutput = (data: unknown, { format, columns }: OutputFormat): string => {
+  const rows = rowsOf(data);
+  const selected = columns && columns.length > 0 ? columns : columnsOf(rows);
+  return format === 'markdown' ? toMarkdown(rows, selected) : toCsv(rows, selected);
+};
+
+/**
+ * Returns the arguments with JSON output, keeping any projection, or null if
+ * the command asks for another format.
+ */
+export const withJsonFormat = (args: string[]): string[] | null => {
+  const index = args.findIndex((arg) => arg === '--format' || arg.startsWith('--format='));
+  if (index === -1) {
+    return [...args, '--format=json'];
+  }
+  const flag = args[index] ?? '';
+  const value = flag === '--format' ? args[index + 1] : flag.slice('--format='.length);
+  return value?.startsWith('json') ? args : null;
+};
@@ -0,0 +1,103 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test } from 'vitest';
+import { Profile, Profiles, createProfiles, validateProfile } from './profiles.js';
+import { SessionContext, createSessionContext } from './session_context.js';
+
+const PROFILES: Record<string, Profile> = {
+  dev: { projects: ['dev-1', 'dev-2'], mutations: 'allow' },
+  staging: { projects: ['staging'], mutations: 'confirm', defaults: { region: 'us-east1' } },
+  prod: { projects: ['prod'], mutations: 'deny' },
+};
+
+describe('createProfiles', () => {
+  let session: SessionContext;
+  let profiles: Profiles;
+
+  beforeEach(() => {
+    session = createSessionContext();
+    profiles = createProfiles(PROFILES, session);
+  });
+
+  test('permits everything until a profile is selected', () => {
+    expect(profiles.active()).toBeNull();
+    expect(profiles.check('compute instances delete', ['--project=prod'], false)).toEqual({
+      permitted: true,
+    });
+  });
+
+  test('selects the first project and applies the profile defaults', () => {
+    const result = profiles.use('staging');
+
+    expect(result).toEqual({
+      success: true,
+      profile: 'staging',
+      context: { project: 'staging', region: 'us-east1' },
+    });
+    expect(profiles.active()?.name).toBe('staging');
+  });
+
+  test('keeps the session project if it belongs to the profile', () => {
+    session.update({ project: 'dev-2' });
+
+    profiles.use('dev');
+
+    expect(session.get().project).toBe('dev-2');
+  });
+
+  test('rejects unknown profiles', () => {
+    expect(profiles.use('qa')).toEqual({
+      success: false,
+      error: 'Unknown profile "qa". Available profiles: dev, staging, prod',
+    });
+  });
+
+  test('blocks projects outside the profile', () => {
+    profiles.use('dev');
+
+    const result = profiles.check('compute instances list', ['--project', 'prod'], false);
+
+    expect(result).toMatchObject({ permitted: false, error: 'PROFILE_POLICY', profile: 'dev' });
+  });
+
+  test('blocks mutations in read-only profiles', () => {
+    profiles.use('prod');
+
+    expect(profiles.check('compute instances list', [], false)).toEqual({ permitted: true });
+    expect(profiles.check('compute instances delete', [], true)).toMatchObject({
+      permitted: false,
+      error: 'PROFILE_POLICY',
+    });
+  });
+
+  test('requires confirmation for mutations and unclassified commands', () => {
+    profiles.use('staging');
+
+    expect(profiles.check('compute instances create', [], false)).toMatchObject({
+      permitted: false,
+      error: 'CONFIRMATION_REQUIRED',
+    });
+    expect(profiles.check('compute ssh', [], false)).toMatchObject({ permitted: false });
+    expect(profiles.check('compute instances create', [], true)).toEqual({ permitted: true });
+  });
+});
+
+describe('validateProfile', () => {
+  test('accepts valid profiles', () => {
+    expect(validateProfile('dev', PROFILES['dev']!)).toBeUndefined();
+  });
+});
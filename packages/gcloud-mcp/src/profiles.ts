@@ -0,0 +1,144 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { SessionContext, SessionContextValues } from './session_context.js';
+import { classifyMutation } from './tools/explain_command.js';
+
+export type MutationPolicy = 'allow' | 'confirm' | 'deny';
+
+export const MUTATION_POLICIES: MutationPolicy[] = ['allow', 'confirm', 'deny'];
+
+export interface Profile {
+  /** Projects that commands may target while the profile is active. */
+  projects: string[];
+  /** Whether commands that change state run freely, need confirmation, or are blocked. */
+  mutations: MutationPolicy;
+  defaults?: Omit<SessionContextValues, 'project'>;
+}
+
+export type ProfileCheckResult =
+  | { permitted: true }
+  | {
+      permitted: false;
+      error: 'PROFILE_POLICY' | 'CONFIRMATION_REQUIRED';
+      profile: string;
+      message: string;
+    };
+
+export type ProfileUseResult =
+  | { success: true; profile: string; context: SessionContextValues }
+  | { success: false; error: string };
+
+export type Profiles = ReturnType<typeof createProfiles>;
+
+/** Returns the project a command targets, from its flags or the session. */
+const targetProject = (args: string[], session: SessionContext): string | undefined => {
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]!;
+    if (arg.startsWith('--project=')) {
+      return arg.slice('--project='.length);
+    }
+    if (arg === '--project') {
+      return args[i + 1];
+    }
+  }
+  return session.get().project;
+};
+
+/** Returns an error message if a profile definition is invalid. */
+export const validateProfile = (name: string, profile: Profile): string | undefined => {
+  if (!Array.isArray(profile.projects) || profile.projects.length === 0) {
+    return `Profile "${name}" must list at least one project in "projects".`;
+  }
+  if (!MUTATION_POLICIES.includes(profile.mutations)) {
+    return `Profile "${name}" has an invalid "mutations" policy. Use one of: ${MUTATION_POLICIES.join(', ')}.`;
+  }
+  return undefined;
+};
+
+/**
+ * Creates the named environment profiles selectable for the session.
+ *
+ * Until a profile is selected with `use`, no profile guardrails apply.
+ */
+export const createProfiles = (profiles: Record<string, Profile>, session: SessionContext) => {
+  let active: string | undefined;
+
+  return {
+    names: () => Object.keys(profiles),
+    active: () => (active ? { name: active, ...profiles[active]! } : null),
+    use: (name: string): ProfileUseResult => {
+      const profile = profiles[name];
+      if (!profile) {
+        const available = Object.keys(profiles).join(', ') || 'none';
+        return {
+          success: false,
+          error: `Unknown profile "${name}". Available profiles: ${available}`,
+        };
+      }
+      const current = session.get().project;
+      const project = current && profile.projects.includes(current) ? current : profile.projects[0];
+      const result = session.update({ ...profile.defaults, ...(project && { project }) });
+      if (!result.success) {
+        return result;
+      }
+      active = name;
+      return { success: true, profile: name, context: result.context };
+    },
+    /**
+     * Checks a command against the active profile.
+     *
+     * @param confirmed Whether the user approved this specific command.
+     */
+    check: (parsedCommand: string, args: string[], confirmed: boolean): ProfileCheckResult => {
+      if (!active) {
+        return { permitted: true };
+      }
+      const profile = profiles[active]!;
+      const project = targetProject(args, session);
+      if (project && !profile.projects.includes(project)) {
+        return {
+          permitted: false,
+          error: 'PROFILE_POLICY',
+          profile: active,
+          message: `Project "${project}" is not part of the "${active}" profile. Allowed projects: ${profile.projects.join(', ')}`,
+        };
+      }
+      const verb = parsedCommand.split(' ').pop() ?? '';
+      // Commands that can not be classified are treated as mutations.
+      if (classifyMutation(verb) === false || profile.mutations === 'allow') {
+        return { permitted: true };
+      }
+      if (profile.mutations === 'deny') {
+        return {
+          permitted: false,
+          error: 'PROFILE_POLICY',
+          profile: active,
+          message: `The "${active}" profile is read-only. Commands that change state are not permitted.`,
+        };
+      }
+      if (!confirmed) {
+        return {
+          permitted: false,
+          error: 'CONFIRMATION_REQUIRED',
+          profile: active,
+          message: `The "${active}" profile requires user confirmation for commands that change state. Show the command to the user and, after they approve, invoke this tool again with "confirm": true.`,
+        };
+      }
+      return { permitted: true };
+    },
+  };
+};
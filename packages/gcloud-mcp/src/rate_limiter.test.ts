@@ -0,0 +1,99 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import { apiFamilyOf, createRateLimiter } from './rate_limiter.js';
+
+describe('apiFamilyOf', () => {
+  test('returns the top level command group', () => {
+    expect(apiFamilyOf('compute instances list')).toBe('compute');
+  });
+
+  test('ignores the release track', () => {
+    expect(apiFamilyOf('beta logging read')).toBe('logging');
+  });
+});
+
+describe('createRateLimiter', () => {
+  beforeEach(() => {
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date('2025-01-01T00:00:00.000Z'));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  test('never throttles families without a limit', async () => {
+    const limiter = createRateLimiter({ compute: { qps: 1, burst: 1 } });
+
+    for (let i = 0; i < 10; i++) {
+      expect(await limiter.acquire('logging')).toEqual({ acquired: true, waitedMs: 0 });
+    }
+  });
+
+  test('allows a full burst without waiting', async () => {
+    const limiter = createRateLimiter({ compute: { qps: 1, burst: 3 } });
+
+    for (let i = 0; i < 3; i++) {
+      expect(await limiter.acquire('compute')).toEqual({ acquired: true, waitedMs: 0 });
+    }
+  });
+
+  test('queues commands once the burst is exhausted', async () => {
+    const limiter = createRateLimiter({ compute: { qps: 2, burst: 1 } });
+    await limiter.acquire('compute');
+
+    const pending = limiter.acquire('compute');
+    await vi.advanceTimersByTimeAsync(500);
+
+    expect(await pending).toEqual({ acquired: true, waitedMs: 500 });
+  });
+
+  test('reports the commands waiting for a token', async () => {
+    const limiter = createRateLimiter({ compute: { qps: 1, burst: 1 } });
+    await limiter.acquire('compute');
+
+    const pending = limiter.acquire('compute');
+
+    expect(limiter.queueDepth()).toEqual({ compute: 1 });
+    await vi.advanceTimersByTimeAsync(1000);
+    await pending;
+    expect(limiter.queueDepth()).toEqual({});
+  });
+
+  test('returns retry after when the queue wait exceeds the maximum', async () => {
+    const limiter = createRateLimiter({ monitoring: { qps: 1, burst: 1 } }, 1000);
+    await limiter.acquire('monitoring');
+    const queued = limiter.acquire('monitoring');
+
+    const result = await limiter.acquire('monitoring');
+
+    expect(result).toEqual({ acquired: false, apiFamily: 'monitoring', retryAfterMs: 2000 });
+    await vi.advanceTimersByTimeAsync(1000);
+    await queued;
+  });
+
+  test('refills tokens over time', async () => {
+    const limiter = createRateLimiter({ compute: { qps: 1, burst: 1 } }, 0);
+    await limiter.acquire('compute');
+    expect((await limiter.acquire('compute')).acquired).toBe(false);
+
+    vi.advanceTimersByTime(1000);
+
+    expect(await limiter.acquire('compute')).toEqual({ acquired: true, waitedMs: 0 });
+  });
+});
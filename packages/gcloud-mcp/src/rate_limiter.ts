@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { parseReleaseTrack } from './suggest.js';
+
+export interface RateLimit {
+  /** Sustained number of commands per second. */
+  qps: number;
+  /** Number of commands that may run back to back before throttling starts. */
+  burst: number;
+}
+
+export type RateLimitResult =
+  | {
+      acquired: true;
+      waitedMs: number;
+    }
+  | {
+      acquired: false;
+      apiFamily: string;
+      retryAfterMs: number;
+    };
+
+export type RateLimiter = ReturnType<typeof createRateLimiter>;
+
+// Commands queue for at most this long before being rejected as throttled.
+export const DEFAULT_MAX_QUEUE_MS = 5000;
+
+/**
+ * Returns the API family a parsed gcloud command belongs to.
+ *
+ * The family is the top level command group with any release track removed.
+ * For example, `beta compute instances list` belongs to `compute`.
+ */
+export const apiFamilyOf = (parsedCommand: string): string => {
+  const releaseTrack = parseReleaseTrack(parsedCommand);
+  const command = releaseTrack ? parsedCommand.slice(releaseTrack.length + 1) : parsedCommand;
+  return command.trim().split(' ')[0] ?? '';
+};
+
+interface Bucket {
+  limit: RateLimit;
+  tokens: number;
+  lastRefill: number;
+}
+
+const sleep = (ms: number) => new Promise((resolve) => setTimeout(resolve, ms));
+
+/**
+ * Creates a token bucket rate limiter keyed by API family.
+ *
+ * A single limiter is shared by every tool invocation on the server, so limits
+ * apply across all sessions. API families without a configured limit are never
+ * throttled.
+ */
+export const createRateLimiter = (
+  limits: Record<string, RateLimit> = {},
+  maxQueueMs: number = DEFAULT_MAX_QUEUE_MS,
+) => {
+  const buckets = new Map<string, Bucket>(
+    Object.entries(limits).map(([family, limit]) => [
+      family,
+      { limit, tokens: limit.burst, lastRefill: Date.now() },
+    ]),
+  );
+  // Commands currently waiting for a token, by API family.
+  const queued = new Map<string, number>();
+
+  const refill = (bucket: Bucket) => {
+    const now = Date.now();
+    const elapsedSeconds = (now - bucket.lastRefill) / 1000;
+    bucket.tokens = Math.min(bucket.limit.burst, bucket.tokens + elapsedSeconds * bucket.limit.qps);
+    bucket.lastRefill = now;
+  };
+
+  return {
+    acquire: async (apiFamily: string): Promise<RateLimitResult> => {
+      const bucket = buckets.get(apiFamily);
+      if (!bucket) {
+        return { acquired: true, waitedMs: 0 };
+      }
+
+      refill(bucket);
+      // Tokens below zero represent commands already queued ahead of this one.
+      const waitMs = bucket.tokens >= 1 ? 0 : ((1 - bucket.tokens) / bucket.limit.qps) * 1000;
+      if (waitMs > maxQueueMs) {
+        return { acquired: false, apiFamily, retryAfterMs: Math.ceil(waitMs) };
+      }
+
+      bucket.tokens -= 1;
+      if (waitMs > 0) {
+        queued.set(apiFamily, (queued.get(apiFamily) ?? 0) + 1);
+        await sleep(waitMs);
+        queued.set(apiFamily, (queued.get(apiFamily) ?? 1) - 1);
+      }
+      return { acquired: true, waitedMs: Math.ceil(waitMs) };
+    },
+    limits: () => Object.fromEntries([...buckets].map(([family, { limit }]) => [family, limit])),
+    /** Returns the number of commands waiting for a token, by API family. */
+    queueDepth: (): Record<string, number> =>
+      Object.fromEntries([...queued].filter(([, count]) => count > 0)),
+  };
+};
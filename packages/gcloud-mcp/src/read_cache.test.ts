@@ -0,0 +1,95 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import { cachedBlock, createReadCache, normalizeArgs, validateReadCache } from './read_cache.js';
+
+describe('normalizeArgs', () => {
+  test('sorts assigned flags after the other arguments', () => {
+    expect(
+      normalizeArgs(['gcloud', 'compute', '--zones=b', 'instances', 'list', '--format=json']),
+    ).toEqual(['compute', 'instances', 'list', '--format=json', '--zones=b']);
+  });
+
+  test('keeps flags with separate values in place', () => {
+    expect(normalizeArgs(['run', 'services', 'list', '--region', 'us-east1'])).toEqual([
+      'run',
+      'services',
+      'list',
+      '--region',
+      'us-east1',
+    ]);
+  });
+});
+
+describe('validateReadCache', () => {
+  test('rejects a TTL that is not a positive integer', () => {
+    expect(validateReadCache({ ttlSeconds: 0 })).toContain('"ttlSeconds"');
+    expect(validateReadCache({ maxEntries: 0 })).toContain('"maxEntries"');
+    expect(validateReadCache({ ttlSeconds: 30 })).toBe(undefined);
+  });
+});
+
+describe('createReadCache', () => {
+  test('serves results until they expire', () => {
+    let now = 0;
+    const cache = createReadCache({ ttlSeconds: 30 }, () => ({}), () => now);
+    cache.store(['projects', 'list'], undefined, '[]', '');
+
+    now = 29999;
+    expect(cache.lookup(['gcloud', 'projects', 'list'])).toEqual({
+      stdout: '[]',
+      stderr: '',
+      cachedAt: 0,
+    });
+    now = 30000;
+    expect(cache.lookup(['projects', 'list'])).toBeUndefined();
+    expect(cache.stats()).toEqual({ entries: 0, hits: 1, misses: 1 });
+  });
+
+  test('keeps results of different contexts apart', () => {
+    let project = 'p1';
+    const cache = createReadCache({}, () => ({ CLOUDSDK_CORE_PROJECT: project }));
+    cache.store(['compute', 'instances', 'list'], undefined, 'p1 instances', '');
+
+    project = 'p2';
+    expect(cache.lookup(['compute', 'instances', 'list'])).toBeUndefined();
+    expect(cache.lookup(['compute', 'instances', 'list'], { CLOUDSDK_CORE_PROJECT: 'p1' })).toEqual(
+      expect.objectContaining({ stdout: 'p1 instances' }),
+    );
+  });
+
+  test('evicts the least recently stored results', () => {
+    const cache = createReadCache({ maxEntries: 1 }, () => ({}));
+    cache.store(['projects', 'list'], undefined, 'projects', '');
+    cache.store(['compute', 'zones', 'list'], undefined, 'zones', '');
+
+    expect(cache.lookup(['projects', 'list'])).toBeUndefined();
+    expect(cache.clear()).toBe(1);
+  });
+});
+
+describe('cachedBlock', () => {
+  test('reports the age of the cached result', () => {
+    const block = cachedBlock({ stdout: '', stderr: '', cachedAt: 0 }, 90000);
+
+    expect(block).toMatch(/^\nCACHED:\n/);
+    expect(JSON.parse(block.slice('\nCACHED:\n'.length))).toMatchObject({
+      cachedAt: '1970-01-01T00:00:00.000Z',
+      ageSeconds: 90,
+    });
+  });
+});
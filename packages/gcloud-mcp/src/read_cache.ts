@@ -0,0 +1,128 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { DEFAULT_MAX_CACHE_ENTRIES } from './response_cache.js';
+
+export interface ReadCacheConfig {
+  /** How long results are served from the cache. Defaults to DEFAULT_TTL_SECONDS. */
+  ttlSeconds?: number;
+  /** Defaults to DEFAULT_MAX_CACHE_ENTRIES. */
+  maxEntries?: number;
+}
+
+export interface CachedRead {
+  stdout: string;
+  stderr: string;
+  cachedAt: number;
+}
+
+export const DEFAULT_TTL_SECONDS = 60;
+
+export const validateReadCache = (config: ReadCacheConfig): string | undefined => {
+  const { ttlSeconds, maxEntries } = config;
+  if (ttlSeconds !== undefined && !(Number.isInteger(ttlSeconds) && ttlSeconds > 0)) {
+    return `Invalid read cache TTL ${ttlSeconds}: "ttlSeconds" must be a positive integer.`;
+  }
+  if (maxEntries !== undefined && !(maxEntries >= 1)) {
+    return 'The read cache "maxEntries" must be at least 1.';
+  }
+  return undefined;
+};
+
+/**
+ * Normalizes the arguments of a command, so that spellings of the same command
+ * share a cache entry: a leading `gcloud` is dropped and `--flag=value` flags,
+ * whose order does not matter, are sorted after the other arguments.
+ */
+export const normalizeArgs = (args: string[]): string[] => {
+  const trimmed = args.map((arg) => arg.trim());
+  const command = trimmed[0] === 'gcloud' ? trimmed.slice(1) : trimmed;
+  const isAssignment = (arg: string) => arg.startsWith('--') && arg.includes('=');
+  return [
+    ...command.filter((arg) => !isAssignment(arg)),
+    ...command.filter(isAssignment).sort(),
+  ];
+};
+
+export type ReadCache = ReturnType<typeof createReadCache>;
+
+/**
+ * Creates the cache of the results of read commands of a session. Results are
+ * keyed by the normalized arguments and the environment overrides, which
+ * include the session context, and expire after the TTL.
+ */
+export const createReadCache = (
+  config: ReadCacheConfig,
+  contextEnv: () => NodeJS.ProcessEnv,
+  now: () => number = Date.now,
+) => {
+  const ttlMs = (config.ttlSeconds ?? DEFAULT_TTL_SECONDS) * 1000;
+  const maxEntries = config.maxEntries ?? DEFAULT_MAX_CACHE_ENTRIES;
+  const entries = new Map<string, CachedRead>();
+  let hits = 0;
+  let misses = 0;
+
+  const keyOf = (args: string[], env: NodeJS.ProcessEnv = {}) =>
+    JSON.stringify([
+      normalizeArgs(args),
+      Object.entries({ ...contextEnv(), ...env }).sort(([a], [b]) => a.localeCompare(b)),
+    ]);
+
+  return {
+    lookup: (args: string[], env?: NodeJS.ProcessEnv): CachedRead | undefined => {
+      const key = keyOf(args, env);
+      const cached = entries.get(key);
+      if (cached && now() - cached.cachedAt < ttlMs) {
+        hits++;
+        return cached;
+      }
+      entries.delete(key);
+      misses++;
+      return undefined;
+    },
+    store: (args: string[], env: NodeJS.ProcessEnv | undefined, stdout: string, stderr: string) => {
+      const key = keyOf(args, env);
+      entries.delete(key);
+      entries.set(key, { stdout, stderr, cachedAt: now() });
+      for (const oldest of entries.keys()) {
+        if (entries.size <= maxEntries) {
+          break;
+        }
+        entries.delete(oldest);
+      }
+    },
+    /** Drops every cached result and returns how many there were. */
+    clear: () => {
+      const cleared = entries.size;
+      entries.clear();
+      return cleared;
+    },
+    stats: () => ({ entries: entries.size, hits, misses }),
+  };
+};
+
+/** Returns the block marking a result served from the read cache. */
+export const cachedBlock = (cached: CachedRead, now: number = Date.now()) =>
+  `\nCACHED:\n${JSON.stringify(
+    {
+      cachedAt: new Date(cached.cachedAt).toISOString(),
+      ageSeconds: Math.round((now - cached.cachedAt) / 1000),
+      message:
+        'This is the cached result of the same command run earlier in this session. Set "cache" to false to run it again.',
+    },
+    null,
+    2,
+  )}`;
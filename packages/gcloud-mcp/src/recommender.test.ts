@@ -0,0 +1,99 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test } from 'vitest';
+import {
+  listRecommendationsArgs,
+  monthlySavings,
+  summarizeRecommendations,
+} from './recommender.js';
+
+const recommendation = (name: string, units: string | null, state = 'ACTIVE') => ({
+  name: `projects/123/locations/us-central1-a/recommenders/r/recommendations/${name}`,
+  description: `Recommendation ${name}`,
+  recommenderSubtype: 'STOP_VM',
+  priority: 'P2',
+  primaryImpact: {
+    category: units ? 'COST' : 'SECURITY',
+    ...(units && {
+      costProjection: { cost: { currencyCode: 'USD', units, nanos: 0 }, duration: '2592000s' },
+    }),
+  },
+  content: {
+    operationGroups: [
+      {
+        operations: [
+          { action: 'test', resource: '//compute.googleapis.com/projects/p/zones/z/instances/vm' },
+          { action: 'stop', resource: '//compute.googleapis.com/projects/p/zones/z/instances/vm' },
+        ],
+      },
+    ],
+  },
+  stateInfo: { state },
+});
+
+describe('listRecommendationsArgs', () => {
+  test('uses the session project without a project', () => {
+    expect(listRecommendationsArgs(undefined, 'global', 'google.iam.policy.Recommender')).toEqual([
+      'recommender',
+      'recommendations',
+      'list',
+      '--location=global',
+      '--recommender=google.iam.policy.Recommender',
+      '--format=json',
+    ]);
+  });
+});
+
+describe('monthlySavings', () => {
+  test('scales the projected savings to 30 days', () => {
+    const weekly = {
+      name: 'r',
+      primaryImpact: {
+        costProjection: {
+          cost: { currencyCode: 'USD', units: '-7', nanos: 0 },
+          duration: '604800s',
+        },
+      },
+    };
+    expect(monthlySavings(weekly)).toEqual({ amount: 30, currency: 'USD' });
+  });
+});
+
+describe('summarizeRecommendations', () => {
+  test('keeps active recommendations, largest savings first', () => {
+    const summaries = summarizeRecommendations([
+      recommendation('small', '-10'),
+      recommendation('iam', null),
+      recommendation('large', '-100'),
+      recommendation('dismissed', '-1000', 'DISMISSED'),
+    ]);
+
+    expect(summaries.map((summary) => [summary.id, summary.monthlySavings])).toEqual([
+      ['large', 100],
+      ['small', 10],
+      ['iam', null],
+    ]);
+    expect(summaries[0]).toMatchObject({
+      description: 'Recommendation large',
+      subtype: 'STOP_VM',
+      category: 'COST',
+      priority: 'P2',
+      currency: 'USD',
+      resources: ['//compute.googleapis.com/projects/p/zones/z/instances/vm'],
+    });
+  });
+});
@@ -0,0 +1,189 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+
+const SECONDS_PER_DAY = 24 * 60 * 60;
+
+export const MoneySchema = z.object({
+  currencyCode: z.string().nullish(),
+  units: z.string().default('0'),
+  nanos: z.number().default(0),
+});
+
+// There are more fields in each recommendation, but only these are used.
+export const RecommendationSchema = z.object({
+  name: z.string(),
+  description: z.string().nullish(),
+  recommenderSubtype: z.string().nullish(),
+  priority: z.string().nullish(),
+  lastRefreshTime: z.string().nullish(),
+  primaryImpact: z
+    .object({
+      category: z.string().nullish(),
+      costProjection: z
+        .object({ cost: MoneySchema.nullish(), duration: z.string().nullish() })
+        .nullish(),
+    })
+    .nullish(),
+  content: z
+    .object({
+      operationGroups: z
+        .array(
+          z.object({
+            operations: z
+              .array(
+                z.object({
+                  action: z.string().nullish(),
+                  resource: z.string().nullish(),
+                  value: z.record(z.unknown()).nullish(),
+                }),
+              )
+              .default([]),
+          }),
+        )
+        .default([]),
+    })
+    .nullish(),
+  stateInfo: z.object({ state: z.string().nullish() }).nullish(),
+});
+
+export type Recommendation = z.infer<typeof RecommendationSchema>;
+
+/** Returns the operations of a recommendation across its operation groups. */
+export const operationsOf = (recommendation: Recommendation) =>
+  recommendation.content?.operationGroups.flatMap((group) => group.operations) ?? [];
+
+/**
+ * Returns the savings of a recommendation per 30 days, or null if it has no
+ * cost projection. Projected costs are negative for savings.
+ */
+export const monthlySavings = (
+  recommendation: Recommendation,
+): { amount: number; currency: string | null } | null => {
+  const projection = recommendation.primaryImpact?.costProjection;
+  const durationDays = Number(projection?.duration?.replace(/s$/, '') ?? 0) / SECONDS_PER_DAY;
+  const cost = projection?.cost;
+  if (!cost || !(durationDays > 0)) {
+    return null;
+  }
+  const amount = (-(Number(cost.units) + cost.nanos / 1e9) * 30) / durationDays;
+  return { amount: Math.round(amount * 100) / 100, currency: cost.currencyCode ?? null };
+};
+
+export const listRecommendationsArgs = (
+  project: string | undefined,
+  location: string,
+  recommender: string,
+) => [
+  'recommender',
+  'recommendations',
+  'list',
+  ...(project ? [`--project=${project}`] : []),
+  `--location=${location}`,
+  `--recommender=${recommender}`,
+  '--format=json',
+];
+
+const isActive = (recommendation: Recommendation) =>
+  (recommendation.stateInfo?.state ?? 'ACTIVE') === 'ACTIVE';
+
+/** Lists the active recommendations of a recommender in a location. */
+export const listRecommendations = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  location: string,
+  recommender: string,
+): Promise<Recommendation[]> => {
+  const args = listRecommendationsArgs(project, location, recommender);
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  return z.array(RecommendationSchema).parse(JSON.parse(stdout)).filter(isActive);
+};
+
+export type RecommenderScope = 'zone' | 'region' | 'global';
+
+/** The Active Assist recommenders agents ask about most, by ID. */
+export const RECOMMENDERS: Record<string, { scope: RecommenderScope; summary: string }> = {
+  'google.compute.instance.IdleResourceRecommender': { scope: 'zone', summary: 'idle VMs' },
+  'google.compute.instance.MachineTypeRecommender': { scope: 'zone', summary: 'VM rightsizing' },
+  'google.compute.disk.IdleResourceRecommender': { scope: 'zone', summary: 'idle disks' },
+  'google.compute.address.IdleResourceRecommender': {
+    scope: 'region',
+    summary: 'unused IP addresses',
+  },
+  'google.compute.commitment.UsageCommitmentRecommender': {
+    scope: 'region',
+    summary: 'committed use discounts',
+  },
+  'google.iam.policy.Recommender': { scope: 'global', summary: 'excess IAM permissions' },
+  'google.cloudsql.instance.IdleRecommender': { scope: 'region', summary: 'idle Cloud SQL' },
+  'google.cloudsql.instance.OverprovisionedRecommender': {
+    scope: 'region',
+    summary: 'Cloud SQL rightsizing',
+  },
+};
+
+export const RecommendationSummarySchema = z.object({
+  /** The ID of the recommendation, the last part of its name. */
+  id: z.string(),
+  name: z.string(),
+  description: z.string().nullable(),
+  subtype: z.string().nullable(),
+  category: z.string().nullable(),
+  priority: z.string().nullable(),
+  /** Savings per 30 days, or null if the recommendation has no cost impact. */
+  monthlySavings: z.number().nullable(),
+  currency: z.string().nullable(),
+  resources: z.array(z.string()),
+  lastRefreshTime: z.string().nullable(),
+});
+export type RecommendationSummary = z.infer<typeof RecommendationSummarySchema>;
+
+/**
+ * Summarizes the output of `gcloud recommender recommendations list
+ * --format=json`, keeping the active recommendations, largest savings first.
+ */
+export const summarizeRecommendations = (json: unknown): RecommendationSummary[] =>
+  z
+    .array(RecommendationSchema)
+    .parse(json)
+    .filter(isActive)
+    .map((recommendation) => {
+      const savings = monthlySavings(recommendation);
+      return {
+        id: recommendation.name.split('/').at(-1) ?? recommendation.name,
+        name: recommendation.name,
+        description: recommendation.description ?? null,
+        subtype: recommendation.recommenderSubtype ?? null,
+        category: recommendation.primaryImpact?.category ?? null,
+        priority: recommendation.priority ?? null,
+        monthlySavings: savings?.amount ?? null,
+        currency: savings?.currency ?? null,
+        resources: [
+          ...new Set(
+            operationsOf(recommendation).flatMap((operation) =>
+              operation.resource ? [operation.resource] : [],
+            ),
+          ),
+        ],
+        lastRefreshTime: recommendation.lastRefreshTime ?? null,
+      };
+    })
+    .sort((a, b) => (b.monthlySavings ?? 0) - (a.monthlySavings ?? 0));
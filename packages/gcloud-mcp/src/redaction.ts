@@ -0,0 +1,181 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { GcloudExecutable } from './gcloud.js';
+import { log } from './utility/logger.js';
+
+// Commands whose whole standard output is a credential or a secret.
+const SECRET_OUTPUT_COMMANDS = [
+  'auth print-access-token',
+  'auth print-identity-token',
+  'auth application-default print-access-token',
+  'secrets versions access',
+  'api-keys get-key-string',
+  'sql generate-login-token',
+];
+
+const RELEASE_TRACKS = ['alpha', 'beta', 'preview'];
+
+// Fields of JSON and YAML output that hold a secret.
+const SECRET_FIELDS = [
+  'privateKeyData',
+  'secret',
+  'client_secret',
+  'keyString',
+  'access_token',
+  'refresh_token',
+  'id_token',
+];
+
+interface SecretPattern {
+  kind: string;
+  pattern: RegExp;
+  /** The group holding the secret. The whole match is masked if unset. */
+  group?: number;
+}
+
+const SECRET_PATTERNS: SecretPattern[] = [
+  {
+    // Private keys may be on their own or in the JSON of a service account key.
+    kind: 'private-key',
+    pattern: /-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----/g,
+  },
+  { kind: 'access-token', pattern: /\bya29\.[\w.-]+/g },
+  { kind: 'refresh-token', pattern: /\b1\/\/0[\w-]{20,}/g },
+  { kind: 'jwt', pattern: /\beyJ[\w-]+\.eyJ[\w-]+\.[\w-]+/g },
+  { kind: 'api-key', pattern: /\bAIza[\w-]{35}\b/g },
+  {
+    kind: 'secret-payload',
+    pattern: /"payload"\s*:\s*\{[^}]*?"data"\s*:\s*"([^"]+)"/g,
+    group: 1,
+  },
+  { kind: 'secret-payload', pattern: /^payload:\n\s+data:\s*(\S+)$/gm, group: 1 },
+  {
+    // Service account keys, HMAC key secrets, API key strings and OAuth credentials.
+    kind: 'secret-field',
+    pattern: new RegExp(`"(?:${SECRET_FIELDS.join('|')})"\\s*:\\s*"([^"]+)"`, 'g'),
+    group: 1,
+  },
+  {
+    kind: 'secret-field',
+    pattern: new RegExp(`^\\s*(?:${SECRET_FIELDS.join('|')}):\\s*(\\S+)$`, 'gm'),
+    group: 1,
+  },
+];
+
+export interface Redaction {
+  text: string;
+  /** The number of secrets masked, by kind. */
+  redacted: Record<string, number>;
+}
+
+const mask = (kind: string) => `[REDACTED ${kind}]`;
+
+// Global flags that take no value, so the argument after them is not skipped.
+const BOOLEAN_FLAGS = [
+  '--quiet',
+  '-q',
+  '--log-http',
+  '--user-output-enabled',
+  '--no-user-output-enabled',
+  '--help',
+  '-h',
+];
+
+/** Returns the command of gcloud arguments without its release track, e.g. `auth login`. */
+const commandOf = (args: string[]): string => {
+  const path: string[] = [];
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]!;
+    if (!arg.startsWith('-')) {
+      path.push(arg);
+    } else if (!arg.includes('=') && !BOOLEAN_FLAGS.includes(arg)) {
+      i++; // Skip the separate value of the flag, e.g. `--project my-project`.
+    }
+  }
+  if (path[0] === 'gcloud') {
+    path.shift();
+  }
+  if (RELEASE_TRACKS.includes(path[0] ?? '')) {
+    path.shift();
+  }
+  return path.join(' ');
+};
+
+/** Returns whether the whole output of a command is a credential or a secret. */
+export const outputIsSecret = (args: string[]): boolean => {
+  const command = commandOf(args);
+  return SECRET_OUTPUT_COMMANDS.some(
+    (prefix) => command === prefix || command.startsWith(`${prefix} `),
+  );
+};
+
+/** Masks tokens, private keys and secret payloads found in text. */
+export const redactSecrets = (text: string): Redaction => {
+  const redacted: Record<string, number> = {};
+  let result = text;
+  for (const { kind, pattern, group } of SECRET_PATTERNS) {
+    result = result.replace(pattern, (match, ...groups: unknown[]) => {
+      const secret = group ? groups[group - 1] : undefined;
+      // A field may hold a secret that an earlier pattern already masked.
+      if (typeof secret === 'string' && secret.startsWith('[REDACTED')) {
+        return match;
+      }
+      redacted[kind] = (redacted[kind] ?? 0) + 1;
+      return typeof secret === 'string' ? match.replace(secret, mask(kind)) : mask(kind);
+    });
+  }
+  return { text: result, redacted };
+};
+
+/**
+ * Masks secrets in the output of gcloud. Commands that only print a secret,
+ * e.g. `gcloud secrets versions access`, have their whole output masked.
+ */
+export const redactOutput = (args: string[], stdout: string): Redaction => {
+  if (stdout.trim() && outputIsSecret(args)) {
+    return {
+      text: `[REDACTED: the output of "gcloud ${commandOf(args)}" is a secret. Start the server with --allow-secrets to return it.]`,
+      redacted: { 'secret-output': 1 },
+    };
+  }
+  return redactSecrets(stdout);
+};
+
+/** Wraps gcloud so that credentials and secrets never reach the output of tools. */
+export const withRedaction = (gcloud: GcloudExecutable): GcloudExecutable => ({
+  ...gcloud,
+  invoke: async (args, env, options) => {
+    // Progress lines are streamed to the client before the result, so mask them as they arrive.
+    const onStderrLine = options?.onStderrLine;
+    const streamOptions = onStderrLine
+      ? { ...options, onStderrLine: (line: string) => onStderrLine(redactSecrets(line).text) }
+      : options;
+    const result = await (streamOptions
+      ? gcloud.invoke(args, env, streamOptions)
+      : gcloud.invoke(args, env));
+    const stdout = redactOutput(args, result.stdout);
+    const stderr = redactSecrets(result.stderr);
+    const redacted = { ...stdout.redacted };
+    for (const [kind, count] of Object.entries(stderr.redacted)) {
+      redacted[kind] = (redacted[kind] ?? 0) + count;
+    }
+    if (Object.keys(redacted).length > 0) {
+      log.info('Redacted secrets from gcloud output', { command: commandOf(args), redacted });
+    }
+    return { ...result, stdout: stdout.text, stderr: stderr.text };
+  },
+});
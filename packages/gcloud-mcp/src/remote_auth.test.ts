@@ -0,0 +1,210 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, test, expect, vi } from 'vitest';
+import crypto from 'crypto';
+import {
+  IAP_ASSERTION_HEADER,
+  IAP_ISSUER,
+  RemoteConfig,
+  authenticate,
+  createJwtVerifier,
+  protectedResourceMetadata,
+  serviceAccountFor,
+  validateRemoteConfig,
+} from './remote_auth.js';
+
+const NOW = Date.parse('2025-01-01T00:00:00.000Z');
+const AUDIENCE = '/projects/123/global/backendServices/456';
+const { publicKey, privateKey } = crypto.generateKeyPairSync('ec', { namedCurve: 'P-256' });
+
+const segment = (value: unknown) => Buffer.from(JSON.stringify(value)).toString('base64url');
+
+const sign = (claims: Record<string, unknown>, kid = 'key-1') => {
+  const unsigned = `${segment({ alg: 'ES256', kid })}.${segment({
+    iss: IAP_ISSUER,
+    aud: AUDIENCE,
+    sub: 'accounts.google.com:42',
+    email: 'alice@example.com',
+    exp: NOW / 1000 + 600,
+    ...claims,
+  })}`;
+  const signature = crypto.sign('sha256', Buffer.from(unsigned), {
+    key: privateKey,
+    dsaEncoding: 'ieee-p1363',
+  });
+  return `${unsigned}.${signature.toString('base64url')}`;
+};
+
+const createVerifier = () => {
+  const fetchFn = vi.fn().mockResolvedValue({
+    ok: true,
+    json: async () => ({ keys: [{ ...publicKey.export({ format: 'jwk' }), kid: 'key-1' }] }),
+  });
+  const verify = createJwtVerifier({
+    jwksUrl: 'https://keys.example.com',
+    issuers: [IAP_ISSUER],
+    audience: AUDIENCE,
+    fetchFn: fetchFn as unknown as typeof fetch,
+    now: () => NOW,
+  });
+  return { verify, fetchFn };
+};
+
+const config: RemoteConfig = {
+  auth: 'iap',
+  audience: AUDIENCE,
+  allowedPrincipals: ['*@example.com'],
+  impersonation: { '*@example.com': 'mcp@ops.iam.gserviceaccount.com' },
+};
+
+describe('createJwtVerifier', () => {
+  test('returns the principal of a valid token', async () => {
+    const { verify, fetchFn } = createVerifier();
+
+    await expect(verify(sign({}))).resolves.toEqual({
+      email: 'alice@example.com',
+      subject: 'accounts.google.com:42',
+    });
+    await verify(sign({}));
+    expect(fetchFn).toHaveBeenCalledTimes(1);
+  });
+
+  test('rejects tokens for another audience, expired tokens, and unknown keys', async () => {
+    const { verify } = createVerifier();
+
+    await expect(verify(sign({ aud: 'other' }))).rejects.toThrow('different audience');
+    await expect(verify(sign({ exp: NOW / 1000 - 3600 }))).rejects.toThrow('expired');
+    await expect(verify(sign({ iss: 'https://evil.example.com' }))).rejects.toThrow(
+      'Unexpected token issuer',
+    );
+    await expect(verify(sign({}, 'key-2'))).rejects.toThrow('Unknown signing key "key-2"');
+  });
+
+  test('rejects tampered tokens', async () => {
+    const { verify } = createVerifier();
+    const [header, , signature] = sign({}).split('.');
+    const forged = `${header}.${segment({ email: 'mallory@example.com' })}.${signature}`;
+
+    await expect(verify(forged)).rejects.toThrow('Invalid token signature');
+  });
+});
+
+describe('serviceAccountFor', () => {
+  test('prefers exact addresses over domains over the wildcard', () => {
+    const impersonation = {
+      '*': 'viewer@ops.iam.gserviceaccount.com',
+      '*@example.com': 'dev@ops.iam.gserviceaccount.com',
+      'alice@example.com': 'admin@ops.iam.gserviceaccount.com',
+    };
+
+    expect(serviceAccountFor('alice@example.com', impersonation)).toBe(
+      'admin@ops.iam.gserviceaccount.com',
+    );
+    expect(serviceAccountFor('bob@example.com', impersonation)).toBe(
+      'dev@ops.iam.gserviceaccount.com',
+    );
+    expect(serviceAccountFor('eve@other.com', impersonation)).toBe(
+      'viewer@ops.iam.gserviceaccount.com',
+    );
+    expect(serviceAccountFor('eve@other.com', { '*@example.com': 'x' })).toBe(undefined);
+  });
+});
+
+describe('authenticate', () => {
+  test('resolves the service account of an allowed principal', async () => {
+    const { verify } = createVerifier();
+
+    const result = await authenticate({ [IAP_ASSERTION_HEADER]: sign({}) }, config, verify);
+
+    expect(result).toEqual({
+      authenticated: true,
+      principal: { email: 'alice@example.com', subject: 'accounts.google.com:42' },
+      serviceAccount: 'mcp@ops.iam.gserviceaccount.com',
+    });
+  });
+
+  test('rejects requests without a valid token with 401', async () => {
+    const { verify } = createVerifier();
+
+    await expect(authenticate({}, config, verify)).resolves.toMatchObject({ status: 401 });
+    await expect(
+      authenticate({ [IAP_ASSERTION_HEADER]: 'not-a-token' }, config, verify),
+    ).resolves.toMatchObject({ status: 401 });
+    await expect(
+      authenticate({ authorization: `Bearer ${sign({})}` }, { ...config, auth: 'oidc' }, verify),
+    ).resolves.toMatchObject({ authenticated: true });
+  });
+
+  test('rejects principals that are not allowed with 403', async () => {
+    const { verify } = createVerifier();
+    const token = sign({ email: 'eve@other.com' });
+
+    const result = await authenticate({ [IAP_ASSERTION_HEADER]: token }, config, verify);
+
+    expect(result).toEqual({
+      authenticated: false,
+      status: 403,
+      message: 'eve@other.com is not allowed to use this server.',
+    });
+  });
+});
+
+describe('validateRemoteConfig', () => {
+  test('requires an audience and impersonation', () => {
+    expect(validateRemoteConfig({ ...config, audience: '' })).toContain('"audience"');
+    expect(validateRemoteConfig({ ...config, impersonation: {} })).toContain('"impersonation"');
+    expect(validateRemoteConfig(config)).toBe(undefined);
+  });
+
+  test('requires an https issuer and key set for other OIDC providers', () => {
+    const oidc: RemoteConfig = { ...config, auth: 'oidc', audience: 'gcloud-mcp' };
+    const issuer = 'https://login.example.com';
+    const jwksUrl = 'https://login.example.com/jwks';
+
+    expect(validateRemoteConfig({ ...config, issuer, jwksUrl })).toContain(
+      'only be set for "oidc"',
+    );
+    expect(validateRemoteConfig({ ...oidc, issuer })).toContain('must be set together');
+    expect(validateRemoteConfig({ ...oidc, issuer: 'http://login', jwksUrl })).toContain(
+      '"issuer" must be an https URL',
+    );
+    expect(validateRemoteConfig({ ...oidc, sessionIdleMinutes: 0 })).toContain(
+      '"sessionIdleMinutes"',
+    );
+    expect(validateRemoteConfig({ ...oidc, issuer, jwksUrl })).toBe(undefined);
+  });
+});
+
+describe('protectedResourceMetadata', () => {
+  test('names the issuer of the tokens as the authorization server', () => {
+    const oidc: RemoteConfig = { ...config, auth: 'oidc', audience: 'gcloud-mcp' };
+    const resource = 'https://mcp.example.com/mcp';
+
+    expect(protectedResourceMetadata(oidc, resource)).toEqual({
+      resource,
+      authorization_servers: ['https://accounts.google.com'],
+      bearer_methods_supported: ['header'],
+      scopes_supported: ['openid', 'email'],
+    });
+    expect(
+      protectedResourceMetadata(
+        { ...oidc, issuer: 'https://login.example.com', jwksUrl: 'https://login.example.com/jwks' },
+        resource,
+      ).authorization_servers,
+    ).toEqual(['https://login.example.com']);
+  });
+});
@@ -0,0 +1,312 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import crypto from 'crypto';
+import { IncomingHttpHeaders } from 'http';
+import { z } from 'zod';
+
+export const IAP_JWKS_URL = 'https://www.gstatic.com/iap/verify/public_key-jwk';
+export const GOOGLE_JWKS_URL = 'https://www.googleapis.com/oauth2/v3/certs';
+export const IAP_ISSUER = 'https://cloud.google.com/iap';
+export const GOOGLE_ISSUERS = ['https://accounts.google.com', 'accounts.google.com'];
+export const IAP_ASSERTION_HEADER = 'x-goog-iap-jwt-assertion';
+export const DEFAULT_SESSION_IDLE_MINUTES = 60;
+
+// Keys are refetched after this long, or sooner for an unknown key ID.
+const JWKS_MAX_AGE_MS = 60 * 60 * 1000;
+// Tolerated difference between the clocks of the issuer and the server.
+const CLOCK_SKEW_SECONDS = 60;
+
+export type RemoteAuthMode = 'iap' | 'oidc';
+
+export interface RemoteConfig {
+  /**
+   * How callers are authenticated: `iap` verifies the assertion added by
+   * Identity-Aware Proxy and `oidc` verifies Google-signed ID tokens sent as
+   * bearer tokens.
+   */
+  auth: RemoteAuthMode;
+  /** The expected audience, e.g. `/projects/123/global/backendServices/456` for IAP. */
+  audience: string;
+  /** Principals allowed to connect, e.g. `alice@example.com` or `*@example.com`. */
+  allowedPrincipals?: string[];
+  /**
+   * The service account each principal's commands run as, keyed by the same
+   * patterns as `allowedPrincipals`. Principals without one are rejected.
+   */
+  impersonation: Record<string, string>;
+  /** For `oidc`, the issuer of the tokens if it is not Google, e.g. `https://login.example.com`. */
+  issuer?: string;
+  /** For `oidc` with an `issuer`, the URL of its JSON Web Key Set. */
+  jwksUrl?: string;
+  /**
+   * The public URL of the MCP endpoint, advertised to OAuth clients in the
+   * protected resource metadata. Defaults to the URL the request was sent to.
+   */
+  resourceUrl?: string;
+  /** Sessions without a request for this long are closed. Defaults to 60. */
+  sessionIdleMinutes?: number;
+}
+
+export interface Principal {
+  email: string;
+  subject: string;
+}
+
+export type AuthenticationResult =
+  | { authenticated: true; principal: Principal; serviceAccount: string }
+  | { authenticated: false; status: 401 | 403; message: string };
+
+const JwkSchema = z.object({ kid: z.string() }).passthrough();
+const JwksSchema = z.object({ keys: z.array(JwkSchema) });
+
+const HeaderSchema = z.object({ alg: z.enum(['RS256', 'ES256']), kid: z.string() });
+
+const ClaimsSchema = z.object({
+  iss: z.string(),
+  aud: z.union([z.string(), z.array(z.string())]),
+  sub: z.string(),
+  email: z.string(),
+  email_verified: z.boolean().optional(),
+  exp: z.number(),
+  nbf: z.number().optional(),
+  iat: z.number().optional(),
+});
+
+export type JwtVerifier = (token: string) => Promise<Principal>;
+
+const decodeSegment = (segment: string): unknown =>
+  JSON.parse(Buffer.from(segment, 'base64url').toString('utf-8'));
+
+/**
+ * Creates a verifier of JWTs signed by Google. Tokens must be signed by a key
+ * of the JWKS, issued by one of the issuers for the audience, and unexpired.
+ */
+export const createJwtVerifier = (options: {
+  jwksUrl: string;
+  issuers: string[];
+  audience: string;
+  fetchFn?: typeof fetch;
+  now?: () => number;
+}): JwtVerifier => {
+  const { jwksUrl, issuers, audience, fetchFn = fetch, now = Date.now } = options;
+  let keys = new Map<string, crypto.KeyObject>();
+  let fetchedAt = 0;
+
+  const refreshKeys = async () => {
+    const response = await fetchFn(jwksUrl);
+    if (!response.ok) {
+      throw new Error(`Unable to fetch signing keys from ${jwksUrl}: ${response.status}`);
+    }
+    const jwks = JwksSchema.parse(await response.json());
+    keys = new Map(
+      jwks.keys.map((jwk) => [
+        jwk.kid,
+        crypto.createPublicKey({ key: jwk as crypto.JsonWebKey, format: 'jwk' }),
+      ]),
+    );
+    fetchedAt = now();
+  };
+
+  const keyFor = async (kid: string) => {
+    if (!keys.has(kid) || now() - fetchedAt > JWKS_MAX_AGE_MS) {
+      await refreshKeys();
+    }
+    const key = keys.get(kid);
+    if (!key) {
+      throw new Error(`Unknown signing key "${kid}".`);
+    }
+    return key;
+  };
+
+  return async (token) => {
+    const [headerSegment, payloadSegment, signatureSegment] = token.split('.');
+    if (!headerSegment || !payloadSegment || !signatureSegment) {
+      throw new Error('Malformed token.');
+    }
+    const header = HeaderSchema.parse(decodeSegment(headerSegment));
+    const key = await keyFor(header.kid);
+    const valid = crypto.verify(
+      'sha256',
+      Buffer.from(`${headerSegment}.${payloadSegment}`),
+      header.alg === 'ES256' ? { key, dsaEncoding: 'ieee-p1363' } : key,
+      Buffer.from(signatureSegment, 'base64url'),
+    );
+    if (!valid) {
+      throw new Error('Invalid token signature.');
+    }
+    const claims = ClaimsSchema.parse(decodeSegment(payloadSegment));
+    const nowSeconds = now() / 1000;
+    if (!issuers.includes(claims.iss)) {
+      throw new Error(`Unexpected token issuer "${claims.iss}".`);
+    }
+    const audiences = Array.isArray(claims.aud) ? claims.aud : [claims.aud];
+    if (!audiences.includes(audience)) {
+      throw new Error('The token was issued for a different audience.');
+    }
+    if (claims.exp + CLOCK_SKEW_SECONDS < nowSeconds) {
+      throw new Error('The token has expired.');
+    }
+    if (claims.nbf !== undefined && claims.nbf - CLOCK_SKEW_SECONDS > nowSeconds) {
+      throw new Error('The token is not valid yet.');
+    }
+    if (claims.email_verified === false) {
+      throw new Error('The email address of the token is not verified.');
+    }
+    return { email: claims.email.toLowerCase(), subject: claims.sub };
+  };
+};
+
+// The signing keys and issuers of the tokens of each authentication mode.
+const TOKEN_ISSUERS: Record<RemoteAuthMode, { jwksUrl: string; issuers: string[] }> = {
+  iap: { jwksUrl: IAP_JWKS_URL, issuers: [IAP_ISSUER] },
+  oidc: { jwksUrl: GOOGLE_JWKS_URL, issuers: GOOGLE_ISSUERS },
+};
+
+/** Returns the signing keys and issuers of the tokens of a configuration. */
+const tokenIssuerOf = (config: RemoteConfig) =>
+  config.auth === 'oidc' && config.issuer && config.jwksUrl
+    ? { jwksUrl: config.jwksUrl, issuers: [config.issuer] }
+    : TOKEN_ISSUERS[config.auth];
+
+/** Returns the verifier for an authentication mode. */
+export const verifierFor = (config: RemoteConfig, fetchFn?: typeof fetch): JwtVerifier =>
+  createJwtVerifier({
+    ...tokenIssuerOf(config),
+    audience: config.audience,
+    ...(fetchFn && { fetchFn }),
+  });
+
+/**
+ * Returns the OAuth 2.0 protected resource metadata (RFC 9728) of the MCP
+ * endpoint, which tells clients where to obtain a token for it.
+ */
+export const protectedResourceMetadata = (config: RemoteConfig, resource: string) => ({
+  resource,
+  authorization_servers: [tokenIssuerOf(config).issuers[0]],
+  bearer_methods_supported: ['header'],
+  scopes_supported: ['openid', 'email'],
+});
+
+/** Returns true if an email matches a pattern such as `alice@example.com` or `*@example.com`. */
+export const principalMatches = (email: string, pattern: string): boolean => {
+  const normalized = pattern.toLowerCase();
+  if (normalized === '*') {
+    return true;
+  }
+  return normalized.startsWith('*@') ? email.endsWith(normalized.slice(1)) : email === normalized;
+};
+
+/** Returns the service account of a principal, preferring exact over domain matches. */
+export const serviceAccountFor = (
+  email: string,
+  impersonation: Record<string, string>,
+): string | undefined => {
+  // Exact addresses take precedence over `*@domain` patterns, which take precedence over `*`.
+  const rank = (pattern: string) => (pattern === '*' ? 2 : pattern.startsWith('*@') ? 1 : 0);
+  const best = Object.keys(impersonation)
+    .filter((pattern) => principalMatches(email, pattern))
+    .sort((a, b) => rank(a) - rank(b))[0];
+  return best === undefined ? undefined : impersonation[best];
+};
+
+const tokenOf = (headers: IncomingHttpHeaders, mode: RemoteAuthMode): string | undefined => {
+  if (mode === 'iap') {
+    const assertion = headers[IAP_ASSERTION_HEADER];
+    return Array.isArray(assertion) ? assertion[0] : assertion;
+  }
+  const match = headers.authorization?.match(/^Bearer\s+(.+)$/i);
+  return match?.[1];
+};
+
+/**
+ * Authenticates the caller of a request and resolves the service account its
+ * commands run as. Callers must be allowed and have a service account, so no
+ * request ever runs with the server's own credentials.
+ */
+export const authenticate = async (
+  headers: IncomingHttpHeaders,
+  config: RemoteConfig,
+  verify: JwtVerifier,
+): Promise<AuthenticationResult> => {
+  const token = tokenOf(headers, config.auth);
+  if (!token) {
+    const expected =
+      config.auth === 'iap' ? `the ${IAP_ASSERTION_HEADER} header` : 'a bearer token';
+    return { authenticated: false, status: 401, message: `Authentication requires ${expected}.` };
+  }
+  let principal: Principal;
+  try {
+    principal = await verify(token);
+  } catch (e: unknown) {
+    const message = e instanceof Error ? e.message : String(e);
+    return { authenticated: false, status: 401, message: `Invalid credentials: ${message}` };
+  }
+  const { allowedPrincipals } = config;
+  if (allowedPrincipals && !allowedPrincipals.some((p) => principalMatches(principal.email, p))) {
+    return {
+      authenticated: false,
+      status: 403,
+      message: `${principal.email} is not allowed to use this server.`,
+    };
+  }
+  const serviceAccount = serviceAccountFor(principal.email, config.impersonation);
+  if (!serviceAccount) {
+    return {
+      authenticated: false,
+      status: 403,
+      message: `No service account is configured for ${principal.email}.`,
+    };
+  }
+  return { authenticated: true, principal, serviceAccount };
+};
+
+export const validateRemoteConfig = (config: RemoteConfig): string | undefined => {
+  if (!['iap', 'oidc'].includes(config.auth)) {
+    return `Invalid remote auth mode "${config.auth}". Use "iap" or "oidc".`;
+  }
+  if (!config.audience) {
+    return 'Remote mode requires an "audience" for the identity tokens.';
+  }
+  if (Object.keys(config.impersonation ?? {}).length === 0) {
+    return 'Remote mode requires "impersonation", so commands never run with the server credentials.';
+  }
+  for (const serviceAccount of Object.values(config.impersonation)) {
+    if (!/^[^@\s]+@[^@\s]+\.iam\.gserviceaccount\.com$/.test(serviceAccount)) {
+      return `Invalid service account "${serviceAccount}" in "impersonation".`;
+    }
+  }
+  if ((config.issuer || config.jwksUrl) && config.auth !== 'oidc') {
+    return '"issuer" and "jwksUrl" can only be set for "oidc" authentication.';
+  }
+  if (!config.issuer !== !config.jwksUrl) {
+    return '"issuer" and "jwksUrl" must be set together.';
+  }
+  for (const [key, url] of Object.entries({
+    issuer: config.issuer,
+    jwksUrl: config.jwksUrl,
+    resourceUrl: config.resourceUrl,
+  })) {
+    if (url !== undefined && !url.startsWith('https://')) {
+      return `"${key}" must be an https URL: ${url}`;
+    }
+  }
+  const idle = config.sessionIdleMinutes;
+  if (idle !== undefined && !(idle > 0)) {
+    return `"sessionIdleMinutes" must be greater than 0: ${idle}`;
+  }
+  return undefined;
+};
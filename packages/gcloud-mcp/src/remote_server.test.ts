@@ -0,0 +1,263 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, test, expect, vi, beforeAll, afterAll } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { AddressInfo } from 'net';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { GcloudExecutable } from './gcloud.js';
+import { RemoteConfig } from './remote_auth.js';
+import {
+  IMPERSONATION_ENV_VAR,
+  createRemoteServer,
+  createSessionConfig,
+  withImpersonation,
+} from './remote_server.js';
+
+const INITIALIZE = {
+  jsonrpc: '2.0',
+  id: 1,
+  method: 'initialize',
+  params: {
+    protocolVersion: '2025-03-26',
+    capabilities: {},
+    clientInfo: { name: 'test', version: '1.0.0' },
+  },
+};
+
+const config: RemoteConfig = {
+  auth: 'oidc',
+  audience: 'gcloud-mcp',
+  impersonation: { 'alice@example.com': 'mcp@ops.iam.gserviceaccount.com' },
+};
+
+describe('withImpersonation', () => {
+  const SESSION_CONFIG = '/config/mcp-sessions/alice';
+  const mockGcloud = () =>
+    ({
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: '', stderr: '' }),
+    }) as unknown as GcloudExecutable;
+
+  test('runs every invocation as the service account with the session configuration', async () => {
+    const gcloud = mockGcloud();
+
+    const impersonated = withImpersonation(
+      gcloud,
+      'mcp@ops.iam.gserviceaccount.com',
+      SESSION_CONFIG,
+    );
+    await impersonated.invoke(['projects', 'list'], {
+      CLOUDSDK_CORE_PROJECT: 'dev',
+      CLOUDSDK_CONFIG: '/config',
+      [IMPERSONATION_ENV_VAR]: 'owner@ops.iam.gserviceaccount.com',
+    });
+
+    expect(gcloud.invoke).toHaveBeenCalledWith(['projects', 'list'], {
+      CLOUDSDK_CORE_PROJECT: 'dev',
+      CLOUDSDK_CONFIG: SESSION_CONFIG,
+      [IMPERSONATION_ENV_VAR]: 'mcp@ops.iam.gserviceaccount.com',
+    });
+  });
+
+  test.each([
+    [['projects', 'list', '--impersonate-service-account=admin@ops.iam.gserviceaccount.com']],
+    [['projects', 'list', '--impersonate-service-account', 'admin@ops.iam.gserviceaccount.com']],
+    [['projects', 'list', '--account=bob@example.com']],
+    [['projects', 'list', '--access-token-file', '/tmp/token']],
+    [['projects', 'list', '--credential-file-override=/tmp/key.json']],
+    [['projects', 'list', '--flags-file=/tmp/flags.yaml']],
+    [['config', 'set', 'auth/impersonate_service_account', 'admin@ops.iam.gserviceaccount.com']],
+    [['config', 'set', 'account', 'bob@example.com']],
+    [['config', 'unset', 'core/account']],
+  ])('refuses %j, which selects other credentials', async (args) => {
+    const gcloud = mockGcloud();
+
+    const impersonated = withImpersonation(
+      gcloud,
+      'mcp@ops.iam.gserviceaccount.com',
+      SESSION_CONFIG,
+    );
+    const result = await impersonated.invoke(args);
+
+    expect(result.code).toBe(1);
+    expect(result.stderr).toContain('is not permitted in a remote session');
+    expect(gcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('runs commands that only set other properties', async () => {
+    const gcloud = mockGcloud();
+
+    const impersonated = withImpersonation(
+      gcloud,
+      'mcp@ops.iam.gserviceaccount.com',
+      SESSION_CONFIG,
+    );
+    await impersonated.invoke(['config', 'set', 'compute/region', 'us-east1']);
+
+    expect(gcloud.invoke).toHaveBeenCalledOnce();
+  });
+});
+
+describe('createSessionConfig', () => {
+  test('creates a configuration directory per session in the server one', () => {
+    const serverConfig = fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-test-'));
+    vi.stubEnv('CLOUDSDK_CONFIG', serverConfig);
+    try {
+      const alice = createSessionConfig({ project: 'dev' });
+      const bob = createSessionConfig();
+
+      expect(alice.directory).not.toBe(bob.directory);
+      expect(path.dirname(alice.directory)).toBe(path.join(serverConfig, 'mcp-sessions'));
+      expect(
+        fs.readFileSync(path.join(alice.directory, 'configurations', 'config_default'), 'utf-8'),
+      ).toContain('project = dev');
+      alice.dispose();
+      expect(fs.existsSync(alice.directory)).toBe(false);
+      expect(fs.existsSync(bob.directory)).toBe(true);
+    } finally {
+      vi.unstubAllEnvs();
+      fs.rmSync(serverConfig, { recursive: true, force: true });
+    }
+  });
+});
+
+describe('createRemoteServer', () => {
+  const createSessionServer = vi.fn(() => ({}) as unknown as McpServer);
+  const verify = vi.fn(async (token: string) => {
+    if (token !== 'alice-token') {
+      throw new Error('Invalid token signature.');
+    }
+    return { email: 'alice@example.com', subject: '42' };
+  });
+  const remote = createRemoteServer({ config, createSessionServer, verify });
+  let baseUrl = '';
+
+  beforeAll(async () => {
+    await new Promise<void>((resolve) => remote.httpServer.listen(0, '127.0.0.1', resolve));
+    baseUrl = `http://127.0.0.1:${(remote.httpServer.address() as AddressInfo).port}`;
+  });
+
+  afterAll(async () => {
+    await remote.close();
+  });
+
+  test('reports liveness without authentication', async () => {
+    const response = await fetch(`${baseUrl}/healthz`);
+
+    expect(response.status).toBe(200);
+    await expect(response.json()).resolves.toEqual({ status: 'ok', sessions: 0 });
+  });
+
+  test('rejects requests without valid credentials', async () => {
+    const missing = await fetch(`${baseUrl}/mcp`, { method: 'POST', body: '{}' });
+    const invalid = await fetch(`${baseUrl}/mcp`, {
+      method: 'POST',
+      headers: { Authorization: 'Bearer forged' },
+      body: '{}',
+    });
+
+    expect(missing.status).toBe(401);
+    expect(missing.headers.get('www-authenticate')).toMatch(
+      /^Bearer resource_metadata="http:\/\/127\.0\.0\.1:\d+\/\.well-known\/oauth-protected-resource"$/,
+    );
+    expect(invalid.status).toBe(401);
+    expect(invalid.headers.get('www-authenticate')).toContain('error="invalid_token"');
+    await expect(invalid.json()).resolves.toEqual({
+      error: 'Invalid credentials: Invalid token signature.',
+    });
+    expect(createSessionServer).not.toHaveBeenCalled();
+  });
+
+  test('requires an initialize request to start a session', async () => {
+    const headers = { Authorization: 'Bearer alice-token' };
+
+    const notInitialize = await fetch(`${baseUrl}/mcp`, {
+      method: 'POST',
+      headers,
+      body: JSON.stringify({ jsonrpc: '2.0', id: 1, method: 'tools/list' }),
+    });
+    const unknownSession = await fetch(`${baseUrl}/mcp`, {
+      method: 'POST',
+      headers: { ...headers, 'mcp-session-id': 'unknown' },
+      body: '{}',
+    });
+
+    expect(notInitialize.status).toBe(400);
+    expect(unknownSession.status).toBe(404);
+    expect(createSessionServer).not.toHaveBeenCalled();
+  });
+
+  test('serves the protected resource metadata without authentication', async () => {
+    const response = await fetch(`${baseUrl}/.well-known/oauth-protected-resource`);
+
+    expect(response.status).toBe(200);
+    await expect(response.json()).resolves.toEqual({
+      resource: `${baseUrl}/mcp`,
+      authorization_servers: ['https://accounts.google.com'],
+      bearer_methods_supported: ['header'],
+      scopes_supported: ['openid', 'email'],
+    });
+  });
+});
+
+describe('createRemoteServer sessions', () => {
+  test('closes sessions that are idle for too long', async () => {
+    let now = Date.parse('2025-01-01T00:00:00.000Z');
+    const remote = createRemoteServer({
+      config: { ...config, sessionIdleMinutes: 5 },
+      createSessionServer: () => new McpServer({ name: 'test', version: '1.0.0' }),
+      verify: async () => ({ email: 'alice@example.com', subject: '42' }),
+      now: () => now,
+    });
+    await new Promise<void>((resolve) => remote.httpServer.listen(0, '127.0.0.1', resolve));
+    const url = `http://127.0.0.1:${(remote.httpServer.address() as AddressInfo).port}/mcp`;
+    const headers = {
+      Authorization: 'Bearer alice-token',
+      'Content-Type': 'application/json',
+      Accept: 'application/json, text/event-stream',
+    };
+
+    try {
+      const initialized = await fetch(url, {
+        method: 'POST',
+        headers,
+        body: JSON.stringify(INITIALIZE),
+      });
+      await initialized.text();
+      const sessionId = initialized.headers.get('mcp-session-id')!;
+      expect(remote.sessions()).toBe(1);
+
+      now += 4 * 60 * 1000;
+      await remote.closeIdleSessions();
+      expect(remote.sessions()).toBe(1);
+
+      now += 2 * 60 * 1000;
+      await remote.closeIdleSessions();
+      expect(remote.sessions()).toBe(0);
+      const expired = await fetch(url, {
+        method: 'POST',
+        headers: { ...headers, 'mcp-session-id': sessionId },
+        body: JSON.stringify({ jsonrpc: '2.0', id: 2, method: 'tools/list' }),
+      });
+      expect(expired.status).toBe(404);
+    } finally {
+      await remote.close();
+    }
+  });
+});
@@ -0,0 +1,312 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import http from 'http';
+import path from 'path';
+import { randomUUID } from 'crypto';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { StreamableHTTPServerTransport } from '@modelcontextprotocol/sdk/server/streamableHttp.js';
+import { isInitializeRequest } from '@modelcontextprotocol/sdk/types.js';
+import { hostConfigDir } from './container.js';
+import { GcloudExecutable } from './gcloud.js';
+import { ExecutionOptions } from './gcloud_executor.js';
+import { ConfigProperties, createIsolatedConfig } from './isolated_config.js';
+import {
+  DEFAULT_SESSION_IDLE_MINUTES,
+  JwtVerifier,
+  Principal,
+  RemoteConfig,
+  authenticate,
+  protectedResourceMetadata,
+  verifierFor,
+} from './remote_auth.js';
+import { log } from './utility/logger.js';
+
+export const MCP_PATH = '/mcp';
+export const HEALTH_PATH = '/healthz';
+export const PROTECTED_RESOURCE_PATH = '/.well-known/oauth-protected-resource';
+export const DEFAULT_PORT = 8080;
+const SESSION_HEADER = 'mcp-session-id';
+const MAX_BODY_BYTES = 4 * 1024 * 1024;
+
+// gcloud reads this in preference to auth/impersonate_service_account.
+export const IMPERSONATION_ENV_VAR = 'CLOUDSDK_AUTH_IMPERSONATE_SERVICE_ACCOUNT';
+
+// Flags gcloud prefers to the environment when it picks the credentials to run
+// with. --flags-file can set any of them.
+export const CREDENTIAL_FLAGS = [
+  '--impersonate-service-account',
+  '--account',
+  '--access-token-file',
+  '--credential-file-override',
+  '--flags-file',
+];
+
+// Properties that select the credentials of later commands, such as
+// auth/impersonate_service_account.
+const CREDENTIAL_PROPERTY = /^(account|core\/account|auth\/.+)$/;
+
+/** Returns the flag of a command that selects its credentials, if any. */
+export const credentialFlagOf = (args: string[]): string | undefined =>
+  CREDENTIAL_FLAGS.find((flag) => args.some((arg) => arg === flag || arg.startsWith(`${flag}=`)));
+
+/**
+ * Returns the property a `gcloud config set` or `unset` command changes if it
+ * selects the credentials of later commands.
+ */
+export const credentialPropertyOf = (args: string[]): string | undefined => {
+  // Flags before the command group may take values, e.g. `--project p config set`.
+  const positional = args.filter((arg) => !arg.startsWith('-'));
+  const start = positional.findIndex(
+    (arg, i) => arg === 'config' && ['set', 'unset'].includes(positional[i + 1] ?? ''),
+  );
+  const property = start === -1 ? undefined : positional[start + 2];
+  return property && CREDENTIAL_PROPERTY.test(property) ? property : undefined;
+};
+
+/**
+ * Creates the gcloud configuration directory of a remote session, so that a
+ * `gcloud config set` of one user does not change the commands of another.
+ * It is created in the server's own directory, which a container mounts.
+ */
+export const createSessionConfig = (defaults: ConfigProperties = {}) => {
+  const directory = path.join(hostConfigDir(), 'mcp-sessions', randomUUID());
+  createIsolatedConfig({ ...defaults, directory });
+  return {
+    directory,
+    dispose: () => fs.rmSync(directory, { recursive: true, force: true }),
+  };
+};
+
+/**
+ * Wraps gcloud so every invocation runs as a service account with the
+ * configuration directory of the session. Commands that select other
+ * credentials, with a flag or a property, are refused without being run, as
+ * gcloud would prefer those to the service account.
+ */
+export const withImpersonation = (
+  gcloud: GcloudExecutable,
+  serviceAccount: string,
+  configDir: string,
+): GcloudExecutable => ({
+  ...gcloud,
+  invoke: async (args: string[], env?: NodeJS.ProcessEnv, options?: ExecutionOptions) => {
+    const selected = credentialFlagOf(args) ?? credentialPropertyOf(args);
+    if (selected) {
+      return {
+        code: 1,
+        stdout: '',
+        stderr: `${selected} is not permitted in a remote session, whose commands run as ${serviceAccount}.`,
+      };
+    }
+    // Applied last, so that no override of the caller can drop them.
+    const impersonatedEnv = {
+      ...env,
+      CLOUDSDK_CONFIG: configDir,
+      [IMPERSONATION_ENV_VAR]: serviceAccount,
+    };
+    return options
+      ? gcloud.invoke(args, impersonatedEnv, options)
+      : gcloud.invoke(args, impersonatedEnv);
+  },
+});
+
+export interface RemoteServerOptions {
+  config: RemoteConfig;
+  /** Creates the MCP server of a new session whose commands run as the service account. */
+  createSessionServer: (serviceAccount: string, principal: Principal) => McpServer;
+  verify?: JwtVerifier;
+  now?: () => number;
+}
+
+interface RemoteSession {
+  email: string;
+  server: McpServer;
+  transport: StreamableHTTPServerTransport;
+  lastRequestAt: number;
+}
+
+const sendJson = (
+  res: http.ServerResponse,
+  status: number,
+  body: unknown,
+  headers: http.OutgoingHttpHeaders = {},
+) => {
+  res.writeHead(status, { 'Content-Type': 'application/json', ...headers });
+  res.end(JSON.stringify(body));
+};
+
+const firstHeader = (value: string | string[] | undefined) =>
+  Array.isArray(value) ? value[0] : value;
+
+/** Returns the public origin of the server, as seen by the client behind any proxy. */
+const originOf = (req: http.IncomingMessage) => {
+  const proto = firstHeader(req.headers['x-forwarded-proto'])?.split(',')[0] ?? 'http';
+  return `${proto}://${req.headers.host ?? 'localhost'}`;
+};
+
+const readJson = async (req: http.IncomingMessage): Promise<unknown> => {
+  const chunks: Buffer[] = [];
+  let size = 0;
+  for await (const chunk of req) {
+    size += (chunk as Buffer).length;
+    if (size > MAX_BODY_BYTES) {
+      throw new RangeError('The request body is too large.');
+    }
+    chunks.push(chunk as Buffer);
+  }
+  return JSON.parse(Buffer.concat(chunks).toString('utf-8'));
+};
+
+/**
+ * Creates the HTTP server of the remote deployment mode. Every request is
+ * authenticated, and each MCP session gets its own server whose commands run
+ * as the service account of the principal that started it. Sessions can only
+ * be used by that principal.
+ */
+export const createRemoteServer = (options: RemoteServerOptions) => {
+  const { config, createSessionServer, now = Date.now } = options;
+  const verify = options.verify ?? verifierFor(config);
+  const sessions = new Map<string, RemoteSession>();
+  const idleMs = (config.sessionIdleMinutes ?? DEFAULT_SESSION_IDLE_MINUTES) * 60 * 1000;
+
+  const resourceUrlOf = (req: http.IncomingMessage) =>
+    config.resourceUrl ?? `${originOf(req)}${MCP_PATH}`;
+
+  // OAuth clients find the protected resource metadata through the challenge
+  // of a rejected request. IAP authenticates callers before they reach the server.
+  const challengeOf = (req: http.IncomingMessage, invalidToken: boolean) => {
+    if (config.auth !== 'oidc') {
+      return {};
+    }
+    const metadataUrl = `${new URL(resourceUrlOf(req)).origin}${PROTECTED_RESOURCE_PATH}`;
+    const error = invalidToken ? ', error="invalid_token"' : '';
+    return { 'WWW-Authenticate': `Bearer resource_metadata="${metadataUrl}"${error}` };
+  };
+
+  const closeIdleSessions = async () => {
+    const idle = [...sessions.entries()].filter(
+      ([, session]) => now() - session.lastRequestAt > idleMs,
+    );
+    for (const [id, session] of idle) {
+      sessions.delete(id);
+      log.info('Remote session expired', { email: session.email });
+      await session.server.close();
+    }
+  };
+
+  const handleMcp = async (req: http.IncomingMessage, res: http.ServerResponse) => {
+    const auth = await authenticate(req.headers, config, verify);
+    if (!auth.authenticated) {
+      const invalidToken = auth.status === 401 && auth.message.startsWith('Invalid credentials');
+      sendJson(res, auth.status, { error: auth.message }, challengeOf(req, invalidToken));
+      return;
+    }
+    let body: unknown;
+    if (req.method === 'POST') {
+      try {
+        body = await readJson(req);
+      } catch (e: unknown) {
+        const message = e instanceof RangeError ? e.message : 'The request body is not valid JSON.';
+        sendJson(res, 400, { error: message });
+        return;
+      }
+    }
+
+    const sessionId = req.headers[SESSION_HEADER];
+    if (typeof sessionId === 'string') {
+      const session = sessions.get(sessionId);
+      if (!session) {
+        sendJson(res, 404, { error: `Unknown session "${sessionId}".` });
+        return;
+      }
+      if (session.email !== auth.principal.email) {
+        sendJson(res, 403, { error: 'The session belongs to another principal.' });
+        return;
+      }
+      session.lastRequestAt = now();
+      await session.transport.handleRequest(req, res, body);
+      return;
+    }
+
+    if (req.method !== 'POST' || !isInitializeRequest(body)) {
+      sendJson(res, 400, { error: 'Start a session with an initialize request.' });
+      return;
+    }
+    const { email } = auth.principal;
+    const server = createSessionServer(auth.serviceAccount, auth.principal);
+    const transport: StreamableHTTPServerTransport = new StreamableHTTPServerTransport({
+      sessionIdGenerator: () => randomUUID(),
+      onsessioninitialized: (id) => {
+        sessions.set(id, { email, server, transport, lastRequestAt: now() });
+        log.info('Remote session started', { email, serviceAccount: auth.serviceAccount });
+      },
+    });
+    transport.onclose = () => {
+      if (transport.sessionId) {
+        sessions.delete(transport.sessionId);
+      }
+    };
+    await server.connect(transport);
+    await transport.handleRequest(req, res, body);
+  };
+
+  const httpServer = http.createServer((req, res) => {
+    const path = new URL(req.url ?? '/', 'http://localhost').pathname;
+    if (path === HEALTH_PATH) {
+      // Liveness only: the health_check tool reports details to authenticated callers.
+      sendJson(res, 200, { status: 'ok', sessions: sessions.size });
+      return;
+    }
+    if (config.auth === 'oidc' && path.startsWith(PROTECTED_RESOURCE_PATH)) {
+      sendJson(res, 200, protectedResourceMetadata(config, resourceUrlOf(req)));
+      return;
+    }
+    if (path !== MCP_PATH) {
+      sendJson(res, 404, { error: 'Not found.' });
+      return;
+    }
+    handleMcp(req, res).catch((e: unknown) => {
+      log.error('Remote request failed', e instanceof Error ? e : new Error(String(e)));
+      if (!res.headersSent) {
+        sendJson(res, 500, { error: 'Internal server error.' });
+      }
+    });
+  });
+
+  const reaper = setInterval(
+    () => {
+      closeIdleSessions().catch((e: unknown) => {
+        log.error('Closing idle sessions failed', e instanceof Error ? e : new Error(String(e)));
+      });
+    },
+    Math.min(idleMs, 60 * 1000),
+  );
+  reaper.unref();
+
+  return {
+    httpServer,
+    sessions: () => sessions.size,
+    closeIdleSessions,
+    close: async () => {
+      clearInterval(reaper);
+      await Promise.all([...sessions.values()].map(({ server }) => server.close()));
+      sessions.clear();
+      await new Promise<void>((resolve) => httpServer.close(() => resolve()));
+    },
+  };
+};
@@ -0,0 +1,213 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { ASSET_TYPES } from './assets.js';
+import {
+  MAX_DIFFERENCES,
+  describeResource,
+  diffResources,
+  flattenResource,
+  resourceHistory,
+} from './resource_diff.js';
+
+vi.mock('./gcloud.js');
+
+const INSTANCE = ASSET_TYPES['compute.googleapis.com/Instance']!;
+const WEB_1 = { project: 'p', name: 'web-1', location: 'us-central1-a' };
+const WEB_2 = { project: 'p', name: 'web-2', location: 'us-central1-a' };
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+beforeEach(() => {
+  mockedGcloud = {
+    lint: vi.fn(),
+    invoke: vi.fn().mockResolvedValue({ code: 0, stdout: '{}', stderr: '' }),
+  };
+});
+
+describe('flattenResource', () => {
+  test('keys lists by name and drops server-populated fields', () => {
+    const fields = flattenResource({
+      id: '123',
+      name: 'web-1',
+      disks: [
+        { deviceName: 'boot', diskSizeGb: '10' },
+        { deviceName: 'data', diskSizeGb: '100' },
+      ],
+      tags: { items: ['http'], fingerprint: 'abc' },
+      metadata: { items: [{ key: 'startup-script', value: 'echo' }] },
+    });
+
+    expect(Object.fromEntries(fields)).toEqual({
+      name: 'web-1',
+      'disks[deviceName=boot].deviceName': 'boot',
+      'disks[deviceName=boot].diskSizeGb': '10',
+      'disks[deviceName=data].deviceName': 'data',
+      'disks[deviceName=data].diskSizeGb': '100',
+      'tags.items': ['http'],
+      'metadata.items[key=startup-script].key': 'startup-script',
+      'metadata.items[key=startup-script].value': 'echo',
+    });
+  });
+
+  test('keys lists by index without a unique name', () => {
+    const fields = flattenResource({ rules: [{ action: 'allow' }, { action: 'allow' }] });
+
+    expect([...fields.keys()]).toEqual(['rules[0].action', 'rules[1].action']);
+  });
+});
+
+describe('diffResources', () => {
+  const left = {
+    name: 'web-1',
+    creationTimestamp: '2025-01-01T00:00:00.000-07:00',
+    machineType: 'zones/us-central1-a/machineTypes/e2-small',
+    deletionProtection: true,
+    labels: { env: 'prod' },
+    disks: [{ deviceName: 'boot', source: 'projects/p/zones/us-central1-a/disks/web-1' }],
+  };
+  const right = {
+    name: 'web-2',
+    creationTimestamp: '2025-02-01T00:00:00.000-07:00',
+    machineType: 'zones/us-central1-a/machineTypes/e2-medium',
+    labels: { env: 'prod', team: 'web' },
+    disks: [{ deviceName: 'boot', source: 'projects/p/zones/us-central1-a/disks/web-2' }],
+  };
+
+  test('returns the differences between two resources', () => {
+    expect(diffResources(left, right, { refs: [WEB_1, WEB_2] })).toEqual({
+      identical: false,
+      counts: { changed: 1, onlyLeft: 1, onlyRight: 1 },
+      changed: [
+        {
+          path: 'machineType',
+          left: 'zones/us-central1-a/machineTypes/e2-small',
+          right: 'zones/us-central1-a/machineTypes/e2-medium',
+        },
+      ],
+      onlyLeft: [{ path: 'deletionProtection', value: true }],
+      onlyRight: [{ path: 'labels.team', value: 'web' }],
+    });
+  });
+
+  test('reports self-references without placeholders', () => {
+    const diff = diffResources(left, right);
+
+    expect(diff.changed.map(({ path }) => path)).toEqual([
+      'disks[deviceName=boot].source',
+      'machineType',
+      'name',
+    ]);
+  });
+
+  test('skips ignored fields', () => {
+    const diff = diffResources(left, right, {
+      ignoreFields: ['labels', 'deletionProtection'],
+      refs: [WEB_1, WEB_2],
+    });
+
+    expect(diff.counts).toEqual({ changed: 1, onlyLeft: 0, onlyRight: 0 });
+  });
+
+  test('reports identical resources', () => {
+    expect(diffResources(left, { ...left, id: '456' }).identical).toBe(true);
+  });
+
+  test('truncates long diffs', () => {
+    const many = Object.fromEntries(
+      Array.from({ length: MAX_DIFFERENCES + 1 }, (_, i) => [`field${i}`, i]),
+    );
+
+    const diff = diffResources({}, many);
+
+    expect(diff.counts.onlyRight).toBe(MAX_DIFFERENCES + 1);
+    expect(diff.onlyRight).toHaveLength(MAX_DIFFERENCES);
+    expect(diff.truncated).toBe(true);
+  });
+});
+
+describe('describeResource', () => {
+  test('describes the resource as JSON', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 0,
+      stdout: '{"name": "web-1"}',
+      stderr: '',
+    });
+
+    await expect(describeResource(mockedGcloud, INSTANCE, WEB_1)).resolves.toEqual({
+      name: 'web-1',
+    });
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'compute',
+      'instances',
+      'describe',
+      'web-1',
+      '--zone=us-central1-a',
+      '--project=p',
+      '--format=json',
+    ]);
+  });
+
+  test('throws if gcloud fails', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 1, stdout: '', stderr: 'not found' });
+
+    await expect(describeResource(mockedGcloud, INSTANCE, WEB_1)).rejects.toThrow('not found');
+  });
+});
+
+describe('resourceHistory', () => {
+  test('returns the snapshot and current versions', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 0,
+      stdout: JSON.stringify([
+        { asset: { resource: { data: { machineType: 'e2-small' } } } },
+        { asset: { resource: { data: { machineType: 'e2-medium' } } } },
+      ]),
+      stderr: '',
+    });
+
+    await expect(
+      resourceHistory(mockedGcloud, INSTANCE, WEB_1, '2025-01-01T00:00:00Z'),
+    ).resolves.toEqual({
+      snapshot: { machineType: 'e2-small' },
+      current: { machineType: 'e2-medium' },
+    });
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(
+      expect.arrayContaining([
+        'get-history',
+        '--asset-names=//compute.googleapis.com/projects/p/zones/us-central1-a/instances/web-1',
+        '--start-time=2025-01-01T00:00:00Z',
+      ]),
+    );
+  });
+
+  test('returns null for a deleted resource', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 0,
+      stdout: JSON.stringify([
+        { asset: { resource: { data: { machineType: 'e2-small' } } } },
+        { deleted: true, asset: {} },
+      ]),
+      stderr: '',
+    });
+
+    const history = await resourceHistory(mockedGcloud, INSTANCE, WEB_1, '2025-01-01T00:00:00Z');
+
+    expect(history.current).toBeNull();
+  });
+});
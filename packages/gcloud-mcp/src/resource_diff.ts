@@ -0,0 +1,239 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { AssetType } from './assets.js';
+
+// Fields the server populates or bumps on its own. They differ between any two
+// resources and between any two versions of a resource, so they only add noise.
+const NOISE_FIELDS = new Set([
+  'conditions',
+  'createTime',
+  'creationTimestamp',
+  'etag',
+  'generation',
+  'id',
+  'kind',
+  'lastStartTimestamp',
+  'lastStopTimestamp',
+  'metageneration',
+  'observedGeneration',
+  'resourceVersion',
+  'selfLink',
+  'selfLinkWithId',
+  'timeCreated',
+  'uid',
+  'updateTime',
+  'updated',
+]);
+
+// Arrays of objects are matched by these keys, so reordering is not a difference.
+const IDENTITY_KEYS = ['name', 'key', 'deviceName', 'id'];
+
+// The diff lists at most this many fields; the counts cover all of them.
+export const MAX_DIFFERENCES = 200;
+
+export interface ResourceRef {
+  project: string;
+  name: string;
+  location: string;
+}
+
+export interface ResourceDiff {
+  identical: boolean;
+  counts: { changed: number; onlyLeft: number; onlyRight: number };
+  changed: Array<{ path: string; left: unknown; right: unknown }>;
+  onlyLeft: Array<{ path: string; value: unknown }>;
+  onlyRight: Array<{ path: string; value: unknown }>;
+  truncated?: boolean;
+}
+
+const isNoiseField = (key: string) => NOISE_FIELDS.has(key) || /[fF]ingerprint$/.test(key);
+
+const isObject = (value: unknown): value is Record<string, unknown> =>
+  typeof value === 'object' && value !== null && !Array.isArray(value);
+
+const identityOf = (items: unknown[]): string | undefined =>
+  IDENTITY_KEYS.find((key) => {
+    const values = items.map((item) => (isObject(item) ? item[key] : undefined));
+    return (
+      values.every((v) => typeof v === 'string' || typeof v === 'number') &&
+      new Set(values).size === values.length
+    );
+  });
+
+/**
+ * Flattens a resource into a map from field paths, e.g.
+ * `disks[deviceName=boot].diskSizeGb`, to leaf values. Arrays of scalars are
+ * kept as leaves because their order is usually significant.
+ */
+export const flattenResource = (
+  value: unknown,
+  path = '',
+  out = new Map<string, unknown>(),
+): Map<string, unknown> => {
+  if (isObject(value)) {
+    for (const [key, child] of Object.entries(value)) {
+      if (!isNoiseField(key)) {
+        flattenResource(child, path ? `${path}.${key}` : key, out);
+      }
+    }
+    return out;
+  }
+  if (Array.isArray(value) && value.length > 0 && value.every(isObject)) {
+    const identity = identityOf(value);
+    value.forEach((item, i) => {
+      const key = identity ? `${identity}=${String(item[identity])}` : String(i);
+      flattenResource(item, `${path}[${key}]`, out);
+    });
+    return out;
+  }
+  out.set(path, value);
+  return out;
+};
+
+const isIgnored = (path: string, ignoreFields: string[]) =>
+  ignoreFields.some(
+    (field) => path === field || path.startsWith(`${field}.`) || path.startsWith(`${field}[`),
+  );
+
+const escapeRegExp = (value: string) => value.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+
+const wholeWord = (value: string) =>
+  new RegExp(`(?<![\\w-])${escapeRegExp(value)}(?![\\w-])`, 'g');
+
+/**
+ * Replaces whole occurrences of the resource's project and name in string
+ * values with placeholders, so references to the resource itself, like
+ * `projects/p/zones/z/disks/vm-1`, do not differ between two resources.
+ */
+const withPlaceholders = (fields: Map<string, unknown>, ref: ResourceRef) => {
+  const patterns: Array<[RegExp, string]> = [
+    [wholeWord(ref.project), '{project}'],
+    [wholeWord(ref.name), '{name}'],
+  ];
+  const replace = (value: unknown): unknown => {
+    if (typeof value === 'string') {
+      return patterns.reduce((s, [pattern, placeholder]) => s.replace(pattern, placeholder), value);
+    }
+    return Array.isArray(value) ? value.map(replace) : value;
+  };
+  return new Map([...fields].map(([path, value]) => [path, replace(value)]));
+};
+
+/**
+ * Returns the field-level differences between two resource descriptions,
+ * ignoring server-populated fields and the given field paths. If both
+ * references are given, each side's project and name are replaced with
+ * placeholders so that only configuration differences remain.
+ */
+export const diffResources = (
+  left: unknown,
+  right: unknown,
+  options: { ignoreFields?: string[]; refs?: [ResourceRef, ResourceRef] } = {},
+): ResourceDiff => {
+  const ignoreFields = options.ignoreFields ?? [];
+  let leftFields = flattenResource(left);
+  let rightFields = flattenResource(right);
+  if (options.refs) {
+    leftFields = withPlaceholders(leftFields, options.refs[0]);
+    rightFields = withPlaceholders(rightFields, options.refs[1]);
+  }
+
+  const changed: ResourceDiff['changed'] = [];
+  const onlyLeft: ResourceDiff['onlyLeft'] = [];
+  const onlyRight: ResourceDiff['onlyRight'] = [];
+  const paths = [...new Set([...leftFields.keys(), ...rightFields.keys()])].sort();
+  for (const path of paths.filter((p) => !isIgnored(p, ignoreFields))) {
+    const inLeft = leftFields.has(path);
+    const inRight = rightFields.has(path);
+    const leftValue = leftFields.get(path);
+    const rightValue = rightFields.get(path);
+    if (!inRight) {
+      onlyLeft.push({ path, value: leftValue });
+    } else if (!inLeft) {
+      onlyRight.push({ path, value: rightValue });
+    } else if (JSON.stringify(leftValue) !== JSON.stringify(rightValue)) {
+      changed.push({ path, left: leftValue, right: rightValue });
+    }
+  }
+
+  const total = changed.length + onlyLeft.length + onlyRight.length;
+  return {
+    identical: total === 0,
+    counts: { changed: changed.length, onlyLeft: onlyLeft.length, onlyRight: onlyRight.length },
+    changed: changed.slice(0, MAX_DIFFERENCES),
+    onlyLeft: onlyLeft.slice(0, MAX_DIFFERENCES),
+    onlyRight: onlyRight.slice(0, MAX_DIFFERENCES),
+    ...(total > MAX_DIFFERENCES && { truncated: true }),
+  };
+};
+
+/** Describes a resource with gcloud and returns its parsed JSON representation. */
+export const describeResource = async (
+  gcloud: GcloudExecutable,
+  type: AssetType,
+  ref: ResourceRef,
+): Promise<unknown> => {
+  const args = [
+    ...type.describeArgs(ref.name, ref.location),
+    `--project=${ref.project}`,
+    '--format=json',
+  ];
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  return JSON.parse(stdout);
+};
+
+const TemporalAssetSchema = z.object({
+  deleted: z.boolean().nullish(),
+  asset: z.object({ resource: z.object({ data: z.unknown() }).nullish() }).nullish(),
+});
+
+/**
+ * Returns the resource as it was at the given time and as it is now, from
+ * Cloud Asset Inventory history. Both come from the same API representation,
+ * so they can be compared field by field. Either is null if the resource did
+ * not exist at that time.
+ */
+export const resourceHistory = async (
+  gcloud: GcloudExecutable,
+  type: AssetType,
+  ref: ResourceRef,
+  snapshotTime: string,
+): Promise<{ snapshot: unknown; current: unknown }> => {
+  const args = [
+    'asset',
+    'get-history',
+    `--project=${ref.project}`,
+    `--asset-names=${type.fullName(ref.project, ref.name, ref.location)}`,
+    '--content-type=resource',
+    `--start-time=${snapshotTime}`,
+    '--format=json',
+  ];
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  // Versions are ordered by time: the first covers the start time, the last is current.
+  const versions = z.array(TemporalAssetSchema).parse(JSON.parse(stdout || '[]'));
+  const dataOf = (version: z.infer<typeof TemporalAssetSchema> | undefined) =>
+    version && !version.deleted ? (version.asset?.resource?.data ?? null) : null;
+  return { snapshot: dataOf(versions[0]), current: dataOf(versions.at(-1)) };
+};
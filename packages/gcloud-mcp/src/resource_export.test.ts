@@ -0,0 +1,107 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import * as gcloud from './gcloud.js';
+import { exportArgs, localManifest, storageManifest } from './resource_export.js';
+
+vi.mock('./gcloud.js');
+
+describe('exportArgs', () => {
+  test('exports a project to a local directory', () => {
+    expect(
+      exportArgs({
+        scope: { project: 'p' },
+        format: 'terraform',
+        resourceTypes: [],
+        destination: '/tmp/export',
+      }),
+    ).toEqual([
+      'beta',
+      'resource-config',
+      'bulk-export',
+      '--project=p',
+      '--resource-format=terraform',
+      '--path=/tmp/export',
+      '--quiet',
+    ]);
+  });
+
+  test('exports selected kinds of a folder to Cloud Storage', () => {
+    expect(
+      exportArgs({
+        scope: { folder: '123' },
+        format: 'krm',
+        resourceTypes: ['ComputeInstance', 'StorageBucket'],
+        destination: 'gs://bucket/exports',
+      }),
+    ).toEqual([
+      'beta',
+      'resource-config',
+      'bulk-export',
+      '--folder=123',
+      '--resource-format=krm',
+      '--storage-path=gs://bucket/exports',
+      '--resource-types=ComputeInstance,StorageBucket',
+      '--quiet',
+    ]);
+  });
+});
+
+describe('localManifest', () => {
+  test('lists the exported files', () => {
+    const dir = fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-'));
+    fs.mkdirSync(path.join(dir, 'ComputeInstance'));
+    fs.writeFileSync(path.join(dir, 'ComputeInstance', 'vm-1.tf'), 'resource {}');
+    fs.writeFileSync(path.join(dir, 'providers.tf'), '');
+
+    expect(localManifest(dir)).toEqual([
+      { path: path.join('ComputeInstance', 'vm-1.tf'), bytes: 11 },
+      { path: 'providers.tf', bytes: 0 },
+    ]);
+  });
+});
+
+describe('storageManifest', () => {
+  test('lists the exported objects relative to the URL', async () => {
+    const mockedGcloud: gcloud.GcloudExecutable = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({
+        code: 0,
+        stdout: JSON.stringify([
+          { name: 'exports/StorageBucket/logs.yaml', size: '42' },
+          { name: 'exports/ComputeInstance/vm-1.yaml', size: 7 },
+        ]),
+        stderr: '',
+      }),
+    };
+
+    await expect(storageManifest(mockedGcloud, 'gs://bucket/exports/')).resolves.toEqual([
+      { path: 'ComputeInstance/vm-1.yaml', bytes: 7 },
+      { path: 'StorageBucket/logs.yaml', bytes: 42 },
+    ]);
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'storage',
+      'objects',
+      'list',
+      'gs://bucket/exports/**',
+      '--format=json(name,size)',
+    ]);
+  });
+});
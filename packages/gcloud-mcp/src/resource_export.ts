@@ -0,0 +1,98 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import path from 'path';
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+
+export const RESOURCE_FORMATS = ['terraform', 'krm'] as const;
+
+export type ResourceFormat = (typeof RESOURCE_FORMATS)[number];
+
+export interface ExportRequest {
+  /** Exactly one of project, folder and organization. */
+  scope: { project: string } | { folder: string } | { organization: string };
+  format: ResourceFormat;
+  /** KRM kinds to export, e.g. `ComputeInstance`. Exports every supported kind if empty. */
+  resourceTypes: string[];
+  /** An absolute local directory or a `gs://` URL. */
+  destination: string;
+}
+
+export interface ManifestFile {
+  path: string;
+  bytes: number;
+}
+
+export const isStorageUrl = (destination: string) => destination.startsWith('gs://');
+
+/** Returns the bulk-export command for the request. */
+export const exportArgs = ({ scope, format, resourceTypes, destination }: ExportRequest) => [
+  'beta',
+  'resource-config',
+  'bulk-export',
+  ...Object.entries(scope).map(([key, value]) => `--${key}=${value}`),
+  `--resource-format=${format}`,
+  isStorageUrl(destination) ? `--storage-path=${destination}` : `--path=${destination}`,
+  ...(resourceTypes.length > 0 ? [`--resource-types=${resourceTypes.join(',')}`] : []),
+  '--quiet',
+];
+
+/** Lists the files below a local directory, relative to it, sorted by path. */
+export const localManifest = (dir: string): ManifestFile[] => {
+  const files: ManifestFile[] = [];
+  const walk = (current: string) => {
+    for (const entry of fs.readdirSync(current, { withFileTypes: true })) {
+      const full = path.join(current, entry.name);
+      if (entry.isDirectory()) {
+        walk(full);
+      } else if (entry.isFile()) {
+        files.push({ path: path.relative(dir, full), bytes: fs.statSync(full).size });
+      }
+    }
+  };
+  walk(dir);
+  return files.sort((a, b) => a.path.localeCompare(b.path));
+};
+
+const StorageObjectSchema = z.object({
+  name: z.string(),
+  size: z.union([z.number(), z.string()]).nullish(),
+});
+
+/** Lists the objects below a Cloud Storage URL, relative to it, sorted by path. */
+export const storageManifest = async (
+  gcloud: GcloudExecutable,
+  url: string,
+): Promise<ManifestFile[]> => {
+  const prefix = url.replace(/\/+$/, '');
+  const args = ['storage', 'objects', 'list', `${prefix}/**`, '--format=json(name,size)'];
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  // Object names exclude the bucket, e.g. `exports/ComputeInstance/vm-1.tf`.
+  const objectPrefix = prefix.replace(/^gs:\/\/[^/]+\/?/, '');
+  return z
+    .array(StorageObjectSchema)
+    .parse(JSON.parse(stdout || '[]'))
+    .map(({ name, size }) => ({
+      path: objectPrefix ? name.slice(objectPrefix.length + 1) : name,
+      bytes: Number(size ?? 0),
+    }))
+    .sort((a, b) => a.path.localeCompare(b.path));
+};
@@ -0,0 +1,190 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { createResourceWatcher, describeArgs, observedStateOf } from './resource_watch.js';
+
+vi.mock('./gcloud.js');
+
+const described = (resource: unknown) => ({
+  code: 0,
+  stdout: JSON.stringify(resource),
+  stderr: '',
+});
+
+describe('describeArgs', () => {
+  test('describes resources in their location', () => {
+    expect(describeArgs({ kind: 'build', name: 'b-1', location: 'us-central1' })).toEqual([
+      'builds',
+      'describe',
+      'b-1',
+      '--region=us-central1',
+      '--format=json',
+    ]);
+    expect(describeArgs({ kind: 'compute-operation', name: 'op-1', project: 'p' })).toEqual([
+      'compute',
+      'operations',
+      'describe',
+      'op-1',
+      '--global',
+      '--project=p',
+      '--format=json',
+    ]);
+    expect(
+      describeArgs({ kind: 'instance-group', name: 'web', location: 'us-central1-a' }),
+    ).toContain('--zone=us-central1-a');
+  });
+});
+
+describe('observedStateOf', () => {
+  test('reports builds', () => {
+    expect(observedStateOf('build', { status: 'WORKING' })).toEqual({
+      state: 'WORKING',
+      terminal: false,
+      succeeded: false,
+    });
+    expect(observedStateOf('build', { status: 'FAILURE', statusDetail: 'step 2 failed' })).toEqual(
+      { state: 'FAILURE', terminal: true, succeeded: false, detail: 'step 2 failed' },
+    );
+  });
+
+  test('reports operations', () => {
+    expect(observedStateOf('compute-operation', { status: 'DONE' })).toMatchObject({
+      terminal: true,
+      succeeded: true,
+    });
+    expect(
+      observedStateOf('container-operation', {
+        status: 'DONE',
+        error: { message: 'quota exceeded' },
+      }),
+    ).toEqual({ state: 'DONE', terminal: true, succeeded: false, detail: 'quota exceeded' });
+  });
+
+  test('reports Cloud Run rollouts once the new generation is observed', () => {
+    const service = (observedGeneration: number, status: string) => ({
+      metadata: { generation: 2 },
+      status: { observedGeneration, conditions: [{ type: 'Ready', status }] },
+    });
+
+    expect(observedStateOf('run-service', service(1, 'True')).state).toBe('DEPLOYING');
+    expect(observedStateOf('run-service', service(2, 'Unknown')).state).toBe('DEPLOYING');
+    expect(observedStateOf('run-service', service(2, 'True'))).toEqual({
+      state: 'READY',
+      terminal: true,
+      succeeded: true,
+    });
+    expect(observedStateOf('run-service', service(2, 'False')).state).toBe('FAILED');
+  });
+
+  test('reports managed instance groups', () => {
+    expect(observedStateOf('instance-group', { status: { isStable: false } }).state).toBe(
+      'UPDATING',
+    );
+    expect(observedStateOf('instance-group', { status: { isStable: true } }).terminal).toBe(true);
+  });
+});
+
+describe('createResourceWatcher', () => {
+  let mockedGcloud: gcloud.GcloudExecutable;
+  const onTransition = vi.fn();
+
+  beforeEach(() => {
+    vi.useFakeTimers();
+    onTransition.mockClear();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue(described({ status: 'QUEUED' })),
+    };
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  test('reports transitions until the resource is done', async () => {
+    const watcher = createResourceWatcher(mockedGcloud, onTransition);
+    const watch = await watcher.start(
+      { kind: 'build', name: 'b-1' },
+      { pollMs: 1000, timeoutMs: 60000 },
+    );
+    expect(watch).toMatchObject({ id: '1', uri: 'gcloud-watch://1', state: 'QUEUED' });
+
+    await vi.advanceTimersByTimeAsync(1000);
+    expect(onTransition).not.toHaveBeenCalled();
+
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue(described({ status: 'WORKING' }));
+    await vi.advanceTimersByTimeAsync(1000);
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue(described({ status: 'SUCCESS' }));
+    await vi.advanceTimersByTimeAsync(1000);
+
+    expect(onTransition).toHaveBeenCalledTimes(2);
+    expect(watcher.get('1')).toMatchObject({ state: 'SUCCESS', terminal: true, succeeded: true });
+    expect(watcher.get('1')?.transitions.map(({ state }) => state)).toEqual([
+      'QUEUED',
+      'WORKING',
+      'SUCCESS',
+    ]);
+
+    await vi.advanceTimersByTimeAsync(5000);
+    expect(mockedGcloud.invoke).toHaveBeenCalledTimes(4);
+  });
+
+  test('times out', async () => {
+    const watcher = createResourceWatcher(mockedGcloud, onTransition);
+    await watcher.start({ kind: 'build', name: 'b-1' }, { pollMs: 1000, timeoutMs: 2500 });
+
+    await vi.advanceTimersByTimeAsync(3000);
+
+    expect(watcher.get('1')).toMatchObject({ state: 'TIMED_OUT', terminal: true });
+    expect(onTransition).toHaveBeenCalledOnce();
+  });
+
+  test('keeps polling after a failed poll', async () => {
+    const watcher = createResourceWatcher(mockedGcloud, onTransition);
+    await watcher.start({ kind: 'build', name: 'b-1' }, { pollMs: 1000, timeoutMs: 60000 });
+
+    vi.mocked(mockedGcloud.invoke).mockResolvedValueOnce({ code: 1, stdout: '', stderr: 'x' });
+    await vi.advanceTimersByTimeAsync(2000);
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledTimes(3);
+  });
+
+  test('stops polling', async () => {
+    const watcher = createResourceWatcher(mockedGcloud, onTransition);
+    await watcher.start({ kind: 'build', name: 'b-1' }, { pollMs: 1000, timeoutMs: 60000 });
+
+    watcher.stopAll();
+    await vi.advanceTimersByTimeAsync(5000);
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+  });
+
+  test('does not poll resources that are already done', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue(described({ status: 'SUCCESS' }));
+    const watcher = createResourceWatcher(mockedGcloud, onTransition);
+
+    const watch = await watcher.start(
+      { kind: 'build', name: 'b-1' },
+      { pollMs: 1000, timeoutMs: 60000 },
+    );
+    await vi.advanceTimersByTimeAsync(5000);
+
+    expect(watch.terminal).toBe(true);
+    expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+  });
+});
@@ -0,0 +1,287 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { isZone } from './gcloud_json.js';
+import { log } from './utility/logger.js';
+
+export const RESOURCE_WATCH_SCHEME = 'gcloud-watch';
+
+export const WATCH_KINDS = [
+  'build',
+  'compute-operation',
+  'container-operation',
+  'run-service',
+  'instance-group',
+] as const;
+
+export type WatchKind = (typeof WATCH_KINDS)[number];
+
+/** The gcloud command each kind of watch polls. */
+export const WATCH_COMMANDS: Record<WatchKind, string> = {
+  build: 'builds describe',
+  'compute-operation': 'compute operations describe',
+  'container-operation': 'container operations describe',
+  'run-service': 'run services describe',
+  'instance-group': 'compute instance-groups managed describe',
+};
+
+export interface WatchTarget {
+  kind: WatchKind;
+  name: string;
+  project?: string;
+  /** The zone or region of the resource, if it has one. */
+  location?: string;
+}
+
+export interface ObservedState {
+  state: string;
+  /** True once the state will not change anymore. */
+  terminal: boolean;
+  succeeded: boolean;
+  detail?: string;
+}
+
+export interface ResourceWatch {
+  id: string;
+  uri: string;
+  target: WatchTarget;
+  state: string;
+  terminal: boolean;
+  succeeded: boolean;
+  startedAt: string;
+  deadline: string;
+  transitions: Array<{ state: string; at: string; detail?: string }>;
+}
+
+const locationFlags: Record<WatchKind, (location?: string) => string[]> = {
+  build: (location) => (location ? [`--region=${location}`] : []),
+  'compute-operation': (location) => {
+    if (!location) {
+      return ['--global'];
+    }
+    return [isZone(location) ? `--zone=${location}` : `--region=${location}`];
+  },
+  'container-operation': (location) => (location ? [`--location=${location}`] : []),
+  'run-service': (location) => (location ? [`--region=${location}`] : []),
+  'instance-group': (location) => {
+    if (!location) {
+      return [];
+    }
+    return [isZone(location) ? `--zone=${location}` : `--region=${location}`];
+  },
+};
+
+/** Returns the command that describes the watched resource. */
+export const describeArgs = ({ kind, name, project, location }: WatchTarget): string[] => [
+  ...WATCH_COMMANDS[kind].split(' '),
+  name,
+  ...locationFlags[kind](location),
+  ...(project ? [`--project=${project}`] : []),
+  '--format=json',
+];
+
+const BuildSchema = z.object({ status: z.string(), statusDetail: z.string().nullish() });
+
+const OperationSchema = z.object({
+  status: z.string(),
+  statusMessage: z.string().nullish(),
+  error: z
+    .object({
+      message: z.string().nullish(),
+      errors: z.array(z.object({ message: z.string().nullish() })).nullish(),
+    })
+    .nullish(),
+});
+
+const ServiceSchema = z.object({
+  metadata: z.object({ generation: z.number().nullish() }),
+  status: z
+    .object({
+      observedGeneration: z.number().nullish(),
+      conditions: z
+        .array(z.object({ type: z.string(), status: z.string(), message: z.string().nullish() }))
+        .nullish(),
+    })
+    .nullish(),
+});
+
+const InstanceGroupSchema = z.object({
+  status: z.object({ isStable: z.boolean().nullish() }).nullish(),
+});
+
+const ACTIVE_BUILD_STATES = ['PENDING', 'QUEUED', 'WORKING'];
+
+const operationState = (resource: unknown): ObservedState => {
+  const { status, statusMessage, error } = OperationSchema.parse(resource);
+  const detail = error?.message ?? error?.errors?.[0]?.message ?? statusMessage;
+  return {
+    state: status,
+    terminal: status === 'DONE',
+    succeeded: status === 'DONE' && !error,
+    ...(detail && { detail }),
+  };
+};
+
+const STATE_OF: Record<WatchKind, (resource: unknown) => ObservedState> = {
+  build: (resource) => {
+    const { status, statusDetail } = BuildSchema.parse(resource);
+    return {
+      state: status,
+      terminal: !ACTIVE_BUILD_STATES.includes(status),
+      succeeded: status === 'SUCCESS',
+      ...(statusDetail && { detail: statusDetail }),
+    };
+  },
+  'compute-operation': operationState,
+  'container-operation': operationState,
+  'run-service': (resource) => {
+    const { metadata, status } = ServiceSchema.parse(resource);
+    const ready = status?.conditions?.find(({ type }) => type === 'Ready');
+    // Conditions describe an older revision until the new generation is observed.
+    const current = status?.observedGeneration === metadata.generation;
+    if (!ready || !current || ready.status === 'Unknown') {
+      return { state: 'DEPLOYING', terminal: false, succeeded: false };
+    }
+    const succeeded = ready.status === 'True';
+    return {
+      state: succeeded ? 'READY' : 'FAILED',
+      terminal: true,
+      succeeded,
+      ...(ready.message && { detail: ready.message }),
+    };
+  },
+  'instance-group': (resource) => {
+    const stable = !!InstanceGroupSchema.parse(resource).status?.isStable;
+    return { state: stable ? 'STABLE' : 'UPDATING', terminal: stable, succeeded: stable };
+  },
+};
+
+/** Returns the state of a described resource. */
+export const observedStateOf = (kind: WatchKind, resource: unknown): ObservedState =>
+  STATE_OF[kind](resource);
+
+/** Describes the watched resource and returns its current state. */
+export const observeResource = async (
+  gcloud: GcloudExecutable,
+  target: WatchTarget,
+): Promise<ObservedState> => {
+  const args = describeArgs(target);
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  return observedStateOf(target.kind, JSON.parse(stdout));
+};
+
+export type ResourceWatcher = ReturnType<typeof createResourceWatcher>;
+
+/**
+ * Creates the watcher that polls resources until they reach a terminal state
+ * or the watch times out, and reports every state transition.
+ *
+ * @param onTransition Called with the watch after each state change.
+ */
+export const createResourceWatcher = (
+  gcloud: GcloudExecutable,
+  onTransition: (watch: ResourceWatch) => void,
+) => {
+  const watches = new Map<string, ResourceWatch>();
+  const timers = new Map<string, NodeJS.Timeout>();
+  let nextId = 1;
+
+  const transition = (watch: ResourceWatch, observed: ObservedState) => {
+    watch.state = observed.state;
+    watch.terminal = observed.terminal;
+    watch.succeeded = observed.succeeded;
+    watch.transitions.push({
+      state: observed.state,
+      at: new Date().toISOString(),
+      ...(observed.detail && { detail: observed.detail }),
+    });
+    onTransition(watch);
+  };
+
+  const schedule = (watch: ResourceWatch, pollMs: number) => {
+    const timer = setTimeout(() => {
+      observeResource(gcloud, watch.target)
+        .catch((e: unknown) => {
+          log.warn(`Unable to poll watch ${watch.id}`, { error: String(e) });
+          return undefined;
+        })
+        .then((observed) => {
+          if (!timers.has(watch.id)) {
+            return;
+          }
+          if (observed && observed.state !== watch.state) {
+            transition(watch, observed);
+          }
+          if (!watch.terminal && Date.now() >= Date.parse(watch.deadline)) {
+            transition(watch, { state: 'TIMED_OUT', terminal: true, succeeded: false });
+          }
+          if (watch.terminal) {
+            timers.delete(watch.id);
+          } else {
+            schedule(watch, pollMs);
+          }
+        });
+    }, pollMs);
+    timer.unref();
+    timers.set(watch.id, timer);
+  };
+
+  return {
+    /** Observes the resource once and, unless it is already done, polls it until it is. */
+    start: async (
+      target: WatchTarget,
+      options: { pollMs: number; timeoutMs: number },
+    ): Promise<ResourceWatch> => {
+      const observed = await observeResource(gcloud, target);
+      const id = String(nextId++);
+      const startedAt = new Date();
+      const watch: ResourceWatch = {
+        id,
+        uri: `${RESOURCE_WATCH_SCHEME}://${id}`,
+        target,
+        state: observed.state,
+        terminal: observed.terminal,
+        succeeded: observed.succeeded,
+        startedAt: startedAt.toISOString(),
+        deadline: new Date(startedAt.getTime() + options.timeoutMs).toISOString(),
+        transitions: [
+          {
+            state: observed.state,
+            at: startedAt.toISOString(),
+            ...(observed.detail && { detail: observed.detail }),
+          },
+        ],
+      };
+      watches.set(id, watch);
+      if (!watch.terminal) {
+        schedule(watch, options.pollMs);
+      }
+      return watch;
+    },
+    get: (id: string): ResourceWatch | undefined => watches.get(id),
+    list: (): ResourceWatch[] => [...watches.values()],
+    /** Stops polling every watch, e.g. when the session closes. */
+    stopAll: () => {
+      timers.forEach((timer) => clearTimeout(timer));
+      timers.clear();
+    },
+  };
+};
@@ -0,0 +1,121 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, test, expect } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import {
+  connectivityFailureOf,
+  createResponseCache,
+  forSession,
+  isCacheable,
+  staleBlock,
+  validateOffline,
+} from './response_cache.js';
+
+const HOUR_MS = 60 * 60 * 1000;
+
+describe('connectivityFailureOf', () => {
+  test('classifies network and credential failures', () => {
+    expect(
+      connectivityFailureOf(
+        "ERROR: gcloud crashed (ConnectionError): HTTPSConnectionPool(host='oauth2.googleapis.com', port=443): Max retries exceeded",
+      ),
+    ).toBe('NETWORK_UNAVAILABLE');
+    expect(
+      connectivityFailureOf(
+        'ERROR: (gcloud.compute.instances.list) There was a problem refreshing your current auth tokens: Reauthentication failed.',
+      ),
+    ).toBe('CREDENTIALS_UNAVAILABLE');
+    expect(connectivityFailureOf('ERROR: (gcloud.compute.instances.describe) NOT_FOUND')).toBe(
+      undefined,
+    );
+  });
+});
+
+describe('isCacheable', () => {
+  test('excludes commands printing credentials', () => {
+    expect(isCacheable('compute instances list')).toBe(true);
+    expect(isCacheable('auth print-access-token')).toBe(false);
+    expect(isCacheable('secrets versions access')).toBe(false);
+  });
+});
+
+describe('createResponseCache', () => {
+  test('keys results by arguments and session environment', () => {
+    const cache = createResponseCache({});
+    let project = 'dev';
+    const session = forSession(cache, () => ({ CLOUDSDK_CORE_PROJECT: project }));
+
+    session.store(['compute', 'instances', 'list'], {}, 'dev instances');
+    project = 'prod';
+
+    expect(session.lookup(['compute', 'instances', 'list'], {})).toBe(undefined);
+    project = 'dev';
+    expect(session.lookup(['compute', 'instances', 'list'], {})?.stdout).toBe('dev instances');
+  });
+
+  test('evicts the oldest entries and expires old results', () => {
+    let now = Date.parse('2025-01-01T00:00:00.000Z');
+    const cache = createResponseCache({ maxEntries: 2, maxAgeHours: 1 }, () => now);
+
+    cache.store(['a'], {}, 'a');
+    cache.store(['b'], {}, 'b');
+    cache.store(['c'], {}, 'c');
+
+    expect(cache.size()).toBe(2);
+    expect(cache.lookup(['a'], {})).toBe(undefined);
+    expect(cache.lookup(['c'], {})?.stdout).toBe('c');
+    now += 2 * HOUR_MS;
+    expect(cache.lookup(['c'], {})).toBe(undefined);
+  });
+
+  test('persists results to the cache file', () => {
+    const dir = fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-'));
+    const cacheFile = path.join(dir, 'cache.json');
+
+    createResponseCache({ cacheFile }).store(['projects', 'list'], {}, 'my-project');
+
+    expect(createResponseCache({ cacheFile }).lookup(['projects', 'list'], {})?.stdout).toBe(
+      'my-project',
+    );
+  });
+});
+
+describe('staleBlock', () => {
+  test('reports the age of the cached result', () => {
+    const block = staleBlock(
+      { stdout: '', cachedAt: '2025-01-01T00:00:00.000Z' },
+      'NETWORK_UNAVAILABLE',
+      Date.parse('2025-01-01T01:30:00.000Z'),
+    );
+
+    expect(block.startsWith('\nSTALE:\n')).toBe(true);
+    expect(JSON.parse(block.slice('\nSTALE:\n'.length))).toMatchObject({
+      reason: 'NETWORK_UNAVAILABLE',
+      ageMinutes: 90,
+    });
+  });
+});
+
+describe('validateOffline', () => {
+  test('requires an absolute cache file', () => {
+    expect(validateOffline({ cacheFile: 'cache.json' })).toContain('must be absolute');
+    expect(validateOffline({ maxEntries: 0 })).toContain('at least 1');
+    expect(validateOffline({ cacheFile: '/tmp/cache.json' })).toBe(undefined);
+  });
+});
@@ -0,0 +1,167 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import path from 'path';
+import { log } from './utility/logger.js';
+
+export interface OfflineConfig {
+  /** Persists cached results so they survive restarts. */
+  cacheFile?: string;
+  /** Defaults to DEFAULT_MAX_CACHE_ENTRIES. */
+  maxEntries?: number;
+  /** Cached results older than this are discarded. Defaults to 7 days. */
+  maxAgeHours?: number;
+}
+
+export type ConnectivityFailure = 'NETWORK_UNAVAILABLE' | 'CREDENTIALS_UNAVAILABLE';
+
+export interface CachedResult {
+  stdout: string;
+  cachedAt: string;
+}
+
+export const DEFAULT_MAX_CACHE_ENTRIES = 500;
+const DEFAULT_MAX_AGE_HOURS = 7 * 24;
+
+// Read commands whose output is a credential, and so must never be stored.
+const NEVER_CACHED = ['auth', 'config config-helper', 'secrets versions access'];
+
+const FAILURE_PATTERNS: Array<{ failure: ConnectivityFailure; pattern: RegExp }> = [
+  {
+    failure: 'CREDENTIALS_UNAVAILABLE',
+    pattern:
+      /problem refreshing your current auth tokens|Reauthentication (failed|required)|invalid_grant|do not currently have an active account selected/i,
+  },
+  {
+    failure: 'NETWORK_UNAVAILABLE',
+    pattern:
+      /Failed to (connect|establish a new connection)|Unable to find the server|ConnectionError|Connection (refused|reset|aborted)|Name or service not known|getaddrinfo|Temporary failure in name resolution|Network is unreachable|Max retries exceeded|Read timed out/i,
+  },
+];
+
+/** Returns why a command failed if the network or the credentials were unavailable. */
+export const connectivityFailureOf = (stderr: string): ConnectivityFailure | undefined =>
+  FAILURE_PATTERNS.find(({ pattern }) => pattern.test(stderr))?.failure;
+
+export const isCacheable = (parsedCommand: string): boolean =>
+  !NEVER_CACHED.some(
+    (prefix) => parsedCommand === prefix || parsedCommand.startsWith(`${prefix} `),
+  );
+
+export const validateOffline = (config: OfflineConfig): string | undefined => {
+  if (config.cacheFile && !path.isAbsolute(config.cacheFile)) {
+    return `Offline cache file path must be absolute: ${config.cacheFile}`;
+  }
+  if (config.maxEntries !== undefined && !(config.maxEntries >= 1)) {
+    return 'The offline cache "maxEntries" must be at least 1.';
+  }
+  return undefined;
+};
+
+const readEntries = (file: string): Array<[string, CachedResult]> => {
+  try {
+    return fs.existsSync(file)
+      ? (JSON.parse(fs.readFileSync(file, 'utf-8')) as Array<[string, CachedResult]>)
+      : [];
+  } catch (e: unknown) {
+    log.warn(`Ignoring the unreadable offline cache ${file}`, { error: String(e) });
+    return [];
+  }
+};
+
+export type ResponseCache = ReturnType<typeof createResponseCache>;
+
+/**
+ * Creates the cache of read command results served when the network or the
+ * credentials are unavailable. Results are keyed by the arguments and the
+ * environment overrides, which include the session project.
+ */
+export const createResponseCache = (config: OfflineConfig, now: () => number = Date.now) => {
+  const maxEntries = config.maxEntries ?? DEFAULT_MAX_CACHE_ENTRIES;
+  const maxAgeMs = (config.maxAgeHours ?? DEFAULT_MAX_AGE_HOURS) * 60 * 60 * 1000;
+  const entries = new Map(config.cacheFile ? readEntries(config.cacheFile) : []);
+  let fileFailed = false;
+
+  const keyOf = (args: string[], env: NodeJS.ProcessEnv) =>
+    JSON.stringify([args, Object.entries(env).sort(([a], [b]) => a.localeCompare(b))]);
+
+  const persist = () => {
+    if (!config.cacheFile || fileFailed) {
+      return;
+    }
+    try {
+      fs.writeFileSync(config.cacheFile, JSON.stringify([...entries]));
+    } catch (e: unknown) {
+      // The cache must never break the commands, so a broken file is reported once.
+      fileFailed = true;
+      log.warn(`Unable to write the offline cache to ${config.cacheFile}`, { error: String(e) });
+    }
+  };
+
+  return {
+    store: (args: string[], env: NodeJS.ProcessEnv, stdout: string) => {
+      const key = keyOf(args, env);
+      // Re-inserting moves the entry to the end, so the least recently stored is evicted first.
+      entries.delete(key);
+      entries.set(key, { stdout, cachedAt: new Date(now()).toISOString() });
+      for (const oldest of entries.keys()) {
+        if (entries.size <= maxEntries) {
+          break;
+        }
+        entries.delete(oldest);
+      }
+      persist();
+    },
+    lookup: (args: string[], env: NodeJS.ProcessEnv): CachedResult | undefined => {
+      const cached = entries.get(keyOf(args, env));
+      if (!cached || now() - Date.parse(cached.cachedAt) > maxAgeMs) {
+        return undefined;
+      }
+      return cached;
+    },
+    size: () => entries.size,
+  };
+};
+
+export type SessionResponseCache = Pick<ResponseCache, 'store' | 'lookup'>;
+
+/** Scopes a cache to a session, whose environment overrides are part of every key. */
+export const forSession = (
+  cache: ResponseCache,
+  contextEnv: () => NodeJS.ProcessEnv,
+): SessionResponseCache => ({
+  store: (args, env, stdout) => cache.store(args, { ...contextEnv(), ...env }, stdout),
+  lookup: (args, env) => cache.lookup(args, { ...contextEnv(), ...env }),
+});
+
+/** Returns the block marking a result served from the cache. */
+export const staleBlock = (
+  cached: CachedResult,
+  failure: ConnectivityFailure,
+  now: number = Date.now(),
+) =>
+  `\nSTALE:\n${JSON.stringify(
+    {
+      reason: failure,
+      cachedAt: cached.cachedAt,
+      ageMinutes: Math.round((now - Date.parse(cached.cachedAt)) / 60000),
+      message:
+        'Google Cloud could not be reached, so this is the cached result of an earlier run. It may be out of date.',
+    },
+    null,
+    2,
+  )}`;
@@ -0,0 +1,304 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { Mock, afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import {
+  Runbook,
+  createRunbookEngine,
+  loadRunbooks,
+  parseRunbook,
+  renderArguments,
+  resolveParameters,
+} from './runbooks.js';
+import { ToolRegistry } from './scheduler.js';
+
+const RESIZE = `
+name: resize-pool
+title: Resize a node pool
+description: Resizes a GKE node pool after checking the cluster is healthy.
+parameters:
+  cluster:
+    description: The name of the cluster.
+  nodes:
+    default: "3"
+steps:
+  - id: describe
+    tool: run_gcloud_command
+    arguments:
+      args: [container, clusters, describe, "{{ parameters.cluster }}", --format=json]
+  - id: resize
+    description: Resize the default pool.
+    tool: run_gcloud_command
+    when: { step: describe, contains: RUNNING }
+    confirm: true
+    arguments:
+      args:
+        - container
+        - clusters
+        - resize
+        - "{{ parameters.cluster }}"
+        - "--num-nodes={{ parameters.nodes }}"
+        - "--location={{ steps.describe.output.location }}"
+    rollback:
+      tool: run_gcloud_command
+      arguments:
+        args: [container, clusters, resize, "{{ parameters.cluster }}", --num-nodes=1]
+  - id: verify
+    tool: run_gcloud_command
+    arguments:
+      args: [container, node-pools, list, "--cluster={{ parameters.cluster }}"]
+`;
+
+const textResult = (text: string, isError = false) => ({
+  content: [{ type: 'text', text }],
+  ...(isError && { isError }),
+});
+
+describe('parseRunbook', () => {
+  test('parses a runbook with defaults', () => {
+    const runbook = parseRunbook(RESIZE);
+    expect(runbook.name).toBe('resize-pool');
+    expect(runbook.steps.map(({ id, confirm }) => [id, confirm])).toEqual([
+      ['describe', false],
+      ['resize', true],
+      ['verify', false],
+    ]);
+  });
+
+  test('rejects unknown fields and invalid names', () => {
+    expect(() => parseRunbook(RESIZE.replace('title:', 'titel:'))).toThrow('Unrecognized key');
+    expect(() => parseRunbook(RESIZE.replace('name: resize-pool', 'name: Resize'))).toThrow(
+      'Invalid runbook name "Resize"',
+    );
+  });
+
+  test('rejects conditions and references that are not earlier steps', () => {
+    expect(() => parseRunbook(RESIZE.replace('step: describe', 'step: verify'))).toThrow(
+      'must refer to an earlier step, not "verify"',
+    );
+    const laterStep = RESIZE.replace('steps.describe.output', 'steps.verify.output');
+    expect(() => parseRunbook(laterStep)).toThrow(
+      'Invalid reference "{{ steps.verify.output.location }}"',
+    );
+    expect(() => parseRunbook(RESIZE.replace('parameters.nodes', 'parameters.count'))).toThrow(
+      'Invalid reference "{{ parameters.count }}"',
+    );
+  });
+
+  test('rejects steps that pass confirm or run other runbooks', () => {
+    expect(() =>
+      parseRunbook(RESIZE.replace('args: [container, node-pools', 'confirm: true\n      args: [x')),
+    ).toThrow('can not pass "confirm"');
+    const nested = RESIZE.replace(/tool: run_gcloud_command$/m, 'tool: run_runbook');
+    expect(() => parseRunbook(nested)).toThrow('can not run other runbooks');
+  });
+});
+
+describe('loadRunbooks', () => {
+  let dir: string;
+
+  beforeEach(() => {
+    dir = fs.mkdtempSync(path.join(os.tmpdir(), 'runbooks-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(dir, { recursive: true, force: true });
+  });
+
+  test('loads the YAML files of the directory', () => {
+    fs.writeFileSync(path.join(dir, 'resize.yaml'), RESIZE);
+    fs.writeFileSync(path.join(dir, 'README.md'), '# Runbooks');
+    expect(loadRunbooks(dir).map(({ name }) => name)).toEqual(['resize-pool']);
+  });
+
+  test('names the file of an invalid or duplicate runbook', () => {
+    fs.writeFileSync(path.join(dir, 'a.yaml'), RESIZE);
+    fs.writeFileSync(path.join(dir, 'b.yml'), RESIZE);
+    expect(() => loadRunbooks(dir)).toThrow('Invalid runbook b.yml: another runbook is named');
+    fs.writeFileSync(path.join(dir, 'b.yml'), 'name: [unclosed');
+    expect(() => loadRunbooks(dir)).toThrow('Invalid runbook b.yml');
+  });
+});
+
+describe('resolveParameters', () => {
+  test('applies defaults and reports missing and unknown parameters', () => {
+    const runbook = parseRunbook(RESIZE);
+    expect(resolveParameters(runbook, { cluster: 'prod' })).toEqual({
+      cluster: 'prod',
+      nodes: '3',
+    });
+    expect(resolveParameters(runbook, {})).toContain('requires the parameters cluster');
+    expect(resolveParameters(runbook, { cluster: 'prod', zone: 'a' })).toContain(
+      'has no parameters named zone',
+    );
+  });
+});
+
+describe('renderArguments', () => {
+  const run = {
+    parameters: { cluster: 'prod' },
+    steps: [{ id: 'list', status: 'succeeded', output: '{"items":[{"name":"a"}],"count":1}' }],
+  } as unknown as Parameters<typeof renderArguments>[1];
+
+  test('substitutes parameters and fields of JSON output', () => {
+    expect(
+      renderArguments(
+        {
+          args: ['{{ parameters.cluster }}', '--name={{ steps.list.output.items.0.name }}'],
+          items: '{{ steps.list.output.items }}',
+          count: '{{steps.list.output.count}}',
+        },
+        run,
+      ),
+    ).toEqual({
+      args: ['prod', '--name=a'],
+      items: [{ name: 'a' }],
+      count: 1,
+    });
+  });
+
+  test('throws for missing fields', () => {
+    expect(() => renderArguments('{{ steps.list.output.missing }}', run)).toThrow(
+      'has no field missing',
+    );
+  });
+});
+
+describe('createRunbookEngine', () => {
+  let runbook: Runbook;
+  let registry: ToolRegistry;
+
+  beforeEach(() => {
+    runbook = parseRunbook(RESIZE);
+    registry = {
+      has: vi.fn().mockReturnValue(true),
+      call: vi
+        .fn()
+        .mockResolvedValueOnce(textResult('{"status":"RUNNING","location":"us-central1"}'))
+        .mockResolvedValue(textResult('done')),
+    };
+  });
+
+  test('stops for confirmation and resumes with the approved step', async () => {
+    const engine = createRunbookEngine([runbook], registry);
+    const onStep = vi.fn();
+
+    const started = await engine.start('resize-pool', { cluster: 'prod' }, { onStep });
+
+    expect(started).toMatchObject({
+      success: true,
+      run: {
+        id: 'resize-pool-1',
+        status: 'awaiting-confirmation',
+        pending: {
+          step: 'resize',
+          arguments: {
+            args: [
+              'container',
+              'clusters',
+              'resize',
+              'prod',
+              '--num-nodes=3',
+              '--location=us-central1',
+            ],
+          },
+        },
+      },
+    });
+    expect(onStep).toHaveBeenCalledTimes(1);
+
+    const resumed = await engine.resume('resize-pool-1', { confirm: true });
+
+    expect(resumed).toMatchObject({ success: true, run: { status: 'succeeded' } });
+    expect((registry.call as Mock).mock.calls[1]).toEqual([
+      'run_gcloud_command',
+      expect.objectContaining({ confirm: true }),
+    ]);
+    expect((registry.call as Mock).mock.calls[2]?.[1]).not.toHaveProperty('confirm');
+  });
+
+  test('skips steps whose condition does not hold', async () => {
+    vi.mocked(registry.call).mockReset().mockResolvedValue(textResult('{"status":"ERROR"}'));
+    const engine = createRunbookEngine([runbook], registry);
+
+    const result = await engine.start('resize-pool', { cluster: 'prod' });
+
+    expect(result.success && result.run.steps.map(({ status }) => status)).toEqual([
+      'succeeded',
+      'skipped',
+      'succeeded',
+    ]);
+  });
+
+  test('fails at a failed step and retries it on resume', async () => {
+    vi.mocked(registry.call)
+      .mockReset()
+      .mockResolvedValueOnce(textResult('NOT_FOUND', true))
+      .mockResolvedValue(textResult('{"status":"RUNNING","location":"us-central1"}'));
+    const engine = createRunbookEngine([runbook], registry);
+
+    const failed = await engine.start('resize-pool', { cluster: 'prod' });
+
+    expect(failed).toMatchObject({
+      success: true,
+      run: { status: 'failed', next: 0, error: 'Step "describe" failed.' },
+    });
+    expect(await engine.resume('resize-pool-1')).toMatchObject({
+      success: true,
+      run: { status: 'awaiting-confirmation', next: 1 },
+    });
+  });
+
+  test('rolls back the steps that succeeded', async () => {
+    const engine = createRunbookEngine([runbook], registry);
+    await engine.start('resize-pool', { cluster: 'prod' });
+    await engine.resume('resize-pool-1', { confirm: true });
+
+    const plan = engine.rollbackPlan('resize-pool-1');
+
+    expect(plan).toEqual([
+      {
+        step: 'resize',
+        tool: 'run_gcloud_command',
+        arguments: { args: ['container', 'clusters', 'resize', 'prod', '--num-nodes=1'] },
+      },
+    ]);
+    const result = await engine.rollback('resize-pool-1', plan as Exclude<typeof plan, string>);
+    expect(result).toMatchObject({ success: true, run: { status: 'rolled-back' } });
+    expect(registry.call).toHaveBeenLastCalledWith('run_gcloud_command', {
+      args: ['container', 'clusters', 'resize', 'prod', '--num-nodes=1'],
+      confirm: true,
+    });
+    expect(engine.rollbackPlan('resize-pool-1')).toEqual([]);
+  });
+
+  test('rejects unknown runbooks, runs, and tools', async () => {
+    const engine = createRunbookEngine([runbook], registry);
+    expect(await engine.start('missing', {})).toEqual({
+      success: false,
+      error: 'Unknown runbook "missing".',
+    });
+    expect(await engine.resume('missing-1')).toMatchObject({ success: false });
+    vi.mocked(registry.has).mockReturnValue(false);
+    expect(await engine.start('resize-pool', { cluster: 'prod' })).toMatchObject({
+      success: false,
+      error: expect.stringContaining('not available: run_gcloud_command'),
+    });
+  });
+});
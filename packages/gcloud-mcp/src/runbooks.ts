@@ -0,0 +1,582 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import path from 'path';
+import { load } from 'js-yaml';
+import { z } from 'zod';
+import { ToolRegistry, resultTextOf } from './scheduler.js';
+
+export const RUNBOOK_NAME = /^[a-z][a-z0-9-]{0,62}$/;
+
+// Runbooks may not start other runbooks.
+export const RUNBOOK_TOOLS = ['run_runbook', 'list_runbooks'];
+
+// The session keeps this many runs, dropping the oldest finished runs first.
+export const MAX_RUNS_KEPT = 20;
+
+// Step outputs are truncated to this many characters before they are kept.
+export const MAX_OUTPUT_CHARS = 100_000;
+
+const TEMPLATE = /\{\{\s*([^}]*?)\s*\}\}/g;
+
+const ToolCallSchema = z
+  .object({
+    tool: z.string(),
+    arguments: z.record(z.unknown()).default({}),
+  })
+  .strict();
+
+const StepSchema = ToolCallSchema.extend({
+  id: z.string(),
+  description: z.string().optional(),
+  /** Runs the step only if an earlier step has this outcome. */
+  when: z
+    .object({
+      step: z.string(),
+      status: z.enum(['succeeded', 'failed']).optional(),
+      contains: z.string().optional(),
+      notContains: z.string().optional(),
+    })
+    .strict()
+    .optional(),
+  /** Stops the run until the user approves the step, which then runs with confirm. */
+  confirm: z.boolean().default(false),
+  continueOnError: z.boolean().default(false),
+  /** The tool call that reverts the step. */
+  rollback: ToolCallSchema.optional(),
+}).strict();
+
+const RunbookSchema = z
+  .object({
+    name: z.string(),
+    title: z.string().optional(),
+    description: z.string(),
+    parameters: z
+      .record(
+        z
+          .object({
+            description: z.string().optional(),
+            default: z.string().optional(),
+          })
+          .strict(),
+      )
+      .default({}),
+    steps: z.array(StepSchema).min(1),
+  })
+  .strict();
+
+export type Runbook = z.infer<typeof RunbookSchema>;
+export type RunbookStep = z.infer<typeof StepSchema>;
+
+export type StepStatus = 'pending' | 'succeeded' | 'failed' | 'skipped' | 'rolled-back';
+
+export type RunStatus =
+  | 'running'
+  | 'awaiting-confirmation'
+  | 'succeeded'
+  | 'failed'
+  | 'rolled-back'
+  | 'rollback-failed';
+
+export interface StepRun {
+  id: string;
+  status: StepStatus;
+  output?: string;
+  /** The output of the rollback of the step, if it ran. */
+  rollbackOutput?: string;
+  startedAt?: string;
+  finishedAt?: string;
+}
+
+export interface ToolCall {
+  step: string;
+  tool: string;
+  arguments: Record<string, unknown>;
+}
+
+export interface RunbookRun {
+  id: string;
+  runbook: string;
+  parameters: Record<string, string>;
+  status: RunStatus;
+  /** The index of the step the run continues with when it is resumed. */
+  next: number;
+  steps: StepRun[];
+  /** The call that runs once the user confirms it. */
+  pending?: ToolCall;
+  error?: string;
+  startedAt: string;
+  updatedAt: string;
+}
+
+const referencesOf = (value: unknown): string[] => {
+  if (typeof value === 'string') {
+    return [...value.matchAll(TEMPLATE)].map((match) => match[1] ?? '');
+  }
+  if (Array.isArray(value)) {
+    return value.flatMap(referencesOf);
+  }
+  if (value && typeof value === 'object') {
+    return Object.values(value).flatMap(referencesOf);
+  }
+  return [];
+};
+
+const checkReferences = (
+  runbook: Runbook,
+  stepIds: string[],
+  where: string,
+  value: unknown,
+): string | undefined => {
+  for (const reference of referencesOf(value)) {
+    const [scope, name = '', field] = reference.split('.');
+    if (scope === 'parameters' && runbook.parameters[name]) {
+      continue;
+    }
+    if (scope === 'steps' && stepIds.includes(name) && field === 'output') {
+      continue;
+    }
+    return `Invalid reference "{{ ${reference} }}" in ${where}: use parameters.NAME or steps.ID.output of an earlier step.`;
+  }
+  return undefined;
+};
+
+/** Returns an error message if the runbook can not run. */
+export const validateRunbook = (runbook: Runbook): string | undefined => {
+  if (!RUNBOOK_NAME.test(runbook.name)) {
+    return `Invalid runbook name "${runbook.name}": use lowercase letters, digits and hyphens.`;
+  }
+  const stepIds: string[] = [];
+  for (const step of runbook.steps) {
+    const where = `step "${step.id}" of runbook "${runbook.name}"`;
+    if (stepIds.includes(step.id)) {
+      return `Duplicate step "${step.id}" in runbook "${runbook.name}".`;
+    }
+    if (RUNBOOK_TOOLS.includes(step.tool) || RUNBOOK_TOOLS.includes(step.rollback?.tool ?? '')) {
+      return `The ${where} can not run other runbooks.`;
+    }
+    if (
+      [step.arguments, step.rollback?.arguments].some((args) => args?.['confirm'] !== undefined)
+    ) {
+      return `The ${where} can not pass "confirm": set "confirm: true" on the step to gate it.`;
+    }
+    if (step.when && !stepIds.includes(step.when.step)) {
+      return `The condition of ${where} must refer to an earlier step, not "${step.when.step}".`;
+    }
+    const argumentsError = checkReferences(runbook, stepIds, where, step.arguments);
+    if (argumentsError) {
+      return argumentsError;
+    }
+    // A rollback may also use the output of the step it reverts.
+    stepIds.push(step.id);
+    const rollbackError = checkReferences(
+      runbook,
+      stepIds,
+      `the rollback of ${where}`,
+      step.rollback?.arguments,
+    );
+    if (rollbackError) {
+      return rollbackError;
+    }
+  }
+  return undefined;
+};
+
+/** Parses and validates a runbook written in YAML. */
+export const parseRunbook = (text: string): Runbook => {
+  const result = RunbookSchema.safeParse(load(text));
+  if (!result.success) {
+    const [issue] = result.error.issues;
+    throw new Error(
+      issue ? `${issue.path.join('.') || 'runbook'}: ${issue.message}` : result.error.message,
+    );
+  }
+  const runbookError = validateRunbook(result.data);
+  if (runbookError) {
+    throw new Error(runbookError);
+  }
+  return result.data;
+};
+
+/** Loads every `.yaml` and `.yml` file in the directory as a runbook. */
+export const loadRunbooks = (directory: string): Runbook[] => {
+  const runbooks: Runbook[] = [];
+  const files = fs
+    .readdirSync(directory)
+    .filter((file) => /\.ya?ml$/.test(file))
+    .sort();
+  for (const file of files) {
+    let runbook: Runbook;
+    try {
+      runbook = parseRunbook(fs.readFileSync(path.join(directory, file), 'utf-8'));
+    } catch (e: unknown) {
+      throw new Error(`Invalid runbook ${file}: ${e instanceof Error ? e.message : String(e)}`);
+    }
+    if (runbooks.some(({ name }) => name === runbook.name)) {
+      throw new Error(`Invalid runbook ${file}: another runbook is named "${runbook.name}".`);
+    }
+    runbooks.push(runbook);
+  }
+  return runbooks;
+};
+
+export interface RunbookConfig {
+  /** An absolute path to a directory of runbooks written in YAML. */
+  directory: string;
+}
+
+export const validateRunbookConfig = (config: RunbookConfig): string | undefined => {
+  if (!config.directory || !path.isAbsolute(config.directory)) {
+    return `The runbook directory path must be absolute: ${config.directory}`;
+  }
+  return undefined;
+};
+
+/** Returns the parameters with defaults applied, or an error if one is missing. */
+export const resolveParameters = (
+  runbook: Runbook,
+  parameters: Record<string, string>,
+): Record<string, string> | string => {
+  const unknown = Object.keys(parameters).filter((name) => !runbook.parameters[name]);
+  if (unknown.length > 0) {
+    return `Runbook "${runbook.name}" has no parameters named ${unknown.join(', ')}.`;
+  }
+  const resolved: Record<string, string> = {};
+  const missing: string[] = [];
+  for (const [name, { default: defaultValue }] of Object.entries(runbook.parameters)) {
+    const value = parameters[name] ?? defaultValue;
+    if (value === undefined) {
+      missing.push(name);
+    } else {
+      resolved[name] = value;
+    }
+  }
+  if (missing.length > 0) {
+    return `Runbook "${runbook.name}" requires the parameters ${missing.join(', ')}.`;
+  }
+  return resolved;
+};
+
+const outputAt = (step: StepRun, fieldPath: string[]): unknown => {
+  if (step.output === undefined) {
+    throw new Error(`Step "${step.id}" has not run, so its output is not available.`);
+  }
+  if (fieldPath.length === 0) {
+    return step.output;
+  }
+  let value: unknown;
+  try {
+    value = JSON.parse(step.output);
+  } catch {
+    throw new Error(`The output of step "${step.id}" is not JSON.`);
+  }
+  for (const field of fieldPath) {
+    value =
+      value && typeof value === 'object' ? (value as Record<string, unknown>)[field] : undefined;
+    if (value === undefined) {
+      throw new Error(`The output of step "${step.id}" has no field ${fieldPath.join('.')}.`);
+    }
+  }
+  return value;
+};
+
+const lookup = (reference: string, run: RunbookRun): unknown => {
+  const [scope, name = '', field, ...fieldPath] = reference.split('.');
+  if (scope === 'parameters') {
+    return run.parameters[name];
+  }
+  const step = run.steps.find(({ id }) => id === name);
+  if (!step || field !== 'output') {
+    throw new Error(`Unable to resolve "{{ ${reference} }}".`);
+  }
+  return outputAt(step, fieldPath);
+};
+
+/**
+ * Substitutes `{{ parameters.NAME }}` and `{{ steps.ID.output }}` references,
+ * where `steps.ID.output.a.0.b` selects a field of JSON output. A string that
+ * is a single reference takes the value as is, so it may be a list or object.
+ */
+export const renderArguments = (value: unknown, run: RunbookRun): unknown => {
+  if (typeof value === 'string') {
+    const whole = /^\{\{\s*([^}]*?)\s*\}\}$/.exec(value);
+    if (whole) {
+      return lookup(whole[1] ?? '', run);
+    }
+    return value.replace(TEMPLATE, (_, reference: string) => {
+      const resolved = lookup(reference, run);
+      return typeof resolved === 'string' ? resolved : JSON.stringify(resolved);
+    });
+  }
+  if (Array.isArray(value)) {
+    return value.map((item) => renderArguments(item, run));
+  }
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(
+      Object.entries(value).map(([key, item]) => [key, renderArguments(item, run)]),
+    );
+  }
+  return value;
+};
+
+/** Returns true if the step should run given the outcome of earlier steps. */
+export const conditionHolds = (step: RunbookStep, run: RunbookRun): boolean => {
+  if (!step.when) {
+    return true;
+  }
+  const { status, contains, notContains } = step.when;
+  const earlier = run.steps.find(({ id }) => id === step.when?.step);
+  if (!earlier || (earlier.status !== 'succeeded' && earlier.status !== 'failed')) {
+    return false;
+  }
+  const output = earlier.output ?? '';
+  return (
+    (status === undefined || earlier.status === status) &&
+    (contains === undefined || output.includes(contains)) &&
+    (notContains === undefined || !output.includes(notContains))
+  );
+};
+
+export type RunbookResult =
+  | {
+      success: true;
+      run: RunbookRun;
+    }
+  | {
+      success: false;
+      error: string;
+    };
+
+export interface AdvanceOptions {
+  /** Approves the next call that requires confirmation. */
+  confirm?: boolean;
+  /** Called before each step runs, e.g. to report progress. */
+  onStep?: (run: RunbookRun, index: number) => void;
+}
+
+export type RunbookEngine = ReturnType<typeof createRunbookEngine>;
+
+/**
+ * Creates the engine that runs runbooks step by step with the tools of the
+ * server. Runs stop at steps that require confirmation and at failures, and
+ * are kept for the session so that they can be resumed or rolled back.
+ */
+export const createRunbookEngine = (runbooks: Runbook[], registry: ToolRegistry) => {
+  const runs = new Map<string, RunbookRun>();
+  let nextRunNumber = 1;
+
+  const runbookOf = (run: RunbookRun) => runbooks.find(({ name }) => name === run.runbook);
+
+  const callTool = async (tool: string, args: Record<string, unknown>) => {
+    try {
+      const result = await registry.call(tool, args);
+      return {
+        isError: (result as { isError?: boolean }).isError === true,
+        output: resultTextOf(result).slice(0, MAX_OUTPUT_CHARS),
+      };
+    } catch (e: unknown) {
+      return { isError: true, output: e instanceof Error ? e.message : String(e) };
+    }
+  };
+
+  const keep = (run: RunbookRun) => {
+    runs.set(run.id, run);
+    const finished = [...runs.values()].filter(
+      ({ status }) => status !== 'running' && status !== 'awaiting-confirmation',
+    );
+    while (runs.size > MAX_RUNS_KEPT && finished.length > 0) {
+      runs.delete(finished.shift()?.id ?? '');
+    }
+  };
+
+  const advance = async (run: RunbookRun, runbook: Runbook, options: AdvanceOptions) => {
+    let approved = options.confirm === true;
+    run.status = 'running';
+    delete run.pending;
+    delete run.error;
+    for (; run.next < runbook.steps.length; run.next++) {
+      const step = runbook.steps[run.next];
+      const stepRun = run.steps[run.next];
+      if (!step || !stepRun) {
+        break;
+      }
+      if (!conditionHolds(step, run)) {
+        stepRun.status = 'skipped';
+        continue;
+      }
+      let args: Record<string, unknown>;
+      try {
+        args = renderArguments(step.arguments, run) as Record<string, unknown>;
+      } catch (e: unknown) {
+        stepRun.status = 'failed';
+        run.status = 'failed';
+        run.error = e instanceof Error ? e.message : String(e);
+        return;
+      }
+      if (step.confirm) {
+        if (!approved) {
+          run.status = 'awaiting-confirmation';
+          run.pending = { step: step.id, tool: step.tool, arguments: args };
+          return;
+        }
+        // Each approval covers a single step.
+        approved = false;
+        args = { ...args, confirm: true };
+      }
+      options.onStep?.(run, run.next);
+      stepRun.startedAt = new Date().toISOString();
+      const { isError, output } = await callTool(step.tool, args);
+      stepRun.finishedAt = new Date().toISOString();
+      stepRun.output = output;
+      stepRun.status = isError ? 'failed' : 'succeeded';
+      run.updatedAt = stepRun.finishedAt;
+      if (isError && !step.continueOnError) {
+        run.status = 'failed';
+        run.error = `Step "${step.id}" failed.`;
+        return;
+      }
+    }
+    run.status = 'succeeded';
+  };
+
+  // Returns the run and its runbook, or an error if the run can not continue.
+  const runnable = (runId: string): { run: RunbookRun; runbook: Runbook } | string => {
+    const run = runs.get(runId);
+    const runbook = run && runbookOf(run);
+    if (!run || !runbook) {
+      return `No run with ID "${runId}" is kept in this session.`;
+    }
+    if (run.status === 'running') {
+      return `Run "${runId}" is still running.`;
+    }
+    return { run, runbook };
+  };
+
+  return {
+    list: (): Runbook[] => [...runbooks],
+    runs: (): RunbookRun[] => [...runs.values()],
+    get: (runId: string): RunbookRun | undefined => runs.get(runId),
+    start: async (
+      name: string,
+      parameters: Record<string, string>,
+      options: AdvanceOptions = {},
+    ): Promise<RunbookResult> => {
+      const runbook = runbooks.find((candidate) => candidate.name === name);
+      if (!runbook) {
+        return { success: false, error: `Unknown runbook "${name}".` };
+      }
+      const tools = runbook.steps.flatMap((step) => [
+        step.tool,
+        ...(step.rollback ? [step.rollback.tool] : []),
+      ]);
+      const unknownTools = [...new Set(tools.filter((tool) => !registry.has(tool)))];
+      if (unknownTools.length > 0) {
+        return {
+          success: false,
+          error: `Runbook "${name}" uses tools that are not available: ${unknownTools.join(', ')}.`,
+        };
+      }
+      const resolved = resolveParameters(runbook, parameters);
+      if (typeof resolved === 'string') {
+        return { success: false, error: resolved };
+      }
+      const now = new Date().toISOString();
+      const run: RunbookRun = {
+        id: `${name}-${nextRunNumber++}`,
+        runbook: name,
+        parameters: resolved,
+        status: 'running',
+        next: 0,
+        steps: runbook.steps.map(({ id }) => ({ id, status: 'pending' })),
+        startedAt: now,
+        updatedAt: now,
+      };
+      keep(run);
+      await advance(run, runbook, options);
+      return { success: true, run };
+    },
+    /** Continues a run at the step it stopped at, retrying it if it failed. */
+    resume: async (runId: string, options: AdvanceOptions = {}): Promise<RunbookResult> => {
+      const found = runnable(runId);
+      if (typeof found === 'string') {
+        return { success: false, error: found };
+      }
+      const { run, runbook } = found;
+      if (run.status !== 'failed' && run.status !== 'awaiting-confirmation') {
+        return { success: false, error: `Run "${runId}" is ${run.status} and can not resume.` };
+      }
+      await advance(run, runbook, options);
+      return { success: true, run };
+    },
+    /** Returns the calls that revert the steps of the run that succeeded, last step first. */
+    rollbackPlan: (runId: string): ToolCall[] | string => {
+      const found = runnable(runId);
+      if (typeof found === 'string') {
+        return found;
+      }
+      const { run, runbook } = found;
+      const plan: ToolCall[] = [];
+      for (const [index, step] of [...runbook.steps.entries()].reverse()) {
+        if (!step.rollback || run.steps[index]?.status !== 'succeeded') {
+          continue;
+        }
+        try {
+          const args = renderArguments(step.rollback.arguments, run) as Record<string, unknown>;
+          plan.push({ step: step.id, tool: step.rollback.tool, arguments: args });
+        } catch (e: unknown) {
+          const msg = e instanceof Error ? e.message : String(e);
+          return `Unable to plan the rollback of step "${step.id}": ${msg}`;
+        }
+      }
+      return plan;
+    },
+    /** Runs the rollback plan, confirmed, and stops at the first rollback that fails. */
+    rollback: async (
+      runId: string,
+      plan: ToolCall[],
+      onStep?: (run: RunbookRun, index: number) => void,
+    ): Promise<RunbookResult> => {
+      const found = runnable(runId);
+      if (typeof found === 'string') {
+        return { success: false, error: found };
+      }
+      const { run } = found;
+      run.status = 'running';
+      delete run.pending;
+      delete run.error;
+      for (const call of plan) {
+        const index = run.steps.findIndex(({ id }) => id === call.step);
+        const stepRun = run.steps[index];
+        if (!stepRun) {
+          continue;
+        }
+        onStep?.(run, index);
+        const { isError, output } = await callTool(call.tool, { ...call.arguments, confirm: true });
+        stepRun.rollbackOutput = output;
+        run.updatedAt = new Date().toISOString();
+        if (isError) {
+          run.status = 'rollback-failed';
+          run.error = `The rollback of step "${call.step}" failed.`;
+          return { success: true, run };
+        }
+        stepRun.status = 'rolled-back';
+      }
+      run.status = 'rolled-back';
+      return { success: true, run };
+    },
+  };
+};
@@ -0,0 +1,238 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import { z } from 'zod';
+import {
+  MAX_RUNS_KEPT,
+  ToolRegistry,
+  captureTools,
+  createScheduler,
+  nextRun,
+  parseCron,
+  validateSchedules,
+} from './scheduler.js';
+import { GcloudCommandRunner, RunOptions } from './tools/run_gcloud_command.js';
+import { errorTextResult, successfulTextResult } from './tools/tool_result.js';
+
+const next = (expression: string, after: string) =>
+  nextRun(parseCron(expression)!, new Date(after))?.toISOString();
+
+describe('parseCron', () => {
+  test('rejects invalid expressions', () => {
+    expect(parseCron('* * * *')).toBeUndefined();
+    expect(parseCron('60 * * * *')).toBeUndefined();
+    expect(parseCron('0 0 * * fun')).toBeUndefined();
+    expect(parseCron('*/0 * * * *')).toBeUndefined();
+    expect(parseCron('5-1 * * * *')).toBeUndefined();
+  });
+
+  test('treats 7 as Sunday', () => {
+    expect([...parseCron('0 0 * * 7')!.dayOfWeek.values]).toEqual([0]);
+  });
+});
+
+describe('nextRun', () => {
+  test('finds the next matching minute in UTC', () => {
+    expect(next('0 6 * * 1', '2025-01-01T00:00:00Z')).toBe('2025-01-06T06:00:00.000Z');
+    expect(next('*/15 * * * *', '2025-01-01T00:07:30Z')).toBe('2025-01-01T00:15:00.000Z');
+    expect(next('@daily', '2025-01-01T00:00:00Z')).toBe('2025-01-02T00:00:00.000Z');
+    expect(next('30 9 * * mon-fri', '2025-01-04T12:00:00Z')).toBe('2025-01-06T09:30:00.000Z');
+  });
+
+  test('matches either day field if both are restricted', () => {
+    expect(next('0 0 13 * 5', '2025-01-01T00:00:00Z')).toBe('2025-01-03T00:00:00.000Z');
+  });
+
+  test('finds rare dates', () => {
+    expect(next('0 0 29 2 *', '2025-01-01T00:00:00Z')).toBe('2028-02-29T00:00:00.000Z');
+  });
+
+  test('returns null for dates that never occur', () => {
+    expect(next('0 0 31 2 *', '2025-01-01T00:00:00Z')).toBeUndefined();
+  });
+});
+
+describe('validateSchedules', () => {
+  const job = { tool: 'find_idle_resources', schedule: '0 6 * * 1' };
+
+  test('accepts valid jobs', () => {
+    const weekly = { ...job, outputUri: 'gs://reports/idle' };
+    expect(validateSchedules({ weekly })).toBeUndefined();
+  });
+
+  test('rejects invalid jobs', () => {
+    expect(validateSchedules({ Weekly: job })).toContain('Invalid job name');
+    expect(validateSchedules({ weekly: { ...job, schedule: 'mondays' } })).toContain(
+      'is not a cron expression',
+    );
+    expect(validateSchedules({ weekly: { ...job, outputUri: '/tmp/idle' } })).toContain(
+      'must be a gs:// URL',
+    );
+    expect(validateSchedules({ weekly: { ...job, arguments: { confirm: true } } })).toContain(
+      'can not confirm changes',
+    );
+  });
+});
+
+describe('captureTools', () => {
+  test('runs registered tools with validated arguments', async () => {
+    const registerTool = vi.fn();
+    const server = { registerTool } as unknown as McpServer;
+    const callback = vi.fn().mockResolvedValue({ content: [{ type: 'text', text: 'ok' }] });
+    const registry = captureTools(server);
+
+    server.registerTool('echo', { inputSchema: { n: z.number().default(1) } }, callback);
+
+    expect(registerTool).toHaveBeenCalledWith('echo', expect.anything(), callback);
+    expect(registry.has('echo')).toBe(true);
+    await registry.call('echo', {});
+    expect(callback.mock.calls[0]![0]).toEqual({ n: 1 });
+    await expect(registry.call('echo', { n: 'one' })).rejects.toThrow();
+    await expect(registry.call('missing', {})).rejects.toThrow('Unknown tool: missing');
+  });
+});
+
+describe('createScheduler', () => {
+  let runner: GcloudCommandRunner;
+  let registry: ToolRegistry;
+
+  beforeEach(() => {
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date('2025-01-01T00:00:00Z'));
+    runner = vi.fn(
+      async (
+        args: string[],
+        _env?: NodeJS.ProcessEnv,
+        _confirmed?: boolean,
+        runOptions?: RunOptions,
+      ) => {
+        runOptions?.onExecuted?.({ argv: args, exitCode: 0, durationMs: 1, gcloudVersion: null });
+        return successfulTextResult('');
+      },
+    );
+    registry = {
+      has: vi.fn().mockReturnValue(true),
+      call: vi.fn().mockResolvedValue({ content: [{ type: 'text', text: 'no idle resources' }] }),
+    };
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  test('runs jobs on their schedule and keeps the results', async () => {
+    const onRun = vi.fn();
+    const scheduler = createScheduler(runner, registry, onRun);
+
+    const result = scheduler.add('idle', {
+      tool: 'find_idle_resources',
+      arguments: { projects: ['p'] },
+      schedule: '*/5 * * * *',
+    });
+
+    expect(result).toMatchObject({
+      success: true,
+      job: { uri: 'gcloud-schedule://idle', nextRunAt: '2025-01-01T00:05:00.000Z' },
+    });
+    await vi.advanceTimersByTimeAsync(5 * 60 * 1000);
+    expect(registry.call).toHaveBeenCalledWith('find_idle_resources', { projects: ['p'] });
+    expect(onRun).toHaveBeenCalledOnce();
+    expect(scheduler.get('idle')).toMatchObject({
+      nextRunAt: '2025-01-01T00:10:00.000Z',
+      runs: [
+        { startedAt: '2025-01-01T00:05:00.000Z', isError: false, result: 'no idle resources' },
+      ],
+    });
+
+    await vi.advanceTimersByTimeAsync((MAX_RUNS_KEPT + 2) * 5 * 60 * 1000);
+    expect(scheduler.get('idle')?.runs).toHaveLength(MAX_RUNS_KEPT);
+  });
+
+  test('records failed runs', async () => {
+    vi.mocked(registry.call).mockRejectedValue(new Error('Expected number'));
+    const scheduler = createScheduler(runner, registry, vi.fn());
+
+    scheduler.add('idle', { tool: 'find_idle_resources', schedule: '@hourly' });
+    await vi.advanceTimersByTimeAsync(60 * 60 * 1000);
+
+    expect(scheduler.get('idle')?.runs[0]).toMatchObject({
+      isError: true,
+      result: 'Expected number',
+    });
+  });
+
+  test('writes results to Cloud Storage', async () => {
+    const scheduler = createScheduler(runner, registry, vi.fn());
+
+    scheduler.add('idle', {
+      tool: 'find_idle_resources',
+      schedule: '@hourly',
+      outputUri: 'gs://reports/idle/',
+    });
+    await vi.advanceTimersByTimeAsync(60 * 60 * 1000);
+
+    const [args, env, confirmed] = (runner as Mock).mock.calls[0]!;
+    expect(args.slice(0, 2)).toEqual(['storage', 'cp']);
+    expect(args[3]).toBe('gs://reports/idle/idle/2025-01-01T01-00-00-000Z.json');
+    expect(env).toBeUndefined();
+    expect(confirmed).toBe(false);
+    expect(scheduler.get('idle')?.runs[0]?.output).toBe(args[3]);
+  });
+
+  test('keeps no output if the runner refuses to write it', async () => {
+    vi.mocked(runner).mockResolvedValue(errorTextResult('{"error": "READ_ONLY"}'));
+    const scheduler = createScheduler(runner, registry, vi.fn());
+
+    scheduler.add('idle', {
+      tool: 'find_idle_resources',
+      schedule: '@hourly',
+      outputUri: 'gs://reports/idle/',
+    });
+    await vi.advanceTimersByTimeAsync(60 * 60 * 1000);
+
+    expect(scheduler.get('idle')?.runs[0]).toMatchObject({ isError: false });
+    expect(scheduler.get('idle')?.runs[0]?.output).toBeUndefined();
+  });
+
+  test('refuses unknown tools and duplicate names', () => {
+    const scheduler = createScheduler(runner, registry, vi.fn());
+    scheduler.add('idle', { tool: 'find_idle_resources', schedule: '@daily' });
+    vi.mocked(registry.has).mockReturnValue(false);
+
+    expect(scheduler.add('other', { tool: 'missing', schedule: '@daily' })).toEqual({
+      success: false,
+      error: 'Unknown tool "missing" for job "other".',
+    });
+    vi.mocked(registry.has).mockReturnValue(true);
+    expect(scheduler.add('idle', { tool: 'find_idle_resources', schedule: '@daily' })).toEqual({
+      success: false,
+      error: 'A job named "idle" is already scheduled. Unschedule it first.',
+    });
+  });
+
+  test('stops removed jobs', async () => {
+    const scheduler = createScheduler(runner, registry, vi.fn());
+    scheduler.add('idle', { tool: 'find_idle_resources', schedule: '@hourly' });
+
+    expect(scheduler.remove('idle')).toBe(true);
+    await vi.advanceTimersByTimeAsync(60 * 60 * 1000);
+
+    expect(registry.call).not.toHaveBeenCalled();
+    expect(scheduler.list()).toEqual([]);
+  });
+});
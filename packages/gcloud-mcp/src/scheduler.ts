@@ -0,0 +1,408 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudCommandRunner } from './tools/run_gcloud_command.js';
+import { log } from './utility/logger.js';
+
+export const SCHEDULE_SCHEME = 'gcloud-schedule';
+
+export const JOB_NAME = /^[a-z][a-z0-9-]{0,62}$/;
+
+// Each job keeps the results of this many of its latest runs.
+export const MAX_RUNS_KEPT = 10;
+
+// Results are truncated to this many characters before they are kept.
+export const MAX_RESULT_CHARS = 100_000;
+
+const MINUTE_MS = 60 * 1000;
+
+// setTimeout fires immediately for delays that do not fit in 32 bits.
+const MAX_TIMER_MS = 2 ** 31 - 1;
+
+export interface ScheduledJobConfig {
+  /** The name of the tool to run, e.g. `find_idle_resources`. */
+  tool: string;
+  /** The arguments of the tool. */
+  arguments?: Record<string, unknown>;
+  /** A cron expression in UTC, e.g. `0 6 * * 1` for every Monday at 06:00. */
+  schedule: string;
+  /** A `gs://` URL under which the result of every run is written. */
+  outputUri?: string;
+}
+
+export interface JobRun {
+  startedAt: string;
+  finishedAt: string;
+  isError: boolean;
+  result: string;
+  /** The Cloud Storage object the result was written to. */
+  output?: string;
+}
+
+export interface ScheduledJob extends ScheduledJobConfig {
+  name: string;
+  uri: string;
+  nextRunAt: string | null;
+  runs: JobRun[];
+}
+
+const CRON_ALIASES: Record<string, string> = {
+  '@hourly': '0 * * * *',
+  '@daily': '0 0 * * *',
+  '@weekly': '0 0 * * 0',
+  '@monthly': '0 0 1 * *',
+};
+
+const DAY_NAMES = ['sun', 'mon', 'tue', 'wed', 'thu', 'fri', 'sat'];
+
+interface CronField {
+  values: Set<number>;
+  /** True if the field is `*`, which matters for the day fields. */
+  any: boolean;
+}
+
+export interface Cron {
+  minute: CronField;
+  hour: CronField;
+  dayOfMonth: CronField;
+  month: CronField;
+  dayOfWeek: CronField;
+}
+
+const parseValue = (value: string, names: string[]) => {
+  const named = names.indexOf(value.toLowerCase());
+  return named >= 0 ? named : /^\d+$/.test(value) ? Number(value) : NaN;
+};
+
+const parseField = (field: string, min: number, max: number, names: string[] = []) => {
+  const values = new Set<number>();
+  for (const part of field.split(',')) {
+    const [range = '', stepText] = part.split('/');
+    const step = stepText === undefined ? 1 : Number(stepText);
+    let from = min;
+    let to = max;
+    if (range !== '*') {
+      const [start = '', end] = range.split('-');
+      from = parseValue(start, names);
+      to = end === undefined ? (stepText === undefined ? from : max) : parseValue(end, names);
+    }
+    if (!(from >= min && to <= max && from <= to && Number.isInteger(step) && step >= 1)) {
+      return undefined;
+    }
+    for (let value = from; value <= to; value += step) {
+      values.add(value);
+    }
+  }
+  return { values, any: field === '*' };
+};
+
+/** Parses a five-field cron expression or an alias like `@daily`. */
+export const parseCron = (expression: string): Cron | undefined => {
+  const fields = (CRON_ALIASES[expression.trim()] ?? expression).trim().split(/\s+/);
+  if (fields.length !== 5) {
+    return undefined;
+  }
+  const [minute, hour, dayOfMonth, month, dayOfWeek] = [
+    parseField(fields[0] ?? '', 0, 59),
+    parseField(fields[1] ?? '', 0, 23),
+    parseField(fields[2] ?? '', 1, 31),
+    parseField(fields[3] ?? '', 1, 12),
+    // Both 0 and 7 are Sunday.
+    parseField(fields[4] ?? '', 0, 7, DAY_NAMES),
+  ];
+  if (!minute || !hour || !dayOfMonth || !month || !dayOfWeek) {
+    return undefined;
+  }
+  if (dayOfWeek.values.delete(7)) {
+    dayOfWeek.values.add(0);
+  }
+  return { minute, hour, dayOfMonth, month, dayOfWeek };
+};
+
+const matchesDay = (cron: Cron, date: Date) => {
+  const dom = cron.dayOfMonth.values.has(date.getUTCDate());
+  const dow = cron.dayOfWeek.values.has(date.getUTCDay());
+  // As in cron, a day matches either field if both are restricted.
+  if (!cron.dayOfMonth.any && !cron.dayOfWeek.any) {
+    return dom || dow;
+  }
+  return dom && dow;
+};
+
+/** Returns the first time after the given one that matches the cron expression. */
+export const nextRun = (cron: Cron, after: Date): Date | null => {
+  const time = new Date(Math.floor(after.getTime() / MINUTE_MS) * MINUTE_MS + MINUTE_MS);
+  // Every valid expression matches within a few years, e.g. February 29.
+  const limit = after.getTime() + 5 * 366 * 24 * 60 * MINUTE_MS;
+  while (time.getTime() <= limit) {
+    if (!cron.month.values.has(time.getUTCMonth() + 1)) {
+      time.setUTCMonth(time.getUTCMonth() + 1, 1);
+      time.setUTCHours(0, 0);
+    } else if (!matchesDay(cron, time)) {
+      time.setUTCDate(time.getUTCDate() + 1);
+      time.setUTCHours(0, 0);
+    } else if (!cron.hour.values.has(time.getUTCHours())) {
+      time.setUTCHours(time.getUTCHours() + 1, 0);
+    } else if (!cron.minute.values.has(time.getUTCMinutes())) {
+      time.setUTCMinutes(time.getUTCMinutes() + 1);
+    } else {
+      return time;
+    }
+  }
+  return null;
+};
+
+/** Returns an error message if the job can not be scheduled. */
+export const validateJob = (name: string, job: ScheduledJobConfig): string | undefined => {
+  if (!JOB_NAME.test(name)) {
+    return `Invalid job name "${name}": use lowercase letters, digits and hyphens.`;
+  }
+  if (!job.tool) {
+    return `Scheduled job "${name}" must name a tool.`;
+  }
+  if (!parseCron(job.schedule)) {
+    return `Invalid schedule for job "${name}": "${job.schedule}" is not a cron expression.`;
+  }
+  if (job.outputUri && !job.outputUri.startsWith('gs://')) {
+    return `The outputUri of job "${name}" must be a gs:// URL.`;
+  }
+  if (job.arguments?.['confirm'] !== undefined) {
+    return `Scheduled job "${name}" can not confirm changes: remove "confirm" from its arguments.`;
+  }
+  return undefined;
+};
+
+export const validateSchedules = (
+  schedules: Record<string, ScheduledJobConfig>,
+): string | undefined => {
+  for (const [name, job] of Object.entries(schedules)) {
+    const jobError = validateJob(name, job);
+    if (jobError) {
+      return jobError;
+    }
+  }
+  return undefined;
+};
+
+type ToolCallback = (...args: unknown[]) => Promise<unknown>;
+
+export interface ToolRegistry {
+  has: (name: string) => boolean;
+  /** Runs a tool with validated arguments, as if a client called it. */
+  call: (name: string, args: Record<string, unknown>) => Promise<unknown>;
+}
+
+/**
+ * Records every tool registered on the server from now on, so the server can
+ * run tools itself. Call it before tools are instrumented, so that runs are
+ * instrumented like calls from clients.
+ */
+export const captureTools = (server: McpServer): ToolRegistry => {
+  const tools = new Map<string, { inputSchema: z.ZodRawShape; callback: ToolCallback }>();
+  const registerTool = server.registerTool.bind(server) as unknown as (
+    name: string,
+    config: { inputSchema?: z.ZodRawShape },
+    callback: ToolCallback,
+  ) => unknown;
+  const capturing = (
+    name: string,
+    config: { inputSchema?: z.ZodRawShape },
+    callback: ToolCallback,
+  ) => {
+    tools.set(name, { inputSchema: config.inputSchema ?? {}, callback });
+    return registerTool(name, config, callback);
+  };
+  server.registerTool = capturing as unknown as typeof server.registerTool;
+  return {
+    has: (name) => tools.has(name),
+    call: async (name, args) => {
+      const tool = tools.get(name);
+      if (!tool) {
+        throw new Error(`Unknown tool: ${name}`);
+      }
+      const input = z.object(tool.inputSchema).parse(args);
+      return tool.callback(input, { signal: new AbortController().signal });
+    },
+  };
+};
+
+export const resultTextOf = (result: unknown) =>
+  ((result as { content?: Array<{ text?: string }> }).content ?? [])
+    .map(({ text }) => text ?? '')
+    .join('\n');
+
+/**
+ * Writes the result of a run to Cloud Storage and returns the object URL. The
+ * upload goes through the runner, so it is checked like every other command,
+ * e.g. it is rejected in read-only mode.
+ */
+const writeOutput = async (
+  runner: GcloudCommandRunner,
+  job: ScheduledJob,
+  run: Omit<JobRun, 'output'>,
+): Promise<string> => {
+  const prefix = (job.outputUri ?? '').replace(/\/+$/, '');
+  const url = `${prefix}/${job.name}/${run.startedAt.replace(/[:.]/g, '-')}.json`;
+  const dir = fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-'));
+  const file = path.join(dir, 'result.json');
+  try {
+    fs.writeFileSync(file, JSON.stringify({ job: job.name, tool: job.tool, ...run }, null, 2));
+    const args = ['storage', 'cp', file, url];
+    // The runner reports failed commands as output, so the exit code tells
+    // whether the object was written. It is not set if the runner refused.
+    const execution: { exitCode?: number | null } = {};
+    const result = await runner(args, undefined, false, {
+      onExecuted: ({ exitCode }) => {
+        execution.exitCode = exitCode;
+      },
+    });
+    if (execution.exitCode !== 0) {
+      throw new Error(`gcloud ${args.join(' ')} failed: ${resultTextOf(result)}`);
+    }
+    return url;
+  } finally {
+    fs.rmSync(dir, { recursive: true, force: true });
+  }
+};
+
+export type ScheduleResult =
+  | {
+      success: true;
+      job: ScheduledJob;
+    }
+  | {
+      success: false;
+      error: string;
+    };
+
+export type Scheduler = ReturnType<typeof createScheduler>;
+
+/**
+ * Creates the scheduler that runs tools on cron schedules while the server
+ * runs. Results are kept per job, optionally written to Cloud Storage, and
+ * reported to `onRun`.
+ */
+export const createScheduler = (
+  runner: GcloudCommandRunner,
+  registry: ToolRegistry,
+  onRun: (job: ScheduledJob) => void,
+) => {
+  const jobs = new Map<string, ScheduledJob>();
+  const timers = new Map<string, NodeJS.Timeout>();
+
+  const runJob = async (job: ScheduledJob) => {
+    const startedAt = new Date().toISOString();
+    let isError: boolean;
+    let result: string;
+    try {
+      const output = await registry.call(job.tool, job.arguments ?? {});
+      isError = (output as { isError?: boolean }).isError === true;
+      result = resultTextOf(output);
+    } catch (e: unknown) {
+      isError = true;
+      result = e instanceof Error ? e.message : String(e);
+    }
+    const run: JobRun = {
+      startedAt,
+      finishedAt: new Date().toISOString(),
+      isError,
+      result: result.slice(0, MAX_RESULT_CHARS),
+    };
+    if (job.outputUri) {
+      try {
+        run.output = await writeOutput(runner, job, run);
+      } catch (e: unknown) {
+        log.warn(`Unable to write the result of job ${job.name}`, { error: String(e) });
+      }
+    }
+    job.runs = [...job.runs, run].slice(-MAX_RUNS_KEPT);
+    onRun(job);
+  };
+
+  const schedule = (job: ScheduledJob) => {
+    const cron = parseCron(job.schedule);
+    const next = cron && nextRun(cron, new Date());
+    job.nextRunAt = next ? next.toISOString() : null;
+    if (!next) {
+      return;
+    }
+    const delay = next.getTime() - Date.now();
+    const timer = setTimeout(
+      () => {
+        if (delay > MAX_TIMER_MS) {
+          schedule(job);
+          return;
+        }
+        runJob(job)
+          .catch((e: unknown) => log.warn(`Job ${job.name} failed`, { error: String(e) }))
+          .finally(() => {
+            if (jobs.get(job.name) === job) {
+              schedule(job);
+            }
+          });
+      },
+      Math.min(delay, MAX_TIMER_MS),
+    );
+    timer.unref();
+    timers.set(job.name, timer);
+  };
+
+  const remove = (name: string): boolean => {
+    clearTimeout(timers.get(name));
+    timers.delete(name);
+    return jobs.delete(name);
+  };
+
+  return {
+    add: (name: string, config: ScheduledJobConfig): ScheduleResult => {
+      const jobError = validateJob(name, config);
+      if (jobError) {
+        return { success: false, error: jobError };
+      }
+      if (!registry.has(config.tool)) {
+        return { success: false, error: `Unknown tool "${config.tool}" for job "${name}".` };
+      }
+      if (jobs.has(name)) {
+        return {
+          success: false,
+          error: `A job named "${name}" is already scheduled. Unschedule it first.`,
+        };
+      }
+      const job: ScheduledJob = {
+        ...config,
+        name,
+        uri: `${SCHEDULE_SCHEME}://${name}`,
+        nextRunAt: null,
+        runs: [],
+      };
+      jobs.set(name, job);
+      schedule(job);
+      return { success: true, job };
+    },
+    remove,
+    get: (name: string): ScheduledJob | undefined => jobs.get(name),
+    list: (): ScheduledJob[] => [...jobs.values()],
+    /** Stops every job, e.g. when the session closes. */
+    stopAll: () => {
+      [...jobs.keys()].forEach(remove);
+    },
+  };
+};
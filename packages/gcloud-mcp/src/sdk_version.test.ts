@@ -0,0 +1,59 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, test, expect, vi, Mock } from 'vitest';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { GcloudExecutable } from './gcloud.js';
+import { reportSdkVersion, sdkVersionOf } from './sdk_version.js';
+
+const gcloudReturning = (code: number, stdout: string) =>
+  ({
+    lint: vi.fn(),
+    invoke: vi.fn().mockResolvedValue({ code, stdout, stderr: '' }),
+  }) as unknown as GcloudExecutable;
+
+describe('sdkVersionOf', () => {
+  test('returns the Cloud SDK version', async () => {
+    const gcloud = gcloudReturning(0, '{"Google Cloud SDK": "499.0.0", "core": "2024.11.08"}');
+
+    await expect(sdkVersionOf(gcloud)).resolves.toBe('499.0.0');
+    expect(gcloud.invoke).toHaveBeenCalledWith(['version', '--format=json']);
+  });
+
+  test('returns null when the version is unavailable', async () => {
+    await expect(sdkVersionOf(gcloudReturning(1, ''))).resolves.toBe(null);
+    await expect(sdkVersionOf(gcloudReturning(0, 'not json'))).resolves.toBe(null);
+  });
+});
+
+describe('reportSdkVersion', () => {
+  test('adds the SDK version to the results of registered tools', async () => {
+    const registerTool = vi.fn();
+    const server = { registerTool } as unknown as McpServer;
+    reportSdkVersion(server, '499.0.0');
+
+    server.registerTool('noop', {}, async () => ({
+      content: [{ type: 'text', text: 'ok' }],
+      _meta: { other: true },
+    }));
+    const callback = (registerTool as Mock).mock.calls[0]![2];
+
+    await expect(callback({})).resolves.toEqual({
+      content: [{ type: 'text', text: 'ok' }],
+      _meta: { other: true, sdk_version: '499.0.0' },
+    });
+  });
+});
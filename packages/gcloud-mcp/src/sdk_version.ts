@@ -0,0 +1,57 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+
+// The key of the SDK version in the `_meta` of every tool result.
+export const SDK_VERSION_META_KEY = 'sdk_version';
+
+/** Returns the version of the Cloud SDK gcloud runs from, or null if it can't be determined. */
+export const sdkVersionOf = async (gcloud: GcloudExecutable): Promise<string | null> => {
+  try {
+    const { code, stdout } = await gcloud.invoke(['version', '--format=json']);
+    if (code !== 0) {
+      return null;
+    }
+    return z.record(z.string()).parse(JSON.parse(stdout))['Google Cloud SDK'] ?? null;
+  } catch {
+    return null;
+  }
+};
+
+type ToolCallback = (...args: unknown[]) => Promise<{ _meta?: Record<string, unknown> }>;
+
+/**
+ * Reports the SDK version in the `_meta` of the results of every tool
+ * registered on the server from now on, so differences in behavior can be
+ * traced to differences in gcloud versions.
+ */
+export const reportSdkVersion = (server: McpServer, version: string | null): McpServer => {
+  const registerTool = server.registerTool.bind(server) as unknown as (
+    name: string,
+    config: unknown,
+    callback: ToolCallback,
+  ) => unknown;
+  const reporting = (name: string, config: unknown, callback: ToolCallback) =>
+    registerTool(name, config, async (...args: unknown[]) => {
+      const result = await callback(...args);
+      return { ...result, _meta: { ...result._meta, [SDK_VERSION_META_KEY]: version } };
+    });
+  server.registerTool = reporting as unknown as typeof server.registerTool;
+  return server;
+};
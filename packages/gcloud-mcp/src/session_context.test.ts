@@ -0,0 +1,241 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import { z } from 'zod';
+import * as gcloud from './gcloud.js';
+import {
+  createSessionContext,
+  sessionProject,
+  withProjectParameter,
+  withSessionContext,
+} from './session_context.js';
+
+vi.mock('./gcloud.js');
+
+describe('createSessionContext', () => {
+  test('starts empty', () => {
+    const session = createSessionContext();
+    expect(session.get()).toEqual({});
+    expect(session.env()).toEqual({});
+  });
+
+  test('maps values to gcloud property environment variables', () => {
+    const session = createSessionContext();
+    session.update({ project: 'staging', region: 'us-east1', zone: 'us-east1-b' });
+    expect(session.env()).toEqual({
+      CLOUDSDK_CORE_PROJECT: 'staging',
+      CLOUDSDK_COMPUTE_REGION: 'us-east1',
+      CLOUDSDK_COMPUTE_ZONE: 'us-east1-b',
+    });
+  });
+
+  test('leaves omitted fields unchanged and clears empty ones', () => {
+    const session = createSessionContext();
+    session.update({ project: 'staging', region: 'us-east1' });
+    const result = session.update({ region: '', zone: 'europe-west1-b' });
+    expect(result).toEqual({
+      success: true,
+      context: { project: 'staging', zone: 'europe-west1-b' },
+    });
+  });
+
+  test('rejects projects that are not on the allowlist', () => {
+    const session = createSessionContext(['dev', 'staging']);
+    session.update({ project: 'dev' });
+    const result = session.update({ project: 'prod', zone: 'us-east1-b' });
+    expect(result.success).toBe(false);
+    expect(result).toHaveProperty('error', expect.stringContaining('"prod"'));
+    expect(session.get()).toEqual({ project: 'dev' });
+  });
+
+  test('allows clearing the project with an allowlist', () => {
+    const session = createSessionContext(['dev']);
+    session.update({ project: 'dev' });
+    expect(session.update({ project: '' })).toEqual({ success: true, context: {} });
+  });
+});
+
+describe('withSessionContext', () => {
+  let mockedGcloud: gcloud.GcloudExecutable;
+
+  beforeEach(() => {
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(),
+    };
+  });
+
+  test('passes the session environment to every invocation', async () => {
+    const session = createSessionContext();
+    const wrapped = withSessionContext(mockedGcloud, session);
+    session.update({ project: 'staging' });
+
+    await wrapped.invoke(['compute', 'instances', 'list']);
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(['compute', 'instances', 'list'], {
+      CLOUDSDK_CORE_PROJECT: 'staging',
+    });
+  });
+
+  test('adds the project of the tool call to commands without one', async () => {
+    const session = createSessionContext();
+    const wrapped = withSessionContext(mockedGcloud, session);
+    session.update({ project: 'staging' });
+
+    await session.withProject('prod', async () => {
+      await wrapped.invoke(['compute', 'instances', 'list']);
+      await wrapped.invoke(['compute', 'instances', 'list', '--project=other']);
+    });
+    await wrapped.invoke(['compute', 'instances', 'list']);
+
+    expect(vi.mocked(mockedGcloud.invoke).mock.calls).toEqual([
+      [['compute', 'instances', 'list', '--project=prod'], { CLOUDSDK_CORE_PROJECT: 'prod' }],
+      [['compute', 'instances', 'list', '--project=other'], { CLOUDSDK_CORE_PROJECT: 'prod' }],
+      [['compute', 'instances', 'list'], { CLOUDSDK_CORE_PROJECT: 'staging' }],
+    ]);
+    expect(session.get()).toEqual({ project: 'staging' });
+  });
+
+  test('adds the project of the tool call before the end of the flags', async () => {
+    const session = createSessionContext();
+    const wrapped = withSessionContext(mockedGcloud, session);
+
+    await session.withProject('prod', () =>
+      wrapped.invoke(['compute', 'ssh', 'vm-1', '--', 'ls', '-l']),
+    );
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(
+      ['compute', 'ssh', 'vm-1', '--project=prod', '--', 'ls', '-l'],
+      { CLOUDSDK_CORE_PROJECT: 'prod' },
+    );
+  });
+
+  test('reports the project of the session environment in the result', async () => {
+    const session = createSessionContext();
+    const wrapped = withSessionContext(mockedGcloud, session);
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 0, stdout: '', stderr: '' });
+
+    expect(await wrapped.invoke(['compute', 'instances', 'list'])).toEqual({
+      code: 0,
+      stdout: '',
+      stderr: '',
+    });
+    session.update({ project: 'staging' });
+    expect(await wrapped.invoke(['compute', 'instances', 'list'])).toMatchObject({
+      envProject: 'staging',
+    });
+  });
+
+  test('does not wrap lint', () => {
+    const wrapped = withSessionContext(mockedGcloud, createSessionContext());
+    expect(wrapped.lint).toBe(mockedGcloud.lint);
+  });
+});
+
+describe('sessionProject', () => {
+  const gcloudReturning = (result: gcloud.GcloudInvocationResult): gcloud.GcloudExecutable => ({
+    lint: vi.fn(),
+    invoke: vi.fn().mockResolvedValue(result),
+  });
+
+  test('returns the project gcloud reports', async () => {
+    const mockedGcloud = gcloudReturning({ code: 0, stdout: 'my-project\n', stderr: '' });
+
+    expect(await sessionProject(mockedGcloud)).toBe('my-project');
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(['config', 'get-value', 'project']);
+  });
+
+  test('returns null if no project is set', async () => {
+    const mockedGcloud = gcloudReturning({ code: 0, stdout: '', stderr: '(unset)' });
+
+    expect(await sessionProject(mockedGcloud)).toBeNull();
+  });
+
+  test('throws if gcloud fails', async () => {
+    const mockedGcloud = gcloudReturning({
+      code: 1,
+      stdout: '',
+      stderr: 'ERROR: (gcloud.config.get-value) Invalid configuration',
+    });
+
+    await expect(sessionProject(mockedGcloud)).rejects.toThrow(
+      'Failed to read the session project: ERROR: (gcloud.config.get-value) Invalid configuration',
+    );
+  });
+});
+
+describe('withProjectParameter', () => {
+  const register = (session: ReturnType<typeof createSessionContext>, inputSchema?: object) => {
+    const registerTool = vi.fn();
+    const mockServer = { registerTool } as unknown as McpServer;
+    withProjectParameter(mockServer, session);
+    const callback = vi.fn(async () => session.env());
+    mockServer.registerTool('tool', { ...(inputSchema && { inputSchema }) }, callback);
+    const [, config, handler] = registerTool.mock.calls[0]!;
+    return { config, handler, callback };
+  };
+
+  test('adds a project parameter that applies to the call only', async () => {
+    const session = createSessionContext();
+    session.update({ project: 'staging' });
+    const { config, handler, callback } = register(session, { name: z.string() });
+
+    expect(Object.keys(config.inputSchema)).toEqual(['name', 'project']);
+    const env = await handler({ name: 'vm-1', project: 'prod' }, {});
+
+    expect(callback).toHaveBeenCalledWith({ name: 'vm-1' }, {});
+    expect(env).toEqual({ CLOUDSDK_CORE_PROJECT: 'prod' });
+    expect(session.env()).toEqual({ CLOUDSDK_CORE_PROJECT: 'staging' });
+  });
+
+  test('rejects projects that are not on the allowlist', async () => {
+    const { handler, callback } = register(createSessionContext(['dev']), {});
+
+    const result = await handler({ project: 'prod' }, {});
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('not on the allowlist');
+    expect(callback).not.toHaveBeenCalled();
+  });
+
+  test('keeps the project parameter of tools that have their own', async () => {
+    const inputSchema = { project: z.string().optional() };
+    const session = createSessionContext();
+    session.update({ project: 'staging' });
+    const { config, handler, callback } = register(session, inputSchema);
+
+    expect(config.inputSchema).toBe(inputSchema);
+    const env = await handler({ project: 'prod' }, {});
+
+    expect(callback).toHaveBeenCalledWith({ project: 'prod' }, {});
+    expect(env).toEqual({ CLOUDSDK_CORE_PROJECT: 'staging' });
+  });
+
+  test('checks the own project parameter of tools against the allowlist', async () => {
+    const inputSchema = { project: z.string().optional() };
+    const { handler, callback } = register(createSessionContext(['dev']), inputSchema);
+
+    const result = await handler({ project: 'prod' }, {});
+    await handler({}, {});
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('not on the allowlist');
+    expect(callback).toHaveBeenCalledTimes(1);
+    expect(callback).toHaveBeenCalledWith({}, {});
+  });
+});
@@ -0,0 +1,215 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { AsyncLocalStorage } from 'async_hooks';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { ExecutionOptions } from './gcloud_executor.js';
+import { errorTextResult } from './tools/tool_result.js';
+
+export interface SessionContextValues {
+  project?: string;
+  region?: string;
+  zone?: string;
+}
+
+export type SessionContextUpdateResult =
+  | {
+      success: true;
+      context: SessionContextValues;
+    }
+  | {
+      success: false;
+      error: string;
+    };
+
+// gcloud reads these environment variables in preference to the properties in
+// the active configuration, so setting them never touches the on-disk config.
+const PROPERTY_ENV_VARS: Record<keyof SessionContextValues, string> = {
+  project: 'CLOUDSDK_CORE_PROJECT',
+  region: 'CLOUDSDK_COMPUTE_REGION',
+  zone: 'CLOUDSDK_COMPUTE_ZONE',
+};
+
+export type SessionContext = ReturnType<typeof createSessionContext>;
+
+/**
+ * Creates the per-session defaults applied to every gcloud command.
+ *
+ * @param allowedProjects If non-empty, only these projects may be selected.
+ */
+export const createSessionContext = (allowedProjects: string[] = []) => {
+  let values: SessionContextValues = {};
+  // The project passed to the tool call in progress, if any.
+  const callProject = new AsyncLocalStorage<string>();
+
+  /** Returns an error if the project may not be selected. */
+  const checkProject = (project: string): string | undefined => {
+    if (allowedProjects.length > 0 && !allowedProjects.includes(project)) {
+      const allowed = allowedProjects.join(', ');
+      return `Project "${project}" is not on the allowlist of projects: ${allowed}`;
+    }
+    return undefined;
+  };
+
+  return {
+    get: (): SessionContextValues => ({ ...values }),
+    allowedProjects: () => [...allowedProjects],
+    checkProject,
+    /** The project of the tool call in progress, if it overrides the session's. */
+    callProject: () => callProject.getStore(),
+    /** Runs a tool call with a project in place of the session's for every command it runs. */
+    withProject: <T>(project: string, call: () => T): T => callProject.run(project, call),
+    /**
+     * Applies an update. Omitted fields are left unchanged and empty strings
+     * clear the session override so the gcloud configuration applies again.
+     */
+    update: (update: SessionContextValues): SessionContextUpdateResult => {
+      const error = update.project ? checkProject(update.project) : undefined;
+      if (error) {
+        return { success: false, error };
+      }
+      const next = { ...values };
+      for (const key of Object.keys(PROPERTY_ENV_VARS) as Array<keyof SessionContextValues>) {
+        const value = update[key];
+        if (value === undefined) {
+          continue;
+        }
+        if (value === '') {
+          delete next[key];
+        } else {
+          next[key] = value;
+        }
+      }
+      values = next;
+      return { success: true, context: { ...values } };
+    },
+    env: (): NodeJS.ProcessEnv => {
+      const project = callProject.getStore();
+      return Object.fromEntries(
+        Object.entries({ ...values, ...(project && { project }) }).map(([key, value]) => [
+          PROPERTY_ENV_VARS[key as keyof SessionContextValues],
+          value,
+        ]),
+      );
+    },
+  };
+};
+
+const setsProject = (args: string[]) =>
+  args.some((arg) => arg === '--project' || arg.startsWith('--project='));
+
+/**
+ * Adds a flag to a command. gcloud takes everything after `--` as positional
+ * arguments, so the flag goes before it.
+ */
+const withFlag = (args: string[], flag: string) => {
+  const separator = args.indexOf('--');
+  return separator === -1
+    ? [...args, flag]
+    : [...args.slice(0, separator), flag, ...args.slice(separator)];
+};
+
+/**
+ * Wraps gcloud so every invocation uses the session's defaults. Commands run
+ * for a tool call with its own project also get an explicit --project, unless
+ * they set one themselves.
+ */
+export const withSessionContext = (
+  gcloud: GcloudExecutable,
+  session: SessionContext,
+): GcloudExecutable => ({
+  ...gcloud,
+  invoke: async (args: string[], env?: NodeJS.ProcessEnv, options?: ExecutionOptions) => {
+    const project = session.callProject();
+    const projectArgs =
+      project && !setsProject(args) ? withFlag(args, `--project=${project}`) : args;
+    const sessionEnv = { ...session.env(), ...env };
+    const result = await (options
+      ? gcloud.invoke(projectArgs, sessionEnv, options)
+      : gcloud.invoke(projectArgs, sessionEnv));
+    const envProject = sessionEnv['CLOUDSDK_CORE_PROJECT'];
+    return envProject ? { ...result, envProject } : result;
+  },
+});
+
+/**
+ * Returns the project commands run in when they do not set one, or null if
+ * none is set. Throws if gcloud can not read it, so that commands are not run
+ * against an empty project.
+ */
+export const sessionProject = async (gcloud: GcloudExecutable): Promise<string | null> => {
+  const { code, stdout, stderr } = await gcloud.invoke(['config', 'get-value', 'project']);
+  if (code !== 0) {
+    const reason = stderr.trim() || `gcloud exited with code ${code}`;
+    throw new Error(`Failed to read the session project: ${reason}`);
+  }
+  return stdout.trim() || null;
+};
+
+type ToolCallback = (...args: unknown[]) => unknown;
+
+export const PROJECT_PARAMETER = z
+  .string()
+  .optional()
+  .describe(
+    'The project to run this call in instead of the session project. Does not change the session.',
+  );
+
+/**
+ * Adds an optional `project` parameter to every tool registered after this
+ * call that does not have one, so that an agent can work in another project
+ * for a single call. Tools with their own `project` parameter keep it, but the
+ * project passed to them must be on the allowlist too.
+ */
+export const withProjectParameter = (server: McpServer, session: SessionContext): McpServer => {
+  const registerTool = server.registerTool.bind(server) as unknown as (
+    name: string,
+    config: { inputSchema?: z.ZodRawShape },
+    callback: ToolCallback,
+  ) => unknown;
+  const scoped = (
+    name: string,
+    config: { inputSchema?: z.ZodRawShape },
+    callback: ToolCallback,
+  ) => {
+    if (!config.inputSchema) {
+      return registerTool(name, config, callback);
+    }
+    if ('project' in config.inputSchema) {
+      return registerTool(name, config, async (input, ...rest) => {
+        const { project } = input as { project?: string };
+        const error = project ? session.checkProject(project) : undefined;
+        return error ? errorTextResult(error) : callback(input, ...rest);
+      });
+    }
+    const inputSchema = { ...config.inputSchema, project: PROJECT_PARAMETER };
+    return registerTool(name, { ...config, inputSchema }, async (input, ...rest) => {
+      const { project, ...args } = input as { project?: string };
+      if (!project) {
+        return callback(args, ...rest);
+      }
+      const error = session.checkProject(project);
+      if (error) {
+        return errorTextResult(error);
+      }
+      return session.withProject(project, () => callback(args, ...rest));
+    });
+  };
+  server.registerTool = scoped as unknown as typeof server.registerTool;
+  return server;
+};
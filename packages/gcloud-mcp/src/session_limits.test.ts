@@ -0,0 +1,154 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import {
+  createSessionLimiter,
+  recordQueueTime,
+  reportQueueTime,
+  withSessionLimits,
+} from './session_limits.js';
+
+vi.mock('./gcloud.js');
+
+describe('createSessionLimiter', () => {
+  beforeEach(() => {
+    vi.useFakeTimers();
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  test('hands a released slot to the first waiting command', async () => {
+    const limiter = createSessionLimiter({ maxConcurrentCommands: 1 });
+    const first = await limiter.acquire();
+    const second = limiter.acquire();
+
+    expect(limiter.stats()).toEqual({ running: 1, queued: 1 });
+    await vi.advanceTimersByTimeAsync(300);
+    if (first.acquired) {
+      first.release();
+      first.release();
+    }
+
+    await expect(second).resolves.toMatchObject({ acquired: true, queuedMs: 300 });
+    expect(limiter.stats()).toEqual({ running: 1, queued: 0 });
+  });
+
+  test('rejects commands that wait for a slot for too long', async () => {
+    const limiter = createSessionLimiter({ maxConcurrentCommands: 1, maxQueueMs: 1000 });
+    await limiter.acquire();
+    const second = limiter.acquire();
+
+    await vi.advanceTimersByTimeAsync(1000);
+
+    await expect(second).resolves.toEqual({
+      acquired: false,
+      limit: 'concurrency',
+      retryAfterMs: 1000,
+    });
+    expect(limiter.stats()).toEqual({ running: 1, queued: 0 });
+  });
+
+  test('rejects commands beyond the rate limit', async () => {
+    const limiter = createSessionLimiter({ rateLimit: { qps: 1, burst: 1 }, maxQueueMs: 0 });
+
+    await expect(limiter.acquire()).resolves.toMatchObject({ acquired: true });
+    await expect(limiter.acquire()).resolves.toEqual({
+      acquired: false,
+      limit: 'rate',
+      retryAfterMs: 1000,
+    });
+  });
+});
+
+describe('withSessionLimits', () => {
+  let mockedGcloud: gcloud.GcloudExecutable;
+
+  beforeEach(() => {
+    vi.useFakeTimers();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: 'ok', stderr: '' }),
+    };
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  test('runs commands within the limits and releases their slots', async () => {
+    const limiter = createSessionLimiter({ maxConcurrentCommands: 1 });
+    const limited = withSessionLimits(mockedGcloud, limiter);
+
+    await limited.invoke(['projects', 'list']);
+    const result = await limited.invoke(['projects', 'list'], { CLOUDSDK_CORE_PROJECT: 'p' });
+
+    expect(result).toEqual({ code: 0, stdout: 'ok', stderr: '' });
+    expect(mockedGcloud.invoke).toHaveBeenLastCalledWith(['projects', 'list'], {
+      CLOUDSDK_CORE_PROJECT: 'p',
+    });
+    expect(limiter.stats()).toEqual({ running: 0, queued: 0 });
+  });
+
+  test('returns a structured error instead of running throttled commands', async () => {
+    const limiter = createSessionLimiter({ rateLimit: { qps: 0.5, burst: 1 }, maxQueueMs: 0 });
+    const limited = withSessionLimits(mockedGcloud, limiter);
+
+    await limited.invoke(['projects', 'list']);
+    const result = await limited.invoke(['projects', 'list']);
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+    expect(result.throttled).toEqual({ limit: 'rate', retryAfterMs: 2000 });
+    expect(JSON.parse(result.stderr)).toMatchObject({
+      error: 'THROTTLED',
+      limit: 'session rate',
+      retryAfterSeconds: 2,
+    });
+  });
+});
+
+describe('reportQueueTime', () => {
+  const register = (callback: () => Promise<object>) => {
+    const registerTool = vi.fn();
+    const mockServer = { registerTool } as unknown as McpServer;
+    reportQueueTime(mockServer);
+    mockServer.registerTool('tool', {}, callback);
+    return registerTool.mock.calls[0]![2];
+  };
+
+  test('reports the time the call waited in the result metadata', async () => {
+    const handler = register(async () => {
+      recordQueueTime(120);
+      recordQueueTime(30);
+      return { content: [], _meta: { sdk_version: '499.0.0' } };
+    });
+
+    await expect(handler({}, {})).resolves.toEqual({
+      content: [],
+      _meta: { sdk_version: '499.0.0', queued_ms: 150 },
+    });
+  });
+
+  test('leaves results of calls that did not wait unchanged', async () => {
+    const handler = register(async () => ({ content: [] }));
+
+    await expect(handler({}, {})).resolves.toEqual({ content: [] });
+  });
+});
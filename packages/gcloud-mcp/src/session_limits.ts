@@ -0,0 +1,210 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { AsyncLocalStorage } from 'async_hooks';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { GcloudExecutable, GcloudInvocationResult } from './gcloud.js';
+import { ExecutionOptions } from './gcloud_executor.js';
+import { DEFAULT_MAX_QUEUE_MS, RateLimit, createRateLimiter } from './rate_limiter.js';
+
+export interface SessionLimitsConfig {
+  /** The most gcloud commands a session runs at the same time. */
+  maxConcurrentCommands?: number;
+  /** The rate at which a session may start gcloud commands, across API families. */
+  rateLimit?: RateLimit;
+  /** Commands wait this long for a free slot or a token before being rejected. */
+  maxQueueMs?: number;
+}
+
+export type SessionThrottle = NonNullable<GcloudInvocationResult['throttled']>;
+
+export type SessionLimitResult =
+  | { acquired: true; queuedMs: number; release: () => void }
+  | ({ acquired: false } & SessionThrottle);
+
+// The key of the time a tool call waited for the session limits in the `_meta` of its result.
+export const QUEUE_TIME_META_KEY = 'queued_ms';
+
+// The rate limiter of a session has a single bucket for all of its commands.
+const SESSION_BUCKET = 'session';
+
+export const validateSessionLimits = (config: SessionLimitsConfig): string | undefined => {
+  const max = config.maxConcurrentCommands;
+  if (max !== undefined && !(Number.isInteger(max) && max > 0)) {
+    return `"maxConcurrentCommands" must be a positive integer: ${max}`;
+  }
+  const rate = config.rateLimit;
+  if (rate && (!(rate.qps > 0) || !(rate.burst >= 1))) {
+    return 'Invalid session rate limit: "qps" must be greater than 0 and "burst" at least 1.';
+  }
+  if (config.maxQueueMs !== undefined && !(config.maxQueueMs >= 0)) {
+    return `"maxQueueMs" must not be negative: ${config.maxQueueMs}`;
+  }
+  return undefined;
+};
+
+export type SessionLimiter = ReturnType<typeof createSessionLimiter>;
+
+/**
+ * Creates the limits of a session: a semaphore that bounds the gcloud
+ * processes it runs at the same time, and a token bucket that bounds the rate
+ * at which it starts them. Commands queue for a slot or a token for at most
+ * `maxQueueMs` before being rejected.
+ */
+export const createSessionLimiter = (config: SessionLimitsConfig) => {
+  const maxQueueMs = config.maxQueueMs ?? DEFAULT_MAX_QUEUE_MS;
+  const rateLimiter =
+    config.rateLimit && createRateLimiter({ [SESSION_BUCKET]: config.rateLimit }, maxQueueMs);
+  let running = 0;
+  // Commands waiting for a slot, in order. A released slot is handed to the first.
+  const waiting: Array<() => void> = [];
+
+  const release = () => {
+    const next = waiting.shift();
+    if (next) {
+      next();
+    } else {
+      running--;
+    }
+  };
+
+  const waitForSlot = (): Promise<boolean> => {
+    const max = config.maxConcurrentCommands;
+    if (max === undefined || running < max) {
+      running++;
+      return Promise.resolve(true);
+    }
+    return new Promise((resolve) => {
+      const grant = () => {
+        clearTimeout(timer);
+        resolve(true);
+      };
+      const timer = setTimeout(() => {
+        waiting.splice(waiting.indexOf(grant), 1);
+        resolve(false);
+      }, maxQueueMs);
+      waiting.push(grant);
+    });
+  };
+
+  return {
+    acquire: async (): Promise<SessionLimitResult> => {
+      const start = Date.now();
+      if (rateLimiter) {
+        const rate = await rateLimiter.acquire(SESSION_BUCKET);
+        if (!rate.acquired) {
+          return { acquired: false, limit: 'rate', retryAfterMs: rate.retryAfterMs };
+        }
+      }
+      if (!(await waitForSlot())) {
+        return { acquired: false, limit: 'concurrency', retryAfterMs: maxQueueMs };
+      }
+      let released = false;
+      return {
+        acquired: true,
+        queuedMs: Date.now() - start,
+        release: () => {
+          if (!released) {
+            released = true;
+            release();
+          }
+        },
+      };
+    },
+    /** Returns the number of commands running and waiting for a slot. */
+    stats: () => ({ running, queued: waiting.length }),
+  };
+};
+
+const THROTTLED_MESSAGES: Record<SessionThrottle['limit'], string> = {
+  concurrency:
+    'Execution throttled: this session already runs the most gcloud commands it may run at the same time. Wait for them to finish and retry after the given delay.',
+  rate: 'Execution throttled: this session started gcloud commands faster than its rate limit allows. Retry after the given delay.',
+};
+
+/** Returns the structured error of a command that was rejected by the session limits. */
+export const sessionThrottledErrorMessage = (throttle: SessionThrottle) =>
+  JSON.stringify(
+    {
+      error: 'THROTTLED',
+      limit: `session ${throttle.limit}`,
+      message: THROTTLED_MESSAGES[throttle.limit],
+      retryAfterSeconds: throttle.retryAfterMs / 1000,
+    },
+    null,
+    2,
+  );
+
+// The time the tool call in progress has waited for limits so far.
+const queueTime = new AsyncLocalStorage<{ queuedMs: number }>();
+
+/** Adds to the time the tool call in progress waited before its commands ran. */
+export const recordQueueTime = (ms: number) => {
+  const store = queueTime.getStore();
+  if (store && ms > 0) {
+    store.queuedMs += ms;
+  }
+};
+
+/**
+ * Wraps gcloud so that every invocation waits for the limits of the session.
+ * Rejected commands are not run and return `throttled` instead.
+ */
+export const withSessionLimits = (
+  gcloud: GcloudExecutable,
+  limiter: SessionLimiter,
+): GcloudExecutable => ({
+  ...gcloud,
+  invoke: async (args: string[], env?: NodeJS.ProcessEnv, options?: ExecutionOptions) => {
+    const slot = await limiter.acquire();
+    if (!slot.acquired) {
+      const { limit, retryAfterMs } = slot;
+      const throttled = { limit, retryAfterMs };
+      return { code: null, stdout: '', stderr: sessionThrottledErrorMessage(throttled), throttled };
+    }
+    recordQueueTime(slot.queuedMs);
+    try {
+      return await (options ? gcloud.invoke(args, env, options) : gcloud.invoke(args, env));
+    } finally {
+      slot.release();
+    }
+  },
+});
+
+type ToolCallback = (...args: unknown[]) => Promise<{ _meta?: Record<string, unknown> }>;
+
+/**
+ * Reports how long each call of the tools registered on the server from now
+ * on waited for rate and concurrency limits in the `_meta` of its result.
+ */
+export const reportQueueTime = (server: McpServer): McpServer => {
+  const registerTool = server.registerTool.bind(server) as unknown as (
+    name: string,
+    config: unknown,
+    callback: ToolCallback,
+  ) => unknown;
+  const reporting = (name: string, config: unknown, callback: ToolCallback) =>
+    registerTool(name, config, async (...args: unknown[]) => {
+      const store = { queuedMs: 0 };
+      const result = await queueTime.run(store, () => callback(...args));
+      if (store.queuedMs === 0) {
+        return result;
+      }
+      return { ...result, _meta: { ...result._meta, [QUEUE_TIME_META_KEY]: store.queuedMs } };
+    });
+  server.registerTool = reporting as unknown as typeof server.registerTool;
+  return server;
+};
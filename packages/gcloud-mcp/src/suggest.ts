@@ -57,7 +57,7 @@ export async function findSuggestedAlternativeCommand(
     if (!lintResult.success) {
       continue; // Argument set not valid for this release track.
     }
-    const aclResult = acl.check(lintResult.parsedCommand);
+    const aclResult = acl.check(lintResult.parsedCommand, altArgs);
     if (!aclResult.permitted) {
       continue; // ACL does not permit this release track + command.
     }
@@ -0,0 +1,231 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import {
+  MAX_CASE_COMMANDS,
+  MAX_DESCRIPTION_CHARS,
+  addCommentArgs,
+  caseDescription,
+  createCaseArgs,
+  listCases,
+  searchClassifications,
+  uploadAttachment,
+  validateCaseName,
+} from './support_cases.js';
+
+vi.mock('./gcloud.js');
+
+const output = (value: unknown) => ({ code: 0, stdout: JSON.stringify(value), stderr: '' });
+
+const entry = (index: number, args: string[], exitCode: number | null) => ({
+  index,
+  args,
+  exitCode,
+  timestamp: `2025-01-01T10:0${index}:00.000Z`,
+  env: {},
+});
+
+describe('caseDescription', () => {
+  test('writes up the incident in sections', () => {
+    expect(
+      caseDescription({
+        summary: 'Instances in us-central1-a fail to start.',
+        impact: 'The checkout service is down.',
+        timeline: [
+          { time: '2025-01-01T10:05:00Z', event: 'Restarts began failing' },
+          { time: '2025-01-01T10:00:00Z', event: 'Deployed v42' },
+        ],
+        stepsTaken: ['Rolled back to v41'],
+        commands: [entry(1, ['compute', 'instances', 'start', 'web-1'], 1)],
+      }),
+    ).toBe(`Summary:
+Instances in us-central1-a fail to start.
+
+Impact:
+The checkout service is down.
+
+Timeline:
+- 2025-01-01T10:00:00Z: Deployed v42
+- 2025-01-01T10:05:00Z: Restarts began failing
+
+Steps taken:
+- Rolled back to v41
+
+Commands run while troubleshooting:
+- 2025-01-01T10:01:00.000Z: gcloud compute instances start web-1 (exit code 1)`);
+  });
+
+  test('only includes the most recent commands', () => {
+    const commands = Array.from({ length: MAX_CASE_COMMANDS + 5 }, (_, i) =>
+      entry(i, ['config', 'list', `--n=${i}`], 0),
+    );
+
+    const description = caseDescription({ summary: 'Broken.', commands });
+
+    expect(description).not.toContain('--n=4 ');
+    expect(description).toContain(`--n=${MAX_CASE_COMMANDS + 4} `);
+    expect(description.match(/^- /gm)).toHaveLength(MAX_CASE_COMMANDS);
+  });
+
+  test('truncates long descriptions', () => {
+    const description = caseDescription({ summary: 'x'.repeat(MAX_DESCRIPTION_CHARS * 2) });
+
+    expect(description.length).toBeLessThanOrEqual(MAX_DESCRIPTION_CHARS);
+    expect(description).toMatch(/\[description truncated\]$/);
+  });
+});
+
+describe('createCaseArgs', () => {
+  test('creates a case in a project', () => {
+    expect(
+      createCaseArgs(
+        { project: 'p' },
+        {
+          displayName: 'VMs fail to start',
+          description: 'Summary:\nBroken.',
+          classification: 'ABC123',
+          severity: 's2',
+          ccAddresses: ['sre@example.com', 'dev@example.com'],
+        },
+      ),
+    ).toEqual([
+      'support',
+      'cases',
+      'create',
+      '--display-name=VMs fail to start',
+      '--description=Summary:\nBroken.',
+      '--classification=ABC123',
+      '--severity=s2',
+      '--cc-addresses=sre@example.com,dev@example.com',
+      '--project=p',
+    ]);
+  });
+
+  test('creates test cases in an organization', () => {
+    const args = createCaseArgs(
+      { organization: '123' },
+      {
+        displayName: 'Test',
+        description: 'Test',
+        classification: 'ABC123',
+        severity: 's4',
+        testCase: true,
+      },
+    );
+
+    expect(args.slice(-2)).toEqual(['--test-case', '--organization=123']);
+  });
+});
+
+describe('addCommentArgs', () => {
+  test('adds a comment to a case', () => {
+    expect(addCommentArgs('projects/p/cases/1', 'Logs attached.')).toEqual([
+      'support',
+      'cases',
+      'comments',
+      'create',
+      'projects/p/cases/1',
+      '--comment=Logs attached.',
+    ]);
+  });
+});
+
+describe('validateCaseName', () => {
+  test('accepts full case names only', () => {
+    expect(validateCaseName('projects/p/cases/12345')).toBeUndefined();
+    expect(validateCaseName('organizations/1/cases/12345')).toBeUndefined();
+    expect(validateCaseName('12345')).toContain('Invalid case');
+  });
+});
+
+describe('listCases', () => {
+  test('lists open cases, most recently updated first', async () => {
+    const gcloud: gcloud.GcloudExecutable = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue(
+        output([
+          { name: 'projects/p/cases/1', state: 'CLOSED', updateTime: '2025-01-03T00:00:00Z' },
+          {
+            name: 'projects/p/cases/2',
+            displayName: 'VMs fail to start',
+            state: 'IN_PROGRESS_GOOGLE_SUPPORT',
+            priority: 'P2',
+            classification: { displayName: 'Compute > VM' },
+            creator: { email: 'alice@example.com' },
+            createTime: '2025-01-01T00:00:00Z',
+            updateTime: '2025-01-01T00:00:00Z',
+          },
+          { name: 'projects/p/cases/3', state: 'NEW', updateTime: '2025-01-02T00:00:00Z' },
+        ]),
+      ),
+    };
+
+    const cases = await listCases(gcloud, { project: 'p' }, false);
+
+    expect(cases.map((c) => c.name)).toEqual(['projects/p/cases/3', 'projects/p/cases/2']);
+    expect(cases[1]).toEqual({
+      name: 'projects/p/cases/2',
+      displayName: 'VMs fail to start',
+      state: 'IN_PROGRESS_GOOGLE_SUPPORT',
+      priority: 'P2',
+      classification: 'Compute > VM',
+      creator: 'alice@example.com',
+      escalated: false,
+      createdAt: '2025-01-01T00:00:00Z',
+      updatedAt: '2025-01-01T00:00:00Z',
+    });
+    expect(gcloud.invoke).toHaveBeenCalledWith([
+      'support',
+      'cases',
+      'list',
+      '--project=p',
+      '--format=json',
+    ]);
+  });
+});
+
+describe('searchClassifications', () => {
+  test('searches classifications by display name', async () => {
+    const gcloud: gcloud.GcloudExecutable = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue(output([{ id: 'ABC', displayName: 'Compute Engine' }])),
+    };
+
+    expect(await searchClassifications(gcloud, 'Compute "Engine"')).toEqual([
+      { id: 'ABC', displayName: 'Compute Engine' },
+    ]);
+    expect(vi.mocked(gcloud.invoke).mock.calls[0]![0]).toContain(
+      '--query=displayName:"*Compute Engine*"',
+    );
+  });
+});
+
+describe('uploadAttachment', () => {
+  test('uploads to the attachments of the case', async () => {
+    const api = { get: vi.fn(), post: vi.fn(), upload: vi.fn().mockResolvedValue({}) };
+
+    await uploadAttachment(api, 'projects/p/cases/1', 'events.log', 'line 1', 'text/plain');
+
+    expect(api.upload).toHaveBeenCalledWith(
+      'https://cloudsupport.googleapis.com/upload/v2/projects/p/cases/1/attachments',
+      { attachment: { filename: 'events.log' } },
+      'line 1',
+      'text/plain',
+    );
+  });
+});
@@ -0,0 +1,202 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { invokeJson } from './gcloud_json.js';
+import { GoogleApiUploadClient } from './google_api.js';
+import { CommandHistoryEntry } from './command_history.js';
+
+const SUPPORT_UPLOAD_URL = 'https://cloudsupport.googleapis.com/upload/v2';
+
+// Only the most recent commands are worth reading in a case description.
+export const MAX_CASE_COMMANDS = 20;
+// Longer descriptions are truncated, so a long history can not keep a case from being created.
+export const MAX_DESCRIPTION_CHARS = 25000;
+const TRUNCATED = '\n[description truncated]';
+
+/** Severities as gcloud spells them, from most to least severe. */
+export const CASE_SEVERITIES = ['s0', 's1', 's2', 's3', 's4'] as const;
+
+export type CaseSeverity = (typeof CASE_SEVERITIES)[number];
+
+export type SupportParent = { project: string } | { organization: string };
+
+export interface IncidentReport {
+  summary: string;
+  impact?: string;
+  timeline?: Array<{ time: string; event: string }>;
+  stepsTaken?: string[];
+  /** The commands run while troubleshooting, oldest first. */
+  commands?: CommandHistoryEntry[];
+}
+
+export interface CaseSpec {
+  displayName: string;
+  description: string;
+  /** The classification ID, from `gcloud support classifications search`. */
+  classification: string;
+  severity: CaseSeverity;
+  ccAddresses?: string[];
+  testCase?: boolean;
+}
+
+export interface SupportCase {
+  /** The resource name, e.g. `projects/p/cases/123`. */
+  name: string;
+  displayName: string | null;
+  state: string | null;
+  priority: string | null;
+  classification: string | null;
+  creator: string | null;
+  escalated: boolean;
+  createdAt: string | null;
+  updatedAt: string | null;
+}
+
+const CaseSchema = z.object({
+  name: z.string(),
+  displayName: z.string().nullish(),
+  state: z.string().nullish(),
+  priority: z.string().nullish(),
+  classification: z.object({ displayName: z.string().nullish() }).nullish(),
+  creator: z.object({ email: z.string().nullish() }).nullish(),
+  escalated: z.boolean().nullish(),
+  createTime: z.string().nullish(),
+  updateTime: z.string().nullish(),
+});
+
+const ClassificationSchema = z.object({ id: z.string(), displayName: z.string() });
+
+const parentFlag = (parent: SupportParent) =>
+  'project' in parent ? `--project=${parent.project}` : `--organization=${parent.organization}`;
+
+// Case names look like projects/my-project/cases/12345.
+const CASE_NAME = /^(projects|organizations)\/[^/]+\/cases\/\d+$/;
+
+/** Returns an error message if a case name is not a full resource name. */
+export const validateCaseName = (name: string): string | undefined =>
+  CASE_NAME.test(name)
+    ? undefined
+    : `Invalid case "${name}". Use the full name, e.g. "projects/my-project/cases/12345".`;
+
+/** Writes an incident up as a case description, in the order support engineers read it. */
+export const caseDescription = (report: IncidentReport): string => {
+  const sections = [`Summary:\n${report.summary}`];
+  if (report.impact) {
+    sections.push(`Impact:\n${report.impact}`);
+  }
+  if (report.timeline?.length) {
+    const events = [...report.timeline].sort((a, b) => a.time.localeCompare(b.time));
+    const lines = events.map(({ time, event }) => `- ${time}: ${event}`);
+    sections.push(`Timeline:\n${lines.join('\n')}`);
+  }
+  if (report.stepsTaken?.length) {
+    sections.push(`Steps taken:\n${report.stepsTaken.map((step) => `- ${step}`).join('\n')}`);
+  }
+  const commands = (report.commands ?? []).slice(-MAX_CASE_COMMANDS);
+  if (commands.length > 0) {
+    const lines = commands.map(
+      (entry) =>
+        `- ${entry.timestamp}: gcloud ${entry.args.join(' ')} (exit code ${entry.exitCode ?? 'unknown'})`,
+    );
+    sections.push(`Commands run while troubleshooting:\n${lines.join('\n')}`);
+  }
+  const description = sections.join('\n\n');
+  return description.length > MAX_DESCRIPTION_CHARS
+    ? description.slice(0, MAX_DESCRIPTION_CHARS - TRUNCATED.length) + TRUNCATED
+    : description;
+};
+
+export const createCaseArgs = (parent: SupportParent, spec: CaseSpec): string[] => [
+  'support',
+  'cases',
+  'create',
+  `--display-name=${spec.displayName}`,
+  `--description=${spec.description}`,
+  `--classification=${spec.classification}`,
+  `--severity=${spec.severity}`,
+  ...(spec.ccAddresses?.length ? [`--cc-addresses=${spec.ccAddresses.join(',')}`] : []),
+  ...(spec.testCase ? ['--test-case'] : []),
+  parentFlag(parent),
+];
+
+export const addCommentArgs = (caseName: string, comment: string): string[] => [
+  'support',
+  'cases',
+  'comments',
+  'create',
+  caseName,
+  `--comment=${comment}`,
+];
+
+/** Lists support cases, by default only those that are not closed, most recently updated first. */
+export const listCases = async (
+  gcloud: GcloudExecutable,
+  parent: SupportParent,
+  includeClosed: boolean,
+): Promise<SupportCase[]> =>
+  z
+    .array(CaseSchema)
+    .parse(
+      await invokeJson(gcloud, ['support', 'cases', 'list', parentFlag(parent), '--format=json']),
+    )
+    .filter((c) => includeClosed || c.state !== 'CLOSED')
+    .map((c) => ({
+      name: c.name,
+      displayName: c.displayName ?? null,
+      state: c.state ?? null,
+      priority: c.priority ?? null,
+      classification: c.classification?.displayName ?? null,
+      creator: c.creator?.email ?? null,
+      escalated: c.escalated ?? false,
+      createdAt: c.createTime ?? null,
+      updatedAt: c.updateTime ?? null,
+    }))
+    .sort((a, b) => (b.updatedAt ?? '').localeCompare(a.updatedAt ?? ''));
+
+/** Finds the classifications, which every case needs, whose name contains some text. */
+export const searchClassifications = async (
+  gcloud: GcloudExecutable,
+  text: string,
+): Promise<Array<{ id: string; displayName: string }>> =>
+  z
+    .array(ClassificationSchema)
+    .parse(
+      await invokeJson(gcloud, [
+        'support',
+        'classifications',
+        'search',
+        `--query=displayName:"*${text.replaceAll('"', '')}*"`,
+        '--format=json',
+      ]),
+    )
+    .map(({ id, displayName }) => ({ id, displayName }));
+
+/** Attaches a file, e.g. collected logs, to a case. */
+export const uploadAttachment = async (
+  api: GoogleApiUploadClient,
+  caseName: string,
+  filename: string,
+  content: string,
+  contentType: string,
+): Promise<unknown> =>
+  api.upload(
+    `${SUPPORT_UPLOAD_URL}/${caseName}/attachments`,
+    { attachment: { filename } },
+    content,
+    contentType,
+  );
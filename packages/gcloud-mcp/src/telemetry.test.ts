@@ -0,0 +1,186 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from './google_api.js';
+import { UsageEvent, createTelemetry, instrumentTools, usageReport } from './telemetry.js';
+
+const event = (name: string, latencyMs: number, errorClass?: string): UsageEvent => ({
+  timestamp: '2025-01-01T00:00:00.000Z',
+  kind: 'command',
+  name,
+  latencyMs,
+  ok: !errorClass,
+  ...(errorClass && { errorClass }),
+});
+
+describe('usageReport', () => {
+  test('summarizes calls, errors and latency by name', () => {
+    const report = usageReport(
+      [
+        event('compute instances list', 100),
+        event('compute instances list', 300),
+        event('compute instances list', 200, 'PERMISSION_DENIED'),
+        event('run deploy', 5000, 'COMMAND_FAILED'),
+      ],
+      'session',
+    );
+
+    expect(report.commands).toEqual([
+      {
+        name: 'compute instances list',
+        calls: 3,
+        errors: 1,
+        p50LatencyMs: 200,
+        p95LatencyMs: 300,
+      },
+      { name: 'run deploy', calls: 1, errors: 1, p50LatencyMs: 5000, p95LatencyMs: 5000 },
+    ]);
+    expect(report.tools).toEqual([]);
+    expect(report.errorClasses).toEqual([
+      { errorClass: 'PERMISSION_DENIED', count: 1 },
+      { errorClass: 'COMMAND_FAILED', count: 1 },
+    ]);
+  });
+
+  test('counts read cache hits apart from the commands that ran', () => {
+    const report = usageReport(
+      [
+        { ...event('compute instances list', 100), cache: 'miss' },
+        { ...event('compute instances list', 0), cache: 'hit' },
+        { ...event('compute instances list', 0), cache: 'hit' },
+      ],
+      'session',
+    );
+
+    expect(report.commands).toMatchObject([{ name: 'compute instances list', calls: 1 }]);
+    expect(report.cache).toEqual({ hits: 2, misses: 1 });
+  });
+
+  test('has no period without events', () => {
+    expect(usageReport([], 'session')).toMatchObject({ events: 0, period: null });
+  });
+});
+
+describe('createTelemetry', () => {
+  let dir: string;
+
+  beforeEach(() => {
+    dir = fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-telemetry-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(dir, { recursive: true, force: true });
+  });
+
+  test('appends events to the file and reports from it', () => {
+    const file = path.join(dir, 'usage.jsonl');
+    createTelemetry({ file }).record({
+      kind: 'tool',
+      name: 'gcloud_context',
+      latencyMs: 5,
+      ok: true,
+    });
+
+    const lines = fs.readFileSync(file, 'utf-8').trim().split('\n');
+    expect(lines).toHaveLength(1);
+    expect(JSON.parse(lines[0]!)).toMatchObject({ kind: 'tool', name: 'gcloud_context' });
+    // A new session sees the usage of earlier ones.
+    expect(createTelemetry({ file }).report().tools[0]).toMatchObject({ calls: 1 });
+  });
+
+  test('reports session events without a file', () => {
+    const telemetry = createTelemetry({ monitoringProject: 'ops' });
+    telemetry.record({ kind: 'tool', name: 'gcloud_context', latencyMs: 5, ok: true });
+
+    expect(telemetry.report()).toMatchObject({ source: 'session', events: 1 });
+    expect(telemetry.report(new Date(Date.now() + 1000))).toMatchObject({ events: 0 });
+  });
+
+  test('writes cumulative counts to Cloud Monitoring once per change', async () => {
+    const api: GoogleApiClient = { get: vi.fn(), post: vi.fn().mockResolvedValue({}) };
+    const telemetry = createTelemetry({ monitoringProject: 'ops' }, api);
+    telemetry.record({ kind: 'command', name: 'compute instances list', latencyMs: 10, ok: true });
+    telemetry.record({ kind: 'command', name: 'compute instances list', latencyMs: 30, ok: true });
+
+    await telemetry.flush();
+    await telemetry.flush();
+
+    expect(api.post).toHaveBeenCalledOnce();
+    const [url, body] = vi.mocked(api.post).mock.calls[0]!;
+    expect(url).toBe('https://monitoring.googleapis.com/v3/projects/ops/timeSeries');
+    expect((body as { timeSeries: unknown[] }).timeSeries).toMatchObject([
+      {
+        metric: {
+          type: 'custom.googleapis.com/gcloud_mcp/invocations',
+          labels: { kind: 'command', name: 'compute instances list', outcome: 'success' },
+        },
+        points: [{ value: { int64Value: '2' } }],
+      },
+      {
+        metric: { type: 'custom.googleapis.com/gcloud_mcp/latency' },
+        points: [{ value: { doubleValue: 20 } }],
+      },
+    ]);
+  });
+
+  test('writes read cache lookups to Cloud Monitoring', async () => {
+    const api: GoogleApiClient = { get: vi.fn(), post: vi.fn().mockResolvedValue({}) };
+    const telemetry = createTelemetry({ monitoringProject: 'ops' }, api);
+    const name = 'compute instances list';
+    telemetry.record({ kind: 'command', name, latencyMs: 10, ok: true, cache: 'miss' });
+    telemetry.record({ kind: 'command', name, latencyMs: 0, ok: true, cache: 'hit' });
+
+    await telemetry.flush();
+
+    const [, body] = vi.mocked(api.post).mock.calls[0]!;
+    const { timeSeries } = body as { timeSeries: Array<{ metric: { type: string } }> };
+    expect(timeSeries.map(({ metric }) => metric.type)).toEqual([
+      'custom.googleapis.com/gcloud_mcp/invocations',
+      'custom.googleapis.com/gcloud_mcp/latency',
+      'custom.googleapis.com/gcloud_mcp/cache_lookups',
+      'custom.googleapis.com/gcloud_mcp/cache_lookups',
+    ]);
+    expect(timeSeries[0]).toMatchObject({ points: [{ value: { int64Value: '1' } }] });
+    expect(timeSeries.slice(2)).toMatchObject([
+      { metric: { labels: { name, outcome: 'miss' } } },
+      { metric: { labels: { name, outcome: 'hit' } } },
+    ]);
+  });
+});
+
+describe('instrumentTools', () => {
+  test('records the outcome of tool calls', async () => {
+    const registerTool = vi.fn();
+    const server = { registerTool } as unknown as McpServer;
+    const telemetry = createTelemetry({ monitoringProject: 'ops' });
+    instrumentTools(server, telemetry);
+
+    server.registerTool('failing_tool', {}, async () => ({
+      content: [{ type: 'text' as const, text: JSON.stringify({ error: 'THROTTLED' }) }],
+      isError: true,
+    }));
+    await (registerTool as Mock).mock.calls[0]![2]({});
+
+    expect(registerTool).toHaveBeenCalledWith('failing_tool', {}, expect.any(Function));
+    expect(telemetry.report().tools).toMatchObject([{ name: 'failing_tool', errors: 1 }]);
+    expect(telemetry.report().errorClasses).toEqual([{ errorClass: 'THROTTLED', count: 1 }]);
+  });
+});
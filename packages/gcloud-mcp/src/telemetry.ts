@@ -0,0 +1,346 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import fs from 'fs';
+import path from 'path';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { GoogleApiClient } from './google_api.js';
+import { log } from './utility/logger.js';
+
+export const MONITORING_URL = 'https://monitoring.googleapis.com/v3';
+export const METRIC_PREFIX = 'custom.googleapis.com/gcloud_mcp';
+
+// Events kept in memory for usage reports without a file.
+export const MAX_EVENTS = 10000;
+// Cloud Monitoring accepts at most this many time series per request.
+const MAX_TIME_SERIES_PER_REQUEST = 200;
+export const DEFAULT_FLUSH_INTERVAL_MS = 60 * 1000;
+
+export interface TelemetryConfig {
+  /** A file usage events are appended to as JSON Lines. Must be absolute. */
+  file?: string;
+  /** A project usage is written to as Cloud Monitoring custom metrics. */
+  monitoringProject?: string;
+}
+
+/**
+ * A tool call or gcloud command. Events never include arguments or output,
+ * only the name of what was used and how it went.
+ */
+export interface UsageEvent {
+  timestamp: string;
+  kind: 'tool' | 'command';
+  /** The tool name, or the command without flags and positionals, e.g. `compute instances list`. */
+  name: string;
+  latencyMs: number;
+  ok: boolean;
+  /** A coarse class of the failure, e.g. `PERMISSION_DENIED`. */
+  errorClass?: string;
+  /** Whether a read command was served from the read cache, or ran after missing it. */
+  cache?: 'hit' | 'miss';
+}
+
+export interface UsageStats {
+  name: string;
+  calls: number;
+  errors: number;
+  p50LatencyMs: number;
+  p95LatencyMs: number;
+}
+
+export interface UsageReport {
+  source: string;
+  period: { start: string; end: string } | null;
+  events: number;
+  tools: UsageStats[];
+  commands: UsageStats[];
+  errorClasses: Array<{ errorClass: string; count: number }>;
+  cache: { hits: number; misses: number };
+}
+
+export type Telemetry = ReturnType<typeof createTelemetry>;
+
+export const validateTelemetry = (config: TelemetryConfig): string | undefined => {
+  if (!config.file && !config.monitoringProject) {
+    return 'Telemetry needs a "file", a "monitoringProject", or both.';
+  }
+  if (config.file && !path.isAbsolute(config.file)) {
+    return `Telemetry file path must be absolute: ${config.file}`;
+  }
+  return undefined;
+};
+
+const percentile = (sorted: number[], p: number) =>
+  sorted[Math.min(sorted.length - 1, Math.floor((p / 100) * sorted.length))] ?? 0;
+
+const statsOf = (events: UsageEvent[]): UsageStats[] => {
+  const byName = new Map<string, UsageEvent[]>();
+  for (const event of events) {
+    byName.set(event.name, [...(byName.get(event.name) ?? []), event]);
+  }
+  return [...byName]
+    .map(([name, named]) => {
+      const latencies = named.map(({ latencyMs }) => latencyMs).sort((a, b) => a - b);
+      return {
+        name,
+        calls: named.length,
+        errors: named.filter(({ ok }) => !ok).length,
+        p50LatencyMs: percentile(latencies, 50),
+        p95LatencyMs: percentile(latencies, 95),
+      };
+    })
+    .sort((a, b) => b.calls - a.calls || a.name.localeCompare(b.name));
+};
+
+/** Summarizes usage events, most used first. */
+export const usageReport = (events: UsageEvent[], source: string): UsageReport => {
+  const errorClasses = new Map<string, number>();
+  for (const { errorClass } of events) {
+    if (errorClass) {
+      errorClasses.set(errorClass, (errorClasses.get(errorClass) ?? 0) + 1);
+    }
+  }
+  const timestamps = events.map(({ timestamp }) => timestamp).sort();
+  const [start, end] = [timestamps[0], timestamps.at(-1)];
+  const countCache = (outcome: UsageEvent['cache']) =>
+    events.filter(({ cache }) => cache === outcome).length;
+  return {
+    source,
+    period: start && end ? { start, end } : null,
+    events: events.length,
+    tools: statsOf(events.filter(({ kind }) => kind === 'tool')),
+    // Commands served from the cache did not run, so they do not count as calls.
+    commands: statsOf(events.filter(({ kind, cache }) => kind === 'command' && cache !== 'hit')),
+    errorClasses: [...errorClasses]
+      .map(([errorClass, count]) => ({ errorClass, count }))
+      .sort((a, b) => b.count - a.count),
+    cache: { hits: countCache('hit'), misses: countCache('miss') },
+  };
+};
+
+/** Reads the events of a telemetry file, skipping lines that can not be parsed. */
+export const readUsageEvents = (file: string): UsageEvent[] => {
+  if (!fs.existsSync(file)) {
+    return [];
+  }
+  return fs
+    .readFileSync(file, 'utf-8')
+    .split('\n')
+    .flatMap((line) => {
+      try {
+        return line.trim() ? [JSON.parse(line) as UsageEvent] : [];
+      } catch {
+        return [];
+      }
+    });
+};
+
+interface SeriesTotals {
+  event: Pick<UsageEvent, 'kind' | 'name' | 'ok' | 'errorClass'>;
+  count: number;
+  /** Latency of the events since the last flush. */
+  pending: number[];
+}
+
+interface CacheTotals {
+  name: string;
+  outcome: NonNullable<UsageEvent['cache']>;
+  count: number;
+  changed: boolean;
+}
+
+/**
+ * Creates the usage recorder of an opted-in server. Events are kept in memory,
+ * appended to the telemetry file, and, with a monitoring project, written as
+ * custom metrics every flush interval.
+ */
+export const createTelemetry = (config: TelemetryConfig, api?: GoogleApiClient) => {
+  const events: UsageEvent[] = [];
+  const series = new Map<string, SeriesTotals>();
+  const cacheLookups = new Map<string, CacheTotals>();
+  const startTime = new Date().toISOString();
+  let fileFailed = false;
+
+  const record = (event: Omit<UsageEvent, 'timestamp'>) => {
+    const entry: UsageEvent = { timestamp: new Date().toISOString(), ...event };
+    events.push(entry);
+    if (events.length > MAX_EVENTS) {
+      events.shift();
+    }
+    if (config.file && !fileFailed) {
+      try {
+        fs.appendFileSync(config.file, JSON.stringify(entry) + '\n');
+      } catch (e: unknown) {
+        // Telemetry must never break the tools, so a broken file is reported once.
+        fileFailed = true;
+        log.warn(`Unable to write telemetry to ${config.file}`, { error: String(e) });
+      }
+    }
+    if (config.monitoringProject && entry.cache) {
+      const key = JSON.stringify([entry.name, entry.cache]);
+      const totals = cacheLookups.get(key) ?? {
+        name: entry.name,
+        outcome: entry.cache,
+        count: 0,
+        changed: false,
+      };
+      totals.count += 1;
+      totals.changed = true;
+      cacheLookups.set(key, totals);
+    }
+    // Commands served from the cache did not run, so they are not invocations.
+    if (config.monitoringProject && entry.cache !== 'hit') {
+      const { kind, name, ok, errorClass } = entry;
+      const key = JSON.stringify([kind, name, ok, errorClass ?? '']);
+      const totals = series.get(key) ?? {
+        event: { kind, name, ok, ...(errorClass && { errorClass }) },
+        count: 0,
+        pending: [],
+      };
+      totals.count += 1;
+      totals.pending.push(entry.latencyMs);
+      series.set(key, totals);
+    }
+  };
+
+  /** Writes the metrics of the events since the last flush to Cloud Monitoring. */
+  const flush = async () => {
+    const project = config.monitoringProject;
+    const changed = [...series.values()].filter(({ pending }) => pending.length > 0);
+    const changedLookups = [...cacheLookups.values()].filter((totals) => totals.changed);
+    if (!api || !project || changed.length + changedLookups.length === 0) {
+      return;
+    }
+    const endTime = new Date().toISOString();
+    const resource = { type: 'global', labels: { project_id: project } };
+    const invocations = changed.flatMap(({ event, count, pending }) => {
+      const labels = {
+        kind: event.kind,
+        name: event.name,
+        outcome: event.ok ? 'success' : 'error',
+        error_class: event.errorClass ?? '',
+      };
+      const meanLatency = pending.reduce((sum, value) => sum + value, 0) / pending.length;
+      return [
+        {
+          metric: { type: `${METRIC_PREFIX}/invocations`, labels },
+          resource,
+          metricKind: 'CUMULATIVE',
+          valueType: 'INT64',
+          points: [{ interval: { startTime, endTime }, value: { int64Value: String(count) } }],
+        },
+        {
+          metric: { type: `${METRIC_PREFIX}/latency`, labels },
+          resource,
+          metricKind: 'GAUGE',
+          valueType: 'DOUBLE',
+          points: [{ interval: { endTime }, value: { doubleValue: meanLatency } }],
+        },
+      ];
+    });
+    const lookups = changedLookups.map(({ name, outcome, count }) => ({
+      metric: { type: `${METRIC_PREFIX}/cache_lookups`, labels: { name, outcome } },
+      resource,
+      metricKind: 'CUMULATIVE',
+      valueType: 'INT64',
+      points: [{ interval: { startTime, endTime }, value: { int64Value: String(count) } }],
+    }));
+    const timeSeries = [...invocations, ...lookups];
+    for (const totals of changed) {
+      totals.pending = [];
+    }
+    for (const totals of changedLookups) {
+      totals.changed = false;
+    }
+    for (let i = 0; i < timeSeries.length; i += MAX_TIME_SERIES_PER_REQUEST) {
+      await api.post(`${MONITORING_URL}/projects/${project}/timeSeries`, {
+        timeSeries: timeSeries.slice(i, i + MAX_TIME_SERIES_PER_REQUEST),
+      });
+    }
+  };
+
+  return {
+    record,
+    flush,
+    /** Flushes metrics periodically without keeping the process alive. */
+    start: (intervalMs: number = DEFAULT_FLUSH_INTERVAL_MS) => {
+      if (!config.monitoringProject) {
+        return;
+      }
+      setInterval(() => {
+        flush().catch((e: unknown) =>
+          log.warn('Unable to write telemetry to Cloud Monitoring', { error: String(e) }),
+        );
+      }, intervalMs).unref();
+    },
+    /** Summarizes the events of the telemetry file, or of this session without one. */
+    report: (since?: Date): UsageReport => {
+      const recorded = config.file ? readUsageEvents(config.file) : [...events];
+      const selected = since
+        ? recorded.filter(({ timestamp }) => new Date(timestamp) >= since)
+        : recorded;
+      return usageReport(selected, config.file ?? 'session');
+    },
+  };
+};
+
+/** Returns the class of a failed tool result, e.g. the `error` code of a JSON error. */
+export const errorClassOf = (result: unknown): string => {
+  const text = (result as { content?: Array<{ text?: string }> }).content?.[0]?.text ?? '';
+  try {
+    const error = (JSON.parse(text) as { error?: unknown }).error;
+    if (typeof error === 'string' && /^[A-Z_]+$/.test(error)) {
+      return error;
+    }
+  } catch {
+    // Most tools return plain text errors.
+  }
+  return 'TOOL_ERROR';
+};
+
+type ToolCallback = (...args: unknown[]) => unknown;
+
+/** Records the latency and outcome of every tool registered on the server from now on. */
+export const instrumentTools = (server: McpServer, telemetry: Telemetry): McpServer => {
+  const registerTool = server.registerTool.bind(server) as unknown as ToolCallback;
+  const instrumented = (name: string, config: unknown, callback: ToolCallback) =>
+    registerTool(name, config, async (...args: unknown[]) => {
+      const start = Date.now();
+      try {
+        const result = await callback(...args);
+        const isError = (result as { isError?: boolean } | undefined)?.isError === true;
+        telemetry.record({
+          kind: 'tool',
+          name,
+          latencyMs: Date.now() - start,
+          ok: !isError,
+          ...(isError && { errorClass: errorClassOf(result) }),
+        });
+        return result;
+      } catch (e: unknown) {
+        telemetry.record({
+          kind: 'tool',
+          name,
+          latencyMs: Date.now() - start,
+          ok: false,
+          errorClass: 'EXCEPTION',
+        });
+        throw e;
+      }
+    });
+  server.registerTool = instrumented as unknown as typeof server.registerTool;
+  return server;
+};
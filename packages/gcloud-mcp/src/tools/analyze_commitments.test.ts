@@ -0,0 +1,72 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createAnalyzeCommitments } from './analyze_commitments.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (deny: string[] = []) => {
+  createAnalyzeCommitments(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createAnalyzeCommitments', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: '[]', stderr: '' }),
+    };
+  });
+
+  test('analyzes the session project', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke).mockResolvedValueOnce({
+      code: 0,
+      stdout: 'my-project\n',
+      stderr: '',
+    });
+
+    const result = await tool({ regions: ['us-central1'], days: 30 });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      project: 'my-project',
+      commitments: [],
+      recommendations: [],
+    });
+  });
+
+  test('returns an error if the recommender is denied', async () => {
+    const tool = createTool(['recommender']);
+
+    const result = await tool({ project: 'my-project', days: 30 });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud recommender recommendations list"');
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,100 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { GoogleApiClient } from '../google_api.js';
+import { BillingExportConfig } from '../billing_export.js';
+import { analyzeCommitments } from '../commitments.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const REQUIRED_COMMANDS = ['compute commitments list', 'recommender recommendations list'];
+
+export const createAnalyzeCommitments = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  billingExport?: { api: GoogleApiClient; config: BillingExportConfig },
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'analyze_commitments',
+      {
+        title: 'Analyze committed use discounts',
+        inputSchema: {
+          project: z
+            .string()
+            .optional()
+            .describe('The project to analyze. Defaults to the session project.'),
+          regions: z
+            .array(z.string())
+            .optional()
+            .describe(
+              'The regions to get recommendations for. Defaults to regions with commitments or usage.',
+            ),
+          days: z
+            .number()
+            .int()
+            .min(1)
+            .max(90)
+            .default(30)
+            .describe('The number of days of usage to analyze.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Analyzes Compute Engine committed use discounts (CUDs) for a project: the active commitments and when they expire, how much of each commitment is used and how much of the usage commitments cover, by region and machine family, and the additional commitments Recommender suggests with their monthly savings and break-even utilization.
+
+## Instructions:
+- Use this tool for FinOps reviews and questions about whether to buy more commitments.
+- Utilization and coverage are based on average vCPU and memory usage from the billing export, and are only reported when one is configured.
+- Commitments can not be canceled. Point out the break-even utilization and the commitment term before the user purchases one.`,
+      },
+      async ({ project, regions, days }) => {
+        const toolLogger = log.mcp('analyze_commitments', { project, regions, days });
+        const denied = REQUIRED_COMMANDS.filter((command) => !acl.check(command).permitted);
+        if (denied.length > 0) {
+          return errorTextResult(
+            `Analyzing commitments requires ${denied.map((c) => `"gcloud ${c}"`).join(' and ')}, which is not permitted.`,
+          );
+        }
+        try {
+          const target = project ?? (await sessionProject(gcloud));
+          if (!target) {
+            return errorTextResult(
+              'No project is set. Pass a project or set one with set_context.',
+            );
+          }
+          const analysis = await analyzeCommitments(
+            gcloud,
+            { project: target, days, ...(regions && { regions }) },
+            billingExport,
+          );
+          return successfulTextResult(JSON.stringify(analysis, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'analyze_commitments failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
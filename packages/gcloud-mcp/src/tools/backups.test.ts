@@ -0,0 +1,137 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createBackupTools } from './backups.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let run: GcloudCommandRunner;
+
+const createTool = (name: string, deny: string[] = []) => {
+  createBackupTools(mockedGcloud, createAccessControlList([], deny), run).register(mockServer);
+  return (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+};
+
+describe('createBackupTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: '[]', stderr: '' }),
+    };
+    run = vi.fn().mockResolvedValue({ content: [{ type: 'text', text: 'Created.' }] });
+  });
+
+  test('lists the backups of the session project', async () => {
+    const tool = createTool('list_backups');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValueOnce({
+      code: 0,
+      stdout: 'my-project\n',
+      stderr: '',
+    });
+
+    const result = await tool({ kinds: ['disk-snapshots'] });
+
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      backups: [],
+      schedules: [],
+      errors: [],
+      skipped: [],
+    });
+    expect(vi.mocked(mockedGcloud.invoke).mock.calls[1]![0]).toContain('--project=my-project');
+  });
+
+  test('skips kinds that are not permitted', async () => {
+    const tool = createTool('list_backups', ['sql']);
+
+    const result = await tool({ projects: ['p'], kinds: ['disk-snapshots', 'sql-backups'] });
+
+    expect(JSON.parse(result.content[0].text).skipped).toEqual(['sql-backups']);
+    const denied = await tool({ projects: ['p'], kinds: ['sql-backups'] });
+    expect(denied.isError).toBe(true);
+  });
+
+  test('creates backups with the command runner', async () => {
+    const tool = createTool('create_backup');
+
+    await tool({
+      kind: 'sql-instance',
+      name: 'db',
+      project: 'p',
+      reason: 'Before the migration',
+      confirm: true,
+    });
+
+    expect(run).toHaveBeenCalledWith(
+      [
+        'sql',
+        'backups',
+        'create',
+        '--instance=db',
+        '--description=Before the migration',
+        '--project=p',
+      ],
+      undefined,
+      true,
+    );
+  });
+
+  test('returns an error for incomplete backup targets', async () => {
+    const tool = createTool('create_backup');
+
+    const result = await tool({ kind: 'disk', name: 'web', project: 'p', reason: 'x' });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('verifies the recency of backups', async () => {
+    const tool = createTool('verify_backup_recency');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 0,
+      stdout: JSON.stringify([
+        { id: '1', endTime: new Date().toISOString(), status: 'SUCCESSFUL' },
+      ]),
+      stderr: '',
+    });
+
+    const result = await tool({ kind: 'sql-instance', name: 'db', project: 'p', maxAgeHours: 24 });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      latestBackup: { name: '1' },
+      recent: true,
+    });
+  });
+
+  test('returns an error if listing backups is denied', async () => {
+    const tool = createTool('verify_backup_recency', ['compute snapshots']);
+
+    const result = await tool({ kind: 'disk', name: 'web', project: 'p', maxAgeHours: 24 });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud compute snapshots list"');
+  });
+});
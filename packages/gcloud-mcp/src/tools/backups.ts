@@ -0,0 +1,227 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import {
+  BACKUP_KINDS,
+  BACKUP_KIND_COMMANDS,
+  BACKUP_TARGETS,
+  BACKUP_TARGET_COMMANDS,
+  createBackupArgs,
+  listBackups,
+  validateBackupTarget,
+  verifyBackupRecency,
+} from '../backups.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { ADDITIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const targetSchema = {
+  kind: z.enum(BACKUP_TARGETS).describe('The kind of resource to back up.'),
+  name: z.string().describe('The name of the disk, Cloud SQL instance or Filestore instance.'),
+  project: z
+    .string()
+    .optional()
+    .describe('The project of the resource. Defaults to the session project.'),
+  location: z
+    .string()
+    .optional()
+    .describe(
+      'The zone or region of a disk or Filestore instance. Cloud SQL instances do not need one.',
+    ),
+};
+
+export const createBackupTools = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_backups',
+      {
+        title: 'List backups',
+        inputSchema: {
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The projects to list backups of. Defaults to the session project.'),
+          kinds: z
+            .array(z.enum(BACKUP_KINDS))
+            .optional()
+            .describe('The kinds of backups to list. Defaults to all of them.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Lists disk snapshots, snapshot schedules, Cloud SQL backups and Filestore backups with the resource they were taken of, their status, age and size. Backups are listed newest first.
+
+## Instructions:
+- Use this tool to answer questions about what is backed up and how recently.
+- Cloud SQL does not report the size of backups, so their size is null.
+- Kinds that fail, e.g. because an API is not enabled in a project, are listed under errors. Kinds that are not permitted are listed under skipped.`,
+      },
+      async ({ projects, kinds = [...BACKUP_KINDS] }) => {
+        const toolLogger = log.mcp('list_backups', { projects, kinds });
+        const permitted = kinds.filter((kind) =>
+          BACKUP_KIND_COMMANDS[kind].every((command) => acl.check(command).permitted),
+        );
+        const skipped = kinds.filter((kind) => !permitted.includes(kind));
+        if (permitted.length === 0) {
+          return errorTextResult(
+            `None of the requested kinds are permitted. They require ${skipped
+              .flatMap((kind) => BACKUP_KIND_COMMANDS[kind])
+              .map((c) => `"gcloud ${c}"`)
+              .join(', ')}.`,
+          );
+        }
+        try {
+          let targets = projects ?? [];
+          if (targets.length === 0) {
+            const project = await sessionProject(gcloud);
+            if (!project) {
+              return errorTextResult(
+                'No project is set. Pass projects or set one with set_context.',
+              );
+            }
+            targets = [project];
+          }
+          const inventory = await listBackups(gcloud, targets, permitted);
+          return successfulTextResult(JSON.stringify({ ...inventory, skipped }, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('list_backups failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'create_backup',
+      {
+        title: 'Create backup',
+        inputSchema: {
+          ...targetSchema,
+          fileShare: z
+            .string()
+            .optional()
+            .describe('The file share of a Filestore instance to back up, e.g. "vol1".'),
+          reason: z
+            .string()
+            .describe(
+              'Why the backup is taken, e.g. "Before resizing the database". It is recorded as the description of the backup.',
+            ),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true once the user has explicitly approved the backup.'),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Creates an on-demand disk snapshot, Cloud SQL backup or Filestore backup, e.g. before a risky change. The backup is created with run_gcloud_command, so it is recorded in the command history like every other change.
+
+## Instructions:
+- Take a backup of the affected resources before changes that can lose data, such as resizing, migrating or deleting them, and tell the user which backups you took.
+- Confirm the backup with the user before creating it.
+- Use verify_backup_recency afterwards, or instead if a recent backup may already exist.`,
+      },
+      async ({ kind, name, project: projectInput, location, fileShare, reason, confirm }) => {
+        const toolLogger = log.mcp('create_backup', {
+          kind,
+          name,
+          project: projectInput,
+          location,
+          fileShare,
+          reason,
+        });
+        const project = projectInput ?? (await sessionProject(gcloud));
+        if (!project) {
+          return errorTextResult('No project is set. Pass a project or set one with set_context.');
+        }
+        const target = {
+          kind,
+          name,
+          project,
+          ...(location && { location }),
+          ...(fileShare && { fileShare }),
+        };
+        const targetError = validateBackupTarget(target);
+        if (targetError) {
+          return errorTextResult(targetError);
+        }
+        const args = createBackupArgs(target, reason);
+        toolLogger.info('Creating backup', { args });
+        return run(args, undefined, confirm);
+      },
+    );
+
+    server.registerTool(
+      'verify_backup_recency',
+      {
+        title: 'Verify backup recency',
+        inputSchema: {
+          ...targetSchema,
+          maxAgeHours: z
+            .number()
+            .positive()
+            .default(24)
+            .describe('The maximum age of the latest successful backup.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Finds the latest successful backup of a disk, Cloud SQL instance or Filestore instance and checks that it is at most maxAgeHours old.
+
+## Instructions:
+- Use this tool before changes that can lose data to decide whether a new backup is needed.
+- If "recent" is false, offer to take a backup with create_backup.`,
+      },
+      async ({ kind, name, project: projectInput, location, maxAgeHours }) => {
+        const toolLogger = log.mcp('verify_backup_recency', {
+          kind,
+          name,
+          project: projectInput,
+          location,
+          maxAgeHours,
+        });
+        const command = BACKUP_TARGET_COMMANDS[kind];
+        if (!acl.check(command).permitted) {
+          return errorTextResult(
+            `Verifying backups of a ${kind} requires "gcloud ${command}", which is not permitted.`,
+          );
+        }
+        try {
+          const project = projectInput ?? (await sessionProject(gcloud));
+          if (!project) {
+            return errorTextResult(
+              'No project is set. Pass a project or set one with set_context.',
+            );
+          }
+          const target = { kind, name, project, ...(location && { location }) };
+          const recency = await verifyBackupRecency(gcloud, target, maxAgeHours);
+          return successfulTextResult(JSON.stringify(recency, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'verify_backup_recency failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,144 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { CommandHistory, createCommandHistory } from '../command_history.js';
+import {
+  DEFAULT_SERVICE_ACCOUNT_ROLES,
+  bootstrapSteps,
+  createBootstrapProject,
+} from './bootstrap_project.js';
+import { successfulTextResult } from './tool_result.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+  registerPrompt: vi.fn(),
+} as unknown as McpServer;
+
+let history: CommandHistory;
+const run = vi.fn();
+
+const createTool = () => {
+  createBootstrapProject(run, history).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+// Simulates the runner, which records each command with its exit code.
+const mockRun = (exitCodes: Record<string, { code: number; stderr?: string }> = {}) => {
+  run.mockImplementation(async (args: string[]) => {
+    const outcome = exitCodes[args.slice(0, 3).join(' ')] ?? { code: 0 };
+    history.record(args, outcome.code);
+    return successfulTextResult(outcome.stderr ? `\nSTDERR:\n${outcome.stderr}` : 'ok');
+  });
+};
+
+describe('bootstrapSteps', () => {
+  test('only includes the sink and budget when configured', () => {
+    const names = bootstrapSteps({ project: 'p' }).map((step) => step.name);
+    expect(names).toEqual([
+      'enable-apis',
+      'create-service-account',
+      ...DEFAULT_SERVICE_ACCOUNT_ROLES.map((role) => `grant-${role.replace('roles/', '')}`),
+    ]);
+  });
+
+  test('creates a budget alert on the billing account', () => {
+    const budget = bootstrapSteps({ project: 'p', billingAccount: '0-1-2' }).at(-1);
+    expect(budget?.args).toEqual(
+      expect.arrayContaining([
+        'budgets',
+        '--billing-account=0-1-2',
+        '--filter-projects=projects/p',
+        '--threshold-rule=percent=0.9',
+      ]),
+    );
+  });
+});
+
+describe('createBootstrapProject', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+  });
+
+  test('registers a prompt for the workflow', () => {
+    createTool();
+    expect(mockServer.registerPrompt).toHaveBeenCalledWith(
+      'bootstrap_project',
+      expect.any(Object),
+      expect.any(Function),
+    );
+  });
+
+  test('returns the plan without running it', async () => {
+    const tool = createTool();
+
+    const result = await tool({ project: 'p', sinkDestination: 'storage.googleapis.com/b' });
+
+    expect(run).not.toHaveBeenCalled();
+    const { plan } = JSON.parse(result.content[0].text);
+    expect(plan.map((step: { name: string }) => step.name)).toContain('create-logging-sink');
+  });
+
+  test('runs every step after confirmation', async () => {
+    const tool = createTool();
+    mockRun();
+
+    const result = await tool({ project: 'p', confirm: true });
+
+    const steps = JSON.parse(result.content[0].text);
+    expect(steps.every((step: { status: string }) => step.status === 'succeeded')).toBe(true);
+    expect(run).toHaveBeenCalledWith(
+      expect.arrayContaining([
+        '--member=serviceAccount:default-workload@p.iam.gserviceaccount.com',
+      ]),
+      undefined,
+      true,
+    );
+  });
+
+  test('treats existing resources as succeeded', async () => {
+    const tool = createTool();
+    mockRun({
+      'iam service-accounts create': { code: 1, stderr: 'ERROR: Service account already exists' },
+    });
+
+    const result = await tool({ project: 'p', confirm: true });
+
+    const steps = JSON.parse(result.content[0].text);
+    expect(steps[1]).toMatchObject({ name: 'create-service-account', status: 'succeeded' });
+    expect(steps[2].status).toBe('succeeded');
+  });
+
+  test('skips steps whose dependency failed', async () => {
+    const tool = createTool();
+    mockRun({ 'iam service-accounts create': { code: 1, stderr: 'PERMISSION_DENIED' } });
+
+    const result = await tool({ project: 'p', billingAccount: '0-1-2', confirm: true });
+
+    const steps = JSON.parse(result.content[0].text);
+    expect(steps.map((step: { status: string }) => step.status)).toEqual([
+      'succeeded',
+      'failed',
+      'skipped',
+      'skipped',
+      'skipped',
+      'succeeded',
+    ]);
+  });
+});
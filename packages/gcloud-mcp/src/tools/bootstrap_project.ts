@@ -0,0 +1,273 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { CommandHistory } from '../command_history.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner, runRecorded } from './run_gcloud_command.js';
+import { ADDITIVE_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const STANDARD_APIS = [
+  'cloudresourcemanager.googleapis.com',
+  'iam.googleapis.com',
+  'logging.googleapis.com',
+  'monitoring.googleapis.com',
+  'serviceusage.googleapis.com',
+  'cloudbilling.googleapis.com',
+  'billingbudgets.googleapis.com',
+];
+
+// Roles a workload needs to report telemetry, and nothing more.
+export const DEFAULT_SERVICE_ACCOUNT_ROLES = [
+  'roles/logging.logWriter',
+  'roles/monitoring.metricWriter',
+  'roles/cloudtrace.agent',
+];
+
+export const DEFAULT_SERVICE_ACCOUNT_NAME = 'default-workload';
+export const DEFAULT_SINK_FILTER = 'severity>=WARNING';
+const BUDGET_THRESHOLDS = [0.5, 0.9, 1.0];
+
+export interface BootstrapOptions {
+  project: string;
+  serviceAccountName?: string | undefined;
+  apis?: string[] | undefined;
+  roles?: string[] | undefined;
+  /** A logging sink destination, e.g. `storage.googleapis.com/my-bucket`. */
+  sinkDestination?: string | undefined;
+  billingAccount?: string | undefined;
+  /** The budget amount, e.g. `100USD`. */
+  budgetAmount?: string | undefined;
+}
+
+interface BootstrapStep {
+  name: string;
+  args: string[];
+  /** The name of a step that must succeed first. */
+  dependsOn?: string;
+}
+
+export interface BootstrapStepResult {
+  name: string;
+  command: string;
+  status: 'succeeded' | 'failed' | 'skipped';
+  output: string;
+}
+
+/** Returns the gcloud commands that bootstrap a project, in order. */
+export const bootstrapSteps = (options: BootstrapOptions): BootstrapStep[] => {
+  const { project } = options;
+  const accountName = options.serviceAccountName ?? DEFAULT_SERVICE_ACCOUNT_NAME;
+  const member = `serviceAccount:${accountName}@${project}.iam.gserviceaccount.com`;
+  const steps: BootstrapStep[] = [
+    {
+      name: 'enable-apis',
+      args: ['services', 'enable', ...(options.apis ?? STANDARD_APIS), `--project=${project}`],
+    },
+    {
+      name: 'create-service-account',
+      args: [
+        'iam',
+        'service-accounts',
+        'create',
+        accountName,
+        `--project=${project}`,
+        '--display-name=Default workload service account',
+      ],
+      dependsOn: 'enable-apis',
+    },
+    ...(options.roles ?? DEFAULT_SERVICE_ACCOUNT_ROLES).map((role) => ({
+      name: `grant-${role.replace('roles/', '')}`,
+      args: [
+        'projects',
+        'add-iam-policy-binding',
+        project,
+        `--member=${member}`,
+        `--role=${role}`,
+        '--condition=None',
+      ],
+      dependsOn: 'create-service-account',
+    })),
+  ];
+  if (options.sinkDestination) {
+    steps.push({
+      name: 'create-logging-sink',
+      args: [
+        'logging',
+        'sinks',
+        'create',
+        'default-warnings',
+        options.sinkDestination,
+        `--project=${project}`,
+        `--log-filter=${DEFAULT_SINK_FILTER}`,
+      ],
+      dependsOn: 'enable-apis',
+    });
+  }
+  if (options.billingAccount) {
+    steps.push({
+      name: 'create-budget-alert',
+      args: [
+        'billing',
+        'budgets',
+        'create',
+        `--billing-account=${options.billingAccount}`,
+        `--display-name=${project} budget`,
+        `--budget-amount=${options.budgetAmount ?? '100USD'}`,
+        `--filter-projects=projects/${project}`,
+        ...BUDGET_THRESHOLDS.map((threshold) => `--threshold-rule=percent=${threshold}`),
+      ],
+      dependsOn: 'enable-apis',
+    });
+  }
+  return steps;
+};
+
+/**
+ * Runs the bootstrap steps through the gcloud command runner, so they are
+ * subject to the same restrictions as run_gcloud_command.
+ *
+ * Steps whose dependency failed are skipped. Resources that already exist
+ * count as succeeded, so the workflow can be re-run after a partial failure.
+ */
+export const runBootstrap = async (
+  steps: BootstrapStep[],
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+): Promise<BootstrapStepResult[]> => {
+  const results: BootstrapStepResult[] = [];
+  const succeeded = new Set<string>();
+  for (const step of steps) {
+    const command = `gcloud ${step.args.join(' ')}`;
+    if (step.dependsOn && !succeeded.has(step.dependsOn)) {
+      results.push({
+        name: step.name,
+        command,
+        status: 'skipped',
+        output: `Skipped because "${step.dependsOn}" did not succeed.`,
+      });
+      continue;
+    }
+    const { exitCode, output } = await runRecorded(run, history, step.args);
+    const ok = exitCode === 0 || (exitCode !== null && /already exists/i.test(output));
+    if (ok) {
+      succeeded.add(step.name);
+    }
+    results.push({ name: step.name, command, status: ok ? 'succeeded' : 'failed', output });
+  }
+  return results;
+};
+
+const bootstrapInputSchema = {
+  project: z.string().describe('The ID of the project to bootstrap.'),
+  serviceAccountName: z
+    .string()
+    .optional()
+    .describe(`The service account to create. Defaults to "${DEFAULT_SERVICE_ACCOUNT_NAME}".`),
+  sinkDestination: z
+    .string()
+    .optional()
+    .describe(
+      'Optional. Destination for a sink of warnings and errors, e.g. "storage.googleapis.com/my-bucket".',
+    ),
+  billingAccount: z
+    .string()
+    .optional()
+    .describe('Optional. The billing account ID to create a budget alert on.'),
+  budgetAmount: z.string().optional().describe('The budget amount, e.g. "100USD".'),
+  confirm: z
+    .boolean()
+    .optional()
+    .describe('Set to true to run the steps after the user approved the plan.'),
+};
+
+export const createBootstrapProject = (run: GcloudCommandRunner, history: CommandHistory) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'bootstrap_project',
+      {
+        title: 'Bootstrap project',
+        inputSchema: bootstrapInputSchema,
+        annotations: ADDITIVE_TOOL,
+        description: `Sets up a new project with a standard baseline: enables a standard set of APIs, creates a default service account with least-privilege telemetry roles, and optionally creates a logging sink for warnings and a budget alert.
+
+## Instructions:
+- Call this tool without "confirm" first to get the plan, and show it to the user.
+- Call it again with the same arguments and "confirm": true only after the user approves.
+- The result reports the status and output of each step.`,
+      },
+      async ({ confirm, ...input }) => {
+        const toolLogger = log.mcp('bootstrap_project', input);
+        const steps = bootstrapSteps(input);
+        if (!confirm) {
+          return successfulTextResult(
+            JSON.stringify(
+              {
+                plan: steps.map((step) => ({
+                  name: step.name,
+                  command: `gcloud ${step.args.join(' ')}`,
+                })),
+                next: 'After the user approves, call bootstrap_project again with "confirm": true.',
+              },
+              null,
+              2,
+            ),
+          );
+        }
+        try {
+          const results = await runBootstrap(steps, run, history);
+          toolLogger.info('bootstrap_project finished', {
+            failed: results.filter((r) => r.status !== 'succeeded').length,
+          });
+          return successfulTextResult(JSON.stringify(results, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('bootstrap_project failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerPrompt(
+      'bootstrap_project',
+      {
+        title: 'Bootstrap a new project',
+        description: 'Guides the setup of a new project with a standard baseline.',
+        argsSchema: {
+          project: z.string().describe('The ID of the project to bootstrap.'),
+        },
+      },
+      ({ project }) => ({
+        messages: [
+          {
+            role: 'user',
+            content: {
+              type: 'text',
+              text: `Bootstrap the Google Cloud project "${project}".
+
+1. Call gcloud_context to confirm the active account.
+2. Ask me whether to create a logging sink (and its destination) and a budget alert (and the billing account and amount).
+3. Call bootstrap_project without "confirm" and show me the plan.
+4. After I approve, call bootstrap_project with "confirm": true and summarize the result of each step, including how to fix any step that failed.`,
+            },
+          },
+        ],
+      }),
+    );
+  },
+});
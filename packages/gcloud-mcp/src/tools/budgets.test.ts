@@ -0,0 +1,115 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createBudgetTools } from './budgets.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+const run = vi.fn();
+
+const getTool = (name: string) =>
+  (mockServer.registerTool as Mock).mock.calls.find((call) => call[0] === name)![2];
+
+describe('createBudgetTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(),
+    };
+    run.mockResolvedValue({ content: [{ type: 'text', text: 'Created budget' }] });
+  });
+
+  const register = (deny: string[] = []) =>
+    createBudgetTools(mockedGcloud, createAccessControlList([], deny), run).register(mockServer);
+
+  test('lists budgets of the session project billing account', async () => {
+    register();
+    vi.mocked(mockedGcloud.invoke)
+      .mockResolvedValueOnce({ code: 0, stdout: 'my-project\n', stderr: '' })
+      .mockResolvedValueOnce({
+        code: 0,
+        stdout: 'billingAccounts/012345-6789AB-CDEF01\n',
+        stderr: '',
+      })
+      .mockResolvedValueOnce({
+        code: 0,
+        stdout: JSON.stringify([{ name: 'billingAccounts/012345-6789AB-CDEF01/budgets/b1' }]),
+        stderr: '',
+      });
+
+    const result = await getTool('list_budgets')({});
+
+    expect(mockedGcloud.invoke).toHaveBeenLastCalledWith([
+      'billing',
+      'budgets',
+      'list',
+      '--billing-account=012345-6789AB-CDEF01',
+      '--format=json',
+    ]);
+    expect(JSON.parse(result.content[0].text)).toEqual([
+      expect.objectContaining({ name: 'billingAccounts/012345-6789AB-CDEF01/budgets/b1' }),
+    ]);
+  });
+
+  test('does not list budgets if the command is denied', async () => {
+    register(['billing']);
+
+    const result = await getTool('list_budgets')({ billingAccount: '012345-6789AB-CDEF01' });
+
+    expect(result.isError).toBe(true);
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('creates budgets through the command runner', async () => {
+    register();
+
+    const result = await getTool('create_budget')({
+      billingAccount: '012345-6789AB-CDEF01',
+      displayName: 'Team budget',
+      amount: 100,
+      currency: 'USD',
+      thresholds: [{ percent: 0.9, basis: 'current' }],
+      calendarPeriod: 'month',
+      confirm: true,
+    });
+
+    expect(run).toHaveBeenCalledWith(
+      [
+        'billing',
+        'budgets',
+        'create',
+        '--billing-account=012345-6789AB-CDEF01',
+        '--display-name=Team budget',
+        '--budget-amount=100USD',
+        '--calendar-period=month',
+        '--threshold-rule=percent=0.9',
+      ],
+      undefined,
+      true,
+    );
+    expect(result.content[0].text).toBe('Created budget');
+  });
+});
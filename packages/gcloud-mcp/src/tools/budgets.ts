@@ -0,0 +1,140 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { GoogleApiClient } from '../google_api.js';
+import { BillingExportConfig } from '../billing_export.js';
+import { createBudgetArgs, defaultBillingAccount, listBudgetStatuses } from '../budgets.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { ADDITIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const billingAccountSchema = z
+  .string()
+  .optional()
+  .describe(
+    'The billing account ID, e.g. "012345-6789AB-CDEF01". Defaults to the account of the session project.',
+  );
+
+export const createBudgetTools = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+  billingExport?: { api: GoogleApiClient; config: BillingExportConfig },
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_budgets',
+      {
+        title: 'List budgets',
+        inputSchema: { billingAccount: billingAccountSchema },
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the budgets of a billing account with their amount, month-to-date spend, the spend forecast for the end of the month, and how close spend is to each alert threshold.
+
+## Instructions:
+- Use this tool to answer questions about whether spend is on track.
+- Spend and forecasts are only available when the server is configured with a billing export. The forecast extrapolates the month-to-date spend linearly.`,
+      },
+      async ({ billingAccount }) => {
+        const toolLogger = log.mcp('list_budgets', { billingAccount });
+        if (!acl.check('billing budgets list').permitted) {
+          return errorTextResult(
+            'Listing budgets requires "gcloud billing budgets list", which is not permitted.',
+          );
+        }
+        try {
+          const account = billingAccount ?? (await defaultBillingAccount(gcloud));
+          if (!account) {
+            return errorTextResult(
+              'No billing account found for the session project. Pass a billingAccount.',
+            );
+          }
+          const budgets = await listBudgetStatuses(gcloud, account, billingExport);
+          return successfulTextResult(JSON.stringify(budgets, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('list_budgets failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'create_budget',
+      {
+        title: 'Create budget',
+        inputSchema: {
+          billingAccount: billingAccountSchema,
+          displayName: z.string().describe('The name of the budget.'),
+          amount: z.number().positive().describe('The budget amount.'),
+          currency: z
+            .string()
+            .length(3)
+            .default('USD')
+            .describe('The currency of the billing account.'),
+          thresholds: z
+            .array(
+              z.object({
+                percent: z.number().positive().describe('The threshold, e.g. 0.9 for 90%.'),
+                basis: z.enum(['current', 'forecasted']).default('current'),
+              }),
+            )
+            .default([
+              { percent: 0.5, basis: 'current' },
+              { percent: 0.9, basis: 'current' },
+              { percent: 1, basis: 'forecasted' },
+            ])
+            .describe('The spend thresholds that send alerts.'),
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The project IDs to track. Defaults to every project on the account.'),
+          services: z
+            .array(z.string())
+            .optional()
+            .describe('Billing service IDs to track, e.g. "6F81-5844-456A" for Compute Engine.'),
+          calendarPeriod: z.enum(['month', 'quarter', 'year']).default('month'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true once the user has explicitly approved this budget.'),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Creates a budget with alert thresholds from a structured spec.
+
+## Instructions:
+- Confirm the amount, scope, and thresholds with the user before creating the budget.
+- The budget is created with run_gcloud_command, so the same restrictions apply.`,
+      },
+      async ({ billingAccount, confirm, ...spec }) => {
+        const toolLogger = log.mcp('create_budget', { billingAccount, ...spec });
+        const account = billingAccount ?? (await defaultBillingAccount(gcloud));
+        if (!account) {
+          return errorTextResult(
+            'No billing account found for the session project. Pass a billingAccount.',
+          );
+        }
+        const args = createBudgetArgs({ billingAccount: account, ...spec });
+        toolLogger.info('Creating budget', { args });
+        return run(args, undefined, confirm);
+      },
+    );
+  },
+});
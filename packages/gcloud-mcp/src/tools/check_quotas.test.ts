@@ -0,0 +1,192 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createCheckQuotas, quotaIncreaseCommand } from './check_quotas.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (deny: string[] = []) => {
+  createCheckQuotas(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const mockInvokeJson = (value: unknown) => {
+  vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+    code: 0,
+    stdout: JSON.stringify(value),
+    stderr: '',
+  });
+};
+
+describe('quotaIncreaseCommand', () => {
+  test('requests double the current regional limit', () => {
+    expect(
+      quotaIncreaseCommand('my-project', 'us-central1', {
+        metric: 'CPUS',
+        usage: 22,
+        limit: 24,
+        utilization: 22 / 24,
+      }),
+    ).toBe(
+      'gcloud beta quotas preferences create --service=compute.googleapis.com --quota-id=CPUS-per-project-region --dimensions=region=us-central1 --preferred-value=48 --project=my-project',
+    );
+  });
+});
+
+describe('createCheckQuotas', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(),
+    };
+  });
+
+  test('flags regional compute quotas above the threshold', async () => {
+    const tool = createTool();
+    mockInvokeJson({
+      quotas: [
+        { metric: 'IN_USE_ADDRESSES', limit: 8, usage: 1 },
+        { metric: 'CPUS', limit: 24, usage: 22 },
+      ],
+    });
+
+    const result = await tool({
+      service: 'compute',
+      region: 'us-central1',
+      project: 'my-project',
+      threshold: 0.8,
+    });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'compute',
+      'regions',
+      'describe',
+      'us-central1',
+      '--project=my-project',
+      '--format=json(quotas)',
+    ]);
+    const report = JSON.parse(result.content[0].text);
+    expect(report.quotas.map((q: { metric: string }) => q.metric)).toEqual([
+      'CPUS',
+      'IN_USE_ADDRESSES',
+    ]);
+    expect(report.flagged).toHaveLength(1);
+    expect(report.flagged[0]).toMatchObject({ metric: 'CPUS', usage: 22, limit: 24 });
+    expect(report.flagged[0].increaseCommand).toContain('--quota-id=CPUS-per-project-region');
+  });
+
+  test('uses the session project and project-wide quotas by default', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke)
+      .mockResolvedValueOnce({ code: 0, stdout: 'session-project\n', stderr: '' })
+      .mockResolvedValueOnce({ code: 0, stdout: '{"quotas": []}', stderr: '' });
+
+    const result = await tool({ service: 'compute', threshold: 0.8 });
+
+    expect(mockedGcloud.invoke).toHaveBeenLastCalledWith([
+      'compute',
+      'project-info',
+      'describe',
+      '--project=session-project',
+      '--format=json(quotas)',
+    ]);
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      project: 'session-project',
+      region: null,
+      flagged: [],
+    });
+  });
+
+  test('returns an error if the session project can not be read', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke).mockResolvedValueOnce({
+      code: 1,
+      stdout: '',
+      stderr: 'ERROR: (gcloud.config.get-value) Invalid configuration',
+    });
+
+    const result = await tool({ service: 'compute', threshold: 0.8 });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledTimes(1);
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('Failed to read the session project');
+  });
+
+  test('reports the limits of other services', async () => {
+    const tool = createTool();
+    mockInvokeJson([
+      {
+        metric: 'pubsub.googleapis.com/regionalpublisher',
+        consumerQuotaLimits: [
+          { unit: '1/min/{project}/{region}', quotaBuckets: [{ effectiveLimit: '240000' }] },
+        ],
+      },
+    ]);
+
+    const result = await tool({ service: 'pubsub', project: 'my-project', threshold: 0.8 });
+
+    const report = JSON.parse(result.content[0].text);
+    expect(report.quotas).toEqual([
+      {
+        metric: 'pubsub.googleapis.com/regionalpublisher (1/min/{project}/{region})',
+        usage: null,
+        limit: 240000,
+        utilization: null,
+      },
+    ]);
+    expect(report.note).toContain('Usage is only reported for compute quotas');
+  });
+
+  test('returns an error if the quota command is denied', async () => {
+    const tool = createTool(['compute regions']);
+
+    const result = await tool({ service: 'compute', region: 'us-central1', threshold: 0.8 });
+
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+    expect(result.isError).toBe(true);
+  });
+
+  test('returns an error if gcloud fails', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 1,
+      stdout: '',
+      stderr: 'ERROR: (gcloud.compute.regions.describe) Could not fetch resource',
+    });
+
+    const result = await tool({
+      service: 'compute',
+      region: 'us-central1',
+      project: 'my-project',
+      threshold: 0.8,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('Could not fetch resource');
+  });
+});
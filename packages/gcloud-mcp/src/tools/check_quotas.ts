@@ -0,0 +1,247 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { invokeJson } from '../gcloud_json.js';
+import { AccessControlList } from '../denylist.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const DEFAULT_UTILIZATION_THRESHOLD = 0.8;
+
+export interface QuotaUsage {
+  metric: string;
+  usage: number | null;
+  limit: number;
+  /** usage / limit, or null if usage is not reported. */
+  utilization: number | null;
+  /** The command that requests a higher limit, for quotas above the threshold. */
+  increaseCommand?: string;
+}
+
+export interface QuotaReport {
+  service: string;
+  project: string;
+  region: string | null;
+  threshold: number;
+  /** Quotas above the threshold, most utilized first. */
+  flagged: QuotaUsage[];
+  quotas: QuotaUsage[];
+  note?: string;
+}
+
+const ComputeQuotasSchema = z.object({
+  quotas: z
+    .array(z.object({ metric: z.string(), limit: z.number(), usage: z.number() }))
+    .default([]),
+});
+
+// There are more fields in each quota metric, but only these are used.
+const ServiceQuotasSchema = z.array(
+  z.object({
+    metric: z.string(),
+    consumerQuotaLimits: z
+      .array(
+        z.object({
+          unit: z.string().nullish(),
+          quotaBuckets: z.array(z.object({ effectiveLimit: z.string().nullish() })).default([]),
+        }),
+      )
+      .default([]),
+  }),
+);
+
+/**
+ * Returns the command requesting a higher compute quota through the Cloud Quotas API.
+ *
+ * Compute Engine quota IDs follow the `METRIC-per-project-region` and
+ * `METRIC-per-project` conventions for regional and project quotas.
+ */
+export const quotaIncreaseCommand = (
+  project: string,
+  region: string | null,
+  quota: QuotaUsage,
+): string =>
+  [
+    'gcloud beta quotas preferences create',
+    '--service=compute.googleapis.com',
+    `--quota-id=${quota.metric}-per-project${region ? '-region' : ''}`,
+    ...(region ? [`--dimensions=region=${region}`] : []),
+    `--preferred-value=${Math.max(quota.limit * 2, 1)}`,
+    `--project=${project}`,
+  ].join(' ');
+
+const byUtilization = (a: QuotaUsage, b: QuotaUsage) => (b.utilization ?? 0) - (a.utilization ?? 0);
+
+const computeQuotas = async (
+  gcloud: GcloudExecutable,
+  project: string,
+  region: string | null,
+  threshold: number,
+): Promise<QuotaReport> => {
+  const args = region
+    ? ['compute', 'regions', 'describe', region]
+    : ['compute', 'project-info', 'describe'];
+  const { quotas } = ComputeQuotasSchema.parse(
+    await invokeJson(gcloud, [...args, `--project=${project}`, '--format=json(quotas)']),
+  );
+  const usages = quotas
+    .map(({ metric, limit, usage }) => ({
+      metric,
+      usage,
+      limit,
+      utilization: limit > 0 ? usage / limit : null,
+    }))
+    .sort(byUtilization);
+  return {
+    service: 'compute',
+    project,
+    region,
+    threshold,
+    flagged: usages
+      .filter((quota) => quota.utilization !== null && quota.utilization >= threshold)
+      .map((quota) => ({
+        ...quota,
+        increaseCommand: quotaIncreaseCommand(project, region, quota),
+      })),
+    quotas: usages,
+  };
+};
+
+const serviceQuotas = async (
+  gcloud: GcloudExecutable,
+  service: string,
+  project: string,
+  threshold: number,
+): Promise<QuotaReport> => {
+  const metrics = ServiceQuotasSchema.parse(
+    await invokeJson(gcloud, [
+      'alpha',
+      'services',
+      'quota',
+      'list',
+      `--service=${service}.googleapis.com`,
+      `--consumer=projects/${project}`,
+      '--format=json',
+    ]),
+  );
+  const quotas = metrics.flatMap(({ metric, consumerQuotaLimits }) =>
+    consumerQuotaLimits.flatMap(({ unit, quotaBuckets }) =>
+      quotaBuckets
+        .filter((bucket) => bucket.effectiveLimit !== undefined && bucket.effectiveLimit !== null)
+        .map((bucket) => ({
+          metric: unit ? `${metric} (${unit})` : metric,
+          usage: null,
+          limit: Number(bucket.effectiveLimit),
+          utilization: null,
+        })),
+    ),
+  );
+  return {
+    service,
+    project,
+    region: null,
+    threshold,
+    flagged: [],
+    quotas,
+    note: 'Usage is only reported for compute quotas. Check rate quota usage in the Cloud Monitoring quota metrics.',
+  };
+};
+
+/** Reports quota usage against limits, flagging quotas at or above the threshold. */
+export const checkQuotas = async (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  options: { service: string; project?: string; region?: string; threshold: number },
+): Promise<QuotaReport | { error: string }> => {
+  const { service, threshold } = options;
+  const command =
+    service === 'compute'
+      ? `compute ${options.region ? 'regions' : 'project-info'} describe`
+      : 'alpha services quota list';
+  if (!acl.check(command).permitted) {
+    return { error: `Checking quotas requires "gcloud ${command}", which is not permitted.` };
+  }
+  const project = options.project ?? (await sessionProject(gcloud));
+  if (!project) {
+    return { error: 'No project is set. Pass a project or set one with set_context.' };
+  }
+  return service === 'compute'
+    ? computeQuotas(gcloud, project, options.region ?? null, threshold)
+    : serviceQuotas(gcloud, service, project, threshold);
+};
+
+export const createCheckQuotas = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'check_quotas',
+      {
+        title: 'Check quotas',
+        inputSchema: {
+          service: z
+            .string()
+            .default('compute')
+            .describe('The service whose quotas to check, e.g. "compute" or "pubsub".'),
+          region: z
+            .string()
+            .optional()
+            .describe('For compute, the region to check. Omit for project-wide quotas.'),
+          project: z
+            .string()
+            .optional()
+            .describe('The project to check. Defaults to the session project.'),
+          threshold: z
+            .number()
+            .min(0)
+            .max(1)
+            .default(DEFAULT_UTILIZATION_THRESHOLD)
+            .describe('The utilization, between 0 and 1, at which a quota is flagged.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Reports quota usage against limits for a service, such as CPUs, IP addresses, and disks in a compute region, or API rate limits for other services.
+
+Quotas at or above the utilization threshold are flagged, each with the gcloud command that requests a higher limit.
+
+## Instructions:
+- Use this tool before creating many resources or scaling up, so quota exhaustion does not interrupt a rollout.
+- Show flagged quotas and their increase commands to the user. Quota increase requests are reviewed by Google Cloud and may take time to be approved.`,
+      },
+      async ({ service, region, project, threshold }) => {
+        const toolLogger = log.mcp('check_quotas', { service, region, project, threshold });
+        try {
+          const report = await checkQuotas(gcloud, acl, {
+            service,
+            threshold,
+            ...(region && { region }),
+            ...(project && { project }),
+          });
+          if ('error' in report) {
+            return errorTextResult(report.error);
+          }
+          return successfulTextResult(JSON.stringify(report, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('check_quotas failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
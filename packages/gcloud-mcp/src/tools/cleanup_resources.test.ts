@@ -0,0 +1,142 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { CommandHistory, createCommandHistory } from '../command_history.js';
+import { createCleanupResources } from './cleanup_resources.js';
+import { successfulTextResult } from './tool_result.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const ASSETS = [
+  {
+    name: '//compute.googleapis.com/projects/p/zones/us-central1-a/disks/tmp-1',
+    assetType: 'compute.googleapis.com/Disk',
+    location: 'us-central1-a',
+    labels: { env: 'tmp' },
+  },
+  {
+    name: '//compute.googleapis.com/projects/p/zones/us-central1-a/disks/tmp-2',
+    assetType: 'compute.googleapis.com/Disk',
+    location: 'us-central1-a',
+    labels: { env: 'tmp' },
+  },
+];
+
+const extra = { signal: new AbortController().signal };
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let history: CommandHistory;
+const run = vi.fn();
+
+const createTool = (deny: string[] = []) => {
+  createCleanupResources(mockedGcloud, createAccessControlList([], deny), run, history).register(
+    mockServer,
+  );
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createCleanupResources', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: JSON.stringify(ASSETS), stderr: '' }),
+    };
+    run.mockImplementation(async (args: string[]) => {
+      history.record(args, 0);
+      return successfulTextResult('ok');
+    });
+  });
+
+  test('returns the plan without deleting anything', async () => {
+    const tool = createTool();
+
+    const result = await tool(
+      { projects: ['p'], labels: { env: 'tmp' }, stopOnError: true },
+      extra,
+    );
+
+    const plan = JSON.parse(result.content[0].text);
+    expect(plan.planHash).toMatch(/^[0-9a-f]{16}$/);
+    expect(plan.items.map((item: { command: string }) => item.command)).toEqual([
+      'gcloud compute disks delete tmp-1 --zone=us-central1-a --quiet --project=p',
+      'gcloud compute disks delete tmp-2 --zone=us-central1-a --quiet --project=p',
+    ]);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('deletes the resources once the plan hash is confirmed', async () => {
+    const tool = createTool();
+    const input = { projects: ['p'], labels: { env: 'tmp' }, stopOnError: true };
+    const { planHash } = JSON.parse((await tool(input, extra)).content[0].text);
+
+    const result = await tool({ ...input, planHash, batchSize: 1 }, extra);
+
+    expect(run).toHaveBeenCalledOnce();
+    expect(run).toHaveBeenCalledWith(
+      ['compute', 'disks', 'delete', 'tmp-1', '--zone=us-central1-a', '--quiet', '--project=p'],
+      undefined,
+      true,
+    );
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      summary: { deleted: 1, failed: 0, notRun: 1 },
+    });
+  });
+
+  test('returns the new plan if the resources changed', async () => {
+    const tool = createTool();
+
+    const result = await tool(
+      { projects: ['p'], labels: { env: 'tmp' }, stopOnError: true, planHash: 'stale' },
+      extra,
+    );
+
+    expect(result.isError).toBe(true);
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      error: 'PLAN_CHANGED',
+      items: [{ name: 'tmp-1' }, { name: 'tmp-2' }],
+    });
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('requires a selection criterion', async () => {
+    const tool = createTool();
+
+    const result = await tool({ projects: ['p'], stopOnError: true }, extra);
+
+    expect(result.isError).toBe(true);
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('returns an error if asset search is denied', async () => {
+    const tool = createTool(['asset']);
+
+    const result = await tool({ projects: ['p'], olderThanDays: 30, stopOnError: true }, extra);
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud asset search-all-resources"');
+  });
+});
@@ -0,0 +1,194 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { CommandHistory } from '../command_history.js';
+import { ASSET_TYPES } from '../assets.js';
+import {
+  CleanupItem,
+  CleanupResult,
+  MAX_CLEANUP_ITEMS,
+  executeCleanup,
+  planCleanup,
+  validateCleanupCriteria,
+} from '../cleanup.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { DESTRUCTIVE_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const planItems = (items: CleanupItem[]) =>
+  items.map(({ args, ...item }) => ({ ...item, command: `gcloud ${args.join(' ')}` }));
+
+export const createCleanupResources = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'cleanup_resources',
+      {
+        title: 'Clean up resources',
+        inputSchema: {
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The projects to clean up. Defaults to the session project.'),
+          resourceTypes: z
+            .array(z.string())
+            .optional()
+            .describe(
+              `The resource types to delete. Defaults to all of: ${Object.values(ASSET_TYPES)
+                .map(({ resourceType }) => resourceType)
+                .join(', ')}.`,
+            ),
+          labels: z
+            .record(z.string())
+            .optional()
+            .describe('Only delete resources with these label values, e.g. {"env": "tmp"}.'),
+          olderThanDays: z
+            .number()
+            .int()
+            .min(0)
+            .optional()
+            .describe('Only delete resources created at least this many days ago.'),
+          namePattern: z
+            .string()
+            .optional()
+            .describe('Only delete resources whose names match this regular expression.'),
+          planHash: z
+            .string()
+            .optional()
+            .describe('The hash of the plan the user approved. Omit it to get the plan.'),
+          stopOnError: z
+            .boolean()
+            .default(true)
+            .describe('Whether to stop at the first failed deletion.'),
+          batchSize: z
+            .number()
+            .int()
+            .positive()
+            .optional()
+            .describe('Delete at most this many resources in this call.'),
+        },
+        annotations: DESTRUCTIVE_TOOL,
+        description: `Deletes resources selected by labels, age and name pattern in stages: first a deletion plan listing every resource and command, then, once the user confirmed the plan hash, the deletions one at a time with a result for each.
+
+## Instructions:
+- Use this tool instead of running delete commands one by one for bulk cleanups.
+- Call this tool without "planHash" first, and show the full plan to the user.
+- Call it again with the same criteria and the "planHash" of the plan only after the user approves. If the matching resources changed in the meantime, the new plan is returned instead and must be approved again.
+- Use "batchSize" to delete in stages; resources that were not deleted are reported as "not-run" and appear in the next plan.
+- Deletions can not be undone.`,
+      },
+      async (input, { signal }) => {
+        const { projects, planHash, stopOnError, batchSize, ...criteria } = input;
+        const toolLogger = log.mcp('cleanup_resources', input);
+        if (!acl.check('asset search-all-resources').permitted) {
+          return errorTextResult(
+            'Cleaning up resources requires "gcloud asset search-all-resources", which is not permitted.',
+          );
+        }
+        const criteriaError = validateCleanupCriteria(criteria);
+        if (criteriaError) {
+          return errorTextResult(criteriaError);
+        }
+        try {
+          let targets = projects ?? [];
+          if (targets.length === 0) {
+            const project = await sessionProject(gcloud);
+            if (!project) {
+              return errorTextResult(
+                'No project is set. Pass projects or set one with set_context.',
+              );
+            }
+            targets = [project];
+          }
+          const plan = await planCleanup(gcloud, targets, criteria);
+          if (plan.items.length === 0) {
+            return successfulTextResult(
+              JSON.stringify({ message: 'No resources match.', errors: plan.errors }, null, 2),
+            );
+          }
+          if (plan.items.length > MAX_CLEANUP_ITEMS) {
+            return errorTextResult(
+              `${plan.items.length} resources match, more than the limit of ${MAX_CLEANUP_ITEMS}. Narrow the criteria.`,
+            );
+          }
+          const planned = {
+            planHash: plan.planHash,
+            items: planItems(plan.items),
+            errors: plan.errors,
+          };
+          if (!planHash) {
+            return successfulTextResult(
+              JSON.stringify(
+                {
+                  ...planned,
+                  next: 'After the user approves, call cleanup_resources again with the same criteria and this "planHash".',
+                },
+                null,
+                2,
+              ),
+            );
+          }
+          if (planHash !== plan.planHash) {
+            return errorTextResult(
+              JSON.stringify(
+                {
+                  error: 'PLAN_CHANGED',
+                  message:
+                    'The matching resources changed since the plan was approved. Nothing was deleted. Review the new plan with the user.',
+                  ...planned,
+                },
+                null,
+                2,
+              ),
+            );
+          }
+
+          const { results, stopped } = await executeCleanup(plan.items, run, history, {
+            stopOnError,
+            batchSize,
+            signal,
+          });
+          const count = (status: CleanupResult['status']) =>
+            results.filter((r) => r.status === status).length;
+          const summary = {
+            deleted: count('deleted'),
+            failed: count('failed'),
+            notRun: count('not-run'),
+          };
+          toolLogger.info('cleanup_resources finished', summary);
+          return successfulTextResult(JSON.stringify({ summary, stopped, results }, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'cleanup_resources failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,44 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createReadCache } from '../read_cache.js';
+import { createClearCache } from './clear_cache.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+describe('createClearCache', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('drops the cached results and reports the cache statistics', async () => {
+    const readCache = createReadCache({}, () => ({}));
+    readCache.store(['projects', 'list'], undefined, '[]', '');
+    readCache.lookup(['projects', 'list']);
+    readCache.lookup(['compute', 'instances', 'list']);
+    createClearCache(readCache).register(mockServer);
+    const tool = (mockServer.registerTool as Mock).mock.calls[0]![2];
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text)).toEqual({ cleared: 1, hits: 1, misses: 1 });
+    expect(readCache.lookup(['projects', 'list'])).toBeUndefined();
+  });
+});
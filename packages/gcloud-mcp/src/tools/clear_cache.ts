@@ -0,0 +1,44 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { ReadCache } from '../read_cache.js';
+import { ADDITIVE_TOOL } from './tool_annotations.js';
+import { successfulTextResult } from './tool_result.js';
+
+export const createClearCache = (readCache: ReadCache) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'clear_cache',
+      {
+        title: 'Clear cache',
+        inputSchema: {},
+        annotations: ADDITIVE_TOOL,
+        description: `Drops the cached results of read commands of this session, so that every command runs again, and reports the hits and misses of the cache so far.
+
+## Instructions:
+- Use this tool when the user says resources changed outside of this session, e.g. in the Cloud Console.
+- To run a single command again, set "cache" to false on run_gcloud_command instead.
+- Commands that change state clear the cache themselves.`,
+      },
+      async () => {
+        const { hits, misses } = readCache.stats();
+        const cleared = readCache.clear();
+        return successfulTextResult(JSON.stringify({ cleared, hits, misses }, null, 2));
+      },
+    );
+  },
+});
@@ -0,0 +1,94 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { CommandHistory, createCommandHistory } from '../command_history.js';
+import { createCommandHistoryTools } from './command_history.js';
+import { successfulTextResult } from './tool_result.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let history: CommandHistory;
+const run = vi.fn();
+
+const getTool = (name: string) => {
+  createCommandHistoryTools(history, run).register(mockServer);
+  const call = (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name);
+  expect(call).toBeDefined();
+  return call![2];
+};
+
+describe('createCommandHistoryTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+    history.record(['compute', 'instances', 'list', '--zone=us-east1-b'], 0, {
+      CLOUDSDK_CORE_PROJECT: 'dev',
+    });
+    history.record(['compute', 'instances', 'delete', 'vm-1'], 1);
+    run.mockResolvedValue(successfulTextResult('output'));
+  });
+
+  test('lists the history', async () => {
+    const tool = getTool('list_command_history');
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text).map((e: { index: number }) => e.index)).toEqual([
+      1, 2,
+    ]);
+  });
+
+  test('re-runs an entry with its original environment and flag overrides', async () => {
+    const tool = getTool('rerun_command');
+
+    const result = await tool({ index: 1, flagOverrides: { '--zone': 'europe-west1-b' } });
+
+    expect(run).toHaveBeenCalledWith(['compute', 'instances', 'list', '--zone=europe-west1-b'], {
+      CLOUDSDK_CORE_PROJECT: 'dev',
+    });
+    expect(result).toEqual(successfulTextResult('output'));
+  });
+
+  test('returns an error for unknown entries', async () => {
+    const tool = getTool('rerun_command');
+
+    const result = await tool({ index: 7 });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('exports successful commands by default', async () => {
+    const tool = getTool('export_command_history');
+
+    const script = (await tool({})).content[0].text;
+
+    expect(script).toContain('gcloud compute instances list --zone=us-east1-b');
+    expect(script).not.toContain('delete');
+  });
+
+  test('exports failed commands when requested', async () => {
+    const tool = getTool('export_command_history');
+
+    const script = (await tool({ includeFailed: true })).content[0].text;
+
+    expect(script).toContain('gcloud compute instances delete vm-1');
+  });
+});
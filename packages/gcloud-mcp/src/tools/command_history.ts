@@ -0,0 +1,93 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { CommandHistory, applyFlagOverrides, toShellScript } from '../command_history.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { DESTRUCTIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createCommandHistoryTools = (history: CommandHistory, run: GcloudCommandRunner) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_command_history',
+      {
+        title: 'List command history',
+        inputSchema: {},
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the gcloud commands executed by run_gcloud_command in this session, with their index, exit code, and timestamp.`,
+      },
+      async () => {
+        log.mcp('list_command_history', {});
+        return successfulTextResult(JSON.stringify(history.list(), null, 2));
+      },
+    );
+
+    server.registerTool(
+      'rerun_command',
+      {
+        title: 'Re-run command',
+        inputSchema: {
+          index: z.number().int().min(1).describe('The index of the history entry to re-run.'),
+          flagOverrides: z
+            .record(z.union([z.string(), z.literal(true), z.null()]))
+            .optional()
+            .describe(
+              'Flags to change, e.g. {"--zone": "us-east1-b"}. Use null to remove a flag and true to set a flag without a value.',
+            ),
+        },
+        annotations: DESTRUCTIVE_TOOL,
+        description: `Re-runs a command from the session history with the same project, region, and zone it originally ran with, optionally changing some of its flags.
+
+## Instructions:
+- Use list_command_history to find the index of the command.
+- The command is subject to the same restrictions as run_gcloud_command.`,
+      },
+      async ({ index, flagOverrides }) => {
+        const toolLogger = log.mcp('rerun_command', { index, flagOverrides });
+        const entry = history.get(index);
+        if (!entry) {
+          return errorTextResult(`No command with index ${index} in the session history.`);
+        }
+        const args = flagOverrides ? applyFlagOverrides(entry.args, flagOverrides) : entry.args;
+        toolLogger.info('Re-running command', { args });
+        return run(args, entry.env);
+      },
+    );
+
+    server.registerTool(
+      'export_command_history',
+      {
+        title: 'Export command history',
+        inputSchema: {
+          includeFailed: z
+            .boolean()
+            .optional()
+            .describe('Whether to include commands that exited with an error. Defaults to false.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Exports the commands executed in this session as a bash script that reproduces them.`,
+      },
+      async ({ includeFailed }) => {
+        log.mcp('export_command_history', { includeFailed });
+        const entries = history.list().filter((entry) => includeFailed || entry.exitCode === 0);
+        return successfulTextResult(toShellScript(entries));
+      },
+    );
+  },
+});
@@ -0,0 +1,87 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createComplianceScan } from './compliance_scan.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (deny: string[] = []) => {
+  createComplianceScan(mockedGcloud, createAccessControlList([], deny), {
+    rules: ['uniform-bucket-access', 'os-login'],
+  }).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createComplianceScan', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(async (args: string[]) => {
+        if (args[0] === 'config') {
+          return { code: 0, stdout: 'session-project\n', stderr: '' };
+        }
+        return { code: 0, stdout: '[]', stderr: '' };
+      }),
+    };
+  });
+
+  test('checks the configured rules in the session project', async () => {
+    const tool = createTool();
+
+    const result = await tool({});
+
+    const report = JSON.parse(result.content[0].text);
+    expect(report.projects).toEqual(['session-project']);
+    expect(report.results.map(({ rule }: { rule: string }) => rule)).toEqual([
+      'uniform-bucket-access',
+      'os-login',
+    ]);
+  });
+
+  test('skips rules that are not permitted', async () => {
+    const tool = createTool(['compute']);
+
+    const result = await tool({ projects: ['p'] });
+
+    const report = JSON.parse(result.content[0].text);
+    expect(report.skipped).toEqual(['os-login']);
+    expect(report.results).toEqual([
+      expect.objectContaining({ rule: 'uniform-bucket-access', status: 'pass' }),
+    ]);
+  });
+
+  test('returns an error if no rule is permitted', async () => {
+    const tool = createTool(['storage', 'compute']);
+
+    const result = await tool({ projects: ['p'] });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud storage buckets list"');
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+});
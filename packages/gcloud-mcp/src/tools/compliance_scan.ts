@@ -0,0 +1,99 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import {
+  COMPLIANCE_RULES,
+  COMPLIANCE_RULE_COMMANDS,
+  ComplianceConfig,
+  runComplianceScan,
+} from '../compliance.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createComplianceScan = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  config: ComplianceConfig = {},
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'compliance_scan',
+      {
+        title: 'Scan for compliance',
+        inputSchema: {
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The projects to scan. Defaults to the session project.'),
+          rules: z
+            .array(z.enum(COMPLIANCE_RULES))
+            .optional()
+            .describe('The rules to check. Defaults to the rules of the compliance configuration.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Checks projects against a compliance rule set and returns pass or fail per rule per project, with the command that fixes each finding. The rules are: uniform bucket-level access on every bucket, no VMs running as the default compute service account, OS Login enabled, no external IPs on the configured private subnets, and the configured org policy constraints enforced.
+
+## Instructions:
+- Use this tool for security reviews instead of scripting the individual checks.
+- Nothing is changed. Review the findings with the user before running any remediation command with run_gcloud_command; some, like changing a service account, need the VM to be stopped.
+- Rules without configuration, e.g. no private subnets, are reported as not-applicable. Rules that fail to run, e.g. because an API is not enabled, are reported as error. Rules that are not permitted are listed under skipped.`,
+      },
+      async ({ projects, rules = config.rules ?? [...COMPLIANCE_RULES] }) => {
+        const toolLogger = log.mcp('compliance_scan', { projects, rules });
+        const permitted = rules.filter((rule) =>
+          COMPLIANCE_RULE_COMMANDS[rule].every((command) => acl.check(command).permitted),
+        );
+        const skipped = rules.filter((rule) => !permitted.includes(rule));
+        if (permitted.length === 0) {
+          return errorTextResult(
+            `None of the requested rules are permitted. They require ${skipped
+              .flatMap((rule) => COMPLIANCE_RULE_COMMANDS[rule])
+              .map((c) => `"gcloud ${c}"`)
+              .join(', ')}.`,
+          );
+        }
+        try {
+          let targets = projects ?? [];
+          if (targets.length === 0) {
+            const project = await sessionProject(gcloud);
+            if (!project) {
+              return errorTextResult(
+                'No project is set. Pass projects or set one with set_context.',
+              );
+            }
+            targets = [project];
+          }
+          const report = await runComplianceScan(gcloud, config, {
+            projects: targets,
+            rules: permitted,
+          });
+          toolLogger.info('compliance_scan finished', report.summary);
+          return successfulTextResult(JSON.stringify({ ...report, skipped }, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('compliance_scan failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,118 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { GcloudExecutable } from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createComponentTools } from './components.js';
+import { successfulTextResult } from './tool_result.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const mockedGcloud = {
+  lint: vi.fn(),
+  invoke: vi.fn(),
+};
+const run = vi.fn();
+
+const COMPONENTS_JSON = JSON.stringify([
+  { id: 'kubectl', name: 'kubectl', state: { name: 'Installed' } },
+  {
+    id: 'gke-gcloud-auth-plugin',
+    name: 'gke-gcloud-auth-plugin',
+    state: { name: 'Not Installed' },
+  },
+]);
+
+const getTool = (name: string, deny: string[] = []) => {
+  createComponentTools(
+    mockedGcloud as unknown as GcloudExecutable,
+    createAccessControlList([], deny),
+    run,
+  ).register(mockServer);
+  const call = (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name);
+  expect(call).toBeDefined();
+  return call![2];
+};
+
+describe('createComponentTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud.invoke.mockResolvedValue({ code: 0, stdout: COMPONENTS_JSON, stderr: '' });
+    run.mockResolvedValue(successfulTextResult('All components are up to date.'));
+  });
+
+  test('lists installed components', async () => {
+    const tool = getTool('list_components');
+
+    const result = await tool({ installedOnly: true });
+
+    expect(JSON.parse(result.content[0].text).map(({ id }: { id: string }) => id)).toEqual([
+      'kubectl',
+    ]);
+  });
+
+  test('reports missing components of a command', async () => {
+    const tool = getTool('check_components');
+
+    const result = await tool({ command: 'container clusters get-credentials my-cluster' });
+
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      required: ['gke-gcloud-auth-plugin', 'kubectl'],
+      missing: ['gke-gcloud-auth-plugin'],
+      installCommand: 'gcloud components install gke-gcloud-auth-plugin',
+    });
+  });
+
+  test('requires confirmation before installing', async () => {
+    const tool = getTool('install_components');
+
+    const result = await tool({ components: ['gke-gcloud-auth-plugin'], update: false });
+
+    expect(result.isError).toBe(true);
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      error: 'CONFIRMATION_REQUIRED',
+      command: 'gcloud components install gke-gcloud-auth-plugin --quiet',
+    });
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('installs approved components through the runner', async () => {
+    const tool = getTool('install_components');
+
+    await tool({ components: ['gke-gcloud-auth-plugin'], update: false, confirm: true });
+    await tool({ components: [], update: true, confirm: true });
+
+    expect(run).toHaveBeenCalledWith(
+      ['components', 'install', 'gke-gcloud-auth-plugin', '--quiet'],
+      undefined,
+      true,
+    );
+    expect(run).toHaveBeenCalledWith(['components', 'update', '--quiet'], undefined, true);
+  });
+
+  test('refuses denied component commands', async () => {
+    const tool = getTool('install_components', ['components install']);
+
+    const result = await tool({ components: ['kubectl'], update: false, confirm: true });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,184 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import {
+  COMPONENT_ID,
+  listComponents,
+  missingComponents,
+  requiredComponents,
+} from '../components.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { ADDITIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createComponentTools = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_components',
+      {
+        title: 'List gcloud components',
+        inputSchema: {
+          installedOnly: z
+            .boolean()
+            .default(true)
+            .describe('Whether to only list installed components.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the components of the gcloud installation, such as kubectl, gke-gcloud-auth-plugin, bq and the alpha and beta commands, with their state and versions.
+
+## Instructions:
+- Components with the "update-available" state are installed but outdated.`,
+      },
+      async (input) => {
+        const toolLogger = log.mcp('list_components', input);
+        try {
+          const components = await listComponents(gcloud);
+          const listed = input.installedOnly
+            ? components.filter(({ state }) => state !== 'not-installed')
+            : components;
+          return successfulTextResult(JSON.stringify(listed, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('list_components failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'check_components',
+      {
+        title: 'Check required gcloud components',
+        inputSchema: {
+          command: z
+            .string()
+            .describe(
+              'The command to check, e.g. "container clusters get-credentials my-cluster" or "kubectl get pods".',
+            ),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Detects which gcloud components a command needs and which of them are not installed.
+
+## Instructions:
+- Use this tool before running commands of kubectl, bq, cbt or gsutil, getting GKE credentials, running emulators, or alpha and beta commands, and when a command failed because a component is not installed.
+- Install missing components with install_components after the user approves.`,
+      },
+      async (input) => {
+        const toolLogger = log.mcp('check_components', input);
+        try {
+          const required = requiredComponents(input.command);
+          const missing =
+            required.length > 0 ? missingComponents(await listComponents(gcloud), required) : [];
+          return successfulTextResult(
+            JSON.stringify(
+              {
+                required,
+                missing,
+                ...(missing.length > 0 && {
+                  installCommand: `gcloud components install ${missing.join(' ')}`,
+                }),
+              },
+              null,
+              2,
+            ),
+          );
+        } catch (e: unknown) {
+          toolLogger.error(
+            'check_components failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'install_components',
+      {
+        title: 'Install or update gcloud components',
+        inputSchema: {
+          components: z
+            .array(z.string().regex(COMPONENT_ID))
+            .default([])
+            .describe('The IDs of the components to install, e.g. ["kubectl"].'),
+          update: z
+            .boolean()
+            .default(false)
+            .describe('Whether to update all installed components instead.'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true only after the user approved the installation.'),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Installs gcloud components, or updates all installed components. This changes the gcloud installation of the user.
+
+## Instructions:
+- Call this tool without "confirm" first and show the returned command to the user.
+- Call it again with "confirm": true only after the user approves.
+- If gcloud was installed with a package manager, its component manager is disabled; point the user to the package manager instead.`,
+      },
+      async (input) => {
+        const toolLogger = log.mcp('install_components', input);
+        const { components, update, confirm } = input;
+        if (!update && components.length === 0) {
+          return errorTextResult('Pass the components to install, or set "update" to true.');
+        }
+        const args = update
+          ? ['components', 'update', '--quiet']
+          : ['components', 'install', ...components, '--quiet'];
+        const command = args.slice(0, 2).join(' ');
+        if (!acl.check(command).permitted) {
+          return errorTextResult(`"gcloud ${command}" is not permitted.`);
+        }
+        if (!confirm) {
+          return errorTextResult(
+            JSON.stringify(
+              {
+                error: 'CONFIRMATION_REQUIRED',
+                message:
+                  'Installing components changes the gcloud installation. Show the command to the user and, after they approve, invoke this tool again with "confirm": true.',
+                command: `gcloud ${args.join(' ')}`,
+              },
+              null,
+              2,
+            ),
+          );
+        }
+        try {
+          return await run(args, undefined, true);
+        } catch (e: unknown) {
+          toolLogger.error(
+            'install_components failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
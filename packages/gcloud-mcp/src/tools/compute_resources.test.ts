@@ -0,0 +1,149 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createComputeResourceTools } from './compute_resources.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let run: GcloudCommandRunner;
+
+const createTool = (name: string) => {
+  createComputeResourceTools(run).register(mockServer);
+  return (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+};
+
+const mockOutput = (text: string, isError?: boolean) =>
+  vi.mocked(run).mockResolvedValue({
+    content: [{ type: 'text', text }],
+    ...(isError && { isError }),
+  });
+
+describe('createComputeResourceTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    run = vi.fn();
+  });
+
+  test('lists instances through the command runner', async () => {
+    const tool = createTool('list_instances');
+    mockOutput(JSON.stringify([{ name: 'vm-1', status: 'RUNNING' }]));
+
+    const result = await tool({ project: 'p', zone: 'us-central1-a', limit: 10 });
+
+    expect(run).toHaveBeenCalledWith([
+      'compute',
+      'instances',
+      'list',
+      '--project=p',
+      '--zones=us-central1-a',
+      '--limit=10',
+      '--format=json',
+    ]);
+    expect(result.structuredContent).toEqual({
+      instances: [
+        {
+          name: 'vm-1',
+          zone: null,
+          status: 'RUNNING',
+          machineType: null,
+          internalIp: null,
+          externalIp: null,
+          labels: {},
+        },
+      ],
+      count: 1,
+    });
+    expect(JSON.parse(result.content[0].text)).toEqual(result.structuredContent);
+  });
+
+  test('describes an instance', async () => {
+    const tool = createTool('describe_instance');
+    mockOutput(JSON.stringify({ name: 'vm-1', disks: [{ boot: true, diskSizeGb: '20' }] }));
+
+    const result = await tool({ name: 'vm-1', zone: 'us-central1-a' });
+
+    expect(run).toHaveBeenCalledWith([
+      'compute',
+      'instances',
+      'describe',
+      'vm-1',
+      '--zone=us-central1-a',
+      '--format=json',
+    ]);
+    expect(result.structuredContent.instance.disks).toEqual([
+      { name: null, deviceName: null, boot: true, sizeGb: 20 },
+    ]);
+  });
+
+  test('lists Cloud Run services in a region', async () => {
+    const tool = createTool('list_services');
+    mockOutput('[]');
+
+    const result = await tool({ region: 'europe-west1' });
+
+    expect(run).toHaveBeenCalledWith([
+      'run',
+      'services',
+      'list',
+      '--region=europe-west1',
+      '--format=json',
+    ]);
+    expect(result.structuredContent).toEqual({ services: [], count: 0 });
+  });
+
+  test('lists GKE clusters in a location', async () => {
+    const tool = createTool('list_clusters');
+    mockOutput('[]');
+
+    await tool({ location: 'us-central1', filter: 'status=RUNNING' });
+
+    expect(run).toHaveBeenCalledWith([
+      'container',
+      'clusters',
+      'list',
+      '--location=us-central1',
+      '--filter=status=RUNNING',
+      '--format=json',
+    ]);
+  });
+
+  test('returns errors of the runner unchanged', async () => {
+    const tool = createTool('list_instances');
+    mockOutput('Execution denied: the server runs in read-only mode.', true);
+
+    const result = await tool({});
+
+    expect(result).toEqual({
+      content: [{ type: 'text', text: 'Execution denied: the server runs in read-only mode.' }],
+      isError: true,
+    });
+  });
+
+  test('returns the output of failed commands as an error', async () => {
+    const tool = createTool('list_clusters');
+    mockOutput('\nSTDERR:\nERROR: (gcloud.container.clusters.list) PERMISSION_DENIED');
+
+    const result = await tool({});
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('PERMISSION_DENIED');
+  });
+});
@@ -0,0 +1,206 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import {
+  ClusterSummarySchema,
+  InstanceDetailsSchema,
+  InstanceSummarySchema,
+  ServiceSummarySchema,
+  describeInstanceArgs,
+  detailInstance,
+  listClustersArgs,
+  listInstancesArgs,
+  listServicesArgs,
+  summarizeClusters,
+  summarizeInstances,
+  summarizeServices,
+} from '../compute_resources.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner, runJsonCommand } from './run_gcloud_command.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const projectSchema = z
+  .string()
+  .optional()
+  .describe('The project to look in. Defaults to the session project.');
+
+const filterSchema = z
+  .string()
+  .optional()
+  .describe('A gcloud --filter expression over the raw resource, e.g. "status=RUNNING".');
+
+const limitSchema = z
+  .number()
+  .int()
+  .positive()
+  .max(1000)
+  .optional()
+  .describe('The maximum number of resources to return.');
+
+/**
+ * Runs a list or describe command and returns the summary as text and as
+ * structured content under `key`.
+ */
+const runSummarized = async <T>(
+  tool: string,
+  run: GcloudCommandRunner,
+  args: string[],
+  key: string,
+  summarize: (json: unknown) => T,
+) => {
+  const toolLogger = log.mcp(tool, args);
+  try {
+    const output = await runJsonCommand(run, args);
+    if ('error' in output) {
+      return output.error;
+    }
+    const summary = summarize(output.json);
+    const structuredContent = Array.isArray(summary)
+      ? { [key]: summary, count: summary.length }
+      : { [key]: summary };
+    return {
+      ...successfulTextResult(JSON.stringify(structuredContent, null, 2)),
+      structuredContent,
+    };
+  } catch (e: unknown) {
+    toolLogger.error(`${tool} failed`, e instanceof Error ? e : new Error(String(e)));
+    const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+    return errorTextResult(msg);
+  }
+};
+
+const listOutputSchema = <T extends z.ZodTypeAny>(key: string, item: T) => ({
+  [key]: z.array(item),
+  count: z.number(),
+});
+
+/**
+ * Typed tools for the most common compute resources. The commands run through
+ * the same runner as run_gcloud_command, so they are checked against the
+ * access control list and the read-only mode, rate limited and recorded.
+ */
+export const createComputeResourceTools = (run: GcloudCommandRunner) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_instances',
+      {
+        title: 'List VM instances',
+        inputSchema: {
+          project: projectSchema,
+          zone: z.string().optional().describe('Only list instances in this zone.'),
+          filter: filterSchema,
+          limit: limitSchema,
+        },
+        outputSchema: listOutputSchema('instances', InstanceSummarySchema),
+        annotations: READ_ONLY_TOOL,
+        description: `Lists Compute Engine VM instances with their zone, status, machine type, IP addresses and labels.
+
+## Instructions:
+- Prefer this tool over run_gcloud_command for listing VMs.
+- Use describe_instance for the disks, network interfaces and service accounts of an instance.`,
+      },
+      async ({ project, zone, filter, limit }) =>
+        runSummarized(
+          'list_instances',
+          run,
+          listInstancesArgs({ project, location: zone, filter, limit }),
+          'instances',
+          summarizeInstances,
+        ),
+    );
+
+    server.registerTool(
+      'describe_instance',
+      {
+        title: 'Describe VM instance',
+        inputSchema: {
+          name: z.string().describe('The name of the instance.'),
+          zone: z.string().describe('The zone of the instance, e.g. "us-central1-a".'),
+          project: projectSchema,
+        },
+        outputSchema: { instance: InstanceDetailsSchema },
+        annotations: READ_ONLY_TOOL,
+        description: `Describes a Compute Engine VM instance: its status, machine type, CPU platform, provisioning model, network interfaces, disks, service accounts, tags and labels.`,
+      },
+      async ({ name, zone, project }) =>
+        runSummarized(
+          'describe_instance',
+          run,
+          describeInstanceArgs(name, zone, project),
+          'instance',
+          detailInstance,
+        ),
+    );
+
+    server.registerTool(
+      'list_services',
+      {
+        title: 'List Cloud Run services',
+        inputSchema: {
+          project: projectSchema,
+          region: z.string().optional().describe('Only list services in this region.'),
+          filter: filterSchema,
+          limit: limitSchema,
+        },
+        outputSchema: listOutputSchema('services', ServiceSummarySchema),
+        annotations: READ_ONLY_TOOL,
+        description: `Lists Cloud Run services with their region, URL, readiness, latest ready revision, container image and last modifier.
+
+## Instructions:
+- Prefer this tool over run_gcloud_command for listing Cloud Run services.
+- A service with "ready": false failed to deploy its latest revision.`,
+      },
+      async ({ project, region, filter, limit }) =>
+        runSummarized(
+          'list_services',
+          run,
+          listServicesArgs({ project, location: region, filter, limit }),
+          'services',
+          summarizeServices,
+        ),
+    );
+
+    server.registerTool(
+      'list_clusters',
+      {
+        title: 'List GKE clusters',
+        inputSchema: {
+          project: projectSchema,
+          location: z.string().optional().describe('Only list clusters in this region or zone.'),
+          filter: filterSchema,
+          limit: limitSchema,
+        },
+        outputSchema: listOutputSchema('clusters', ClusterSummarySchema),
+        annotations: READ_ONLY_TOOL,
+        description: `Lists GKE clusters with their location, status, control plane version, node count, release channel and whether they run in Autopilot mode.
+
+## Instructions:
+- Prefer this tool over run_gcloud_command for listing GKE clusters.`,
+      },
+      async ({ project, location, filter, limit }) =>
+        runSummarized(
+          'list_clusters',
+          run,
+          listClustersArgs({ project, location, filter, limit }),
+          'clusters',
+          summarizeClusters,
+        ),
+    );
+  },
+});
@@ -0,0 +1,173 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer, ResourceTemplate } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createSessionContext } from '../session_context.js';
+import { createContextResources } from './context_resources.js';
+
+vi.mock('../gcloud.js');
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let mockServer: McpServer;
+
+const CONFIG = JSON.stringify({ core: { account: 'dev@example.com', project: 'web-prod' } });
+
+const PROJECTS = JSON.stringify([
+  { projectId: 'web-prod', name: 'Web', projectNumber: '123', lifecycleState: 'ACTIVE' },
+  { projectId: 'sandbox', name: 'Sandbox', projectNumber: '456', lifecycleState: 'ACTIVE' },
+]);
+
+const SERVICES = JSON.stringify([
+  { config: { name: 'compute.googleapis.com', title: 'Compute Engine API' } },
+  { config: { name: 'run.googleapis.com' } },
+]);
+
+const register = (allowedProjects: string[] = [], deny: string[] = []) => {
+  createContextResources(
+    mockedGcloud,
+    createSessionContext(allowedProjects),
+    createAccessControlList([], deny),
+  ).register(mockServer);
+  const resource = (name: string) =>
+    (mockServer.registerResource as Mock).mock.calls.find(
+      ([resourceName]) => resourceName === name,
+    )!;
+  return {
+    readConfig: resource('config')[3],
+    readProjects: resource('projects')[3],
+    readServices: resource('enabled-services')[3],
+    servicesTemplate: resource('enabled-services')[1] as ResourceTemplate,
+  };
+};
+
+const text = (result: { contents: Array<{ text: string }> }) =>
+  JSON.parse(result.contents[0]!.text);
+
+describe('createContextResources', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockServer = { registerResource: vi.fn() } as unknown as McpServer;
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(async (args: string[]) => {
+        const stdout = { config: CONFIG, projects: PROJECTS, services: SERVICES }[args[0]!];
+        return { code: 0, stdout: stdout ?? '', stderr: '' };
+      }),
+    };
+  });
+
+  test('registers the config, projects and enabled services resources', () => {
+    register();
+
+    expect(mockServer.registerResource).toHaveBeenCalledWith(
+      'config',
+      'gcloud://config',
+      expect.any(Object),
+      expect.any(Function),
+    );
+    expect(mockServer.registerResource).toHaveBeenCalledWith(
+      'projects',
+      'gcloud://projects',
+      expect.any(Object),
+      expect.any(Function),
+    );
+    expect(mockServer.registerResource).toHaveBeenCalledWith(
+      'enabled-services',
+      expect.any(ResourceTemplate),
+      expect.any(Object),
+      expect.any(Function),
+    );
+  });
+
+  test('reads the session context', async () => {
+    const { readConfig } = register();
+
+    const result = await readConfig(new URL('gcloud://config'));
+
+    expect(result.contents[0].mimeType).toBe('application/json');
+    expect(text(result)).toMatchObject({ account: 'dev@example.com', project: 'web-prod' });
+  });
+
+  test('lists only the allowed projects', async () => {
+    const { readProjects } = register(['sandbox']);
+
+    const result = await readProjects(new URL('gcloud://projects'));
+
+    expect(text(result)).toEqual({
+      projects: [{ projectId: 'sandbox', name: 'Sandbox', projectNumber: '456', state: 'ACTIVE' }],
+    });
+  });
+
+  test('reads the services enabled in a project', async () => {
+    const { readServices } = register();
+
+    const result = await readServices(new URL('gcloud://enabled-services/web-prod'), {
+      project: 'web-prod',
+    });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'services',
+      'list',
+      '--enabled',
+      '--project=web-prod',
+      '--format=json(config.name,config.title)',
+    ]);
+    expect(text(result)).toEqual({
+      project: 'web-prod',
+      services: [
+        { name: 'compute.googleapis.com', title: 'Compute Engine API' },
+        { name: 'run.googleapis.com', title: null },
+      ],
+    });
+  });
+
+  test('lists the enabled services of the session project', async () => {
+    const { servicesTemplate } = register();
+
+    const result = await servicesTemplate.listCallback!({} as never);
+
+    expect(result.resources.map(({ uri }) => uri)).toEqual(['gcloud://enabled-services/web-prod']);
+  });
+
+  test('rejects projects outside the allowlist', async () => {
+    const { readServices } = register(['sandbox']);
+
+    await expect(
+      readServices(new URL('gcloud://enabled-services/web-prod'), { project: 'web-prod' }),
+    ).rejects.toThrow('not on the allowlist');
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('rejects reads of commands that are not permitted', async () => {
+    const { readProjects } = register([], ['projects list']);
+
+    await expect(readProjects(new URL('gcloud://projects'))).rejects.toThrow(
+      '"gcloud projects list", which is not permitted',
+    );
+  });
+
+  test('reports gcloud failures', async () => {
+    const { readProjects } = register();
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 1, stdout: '', stderr: 'boom' });
+
+    await expect(readProjects(new URL('gcloud://projects'))).rejects.toThrow(
+      'gcloud projects list failed: boom',
+    );
+  });
+});
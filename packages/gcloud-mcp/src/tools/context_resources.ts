@@ -0,0 +1,160 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer, ResourceTemplate } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { ErrorCode, McpError } from '@modelcontextprotocol/sdk/types.js';
+import { z } from 'zod';
+import { AccessControlList } from '../denylist.js';
+import { GcloudExecutable } from '../gcloud.js';
+import { SessionContext } from '../session_context.js';
+import { getSessionConfig } from './gcloud_context.js';
+
+export const CONTEXT_SCHEME = 'gcloud';
+
+const ProjectSchema = z.object({
+  projectId: z.string(),
+  name: z.string().nullish(),
+  projectNumber: z.string().nullish(),
+  lifecycleState: z.string().nullish(),
+});
+
+const ServiceSchema = z.object({
+  config: z.object({ name: z.string(), title: z.string().nullish() }),
+});
+
+const jsonContents = (uri: URL, value: unknown) => ({
+  contents: [{ uri: uri.href, mimeType: 'application/json', text: JSON.stringify(value, null, 2) }],
+});
+
+/**
+ * Read-only resources with the context of the session: the gcloud
+ * configuration, the projects the account can see and the services enabled in
+ * a project. Clients can read them up front instead of spending tool calls.
+ */
+export const createContextResources = (
+  gcloud: GcloudExecutable,
+  session: SessionContext,
+  acl: AccessControlList,
+) => {
+  const runJson = async (command: string, args: string[]): Promise<unknown> => {
+    if (!acl.check(command).permitted) {
+      throw new McpError(
+        ErrorCode.InvalidRequest,
+        `Reading this resource requires "gcloud ${command}", which is not permitted.`,
+      );
+    }
+    const { code, stdout, stderr } = await gcloud.invoke(args);
+    if (code !== 0) {
+      throw new McpError(ErrorCode.InternalError, `gcloud ${command} failed: ${stderr}`);
+    }
+    return JSON.parse(stdout);
+  };
+
+  const listProjects = async () => {
+    const json = await runJson('projects list', [
+      'projects',
+      'list',
+      '--format=json(projectId,name,projectNumber,lifecycleState)',
+    ]);
+    const allowed = session.allowedProjects();
+    return z
+      .array(ProjectSchema)
+      .parse(json)
+      .filter(({ projectId }) => allowed.length === 0 || allowed.includes(projectId))
+      .map((project) => ({
+        projectId: project.projectId,
+        name: project.name ?? null,
+        projectNumber: project.projectNumber ?? null,
+        state: project.lifecycleState ?? null,
+      }));
+  };
+
+  const listEnabledServices = async (project: string) => {
+    const error = session.checkProject(project);
+    if (error) {
+      throw new McpError(ErrorCode.InvalidParams, error);
+    }
+    const json = await runJson('services list', [
+      'services',
+      'list',
+      '--enabled',
+      `--project=${project}`,
+      '--format=json(config.name,config.title)',
+    ]);
+    return z
+      .array(ServiceSchema)
+      .parse(json)
+      .map(({ config }) => ({ name: config.name, title: config.title ?? null }));
+  };
+
+  return {
+    register: (server: McpServer) => {
+      server.registerResource(
+        'config',
+        `${CONTEXT_SCHEME}://config`,
+        {
+          title: 'gcloud configuration',
+          description:
+            'The account, project, region and zone commands in this session run with, the session overrides and the projects that may be selected.',
+          mimeType: 'application/json',
+        },
+        async (uri) => jsonContents(uri, await getSessionConfig(gcloud, session)),
+      );
+
+      server.registerResource(
+        'projects',
+        `${CONTEXT_SCHEME}://projects`,
+        {
+          title: 'Projects',
+          description:
+            'The projects the account can see, with their name, number and lifecycle state. Only the allowed projects are listed if the session has an allowlist.',
+          mimeType: 'application/json',
+        },
+        async (uri) => jsonContents(uri, { projects: await listProjects() }),
+      );
+
+      server.registerResource(
+        'enabled-services',
+        new ResourceTemplate(`${CONTEXT_SCHEME}://enabled-services/{project}`, {
+          // Lists the resource of the project commands run in.
+          list: async () => {
+            const { project } = await getSessionConfig(gcloud, session);
+            return {
+              resources: project
+                ? [
+                    {
+                      uri: `${CONTEXT_SCHEME}://enabled-services/${project}`,
+                      name: `Services enabled in ${project}`,
+                      mimeType: 'application/json',
+                    },
+                  ]
+                : [],
+            };
+          },
+        }),
+        {
+          title: 'Enabled services',
+          description: 'The APIs and services enabled in a project, e.g. compute.googleapis.com.',
+          mimeType: 'application/json',
+        },
+        async (uri, { project }) => {
+          const services = await listEnabledServices(String(project));
+          return jsonContents(uri, { project: String(project), services });
+        },
+      );
+    },
+  };
+};
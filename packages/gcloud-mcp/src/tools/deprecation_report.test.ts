@@ -0,0 +1,86 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createDeprecationReport } from './deprecation_report.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (deny: string[] = []) => {
+  createDeprecationReport(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createDeprecationReport', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(async (args: string[]) => {
+        if (args[0] === 'config') {
+          return { code: 0, stdout: 'session-project\n', stderr: '' };
+        }
+        return { code: 0, stdout: '[]', stderr: '' };
+      }),
+    };
+  });
+
+  test('checks the session project', async () => {
+    const tool = createTool();
+
+    const result = await tool({ checks: ['sql-versions'], horizonDays: 365 });
+
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      projects: ['session-project'],
+      horizonDays: 365,
+      summary: { 'end-of-life': 0, deprecated: 0, upcoming: 0 },
+      findings: [],
+      errors: [],
+      skipped: [],
+    });
+  });
+
+  test('skips checks that are not permitted', async () => {
+    const tool = createTool(['functions']);
+
+    const result = await tool({
+      projects: ['p'],
+      checks: ['function-runtimes', 'sql-versions'],
+      horizonDays: 365,
+    });
+
+    expect(JSON.parse(result.content[0].text).skipped).toEqual(['function-runtimes']);
+  });
+
+  test('returns an error if no check is permitted', async () => {
+    const tool = createTool(['sql']);
+
+    const result = await tool({ projects: ['p'], checks: ['sql-versions'], horizonDays: 365 });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud sql instances list"');
+  });
+});
@@ -0,0 +1,110 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import {
+  DEPRECATION_CHECKS,
+  DEPRECATION_CHECK_COMMANDS,
+  assessDeprecations,
+} from '../deprecations.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createDeprecationReport = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'deprecation_report',
+      {
+        title: 'Deprecation and end-of-life report',
+        inputSchema: {
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The projects to check. Defaults to the session project.'),
+          checks: z
+            .array(z.enum(DEPRECATION_CHECKS))
+            .optional()
+            .describe('The checks to run. Defaults to all of them.'),
+          horizonDays: z
+            .number()
+            .int()
+            .min(0)
+            .max(1095)
+            .default(365)
+            .describe(
+              'Also report what is deprecated or reaches end of life within this many days.',
+            ),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Inventories the versions and runtimes in use and reports what is past end of life, deprecated, or will be within the horizon, with the date and what to do about it: GKE control plane and node versions, Cloud Functions and App Engine runtimes, Cloud SQL database versions, and the images and machine types of VMs.
+
+Findings past end of life come first, then the rest by date.
+
+## Instructions:
+- Use this tool for proactive upgrade planning, e.g. "what will break this year".
+- Runtime, image and machine type lifecycles come from the APIs. GKE versions are compared with the versions still offered in each location, and Cloud SQL versions with the community end-of-life dates of the database engines.
+- Nothing is changed. Review the findings with the user before acting on any recommendation; recommendations with placeholders like VERSION or RUNTIME need values chosen with the user.
+- Checks that fail, e.g. because an API is not enabled in a project, are listed under errors. Checks that are not permitted are listed under skipped.`,
+      },
+      async ({ projects, checks = [...DEPRECATION_CHECKS], horizonDays }) => {
+        const toolLogger = log.mcp('deprecation_report', { projects, checks, horizonDays });
+        const permitted = checks.filter((check) =>
+          DEPRECATION_CHECK_COMMANDS[check].every((command) => acl.check(command).permitted),
+        );
+        const skipped = checks.filter((check) => !permitted.includes(check));
+        if (permitted.length === 0) {
+          return errorTextResult(
+            `None of the requested checks are permitted. They require ${skipped
+              .flatMap((check) => DEPRECATION_CHECK_COMMANDS[check])
+              .map((c) => `"gcloud ${c}"`)
+              .join(', ')}.`,
+          );
+        }
+        try {
+          let targets = projects ?? [];
+          if (targets.length === 0) {
+            const project = await sessionProject(gcloud);
+            if (!project) {
+              return errorTextResult(
+                'No project is set. Pass projects or set one with set_context.',
+              );
+            }
+            targets = [project];
+          }
+          const report = await assessDeprecations(gcloud, {
+            projects: targets,
+            checks: permitted,
+            horizonDays,
+          });
+          toolLogger.info('deprecation_report finished', report.summary);
+          return successfulTextResult(JSON.stringify({ ...report, skipped }, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'deprecation_report failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
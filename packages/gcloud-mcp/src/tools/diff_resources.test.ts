@@ -0,0 +1,152 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createDiffResources } from './diff_resources.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const INSTANCES: Record<string, unknown> = {
+  'web-1': { name: 'web-1', id: '1', machineType: 'e2-small' },
+  'web-2': { name: 'web-2', id: '2', machineType: 'e2-medium' },
+};
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (deny: string[] = []) => {
+  createDiffResources(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createDiffResources', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(async (args: string[]) => {
+        if (args[0] === 'config') {
+          return { code: 0, stdout: 'session-project\n', stderr: '' };
+        }
+        if (args[0] === 'asset') {
+          const history = [
+            { asset: { resource: { data: INSTANCES['web-1'] } } },
+            { asset: { resource: { data: { name: 'web-1', machineType: 'e2-medium' } } } },
+          ];
+          return { code: 0, stdout: JSON.stringify(history), stderr: '' };
+        }
+        return { code: 0, stdout: JSON.stringify(INSTANCES[args[3] ?? '']), stderr: '' };
+      }),
+    };
+  });
+
+  test('compares two resources', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      assetType: 'compute.googleapis.com/Instance',
+      left: { name: 'web-1', project: 'p', location: 'us-central1-a' },
+      right: { name: 'web-2', project: 'p', location: 'us-central1-a' },
+    });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      identical: false,
+      changed: [{ path: 'machineType', left: 'e2-small', right: 'e2-medium' }],
+    });
+    expect(mockedGcloud.invoke).not.toHaveBeenCalledWith(['config', 'get-value', 'project']);
+  });
+
+  test('defaults to the session project', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      assetType: 'compute.googleapis.com/Instance',
+      left: { name: 'web-1', location: 'us-central1-a' },
+      right: { name: 'web-2', location: 'us-central1-a' },
+    });
+
+    expect(JSON.parse(result.content[0].text).left).toEqual({
+      project: 'session-project',
+      name: 'web-1',
+      location: 'us-central1-a',
+    });
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(
+      expect.arrayContaining(['describe', 'web-2', '--project=session-project']),
+    );
+  });
+
+  test('compares a resource with its snapshot', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      assetType: 'compute.googleapis.com/Instance',
+      left: { name: 'web-1', project: 'p', location: 'us-central1-a' },
+      snapshotTime: '2025-01-01T00:00:00Z',
+    });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      left: 'web-1@2025-01-01T00:00:00Z',
+      right: 'web-1',
+      changed: [{ path: 'machineType', left: 'e2-small', right: 'e2-medium' }],
+    });
+  });
+
+  test('returns an error without right or snapshotTime', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      assetType: 'compute.googleapis.com/Instance',
+      left: { name: 'web-1', project: 'p', location: 'us-central1-a' },
+    });
+
+    expect(result.isError).toBe(true);
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('returns an error if describing is denied', async () => {
+    const tool = createTool(['compute instances describe']);
+
+    const result = await tool({
+      assetType: 'compute.googleapis.com/Instance',
+      left: { name: 'web-1', project: 'p', location: 'us-central1-a' },
+      right: { name: 'web-2', project: 'p', location: 'us-central1-a' },
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud compute instances describe"');
+  });
+
+  test('returns an error if gcloud fails', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 1, stdout: '', stderr: 'not found' });
+    const tool = createTool();
+
+    const result = await tool({
+      assetType: 'compute.googleapis.com/Instance',
+      left: { name: 'web-1', project: 'p', location: 'us-central1-a' },
+      right: { name: 'web-3', project: 'p', location: 'us-central1-a' },
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('not found');
+  });
+});
@@ -0,0 +1,146 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { ASSET_TYPES } from '../assets.js';
+import { ResourceRef, describeResource, diffResources, resourceHistory } from '../resource_diff.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const ResourceInput = z.object({
+  name: z.string().describe('The short name of the resource, e.g. "web-1" or "my-bucket".'),
+  project: z
+    .string()
+    .optional()
+    .describe('The project of the resource. Defaults to the session project.'),
+  location: z
+    .string()
+    .optional()
+    .describe('The zone or region of the resource, required for zonal and regional resources.'),
+});
+
+export const createDiffResources = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'diff_resources',
+      {
+        title: 'Diff resources',
+        inputSchema: {
+          assetType: z
+            .enum(Object.keys(ASSET_TYPES) as [string, ...string[]])
+            .describe('The Cloud Asset Inventory type of both resources.'),
+          left: ResourceInput.describe('The first resource.'),
+          right: ResourceInput.optional().describe(
+            'The resource to compare with. Omit it to compare "left" with its own history.',
+          ),
+          snapshotTime: z
+            .string()
+            .optional()
+            .describe(
+              'An RFC 3339 timestamp. Compares "left" as it is now with how it was at that time, from Cloud Asset Inventory history.',
+            ),
+          ignoreFields: z
+            .array(z.string())
+            .optional()
+            .describe('Additional field paths to ignore, e.g. ["labels", "metadata.items"].'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Describes two resources of the same type, e.g. two VMs, two Cloud Run services or two buckets, or one resource and its Cloud Asset Inventory snapshot, and returns a normalized field-level diff. Server-populated fields such as ids, timestamps, fingerprints, etags and status conditions are ignored, lists are matched by name where possible, and references to each resource's own project and name are replaced with placeholders.
+
+## Instructions:
+- Use this tool to answer "why does this one behave differently?" or "what changed since yesterday?" instead of describing both resources and comparing them by hand.
+- Pass either "right" or "snapshotTime", not both.
+- Paths look like "disks[deviceName=boot].diskSizeGb". Pass paths in "ignoreFields" to hide expected differences.
+- Snapshot comparisons need the Cloud Asset API and cover at most the last 35 days.`,
+      },
+      async ({ assetType, left, right, snapshotTime, ignoreFields }) => {
+        const toolLogger = log.mcp('diff_resources', {
+          assetType,
+          left,
+          right,
+          snapshotTime,
+          ignoreFields,
+        });
+        const type = ASSET_TYPES[assetType];
+        if (!type) {
+          return errorTextResult(`Unsupported asset type: ${assetType}`);
+        }
+        if (!right === !snapshotTime) {
+          return errorTextResult('Pass either "right" or "snapshotTime".');
+        }
+        const command = snapshotTime ? 'asset get-history' : `${type.resourceType} describe`;
+        if (!acl.check(command).permitted) {
+          return errorTextResult(
+            `Comparing these resources requires "gcloud ${command}", which is not permitted.`,
+          );
+        }
+        try {
+          const needsSessionProject = !left.project || (right && !right.project);
+          const defaultProject = needsSessionProject ? await sessionProject(gcloud) : null;
+          const refOf = (input: z.infer<typeof ResourceInput>): ResourceRef | null => {
+            const project = input.project ?? defaultProject;
+            if (!project) {
+              return null;
+            }
+            return { project, name: input.name, location: input.location ?? 'global' };
+          };
+          // Without "right", snapshotTime is set and the resource is compared with itself.
+          const leftRef = refOf(left);
+          const rightRef = refOf(right ?? left);
+          if (!leftRef || !rightRef) {
+            return errorTextResult(
+              'No project is set. Pass a project or set one with set_context.',
+            );
+          }
+          if (snapshotTime) {
+            const history = await resourceHistory(gcloud, type, leftRef, snapshotTime);
+            if (!history.snapshot || !history.current) {
+              const state = history.current ? 'did not exist at' : 'no longer exists after';
+              return errorTextResult(`${leftRef.name} ${state} ${snapshotTime}.`);
+            }
+            const diff = diffResources(history.snapshot, history.current, {
+              ...(ignoreFields && { ignoreFields }),
+            });
+            const snapshot = `${leftRef.name}@${snapshotTime}`;
+            return successfulTextResult(
+              JSON.stringify({ left: snapshot, right: leftRef.name, ...diff }, null, 2),
+            );
+          }
+          const [leftResource, rightResource] = await Promise.all([
+            describeResource(gcloud, type, leftRef),
+            describeResource(gcloud, type, rightRef),
+          ]);
+          const diff = diffResources(leftResource, rightResource, {
+            ...(ignoreFields && { ignoreFields }),
+            refs: [leftRef, rightRef],
+          });
+          return successfulTextResult(
+            JSON.stringify({ left: leftRef, right: rightRef, ...diff }, null, 2),
+          );
+        } catch (e: unknown) {
+          toolLogger.error('diff_resources failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,104 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { DR_CHECKS, DR_CHECK_COMMANDS, assessDrReadiness } from '../dr_readiness.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createDrReadinessReport = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'dr_readiness_report',
+      {
+        title: 'Disaster recovery readiness report',
+        inputSchema: {
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The projects to assess. Defaults to the session project.'),
+          checks: z
+            .array(z.enum(DR_CHECKS))
+            .optional()
+            .describe('The checks to run. Defaults to all of them.'),
+          criticalBucketLabels: z
+            .record(z.string())
+            .optional()
+            .describe(
+              'Buckets with any of these label values hold critical data, e.g. {"data-class": "critical"}. Single-region buckets holding critical data are reported with high severity.',
+            ),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Assesses the disaster recovery posture of projects and returns a findings report: VMs and managed instance groups pinned to a single zone, disks in use without a snapshot schedule, Cloud SQL instances without high availability or a cross-region replica, single-region buckets, and zonal GKE clusters. Each finding has a severity and a recommendation.
+
+## Instructions:
+- Use this tool for DR and resilience reviews instead of inspecting each service by hand.
+- Nothing is changed. Review the findings with the user before acting on any recommendation; recommendations with placeholders like SCHEDULE or OTHER_REGION need values chosen with the user.
+- Checks that fail, e.g. because an API is not enabled in a project, are listed under errors. Checks that are not permitted are listed under skipped.`,
+      },
+      async ({ projects, checks = [...DR_CHECKS], criticalBucketLabels }) => {
+        const toolLogger = log.mcp('dr_readiness_report', {
+          projects,
+          checks,
+          criticalBucketLabels,
+        });
+        const permitted = checks.filter((check) =>
+          DR_CHECK_COMMANDS[check].every((command) => acl.check(command).permitted),
+        );
+        const skipped = checks.filter((check) => !permitted.includes(check));
+        if (permitted.length === 0) {
+          return errorTextResult(
+            `None of the requested checks are permitted. They require ${skipped
+              .flatMap((check) => DR_CHECK_COMMANDS[check])
+              .map((c) => `"gcloud ${c}"`)
+              .join(', ')}.`,
+          );
+        }
+        try {
+          let targets = projects ?? [];
+          if (targets.length === 0) {
+            const project = await sessionProject(gcloud);
+            if (!project) {
+              return errorTextResult(
+                'No project is set. Pass projects or set one with set_context.',
+              );
+            }
+            targets = [project];
+          }
+          const report = await assessDrReadiness(gcloud, {
+            projects: targets,
+            checks: permitted,
+            ...(criticalBucketLabels && { criticalBucketLabels }),
+          });
+          toolLogger.info('dr_readiness_report finished', report.summary);
+          return successfulTextResult(JSON.stringify({ ...report, skipped }, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'dr_readiness_report failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,118 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createEssentialContactsTools } from './essential_contacts.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let run: GcloudCommandRunner;
+
+const createTool = (name: string, deny: string[] = []) => {
+  createEssentialContactsTools(mockedGcloud, createAccessControlList([], deny), run).register(
+    mockServer,
+  );
+  return (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+};
+
+const output = (stdout: string) => ({ code: 0, stdout, stderr: '' });
+
+describe('createEssentialContactsTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = { lint: vi.fn(), invoke: vi.fn().mockResolvedValue(output('[]')) };
+    run = vi.fn().mockResolvedValue({ content: [{ type: 'text', text: 'Created.' }] });
+  });
+
+  test('lists the contacts of the session project', async () => {
+    const tool = createTool('list_essential_contacts');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValueOnce(output('my-project\n'));
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      parent: { project: 'my-project' },
+      contacts: [],
+    });
+    expect(vi.mocked(mockedGcloud.invoke).mock.calls[1]![0]).toContain('--project=my-project');
+  });
+
+  test('rejects more than one parent', async () => {
+    const tool = createTool('list_essential_contacts');
+
+    const result = await tool({ project: 'p', folder: '123' });
+
+    expect(result.isError).toBe(true);
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('sets a contact through the command runner', async () => {
+    const tool = createTool('set_essential_contact');
+
+    await tool({
+      folder: '123',
+      email: 'sec@example.com',
+      categories: ['security'],
+      language: 'en',
+      confirm: true,
+    });
+
+    expect(run).toHaveBeenCalledWith(
+      [
+        'essential-contacts',
+        'create',
+        '--email=sec@example.com',
+        '--notification-categories=security',
+        '--language=en',
+        '--folder=123',
+      ],
+      undefined,
+      true,
+    );
+  });
+
+  test('audits every project of a folder', async () => {
+    const tool = createTool('audit_essential_contacts');
+    vi.mocked(mockedGcloud.invoke)
+      .mockResolvedValueOnce(output(JSON.stringify([{ additionalAttributes: { projectId: 'a' } }])))
+      .mockResolvedValueOnce(output('[]'));
+
+    const result = await tool({ folder: '123', categories: ['security', 'billing'] });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      summary: { projects: 1, compliant: 0, missingContacts: 1 },
+      projects: [{ project: 'a', missing: ['security', 'billing'] }],
+    });
+  });
+
+  test('returns an error if computing contacts is not permitted', async () => {
+    const tool = createTool('audit_essential_contacts', ['essential-contacts compute']);
+
+    const result = await tool({ projects: ['a'], categories: ['security'] });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud essential-contacts compute"');
+  });
+});
@@ -0,0 +1,262 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { MAX_PROJECTS, projectsInScope } from '../across_projects.js';
+import {
+  ContactParent,
+  DEFAULT_REQUIRED_CATEGORIES,
+  EMAIL_PLACEHOLDER,
+  NOTIFICATION_CATEGORIES,
+  auditContacts,
+  listContacts,
+  setContactArgs,
+} from '../essential_contacts.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { DESTRUCTIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const LIST_COMMAND = 'essential-contacts list';
+const COMPUTE_COMMAND = 'essential-contacts compute';
+
+const parentSchema = {
+  project: z.string().optional().describe('The project. Defaults to the session project.'),
+  folder: z.string().optional().describe('A folder ID, instead of a project.'),
+  organization: z.string().optional().describe('An organization ID, instead of a project.'),
+};
+
+/** Resolves the parent of contacts from the tool input, or returns an error message. */
+const parentOf = async (
+  gcloud: GcloudExecutable,
+  input: {
+    project?: string | undefined;
+    folder?: string | undefined;
+    organization?: string | undefined;
+  },
+): Promise<ContactParent | string> => {
+  const { project, folder, organization } = input;
+  if ([project, folder, organization].filter((p) => p !== undefined).length > 1) {
+    return 'Pass at most one of project, folder, or organization.';
+  }
+  if (folder !== undefined) {
+    return { folder };
+  }
+  if (organization !== undefined) {
+    return { organization };
+  }
+  const target = project ?? (await sessionProject(gcloud));
+  return target
+    ? { project: target }
+    : 'No project is set. Pass a project or set one with set_context.';
+};
+
+export const createEssentialContactsTools = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_essential_contacts',
+      {
+        title: 'List Essential Contacts',
+        inputSchema: parentSchema,
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the Essential Contacts set directly on a project, folder or organization, with the notification categories each is subscribed to and whether its address was validated.
+
+## Instructions:
+- Contacts set on folders and the organization also receive notifications for the projects below them. Use audit_essential_contacts to see the contacts that apply to a project.`,
+      },
+      async (input) => {
+        const toolLogger = log.mcp('list_essential_contacts', input);
+        if (!acl.check(LIST_COMMAND).permitted) {
+          return errorTextResult(
+            `Listing contacts requires "gcloud ${LIST_COMMAND}", which is not permitted.`,
+          );
+        }
+        try {
+          const parent = await parentOf(gcloud, input);
+          if (typeof parent === 'string') {
+            return errorTextResult(parent);
+          }
+          const contacts = await listContacts(gcloud, parent);
+          return successfulTextResult(JSON.stringify({ parent, contacts }, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'list_essential_contacts failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'set_essential_contact',
+      {
+        title: 'Set Essential Contact',
+        inputSchema: {
+          ...parentSchema,
+          email: z.string().describe('The email address to send notifications to.'),
+          categories: z
+            .array(z.enum(NOTIFICATION_CATEGORIES))
+            .min(1)
+            .describe('The notification categories to subscribe the address to.'),
+          language: z
+            .string()
+            .default('en')
+            .describe('The language of notifications as a BCP 47 tag, e.g. "en" or "ja".'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true once the user has explicitly approved the change.'),
+        },
+        annotations: DESTRUCTIVE_TOOL,
+        description: `Subscribes an email address to notification categories on a project, folder or organization. If the address already is a contact there, its categories are replaced by the given ones; otherwise a contact is created. The change is made with run_gcloud_command, so it is recorded in the command history like every other change.
+
+## Instructions:
+- Confirm the change with the user before making it.
+- To add a category to an existing contact, pass its current categories as well.
+- Prefer a group address over a person, so notifications are not lost when people leave.`,
+      },
+      async ({ email, categories, language, confirm, ...input }) => {
+        const toolLogger = log.mcp('set_essential_contact', { ...input, email, categories });
+        if (!acl.check(LIST_COMMAND).permitted) {
+          return errorTextResult(
+            `Setting contacts requires "gcloud ${LIST_COMMAND}", which is not permitted.`,
+          );
+        }
+        try {
+          const parent = await parentOf(gcloud, input);
+          if (typeof parent === 'string') {
+            return errorTextResult(parent);
+          }
+          const existing = await listContacts(gcloud, parent);
+          const args = setContactArgs(parent, existing, email, categories, language);
+          toolLogger.info('Setting contact', { args });
+          return run(args, undefined, confirm);
+        } catch (e: unknown) {
+          toolLogger.error(
+            'set_essential_contact failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'audit_essential_contacts',
+      {
+        title: 'Audit Essential Contacts',
+        inputSchema: {
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The projects to audit. Defaults to the session project.'),
+          folder: z
+            .string()
+            .optional()
+            .describe('A folder ID. Every active project below it is audited.'),
+          organization: z
+            .string()
+            .optional()
+            .describe('An organization ID. Every active project in it is audited.'),
+          categories: z
+            .array(z.enum(NOTIFICATION_CATEGORIES))
+            .min(1)
+            .default(DEFAULT_REQUIRED_CATEGORIES)
+            .describe('The categories every project needs a contact for.'),
+          remediationEmail: z
+            .string()
+            .optional()
+            .describe(
+              'The address to use in the remediation commands, e.g. a security team group.',
+            ),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Finds the projects that have no Essential Contact for security or billing notifications, or other required categories, so that incident and billing notifications reach someone. Contacts inherited from folders and the organization count, as they do for notifications.
+
+Each project missing contacts comes with the gcloud command that adds one.
+
+## Instructions:
+- Pass at most one of projects, folder, or organization; without any, the session project is audited. Folders and organizations are expanded with Cloud Asset Inventory.
+- A contact for "all" covers every category.
+- Without remediationEmail, the commands use the placeholder ${EMAIL_PLACEHOLDER}; ask the user for the address before running them.
+- Adding a contact once on a folder or the organization fixes every project below it, so suggest that when many projects are missing the same categories.`,
+      },
+      async ({ projects, folder, organization, categories, remediationEmail }) => {
+        const toolLogger = log.mcp('audit_essential_contacts', {
+          projects,
+          folder,
+          organization,
+          categories,
+        });
+        if (!acl.check(COMPUTE_COMMAND).permitted) {
+          return errorTextResult(
+            `Auditing contacts requires "gcloud ${COMPUTE_COMMAND}", which is not permitted.`,
+          );
+        }
+        if ([projects, folder, organization].filter((scope) => scope !== undefined).length > 1) {
+          return errorTextResult('Pass at most one of projects, folder, or organization.');
+        }
+        try {
+          let targets = projects ?? [];
+          if (folder !== undefined || organization !== undefined) {
+            if (!acl.check('asset search-all-resources').permitted) {
+              return errorTextResult(
+                'Auditing a folder or organization requires "gcloud asset search-all-resources", which is not permitted.',
+              );
+            }
+            targets = await projectsInScope(
+              gcloud,
+              folder !== undefined ? { folder } : { organization: organization ?? '' },
+            );
+          } else if (targets.length === 0) {
+            const project = await sessionProject(gcloud);
+            if (!project) {
+              return errorTextResult(
+                'No project is set. Pass projects or set one with set_context.',
+              );
+            }
+            targets = [project];
+          }
+          if (targets.length > MAX_PROJECTS) {
+            return errorTextResult(
+              `${targets.length} projects are in scope, more than the limit of ${MAX_PROJECTS}. Audit them per folder instead.`,
+            );
+          }
+          const audit = await auditContacts(gcloud, targets, categories, remediationEmail);
+          return successfulTextResult(JSON.stringify(audit, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'audit_essential_contacts failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,99 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { BillingCatalog } from '../billing_catalog.js';
+import { createEstimateCost } from './estimate_cost.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const mockedGcloud: gcloud.GcloudExecutable = {
+  lint: vi.fn(),
+  invoke: vi.fn(),
+};
+
+const createTool = (catalog: BillingCatalog) => {
+  createEstimateCost(mockedGcloud, catalog).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createEstimateCost', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('returns the estimate as JSON', async () => {
+    const tool = createTool({
+      skus: async () => [
+        {
+          skuId: 'D973-5D65-BAB2',
+          description: 'Balanced PD Capacity in Americas',
+          category: { usageType: 'OnDemand' },
+          serviceRegions: ['us-central1'],
+          pricingInfo: [
+            {
+              pricingExpression: {
+                usageUnit: 'GiBy.mo',
+                tieredRates: [
+                  {
+                    startUsageAmount: 0,
+                    unitPrice: { currencyCode: 'USD', units: '0', nanos: 100000000 },
+                  },
+                ],
+              },
+            },
+          ],
+        },
+      ],
+    });
+
+    const result = await tool({
+      resources: [
+        { type: 'disk', region: 'us-central1', diskType: 'pd-balanced', sizeGb: 200, count: 1 },
+      ],
+    });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      currency: 'USD',
+      totalMonthly: 20,
+      resources: [{ type: 'disk', monthly: 20, warnings: [] }],
+    });
+  });
+
+  test('returns an error if the catalog request fails', async () => {
+    const tool = createTool({
+      skus: async () => {
+        throw new Error('Cloud Billing Catalog request failed with 403');
+      },
+    });
+
+    const result = await tool({
+      resources: [
+        { type: 'disk', region: 'us-central1', diskType: 'pd-balanced', sizeGb: 200, count: 1 },
+      ],
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('failed with 403');
+  });
+});
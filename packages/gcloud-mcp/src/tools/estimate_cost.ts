@@ -0,0 +1,61 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { BillingCatalog } from '../billing_catalog.js';
+import { ResourceSpecSchema, estimateCosts } from '../cost_estimate.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createEstimateCost = (gcloud: GcloudExecutable, catalog: BillingCatalog) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'estimate_cost',
+      {
+        title: 'Estimate cost',
+        inputSchema: {
+          resources: z
+            .array(ResourceSpecSchema)
+            .min(1)
+            .describe('The planned resources. Many resources can be estimated in one call.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Estimates the monthly cost of planned resources from the public list prices in the Cloud Billing Catalog. Supports Compute Engine instances, persistent disks, GKE clusters, and Cloud SQL instances.
+
+Returns the total and, for each resource, the SKUs it was priced with, the monthly cost of each, and warnings for anything that could not be priced.
+
+## Instructions:
+- Use this tool to answer questions about what a deployment will cost before creating it.
+- Estimates are list prices in USD for 730 hours a month. They exclude taxes, sustained use discounts, negotiated discounts, network egress, and operations.
+- Show the user the warnings along with the total.`,
+      },
+      async ({ resources }) => {
+        const toolLogger = log.mcp('estimate_cost', { resources: resources.length });
+        try {
+          const estimate = await estimateCosts(gcloud, catalog, resources);
+          return successfulTextResult(JSON.stringify(estimate, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('estimate_cost failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
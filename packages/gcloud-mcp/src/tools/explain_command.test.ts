@@ -0,0 +1,153 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createExplainCommand, helpSection } from './explain_command.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const HELP = `NAME
+    gcloud compute instances create - create Compute Engine virtual machine
+        instances
+
+SYNOPSIS
+    gcloud compute instances create INSTANCE_NAMES [INSTANCE_NAMES ...]
+        [--machine-type=MACHINE_TYPE] [--zone=ZONE] [--async]
+
+DESCRIPTION
+    gcloud compute instances create facilitates the creation of Compute Engine
+    virtual machines.
+`;
+
+const createTool = (deny: string[] = []) => {
+  createExplainCommand(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const mockLint = (parsedCommand: string) => {
+  vi.mocked(mockedGcloud.lint).mockResolvedValue({ success: true, parsedCommand });
+};
+
+describe('helpSection', () => {
+  test('joins the lines of a section', () => {
+    expect(helpSection(HELP, 'SYNOPSIS')).toBe(
+      'gcloud compute instances create INSTANCE_NAMES [INSTANCE_NAMES ...] [--machine-type=MACHINE_TYPE] [--zone=ZONE] [--async]',
+    );
+  });
+
+  test('returns null for missing sections', () => {
+    expect(helpSection(HELP, 'EXAMPLES')).toBeNull();
+  });
+});
+
+describe('createExplainCommand', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(),
+    };
+  });
+
+  test('explains a mutating command without executing it', async () => {
+    const tool = createTool();
+    mockLint('compute instances create');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 0, stdout: HELP, stderr: '' });
+
+    const result = await tool({
+      args: ['compute', 'instances', 'create', 'vm-1', '--zone', 'us-east1-b', '--async'],
+    });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(['compute', 'instances', 'create', '--help']);
+    const explanation = JSON.parse(result.content[0].text);
+    expect(explanation).toEqual({
+      command: 'compute instances create',
+      summary: 'create Compute Engine virtual machine instances',
+      synopsis: expect.stringContaining('INSTANCE_NAMES'),
+      permitted: true,
+      flags: { '--zone': 'us-east1-b', '--async': true },
+      resources: ['vm-1'],
+      mutatesState: true,
+      implications: [
+        'Counts against the request quota of the compute API.',
+        'May provision billable compute resources.',
+      ],
+    });
+  });
+
+  test('flags unbounded read-only lists', async () => {
+    const tool = createTool();
+    mockLint('compute instances list');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 0, stdout: '', stderr: '' });
+
+    const result = await tool({ args: ['compute', 'instances', 'list'] });
+
+    const explanation = JSON.parse(result.content[0].text);
+    expect(explanation.mutatesState).toBe(false);
+    expect(explanation.implications).toContain(
+      'Lists without --limit or --filter may return large outputs.',
+    );
+  });
+
+  test('reports whether the command is permitted', async () => {
+    const tool = createTool(['compute instances delete']);
+    mockLint('compute instances delete');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 0, stdout: '', stderr: '' });
+
+    const result = await tool({ args: ['compute', 'instances', 'delete', 'vm-1'] });
+
+    const explanation = JSON.parse(result.content[0].text);
+    expect(explanation.permitted).toBe(false);
+    expect(explanation.implications).toContain('Deletion is irreversible and may remove data.');
+  });
+
+  test('still explains the command when help is unavailable', async () => {
+    const tool = createTool();
+    mockLint('foo frobnicate');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 1, stdout: '', stderr: 'error' });
+
+    const result = await tool({ args: ['foo', 'frobnicate'] });
+
+    const explanation = JSON.parse(result.content[0].text);
+    expect(explanation.summary).toBeNull();
+    expect(explanation.synopsis).toBeNull();
+    expect(explanation.mutatesState).toBe('unknown');
+  });
+
+  test('returns an error when the command cannot be parsed', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.lint).mockResolvedValue({
+      success: false,
+      error: 'Invalid choice',
+    });
+
+    const result = await tool({ args: ['compute', 'instancez'] });
+
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+    expect(result).toEqual({ content: [{ type: 'text', text: 'Invalid choice' }], isError: true });
+  });
+});
@@ -0,0 +1,248 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { apiFamilyOf } from '../rate_limiter.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export interface CommandExplanation {
+  command: string;
+  summary: string | null;
+  synopsis: string | null;
+  permitted: boolean;
+  flags: Record<string, string | true>;
+  resources: string[];
+  mutatesState: boolean | 'unknown';
+  implications: string[];
+}
+
+// Matched against the final command token, e.g. `list` or `get-iam-policy`.
+const READ_ONLY_VERB_PREFIXES = [
+  'list',
+  'describe',
+  'get',
+  'read',
+  'show',
+  'search',
+  'lookup',
+  'tail',
+  'print',
+  'explain',
+];
+const MUTATING_VERB_PREFIXES = [
+  'create',
+  'delete',
+  'update',
+  'set',
+  'add',
+  'remove',
+  'deploy',
+  'start',
+  'stop',
+  'reset',
+  'resize',
+  'enable',
+  'disable',
+  'patch',
+  'import',
+  'restore',
+  'cancel',
+  'apply',
+  'attach',
+  'detach',
+  'move',
+  'rollback',
+  'submit',
+  'clear',
+  'unset',
+];
+
+// API families whose create-style commands commonly provision billable resources.
+const BILLABLE_FAMILIES = [
+  'bigquery',
+  'compute',
+  'container',
+  'dataproc',
+  'filestore',
+  'functions',
+  'redis',
+  'run',
+  'spanner',
+  'sql',
+  'storage',
+];
+const PROVISIONING_VERB_PREFIXES = ['create', 'deploy', 'start', 'resize', 'submit', 'import'];
+
+const matchesPrefix = (verb: string, prefixes: string[]) =>
+  prefixes.some((prefix) => verb === prefix || verb.startsWith(`${prefix}-`));
+
+/** Returns whether a command verb, e.g. `list` or `add-tags`, changes state. */
+export const classifyMutation = (verb: string): boolean | 'unknown' => {
+  if (matchesPrefix(verb, READ_ONLY_VERB_PREFIXES)) {
+    return false;
+  }
+  if (matchesPrefix(verb, MUTATING_VERB_PREFIXES)) {
+    return true;
+  }
+  return 'unknown';
+};
+
+/** Returns the body of a section, e.g. SYNOPSIS, from `gcloud ... --help` output. */
+export const helpSection = (help: string, section: string): string | null => {
+  const lines = help.split('\n');
+  const start = lines.findIndex((line) => line.trim() === section);
+  if (start === -1) {
+    return null;
+  }
+  const body: string[] = [];
+  for (const line of lines.slice(start + 1)) {
+    if (/^[A-Z][A-Z ]+$/.test(line)) {
+      break;
+    }
+    body.push(line.trim());
+  }
+  return body.join(' ').replace(/\s+/g, ' ').trim() || null;
+};
+
+/**
+ * Splits arguments into flags and positionals.
+ *
+ * A flag takes the following argument as its value only if the help text
+ * documents it as `--flag=VALUE`; otherwise it is treated as boolean.
+ */
+const parseArgs = (args: string[], commandPath: string[], help: string) => {
+  const flags: Record<string, string | true> = {};
+  const positionals: string[] = [];
+  const remainingPath = [...commandPath];
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]!;
+    if (arg.startsWith('--')) {
+      const [name, ...value] = arg.split('=');
+      if (value.length > 0) {
+        flags[name!] = value.join('=');
+      } else if (help.includes(`${name}=`) && args[i + 1] !== undefined) {
+        flags[name!] = args[++i]!;
+      } else {
+        flags[name!] = true;
+      }
+    } else if (remainingPath[0] === arg) {
+      remainingPath.shift();
+    } else {
+      positionals.push(arg);
+    }
+  }
+  return { flags, positionals };
+};
+
+const implicationsOf = (
+  family: string,
+  verb: string,
+  mutatesState: boolean | 'unknown',
+  flags: Record<string, string | true>,
+): string[] => {
+  const implications = [`Counts against the request quota of the ${family} API.`];
+  if (verb.startsWith('delete')) {
+    implications.push('Deletion is irreversible and may remove data.');
+  }
+  if (family === 'services' && verb === 'enable') {
+    implications.push('Enabling an API may start billing for its usage.');
+  }
+  if (BILLABLE_FAMILIES.includes(family) && matchesPrefix(verb, PROVISIONING_VERB_PREFIXES)) {
+    implications.push(`May provision billable ${family} resources.`);
+  }
+  if (verb === 'list' && !flags['--limit'] && !flags['--filter']) {
+    implications.push('Lists without --limit or --filter may return large outputs.');
+  }
+  if (mutatesState === 'unknown') {
+    implications.push(
+      'Could not determine whether this command changes state; treat it as mutating.',
+    );
+  }
+  return implications;
+};
+
+/** Describes what a gcloud invocation would do without executing it. */
+export const explainCommand = async (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  args: string[],
+): Promise<CommandExplanation | { error: string }> => {
+  const lintResult = await gcloud.lint(args.join(' '));
+  if (!lintResult.success) {
+    return { error: lintResult.error };
+  }
+  const parsedCommand = lintResult.parsedCommand;
+  const commandPath = parsedCommand.split(' ');
+  const verb = commandPath[commandPath.length - 1] ?? '';
+  const family = apiFamilyOf(parsedCommand);
+
+  // --help prints the reference page and never calls the API.
+  const { code, stdout } = await gcloud.invoke([...commandPath, '--help']);
+  const help = code === 0 ? stdout : '';
+  const name = helpSection(help, 'NAME');
+
+  const { flags, positionals } = parseArgs(args, commandPath, help);
+  const mutatesState = classifyMutation(verb);
+  return {
+    command: parsedCommand,
+    summary: name?.split(' - ').slice(1).join(' - ') || null,
+    synopsis: helpSection(help, 'SYNOPSIS'),
+    permitted: acl.check(parsedCommand, args).permitted,
+    flags,
+    resources: positionals,
+    mutatesState,
+    implications: implicationsOf(family, verb, mutatesState, flags),
+  };
+};
+
+export const createExplainCommand = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'explain_command',
+      {
+        title: 'Explain gcloud command',
+        inputSchema: {
+          args: z.array(z.string()),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Explains what a gcloud command would do without executing it: its help summary and synopsis, the flags set, the resources it targets, whether it changes state, whether this server permits it, and rough cost and quota implications.
+
+## Instructions:
+- Use this tool to present a plan to the user before running commands that change state.
+- Pass the same args you would pass to run_gcloud_command.`,
+      },
+      async ({ args }) => {
+        const toolLogger = log.mcp('explain_command', args);
+        try {
+          const explanation = await explainCommand(gcloud, acl, args);
+          if ('error' in explanation) {
+            return errorTextResult(explanation.error);
+          }
+          return successfulTextResult(JSON.stringify(explanation, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('explain_command failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
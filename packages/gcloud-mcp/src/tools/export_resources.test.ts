@@ -0,0 +1,125 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { CommandHistory, createCommandHistory } from '../command_history.js';
+import { createExportResources } from './export_resources.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let history: CommandHistory;
+const run = vi.fn();
+
+const createTool = (deny: string[] = []) => {
+  createExportResources(mockedGcloud, createAccessControlList([], deny), run, history).register(
+    mockServer,
+  );
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createExportResources', () => {
+  let dir: string;
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+    dir = fs.mkdtempSync(path.join(os.tmpdir(), 'gcloud-mcp-'));
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: 'session-project\n', stderr: '' }),
+    };
+    run.mockImplementation(async (args: string[]) => {
+      fs.writeFileSync(path.join(dir, 'main.tf'), 'resource {}');
+      history.record(args, 0);
+      return successfulTextResult('');
+    });
+  });
+
+  test('exports the session project and returns the manifest', async () => {
+    const tool = createTool();
+
+    const result = await tool({ format: 'terraform', destination: dir });
+
+    expect(run).toHaveBeenCalledWith(
+      expect.arrayContaining(['bulk-export', '--project=session-project', `--path=${dir}`]),
+      undefined,
+      true,
+    );
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      fileCount: 1,
+      totalBytes: 11,
+      files: [{ path: 'main.tf', bytes: 11 }],
+    });
+  });
+
+  test('returns the output of a failed export', async () => {
+    run.mockImplementation(async (args: string[]) => {
+      history.record(args, 1);
+      return errorTextResult('Config Connector is not installed');
+    });
+    const tool = createTool();
+
+    const result = await tool({ project: 'p', format: 'krm', destination: dir });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('Config Connector is not installed');
+  });
+
+  test('returns an error for a relative destination', async () => {
+    const tool = createTool();
+
+    const result = await tool({ project: 'p', format: 'terraform', destination: 'export' });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('returns an error for several scopes', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      project: 'p',
+      folder: '123',
+      format: 'terraform',
+      destination: dir,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('returns an error if listing the bucket is denied', async () => {
+    const tool = createTool(['storage']);
+
+    const result = await tool({ project: 'p', format: 'terraform', destination: 'gs://b/x' });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud storage objects list"');
+  });
+});
@@ -0,0 +1,162 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import path from 'path';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { CommandHistory } from '../command_history.js';
+import {
+  ExportRequest,
+  ManifestFile,
+  RESOURCE_FORMATS,
+  exportArgs,
+  isStorageUrl,
+  localManifest,
+  storageManifest,
+} from '../resource_export.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner, runRecorded } from './run_gcloud_command.js';
+import { DESTRUCTIVE_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+// The manifest lists at most this many files; the counts cover all of them.
+export const MAX_MANIFEST_FILES = 500;
+
+export const createExportResources = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'export_resources',
+      {
+        title: 'Export resources as IaC',
+        inputSchema: {
+          project: z
+            .string()
+            .optional()
+            .describe('The project to export. Defaults to the session project.'),
+          folder: z.string().optional().describe('The folder ID to export instead of a project.'),
+          organization: z
+            .string()
+            .optional()
+            .describe('The organization ID to export instead of a project.'),
+          resourceTypes: z
+            .array(z.string())
+            .optional()
+            .describe(
+              'The KRM kinds to export, e.g. ["ComputeInstance", "StorageBucket"]. Exports every supported kind if omitted.',
+            ),
+          format: z
+            .enum(RESOURCE_FORMATS)
+            .default('terraform')
+            .describe('Whether to export Terraform (HCL) or Config Connector (KRM YAML) config.'),
+          destination: z
+            .string()
+            .describe('An absolute local directory, or a gs:// URL to export to Cloud Storage.'),
+        },
+        annotations: DESTRUCTIVE_TOOL,
+        description: `Exports existing resources of a project, folder or organization as Terraform or Config Connector (KRM) config with 'gcloud beta resource-config bulk-export', and returns a manifest of the files written.
+
+## Instructions:
+- Use this tool to capture what already exists as infrastructure as code, e.g. before adopting Terraform for a project.
+- Restrict "resourceTypes" to what the user asked for; run 'gcloud beta resource-config list-resource-types' to find the kind of a resource.
+- A local destination is on the machine that runs gcloud. Exporting to an existing local directory may overwrite files in it.
+- The export needs the Cloud Asset API and the config-connector gcloud component. Large projects can take several minutes.`,
+      },
+      async ({ project, folder, organization, resourceTypes, format, destination }) => {
+        const toolLogger = log.mcp('export_resources', {
+          project,
+          folder,
+          organization,
+          resourceTypes,
+          format,
+          destination,
+        });
+        if ([project, folder, organization].filter(Boolean).length > 1) {
+          return errorTextResult('Pass at most one of project, folder and organization.');
+        }
+        const storage = isStorageUrl(destination);
+        if (!storage && !path.isAbsolute(destination)) {
+          return errorTextResult(
+            `The destination must be an absolute path or a gs:// URL: ${destination}`,
+          );
+        }
+        if (storage && !acl.check('storage objects list').permitted) {
+          return errorTextResult(
+            'Exporting to Cloud Storage requires "gcloud storage objects list", which is not permitted.',
+          );
+        }
+        try {
+          let scope: ExportRequest['scope'];
+          if (folder) {
+            scope = { folder };
+          } else if (organization) {
+            scope = { organization };
+          } else {
+            const id = project ?? (await sessionProject(gcloud));
+            if (!id) {
+              return errorTextResult(
+                'No project is set. Pass a project or set one with set_context.',
+              );
+            }
+            scope = { project: id };
+          }
+          const request = { scope, format, resourceTypes: resourceTypes ?? [], destination };
+          const args = exportArgs(request);
+          const { exitCode, output } = await runRecorded(run, history, args);
+          if (exitCode !== 0) {
+            return errorTextResult(output);
+          }
+
+          const files: ManifestFile[] = storage
+            ? await storageManifest(gcloud, destination)
+            : localManifest(destination);
+          toolLogger.info('export_resources exported files', { files: files.length });
+          return successfulTextResult(
+            JSON.stringify(
+              {
+                command: `gcloud ${args.join(' ')}`,
+                destination,
+                format,
+                fileCount: files.length,
+                totalBytes: files.reduce((sum, { bytes }) => sum + bytes, 0),
+                files: files.slice(0, MAX_MANIFEST_FILES),
+                ...(files.length > MAX_MANIFEST_FILES && {
+                  note: `Only the first ${MAX_MANIFEST_FILES} of ${files.length} files are listed.`,
+                }),
+              },
+              null,
+              2,
+            ),
+          );
+        } catch (e: unknown) {
+          toolLogger.error(
+            'export_resources failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { BillingCatalog } from '../billing_catalog.js';
+import { createFindIdleResources } from './find_idle_resources.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const catalog: BillingCatalog = { skus: vi.fn().mockResolvedValue([]) };
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (deny: string[] = []) => {
+  createFindIdleResources(mockedGcloud, createAccessControlList([], deny), catalog).register(
+    mockServer,
+  );
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createFindIdleResources', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: '[]', stderr: '' }),
+    };
+  });
+
+  test('scans the session project', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke).mockResolvedValueOnce({
+      code: 0,
+      stdout: 'my-project\n',
+      stderr: '',
+    });
+
+    const result = await tool({ checks: ['unattached-disks'], snapshotAgeDays: 90 });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      projects: ['my-project'],
+      findings: [],
+      skipped: [],
+    });
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(
+      expect.arrayContaining(['compute', 'disks', 'list', '--project=my-project']),
+    );
+  });
+
+  test('skips checks that are not permitted', async () => {
+    const tool = createTool(['storage']);
+
+    const result = await tool({
+      projects: ['p'],
+      checks: ['unused-addresses', 'empty-buckets'],
+      snapshotAgeDays: 90,
+    });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({ skipped: ['empty-buckets'] });
+    expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+  });
+
+  test('returns an error if no check is permitted', async () => {
+    const tool = createTool(['compute']);
+
+    const result = await tool({
+      projects: ['p'],
+      checks: ['unattached-disks'],
+      snapshotAgeDays: 90,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud compute disks list"');
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,104 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { BillingCatalog } from '../billing_catalog.js';
+import { IDLE_CHECKS, IDLE_CHECK_COMMANDS, findIdleResources } from '../idle_resources.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createFindIdleResources = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  catalog: BillingCatalog,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'find_idle_resources',
+      {
+        title: 'Find idle resources',
+        inputSchema: {
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The projects to scan. Defaults to the session project.'),
+          checks: z
+            .array(z.enum(IDLE_CHECKS))
+            .optional()
+            .describe('The checks to run. Defaults to all of them.'),
+          snapshotAgeDays: z
+            .number()
+            .int()
+            .min(1)
+            .default(90)
+            .describe('Snapshots older than this many days are reported as stale.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Scans projects for resources that cost money without being used: unattached persistent disks, reserved external IP addresses that are not in use, VMs and Cloud SQL instances Recommender reports as idle, stale snapshots and empty Cloud Storage buckets. Each finding has its estimated monthly waste at list prices and the command that cleans it up.
+
+## Instructions:
+- Use this tool for FinOps reviews and cost clean-ups.
+- Nothing is deleted or stopped. Review the findings with the user before running any cleanup command with run_gcloud_command.
+- Checks that fail, e.g. because an API is not enabled in a project, are listed under errors. Checks that are not permitted are listed under skipped.`,
+      },
+      async ({ projects, checks = [...IDLE_CHECKS], snapshotAgeDays }) => {
+        const toolLogger = log.mcp('find_idle_resources', { projects, checks, snapshotAgeDays });
+        const permitted = checks.filter((check) =>
+          IDLE_CHECK_COMMANDS[check].every((command) => acl.check(command).permitted),
+        );
+        const skipped = checks.filter((check) => !permitted.includes(check));
+        if (permitted.length === 0) {
+          return errorTextResult(
+            `None of the requested checks are permitted. They require ${skipped
+              .flatMap((check) => IDLE_CHECK_COMMANDS[check])
+              .map((c) => `"gcloud ${c}"`)
+              .join(', ')}.`,
+          );
+        }
+        try {
+          let targets = projects ?? [];
+          if (targets.length === 0) {
+            const project = await sessionProject(gcloud);
+            if (!project) {
+              return errorTextResult(
+                'No project is set. Pass projects or set one with set_context.',
+              );
+            }
+            targets = [project];
+          }
+          const report = await findIdleResources(gcloud, catalog, {
+            projects: targets,
+            checks: permitted,
+            snapshotAgeDays,
+          });
+          return successfulTextResult(JSON.stringify({ ...report, skipped }, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'find_idle_resources failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,195 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createSessionContext, SessionContext } from '../session_context.js';
+import { createGcloudContext } from './gcloud_context.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (
+  allow: string[] = [],
+  deny: string[] = [],
+  session: SessionContext = createSessionContext(),
+) => {
+  createGcloudContext(
+    mockedGcloud,
+    session,
+    createAccessControlList(allow, deny),
+    ['gcloud'],
+  ).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledTimes(2);
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const getContextTool = () => {
+  createTool();
+  return (mockServer.registerTool as Mock).mock.calls[1]![2];
+};
+
+const mockGcloudOutputs = (outputs: Record<string, { code?: number; stdout: string }>) => {
+  vi.mocked(mockedGcloud.invoke).mockImplementation(async (args: string[]) => {
+    const output = outputs[args.slice(0, 2).join(' ')];
+    if (!output) {
+      return { code: 1, stdout: '', stderr: 'unexpected command' };
+    }
+    return { code: output.code ?? 0, stdout: output.stdout, stderr: '' };
+  });
+};
+
+describe('createGcloudContext', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(),
+    };
+  });
+
+  test('returns account, project, region, zone and key APIs', async () => {
+    const tool = createTool();
+    mockGcloudOutputs({
+      'config list': {
+        stdout: JSON.stringify({
+          core: { account: 'me@example.com', project: 'my-project' },
+          compute: { region: 'us-central1', zone: 'us-central1-a' },
+        }),
+      },
+      'projects describe': { stdout: JSON.stringify({ projectNumber: '1234' }) },
+      'services list': {
+        stdout: JSON.stringify([
+          { config: { name: 'compute.googleapis.com' } },
+          { config: { name: 'oslogin.googleapis.com' } },
+        ]),
+      },
+    });
+
+    const result = await tool({});
+
+    expect(result.isError).toBeUndefined();
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      account: 'me@example.com',
+      project: { id: 'my-project', number: '1234' },
+      region: 'us-central1',
+      zone: 'us-central1-a',
+      sessionOverrides: {},
+      allowedProjects: [],
+      releaseTracks: { ga: 'allowed', beta: 'allowed', alpha: 'allowed', preview: 'allowed' },
+      toolsets: ['gcloud'],
+      enabledApis: { key: ['compute.googleapis.com'], totalEnabled: 2 },
+    });
+  });
+
+  test('returns nulls when no project is configured', async () => {
+    const tool = createTool();
+    mockGcloudOutputs({
+      'config list': { stdout: JSON.stringify({ core: { account: 'me@example.com' } }) },
+    });
+
+    const result = await tool({});
+
+    const context = JSON.parse(result.content[0].text);
+    expect(context.project).toBeNull();
+    expect(context.region).toBeNull();
+    expect(context.enabledApis).toBeNull();
+    expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+  });
+
+  test('keeps the project when the service listing fails', async () => {
+    const tool = createTool();
+    mockGcloudOutputs({
+      'config list': { stdout: JSON.stringify({ core: { project: 'my-project' } }) },
+      'projects describe': { stdout: JSON.stringify({ projectNumber: '1234' }) },
+    });
+
+    const result = await tool({});
+
+    const context = JSON.parse(result.content[0].text);
+    expect(context.project).toEqual({ id: 'my-project', number: '1234' });
+    expect(context.enabledApis).toBeNull();
+  });
+
+  test('reports the session overrides and the projects that may be selected', async () => {
+    const session = createSessionContext(['dev', 'staging']);
+    session.update({ project: 'staging' });
+    const tool = createTool([], [], session);
+    mockGcloudOutputs({
+      'config list': { stdout: JSON.stringify({ compute: { zone: 'us-east1-b' } }) },
+    });
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      zone: 'us-east1-b',
+      sessionOverrides: { project: 'staging' },
+      allowedProjects: ['dev', 'staging'],
+    });
+  });
+
+  test('reports the release track policy from the access control list', async () => {
+    const tool = createTool([], ['alpha']);
+    mockGcloudOutputs({ 'config list': { stdout: '{}' } });
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text).releaseTracks).toEqual({
+      ga: 'allowed',
+      beta: 'allowed',
+      alpha: 'denied',
+      preview: 'allowed',
+    });
+  });
+
+  test('returns error when the gcloud config cannot be read', async () => {
+    const tool = createTool();
+    mockGcloudOutputs({ 'config list': { code: 1, stdout: '' } });
+
+    const result = await tool({});
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('gcloud config list --format=json failed');
+  });
+
+  test('get_context returns the session configuration only', async () => {
+    const tool = getContextTool();
+    mockGcloudOutputs({
+      'config list': {
+        stdout: JSON.stringify({ core: { account: 'me@example.com', project: 'my-project' } }),
+      },
+    });
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      account: 'me@example.com',
+      project: 'my-project',
+      region: null,
+      zone: null,
+      sessionOverrides: {},
+      allowedProjects: [],
+    });
+    expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+  });
+});
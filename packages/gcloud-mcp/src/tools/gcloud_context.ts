@@ -0,0 +1,218 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { GcloudExecutable } from '../gcloud.js';
+import { invokeJson } from '../gcloud_json.js';
+import { AccessControlList, PRERELEASE_TRACKS_PRIORITIZED } from '../denylist.js';
+import { SessionContext, SessionContextValues } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+// APIs worth reporting on because agents commonly need them. Everything else
+// enabled on the project is summarized as a count.
+export const KEY_APIS = [
+  'bigquery.googleapis.com',
+  'cloudbuild.googleapis.com',
+  'cloudfunctions.googleapis.com',
+  'compute.googleapis.com',
+  'container.googleapis.com',
+  'iam.googleapis.com',
+  'logging.googleapis.com',
+  'monitoring.googleapis.com',
+  'pubsub.googleapis.com',
+  'run.googleapis.com',
+  'secretmanager.googleapis.com',
+  'sqladmin.googleapis.com',
+  'storage.googleapis.com',
+];
+
+/** The gcloud configuration commands of the session run with. */
+export interface SessionConfig {
+  account: string | null;
+  project: string | null;
+  region: string | null;
+  zone: string | null;
+  /** The values set for this session, which take precedence over the gcloud configuration. */
+  sessionOverrides: SessionContextValues;
+  /** Empty if any project may be selected. */
+  allowedProjects: string[];
+}
+
+export interface GcloudContext {
+  account: string | null;
+  project: { id: string; number: string | null } | null;
+  region: string | null;
+  zone: string | null;
+  sessionOverrides: SessionContextValues;
+  allowedProjects: string[];
+  releaseTracks: Record<string, string>;
+  toolsets: string[];
+  enabledApis: { key: string[]; totalEnabled: number } | null;
+}
+
+interface GcloudConfig {
+  core?: { account?: string; project?: string };
+  compute?: { region?: string; zone?: string };
+}
+
+const releaseTrackPolicy = (acl: AccessControlList): Record<string, string> => {
+  const { allow, deny } = acl.lists();
+  return Object.fromEntries(
+    ['ga', ...PRERELEASE_TRACKS_PRIORITIZED].map((track) => {
+      if (deny.includes(track)) {
+        return [track, 'denied'];
+      }
+      if (allow.length > 0) {
+        return [track, 'allowlisted commands only'];
+      }
+      return [track, 'allowed'];
+    }),
+  );
+};
+
+/**
+ * Returns the configuration commands of the session run with. gcloud reports
+ * the session overrides as its properties, so the values are the effective ones.
+ */
+export const getSessionConfig = async (
+  gcloud: GcloudExecutable,
+  session: SessionContext,
+): Promise<SessionConfig> => {
+  const config = (await invokeJson(gcloud, ['config', 'list', '--format=json'])) as GcloudConfig;
+  return {
+    account: config.core?.account ?? null,
+    project: config.core?.project ?? null,
+    region: config.compute?.region ?? null,
+    zone: config.compute?.zone ?? null,
+    sessionOverrides: session.get(),
+    allowedProjects: session.allowedProjects(),
+  };
+};
+
+/** Collects the effective execution context with as few gcloud calls as possible. */
+export const getGcloudContext = async (
+  gcloud: GcloudExecutable,
+  session: SessionContext,
+  acl: AccessControlList,
+  toolsets: string[],
+): Promise<GcloudContext> => {
+  const config = await getSessionConfig(gcloud, session);
+  const projectId = config.project;
+
+  let project: GcloudContext['project'] = null;
+  let enabledApis: GcloudContext['enabledApis'] = null;
+  if (projectId) {
+    // The project lookup and service listing are independent, so run them together.
+    const [described, services] = await Promise.allSettled([
+      invokeJson(gcloud, ['projects', 'describe', projectId, '--format=json(projectNumber)']),
+      invokeJson(gcloud, [
+        'services',
+        'list',
+        '--enabled',
+        '--project',
+        projectId,
+        '--format=json(config.name)',
+      ]),
+    ]);
+    project = {
+      id: projectId,
+      number:
+        described.status === 'fulfilled'
+          ? ((described.value as { projectNumber?: string }).projectNumber ?? null)
+          : null,
+    };
+    if (services.status === 'fulfilled') {
+      const names = (services.value as Array<{ config?: { name?: string } }>)
+        .map((s) => s.config?.name)
+        .filter((name): name is string => !!name);
+      enabledApis = {
+        key: KEY_APIS.filter((api) => names.includes(api)),
+        totalEnabled: names.length,
+      };
+    }
+  }
+
+  return {
+    ...config,
+    project,
+    releaseTracks: releaseTrackPolicy(acl),
+    toolsets,
+    enabledApis,
+  };
+};
+
+export const createGcloudContext = (
+  gcloud: GcloudExecutable,
+  session: SessionContext,
+  acl: AccessControlList,
+  toolsets: string[],
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'gcloud_context',
+      {
+        title: 'Get gcloud context',
+        inputSchema: {},
+        annotations: READ_ONLY_TOOL,
+        description: `Returns the effective gcloud execution context in a single call: the active account, the default project (with its number), the default region and zone, the values overridden for this session, the projects that may be selected, the release track policy, the enabled toolsets, and which key APIs are enabled on the project.
+
+## Instructions:
+- Call this tool once at the start of a conversation instead of running several 'gcloud config' or 'gcloud projects' commands.
+- Call it again to check which project a command will run in before changing anything.
+- A null value means the setting is not configured.`,
+      },
+      async () => {
+        const toolLogger = log.mcp('gcloud_context', {});
+        try {
+          const context = await getGcloudContext(gcloud, session, acl, toolsets);
+          return successfulTextResult(JSON.stringify(context, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('gcloud_context failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    // The session part of gcloud_context, without its project lookups.
+    server.registerTool(
+      'get_context',
+      {
+        title: 'Get session context',
+        inputSchema: {},
+        annotations: READ_ONLY_TOOL,
+        description: `Returns the account, project, region and zone commands in this session run with, the values overridden for the session, and the projects that may be selected. Same as gcloud_context without the project number, release tracks, toolsets and APIs.
+
+## Instructions:
+- Use this tool to check which project a command will run in before changing anything.
+- A null value means the setting is not configured.`,
+      },
+      async () => {
+        const toolLogger = log.mcp('get_context', {});
+        try {
+          const config = await getSessionConfig(gcloud, session);
+          return successfulTextResult(JSON.stringify(config, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('get_context failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
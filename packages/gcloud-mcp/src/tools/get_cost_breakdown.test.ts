@@ -0,0 +1,69 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from '../google_api.js';
+import { createGetCostBreakdown } from './get_cost_breakdown.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const api: GoogleApiClient = { get: vi.fn(), post: vi.fn() };
+
+const createTool = () => {
+  createGetCostBreakdown(api, { table: 'p.billing.export' }).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createGetCostBreakdown', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('returns the breakdown as JSON', async () => {
+    const tool = createTool();
+    vi.mocked(api.post).mockResolvedValue({
+      jobComplete: true,
+      rows: [{ f: [{ v: 'p1' }, { v: 'USD' }, { v: '12' }, { v: '8' }] }],
+    });
+
+    const result = await tool({
+      groupBy: 'project',
+      days: 7,
+      endDate: '2025-01-15T00:00:00Z',
+      limit: 20,
+    });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      groupBy: 'project',
+      periods: { current: { end: '2025-01-15T00:00:00.000Z' } },
+      groups: [{ key: 'p1', delta: 4, deltaPercent: 50 }],
+    });
+  });
+
+  test('returns an error if the query fails', async () => {
+    const tool = createTool();
+    vi.mocked(api.post).mockRejectedValue(new Error('Access Denied: Table p:billing.export'));
+
+    const result = await tool({ groupBy: 'service', days: 7, limit: 20 });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('Access Denied');
+  });
+});
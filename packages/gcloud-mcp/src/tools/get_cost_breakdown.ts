@@ -0,0 +1,85 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GoogleApiClient } from '../google_api.js';
+import { BillingExportConfig, getCostBreakdown } from '../billing_export.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createGetCostBreakdown = (api: GoogleApiClient, config: BillingExportConfig) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'get_cost_breakdown',
+      {
+        title: 'Get cost breakdown',
+        inputSchema: {
+          groupBy: z
+            .enum(['project', 'service', 'sku', 'label'])
+            .default('service')
+            .describe('How to group costs.'),
+          labelKey: z.string().optional().describe('The label to group by, with groupBy "label".'),
+          days: z
+            .number()
+            .int()
+            .min(1)
+            .max(90)
+            .default(7)
+            .describe('The length of each period in days.'),
+          endDate: z
+            .string()
+            .datetime({ offset: true })
+            .optional()
+            .describe('The exclusive end of the current period as an RFC 3339 timestamp.'),
+          projects: z.array(z.string()).optional().describe('Only include these projects.'),
+          limit: z.number().int().min(1).default(20).describe('The maximum number of groups.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Returns net spend, after credits, from the Cloud Billing export in BigQuery, grouped by project, service, SKU, or label, for a period and the period of the same length before it.
+
+Groups are sorted by the absolute change between the periods, so the first groups are what drove a cost increase or decrease.
+
+## Instructions:
+- Use this tool to answer questions like "what drove last week's cost increase" instead of writing SQL.
+- For a drill-down, start with groupBy "service" and then group by "sku" or "project".
+- Billing export data can lag by several hours, so the most recent costs may be incomplete.`,
+      },
+      async ({ groupBy, labelKey, days, endDate, projects, limit }) => {
+        const toolLogger = log.mcp('get_cost_breakdown', { groupBy, labelKey, days, endDate });
+        try {
+          const breakdown = await getCostBreakdown(api, config, {
+            groupBy,
+            days,
+            limit,
+            ...(labelKey && { labelKey }),
+            ...(endDate && { end: new Date(endDate) }),
+            ...(projects && { projects }),
+          });
+          return successfulTextResult(JSON.stringify(breakdown, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'get_cost_breakdown failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,58 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createOutputChunks } from '../output_chunks.js';
+import { createGetOutputChunk } from './get_output_chunk.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const createTool = () => {
+  const outputChunks = createOutputChunks({ chunkKb: 1 });
+  createGetOutputChunk(outputChunks).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return { outputChunks, tool: (mockServer.registerTool as Mock).mock.calls[0]![2] };
+};
+
+describe('createGetOutputChunk', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('returns a chunk with its metadata', async () => {
+    const { outputChunks, tool } = createTool();
+    outputChunks.store('a'.repeat(1024) + 'b'.repeat(10));
+
+    const result = await tool({ token: 'output-1', chunk: 1 });
+
+    expect(result.isError).toBeUndefined();
+    expect(result.content[0].text).toMatch(/^b{10}\nOUTPUT CHUNK:\n/);
+    expect(result.content[0].text).toContain('"totalBytes": 1034');
+    expect(result.content[0].text).not.toContain('nextChunk');
+  });
+
+  test('returns an error for unknown chunks', async () => {
+    const { tool } = createTool();
+
+    const result = await tool({ token: 'output-1', chunk: 0 });
+
+    expect(result.isError).toBe(true);
+    expect(JSON.parse(result.content[0].text)).toMatchObject({ error: 'CHUNK_NOT_FOUND' });
+  });
+});
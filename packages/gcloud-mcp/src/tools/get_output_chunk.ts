@@ -0,0 +1,64 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { OutputChunks, chunkBlock } from '../output_chunks.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createGetOutputChunk = (outputChunks: OutputChunks) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'get_output_chunk',
+      {
+        title: 'Get output chunk',
+        inputSchema: {
+          token: z.string().describe('The token of the OUTPUT CHUNK block, e.g. output-1.'),
+          chunk: z.number().int().min(0).describe('The zero-based index of the chunk.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Returns a chunk of a gcloud command output that was too large to return at once.
+
+## Instructions:
+- Use this tool when a result ends with an OUTPUT CHUNK block that has a "nextChunk".
+- Pass the "token" of the block and the "nextChunk" as "chunk".
+- Every chunk ends with an OUTPUT CHUNK block with the total size of the output.
+- Before retrieving many chunks, consider running the command again with --filter, --limit or a --format projection to reduce the output.
+- Only the outputs of recent commands of this session are kept.`,
+      },
+      async ({ token, chunk }) => {
+        const toolLogger = log.mcp('get_output_chunk', { token, chunk });
+        const outputChunk = outputChunks.get(token, chunk);
+        if (!outputChunk) {
+          toolLogger.warn('get_output_chunk found no chunk');
+          return errorTextResult(
+            JSON.stringify(
+              {
+                error: 'CHUNK_NOT_FOUND',
+                message: `There is no chunk ${chunk} of "${token}". The output may have expired; run the command again.`,
+              },
+              null,
+              2,
+            ),
+          );
+        }
+        return successfulTextResult(outputChunk.text + chunkBlock(outputChunk));
+      },
+    );
+  },
+});
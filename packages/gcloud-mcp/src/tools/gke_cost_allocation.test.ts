@@ -0,0 +1,70 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from '../google_api.js';
+import { createGkeCostAllocation } from './gke_cost_allocation.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const api: GoogleApiClient = { get: vi.fn(), post: vi.fn() };
+
+const createTool = () => {
+  createGkeCostAllocation(api, { table: 'p.billing.export' }).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createGkeCostAllocation', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('returns the report as JSON', async () => {
+    const tool = createTool();
+    vi.mocked(api.post).mockResolvedValue({
+      jobComplete: true,
+      rows: [{ f: [{ v: 'prod' }, { v: 'shop' }, { v: 'web' }, { v: 'USD' }, { v: '12' }] }],
+    });
+
+    const result = await tool({
+      days: 30,
+      endDate: '2025-01-31T00:00:00Z',
+      workloadLabel: 'app',
+      utilizationThreshold: 0.5,
+      limit: 50,
+    });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      period: { end: '2025-01-31T00:00:00.000Z' },
+      total: 12,
+      workloads: [{ cluster: 'prod', namespace: 'shop', workload: 'web', cost: 12 }],
+    });
+  });
+
+  test('returns an error if the query fails', async () => {
+    const tool = createTool();
+    vi.mocked(api.post).mockRejectedValue(new Error('Access Denied: Table p:billing.export'));
+
+    const result = await tool({ days: 30, utilizationThreshold: 0.5, limit: 50 });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('Access Denied');
+  });
+});
@@ -0,0 +1,102 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GoogleApiClient } from '../google_api.js';
+import { BillingExportConfig } from '../billing_export.js';
+import { getGkeCosts } from '../gke_costs.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createGkeCostAllocation = (api: GoogleApiClient, config: BillingExportConfig) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'gke_cost_allocation',
+      {
+        title: 'Get GKE cost allocation',
+        inputSchema: {
+          days: z
+            .number()
+            .int()
+            .min(1)
+            .max(90)
+            .default(30)
+            .describe('The length of the period in days.'),
+          endDate: z
+            .string()
+            .datetime({ offset: true })
+            .optional()
+            .describe('The exclusive end of the period as an RFC 3339 timestamp.'),
+          projects: z.array(z.string()).optional().describe('Only include these projects.'),
+          cluster: z.string().optional().describe('Only include this cluster.'),
+          workloadLabel: z
+            .string()
+            .optional()
+            .describe(
+              'The pod label that names workloads, like "app". Omit to group by namespace.',
+            ),
+          utilizationThreshold: z
+            .number()
+            .min(0)
+            .max(1)
+            .default(0.5)
+            .describe(
+              'Flag workloads using less than this fraction of their CPU or memory requests.',
+            ),
+          limit: z.number().int().min(1).default(50).describe('The maximum number of workloads.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Returns GKE spend from the Cloud Billing export in BigQuery, broken down by cluster, namespace, and workload using GKE cost allocation, with the fraction of requested CPU and memory each workload actually used from GKE usage metering.
+
+Workloads using less than utilizationThreshold of their CPU or memory requests are flagged as overprovisioned.
+
+## Instructions:
+- Use this tool to answer questions like "which namespaces cost the most" or "which workloads request more than they use".
+- Costs are only labeled for clusters with GKE cost allocation enabled. Utilization needs GKE usage metering with resource consumption metering and "billingExport.gkeUsageMeteringDataset"; read the notes in the result when it is null.
+- Lowering the requests of an overprovisioned workload reduces cost only if the nodes are scaled down in turn, for example by the cluster autoscaler.`,
+      },
+      async ({ days, endDate, projects, cluster, workloadLabel, utilizationThreshold, limit }) => {
+        const toolLogger = log.mcp('gke_cost_allocation', {
+          days,
+          endDate,
+          cluster,
+          workloadLabel,
+        });
+        try {
+          const report = await getGkeCosts(api, config, {
+            days,
+            utilizationThreshold,
+            limit,
+            ...(endDate && { end: new Date(endDate) }),
+            ...(projects && { projects }),
+            ...(cluster && { cluster }),
+            ...(workloadLabel && { workloadLabel }),
+          });
+          return successfulTextResult(JSON.stringify(report, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'gke_cost_allocation failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
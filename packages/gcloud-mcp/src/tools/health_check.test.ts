@@ -0,0 +1,71 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createCommandHistory } from '../command_history.js';
+import { createHealthCheck } from './health_check.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = () => {
+  createHealthCheck(mockedGcloud, {
+    serverVersion: '1.2.3',
+    history: createCommandHistory(),
+  }).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createHealthCheck', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: '[]', stderr: '' }),
+    };
+  });
+
+  test('returns the health report as JSON', async () => {
+    const tool = createTool();
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      serverVersion: '1.2.3',
+      apis: { 'cloudresourcemanager.googleapis.com': { ok: true } },
+    });
+  });
+
+  test('reports gcloud that can not be run', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke).mockRejectedValue(new Error('spawn gcloud ENOENT'));
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      status: 'degraded',
+      gcloud: { ok: false, error: 'spawn gcloud ENOENT', version: null },
+    });
+  });
+});
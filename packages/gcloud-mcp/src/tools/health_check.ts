@@ -0,0 +1,51 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { GcloudExecutable } from '../gcloud.js';
+import { HealthSources, checkHealth } from '../health.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createHealthCheck = (gcloud: GcloudExecutable, sources: HealthSources) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'health_check',
+      {
+        title: 'Check server health',
+        inputSchema: {},
+        annotations: READ_ONLY_TOOL,
+        description: `Reports the health of the gcloud MCP server itself: the gcloud version, whether the active account has valid credentials, whether Google Cloud APIs are reachable, the billing catalog cache, commands queued by rate limits, and commands that failed in the last hour.
+
+## Instructions:
+- Use this tool when commands fail in unexpected ways, to tell problems with the server or its credentials apart from problems with the command.
+- A "degraded" status names the failing probe. Report its error to the user instead of retrying commands.`,
+      },
+      async () => {
+        const toolLogger = log.mcp('health_check', {});
+        try {
+          const report = await checkHealth(gcloud, sources);
+          return successfulTextResult(JSON.stringify(report, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('health_check failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,144 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { GoogleApiClient } from '../google_api.js';
+import { createIamPolicyTools } from './iam_policy.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let run: GcloudCommandRunner;
+const api: GoogleApiClient = { get: vi.fn(), post: vi.fn() };
+
+const createTool = (name: string) => {
+  createIamPolicyTools(run, api).register(mockServer);
+  return (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+};
+
+const mockOutput = (text: string, isError?: boolean) =>
+  vi.mocked(run).mockResolvedValue({
+    content: [{ type: 'text', text }],
+    ...(isError && { isError }),
+  });
+
+describe('createIamPolicyTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    run = vi.fn();
+  });
+
+  test('gets the policy of a bucket through the command runner', async () => {
+    const tool = createTool('get_iam_policy');
+    mockOutput(JSON.stringify({ bindings: [{ role: 'roles/viewer', members: ['user:a'] }] }));
+
+    const result = await tool({ resource: 'gs://b' });
+
+    expect(run).toHaveBeenCalledWith([
+      'storage',
+      'buckets',
+      'get-iam-policy',
+      'gs://b',
+      '--format=json',
+    ]);
+    expect(result.structuredContent).toEqual({
+      resource: '//storage.googleapis.com/b',
+      etag: null,
+      bindings: [{ role: 'roles/viewer', members: ['user:a'], condition: null }],
+      rolesByMember: { 'user:a': ['roles/viewer'] },
+    });
+  });
+
+  test('rejects unsupported resources', async () => {
+    const tool = createTool('get_iam_policy');
+
+    const result = await tool({ resource: 'instances/vm-1' });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('returns command errors', async () => {
+    const tool = createTool('get_iam_policy');
+    mockOutput('PERMISSION_DENIED', true);
+
+    const result = await tool({ resource: 'projects/p' });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toBe('PERMISSION_DENIED');
+  });
+
+  test('finds who has a permission on a resource', async () => {
+    const tool = createTool('who_has_role');
+    mockOutput(
+      JSON.stringify({
+        mainAnalysis: {
+          analysisResults: [
+            {
+              attachedResourceFullName: '//storage.googleapis.com/b',
+              iamBinding: { role: 'roles/storage.admin', members: ['user:a'] },
+            },
+          ],
+        },
+      }),
+    );
+
+    const result = await tool({
+      scope: 'projects/p',
+      resource: 'gs://b',
+      permission: 'storage.buckets.delete',
+      expandGroups: false,
+    });
+
+    expect(run).toHaveBeenCalledWith([
+      'asset',
+      'analyze-iam-policy',
+      '--project=p',
+      '--full-resource-name=//storage.googleapis.com/b',
+      '--permissions=storage.buckets.delete',
+      '--format=json',
+    ]);
+    expect(result.structuredContent).toMatchObject({ principals: ['user:a'], fullyExplored: true });
+  });
+
+  test('requires a role or a permission', async () => {
+    const tool = createTool('who_has_role');
+
+    const result = await tool({ scope: 'projects/p', expandGroups: false });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('checks permissions of the active account', async () => {
+    const tool = createTool('check_permission');
+    vi.mocked(api.post).mockResolvedValue({ permissions: ['iam.serviceAccounts.actAs'] });
+
+    const result = await tool({
+      resource: 'sa@p.iam.gserviceaccount.com',
+      permissions: ['iam.serviceAccounts.actAs'],
+    });
+
+    expect(api.post).toHaveBeenCalledWith(
+      'https://iam.googleapis.com/v1/projects/-/serviceAccounts/sa@p.iam.gserviceaccount.com:testIamPermissions',
+      { permissions: ['iam.serviceAccounts.actAs'] },
+    );
+    expect(result.structuredContent).toMatchObject({ allGranted: true, denied: [] });
+  });
+});
@@ -0,0 +1,182 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GoogleApiClient } from '../google_api.js';
+import {
+  AccessAnalysisSchema,
+  IamPolicySummarySchema,
+  RESOURCE_FORMATS,
+  analyzeIamPolicyArgs,
+  fullResourceName,
+  getIamPolicyArgs,
+  parseIamResource,
+  summarizeAnalysis,
+  summarizePolicy,
+  testPermissions,
+} from '../iam_policy.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner, runJsonCommand } from './run_gcloud_command.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const resourceSchema = z.string().describe(`The resource: ${RESOURCE_FORMATS}.`);
+
+const structuredResult = (structuredContent: Record<string, unknown>) => ({
+  ...successfulTextResult(JSON.stringify(structuredContent, null, 2)),
+  structuredContent,
+});
+
+const failed = (tool: string, e: unknown) => {
+  log.mcp(tool).error(`${tool} failed`, e instanceof Error ? e : new Error(String(e)));
+  const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+  return errorTextResult(msg);
+};
+
+/**
+ * Tools answering who has access to what. Policies are read and analyzed
+ * through the command runner, so they are checked against the access control
+ * list like every other command.
+ */
+export const createIamPolicyTools = (run: GcloudCommandRunner, api: GoogleApiClient) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'get_iam_policy',
+      {
+        title: 'Get IAM policy',
+        inputSchema: { resource: resourceSchema },
+        outputSchema: { resource: z.string(), ...IamPolicySummarySchema.shape },
+        annotations: READ_ONLY_TOOL,
+        description: `Returns the IAM policy set directly on a project, folder, organization, bucket or service account: its role bindings with their conditions, and the roles of each member.
+
+## Instructions:
+- The policy does not include roles inherited from the folders and organization above the resource. Use who_has_role for the effective access.`,
+      },
+      async ({ resource }) => {
+        const parsed = parseIamResource(resource);
+        if (!parsed) {
+          return errorTextResult(`Unsupported resource "${resource}". Use ${RESOURCE_FORMATS}.`);
+        }
+        try {
+          const output = await runJsonCommand(run, getIamPolicyArgs(parsed));
+          if ('error' in output) {
+            return output.error;
+          }
+          return structuredResult({
+            resource: fullResourceName(parsed),
+            ...summarizePolicy(output.json),
+          });
+        } catch (e: unknown) {
+          return failed('get_iam_policy', e);
+        }
+      },
+    );
+
+    server.registerTool(
+      'who_has_role',
+      {
+        title: 'Who has role',
+        inputSchema: {
+          scope: z
+            .string()
+            .describe(
+              'The project, folder or organization whose policies are analyzed, e.g. "organizations/123". Policies above it are not seen.',
+            ),
+          resource: resourceSchema
+            .optional()
+            .describe(`Only analyze access to this resource: ${RESOURCE_FORMATS}.`),
+          role: z.string().optional().describe('The role, e.g. "roles/storage.admin".'),
+          permission: z
+            .string()
+            .optional()
+            .describe('The permission, e.g. "storage.buckets.delete".'),
+          expandGroups: z
+            .boolean()
+            .default(false)
+            .describe('List the members of groups instead of the groups.'),
+        },
+        outputSchema: AccessAnalysisSchema.shape,
+        annotations: READ_ONLY_TOOL,
+        description: `Finds the principals that have a role or permission, with the Cloud Asset Inventory policy analyzer. Inherited and group grants are included, so this answers questions like "who can delete this bucket?".
+
+## Instructions:
+- Pass a permission rather than a role to find every role that grants it, e.g. "storage.buckets.delete".
+- Use the organization as the scope to include grants inherited from it.
+- Each grant names the resource whose policy grants the role in "grantedOn".`,
+      },
+      async ({ scope, resource, role, permission, expandGroups }) => {
+        if (!role && !permission) {
+          return errorTextResult('Pass a role or a permission.');
+        }
+        const parsed = resource === undefined ? undefined : parseIamResource(resource);
+        if (resource !== undefined && !parsed) {
+          return errorTextResult(`Unsupported resource "${resource}". Use ${RESOURCE_FORMATS}.`);
+        }
+        try {
+          const args = analyzeIamPolicyArgs({
+            scope,
+            resource: parsed,
+            role,
+            permission,
+            expandGroups,
+          });
+          const output = await runJsonCommand(run, args);
+          if ('error' in output) {
+            return output.error;
+          }
+          return structuredResult(summarizeAnalysis(output.json));
+        } catch (e: unknown) {
+          return failed('who_has_role', e);
+        }
+      },
+    );
+
+    server.registerTool(
+      'check_permission',
+      {
+        title: 'Check permission',
+        inputSchema: {
+          resource: resourceSchema,
+          permissions: z
+            .array(z.string())
+            .min(1)
+            .max(100)
+            .describe('The permissions to test, e.g. ["storage.buckets.delete"].'),
+        },
+        outputSchema: {
+          resource: z.string(),
+          granted: z.array(z.string()),
+          denied: z.array(z.string()),
+          allGranted: z.boolean(),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Tests which permissions the active gcloud account has on a resource, e.g. before running a command that needs them.`,
+      },
+      async ({ resource, permissions }) => {
+        const parsed = parseIamResource(resource);
+        if (!parsed) {
+          return errorTextResult(`Unsupported resource "${resource}". Use ${RESOURCE_FORMATS}.`);
+        }
+        try {
+          return structuredResult({ ...(await testPermissions(api, parsed, permissions)) });
+        } catch (e: unknown) {
+          return failed('check_permission', e);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createIdentityGroupTools } from './identity_groups.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (name: string, deny: string[] = []) => {
+  createIdentityGroupTools(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  return (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+};
+
+const listed = (items: unknown[]) => ({ code: 0, stdout: JSON.stringify(items), stderr: '' });
+
+describe('createIdentityGroupTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = { lint: vi.fn(), invoke: vi.fn().mockResolvedValue(listed([])) };
+  });
+
+  test('describes a group', async () => {
+    const tool = createTool('describe_group');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 0,
+      stdout: JSON.stringify({ name: 'groups/01abc', groupKey: { id: 'eng@example.com' } }),
+      stderr: '',
+    });
+
+    const result = await tool({ group: 'eng@example.com' });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      email: 'eng@example.com',
+      name: 'groups/01abc',
+    });
+  });
+
+  test('lists the members of a group', async () => {
+    const tool = createTool('list_group_members');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue(
+      listed([{ preferredMemberKey: { id: 'alice@example.com' }, type: 'USER' }]),
+    );
+
+    const result = await tool({ group: 'eng@example.com', maxDepth: 0 });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      group: 'eng@example.com',
+      members: [{ email: 'alice@example.com', via: ['eng@example.com'] }],
+    });
+  });
+
+  test('checks the membership of a user', async () => {
+    const tool = createTool('check_group_membership');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue(
+      listed([{ preferredMemberKey: { id: 'alice@example.com' }, type: 'USER' }]),
+    );
+
+    const result = await tool({
+      group: 'eng@example.com',
+      member: 'alice@example.com',
+      maxDepth: 5,
+    });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({ isMember: true, conclusive: true });
+  });
+
+  test('returns an error if listing memberships is not permitted', async () => {
+    const tool = createTool('check_group_membership', ['identity groups memberships']);
+
+    const result = await tool({
+      group: 'eng@example.com',
+      member: 'alice@example.com',
+      maxDepth: 5,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud identity groups memberships list"');
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('returns an error if the group can not be listed', async () => {
+    const tool = createTool('list_group_members');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 1,
+      stdout: '',
+      stderr: 'PERMISSION_DENIED',
+    });
+
+    const result = await tool({ group: 'eng@example.com', maxDepth: 0 });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('PERMISSION_DENIED');
+  });
+});
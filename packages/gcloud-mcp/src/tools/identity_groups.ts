@@ -0,0 +1,148 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import {
+  MAX_EXPANDED_GROUPS,
+  checkGroupMembership,
+  describeGroup,
+  listGroupMembers,
+} from '../identity_groups.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const DESCRIBE_COMMAND = 'identity groups describe';
+const MEMBERSHIPS_COMMAND = 'identity groups memberships list';
+
+const groupSchema = z.string().describe('The email address of the group, e.g. "devs@example.com".');
+
+const maxDepthSchema = (defaultDepth: number) =>
+  z
+    .number()
+    .int()
+    .min(0)
+    .max(10)
+    .default(defaultDepth)
+    .describe('How many levels of nested groups to expand. 0 only looks at direct members.');
+
+export const createIdentityGroupTools = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'describe_group',
+      {
+        title: 'Describe group',
+        inputSchema: { group: groupSchema },
+        annotations: READ_ONLY_TOOL,
+        description: `Looks up a Cloud Identity or Google Workspace group by its email address and returns its name, display name, description, labels and creation time.
+
+## Instructions:
+- Labels tell the kind of group, e.g. "cloudidentity.googleapis.com/groups.security" for security groups.`,
+      },
+      async ({ group }) => {
+        const toolLogger = log.mcp('describe_group', { group });
+        if (!acl.check(DESCRIBE_COMMAND).permitted) {
+          return errorTextResult(
+            `Describing groups requires "gcloud ${DESCRIBE_COMMAND}", which is not permitted.`,
+          );
+        }
+        try {
+          return successfulTextResult(JSON.stringify(await describeGroup(gcloud, group), null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('describe_group failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'list_group_members',
+      {
+        title: 'List group members',
+        inputSchema: { group: groupSchema, maxDepth: maxDepthSchema(0) },
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the members of a Cloud Identity or Google Workspace group with their type and roles. With maxDepth above 0, the members of nested groups are listed too, each with the chain of groups ("via") it is a member through.
+
+## Instructions:
+- Set maxDepth to list everyone who effectively gets the access granted to the group.
+- Each nested group takes one more gcloud call, and at most ${MAX_EXPANDED_GROUPS} nested groups are expanded. "truncated" is true if some were left unexpanded.
+- Nested groups that can not be listed, e.g. groups outside the organization, are listed under errors.`,
+      },
+      async ({ group, maxDepth }) => {
+        const toolLogger = log.mcp('list_group_members', { group, maxDepth });
+        if (!acl.check(MEMBERSHIPS_COMMAND).permitted) {
+          return errorTextResult(
+            `Listing group members requires "gcloud ${MEMBERSHIPS_COMMAND}", which is not permitted.`,
+          );
+        }
+        try {
+          const members = await listGroupMembers(gcloud, group, maxDepth);
+          return successfulTextResult(JSON.stringify(members, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'list_group_members failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'check_group_membership',
+      {
+        title: 'Check group membership',
+        inputSchema: {
+          group: groupSchema,
+          member: z
+            .string()
+            .describe('The email address of the user, service account or group to look for.'),
+          maxDepth: maxDepthSchema(5),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Checks whether a user, service account or group is effectively a member of a Cloud Identity or Google Workspace group, directly or through nested groups, and returns the chain of groups that makes it one.
+
+## Instructions:
+- Use this tool when debugging IAM access granted to a group, to answer "is this person in group X".
+- If isMember is false and conclusive is false, some nested groups could not be checked; report them to the user instead of saying the member is not in the group.`,
+      },
+      async ({ group, member, maxDepth }) => {
+        const toolLogger = log.mcp('check_group_membership', { group, member, maxDepth });
+        if (!acl.check(MEMBERSHIPS_COMMAND).permitted) {
+          return errorTextResult(
+            `Checking group membership requires "gcloud ${MEMBERSHIPS_COMMAND}", which is not permitted.`,
+          );
+        }
+        try {
+          const check = await checkGroupMembership(gcloud, group, member, maxDepth);
+          return successfulTextResult(JSON.stringify(check, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'check_group_membership failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,133 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { CommandHistory, createCommandHistory } from '../command_history.js';
+import { createNamingPolicy } from '../naming_policy.js';
+import { createLabelCoverage } from './label_coverage.js';
+import { successfulTextResult } from './tool_result.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const ASSETS = [
+  {
+    name: '//compute.googleapis.com/projects/p/zones/us-central1-a/instances/web-1',
+    assetType: 'compute.googleapis.com/Instance',
+    location: 'us-central1-a',
+    labels: {},
+  },
+];
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let history: CommandHistory;
+const run = vi.fn();
+
+const createTool = (options: { deny?: string[]; requiredLabels?: string[] } = {}) => {
+  const namingPolicy = createNamingPolicy(
+    options.requiredLabels ? { requiredLabels: { '*': options.requiredLabels } } : {},
+  );
+  createLabelCoverage(
+    mockedGcloud,
+    createAccessControlList([], options.deny ?? []),
+    run,
+    history,
+    namingPolicy,
+  ).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createLabelCoverage', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: JSON.stringify(ASSETS), stderr: '' }),
+    };
+    run.mockImplementation(async (args: string[]) => {
+      history.record(args, 0);
+      return successfulTextResult('ok');
+    });
+  });
+
+  test('reports coverage for the naming policy', async () => {
+    const tool = createTool({ requiredLabels: ['team'] });
+
+    const result = await tool({ projects: ['p'] });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      summary: { resources: 1, missingRequiredLabels: 1, coverage: 0 },
+      unlabeled: [{ name: 'web-1', missing: ['team'] }],
+    });
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('returns the update plan without running it', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      projects: ['p'],
+      requiredLabels: ['team'],
+      labels: { team: 'web' },
+    });
+
+    expect(JSON.parse(result.content[0].text).plan).toEqual([
+      'gcloud compute instances add-labels web-1 --zone=us-central1-a --labels=team=web --project=p',
+    ]);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('runs the update commands once confirmed', async () => {
+    const tool = createTool({ requiredLabels: ['team'] });
+
+    const result = await tool({ projects: ['p'], labels: { team: 'web' }, confirm: true });
+
+    expect(run).toHaveBeenCalledWith(
+      expect.arrayContaining(['add-labels', 'web-1', '--labels=team=web']),
+      undefined,
+      true,
+    );
+    expect(JSON.parse(result.content[0].text)).toEqual([
+      expect.objectContaining({ resource: 'web-1', status: 'succeeded' }),
+    ]);
+  });
+
+  test('returns an error if no labels are required', async () => {
+    const tool = createTool();
+
+    const result = await tool({ projects: ['p'] });
+
+    expect(result.isError).toBe(true);
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('returns an error if asset search is denied', async () => {
+    const tool = createTool({ deny: ['asset'], requiredLabels: ['team'] });
+
+    const result = await tool({ projects: ['p'] });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud asset search-all-resources"');
+  });
+});
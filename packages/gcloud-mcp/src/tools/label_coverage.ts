@@ -0,0 +1,160 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { CommandHistory } from '../command_history.js';
+import { NamingPolicy, createNamingPolicy } from '../naming_policy.js';
+import { ASSET_TYPES } from '../assets.js';
+import { labelCoverage, labelUpdates } from '../label_coverage.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner, runRecorded } from './run_gcloud_command.js';
+import { ADDITIVE_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+// The report lists at most this many unlabeled resources; the counts cover all of them.
+export const MAX_LISTED_RESOURCES = 100;
+
+export const createLabelCoverage = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+  namingPolicy: NamingPolicy,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'label_coverage',
+      {
+        title: 'Report label coverage',
+        inputSchema: {
+          projects: z
+            .array(z.string())
+            .optional()
+            .describe('The projects to report on. Defaults to the session project.'),
+          requiredLabels: z
+            .array(z.string())
+            .optional()
+            .describe(
+              'The labels every resource must have. Defaults to the required labels of the naming policy.',
+            ),
+          labels: z
+            .record(z.string())
+            .optional()
+            .describe(
+              'Label values to set on resources that miss them, e.g. {"team": "payments"}. Returns the update commands as a plan.',
+            ),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true to run the update commands after the user approved the plan.'),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Reports how many resources miss required labels, overall, by project, by service and by label, and lists the unlabeled resources. Covers Compute Engine instances, disks and snapshots, Cloud Storage buckets, Cloud SQL instances, GKE clusters, Cloud Run services and Pub/Sub topics through Cloud Asset Inventory.
+
+## Instructions:
+- Use this tool to audit labeling hygiene, e.g. before relying on labels for cost allocation.
+- To remediate, call this tool with "labels" to get the plan of update commands, and show it to the user.
+- Call it again with the same arguments and "confirm": true only after the user approves. Each command runs with run_gcloud_command, so the same restrictions apply.`,
+      },
+      async ({ projects, requiredLabels, labels, confirm }) => {
+        const toolLogger = log.mcp('label_coverage', { projects, requiredLabels, labels, confirm });
+        if (!acl.check('asset search-all-resources').permitted) {
+          return errorTextResult(
+            'Reporting label coverage requires "gcloud asset search-all-resources", which is not permitted.',
+          );
+        }
+        const policy = requiredLabels
+          ? createNamingPolicy({ requiredLabels: { '*': requiredLabels } })
+          : namingPolicy;
+        const hasRequiredLabels = Object.values(ASSET_TYPES).some(
+          ({ resourceType }) => policy.requiredLabels(resourceType).length > 0,
+        );
+        if (!hasRequiredLabels) {
+          return errorTextResult(
+            'No labels are required. Pass requiredLabels or configure namingPolicy.requiredLabels.',
+          );
+        }
+        try {
+          let targets = projects ?? [];
+          if (targets.length === 0) {
+            const project = await sessionProject(gcloud);
+            if (!project) {
+              return errorTextResult(
+                'No project is set. Pass projects or set one with set_context.',
+              );
+            }
+            targets = [project];
+          }
+          const { unlabeled, ...report } = await labelCoverage(gcloud, policy, targets);
+          const listed = {
+            ...report,
+            unlabeled: unlabeled.slice(0, MAX_LISTED_RESOURCES),
+            ...(unlabeled.length > MAX_LISTED_RESOURCES && {
+              note: `Only the first ${MAX_LISTED_RESOURCES} of ${unlabeled.length} unlabeled resources are listed.`,
+            }),
+          };
+          if (!labels) {
+            return successfulTextResult(JSON.stringify(listed, null, 2));
+          }
+
+          const updates = labelUpdates(unlabeled, labels);
+          if (!confirm) {
+            return successfulTextResult(
+              JSON.stringify(
+                {
+                  ...listed,
+                  plan: updates.map(({ args }) => `gcloud ${args.join(' ')}`),
+                  next: 'After the user approves, call label_coverage again with the same arguments and "confirm": true.',
+                },
+                null,
+                2,
+              ),
+            );
+          }
+          const results: Array<{
+            project: string;
+            resource: string;
+            command: string;
+            status: 'succeeded' | 'failed';
+            output: string;
+          }> = [];
+          for (const { resource, args } of updates) {
+            const { exitCode, output } = await runRecorded(run, history, args);
+            results.push({
+              project: resource.project,
+              resource: resource.name,
+              command: `gcloud ${args.join(' ')}`,
+              status: exitCode === 0 ? 'succeeded' : 'failed',
+              output,
+            });
+          }
+          toolLogger.info('label_coverage updated labels', {
+            failed: results.filter((r) => r.status !== 'succeeded').length,
+          });
+          return successfulTextResult(JSON.stringify(results, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('label_coverage failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
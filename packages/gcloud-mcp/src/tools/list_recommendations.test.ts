@@ -0,0 +1,125 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createListRecommendations } from './list_recommendations.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let run: GcloudCommandRunner;
+
+const createTool = () => {
+  createListRecommendations(run).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const recommendation = (name: string, units: string) => ({
+  name: `projects/123/locations/us-central1-a/recommenders/r/recommendations/${name}`,
+  primaryImpact: {
+    costProjection: { cost: { currencyCode: 'USD', units, nanos: 0 }, duration: '2592000s' },
+  },
+});
+
+describe('createListRecommendations', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    run = vi.fn();
+  });
+
+  test('lists recommendations with their savings through the command runner', async () => {
+    const tool = createTool();
+    vi.mocked(run).mockResolvedValue({
+      content: [
+        {
+          type: 'text',
+          text: JSON.stringify([recommendation('a', '-5.5'), recommendation('b', '-20')]),
+        },
+      ],
+    });
+
+    const result = await tool({
+      recommender: 'google.compute.instance.IdleResourceRecommender',
+      project: 'p',
+      location: 'us-central1-a',
+      minMonthlySavings: 1,
+      limit: 50,
+    });
+
+    expect(run).toHaveBeenCalledWith([
+      'recommender',
+      'recommendations',
+      'list',
+      '--project=p',
+      '--location=us-central1-a',
+      '--recommender=google.compute.instance.IdleResourceRecommender',
+      '--format=json',
+    ]);
+    expect(result.structuredContent).toMatchObject({
+      location: 'us-central1-a',
+      count: 2,
+      totalMonthlySavings: 25.5,
+      recommendations: [{ id: 'b', monthlySavings: 20 }, { id: 'a', monthlySavings: 5.5 }],
+    });
+  });
+
+  test('filters out recommendations below the minimum savings', async () => {
+    const tool = createTool();
+    vi.mocked(run).mockResolvedValue({
+      content: [
+        {
+          type: 'text',
+          text: JSON.stringify([recommendation('a', '-5'), recommendation('b', '-20')]),
+        },
+      ],
+    });
+
+    const result = await tool({
+      recommender: 'google.compute.commitment.UsageCommitmentRecommender',
+      location: 'us-central1',
+      minMonthlySavings: 10,
+      limit: 50,
+    });
+
+    expect(result.structuredContent.recommendations).toHaveLength(1);
+  });
+
+  test('defaults global recommenders to the global location', async () => {
+    const tool = createTool();
+    vi.mocked(run).mockResolvedValue({ content: [{ type: 'text', text: '[]' }] });
+
+    await tool({ recommender: 'google.iam.policy.Recommender', limit: 50 });
+
+    expect(run).toHaveBeenCalledWith(expect.arrayContaining(['--location=global']));
+  });
+
+  test('requires a zone for zonal recommenders', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      recommender: 'google.compute.instance.MachineTypeRecommender',
+      limit: 50,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('Pass a zone');
+    expect(run).not.toHaveBeenCalled();
+  });
+});
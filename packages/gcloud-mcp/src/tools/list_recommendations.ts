@@ -0,0 +1,140 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import {
+  RECOMMENDERS,
+  RecommendationSummarySchema,
+  listRecommendationsArgs,
+  summarizeRecommendations,
+} from '../recommender.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner, runJsonCommand } from './run_gcloud_command.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const knownRecommenders = Object.entries(RECOMMENDERS)
+  .map(([id, { scope, summary }]) => `- ${id}: ${summary} (per ${scope})`)
+  .join('\n');
+
+/**
+ * Lists Active Assist recommendations through the same runner as
+ * run_gcloud_command, so they are checked against the access control list.
+ */
+export const createListRecommendations = (run: GcloudCommandRunner) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_recommendations',
+      {
+        title: 'List recommendations',
+        inputSchema: {
+          recommender: z
+            .string()
+            .describe(
+              'The recommender ID, e.g. "google.compute.instance.IdleResourceRecommender".',
+            ),
+          project: z
+            .string()
+            .optional()
+            .describe('The project to look in. Defaults to the session project.'),
+          location: z
+            .string()
+            .optional()
+            .describe(
+              'The zone or region of a zonal or regional recommender. Defaults to "global" for global recommenders.',
+            ),
+          minMonthlySavings: z
+            .number()
+            .min(0)
+            .optional()
+            .describe('Only return recommendations saving at least this much per month.'),
+          limit: z
+            .number()
+            .int()
+            .positive()
+            .max(500)
+            .default(50)
+            .describe('The maximum number of recommendations to return.'),
+        },
+        outputSchema: {
+          recommender: z.string(),
+          location: z.string(),
+          recommendations: z.array(RecommendationSummarySchema),
+          count: z.number(),
+          totalMonthlySavings: z.number(),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the active Active Assist recommendations of a recommender, such as idle VMs, rightsizing, excess IAM permissions and committed use discounts, with their estimated monthly savings, largest savings first.
+
+Common recommenders:
+${knownRecommenders}
+
+## Instructions:
+- Zonal recommenders need a zone and regional ones a region; list the zones or regions of the project's resources first.
+- "monthlySavings" is null for recommendations without a cost impact, e.g. IAM recommendations.
+- Use "resources" to find the affected resources.`,
+      },
+      async ({ recommender, project, location, minMonthlySavings, limit }) => {
+        const toolLogger = log.mcp('list_recommendations', { recommender, project, location });
+        const scope = RECOMMENDERS[recommender]?.scope;
+        if (!location && scope && scope !== 'global') {
+          const kind = scope === 'zone' ? 'zonal' : 'regional';
+          return errorTextResult(`${recommender} is a ${kind} recommender. Pass a ${scope}.`);
+        }
+        const target = location ?? 'global';
+        try {
+          const output = await runJsonCommand(
+            run,
+            listRecommendationsArgs(project, target, recommender),
+          );
+          if ('error' in output) {
+            return output.error;
+          }
+          const recommendations = summarizeRecommendations(output.json)
+            .filter(
+              (recommendation) =>
+                minMonthlySavings === undefined ||
+                (recommendation.monthlySavings ?? 0) >= minMonthlySavings,
+            )
+            .slice(0, limit);
+          const totalMonthlySavings = recommendations.reduce(
+            (sum, recommendation) => sum + (recommendation.monthlySavings ?? 0),
+            0,
+          );
+          const structuredContent = {
+            recommender,
+            location: target,
+            recommendations,
+            count: recommendations.length,
+            totalMonthlySavings: Math.round(totalMonthlySavings * 100) / 100,
+          };
+          return {
+            ...successfulTextResult(JSON.stringify(structuredContent, null, 2)),
+            structuredContent,
+          };
+        } catch (e: unknown) {
+          toolLogger.error(
+            'list_recommendations failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
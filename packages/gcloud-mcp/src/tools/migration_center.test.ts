@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { GoogleApiClient } from '../google_api.js';
+import { BillingCatalog } from '../billing_catalog.js';
+import { createMigrationCenterTools } from './migration_center.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const catalog: BillingCatalog = { skus: vi.fn().mockResolvedValue([]) };
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let api: GoogleApiClient;
+
+const createTool = (name: string, deny: string[] = []) => {
+  const acl = createAccessControlList([], deny);
+  createMigrationCenterTools(mockedGcloud, api, acl, catalog).register(mockServer);
+  return (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+};
+
+const options = {
+  region: 'us-central1',
+  family: 'n2',
+  commitment: 'on-demand',
+  rightSize: false,
+  headroomPercent: 20,
+};
+
+describe('createMigrationCenterTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: 'my-project\n', stderr: '' }),
+    };
+    api = {
+      get: vi.fn().mockResolvedValue({
+        assets: [
+          {
+            name: 'projects/my-project/locations/us-central1/assets/a',
+            assignedGroups: ['projects/my-project/locations/us-central1/groups/wave-1'],
+            machineDetails: { machineName: 'app', coreCount: 2, memoryMb: 8192 },
+          },
+        ],
+      }),
+      post: vi.fn(),
+    };
+  });
+
+  test('lists the assets of the session project', async () => {
+    const tool = createTool('list_migration_assets');
+
+    const result = await tool({ location: 'us-central1' });
+
+    expect(JSON.parse(result.content[0].text).assets).toEqual([
+      { id: 'a', name: 'app', cpus: 2, memoryGb: 8, groups: ['wave-1'] },
+    ]);
+    expect(vi.mocked(api.get).mock.calls[0]![0]).toContain('/projects/my-project/locations/');
+  });
+
+  test('assesses Migration Center groups and source VMs in one call', async () => {
+    const tool = createTool('migration_fit_report');
+
+    const result = await tool({
+      location: 'us-central1',
+      group: 'wave-1',
+      sourceVms: [{ name: 'db', cpus: 8, memoryGb: 64 }],
+      ...options,
+    });
+
+    const { vms } = JSON.parse(result.content[0].text);
+    expect(vms).toMatchObject([
+      { source: { name: 'db' }, machineType: 'n2-highmem-8' },
+      { source: { name: 'app' }, machineType: 'n2-standard-2' },
+    ]);
+  });
+
+  test('returns an error without VMs to assess', async () => {
+    const tool = createTool('migration_fit_report');
+
+    const result = await tool(options);
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('No VMs to assess');
+  });
+
+  test('returns an error if listing assets is denied', async () => {
+    const tool = createTool('migration_fit_report', ['migration-center']);
+
+    const result = await tool({ location: 'us-central1', group: 'wave-1', ...options });
+
+    expect(result.isError).toBe(true);
+    expect(api.get).not.toHaveBeenCalled();
+  });
+});
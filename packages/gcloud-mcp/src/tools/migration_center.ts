@@ -0,0 +1,246 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { GoogleApiClient } from '../google_api.js';
+import { BillingCatalog } from '../billing_catalog.js';
+import {
+  MACHINE_FAMILIES,
+  MAX_FIT_VMS,
+  SourceVm,
+  buildFitReport,
+  listMigrationAssets,
+  listMigrationGroups,
+} from '../migration_center.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const locationSchema = {
+  project: z
+    .string()
+    .optional()
+    .describe('The project of the Migration Center instance. Defaults to the session project.'),
+  location: z.string().describe('The region of the Migration Center instance, e.g. "us-central1".'),
+};
+
+export const createMigrationCenterTools = (
+  gcloud: GcloudExecutable,
+  api: GoogleApiClient,
+  acl: AccessControlList,
+  catalog: BillingCatalog,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_migration_assets',
+      {
+        title: 'List Migration Center assets',
+        inputSchema: {
+          ...locationSchema,
+          group: z.string().optional().describe('Only list the assets of this group ID.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the machines discovered by Migration Center with their vCPUs, memory, disk capacity, operating system, groups, and peak CPU and memory utilization.
+
+## Instructions:
+- Use list_migration_groups to find the groups of an assessment.
+- Machines without CPU and memory details are left out.`,
+      },
+      async ({ project, location, group }) => {
+        const toolLogger = log.mcp('list_migration_assets', { project, location, group });
+        if (!acl.check('migration-center assets list').permitted) {
+          return errorTextResult(
+            'Listing assets requires "gcloud migration-center assets list", which is not permitted.',
+          );
+        }
+        try {
+          const target = project ?? (await sessionProject(gcloud));
+          if (!target) {
+            return errorTextResult(
+              'No project is set. Pass a project or set one with set_context.',
+            );
+          }
+          const assets = await listMigrationAssets(api, target, location, group);
+          return successfulTextResult(JSON.stringify(assets, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'list_migration_assets failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'list_migration_groups',
+      {
+        title: 'List Migration Center groups',
+        inputSchema: locationSchema,
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the asset groups of a Migration Center instance.`,
+      },
+      async ({ project, location }) => {
+        const toolLogger = log.mcp('list_migration_groups', { project, location });
+        if (!acl.check('migration-center groups list').permitted) {
+          return errorTextResult(
+            'Listing groups requires "gcloud migration-center groups list", which is not permitted.',
+          );
+        }
+        try {
+          const target = project ?? (await sessionProject(gcloud));
+          if (!target) {
+            return errorTextResult(
+              'No project is set. Pass a project or set one with set_context.',
+            );
+          }
+          const groups = await listMigrationGroups(api, target, location);
+          return successfulTextResult(JSON.stringify(groups, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'list_migration_groups failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'migration_fit_report',
+      {
+        title: 'Migration fit report',
+        inputSchema: {
+          project: locationSchema.project,
+          location: z
+            .string()
+            .optional()
+            .describe('The region of the Migration Center instance to read assets from.'),
+          group: z
+            .string()
+            .optional()
+            .describe('Assess the assets of this Migration Center group.'),
+          assetIds: z
+            .array(z.string())
+            .optional()
+            .describe('Assess these Migration Center assets.'),
+          sourceVms: z
+            .array(
+              z.object({
+                name: z.string(),
+                cpus: z.number().int().positive(),
+                memoryGb: z.number().positive(),
+                diskGb: z.number().positive().optional(),
+                os: z.string().optional(),
+                cpuPeakPercent: z.number().min(0).max(100).optional(),
+                memoryPeakPercent: z.number().min(0).max(100).optional(),
+              }),
+            )
+            .optional()
+            .describe('Source VMs to assess that are not in Migration Center.'),
+          region: z.string().describe('The target region, e.g. "us-central1".'),
+          family: z.enum(MACHINE_FAMILIES).default('n2').describe('The target machine family.'),
+          commitment: z.enum(['on-demand', '1-year', '3-year']).default('on-demand'),
+          rightSize: z
+            .boolean()
+            .default(false)
+            .describe('Size VMs by their peak utilization instead of their allocation.'),
+          headroomPercent: z
+            .number()
+            .min(0)
+            .default(20)
+            .describe('Capacity added on top of the peak utilization when right-sizing.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Maps many source VMs in one call to recommended Compute Engine machine types and prices them at list prices. VMs come from a Migration Center group, a list of Migration Center asset IDs, or a list of source VM specs.
+
+Returns per VM the capacity required, the recommended machine type, whether it is predefined or custom or nothing fits, and the monthly cost of the machine and a balanced persistent disk of the source disk capacity.
+
+## Instructions:
+- Use this tool for migration assessments and business cases instead of sizing machines one by one.
+- Set rightSize to size by the peak utilization Migration Center collected, plus headroomPercent. VMs without utilization data keep their allocation.
+- Costs are list prices in USD for 730 hours a month, excluding licenses, discounts and egress.`,
+      },
+      async (input) => {
+        const { project, location, group, assetIds, sourceVms = [], ...options } = input;
+        const toolLogger = log.mcp('migration_fit_report', {
+          project,
+          location,
+          group,
+          assetIds: assetIds?.length,
+          sourceVms: sourceVms.length,
+          ...options,
+        });
+        const fromMigrationCenter = group !== undefined || assetIds !== undefined;
+        if (fromMigrationCenter && !location) {
+          return errorTextResult('Pass the location of the Migration Center instance.');
+        }
+        if (fromMigrationCenter && !acl.check('migration-center assets list').permitted) {
+          return errorTextResult(
+            'Reading assets requires "gcloud migration-center assets list", which is not permitted.',
+          );
+        }
+        try {
+          const vms: SourceVm[] = sourceVms.map(({ name, cpus, memoryGb, ...rest }) => ({
+            name,
+            cpus,
+            memoryGb,
+            ...(rest.diskGb && { diskGb: rest.diskGb }),
+            ...(rest.os && { os: rest.os }),
+            ...(rest.cpuPeakPercent !== undefined && { cpuPeakPercent: rest.cpuPeakPercent }),
+            ...(rest.memoryPeakPercent !== undefined && {
+              memoryPeakPercent: rest.memoryPeakPercent,
+            }),
+          }));
+          if (fromMigrationCenter && location) {
+            const target = project ?? (await sessionProject(gcloud));
+            if (!target) {
+              return errorTextResult(
+                'No project is set. Pass a project or set one with set_context.',
+              );
+            }
+            const { assets } = await listMigrationAssets(api, target, location, group);
+            vms.push(...assets.filter(({ id }) => !assetIds || assetIds.includes(id)));
+          }
+          if (vms.length === 0) {
+            return errorTextResult('No VMs to assess. Pass a group, assetIds or sourceVms.');
+          }
+          if (vms.length > MAX_FIT_VMS) {
+            return errorTextResult(
+              `${vms.length} VMs match, more than the limit of ${MAX_FIT_VMS}. Assess them in batches.`,
+            );
+          }
+          const report = await buildFitReport(gcloud, catalog, vms, options);
+          toolLogger.info('migration_fit_report finished', report.summary);
+          return successfulTextResult(JSON.stringify(report, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'migration_fit_report failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
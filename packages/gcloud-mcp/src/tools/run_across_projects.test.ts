@@ -0,0 +1,134 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { CommandHistory, createCommandHistory } from '../command_history.js';
+import { createRunAcrossProjects } from './run_across_projects.js';
+import { successfulTextResult } from './tool_result.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let history: CommandHistory;
+const run = vi.fn();
+
+const createTool = (deny: string[] = []) => {
+  createRunAcrossProjects(mockedGcloud, createAccessControlList([], deny), run, history).register(
+    mockServer,
+  );
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const mockParsedCommand = (parsedCommand: string) =>
+  vi.mocked(mockedGcloud.lint).mockResolvedValue({ success: true, parsedCommand });
+
+describe('createRunAcrossProjects', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({
+        code: 0,
+        stdout: JSON.stringify([
+          { state: 'ACTIVE', additionalAttributes: { projectId: 'a' } },
+          { state: 'ACTIVE', additionalAttributes: { projectId: 'b' } },
+        ]),
+        stderr: '',
+      }),
+    };
+    run.mockImplementation(async (args: string[]) => {
+      history.record(args, 0);
+      return successfulTextResult('[]');
+    });
+  });
+
+  test('runs read-only commands in every project of a folder', async () => {
+    const tool = createTool();
+    mockParsedCommand('compute instances list');
+
+    const result = await tool({
+      args: ['compute', 'instances', 'list'],
+      folder: '123',
+      concurrency: 5,
+    });
+
+    expect(run).toHaveBeenCalledWith(
+      ['compute', 'instances', 'list', '--project=a'],
+      undefined,
+      true,
+    );
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      summary: { projects: 2, succeeded: 2, failed: 0 },
+    });
+  });
+
+  test('asks for confirmation before commands that change state', async () => {
+    const tool = createTool();
+    mockParsedCommand('compute instances add-labels');
+    const input = {
+      args: ['compute', 'instances', 'add-labels', 'vm', '--labels=team=web'],
+      projects: ['a', 'b'],
+      concurrency: 5,
+    };
+
+    const plan = await tool(input);
+    expect(JSON.parse(plan.content[0].text)).toMatchObject({
+      mutatesState: true,
+      projects: ['a', 'b'],
+    });
+    expect(run).not.toHaveBeenCalled();
+
+    await tool({ ...input, confirm: true });
+    expect(run).toHaveBeenCalledTimes(2);
+  });
+
+  test('requires exactly one scope', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      args: ['compute', 'instances', 'list'],
+      projects: ['a'],
+      folder: '123',
+      concurrency: 5,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(mockedGcloud.lint).not.toHaveBeenCalled();
+  });
+
+  test('returns an error if the command is denied', async () => {
+    const tool = createTool(['compute']);
+    mockParsedCommand('compute instances list');
+
+    const result = await tool({
+      args: ['compute', 'instances', 'list'],
+      projects: ['a'],
+      concurrency: 5,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,167 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { CommandHistory } from '../command_history.js';
+import {
+  DEFAULT_CONCURRENCY,
+  MAX_CONCURRENCY,
+  MAX_PROJECTS,
+  PROJECT_PLACEHOLDER,
+  expandTemplate,
+  projectsInScope,
+  runAcrossProjects,
+  validateTemplate,
+} from '../across_projects.js';
+import { log } from '../utility/logger.js';
+import { classifyMutation } from './explain_command.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { DESTRUCTIVE_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createRunAcrossProjects = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'run_across_projects',
+      {
+        title: 'Run gcloud command across projects',
+        inputSchema: {
+          args: z
+            .array(z.string())
+            .describe(
+              `The gcloud command to run in each project, without "gcloud". "${PROJECT_PLACEHOLDER}" is replaced by the project ID; without it, --project is added.`,
+            ),
+          projects: z.array(z.string()).optional().describe('The projects to run the command in.'),
+          folder: z
+            .string()
+            .optional()
+            .describe('A folder ID. The command runs in every active project below it.'),
+          organization: z
+            .string()
+            .optional()
+            .describe('An organization ID. The command runs in every active project in it.'),
+          concurrency: z
+            .number()
+            .int()
+            .min(1)
+            .max(MAX_CONCURRENCY)
+            .default(DEFAULT_CONCURRENCY)
+            .describe('The maximum number of projects the command runs in at once.'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true once the user approved running a command that changes state.'),
+        },
+        annotations: DESTRUCTIVE_TOOL,
+        description: `Runs a gcloud command in every project of a folder or organization, or in a list of projects, and returns one result per project with its exit code and output. A failure in one project does not stop the others.
+
+## Instructions:
+- Use this tool for org-wide sweeps, e.g. listing public buckets or checking a setting in every project, instead of calling run_gcloud_command once per project.
+- Pass exactly one of "projects", "folder" or "organization".
+- Commands that change state return the list of projects first. Show it to the user and call this tool again with "confirm": true only after they approve.
+- Each command runs with run_gcloud_command, so the same restrictions apply.`,
+      },
+      async ({ args, projects, folder, organization, concurrency, confirm }) => {
+        const toolLogger = log.mcp('run_across_projects', {
+          args,
+          projects,
+          folder,
+          organization,
+          concurrency,
+        });
+        const scopes = [projects, folder, organization].filter((scope) => scope !== undefined);
+        if (scopes.length !== 1) {
+          return errorTextResult('Pass exactly one of projects, folder, or organization.');
+        }
+        const templateError = validateTemplate(args);
+        if (templateError) {
+          return errorTextResult(templateError);
+        }
+        try {
+          const lintResult = await gcloud.lint(expandTemplate(args, 'example-project').join(' '));
+          if (!lintResult.success) {
+            return errorTextResult(lintResult.error);
+          }
+          const { parsedCommand } = lintResult;
+          if (!acl.check(parsedCommand, args).permitted) {
+            return errorTextResult(`"gcloud ${parsedCommand}" is not permitted.`);
+          }
+
+          let targets = projects ?? [];
+          if (folder !== undefined || organization !== undefined) {
+            if (!acl.check('asset search-all-resources').permitted) {
+              return errorTextResult(
+                'Running across a folder or organization requires "gcloud asset search-all-resources", which is not permitted.',
+              );
+            }
+            targets = await projectsInScope(
+              gcloud,
+              folder !== undefined ? { folder } : { organization: organization ?? '' },
+            );
+          }
+          if (targets.length === 0) {
+            return errorTextResult('No projects to run the command in.');
+          }
+          if (targets.length > MAX_PROJECTS) {
+            return errorTextResult(
+              `${targets.length} projects are in scope, more than the limit of ${MAX_PROJECTS}. Run the command per folder instead.`,
+            );
+          }
+
+          const verb = parsedCommand.split(' ').pop() ?? '';
+          if (classifyMutation(verb) !== false && !confirm) {
+            return successfulTextResult(
+              JSON.stringify(
+                {
+                  mutatesState: true,
+                  command: `gcloud ${args.join(' ')}`,
+                  projects: targets,
+                  next: 'The command may change state. After the user approves, call run_across_projects again with "confirm": true.',
+                },
+                null,
+                2,
+              ),
+            );
+          }
+
+          const results = await runAcrossProjects(run, history, args, targets, concurrency);
+          const summary = {
+            projects: results.length,
+            succeeded: results.filter((r) => r.status === 'succeeded').length,
+            failed: results.filter((r) => r.status === 'failed').length,
+          };
+          toolLogger.info('run_across_projects finished', summary);
+          return successfulTextResult(JSON.stringify({ summary, results }, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'run_across_projects failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,180 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { GoogleApiClient } from '../google_api.js';
+import { createProfiles } from '../profiles.js';
+import { createSessionContext } from '../session_context.js';
+import { RunBigQueryQueryOptions, createRunBigQueryQuery } from './run_bigquery_query.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+const api: GoogleApiClient = { get: vi.fn(), post: vi.fn() };
+
+const createTool = (options: RunBigQueryQueryOptions = {}) => {
+  createRunBigQueryQuery(mockedGcloud, api, {}, options).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createRunBigQueryQuery', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: 'session-project\n', stderr: '' }),
+    };
+  });
+
+  test('is only annotated as read-only in read-only mode', () => {
+    createRunBigQueryQuery(mockedGcloud, api).register(mockServer);
+    createRunBigQueryQuery(mockedGcloud, api, {}, { readOnly: true }).register(mockServer);
+
+    const [[, config], [, readOnlyConfig]] = (mockServer.registerTool as Mock).mock.calls;
+    expect(config.annotations.destructiveHint).toBe(true);
+    expect(readOnlyConfig.annotations.readOnlyHint).toBe(true);
+  });
+
+  test('estimates the query in the session project', async () => {
+    const tool = createTool();
+    vi.mocked(api.post).mockResolvedValue({
+      statistics: { totalBytesProcessed: String(2 ** 40), query: { statementType: 'SELECT' } },
+    });
+
+    const result = await tool({
+      query: 'SELECT 1',
+      dryRun: true,
+      maxRows: 100,
+      timeoutSeconds: 60,
+    });
+
+    expect(vi.mocked(api.post).mock.calls[0]![0]).toBe(
+      'https://bigquery.googleapis.com/bigquery/v2/projects/session-project/jobs',
+    );
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      project: 'session-project',
+      dryRun: true,
+      estimate: { totalBytesProcessed: 2 ** 40, estimatedCostUsd: 6.25 },
+    });
+  });
+
+  test('returns an error without a project', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 0, stdout: '', stderr: '' });
+
+    const result = await tool({
+      query: 'SELECT 1',
+      dryRun: true,
+      maxRows: 100,
+      timeoutSeconds: 60,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(api.post).not.toHaveBeenCalled();
+  });
+
+  test('returns an error if the query fails', async () => {
+    const tool = createTool();
+    vi.mocked(api.post).mockRejectedValue(new Error('Syntax error: Unexpected end of script'));
+
+    const result = await tool({
+      query: 'SELECT',
+      project: 'p',
+      dryRun: true,
+      maxRows: 100,
+      timeoutSeconds: 60,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('Syntax error');
+  });
+
+  describe('statements that change data', () => {
+    const DELETE = 'DELETE FROM d.t WHERE true';
+
+    beforeEach(() => {
+      vi.mocked(api.post).mockResolvedValue({
+        statistics: { totalBytesProcessed: '0', query: { statementType: 'DELETE' } },
+      });
+    });
+
+    test('are blocked by a read-only profile', async () => {
+      const session = createSessionContext();
+      const profiles = createProfiles({ prod: { projects: ['p'], mutations: 'deny' } }, session);
+      profiles.use('prod');
+      const tool = createTool({ profiles });
+
+      const result = await tool({
+        query: DELETE,
+        project: 'p',
+        dryRun: false,
+        maxRows: 100,
+        timeoutSeconds: 60,
+      });
+
+      expect(result.isError).toBe(true);
+      expect(JSON.parse(result.content[0].text)).toMatchObject({
+        error: 'PROFILE_POLICY',
+        profile: 'prod',
+      });
+      expect(api.post).toHaveBeenCalledOnce();
+    });
+
+    test('need confirmation with confirmMutation', async () => {
+      const confirmMutation = vi.fn().mockResolvedValue('declined');
+      const tool = createTool({ confirmMutation });
+
+      const result = await tool({
+        query: DELETE,
+        project: 'p',
+        dryRun: false,
+        maxRows: 100,
+        timeoutSeconds: 60,
+      });
+
+      expect(confirmMutation).toHaveBeenCalledWith(`BigQuery DELETE in p:\n${DELETE}`);
+      expect(result.isError).toBe(true);
+      expect(JSON.parse(result.content[0].text)).toMatchObject({
+        error: 'MUTATION_DECLINED',
+        command: 'bigquery delete',
+      });
+      expect(api.post).toHaveBeenCalledOnce();
+    });
+
+    test('do not need confirmation for a dry run', async () => {
+      const confirmMutation = vi.fn();
+      const tool = createTool({ confirmMutation });
+
+      const result = await tool({
+        query: DELETE,
+        project: 'p',
+        dryRun: true,
+        maxRows: 100,
+        timeoutSeconds: 60,
+      });
+
+      expect(result.isError).toBeFalsy();
+      expect(confirmMutation).not.toHaveBeenCalled();
+    });
+  });
+});
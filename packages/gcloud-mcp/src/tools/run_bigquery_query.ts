@@ -0,0 +1,164 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { GoogleApiClient } from '../google_api.js';
+import { BigQueryConfig, DEFAULT_MAX_BYTES_BILLED, runQuery } from '../bigquery.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { RunGcloudCommandOptions, notConfirmedErrorMessage } from './run_gcloud_command.js';
+import { DESTRUCTIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+/** The options of the runner that also apply to queries. */
+export type RunBigQueryQueryOptions = Pick<
+  RunGcloudCommandOptions,
+  'readOnly' | 'confirmMutation' | 'profiles'
+>;
+
+export const createRunBigQueryQuery = (
+  gcloud: GcloudExecutable,
+  api: GoogleApiClient,
+  config: BigQueryConfig = {},
+  options: RunBigQueryQueryOptions = {},
+) => ({
+  register: (server: McpServer) => {
+    const cap = config.maxBytesBilled ?? DEFAULT_MAX_BYTES_BILLED;
+    server.registerTool(
+      'run_bigquery_query',
+      {
+        title: 'Run BigQuery query',
+        inputSchema: {
+          query: z.string().describe('The query in GoogleSQL (Standard SQL).'),
+          project: z
+            .string()
+            .optional()
+            .describe(
+              'The project the query runs and is billed in. Defaults to the session project.',
+            ),
+          dryRun: z
+            .boolean()
+            .default(true)
+            .describe('Only estimate the bytes scanned and the cost, without running the query.'),
+          maxBytesBilled: z
+            .number()
+            .int()
+            .positive()
+            .optional()
+            .describe(`Fails the query if it would bill more bytes. At most ${cap}.`),
+          maxRows: z
+            .number()
+            .int()
+            .min(1)
+            .max(10000)
+            .default(100)
+            .describe('The maximum number of rows to return.'),
+          location: z
+            .string()
+            .optional()
+            .describe('The location of the datasets, e.g. "EU". Detected from the query if unset.'),
+          timeoutSeconds: z
+            .number()
+            .int()
+            .min(1)
+            .max(600)
+            .default(60)
+            .describe('How long to wait for the query to complete.'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe(
+              'Set to true once the user has explicitly approved running a statement that changes data.',
+            ),
+        },
+        // Queries may modify tables with DML and DDL statements, unless the server is read-only.
+        annotations: options.readOnly ? READ_ONLY_TOOL : DESTRUCTIVE_TOOL,
+        description: `Runs a BigQuery query and returns the rows with their schema. Every query is dry-run first to estimate the bytes it scans and its on-demand cost, and is not run if the estimate exceeds the bytes billed cap of the server.
+
+## Instructions:
+- First call this tool with dryRun true, the default, and tell the user the estimated bytes and cost.
+- Only call it again with dryRun false once the user agrees to the cost.
+- To reduce the bytes scanned, select only the columns you need and filter on partition columns. LIMIT does not reduce them.
+- Statements other than SELECT, e.g. DELETE or DROP TABLE, need the same approval as gcloud commands that change state.
+- "truncated" is true if the query returned more rows than maxRows.`,
+      },
+      async ({
+        query,
+        project,
+        dryRun,
+        maxBytesBilled,
+        maxRows,
+        location,
+        timeoutSeconds,
+        confirm,
+      }) => {
+        const toolLogger = log.mcp('run_bigquery_query', { project, dryRun, maxBytesBilled });
+        try {
+          const target = project ?? (await sessionProject(gcloud));
+          if (!target) {
+            return errorTextResult(
+              'No project is set. Pass a project or set one with set_context.',
+            );
+          }
+          // Statements other than SELECT change data, so they pass the
+          // guardrails of gcloud commands that change state.
+          const approve = async (statementType: string | null) => {
+            if (statementType === 'SELECT') {
+              return undefined;
+            }
+            const statement = `bigquery ${(statementType ?? 'unknown').toLowerCase()}`;
+            const profileResult = options.profiles?.check(
+              statement,
+              [`--project=${target}`],
+              confirm ?? false,
+            );
+            if (profileResult && !profileResult.permitted) {
+              const { error, profile, message } = profileResult;
+              return JSON.stringify({ error, profile, message }, null, 2);
+            }
+            const confirmation = await options.confirmMutation?.(
+              `BigQuery ${statementType ?? 'statement'} in ${target}:\n${query}`,
+            );
+            return confirmation && confirmation !== 'confirmed'
+              ? notConfirmedErrorMessage(statement, confirmation)
+              : undefined;
+          };
+          const result = await runQuery(api, config, {
+            project: target,
+            query,
+            dryRun,
+            maxRows,
+            timeoutMs: timeoutSeconds * 1000,
+            approve,
+            ...(options.readOnly && { readOnly: true }),
+            ...(maxBytesBilled && { maxBytesBilled }),
+            ...(location && { location }),
+          });
+          return successfulTextResult(JSON.stringify(result, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'run_bigquery_query failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -17,9 +17,24 @@
 import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
 import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
 import * as gcloud from '../gcloud.js';
-import { createRunGcloudCommand } from './run_gcloud_command.js';
+import {
+  RunGcloudCommandOptions,
+  createGcloudCommandRunner,
+  createRunGcloudCommand,
+  runRecorded,
+} from './run_gcloud_command.js';
 import { McpConfig } from '../index.js';
 import { createAccessControlList } from '../denylist.js';
+import { createOutputChunks } from '../output_chunks.js';
+import { createRateLimiter } from '../rate_limiter.js';
+import { createProfiles } from '../profiles.js';
+import { createSessionContext } from '../session_context.js';
+import { createNamingPolicy } from '../naming_policy.js';
+import { createTelemetry } from '../telemetry.js';
+import { createResponseCache, forSession } from '../response_cache.js';
+import { createReadCache } from '../read_cache.js';
+import { createCommandHistory } from '../command_history.js';
+import { createImpersonationPolicy } from '../impersonation.js';
 
 vi.mock('../gcloud.js');
 vi.mock('child_process');
@@ -38,9 +53,9 @@ const getToolImplementation = () => {
   return (mockServer.registerTool as Mock).mock.calls[0]![2];
 };
 
-const createTool = (config: McpConfig = {}) => {
+const createTool = (config: McpConfig = {}, options: RunGcloudCommandOptions = {}) => {
   const acl = createAccessControlList(config.allow, [...(config.deny ?? []), 'interactive']);
-  createRunGcloudCommand(mockedGcloud, acl).register(mockServer);
+  createRunGcloudCommand(mockedGcloud, acl, options).register(mockServer);
   return getToolImplementation();
 };
 
@@ -56,6 +71,14 @@ const mockGcloudLint = () => {
   }));
 };
 
+// The duration of a command differs on every run.
+const executionOf = (argv: string[], exitCode: number | null = 0) => ({
+  argv,
+  exitCode,
+  durationMs: expect.any(Number),
+  gcloudVersion: null,
+});
+
 const mockGcloudInvoke = (stdout: string, stderr: string = '') => {
   const mockedInvoke = vi.mocked(mockedGcloud.invoke);
   mockedInvoke.mockResolvedValue({
@@ -75,6 +98,23 @@ describe('createRunGcloudCommand', () => {
     mockGcloudLint();
   });
 
+  test('is annotated as destructive', () => {
+    createTool();
+
+    expect((mockServer.registerTool as Mock).mock.calls[0]![1].annotations).toEqual({
+      readOnlyHint: false,
+      destructiveHint: true,
+    });
+  });
+
+  test('is annotated as read-only in read-only mode', () => {
+    createTool({}, { readOnly: true });
+
+    expect((mockServer.registerTool as Mock).mock.calls[0]![1].annotations).toEqual({
+      readOnlyHint: true,
+    });
+  });
+
   describe('gcloud-mcp debug config', () => {
     test('returns user-configured denylist', async () => {
       const tool = createTool({ deny: ['compute list'] });
@@ -117,6 +157,29 @@ describe('createRunGcloudCommand', () => {
       expect(result.isError).toBe(true);
     });
 
+    test('returns the matched rule for a denylisted flag', async () => {
+      const tool = createTool({ deny: ['compute create --network-tier=premium'] });
+
+      const result = await tool({ args: ['compute', 'create', '--network-tier=premium'] });
+
+      expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+      expect(result.content[0].text).toContain(
+        'matched rule: "compute create --network-tier=premium"',
+      );
+      expect(result.isError).toBe(true);
+    });
+
+    test('invokes gcloud when a denylisted flag is not used', async () => {
+      const tool = createTool({ deny: ['compute create --network-tier=premium'] });
+      const inputArgs = ['compute', 'create', '--network-tier=standard'];
+      mockGcloudInvoke('output');
+
+      const result = await tool({ args: inputArgs });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs);
+      expect(result.isError).toBeUndefined();
+    });
+
     test('invokes gcloud for non-denylisted command', async () => {
       const tool = createTool({ deny: ['compute list'] });
       const inputArgs = ['compute', 'create'];
@@ -132,6 +195,7 @@ describe('createRunGcloudCommand', () => {
             text: 'output',
           },
         ],
+        structuredContent: { json: null, execution: executionOf(inputArgs) },
       });
     });
   });
@@ -152,6 +216,7 @@ describe('createRunGcloudCommand', () => {
             text: 'output',
           },
         ],
+        structuredContent: { json: null, execution: executionOf(inputArgs) },
       });
     });
 
@@ -183,6 +248,110 @@ describe('createRunGcloudCommand', () => {
   });
 
   describe('gcloud invocation results', () => {
+    test('returns JSON output as structured content', async () => {
+      const tool = createTool();
+      const instances = [{ name: 'vm-1', status: 'RUNNING' }];
+      mockGcloudInvoke(JSON.stringify(instances), 'Listed 1 item.');
+
+      const args = ['compute', 'instances', 'list', '--format=json'];
+      const result = await tool({ args });
+
+      expect(result.structuredContent).toEqual({ json: instances, execution: executionOf(args) });
+      expect(JSON.parse(result.content[0].text.split('\nSTDERR:\n')[0])).toEqual(instances);
+    });
+
+    test('returns the first chunk of large output', async () => {
+      const outputChunks = createOutputChunks({ chunkKb: 1 });
+      const tool = createTool({}, { outputChunks });
+      mockGcloudInvoke('a'.repeat(1024) + 'b'.repeat(1024));
+
+      const result = await tool({ args: ['compute', 'instances', 'list'] });
+
+      expect(result.content[0].text).toMatch(/^a{1024}\nOUTPUT CHUNK:\n/);
+      expect(result.structuredContent).toEqual({
+        json: null,
+        chunk: { token: 'output-1', chunk: 0, chunks: 2, totalBytes: 2048 },
+        execution: executionOf(['compute', 'instances', 'list']),
+      });
+      expect(outputChunks.get('output-1', 1)?.text).toBe('b'.repeat(1024));
+    });
+
+    test('streams stderr lines as progress notifications', async () => {
+      const tool = createTool();
+      vi.mocked(mockedGcloud.invoke).mockImplementation(async (_args, _env, options) => {
+        options?.onStderrLine?.('Creating instance...');
+        options?.onStderrLine?.('Creating instance...done.');
+        return { code: 0, stdout: '[]', stderr: 'Creating instance...done.' };
+      });
+      const extra = {
+        sendNotification: vi.fn().mockResolvedValue(undefined),
+        _meta: { progressToken: 'token' },
+      };
+
+      await tool({ args: ['compute', 'instances', 'create'] }, extra);
+
+      expect(extra.sendNotification).toHaveBeenCalledTimes(2);
+      expect(extra.sendNotification).toHaveBeenLastCalledWith({
+        method: 'notifications/progress',
+        params: { progressToken: 'token', progress: 2, message: 'Creating instance...done.' },
+      });
+    });
+
+    test('does not stream progress without a progress token', async () => {
+      const tool = createTool();
+      mockGcloudInvoke('[]');
+      const extra = { sendNotification: vi.fn() };
+
+      await tool({ args: ['compute', 'instances', 'create'] }, extra);
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(['compute', 'instances', 'create']);
+      expect(extra.sendNotification).not.toHaveBeenCalled();
+    });
+
+    test('reports the execution with the arguments gcloud ran with', async () => {
+      const tool = createTool({}, { gcloudVersion: '499.0.0' });
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+        code: 1,
+        stdout: '[{"name": "vm-1"}]',
+        stderr: 'Some requests did not succeed.',
+        argv: ['compute', 'instances', 'list', '--format=json', '--project=p'],
+      });
+
+      const result = await tool({ args: ['compute', 'instances', 'list', '--format=json'] });
+
+      expect(result.structuredContent.execution).toEqual({
+        argv: ['compute', 'instances', 'list', '--format=json', '--project=p'],
+        exitCode: 1,
+        durationMs: expect.any(Number),
+        gcloudVersion: '499.0.0',
+      });
+    });
+
+    test('reports the execution of commands that were stopped', async () => {
+      const tool = createTool();
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+        code: null,
+        stdout: '',
+        stderr: 'Creating cluster...',
+        timedOut: true,
+      });
+
+      const args = ['container', 'clusters', 'create', 'c-1'];
+      const result = await tool({ args, timeout_seconds: 5 });
+
+      expect(result.isError).toBe(true);
+      expect(result.structuredContent).toEqual({ json: null, execution: executionOf(args, null) });
+    });
+
+    test('does not add structured content to errors', async () => {
+      const tool = createTool({ deny: ['compute list'] });
+
+      const result = await tool({ args: ['compute', 'list'] });
+
+      expect(result.isError).toBe(true);
+      expect(result.structuredContent).toBeUndefined();
+    });
+
     test('returns stdout and stderr when gcloud invocation is successful', async () => {
       const tool = createTool();
       const inputArgs = ['a', 'c'];
@@ -198,9 +367,42 @@ describe('createRunGcloudCommand', () => {
             text: 'output\nSTDERR:\nerror',
           },
         ],
+        structuredContent: { json: null, execution: executionOf(inputArgs) },
+      });
+    });
+
+    test('appends a remediation block when a failure is recognized', async () => {
+      const tool = createTool();
+      const inputArgs = ['compute', 'instances', 'list'];
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+        code: 1,
+        stdout: '',
+        stderr:
+          "ERROR: (gcloud.compute.instances.list) Some requests did not succeed:\n - Required 'compute.instances.list' permission for 'projects/my-project'",
+      });
+
+      const result = await tool({ args: inputArgs });
+      const [, remediation] = result.content[0].text.split('\nREMEDIATION:\n');
+
+      expect(JSON.parse(remediation)).toEqual({
+        reason: 'PERMISSION_DENIED',
+        summary: expect.any(String),
+        missingPermission: 'compute.instances.list',
+        role: 'roles/compute.viewer',
+        fixCommand:
+          'gcloud projects add-iam-policy-binding my-project --member=<PRINCIPAL> --role=roles/compute.viewer',
       });
     });
 
+    test('does not append a remediation block for successful invocations', async () => {
+      const tool = createTool();
+      mockGcloudInvoke('output', 'WARNING: PERMISSION_DENIED on an optional lookup');
+
+      const result = await tool({ args: ['a', 'c'] });
+
+      expect(result.content[0].text).not.toContain('REMEDIATION');
+    });
+
     test('returns error when gcloud invocation throws an error', async () => {
       const tool = createTool();
       const inputArgs = ['a', 'c'];
@@ -354,4 +556,574 @@ describe('createRunGcloudCommand', () => {
       expect(result.isError).toBe(true);
     });
   });
+
+  describe('with rate limiting', () => {
+    test('invokes gcloud while within the rate limit', async () => {
+      const rateLimiter = createRateLimiter({ compute: { qps: 1, burst: 1 } });
+      const tool = createTool({}, { rateLimiter });
+      const inputArgs = ['compute', 'instances', 'list'];
+      mockGcloudInvoke('output');
+
+      const result = await tool({ args: inputArgs });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(inputArgs);
+      expect(result.isError).toBeUndefined();
+    });
+
+    test('returns throttled error with retry after when the rate limit is exceeded', async () => {
+      const rateLimiter = createRateLimiter({ compute: { qps: 1, burst: 1 } }, 0);
+      const tool = createTool({}, { rateLimiter });
+      const inputArgs = ['compute', 'instances', 'list'];
+      mockGcloudInvoke('output');
+      await tool({ args: inputArgs });
+
+      const result = await tool({ args: inputArgs });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledTimes(1);
+      expect(result.isError).toBe(true);
+      const error = JSON.parse(result.content[0].text);
+      expect(error.error).toBe('THROTTLED');
+      expect(error.apiFamily).toBe('compute');
+      expect(error.retryAfterSeconds).toBeGreaterThan(0);
+    });
+
+    test('does not throttle other API families', async () => {
+      const rateLimiter = createRateLimiter({ compute: { qps: 1, burst: 1 } }, 0);
+      const tool = createTool({}, { rateLimiter });
+      mockGcloudInvoke('output');
+      await tool({ args: ['compute', 'instances', 'list'] });
+
+      const result = await tool({ args: ['logging', 'read'] });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledTimes(2);
+      expect(result.isError).toBeUndefined();
+    });
+  });
+
+  describe('with profiles', () => {
+    const createProfileTool = () => {
+      const profiles = createProfiles(
+        { staging: { projects: ['staging'], mutations: 'confirm' } },
+        createSessionContext(),
+      );
+      profiles.use('staging');
+      return createTool({}, { profiles });
+    };
+
+    test('requires confirmation for mutations', async () => {
+      const tool = createProfileTool();
+      mockGcloudInvoke('output');
+
+      const result = await tool({ args: ['compute', 'instances', 'delete', 'vm-1'] });
+
+      expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+      expect(result.isError).toBe(true);
+      expect(JSON.parse(result.content[0].text)).toMatchObject({
+        error: 'CONFIRMATION_REQUIRED',
+        profile: 'staging',
+      });
+    });
+
+    test('invokes gcloud for confirmed mutations', async () => {
+      const tool = createProfileTool();
+      mockGcloudInvoke('output');
+
+      const result = await tool({
+        args: ['compute', 'instances', 'delete', 'vm-1'],
+        confirm: true,
+      });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(['compute', 'instances', 'delete', 'vm-1']);
+      expect(result.isError).toBeUndefined();
+    });
+  });
+
+  describe('in read-only mode', () => {
+    test('rejects commands that change state or can not be classified', async () => {
+      const tool = createTool({}, { readOnly: true });
+      mockGcloudInvoke('output');
+
+      const deleted = await tool({ args: ['compute', 'instances', 'delete'], confirm: true });
+      const connected = await tool({ args: ['sql', 'connect'] });
+
+      expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+      expect(deleted.isError).toBe(true);
+      expect(JSON.parse(deleted.content[0].text)).toMatchObject({
+        error: 'READ_ONLY',
+        command: 'compute instances delete',
+      });
+      expect(JSON.parse(connected.content[0].text)).toMatchObject({ error: 'READ_ONLY' });
+    });
+
+    test('runs commands that only read state', async () => {
+      const tool = createTool({}, { readOnly: true });
+      mockGcloudInvoke('[]');
+
+      const result = await tool({ args: ['projects', 'get-iam-policy', '--format=json'] });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+        'projects',
+        'get-iam-policy',
+        '--format=json',
+      ]);
+      expect(result.isError).toBeUndefined();
+    });
+  });
+
+  describe('with an impersonation policy', () => {
+    const impersonation = createImpersonationPolicy({
+      serviceAccount: 'mcp-reader@ops.iam.gserviceaccount.com',
+      allowedServiceAccounts: ['mcp-deployer@ops.iam.gserviceaccount.com'],
+    });
+
+    test('rejects commands that impersonate other service accounts', async () => {
+      const tool = createTool({}, { impersonation });
+      mockGcloudInvoke('output');
+
+      const result = await tool({
+        args: [
+          'compute',
+          'instances',
+          'list',
+          '--impersonate-service-account=owner@ops.iam.gserviceaccount.com',
+        ],
+      });
+
+      expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+      expect(result.isError).toBe(true);
+      expect(JSON.parse(result.content[0].text)).toMatchObject({
+        error: 'IMPERSONATION_DENIED',
+        policy: 'impersonation.allowedServiceAccounts',
+        serviceAccount: 'owner@ops.iam.gserviceaccount.com',
+        allowedServiceAccounts: ['mcp-deployer@ops.iam.gserviceaccount.com'],
+      });
+    });
+
+    test('runs commands that impersonate an allowed service account', async () => {
+      const tool = createTool({}, { impersonation });
+      mockGcloudInvoke('output');
+      const args = [
+        'run',
+        'deploy',
+        '--impersonate-service-account=mcp-deployer@ops.iam.gserviceaccount.com',
+      ];
+
+      const result = await tool({ args });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(args);
+      expect(result.isError).toBeUndefined();
+    });
+  });
+
+  describe('with a timeout', () => {
+    test('passes the timeout of the call, or the default, to gcloud', async () => {
+      const tool = createTool({}, { timeoutSeconds: 60 });
+      mockGcloudInvoke('[]');
+
+      await tool({ args: ['compute', 'instances', 'list'] });
+      await tool({ args: ['compute', 'instances', 'list'], timeout_seconds: 5 });
+
+      expect(mockedGcloud.invoke).toHaveBeenNthCalledWith(
+        1,
+        ['compute', 'instances', 'list'],
+        undefined,
+        { timeoutMs: 60_000 },
+      );
+      expect(mockedGcloud.invoke).toHaveBeenNthCalledWith(
+        2,
+        ['compute', 'instances', 'list'],
+        undefined,
+        { timeoutMs: 5000 },
+      );
+    });
+
+    test('returns a TIMEOUT error with the timeout', async () => {
+      const tool = createTool();
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+        code: null,
+        stdout: '',
+        stderr: 'Creating cluster...',
+        timedOut: true,
+      });
+
+      const result = await tool({ args: ['container', 'clusters', 'create'], timeout_seconds: 5 });
+
+      expect(result.isError).toBe(true);
+      expect(JSON.parse(result.content[0].text)).toMatchObject({
+        error: 'TIMEOUT',
+        command: 'container clusters create',
+        timeoutSeconds: 5,
+        stderr: 'Creating cluster...',
+      });
+    });
+  });
+
+  describe('with a prompt', () => {
+    test('returns an INTERACTIVE_INPUT_REQUIRED error with the prompt', async () => {
+      const tool = createTool();
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+        code: null,
+        stdout: '',
+        stderr: 'The following instances will be deleted.\nDo you want to continue (Y/n)?  ',
+        prompt: 'Do you want to continue (Y/n)?',
+      });
+
+      const result = await tool({ args: ['compute', 'instances', 'delete', 'vm-1'] });
+
+      expect(result.isError).toBe(true);
+      expect(JSON.parse(result.content[0].text)).toMatchObject({
+        error: 'INTERACTIVE_INPUT_REQUIRED',
+        command: 'compute instances delete',
+        prompt: 'Do you want to continue (Y/n)?',
+      });
+    });
+  });
+
+  describe('with mutation confirmation', () => {
+    test('runs a mutation the user confirmed', async () => {
+      const confirmMutation = vi.fn().mockResolvedValue('confirmed');
+      const tool = createTool({}, { confirmMutation });
+      mockGcloudInvoke('deleted');
+
+      const result = await tool({ args: ['compute', 'instances', 'delete'] });
+
+      expect(confirmMutation).toHaveBeenCalledWith('gcloud compute instances delete');
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith(['compute', 'instances', 'delete']);
+      expect(result.isError).toBeUndefined();
+    });
+
+    test('rejects a mutation the user declined or could not confirm', async () => {
+      const confirmMutation = vi
+        .fn()
+        .mockResolvedValueOnce('declined')
+        .mockResolvedValueOnce('unavailable');
+      const tool = createTool({}, { confirmMutation });
+
+      const declined = await tool({ args: ['compute', 'instances', 'delete'], confirm: true });
+      const unavailable = await tool({ args: ['sql', 'connect'] });
+
+      expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+      expect(JSON.parse(declined.content[0].text)).toMatchObject({
+        error: 'MUTATION_DECLINED',
+        command: 'compute instances delete',
+      });
+      expect(JSON.parse(unavailable.content[0].text)).toMatchObject({
+        error: 'CONFIRMATION_UNAVAILABLE',
+      });
+    });
+
+    test('does not ask to confirm commands that only read state', async () => {
+      const confirmMutation = vi.fn();
+      const tool = createTool({}, { confirmMutation });
+      mockGcloudInvoke('[]');
+
+      await tool({ args: ['projects', 'get-iam-policy', '--format=json'] });
+
+      expect(confirmMutation).not.toHaveBeenCalled();
+      expect(mockedGcloud.invoke).toHaveBeenCalled();
+    });
+  });
+
+  describe('with a naming policy', () => {
+    const createPolicyTool = (enforce: boolean) => {
+      const namingPolicy = createNamingPolicy({ namePatterns: { '*': '^dev-' }, enforce });
+      return createTool({}, { namingPolicy });
+    };
+
+    beforeEach(() => {
+      vi.mocked(mockedGcloud.lint).mockResolvedValue({
+        success: true,
+        parsedCommand: 'compute instances create',
+      });
+    });
+
+    test('blocks create commands that violate an enforced policy', async () => {
+      const tool = createPolicyTool(true);
+      mockGcloudInvoke('output');
+
+      const result = await tool({ args: ['compute', 'instances', 'create', 'web-1'] });
+
+      expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+      expect(result.isError).toBe(true);
+      expect(JSON.parse(result.content[0].text)).toMatchObject({
+        error: 'NAMING_POLICY',
+        violations: [{ kind: 'name', subject: 'web-1' }],
+      });
+    });
+
+    test('runs compliant create commands', async () => {
+      const tool = createPolicyTool(true);
+      mockGcloudInvoke('output');
+
+      await tool({ args: ['compute', 'instances', 'create', 'dev-web-1'] });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalled();
+    });
+
+    test('does not block commands when the policy is not enforced', async () => {
+      const tool = createPolicyTool(false);
+      mockGcloudInvoke('output');
+
+      await tool({ args: ['compute', 'instances', 'create', 'web-1'] });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalled();
+    });
+  });
+
+  describe('with an output format', () => {
+    beforeEach(() => {
+      mockGcloudLint();
+    });
+
+    test('converts JSON output into a Markdown table', async () => {
+      const tool = createTool();
+      mockGcloudInvoke(JSON.stringify([{ name: 'vm-1', zone: 'us-east1-b' }]));
+
+      const result = await tool({
+        args: ['compute', 'instances', 'list'],
+        outputFormat: { format: 'markdown', columns: ['name'] },
+      });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+        'compute',
+        'instances',
+        'list',
+        '--format=json',
+      ]);
+      expect(result.content[0].text).toBe('| name |\n| --- |\n| vm-1 |');
+    });
+
+    test('returns CSV as an embedded resource', async () => {
+      const tool = createTool();
+      mockGcloudInvoke(JSON.stringify([{ name: 'vm-1' }]));
+
+      const result = await tool({
+        args: ['compute', 'instances', 'list', '--format=json(name)'],
+        outputFormat: { format: 'csv', asResource: true },
+      });
+
+      expect(result.content[1]).toEqual({
+        type: 'resource',
+        resource: {
+          uri: expect.stringMatching(/\.csv$/),
+          mimeType: 'text/csv',
+          text: 'name\nvm-1',
+        },
+      });
+    });
+
+    test('rejects formats other than JSON', async () => {
+      const tool = createTool();
+
+      const result = await tool({
+        args: ['compute', 'instances', 'list', '--format=table(name)'],
+        outputFormat: { format: 'csv' },
+      });
+
+      expect(result.isError).toBe(true);
+      expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('with console links', () => {
+    beforeEach(() => {
+      mockGcloudLint();
+    });
+
+    test('adds console URLs to resources in JSON output', async () => {
+      const tool = createTool();
+      const selfLink =
+        'https://www.googleapis.com/compute/v1/projects/p/zones/us-east1-b/instances/vm';
+      mockGcloudInvoke(JSON.stringify([{ name: 'vm', selfLink }]));
+
+      const result = await tool({ args: ['compute', 'instances', 'list', '--format=json'] });
+
+      expect(JSON.parse(result.content[0].text)).toEqual([
+        {
+          name: 'vm',
+          selfLink,
+          consoleUrl:
+            'https://console.cloud.google.com/compute/instancesDetail/zones/us-east1-b/instances/vm?project=p',
+        },
+      ]);
+    });
+
+    test('leaves other output unchanged', async () => {
+      const tool = createTool();
+      mockGcloudInvoke('[{"name": "vm"}]');
+
+      const result = await tool({ args: ['compute', 'instances', 'list', '--format=json'] });
+
+      expect(result.content[0].text).toBe('[{"name": "vm"}]');
+    });
+
+    test('adds the Logs Explorer link of log queries', async () => {
+      const tool = createTool();
+      mockGcloudInvoke('[]');
+
+      const result = await tool({ args: ['logging', 'read', 'severity>=ERROR', '--project=p'] });
+
+      expect(result.content[0].text).toContain(
+        '[]\nLOGS EXPLORER:\nhttps://console.cloud.google.com/logs/query;query=severity%3E%3DERROR;',
+      );
+    });
+  });
+
+  describe('with telemetry', () => {
+    test('records the command without its arguments', async () => {
+      const telemetry = createTelemetry({ monitoringProject: 'ops' });
+      const tool = createTool({}, { telemetry });
+      mockGcloudLint();
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+        code: 1,
+        stdout: '',
+        stderr: 'ERROR: (gcloud.compute.instances.list) unexpected failure',
+      });
+
+      await tool({ args: ['compute', 'instances', 'list', '--project=secret-project'] });
+
+      expect(telemetry.report().commands).toMatchObject([
+        { name: 'compute instances list', calls: 1, errors: 1 },
+      ]);
+      expect(telemetry.report().errorClasses).toEqual([
+        { errorClass: 'COMMAND_FAILED', count: 1 },
+      ]);
+    });
+  });
+
+  describe('with an offline cache', () => {
+    const offline = {
+      code: 1,
+      stdout: '',
+      stderr: 'ERROR: gcloud crashed (ConnectionError): HTTPSConnectionPool: Max retries exceeded',
+    };
+
+    test('serves the cached result of a read command marked as stale', async () => {
+      const cache = createResponseCache({});
+      const tool = createTool({}, { responseCache: forSession(cache, () => ({})) });
+      mockGcloudLint();
+      mockGcloudInvoke('[{"name": "vm-1"}]');
+      await tool({ args: ['compute', 'instances', 'list', '--format=json'] });
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue(offline);
+
+      const result = await tool({ args: ['compute', 'instances', 'list', '--format=json'] });
+
+      expect(result.isError).toBeUndefined();
+      expect(result.content[0].text).toContain('"name": "vm-1"');
+      expect(result.content[0].text).toContain('STALE:');
+      expect(result.content[0].text).toContain('"reason": "NETWORK_UNAVAILABLE"');
+    });
+
+    test('never caches mutations or credentials', async () => {
+      const cache = createResponseCache({});
+      const tool = createTool({}, { responseCache: forSession(cache, () => ({})) });
+      mockGcloudLint();
+      mockGcloudInvoke('done');
+
+      await tool({ args: ['compute', 'instances', 'delete', '--quiet'] });
+      await tool({ args: ['auth', 'print-access-token'] });
+
+      expect(cache.size()).toBe(0);
+    });
+
+    test('fails as usual without a cached result', async () => {
+      const cache = createResponseCache({});
+      const tool = createTool({}, { responseCache: forSession(cache, () => ({})) });
+      mockGcloudLint();
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue(offline);
+
+      const result = await tool({ args: ['compute', 'instances', 'list'] });
+
+      expect(result.content[0].text).not.toContain('STALE:');
+      expect(result.content[0].text).toContain('Max retries exceeded');
+    });
+  });
+
+  describe('with a read cache', () => {
+    test('serves a read command run again from the cache', async () => {
+      const telemetry = createTelemetry({ monitoringProject: 'ops' });
+      const tool = createTool({}, { readCache: createReadCache({}, () => ({})), telemetry });
+      mockGcloudLint();
+      mockGcloudInvoke('[{"name": "vm-1"}]');
+      await tool({ args: ['compute', 'instances', 'list', '--format=json', '--zones=a'] });
+
+      const args = ['compute', 'instances', 'list', '--zones=a', '--format=json'];
+      const result = await tool({ args });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+      expect(result.content[0].text).toContain('CACHED:');
+      expect(result.structuredContent).toEqual({ json: [{ name: 'vm-1' }] });
+      expect(telemetry.report().cache).toEqual({ hits: 1, misses: 1 });
+    });
+
+    test('runs the command again when the cache is bypassed', async () => {
+      const tool = createTool({}, { readCache: createReadCache({}, () => ({})) });
+      mockGcloudLint();
+      mockGcloudInvoke('[]');
+      await tool({ args: ['compute', 'instances', 'list'] });
+
+      const result = await tool({ args: ['compute', 'instances', 'list'], cache: false });
+
+      expect(mockedGcloud.invoke).toHaveBeenCalledTimes(2);
+      expect(result.content[0].text).not.toContain('CACHED:');
+    });
+
+    test('clears the cache when a command changes state', async () => {
+      const readCache = createReadCache({}, () => ({}));
+      const tool = createTool({}, { readCache });
+      mockGcloudLint();
+      mockGcloudInvoke('[]');
+
+      await tool({ args: ['compute', 'instances', 'list'] });
+      await tool({ args: ['compute', 'instances', 'delete', 'vm-1', '--quiet'] });
+
+      expect(readCache.stats().entries).toBe(0);
+    });
+
+    test('does not cache failed commands', async () => {
+      const readCache = createReadCache({}, () => ({}));
+      const tool = createTool({}, { readCache });
+      mockGcloudLint();
+      vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 1, stdout: '', stderr: 'boom' });
+
+      await tool({ args: ['compute', 'instances', 'list'] });
+
+      expect(readCache.stats().entries).toBe(0);
+    });
+  });
+});
+
+describe('runRecorded', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 2, stdout: '', stderr: 'not found' }),
+    };
+    mockGcloudLint();
+  });
+
+  test('returns the exit code of the command', async () => {
+    const history = createCommandHistory();
+    const run = createGcloudCommandRunner(mockedGcloud, createAccessControlList([], []), {
+      history,
+    });
+
+    const { exitCode, output } = await runRecorded(run, history, ['compute', 'disks', 'delete']);
+
+    expect(exitCode).toBe(2);
+    expect(output).toContain('not found');
+  });
+
+  test('returns a null exit code if the command was not run', async () => {
+    const history = createCommandHistory();
+    const run = createGcloudCommandRunner(mockedGcloud, createAccessControlList([], ['compute']), {
+      history,
+    });
+
+    const { exitCode } = await runRecorded(run, history, ['compute', 'disks', 'delete']);
+
+    expect(exitCode).toBeNull();
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
 });
@@ -15,11 +15,47 @@
  */
 
 import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { RequestHandlerExtra } from '@modelcontextprotocol/sdk/shared/protocol.js';
+import { ServerNotification, ServerRequest } from '@modelcontextprotocol/sdk/types.js';
 import { GcloudExecutable } from '../gcloud.js';
 import { AccessControlList } from '../denylist.js';
 import { findSuggestedAlternativeCommand } from '../suggest.js';
 import { z } from 'zod';
 import { log } from '../utility/logger.js';
+import { RateLimiter, apiFamilyOf } from '../rate_limiter.js';
+import { recordQueueTime, sessionThrottledErrorMessage } from '../session_limits.js';
+import {
+  EmbeddedResourceResultType,
+  TextResultType,
+  embeddedResourceResult,
+  errorTextResult,
+  successfulTextResult,
+} from './tool_result.js';
+import { CommandHistory } from '../command_history.js';
+import { Profiles } from '../profiles.js';
+import { NamingPolicy, proposedResourceOf } from '../naming_policy.js';
+import { ImpersonationPolicy, impersonatedAccountOf, rejectionMessage } from '../impersonation.js';
+import { findRemediation } from '../error_remediation.js';
+import { Telemetry } from '../telemetry.js';
+import {
+  SessionResponseCache,
+  connectivityFailureOf,
+  isCacheable,
+  staleBlock,
+} from '../response_cache.js';
+import { ReadCache, cachedBlock } from '../read_cache.js';
+import { classifyMutation } from './explain_command.js';
+import {
+  MIME_TYPES,
+  OutputFormat,
+  OutputFormatSchema,
+  formatOutput,
+  withJsonFormat,
+} from '../output_format.js';
+import { logsExplorerUrlOfCommand, withConsoleUrls } from '../console_links.js';
+import { OutputChunk, OutputChunks, chunkBlock } from '../output_chunks.js';
+import { MutationConfirmer } from '../mutation_confirmation.js';
+import { DESTRUCTIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
 
 const suggestionErrorMessage = (suggestedCommand: string) =>
   `Execution denied: This command not permitted. However, a similar command is permitted.
@@ -31,15 +67,581 @@ const aclErrorMessage = (aclMessage: string) =>
   '\n\n' +
   'To get the access control list details, invoke this tool again with the args ["gcloud-mcp", "debug", "config"]';
 
-export const createRunGcloudCommand = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+const throttledErrorMessage = (apiFamily: string, retryAfterMs: number) =>
+  JSON.stringify(
+    {
+      error: 'THROTTLED',
+      message: `Execution throttled: the client-side rate limit for the "${apiFamily}" API family was exceeded. Retry after the given delay.`,
+      apiFamily,
+      retryAfterSeconds: retryAfterMs / 1000,
+    },
+    null,
+    2,
+  );
+
+const readOnlyErrorMessage = (parsedCommand: string) =>
+  JSON.stringify(
+    {
+      error: 'READ_ONLY',
+      command: parsedCommand,
+      message:
+        'Execution denied: the server runs in read-only mode. Only commands that read state, e.g. list, describe, get-iam-policy, or logging read, are permitted. Do not retry this command.',
+    },
+    null,
+    2,
+  );
+
+const NOT_CONFIRMED_MESSAGES: Record<'declined' | 'unavailable', string> = {
+  declined: 'The user declined to run this command. Do not retry it unless the user asks you to.',
+  unavailable:
+    'The server requires the user to confirm commands that change state, but the client can not ask the user. Tell the user to run the command themselves.',
+};
+
+export const notConfirmedErrorMessage = (
+  parsedCommand: string,
+  confirmation: 'declined' | 'unavailable',
+) =>
+  JSON.stringify(
+    {
+      error: confirmation === 'declined' ? 'MUTATION_DECLINED' : 'CONFIRMATION_UNAVAILABLE',
+      command: parsedCommand,
+      message: NOT_CONFIRMED_MESSAGES[confirmation],
+    },
+    null,
+    2,
+  );
+
+// The end of the stderr of a command that timed out or waited for input is included in the error.
+const TIMEOUT_STDERR_CHARS = 2000;
+
+const timeoutErrorMessage = (parsedCommand: string, timeoutSeconds: number, stderr: string) =>
+  JSON.stringify(
+    {
+      error: 'TIMEOUT',
+      command: parsedCommand,
+      timeoutSeconds,
+      message: `gcloud did not finish within ${timeoutSeconds} seconds and was stopped. A command that changes state may have partially completed; check the resources before retrying.`,
+      stderr: stderr.slice(-TIMEOUT_STDERR_CHARS),
+    },
+    null,
+    2,
+  );
+
+const promptErrorMessage = (parsedCommand: string, prompt: string, stderr: string) =>
+  JSON.stringify(
+    {
+      error: 'INTERACTIVE_INPUT_REQUIRED',
+      command: parsedCommand,
+      prompt,
+      message:
+        'gcloud waited for an answer to a prompt, which this server can not give, and was stopped. Pass the answer as a flag, e.g. --quiet to accept the default answers once the user agrees to them, or ask the user to run the command themselves.',
+      stderr: stderr.slice(-TIMEOUT_STDERR_CHARS),
+    },
+    null,
+    2,
+  );
+
+export interface RunGcloudCommandOptions {
+  /** Rejects every command that is not known to only read state. */
+  readOnly?: boolean;
+  /** Asks the user to approve every command that is not known to only read state. */
+  confirmMutation?: MutationConfirmer;
+  /** Stops commands that run longer, unless a run sets its own timeout. */
+  timeoutSeconds?: number;
+  rateLimiter?: RateLimiter;
+  history?: CommandHistory;
+  profiles?: Profiles;
+  namingPolicy?: NamingPolicy;
+  /** Limits the service accounts commands may impersonate with their own flag. */
+  impersonation?: ImpersonationPolicy;
+  telemetry?: Telemetry;
+  /** Serves cached read results when the network or the credentials are unavailable. */
+  responseCache?: SessionResponseCache;
+  /** Serves the results of read commands run again within its TTL from the session cache. */
+  readCache?: ReadCache;
+  /**
+   * Returns large outputs of the tool in chunks. Other tools get the whole
+   * output from the runner.
+   */
+  outputChunks?: OutputChunks;
+  /** The version of the Cloud SDK gcloud runs from, reported with every execution. */
+  gcloudVersion?: string | null;
+}
+
+/** What the runner executed for a command, reported with the result of the tool. */
+export interface CommandExecution {
+  /** The arguments gcloud ran with, including the ones the server added, e.g. --project. */
+  argv: string[];
+  /** The exit code of gcloud, or null if it was stopped. */
+  exitCode: number | null;
+  durationMs: number;
+  gcloudVersion: string | null;
+}
+
+/** Options of a single run of a command. */
+export interface RunOptions {
+  /** Called with each progress line gcloud writes to stderr. */
+  onProgress?: (line: string) => void;
+  /** Overrides the default timeout of the runner. */
+  timeoutSeconds?: number;
+  /** Set to false to run a read command even if its result is cached. */
+  cache?: boolean;
+  /** Called once gcloud ran the command; not called for cached or rejected commands. */
+  onExecuted?: (execution: CommandExecution) => void;
+}
+
+/**
+ * Checks, runs and records a gcloud command, with optional environment overrides.
+ * `confirmed` is true if the user approved this specific command.
+ */
+export type GcloudCommandRunner = (
+  args: string[],
+  env?: NodeJS.ProcessEnv,
+  confirmed?: boolean,
+  runOptions?: RunOptions,
+) => Promise<TextResultType>;
+
+/**
+ * Runs an approved command and returns its exit code, or null if the runner
+ * refused to run it, e.g. because it is denied, or the exit code is unknown.
+ *
+ * Different commands may run concurrently; each finds its own history entry.
+ */
+export const runRecorded = async (
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+  args: string[],
+): Promise<{ exitCode: number | null; output: string }> => {
+  const lastIndex = history.list().at(-1)?.index ?? 0;
+  const result = await run(args, undefined, true);
+  // The runner records every command it runs, so a new entry holds the exit code.
+  const key = args.join('\0');
+  const entry = history
+    .list()
+    .find((candidate) => candidate.index > lastIndex && candidate.args.join('\0') === key);
+  return { exitCode: entry ? entry.exitCode : null, output: result.content[0].text };
+};
+
+export const createGcloudCommandRunner =
+  (
+    gcloud: GcloudExecutable,
+    acl: AccessControlList,
+    options: RunGcloudCommandOptions = {},
+  ): GcloudCommandRunner =>
+  async (args, env, confirmed = false, runOptions = {}) => {
+    const toolLogger = log.mcp('run_gcloud_command', args);
+
+    if (args.join(' ') === 'gcloud-mcp debug config') {
+      return successfulTextResult(acl.print());
+    }
+
+    let parsedCommand;
+    try {
+      // Lint parses and isolates the gcloud command from flags and positionals.
+      // Example
+      //   Given: gcloud compute --log-http=true instance list
+      //   Desired command string is: compute instances list
+      const parsedLintResult = await gcloud.lint(args.join(' '));
+      if (!parsedLintResult.success) {
+        return errorTextResult(parsedLintResult.error);
+      }
+      parsedCommand = parsedLintResult.parsedCommand;
+    } catch (e: unknown) {
+      const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+      return errorTextResult(`Failed to parse the input command. ${msg}`);
+    }
+
+    try {
+      const accessControlResult = acl.check(parsedCommand, args);
+      if (!accessControlResult.permitted) {
+        const suggestion = await findSuggestedAlternativeCommand(args, acl, gcloud);
+        if (suggestion) {
+          return errorTextResult(suggestionErrorMessage(suggestion));
+        } else {
+          return errorTextResult(aclErrorMessage(accessControlResult.message));
+        }
+      }
+
+      const impersonated = options.impersonation && impersonatedAccountOf(args);
+      const rejection = impersonated && options.impersonation?.check(impersonated);
+      if (rejection) {
+        toolLogger.warn('run_gcloud_command impersonation denied', { ...rejection });
+        return errorTextResult(rejectionMessage(rejection));
+      }
+
+      const verb = parsedCommand.split(' ').pop() ?? '';
+      // Commands that can not be classified are treated as mutations.
+      if (options.readOnly && classifyMutation(verb) !== false) {
+        return errorTextResult(readOnlyErrorMessage(parsedCommand));
+      }
+
+      const profileResult = options.profiles?.check(parsedCommand, args, confirmed);
+      if (profileResult && !profileResult.permitted) {
+        const { error, profile, message } = profileResult;
+        return errorTextResult(JSON.stringify({ error, profile, message }, null, 2));
+      }
+
+      const { namingPolicy } = options;
+      const proposed = namingPolicy?.enforced() ? proposedResourceOf(parsedCommand, args) : null;
+      const violations = namingPolicy && proposed ? namingPolicy.validate(proposed) : [];
+      if (violations.length > 0) {
+        return errorTextResult(
+          JSON.stringify(
+            {
+              error: 'NAMING_POLICY',
+              message: 'The command violates the naming policy. Fix the violations and retry.',
+              violations,
+            },
+            null,
+            2,
+          ),
+        );
+      }
+
+      if (options.confirmMutation && classifyMutation(verb) !== false) {
+        const confirmation = await options.confirmMutation(`gcloud ${args.join(' ')}`);
+        if (confirmation !== 'confirmed') {
+          toolLogger.warn('run_gcloud_command not confirmed', { confirmation });
+          return errorTextResult(notConfirmedErrorMessage(parsedCommand, confirmation));
+        }
+      }
+
+      const readCache =
+        classifyMutation(verb) === false && isCacheable(parsedCommand)
+          ? options.readCache
+          : undefined;
+      const useCache = runOptions.cache !== false;
+      const cachedRead = readCache && useCache ? readCache.lookup(args, env) : undefined;
+      if (cachedRead) {
+        toolLogger.info('run_gcloud_command served a cached result');
+        options.telemetry?.record({
+          kind: 'command',
+          name: parsedCommand,
+          latencyMs: 0,
+          ok: true,
+          cache: 'hit',
+        });
+        const output = cachedRead.stderr
+          ? `${cachedRead.stdout}\nSTDERR:\n${cachedRead.stderr}`
+          : cachedRead.stdout;
+        return successfulTextResult(output + cachedBlock(cachedRead));
+      }
+
+      if (options.rateLimiter) {
+        const rateLimitResult = await options.rateLimiter.acquire(apiFamilyOf(parsedCommand));
+        if (!rateLimitResult.acquired) {
+          toolLogger.warn('run_gcloud_command throttled', {
+            apiFamily: rateLimitResult.apiFamily,
+            retryAfterMs: rateLimitResult.retryAfterMs,
+          });
+          return errorTextResult(
+            throttledErrorMessage(rateLimitResult.apiFamily, rateLimitResult.retryAfterMs),
+          );
+        }
+        recordQueueTime(rateLimitResult.waitedMs);
+      }
+
+      toolLogger.info('Executing run_gcloud_command');
+      const start = Date.now();
+      const { onProgress } = runOptions;
+      const timeoutSeconds = runOptions.timeoutSeconds ?? options.timeoutSeconds;
+      const executionOptions = {
+        ...(onProgress && { onStderrLine: onProgress }),
+        ...(timeoutSeconds !== undefined && { timeoutMs: timeoutSeconds * 1000 }),
+      };
+      let invocation;
+      if (Object.keys(executionOptions).length > 0) {
+        invocation = gcloud.invoke(args, env, executionOptions);
+      } else {
+        invocation = env ? gcloud.invoke(args, env) : gcloud.invoke(args);
+      }
+      const { code, stdout, stderr, timedOut, prompt, throttled, argv } = await invocation;
+      if (throttled) {
+        toolLogger.warn('run_gcloud_command throttled', { ...throttled });
+        return errorTextResult(sessionThrottledErrorMessage(throttled));
+      }
+      const durationMs = Date.now() - start;
+      runOptions.onExecuted?.({
+        argv: argv ?? args,
+        exitCode: code,
+        durationMs,
+        gcloudVersion: options.gcloudVersion ?? null,
+      });
+      options.history?.record(args, code, env, parsedCommand);
+      if (readCache && code === 0) {
+        readCache.store(args, env, stdout, stderr);
+      } else if (classifyMutation(verb) !== false) {
+        // Reads cached before a change, even a failed one, may no longer be accurate.
+        options.readCache?.clear();
+      }
+      const remediation = code !== 0 ? findRemediation(stderr, args) : undefined;
+      options.telemetry?.record({
+        kind: 'command',
+        name: parsedCommand,
+        latencyMs: durationMs,
+        ok: code === 0,
+        ...(code !== 0 && {
+          errorClass: timedOut
+            ? 'TIMEOUT'
+            : prompt !== undefined
+              ? 'INTERACTIVE_INPUT_REQUIRED'
+              : (remediation?.reason ?? 'COMMAND_FAILED'),
+        }),
+        ...(readCache && useCache && { cache: 'miss' as const }),
+      });
+      if (timedOut) {
+        toolLogger.warn('run_gcloud_command timed out', { timeoutSeconds });
+        return errorTextResult(timeoutErrorMessage(parsedCommand, timeoutSeconds ?? 0, stderr));
+      }
+      if (prompt !== undefined) {
+        toolLogger.warn('run_gcloud_command waited for input', { prompt });
+        return errorTextResult(promptErrorMessage(parsedCommand, prompt, stderr));
+      }
+      const { responseCache } = options;
+      if (responseCache && classifyMutation(verb) === false && isCacheable(parsedCommand)) {
+        if (code === 0) {
+          responseCache.store(args, env ?? {}, stdout);
+        }
+        const failure = code !== 0 ? connectivityFailureOf(stderr) : undefined;
+        const cached = failure && responseCache.lookup(args, env ?? {});
+        if (failure && cached) {
+          toolLogger.warn('run_gcloud_command served a cached result', { reason: failure });
+          return successfulTextResult(
+            `${cached.stdout}\nSTDERR:\n${stderr}${staleBlock(cached, failure)}`,
+          );
+        }
+      }
+      // If the exit status is not zero, an error occurred and the output may be
+      // incomplete unless the command documentation notes otherwise. For example,
+      // a command that creates multiple resources may only create a few, list them
+      // on the standard output, and then exit with a non-zero status.
+      // See https://cloud.google.com/sdk/docs/scripting-gcloud#best_practices
+      let result = stdout;
+      if (code !== 0 || stderr) {
+        result += `\nSTDERR:\n${stderr}`;
+      }
+      if (remediation) {
+        result += `\nREMEDIATION:\n${JSON.stringify(remediation, null, 2)}`;
+      }
+      return successfulTextResult(result);
+    } catch (e: unknown) {
+      toolLogger.error('run_gcloud_command failed', e instanceof Error ? e : new Error(String(e)));
+      const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+      return errorTextResult(msg);
+    }
+  };
+
+// Blocks that may directly follow the standard output, e.g. of a cached result without stderr.
+const BLOCK_MARKERS = ['\nSTDERR:\n', '\nCACHED:\n'];
+
+/** Splits the text of a result into the standard output and the blocks after it. */
+const splitOutput = (text: string) => {
+  const positions = BLOCK_MARKERS.map((marker) => text.indexOf(marker)).filter((i) => i !== -1);
+  const marker = positions.length > 0 ? Math.min(...positions) : -1;
+  return marker === -1
+    ? { stdout: text, rest: '' }
+    : { stdout: text.slice(0, marker), rest: text.slice(marker) };
+};
+
+const parseJson = (text: string): { value: unknown } | null => {
+  try {
+    return { value: JSON.parse(text) };
+  } catch {
+    return null;
+  }
+};
+
+/**
+ * Runs a command with JSON output through the runner, so that it is checked
+ * and recorded like every other command, and returns the parsed output. The
+ * error result of the runner, or the output of a failed command, is returned
+ * as the error.
+ */
+export const runJsonCommand = async (
+  run: GcloudCommandRunner,
+  args: string[],
+): Promise<{ json: unknown } | { error: TextResultType }> => {
+  const result = await run(withJsonFormat(args) ?? args);
+  if (result.isError) {
+    return { error: result };
+  }
+  const text = result.content[0].text;
+  const parsed = parseJson(splitOutput(text).stdout);
+  return parsed ? { json: parsed.value } : { error: errorTextResult(text) };
+};
+
+const logsExplorerBlock = (args: string[]) => {
+  const url = logsExplorerUrlOfCommand(args);
+  return url ? `\nLOGS EXPLORER:\n${url}` : '';
+};
+
+/**
+ * Adds Cloud Console links to a result: a `consoleUrl` field for each resource
+ * in JSON output and the Logs Explorer link of log queries.
+ */
+const withConsoleLinks = (result: TextResultType, args: string[]): TextResultType => {
+  if (result.isError) {
+    return result;
+  }
+  const { stdout, rest } = splitOutput(result.content[0].text);
+  const parsed = parseJson(stdout);
+  const linked = parsed && withConsoleUrls(parsed.value);
+  const output = linked ? JSON.stringify(linked, null, 2) : stdout;
+  return successfulTextResult(output + rest + logsExplorerBlock(args));
+};
+
+/**
+ * Runs a command with JSON output and converts the result into a table. Output
+ * that is not JSON, e.g. of a failed command, is returned unchanged.
+ */
+const runFormatted = async (
+  run: GcloudCommandRunner,
+  args: string[],
+  confirm: boolean | undefined,
+  outputFormat: OutputFormat,
+  runOptions: RunOptions,
+) => {
+  const jsonArgs = withJsonFormat(args);
+  if (!jsonArgs) {
+    return errorTextResult(
+      'outputFormat needs JSON output. Remove --format or use a JSON projection, e.g. --format=json(name,zone).',
+    );
+  }
+  const result = await run(jsonArgs, undefined, confirm, runOptions);
+  if (result.isError) {
+    return result;
+  }
+  const { stdout, rest: stderr } = splitOutput(result.content[0].text);
+  const parsed = parseJson(stdout);
+  if (!parsed) {
+    return result;
+  }
+  const table = formatOutput(withConsoleUrls(parsed.value) ?? parsed.value, outputFormat);
+  const rest = stderr + logsExplorerBlock(args);
+  if (!outputFormat.asResource) {
+    return successfulTextResult(table + rest);
+  }
+  const extension = outputFormat.format === 'markdown' ? 'md' : 'csv';
+  return embeddedResourceResult(`Converted the result to ${outputFormat.format}.${rest}`, {
+    uri: `gcloud-mcp://results/${Date.now()}.${extension}`,
+    mimeType: MIME_TYPES[outputFormat.format],
+    text: table,
+  });
+};
+
+/** The structured content of successful results. */
+export const RunGcloudCommandOutputSchema = {
+  json: z
+    .unknown()
+    .describe('The parsed output if the command printed JSON, e.g. with --format=json, or null.'),
+  chunk: z
+    .object({
+      token: z.string(),
+      chunk: z.number(),
+      chunks: z.number(),
+      totalBytes: z.number(),
+    })
+    .optional()
+    .describe('Set if the output is too large and only the first chunk is returned.'),
+  execution: z
+    .object({
+      argv: z.array(z.string()),
+      exitCode: z.number().nullable(),
+      durationMs: z.number(),
+      gcloudVersion: z.string().nullable(),
+    })
+    .optional()
+    .describe(
+      'The exact arguments gcloud ran with, its exit code, its duration and the gcloud version. Not set if the command did not run, e.g. because it was denied or cached.',
+    ),
+};
+
+/**
+ * Adds the parsed JSON output and the execution, if the command ran, as
+ * structured content and keeps the text content for clients that predate it.
+ * Clients validate the structured content against the output schema, so every
+ * successful result has it. Errors only have it if the command ran, e.g. to
+ * report the exit code of a command that timed out.
+ */
+const withStructuredContent = (
+  result: TextResultType | EmbeddedResourceResultType,
+  execution?: CommandExecution,
+) => {
+  const executed = execution && { execution };
+  if ('isError' in result && result.isError) {
+    return executed ? { ...result, structuredContent: { json: null, ...executed } } : result;
+  }
+  const parsed = parseJson(splitOutput(result.content[0].text).stdout);
+  return { ...result, structuredContent: { json: parsed ? parsed.value : null, ...executed } };
+};
+
+/**
+ * Streams the progress lines of gcloud, e.g. "Creating instance...done.", as
+ * progress notifications if the client asked for progress.
+ */
+const progressReporter = (extra?: RequestHandlerExtra<ServerRequest, ServerNotification>) => {
+  const progressToken = extra?._meta?.progressToken;
+  if (extra === undefined || progressToken === undefined) {
+    return undefined;
+  }
+  let progress = 0;
+  return (line: string) => {
+    extra
+      .sendNotification({
+        method: 'notifications/progress',
+        params: { progressToken, progress: ++progress, message: line.trim() },
+      })
+      .catch((e: unknown) =>
+        log.warn('Unable to report the progress of a gcloud command', { error: String(e) }),
+      );
+  };
+};
+
+/** Returns the first chunk of an output that is too large to return at once. */
+const chunkResult = (chunk: OutputChunk, execution?: CommandExecution) => {
+  const { text, ...metadata } = chunk;
+  return {
+    ...successfulTextResult(text + chunkBlock(metadata)),
+    structuredContent: { json: null, chunk: metadata, ...(execution && { execution }) },
+  };
+};
+
+export const createRunGcloudCommand = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  options: RunGcloudCommandOptions = {},
+) => ({
   register: (server: McpServer) => {
+    const run = createGcloudCommandRunner(gcloud, acl, options);
     server.registerTool(
       'run_gcloud_command',
       {
         title: 'Run gcloud command',
         inputSchema: {
           args: z.array(z.string()),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true only after the user approved this exact command.'),
+          timeout_seconds: z
+            .number()
+            .int()
+            .positive()
+            .optional()
+            .describe('Stops the command if it runs longer, e.g. 600 for creating a cluster.'),
+          outputFormat: OutputFormatSchema.optional().describe(
+            'Converts the JSON output into a Markdown table or CSV, e.g. when the user asks for a table.',
+          ),
+          cache: z
+            .boolean()
+            .optional()
+            .describe(
+              'Set to false to run a read command again instead of returning a cached result.',
+            ),
         },
+        outputSchema: RunGcloudCommandOutputSchema,
+        // In read-only mode the runner rejects every command that may change state.
+        annotations: options.readOnly ? READ_ONLY_TOOL : DESTRUCTIVE_TOOL,
         description: `Executes a gcloud command.
 
 ## Instructions:
@@ -58,78 +660,48 @@ export const createRunGcloudCommand = (gcloud: GcloudExecutable, acl: AccessCont
 - Retrieve only necessary information for the user intent. Utilize projection capability of '--format' reduce data size.
 - If the exact JSON key path for formatting or filtering is unknown, run 'gcloud ... --limit=1 --format=json' to discover it.
 - If you receive zero results while using a projection or filter: Consider whether the project/filter syntax may be incorrect.
+- If the result is a CONFIRMATION_REQUIRED error, ask the user to approve the command before invoking this tool again with "confirm": true.
+- If a failed command's output includes a REMEDIATION block, its "reason" classifies the failure. Propose its fix to the user rather than running it yourself; once approved, pass its "fixArgs", if any, as the args of this tool.
+- For reason UNKNOWN_FLAG, "fixArgs" are the same command with the flags gcloud suggests, and need no further approval than the original command.
+- When the user asks for a table or CSV, set "outputFormat" instead of converting the output yourself.
+- With JSON output, the structured content of the result holds the parsed JSON under "json".
+- Once gcloud ran the command, the structured content holds the exact "argv", the "exitCode", the "durationMs" and the "gcloudVersion" under "execution". A non-zero exit code with output means the command may have partially succeeded.
+- If the output ends with an OUTPUT CHUNK block, it is too large to return at once. Prefer narrowing the command with --filter, --limit or a --format projection; use 'get_output_chunk' only when the rest of the output is needed.
+- Share the "consoleUrl" of resources and the LOGS EXPLORER link when the user may want to open them in the Cloud Console.
+- If the result is a THROTTLED error, wait "retryAfterSeconds" before retrying, and run fewer commands at the same time.
+- If the result is a TIMEOUT error, check whether the command partially completed before retrying it, with a larger "timeout_seconds" if the command is expected to take long.
+- If the result is an INTERACTIVE_INPUT_REQUIRED error, the command asked for input. Tell the user the prompt, and retry with the answer as a flag only after the user agrees to it.
+- Output may have tokens, private keys and secret payloads replaced with [REDACTED ...] markers. Do not try to work around the masking; tell the user to read the secret themselves.
+- If the output ends with a CACHED block, the same command ran earlier in this session. Set "cache" to false only when the user needs the current state, e.g. after waiting for an operation to finish.
+- If the output includes a STALE block, Google Cloud could not be reached and the output is cached from an earlier run. Always tell the user it may be out of date.
 
 ## Adhere to the following restrictions:
 - **No command substitution**: Do not use subshells or command substitution (e.g., $(...))
 - **No pipes**: Do not use pipes (i.e., |) or any other shell-specific operators
 - **No redirection**: Do not use redirection operators (e.g., >, >>, <)`,
       },
-      async ({ args }) => {
-        const toolLogger = log.mcp('run_gcloud_command', args);
-
-        if (args.join(' ') === 'gcloud-mcp debug config') {
-          return successfulTextResult(acl.print());
-        }
-
-        let parsedCommand;
-        try {
-          // Lint parses and isolates the gcloud command from flags and positionals.
-          // Example
-          //   Given: gcloud compute --log-http=true instance list
-          //   Desired command string is: compute instances list
-          const parsedLintResult = await gcloud.lint(args.join(' '));
-          if (!parsedLintResult.success) {
-            return errorTextResult(parsedLintResult.error);
-          }
-          parsedCommand = parsedLintResult.parsedCommand;
-        } catch (e: unknown) {
-          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
-          return errorTextResult(`Failed to parse the input command. ${msg}`);
-        }
-
-        try {
-          const accessControlResult = acl.check(parsedCommand);
-          if (!accessControlResult.permitted) {
-            const suggestion = await findSuggestedAlternativeCommand(args, acl, gcloud);
-            if (suggestion) {
-              return errorTextResult(suggestionErrorMessage(suggestion));
-            } else {
-              return errorTextResult(aclErrorMessage(accessControlResult.message));
-            }
-          }
-
-          toolLogger.info('Executing run_gcloud_command');
-          const { code, stdout, stderr } = await gcloud.invoke(args);
-          // If the exit status is not zero, an error occurred and the output may be
-          // incomplete unless the command documentation notes otherwise. For example,
-          // a command that creates multiple resources may only create a few, list them
-          // on the standard output, and then exit with a non-zero status.
-          // See https://cloud.google.com/sdk/docs/scripting-gcloud#best_practices
-          let result = stdout;
-          if (code !== 0 || stderr) {
-            result += `\nSTDERR:\n${stderr}`;
-          }
-          return successfulTextResult(result);
-        } catch (e: unknown) {
-          toolLogger.error(
-            'run_gcloud_command failed',
-            e instanceof Error ? e : new Error(String(e)),
-          );
-          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
-          return errorTextResult(msg);
+      async ({ args, confirm, outputFormat, timeout_seconds, cache }, extra) => {
+        const onProgress = progressReporter(extra);
+        const executed: { execution?: CommandExecution } = {};
+        const runOptions = {
+          ...(onProgress && { onProgress }),
+          ...(timeout_seconds !== undefined && { timeoutSeconds: timeout_seconds }),
+          ...(cache !== undefined && { cache }),
+          onExecuted: (execution: CommandExecution) => {
+            executed.execution = execution;
+          },
+        };
+        if (outputFormat) {
+          const formatted = await runFormatted(run, args, confirm, outputFormat, runOptions);
+          return withStructuredContent(formatted, executed.execution);
         }
+        const result = withConsoleLinks(await run(args, undefined, confirm, runOptions), args);
+        const { outputChunks } = options;
+        const chunk = result.isError ? undefined : outputChunks?.store(result.content[0].text);
+        return chunk
+          ? chunkResult(chunk, executed.execution)
+          : withStructuredContent(result, executed.execution);
       },
     );
   },
 });
-
-type TextResultType = { content: [{ type: 'text'; text: string }]; isError?: boolean };
-
-const successfulTextResult = (text: string): TextResultType => ({
-  content: [{ type: 'text', text }],
-});
-
-const errorTextResult = (text: string): TextResultType => ({
-  content: [{ type: 'text', text }],
-  isError: true,
-});
@@ -0,0 +1,187 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { parseRunbook } from '../runbooks.js';
+import { ToolRegistry } from '../scheduler.js';
+import { createRunbookTools } from './runbooks.js';
+
+const RUNBOOK = parseRunbook(`
+name: rotate-key
+description: Rotates the key of a service account.
+parameters:
+  account: {}
+steps:
+  - id: create
+    tool: run_gcloud_command
+    confirm: true
+    arguments:
+      args: [iam, service-accounts, keys, create, key.json, "--iam-account={{ parameters.account }}"]
+    rollback:
+      tool: run_gcloud_command
+      arguments:
+        args: [iam, service-accounts, keys, delete, "{{ steps.create.output }}"]
+`);
+
+const textResult = (text: string, isError = false) => ({
+  content: [{ type: 'text', text }],
+  ...(isError && { isError }),
+});
+
+let mockServer: McpServer;
+let registry: ToolRegistry;
+
+const register = () => {
+  createRunbookTools(registry, [RUNBOOK]).register(mockServer);
+  const tool = (name: string) =>
+    (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+  return {
+    list: tool('list_runbooks'),
+    run: tool('run_runbook'),
+    prompt: (mockServer.registerPrompt as Mock).mock.calls[0]!,
+  };
+};
+
+const extra = { sendNotification: vi.fn().mockResolvedValue(undefined) };
+
+describe('createRunbookTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    registry = {
+      has: vi.fn().mockReturnValue(true),
+      call: vi.fn().mockResolvedValue(textResult('key-123')),
+    };
+    mockServer = { registerTool: vi.fn(), registerPrompt: vi.fn() } as unknown as McpServer;
+  });
+
+  test('lists runbooks and the runs of the session', async () => {
+    const { list, run } = register();
+    await run({ runbook: 'rotate-key', parameters: { account: 'sa' } }, extra);
+
+    const result = JSON.parse((await list({})).content[0].text);
+
+    expect(result.runbooks[0]).toMatchObject({
+      name: 'rotate-key',
+      steps: [{ id: 'create', confirm: true, rollback: true }],
+    });
+    expect(result.runs).toEqual([
+      expect.objectContaining({ id: 'rotate-key-1', status: 'awaiting-confirmation' }),
+    ]);
+  });
+
+  test('runs the pending step after confirmation and reports progress', async () => {
+    const { run } = register();
+    const parameters = { account: 'sa@p.iam.gserviceaccount.com' };
+    const started = await run({ runbook: 'rotate-key', parameters }, extra);
+    expect(JSON.parse(started.content[0].text).pending).toEqual({
+      step: 'create',
+      tool: 'run_gcloud_command',
+      arguments: {
+        args: [
+          'iam',
+          'service-accounts',
+          'keys',
+          'create',
+          'key.json',
+          '--iam-account=sa@p.iam.gserviceaccount.com',
+        ],
+      },
+    });
+    expect(registry.call).not.toHaveBeenCalled();
+
+    const progressExtra = { ...extra, _meta: { progressToken: 'token' } };
+    const resumed = await run(
+      { parameters: {}, runId: 'rotate-key-1', confirm: true },
+      progressExtra,
+    );
+
+    expect(JSON.parse(resumed.content[0].text)).toMatchObject({
+      status: 'succeeded',
+      steps: [{ id: 'create', status: 'succeeded', output: 'key-123' }],
+    });
+    expect(extra.sendNotification).toHaveBeenCalledWith({
+      method: 'notifications/progress',
+      params: {
+        progressToken: 'token',
+        progress: 0,
+        total: 1,
+        message: 'Running step 1 of 1: create',
+      },
+    });
+  });
+
+  test('plans a rollback and runs it after confirmation', async () => {
+    const { run } = register();
+    await run({ runbook: 'rotate-key', parameters: { account: 'sa' } }, extra);
+    await run({ parameters: {}, runId: 'rotate-key-1', confirm: true }, extra);
+
+    const plan = await run({ parameters: {}, runId: 'rotate-key-1', rollback: true }, extra);
+
+    expect(JSON.parse(plan.content[0].text).rollback).toEqual([
+      {
+        step: 'create',
+        tool: 'run_gcloud_command',
+        arguments: { args: ['iam', 'service-accounts', 'keys', 'delete', 'key-123'] },
+      },
+    ]);
+    expect(registry.call).toHaveBeenCalledTimes(1);
+
+    const rolledBack = await run(
+      { parameters: {}, runId: 'rotate-key-1', rollback: true, confirm: true },
+      extra,
+    );
+
+    expect(JSON.parse(rolledBack.content[0].text).status).toBe('rolled-back');
+    expect(registry.call).toHaveBeenLastCalledWith('run_gcloud_command', {
+      args: ['iam', 'service-accounts', 'keys', 'delete', 'key-123'],
+      confirm: true,
+    });
+  });
+
+  test('returns an error when a step fails', async () => {
+    vi.mocked(registry.call).mockResolvedValue(textResult('PERMISSION_DENIED', true));
+    const { run } = register();
+    await run({ runbook: 'rotate-key', parameters: { account: 'sa' } }, extra);
+
+    const result = await run({ parameters: {}, runId: 'rotate-key-1', confirm: true }, extra);
+
+    expect(result.isError).toBe(true);
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      status: 'failed',
+      steps: [{ status: 'failed', output: 'PERMISSION_DENIED' }],
+    });
+  });
+
+  test('requires a runbook or a run ID', async () => {
+    const { run } = register();
+    expect((await run({ parameters: {} }, extra)).isError).toBe(true);
+    expect((await run({ parameters: {}, runId: 'missing-1' }, extra)).content[0].text).toBe(
+      'No run with ID "missing-1" is kept in this session.',
+    );
+  });
+
+  test('registers each runbook as a prompt', () => {
+    const { prompt } = register();
+    const [name, config, callback] = prompt;
+
+    expect(name).toBe('rotate-key');
+    expect(Object.keys(config.argsSchema)).toEqual(['account']);
+    const text = callback({ account: 'sa' }).messages[0].content.text;
+    expect(text).toContain('1. create: run_gcloud_command (requires my approval)');
+    expect(text).toContain('"parameters": {"account":"sa"}');
+  });
+});
@@ -0,0 +1,230 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { RequestHandlerExtra } from '@modelcontextprotocol/sdk/shared/protocol.js';
+import { ServerNotification, ServerRequest } from '@modelcontextprotocol/sdk/types.js';
+import { z } from 'zod';
+import { Runbook, RunbookRun, RunbookResult, createRunbookEngine } from '../runbooks.js';
+import { ToolRegistry } from '../scheduler.js';
+import { log } from '../utility/logger.js';
+import { DESTRUCTIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+// The output of each step is truncated to this many characters in results.
+export const MAX_STEP_OUTPUT_CHARS = 2000;
+
+const summaryOf = (run: RunbookRun) => ({
+  ...run,
+  steps: run.steps.map(({ output, rollbackOutput, ...step }) => ({
+    ...step,
+    ...(output !== undefined && { output: output.slice(0, MAX_STEP_OUTPUT_CHARS) }),
+    ...(rollbackOutput !== undefined && {
+      rollbackOutput: rollbackOutput.slice(0, MAX_STEP_OUTPUT_CHARS),
+    }),
+  })),
+});
+
+const runResult = (result: RunbookResult) => {
+  if (!result.success) {
+    return errorTextResult(result.error);
+  }
+  const text = JSON.stringify(summaryOf(result.run), null, 2);
+  return result.run.status === 'failed' || result.run.status === 'rollback-failed'
+    ? errorTextResult(text)
+    : successfulTextResult(text);
+};
+
+/** Reports each step as a progress notification if the client asked for progress. */
+const progressReporter =
+  (extra: RequestHandlerExtra<ServerRequest, ServerNotification>) =>
+  (run: RunbookRun, index: number) => {
+    const progressToken = extra._meta?.progressToken;
+    if (progressToken === undefined) {
+      return;
+    }
+    extra
+      .sendNotification({
+        method: 'notifications/progress',
+        params: {
+          progressToken,
+          progress: index,
+          total: run.steps.length,
+          message: `Running step ${index + 1} of ${run.steps.length}: ${run.steps[index]?.id}`,
+        },
+      })
+      .catch((e: unknown) =>
+        log.warn(`Unable to report the progress of run ${run.id}`, { error: String(e) }),
+      );
+  };
+
+const promptText = (runbook: Runbook, parameters: Record<string, string | undefined>) => {
+  const steps = runbook.steps
+    .map((step, index) => {
+      const gate = step.confirm ? ' (requires my approval)' : '';
+      return `${index + 1}. ${step.id}: ${step.description ?? step.tool}${gate}`;
+    })
+    .join('\n');
+  const given = Object.fromEntries(
+    Object.entries(parameters).filter(([, value]) => value !== undefined && value !== ''),
+  );
+  return `Run the "${runbook.title ?? runbook.name}" runbook: ${runbook.description}
+
+Its steps are:
+${steps}
+
+1. Call run_runbook with "runbook": "${runbook.name}" and "parameters": ${JSON.stringify(given)}.
+2. When the run stops for confirmation, show me the pending step and its arguments, and call run_runbook with the run ID and "confirm": true only after I approve it.
+3. If a step fails, explain the error and ask me whether to fix the cause and resume the run with its run ID, or to roll it back with "rollback": true.
+4. When the run finishes, summarize the outcome of each step.`;
+};
+
+export const createRunbookTools = (registry: ToolRegistry, runbooks: Runbook[]) => ({
+  register: (server: McpServer) => {
+    const engine = createRunbookEngine(runbooks, registry);
+
+    server.registerTool(
+      'list_runbooks',
+      {
+        title: 'List runbooks',
+        inputSchema: {},
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the runbooks of the server with their parameters and steps, and the runs of this session with their status.`,
+      },
+      async () => {
+        log.mcp('list_runbooks', {});
+        const result = {
+          runbooks: engine.list().map(({ name, title, description, parameters, steps }) => ({
+            name,
+            ...(title && { title }),
+            description,
+            parameters,
+            steps: steps.map(({ id, description: stepDescription, tool, confirm, rollback }) => ({
+              id,
+              ...(stepDescription && { description: stepDescription }),
+              tool,
+              confirm,
+              rollback: rollback !== undefined,
+            })),
+          })),
+          runs: engine.runs().map(({ id, runbook, status, updatedAt }) => ({
+            id,
+            runbook,
+            status,
+            updatedAt,
+          })),
+        };
+        return successfulTextResult(JSON.stringify(result, null, 2));
+      },
+    );
+
+    server.registerTool(
+      'run_runbook',
+      {
+        title: 'Run runbook',
+        inputSchema: {
+          runbook: z.string().optional().describe('The name of the runbook to start.'),
+          parameters: z
+            .record(z.string())
+            .default({})
+            .describe('The parameters of the runbook to start, by name.'),
+          runId: z
+            .string()
+            .optional()
+            .describe('The ID of a run to resume or roll back, instead of starting a runbook.'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe(
+              'Approves the step the run is waiting for, or the rollback plan. Requires runId.',
+            ),
+          rollback: z
+            .boolean()
+            .optional()
+            .describe('Rolls back the steps of the run that succeeded, last step first.'),
+        },
+        annotations: DESTRUCTIVE_TOOL,
+        description: `Runs a runbook: a reviewed sequence of tool calls defined by the operators of the server, with conditions on the results of earlier steps, confirmation gates, and rollback steps. The result reports the status and output of every step.
+
+## Instructions:
+- Call list_runbooks to find the runbook for a task and its parameters.
+- A run stops with status "awaiting-confirmation" before a step that changes resources. Show the user the pending tool call and, after they approve, call this tool with the runId and "confirm": true. Each approval covers one step.
+- A run stops with status "failed" at the first step that fails. After fixing the cause, call this tool with the runId to retry the step and continue.
+- To revert a run, call this tool with the runId and "rollback": true to get the rollback plan, then again with "confirm": true after the user approves it.
+- Runs are kept for the session only.`,
+      },
+      async ({ runbook, parameters, runId, confirm, rollback }, extra) => {
+        log.mcp('run_runbook', { runbook, parameters, runId, confirm, rollback });
+        const onStep = progressReporter(extra);
+        if (!runId) {
+          if (!runbook) {
+            return errorTextResult('Pass a runbook to start, or the runId of a run to resume.');
+          }
+          return runResult(await engine.start(runbook, parameters, { onStep }));
+        }
+        if (!rollback) {
+          return runResult(await engine.resume(runId, { confirm: confirm === true, onStep }));
+        }
+        const plan = engine.rollbackPlan(runId);
+        if (typeof plan === 'string') {
+          return errorTextResult(plan);
+        }
+        if (plan.length === 0) {
+          return errorTextResult(`Run "${runId}" has no succeeded steps with a rollback.`);
+        }
+        if (!confirm) {
+          return successfulTextResult(
+            JSON.stringify(
+              {
+                runId,
+                rollback: plan,
+                message:
+                  'These calls run, in this order, after the user approves the rollback. Call run_runbook with the runId, "rollback": true and "confirm": true to run them.',
+              },
+              null,
+              2,
+            ),
+          );
+        }
+        return runResult(await engine.rollback(runId, plan, onStep));
+      },
+    );
+
+    for (const runbook of runbooks) {
+      server.registerPrompt(
+        runbook.name,
+        {
+          title: runbook.title ?? runbook.name,
+          description: runbook.description,
+          argsSchema: Object.fromEntries(
+            Object.entries(runbook.parameters).map(([name, parameter]) => {
+              const schema = z.string().describe(parameter.description ?? name);
+              return [name, parameter.default === undefined ? schema : schema.optional()];
+            }),
+          ),
+        },
+        (parameters: Record<string, string | undefined>) => ({
+          messages: [
+            {
+              role: 'user',
+              content: { type: 'text', text: promptText(runbook, parameters) },
+            },
+          ],
+        }),
+      );
+    }
+  },
+});
@@ -0,0 +1,183 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createAccessControlList } from '../denylist.js';
+import { ScheduledJobConfig, ToolRegistry } from '../scheduler.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { createScheduledJobTools } from './scheduled_jobs.js';
+
+let run: GcloudCommandRunner;
+let mockServer: McpServer;
+let registry: ToolRegistry;
+
+const register = (
+  deny: string[] = [],
+  schedules?: Record<string, ScheduledJobConfig>,
+  readOnly = false,
+) => {
+  createScheduledJobTools(
+    createAccessControlList([], deny),
+    run,
+    registry,
+    schedules,
+    readOnly,
+  ).register(mockServer);
+  const tool = (name: string) =>
+    (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+  return {
+    schedule: tool('schedule_job'),
+    list: tool('list_scheduled_jobs'),
+    unschedule: tool('unschedule_job'),
+    read: (mockServer.registerResource as Mock).mock.calls[0]![3],
+  };
+};
+
+describe('createScheduledJobTools', () => {
+  beforeEach(() => {
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date('2025-01-01T00:00:00Z'));
+    run = vi.fn();
+    registry = {
+      has: vi.fn().mockReturnValue(true),
+      call: vi.fn().mockResolvedValue({ content: [{ type: 'text', text: 'no idle resources' }] }),
+    };
+    mockServer = {
+      registerTool: vi.fn(),
+      registerResource: vi.fn(),
+      sendLoggingMessage: vi.fn().mockResolvedValue(undefined),
+      server: { sendResourceUpdated: vi.fn().mockResolvedValue(undefined) },
+    } as unknown as McpServer;
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  test('schedules a job and notifies the client of its runs', async () => {
+    const { schedule, read } = register();
+
+    const result = await schedule({
+      name: 'idle',
+      tool: 'find_idle_resources',
+      arguments: {},
+      schedule: '@hourly',
+    });
+
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      name: 'idle',
+      uri: 'gcloud-schedule://idle',
+      tool: 'find_idle_resources',
+      schedule: '@hourly',
+      nextRunAt: '2025-01-01T01:00:00.000Z',
+      lastRun: null,
+    });
+    await vi.advanceTimersByTimeAsync(60 * 60 * 1000);
+
+    expect(mockServer.server.sendResourceUpdated).toHaveBeenCalledWith({
+      uri: 'gcloud-schedule://idle',
+    });
+    expect(mockServer.sendLoggingMessage).toHaveBeenCalledWith(
+      expect.objectContaining({
+        level: 'info',
+        logger: 'scheduler',
+        data: expect.objectContaining({ name: 'idle', isError: false }),
+      }),
+    );
+    const { contents } = read(new URL('gcloud-schedule://idle'), { name: 'idle' });
+    expect(JSON.parse(contents[0].text).runs[0].result).toBe('no idle resources');
+  });
+
+  test('returns an error for invalid jobs', async () => {
+    const { schedule } = register();
+
+    const invalid = await schedule({
+      name: 'idle',
+      tool: 'find_idle_resources',
+      arguments: {},
+      schedule: 'every monday',
+    });
+    const recursive = await schedule({
+      name: 'jobs',
+      tool: 'schedule_job',
+      arguments: {},
+      schedule: '@daily',
+    });
+
+    expect(invalid.isError).toBe(true);
+    expect(invalid.content[0].text).toContain('is not a cron expression');
+    expect(recursive.isError).toBe(true);
+    expect(recursive.content[0].text).toContain('can not run schedule_job');
+  });
+
+  test('returns an error if writing results is denied', async () => {
+    const { schedule } = register(['storage cp']);
+
+    const result = await schedule({
+      name: 'idle',
+      tool: 'find_idle_resources',
+      arguments: {},
+      schedule: '@daily',
+      outputUri: 'gs://reports',
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud storage cp"');
+  });
+
+  test('returns an error for results written in read-only mode', async () => {
+    const { schedule } = register([], undefined, true);
+
+    const result = await schedule({
+      name: 'idle',
+      tool: 'find_idle_resources',
+      arguments: {},
+      schedule: '@daily',
+      outputUri: 'gs://reports',
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toBe(
+      'Writing job results to Cloud Storage is not permitted in read-only mode.',
+    );
+  });
+
+  test('schedules jobs from the configuration and unschedules them', async () => {
+    const { list, unschedule } = register([], {
+      weekly: { tool: 'find_idle_resources', schedule: '0 6 * * 1' },
+    });
+
+    const listed = await list({});
+    expect(JSON.parse(listed.content[0].text)).toMatchObject([
+      { name: 'weekly', nextRunAt: '2025-01-06T06:00:00.000Z' },
+    ]);
+
+    expect((await unschedule({ name: 'weekly' })).content[0].text).toBe(
+      'Unscheduled job "weekly".',
+    );
+    expect((await unschedule({ name: 'weekly' })).isError).toBe(true);
+  });
+
+  test('stops jobs when the session closes', async () => {
+    register([], { hourly: { tool: 'find_idle_resources', schedule: '@hourly' } });
+
+    mockServer.server.onclose?.();
+    await vi.advanceTimersByTimeAsync(60 * 60 * 1000);
+
+    expect(registry.call).not.toHaveBeenCalled();
+  });
+});
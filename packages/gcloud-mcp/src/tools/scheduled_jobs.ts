@@ -0,0 +1,220 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer, ResourceTemplate } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { AccessControlList } from '../denylist.js';
+import {
+  SCHEDULE_SCHEME,
+  ScheduleResult,
+  ScheduledJob,
+  ScheduledJobConfig,
+  ToolRegistry,
+  createScheduler,
+} from '../scheduler.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { ADDITIVE_TOOL, DESTRUCTIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+// A job may not schedule, or remove, other jobs.
+export const SCHEDULER_TOOLS = ['schedule_job', 'list_scheduled_jobs', 'unschedule_job'];
+
+const checkJob = (
+  acl: AccessControlList,
+  readOnly: boolean,
+  name: string,
+  job: ScheduledJobConfig,
+) => {
+  if (SCHEDULER_TOOLS.includes(job.tool)) {
+    return `Scheduled job "${name}" can not run ${job.tool}.`;
+  }
+  if (job.outputUri && readOnly) {
+    return 'Writing job results to Cloud Storage is not permitted in read-only mode.';
+  }
+  if (job.outputUri && !acl.check('storage cp').permitted) {
+    return 'Writing job results to Cloud Storage requires "gcloud storage cp", which is not permitted.';
+  }
+  return undefined;
+};
+
+const summaryOf = ({ name, uri, tool, schedule, outputUri, nextRunAt, runs }: ScheduledJob) => {
+  const last = runs[runs.length - 1];
+  return {
+    name,
+    uri,
+    tool,
+    schedule,
+    ...(outputUri && { outputUri }),
+    nextRunAt,
+    lastRun: last ? { startedAt: last.startedAt, isError: last.isError } : null,
+  };
+};
+
+export const createScheduledJobTools = (
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+  registry: ToolRegistry,
+  schedules: Record<string, ScheduledJobConfig> = {},
+  readOnly = false,
+) => ({
+  register: (server: McpServer) => {
+    const notify = (job: ScheduledJob) => {
+      const run = job.runs[job.runs.length - 1];
+      Promise.all([
+        server.server.sendResourceUpdated({ uri: job.uri }),
+        server.sendLoggingMessage({
+          level: run?.isError ? 'warning' : 'info',
+          logger: 'scheduler',
+          data: {
+            name: job.name,
+            uri: job.uri,
+            tool: job.tool,
+            startedAt: run?.startedAt,
+            isError: run?.isError,
+            ...(run?.output && { output: run.output }),
+          },
+        }),
+      ]).catch((e: unknown) =>
+        log.warn(`Unable to notify the client of job ${job.name}`, { error: String(e) }),
+      );
+    };
+    const scheduler = createScheduler(run, registry, notify);
+    const addJob = (name: string, job: ScheduledJobConfig): ScheduleResult => {
+      const jobError = checkJob(acl, readOnly, name, job);
+      return jobError ? { success: false, error: jobError } : scheduler.add(name, job);
+    };
+    const onclose = server.server.onclose;
+    server.server.onclose = () => {
+      scheduler.stopAll();
+      onclose?.();
+    };
+
+    server.registerResource(
+      'scheduled-job',
+      new ResourceTemplate(`${SCHEDULE_SCHEME}://{name}`, {
+        list: () => ({
+          resources: scheduler.list().map(({ name, uri, tool }) => ({
+            uri,
+            name: `${name} (${tool})`,
+            mimeType: 'application/json',
+          })),
+        }),
+      }),
+      {
+        title: 'Scheduled job',
+        description: 'A job scheduled with schedule_job and the results of its latest runs.',
+        mimeType: 'application/json',
+      },
+      (uri, { name }) => {
+        const job = scheduler.get(String(name));
+        if (!job) {
+          throw new Error(`Unknown job: ${uri.href}`);
+        }
+        return {
+          contents: [
+            { uri: uri.href, mimeType: 'application/json', text: JSON.stringify(job, null, 2) },
+          ],
+        };
+      },
+    );
+
+    server.registerTool(
+      'schedule_job',
+      {
+        title: 'Schedule job',
+        inputSchema: {
+          name: z.string().describe('A name for the job: lowercase letters, digits and hyphens.'),
+          tool: z.string().describe('The tool to run, e.g. find_idle_resources.'),
+          arguments: z
+            .record(z.unknown())
+            .default({})
+            .describe('The arguments of the tool, as you would pass them when calling it.'),
+          schedule: z
+            .string()
+            .describe(
+              'When to run the tool, as a cron expression in UTC, e.g. "0 6 * * 1" for every Monday at 06:00. @hourly, @daily, @weekly and @monthly are also accepted.',
+            ),
+          outputUri: z
+            .string()
+            .optional()
+            .describe('A gs:// URL under which the result of every run is written.'),
+        },
+        annotations: DESTRUCTIVE_TOOL,
+        description: `Schedules a tool to run on the server on a recurring schedule, e.g. an idle resource scan every Monday. The results of the latest runs are kept as the job's resource, and every run is pushed to the client as a log message notification and a resource updated notification for the job URI.
+
+## Instructions:
+- Read the returned job URI to get the results of the latest runs.
+- Set outputUri to keep every result in Cloud Storage, e.g. for a weekly report.
+- Jobs run as long as the server runs. Jobs that must survive restarts belong in the "schedules" section of the configuration file.
+- Jobs can not confirm changes, so tools that require confirm only plan the change when they run on a schedule.`,
+      },
+      async ({ name, tool, arguments: args, schedule, outputUri }) => {
+        log.mcp('schedule_job', { name, tool, schedule, outputUri });
+        const result = addJob(name, {
+          tool,
+          arguments: args,
+          schedule,
+          ...(outputUri && { outputUri }),
+        });
+        if (!result.success) {
+          return errorTextResult(result.error);
+        }
+        return successfulTextResult(JSON.stringify(summaryOf(result.job), null, 2));
+      },
+    );
+
+    server.registerTool(
+      'list_scheduled_jobs',
+      {
+        title: 'List scheduled jobs',
+        inputSchema: {},
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the jobs scheduled on the server, including those from the configuration file, with their next run and the outcome of their last run.`,
+      },
+      async () => {
+        log.mcp('list_scheduled_jobs', {});
+        return successfulTextResult(JSON.stringify(scheduler.list().map(summaryOf), null, 2));
+      },
+    );
+
+    server.registerTool(
+      'unschedule_job',
+      {
+        title: 'Unschedule job',
+        inputSchema: {
+          name: z.string().describe('The name of the job to stop.'),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Stops a scheduled job. Jobs from the configuration file are scheduled again when the server restarts.`,
+      },
+      async ({ name }) => {
+        log.mcp('unschedule_job', { name });
+        if (!scheduler.remove(name)) {
+          return errorTextResult(`No job named "${name}" is scheduled.`);
+        }
+        return successfulTextResult(`Unscheduled job "${name}".`);
+      },
+    );
+
+    for (const [name, job] of Object.entries(schedules)) {
+      const result = addJob(name, job);
+      if (!result.success) {
+        log.warn(`Unable to schedule job ${name}: ${result.error}`);
+      }
+    }
+  },
+});
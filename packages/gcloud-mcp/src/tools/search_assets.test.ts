@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createSearchAssets } from './search_assets.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let run: GcloudCommandRunner;
+
+const createTool = () => {
+  createSearchAssets(run).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const mockOutput = (text: string, isError?: boolean) =>
+  vi.mocked(run).mockResolvedValue({
+    content: [{ type: 'text', text }],
+    ...(isError && { isError }),
+  });
+
+describe('createSearchAssets', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    run = vi.fn();
+  });
+
+  test('searches resources through the command runner', async () => {
+    const tool = createTool();
+    mockOutput(
+      JSON.stringify([
+        { name: '//storage.googleapis.com/b', assetType: 'storage.googleapis.com/Bucket' },
+      ]),
+    );
+
+    const result = await tool({ scope: 'projects/p', searchIamPolicies: false, limit: 200 });
+
+    expect(run).toHaveBeenCalledWith([
+      'asset',
+      'search-all-resources',
+      '--scope=projects/p',
+      '--limit=200',
+      '--format=json',
+    ]);
+    expect(result.structuredContent).toMatchObject({
+      resources: [{ name: '//storage.googleapis.com/b' }],
+      count: 1,
+    });
+  });
+
+  test('searches IAM policies', async () => {
+    const tool = createTool();
+    mockOutput(JSON.stringify([{ resource: '//storage.googleapis.com/b' }]));
+
+    const result = await tool({
+      scope: 'organizations/123',
+      query: 'policy:allUsers',
+      searchIamPolicies: true,
+      limit: 200,
+    });
+
+    expect(vi.mocked(run).mock.calls[0]![0]).toContain('search-all-iam-policies');
+    expect(result.structuredContent).toEqual({
+      policies: [
+        { resource: '//storage.googleapis.com/b', assetType: null, project: null, bindings: [] },
+      ],
+      count: 1,
+    });
+  });
+
+  test('returns an error for an invalid scope', async () => {
+    const tool = createTool();
+
+    const result = await tool({ scope: 'p', searchIamPolicies: false, limit: 200 });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+});
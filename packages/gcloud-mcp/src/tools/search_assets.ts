@@ -0,0 +1,116 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import {
+  PolicySummarySchema,
+  ResourceSummarySchema,
+  searchIamPoliciesArgs,
+  searchResourcesArgs,
+  summarizeIamPolicies,
+  summarizeResources,
+} from '../assets.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner, runJsonCommand } from './run_gcloud_command.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+/**
+ * Searches Cloud Asset Inventory through the same runner as run_gcloud_command,
+ * so searches are checked against the access control list and recorded.
+ */
+export const createSearchAssets = (run: GcloudCommandRunner) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'search_assets',
+      {
+        title: 'Search assets',
+        inputSchema: {
+          scope: z
+            .string()
+            .describe(
+              'The project, folder or organization to search, e.g. "projects/my-project" or "organizations/123".',
+            ),
+          query: z
+            .string()
+            .optional()
+            .describe(
+              'A Cloud Asset Inventory search query, e.g. "state:RUNNING labels.env:prod" or, for IAM policies, "policy:allUsers".',
+            ),
+          assetTypes: z
+            .array(z.string())
+            .optional()
+            .describe('Only search these asset types, e.g. ["compute.googleapis.com/Instance"].'),
+          searchIamPolicies: z
+            .boolean()
+            .default(false)
+            .describe('Search the IAM policies of resources instead of the resources.'),
+          limit: z
+            .number()
+            .int()
+            .positive()
+            .max(2000)
+            .default(200)
+            .describe('The maximum number of results.'),
+        },
+        outputSchema: {
+          resources: z.array(ResourceSummarySchema).optional(),
+          policies: z.array(PolicySummarySchema).optional(),
+          count: z.number(),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Searches the resources, or the IAM policies of resources, of a project, folder or organization with Cloud Asset Inventory, across every service in one call.
+
+## Instructions:
+- Use this tool for questions across projects or services, e.g. all VMs with external IPs or all public buckets, instead of running list commands per project.
+- Resources are returned with their full resource name, e.g. "//compute.googleapis.com/projects/p/zones/us-central1-a/instances/vm-1", and their project by number.
+- VMs with external IPs: query "externalIPs:*" with asset type "compute.googleapis.com/Instance".
+- Public buckets: searchIamPolicies with query "policy:(allUsers OR allAuthenticatedUsers)" and asset type "storage.googleapis.com/Bucket".
+- The search syntax is described at https://cloud.google.com/asset-inventory/docs/searching-resources.`,
+      },
+      async ({ scope, query, assetTypes, searchIamPolicies, limit }) => {
+        const toolLogger = log.mcp('search_assets', { scope, query, assetTypes });
+        try {
+          const search = { scope, query, assetTypes, limit };
+          const output = await runJsonCommand(
+            run,
+            searchIamPolicies ? searchIamPoliciesArgs(search) : searchResourcesArgs(search),
+          );
+          if ('error' in output) {
+            return output.error;
+          }
+          let structuredContent: Record<string, unknown>;
+          if (searchIamPolicies) {
+            const policies = summarizeIamPolicies(output.json);
+            structuredContent = { policies, count: policies.length };
+          } else {
+            const resources = summarizeResources(output.json);
+            structuredContent = { resources, count: resources.length };
+          }
+          return {
+            ...successfulTextResult(JSON.stringify(structuredContent, null, 2)),
+            structuredContent,
+          };
+        } catch (e: unknown) {
+          toolLogger.error('search_assets failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
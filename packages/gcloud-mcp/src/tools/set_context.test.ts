@@ -0,0 +1,84 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createSessionContext } from '../session_context.js';
+import { createSetContext } from './set_context.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const createTool = (allowedProjects: string[] = []) => {
+  const session = createSessionContext(allowedProjects);
+  createSetContext(session).register(mockServer);
+  const [[, , tool], [, , setProject]] = (mockServer.registerTool as Mock).mock.calls;
+  return { session, tool, setProject };
+};
+
+describe('createSetContext', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('registers the set_context and set_project tools', () => {
+    createTool();
+    expect(mockServer.registerTool).toHaveBeenCalledTimes(2);
+    expect(mockServer.registerTool).toHaveBeenCalledWith(
+      'set_context',
+      expect.any(Object),
+      expect.any(Function),
+    );
+    expect(mockServer.registerTool).toHaveBeenCalledWith(
+      'set_project',
+      expect.any(Object),
+      expect.any(Function),
+    );
+  });
+
+  test('updates the session and returns the new context', async () => {
+    const { session, tool } = createTool();
+
+    const result = await tool({ project: 'staging', zone: 'us-east1-b' });
+
+    expect(session.get()).toEqual({ project: 'staging', zone: 'us-east1-b' });
+    expect(JSON.parse(result.content[0].text)).toEqual({ project: 'staging', zone: 'us-east1-b' });
+    expect(result.isError).toBeUndefined();
+  });
+
+  test('returns an error for projects outside the allowlist', async () => {
+    const { session, tool } = createTool(['dev']);
+
+    const result = await tool({ project: 'prod' });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('not on the allowlist');
+    expect(session.get()).toEqual({});
+  });
+
+  test('set_project only changes the session project', async () => {
+    const { session, tool, setProject } = createTool(['dev', 'staging']);
+    await tool({ project: 'dev', zone: 'us-east1-b' });
+
+    const result = await setProject({ project: 'staging' });
+    const rejected = await setProject({ project: 'prod' });
+
+    expect(JSON.parse(result.content[0].text)).toEqual({ project: 'staging', zone: 'us-east1-b' });
+    expect(rejected.isError).toBe(true);
+    expect(session.get()).toEqual({ project: 'staging', zone: 'us-east1-b' });
+  });
+});
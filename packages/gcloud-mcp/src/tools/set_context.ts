@@ -0,0 +1,86 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { SessionContext, SessionContextValues } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { ADDITIVE_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createSetContext = (session: SessionContext) => {
+  const update = (tool: string, values: SessionContextValues) => {
+    const toolLogger = log.mcp(tool, values);
+    const result = session.update(values);
+    if (!result.success) {
+      toolLogger.warn(`${tool} rejected`, { error: result.error });
+      return errorTextResult(result.error);
+    }
+    toolLogger.info('Session context updated');
+    return successfulTextResult(JSON.stringify(result.context, null, 2));
+  };
+
+  return {
+    register: (server: McpServer) => {
+      server.registerTool(
+        'set_context',
+        {
+          title: 'Set session context',
+          inputSchema: {
+            project: z.string().optional().describe('The default project ID for this session.'),
+            region: z.string().optional().describe('The default region, e.g. us-central1.'),
+            zone: z.string().optional().describe('The default zone, e.g. us-central1-a.'),
+          },
+          annotations: ADDITIVE_TOOL,
+          description: `Changes the default project, region, and zone used by every subsequent gcloud command in this session.
+
+## Instructions:
+- Use this tool when the user asks to switch environments, e.g. "now do the same in staging".
+- Only the fields provided are changed. Pass an empty string to clear a session default and fall back to the user's gcloud configuration.
+- The user's gcloud configuration on disk is never modified.
+- Flags passed explicitly to a command (e.g. --project) still take precedence.`,
+        },
+        async ({ project, region, zone }) =>
+          update('set_context', {
+            ...(project !== undefined && { project }),
+            ...(region !== undefined && { region }),
+            ...(zone !== undefined && { zone }),
+          }),
+      );
+
+      // The project-only form of set_context.
+      server.registerTool(
+        'set_project',
+        {
+          title: 'Set session project',
+          inputSchema: {
+            project: z
+              .string()
+              .describe('The project ID to use. An empty string clears the session project.'),
+          },
+          annotations: ADDITIVE_TOOL,
+          description: `Switches the project every subsequent gcloud command in this session runs in. Same as set_context with only a project.
+
+## Instructions:
+- Use this tool to move the whole conversation to another project.
+- To run a single tool call in another project, pass its "project" parameter instead.
+- The user's gcloud configuration on disk is never modified.`,
+        },
+        async ({ project }) => update('set_project', { project }),
+      );
+    },
+  };
+};
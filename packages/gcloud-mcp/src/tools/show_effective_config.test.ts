@@ -0,0 +1,38 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, describe, expect, test, vi } from 'vitest';
+import { createShowEffectiveConfig } from './show_effective_config.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+describe('createShowEffectiveConfig', () => {
+  test('returns the effective configuration and its origins', async () => {
+    const effectiveConfig = {
+      config: { deny: ['sql'] },
+      origins: { deny: 'project (/repo/.gcloud-mcp.json)' },
+    };
+    createShowEffectiveConfig(effectiveConfig).register(mockServer);
+    const tool = (mockServer.registerTool as Mock).mock.calls[0]![2];
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text)).toEqual(effectiveConfig);
+  });
+});
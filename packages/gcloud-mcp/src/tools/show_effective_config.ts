@@ -0,0 +1,41 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { EffectiveConfig } from '../config.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { successfulTextResult } from './tool_result.js';
+
+export const createShowEffectiveConfig = (effectiveConfig: EffectiveConfig) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'show_effective_config',
+      {
+        title: 'Show effective configuration',
+        inputSchema: {},
+        annotations: READ_ONLY_TOOL,
+        description: `Returns the configuration this server is running with and where each setting came from.
+
+Settings are layered from lowest to highest precedence: the user configuration file, the project configuration file, the file passed with --config, GCLOUD_MCP_* environment variables, and command line flags.`,
+      },
+      async () => {
+        log.mcp('show_effective_config', {});
+        return successfulTextResult(JSON.stringify(effectiveConfig, null, 2));
+      },
+    );
+  },
+});
@@ -0,0 +1,145 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createSuggestCommand, synopsisFlags } from './suggest_command.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const createTool = (deny: string[] = []) => {
+  createSuggestCommand(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const SEARCH_RESULTS = [
+  {
+    path: ['gcloud', 'compute', 'firewall-rules', 'list'],
+    summary: 'List Compute Engine firewall rules.',
+    release: 'GA',
+  },
+  {
+    path: ['gcloud', 'beta', 'compute', 'firewall-rules', 'list'],
+    summary: 'List Compute Engine firewall rules.',
+    release: 'BETA',
+  },
+];
+
+const HELP = `NAME
+    gcloud compute firewall-rules list - list Compute Engine firewall rules
+
+SYNOPSIS
+    gcloud compute firewall-rules list [NAME ...] [--regexp=REGEXP, -r REGEXP]
+        [--filter=EXPRESSION] [--limit=LIMIT] [--filter=EXPRESSION]
+`;
+
+const mockGcloudInvoke = (searchResults: unknown, help: string = HELP) => {
+  vi.mocked(mockedGcloud.invoke).mockImplementation(async (args: string[]) => {
+    if (args[0] === 'help') {
+      return { code: 0, stdout: JSON.stringify(searchResults), stderr: '' };
+    }
+    return { code: 0, stdout: help, stderr: '' };
+  });
+};
+
+describe('synopsisFlags', () => {
+  test('returns each flag once', () => {
+    expect(synopsisFlags('cmd [--a=A] [--b-c] [--a=A] [-r R]')).toEqual(['--a', '--b-c']);
+  });
+});
+
+describe('createSuggestCommand', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(),
+    };
+  });
+
+  test('returns matching commands with flags from the installed help', async () => {
+    const tool = createTool(['beta']);
+    mockGcloudInvoke(SEARCH_RESULTS);
+
+    const result = await tool({ query: 'list  firewall rules' });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'help',
+      '--format=json',
+      '--',
+      'list',
+      'firewall',
+      'rules',
+    ]);
+    expect(JSON.parse(result.content[0].text)).toEqual([
+      {
+        command: 'compute firewall-rules list',
+        summary: 'List Compute Engine firewall rules.',
+        releaseTrack: 'GA',
+        synopsis: expect.stringContaining('--regexp=REGEXP'),
+        flags: ['--regexp', '--filter', '--limit'],
+        permitted: true,
+      },
+      {
+        command: 'beta compute firewall-rules list',
+        summary: 'List Compute Engine firewall rules.',
+        releaseTrack: 'BETA',
+        synopsis: expect.any(String),
+        flags: ['--regexp', '--filter', '--limit'],
+        permitted: false,
+      },
+    ]);
+  });
+
+  test('limits the number of suggestions', async () => {
+    const tool = createTool();
+    mockGcloudInvoke(SEARCH_RESULTS);
+
+    const result = await tool({ query: 'firewall', maxSuggestions: 1 });
+
+    expect(JSON.parse(result.content[0].text)).toHaveLength(1);
+  });
+
+  test('returns an error for an empty query', async () => {
+    const tool = createTool();
+
+    const result = await tool({ query: '  ' });
+
+    expect(result.isError).toBe(true);
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('returns an error when the help search fails', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 1, stdout: '', stderr: 'boom' });
+
+    const result = await tool({ query: 'firewall' });
+
+    expect(result).toEqual({
+      content: [{ type: 'text', text: 'gcloud help search failed: boom' }],
+      isError: true,
+    });
+  });
+});
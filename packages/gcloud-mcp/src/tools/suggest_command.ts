@@ -0,0 +1,128 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { log } from '../utility/logger.js';
+import { helpSection } from './explain_command.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const DEFAULT_MAX_SUGGESTIONS = 5;
+
+export interface CommandSuggestion {
+  command: string;
+  summary: string | null;
+  releaseTrack: string;
+  synopsis: string | null;
+  flags: string[];
+  permitted: boolean;
+}
+
+// There are more fields in each search result, but only these are used.
+const SearchResultsSchema = z.array(
+  z.object({
+    path: z.array(z.string()),
+    summary: z.string().nullish(),
+    release: z.string().nullish(),
+  }),
+);
+
+/** Returns the flags listed in a SYNOPSIS section, e.g. `--zone`. */
+export const synopsisFlags = (synopsis: string): string[] => [
+  ...new Set(synopsis.match(/--[a-z0-9][a-z0-9-]*/g) ?? []),
+];
+
+/**
+ * Searches the help tree of the installed gcloud for commands matching the terms.
+ *
+ * `gcloud help -- TERMS` searches the locally installed help index, so results
+ * always reflect the flags of the installed gcloud version.
+ */
+export const suggestCommands = async (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  terms: string[],
+  maxSuggestions: number = DEFAULT_MAX_SUGGESTIONS,
+): Promise<CommandSuggestion[]> => {
+  const { code, stdout, stderr } = await gcloud.invoke(['help', '--format=json', '--', ...terms]);
+  if (code !== 0) {
+    throw new Error(`gcloud help search failed: ${stderr}`);
+  }
+  const results = SearchResultsSchema.parse(JSON.parse(stdout)).slice(0, maxSuggestions);
+
+  return Promise.all(
+    results.map(async (result) => {
+      const commandPath = result.path[0] === 'gcloud' ? result.path.slice(1) : result.path;
+      const command = commandPath.join(' ');
+      const help = await gcloud.invoke([...commandPath, '--help']);
+      const synopsis = help.code === 0 ? helpSection(help.stdout, 'SYNOPSIS') : null;
+      return {
+        command,
+        summary: result.summary ?? null,
+        releaseTrack: result.release ?? 'GA',
+        synopsis,
+        flags: synopsis ? synopsisFlags(synopsis) : [],
+        permitted: acl.check(command).permitted,
+      };
+    }),
+  );
+};
+
+export const createSuggestCommand = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'suggest_command',
+      {
+        title: 'Suggest gcloud command',
+        inputSchema: {
+          query: z.string().describe('Keywords describing what to do, e.g. "list firewall rules".'),
+          maxSuggestions: z
+            .number()
+            .int()
+            .min(1)
+            .max(20)
+            .optional()
+            .describe(`Maximum number of commands to return. Defaults to ${DEFAULT_MAX_SUGGESTIONS}.`),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Searches the help of the locally installed gcloud CLI for commands matching an intent and returns candidate commands with their synopsis and flags.
+
+## Instructions:
+- Use this tool when you are not confident about the exact gcloud command or its flags.
+- Prefer the flags returned by this tool over flags you remember; they reflect the installed gcloud version.
+- Commands with "permitted": false are blocked by this server and will fail with run_gcloud_command.`,
+      },
+      async ({ query, maxSuggestions }) => {
+        const toolLogger = log.mcp('suggest_command', { query, maxSuggestions });
+        const terms = query.split(/\s+/).filter((term) => term.length > 0);
+        if (terms.length === 0) {
+          return errorTextResult('The query must contain at least one search term.');
+        }
+        try {
+          const suggestions = await suggestCommands(gcloud, acl, terms, maxSuggestions);
+          return successfulTextResult(JSON.stringify(suggestions, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('suggest_command failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,183 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { CommandHistory, createCommandHistory } from '../command_history.js';
+import { GoogleApiUploadClient } from '../google_api.js';
+import { createSupportCaseTools } from './support_cases.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let run: GcloudCommandRunner;
+let history: CommandHistory;
+let api: GoogleApiUploadClient;
+
+const createTool = (name: string, deny: string[] = [], readOnly = false) => {
+  createSupportCaseTools(
+    mockedGcloud,
+    createAccessControlList([], deny),
+    run,
+    history,
+    api,
+    readOnly,
+  ).register(mockServer);
+  return (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+};
+
+const output = (stdout: string) => ({ code: 0, stdout, stderr: '' });
+
+describe('createSupportCaseTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = { lint: vi.fn(), invoke: vi.fn().mockResolvedValue(output('[]')) };
+    run = vi.fn().mockResolvedValue({ content: [{ type: 'text', text: 'Created.' }] });
+    history = createCommandHistory();
+    api = { get: vi.fn(), post: vi.fn(), upload: vi.fn().mockResolvedValue({ name: 'a/1' }) };
+  });
+
+  test('lists the open cases of the session project', async () => {
+    const tool = createTool('list_support_cases');
+    vi.mocked(mockedGcloud.invoke).mockResolvedValueOnce(output('my-project\n'));
+
+    const result = await tool({ includeClosed: false });
+
+    expect(JSON.parse(result.content[0].text)).toEqual([]);
+    expect(vi.mocked(mockedGcloud.invoke).mock.calls[1]![0]).toContain('--project=my-project');
+  });
+
+  test('creates a case described with the session history', async () => {
+    const tool = createTool('create_support_case');
+    history.record(['compute', 'instances', 'start', 'web-1'], 1);
+
+    await tool({
+      project: 'p',
+      displayName: 'VMs fail to start',
+      classification: 'ABC123',
+      severity: 's2',
+      summary: 'web-1 fails to start.',
+      includeCommandHistory: true,
+      confirm: true,
+    });
+
+    const [args, , confirm] = vi.mocked(run).mock.calls[0]!;
+    expect(args.slice(0, 4)).toEqual([
+      'support',
+      'cases',
+      'create',
+      '--display-name=VMs fail to start',
+    ]);
+    expect(args).toContainEqual(
+      expect.stringContaining('gcloud compute instances start web-1 (exit code 1)'),
+    );
+    expect(args.at(-1)).toBe('--project=p');
+    expect(confirm).toBe(true);
+  });
+
+  test('leaves the session history out on request', async () => {
+    const tool = createTool('create_support_case');
+    history.record(['compute', 'instances', 'start', 'web-1'], 1);
+
+    await tool({
+      project: 'p',
+      displayName: 'VMs fail to start',
+      classification: 'ABC123',
+      severity: 's2',
+      summary: 'web-1 fails to start.',
+      includeCommandHistory: false,
+    });
+
+    const [args] = vi.mocked(run).mock.calls[0]!;
+    expect(args).toContain('--description=Summary:\nweb-1 fails to start.');
+  });
+
+  test('adds comments through the command runner', async () => {
+    const tool = createTool('add_support_case_comment');
+
+    await tool({ case: 'projects/p/cases/1', comment: 'Any news?', confirm: true });
+
+    expect(run).toHaveBeenCalledWith(
+      ['support', 'cases', 'comments', 'create', 'projects/p/cases/1', '--comment=Any news?'],
+      undefined,
+      true,
+    );
+  });
+
+  test('rejects case numbers without their parent', async () => {
+    const tool = createTool('add_support_case_comment');
+
+    const result = await tool({ case: '1', comment: 'Any news?' });
+
+    expect(result.isError).toBe(true);
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('asks for confirmation before uploading an attachment', async () => {
+    const tool = createTool('attach_to_support_case');
+    const input = {
+      case: 'projects/p/cases/1',
+      filename: 'events.log',
+      content: 'line 1',
+      contentType: 'text/plain',
+    };
+
+    const preview = await tool(input);
+    expect(JSON.parse(preview.content[0].text)).toMatchObject({ sizeBytes: 6 });
+    expect(api.upload).not.toHaveBeenCalled();
+
+    const result = await tool({ ...input, confirm: true });
+    expect(result.isError).toBeUndefined();
+    expect(api.upload).toHaveBeenCalledOnce();
+  });
+
+  test('does not upload attachments when comments are not permitted', async () => {
+    const tool = createTool('attach_to_support_case', ['support']);
+
+    const result = await tool({
+      case: 'projects/p/cases/1',
+      filename: 'events.log',
+      content: 'line 1',
+      contentType: 'text/plain',
+      confirm: true,
+    });
+
+    expect(result.isError).toBe(true);
+    expect(api.upload).not.toHaveBeenCalled();
+  });
+
+  test('does not upload attachments in read-only mode', async () => {
+    const tool = createTool('attach_to_support_case', [], true);
+
+    const result = await tool({
+      case: 'projects/p/cases/1',
+      filename: 'events.log',
+      content: 'line 1',
+      contentType: 'text/plain',
+      confirm: true,
+    });
+
+    expect(result.content[0].text).toBe('Attaching files is not permitted in read-only mode.');
+    expect(api.upload).not.toHaveBeenCalled();
+  });
+});
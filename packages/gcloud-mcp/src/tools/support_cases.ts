@@ -0,0 +1,356 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { CommandHistory } from '../command_history.js';
+import { GoogleApiUploadClient } from '../google_api.js';
+import {
+  CASE_SEVERITIES,
+  SupportParent,
+  addCommentArgs,
+  caseDescription,
+  createCaseArgs,
+  listCases,
+  searchClassifications,
+  uploadAttachment,
+  validateCaseName,
+} from '../support_cases.js';
+import { sessionProject } from '../session_context.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { ADDITIVE_TOOL, READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const LIST_COMMAND = 'support cases list';
+const SEARCH_COMMAND = 'support classifications search';
+const COMMENT_COMMAND = 'support cases comments create';
+
+// Attachments are uploaded as text, so keep them to what a tool call can carry.
+const MAX_ATTACHMENT_CHARS = 1_000_000;
+
+const parentSchema = {
+  project: z
+    .string()
+    .optional()
+    .describe('The project the case is about. Defaults to the session project.'),
+  organization: z.string().optional().describe('An organization ID, instead of a project.'),
+};
+
+const caseSchema = z
+  .string()
+  .describe('The full name of the case, e.g. "projects/my-project/cases/12345".');
+
+const parentOf = async (
+  gcloud: GcloudExecutable,
+  input: { project?: string | undefined; organization?: string | undefined },
+): Promise<SupportParent | string> => {
+  if (input.project !== undefined && input.organization !== undefined) {
+    return 'Pass either a project or an organization, not both.';
+  }
+  if (input.organization !== undefined) {
+    return { organization: input.organization };
+  }
+  const project = input.project ?? (await sessionProject(gcloud));
+  return project
+    ? { project }
+    : 'No project is set. Pass a project or set one with set_context.';
+};
+
+export const createSupportCaseTools = (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  run: GcloudCommandRunner,
+  history: CommandHistory,
+  api: GoogleApiUploadClient,
+  readOnly = false,
+) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'list_support_cases',
+      {
+        title: 'List support cases',
+        inputSchema: {
+          ...parentSchema,
+          includeClosed: z.boolean().default(false).describe('Also list closed cases.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Lists the Cloud Customer Care cases of a project or organization with their state, priority, classification and creator, most recently updated first. Closed cases are left out unless includeClosed is set.
+
+## Instructions:
+- Check for an open case about the same problem before creating a new one.
+- Support cases need a Customer Care support entitlement on the organization.`,
+      },
+      async ({ includeClosed, ...input }) => {
+        const toolLogger = log.mcp('list_support_cases', { ...input, includeClosed });
+        if (!acl.check(LIST_COMMAND).permitted) {
+          return errorTextResult(
+            `Listing support cases requires "gcloud ${LIST_COMMAND}", which is not permitted.`,
+          );
+        }
+        try {
+          const parent = await parentOf(gcloud, input);
+          if (typeof parent === 'string') {
+            return errorTextResult(parent);
+          }
+          const cases = await listCases(gcloud, parent, includeClosed);
+          return successfulTextResult(JSON.stringify(cases, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'list_support_cases failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'search_support_classifications',
+      {
+        title: 'Search support classifications',
+        inputSchema: {
+          text: z
+            .string()
+            .describe('Text the classification name contains, e.g. "Compute Engine".'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Finds the classifications of support cases, which name the product and kind of problem, whose name contains some text. Every case needs the ID of one.
+
+## Instructions:
+- Search for the product the problem is with, and pick the most specific classification that matches.`,
+      },
+      async ({ text }) => {
+        const toolLogger = log.mcp('search_support_classifications', { text });
+        if (!acl.check(SEARCH_COMMAND).permitted) {
+          return errorTextResult(
+            `Searching classifications requires "gcloud ${SEARCH_COMMAND}", which is not permitted.`,
+          );
+        }
+        try {
+          const classifications = await searchClassifications(gcloud, text);
+          return successfulTextResult(JSON.stringify(classifications, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'search_support_classifications failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'create_support_case',
+      {
+        title: 'Create support case',
+        inputSchema: {
+          ...parentSchema,
+          displayName: z.string().describe('A short title for the case.'),
+          classification: z
+            .string()
+            .describe('The classification ID, from search_support_classifications.'),
+          severity: z
+            .enum(CASE_SEVERITIES)
+            .default('s3')
+            .describe('The severity; s0 is a critical impact on production and s4 is low.'),
+          summary: z.string().describe('What is wrong, including the affected resources.'),
+          impact: z.string().optional().describe('The impact on users or the business.'),
+          timeline: z
+            .array(z.object({ time: z.string(), event: z.string() }))
+            .optional()
+            .describe('The events of the incident, with RFC 3339 times.'),
+          stepsTaken: z
+            .array(z.string())
+            .optional()
+            .describe('What was already tried, and with what result.'),
+          includeCommandHistory: z
+            .boolean()
+            .default(true)
+            .describe('Add the gcloud commands run in this session to the description.'),
+          ccAddresses: z
+            .array(z.string())
+            .optional()
+            .describe('Email addresses to copy on updates to the case.'),
+          testCase: z
+            .boolean()
+            .optional()
+            .describe('Create a test case, which support engineers do not work on.'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true once the user has explicitly approved creating the case.'),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Creates a Cloud Customer Care case whose description is written up from the incident: the summary, impact, timeline, the steps already taken, and the gcloud commands run in this session with their exit codes. The case is created with run_gcloud_command, so it is recorded in the command history like every other change.
+
+## Instructions:
+- Use this tool when troubleshooting ends in needing Google's help, instead of sending the user to the console.
+- Find the classification with search_support_classifications first.
+- Only use severities s0 and s1 for production outages, as they page support engineers.
+- Show the user the case before creating it; it is sent to Google and can not be deleted. Leave secrets and personal data out of the description.
+- Attach logs with attach_to_support_case once the case exists.`,
+      },
+      async (input) => {
+        const {
+          confirm,
+          includeCommandHistory,
+          project,
+          organization,
+          displayName,
+          classification,
+          severity,
+          ccAddresses,
+          testCase,
+        } = input;
+        const toolLogger = log.mcp('create_support_case', { project, organization, displayName });
+        try {
+          const parent = await parentOf(gcloud, { project, organization });
+          if (typeof parent === 'string') {
+            return errorTextResult(parent);
+          }
+          const description = caseDescription({
+            summary: input.summary,
+            ...(input.impact && { impact: input.impact }),
+            ...(input.timeline && { timeline: input.timeline }),
+            ...(input.stepsTaken && { stepsTaken: input.stepsTaken }),
+            ...(includeCommandHistory && { commands: history.list() }),
+          });
+          const args = createCaseArgs(parent, {
+            displayName,
+            description,
+            classification,
+            severity,
+            ...(ccAddresses && { ccAddresses }),
+            ...(testCase && { testCase }),
+          });
+          toolLogger.info('Creating support case', { displayName, severity });
+          return run(args, undefined, confirm);
+        } catch (e: unknown) {
+          toolLogger.error(
+            'create_support_case failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'add_support_case_comment',
+      {
+        title: 'Add support case comment',
+        inputSchema: {
+          case: caseSchema,
+          comment: z
+            .string()
+            .describe('The comment, e.g. new findings or an answer to a question.'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true once the user has explicitly approved the comment.'),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Adds a comment to a Cloud Customer Care case. The comment is added with run_gcloud_command, so it is recorded in the command history like every other change.
+
+## Instructions:
+- Show the user the comment before adding it; comments are sent to Google and can not be deleted.`,
+      },
+      async ({ case: caseName, comment, confirm }) => {
+        const toolLogger = log.mcp('add_support_case_comment', { case: caseName });
+        const nameError = validateCaseName(caseName);
+        if (nameError) {
+          return errorTextResult(nameError);
+        }
+        const args = addCommentArgs(caseName, comment);
+        toolLogger.info('Adding support case comment', { case: caseName });
+        return run(args, undefined, confirm);
+      },
+    );
+
+    server.registerTool(
+      'attach_to_support_case',
+      {
+        title: 'Attach to support case',
+        inputSchema: {
+          case: caseSchema,
+          filename: z.string().describe('The name of the attachment, e.g. "gke-events.log".'),
+          content: z
+            .string()
+            .max(MAX_ATTACHMENT_CHARS)
+            .describe('The text to attach, e.g. collected logs or command output.'),
+          contentType: z.string().default('text/plain').describe('The media type of the content.'),
+          confirm: z
+            .boolean()
+            .optional()
+            .describe('Set to true once the user has explicitly approved the upload.'),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Attaches text, such as collected logs or command output, to a Cloud Customer Care case as a file.
+
+## Instructions:
+- Call this tool without "confirm" first and tell the user what will be uploaded. Call it again with "confirm": true only after they approve; attachments are sent to Google and can not be deleted.
+- Remove secrets and personal data from logs before attaching them.`,
+      },
+      async ({ case: caseName, filename, content, contentType, confirm }) => {
+        const toolLogger = log.mcp('attach_to_support_case', { case: caseName, filename });
+        const nameError = validateCaseName(caseName);
+        if (nameError) {
+          return errorTextResult(nameError);
+        }
+        // Uploads have no gcloud command, so they are allowed along with comments.
+        if (!acl.check(COMMENT_COMMAND).permitted) {
+          return errorTextResult(
+            `Attaching files requires "gcloud ${COMMENT_COMMAND}", which is not permitted.`,
+          );
+        }
+        if (readOnly) {
+          return errorTextResult('Attaching files is not permitted in read-only mode.');
+        }
+        if (!confirm) {
+          return successfulTextResult(
+            JSON.stringify(
+              {
+                case: caseName,
+                filename,
+                sizeBytes: Buffer.byteLength(content),
+                next: 'The file is sent to Google Cloud Support. After the user approves, call attach_to_support_case again with "confirm": true.',
+              },
+              null,
+              2,
+            ),
+          );
+        }
+        try {
+          const attachment = await uploadAttachment(api, caseName, filename, content, contentType);
+          return successfulTextResult(JSON.stringify(attachment, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error(
+            'attach_to_support_case failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,43 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+export type TextResultType = { content: [{ type: 'text'; text: string }]; isError?: boolean };
+
+export const successfulTextResult = (text: string): TextResultType => ({
+  content: [{ type: 'text', text }],
+});
+
+export const errorTextResult = (text: string): TextResultType => ({
+  content: [{ type: 'text', text }],
+  isError: true,
+});
+
+export type EmbeddedResourceResultType = {
+  content: [
+    { type: 'text'; text: string },
+    { type: 'resource'; resource: { uri: string; mimeType: string; text: string } },
+  ];
+};
+
+export const embeddedResourceResult = (
+  text: string,
+  resource: { uri: string; mimeType: string; text: string },
+): EmbeddedResourceResultType => ({
+  content: [
+    { type: 'text', text },
+    { type: 'resource', resource },
+  ],
+});
@@ -0,0 +1,109 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { CommandHistory, createCommandHistory } from '../command_history.js';
+import { createUndoLastChange } from './undo_last_change.js';
+import { successfulTextResult } from './tool_result.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let history: CommandHistory;
+const run = vi.fn();
+
+const createTool = () => {
+  createUndoLastChange(history, run).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const ADD_TAGS = ['compute', 'instances', 'add-tags', 'vm-1', '--tags=http'];
+const REMOVE_TAGS = ['compute', 'instances', 'remove-tags', 'vm-1', '--tags=http'];
+
+describe('createUndoLastChange', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    history = createCommandHistory();
+    history.record(ADD_TAGS, 0, { CLOUDSDK_CORE_PROJECT: 'dev' }, 'compute instances add-tags');
+    history.record(['compute', 'instances', 'list'], 0, {}, 'compute instances list');
+    // The runner records every command it runs.
+    run.mockImplementation(async (args: string[]) => {
+      history.record(args, 0, {}, 'compute instances remove-tags');
+      return successfulTextResult('done');
+    });
+  });
+
+  test('proposes the inverse of the last change without running it', async () => {
+    const tool = createTool();
+
+    const result = await tool({});
+
+    expect(run).not.toHaveBeenCalled();
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      index: 1,
+      change: `gcloud ${ADD_TAGS.join(' ')}`,
+      undo: `gcloud ${REMOVE_TAGS.join(' ')}`,
+      caveat: expect.any(String),
+      next: expect.stringContaining('"confirm": true'),
+    });
+  });
+
+  test('runs the inverse with the original environment after confirmation', async () => {
+    const tool = createTool();
+
+    const result = await tool({ index: 1, confirm: true });
+
+    expect(run).toHaveBeenCalledWith(REMOVE_TAGS, { CLOUDSDK_CORE_PROJECT: 'dev' }, true);
+    expect(result).toEqual(successfulTextResult('done'));
+  });
+
+  test('does not propose undone changes or undo commands again', async () => {
+    const tool = createTool();
+    await tool({ confirm: true });
+
+    const result = await tool({});
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('no change');
+  });
+
+  test('keeps the change undoable when the undo command fails', async () => {
+    const tool = createTool();
+    run.mockImplementation(async (args: string[]) => {
+      history.record(args, 1);
+      return successfulTextResult('STDERR:\nfailed');
+    });
+    await tool({ confirm: true });
+
+    const result = await tool({});
+
+    expect(JSON.parse(result.content[0].text).index).toBe(1);
+  });
+
+  test('returns an error for commands that can not be undone', async () => {
+    const tool = createTool();
+
+    const result = await tool({ index: 2 });
+
+    expect(result).toEqual({
+      content: [{ type: 'text', text: 'Command 2 can not be undone.' }],
+      isError: true,
+    });
+  });
+});
@@ -0,0 +1,110 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { CommandHistory, CommandHistoryEntry } from '../command_history.js';
+import { InverseCommand, inverseOf } from '../inverse_command.js';
+import { log } from '../utility/logger.js';
+import { GcloudCommandRunner } from './run_gcloud_command.js';
+import { DESTRUCTIVE_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const inverseOfEntry = (entry: CommandHistoryEntry): InverseCommand | null =>
+  entry.exitCode === 0 && entry.command ? inverseOf(entry.command, entry.args) : null;
+
+export const createUndoLastChange = (history: CommandHistory, run: GcloudCommandRunner) => {
+  // History indices of changes that were undone, and of the undo commands themselves.
+  const undone = new Set<number>();
+
+  const lastUndoable = () =>
+    history
+      .list()
+      .reverse()
+      .find((entry) => !undone.has(entry.index) && inverseOfEntry(entry));
+
+  return {
+    register: (server: McpServer) => {
+      server.registerTool(
+        'undo_last_change',
+        {
+          title: 'Undo last change',
+          inputSchema: {
+            index: z
+              .number()
+              .int()
+              .min(1)
+              .optional()
+              .describe('The history index of the change to undo. Defaults to the last change.'),
+            confirm: z
+              .boolean()
+              .optional()
+              .describe('Set to true to run the undo command. Defaults to false.'),
+          },
+          annotations: DESTRUCTIVE_TOOL,
+          description: `Proposes, and after confirmation runs, a command that reverts a change made by run_gcloud_command in this session. For example, the undo of "compute instances add-tags" is "compute instances remove-tags", and the undo of a "create" is the matching "delete".
+
+## Instructions:
+- Call this tool without "confirm" first and show the proposed undo command and its caveat to the user.
+- Only call it again with "confirm": true and the proposed "index" after the user approves.
+- Undo commands are best-effort and are subject to the same restrictions as run_gcloud_command.`,
+        },
+        async ({ index, confirm }) => {
+          const toolLogger = log.mcp('undo_last_change', { index, confirm });
+          const entry = index === undefined ? lastUndoable() : history.get(index);
+          if (!entry) {
+            return errorTextResult(
+              index === undefined
+                ? 'There is no change in this session that can be undone.'
+                : `No command with index ${index} in the session history.`,
+            );
+          }
+          const inverse = inverseOfEntry(entry);
+          if (!inverse || undone.has(entry.index)) {
+            return errorTextResult(`Command ${entry.index} can not be undone.`);
+          }
+
+          if (!confirm) {
+            return successfulTextResult(
+              JSON.stringify(
+                {
+                  index: entry.index,
+                  change: `gcloud ${entry.args.join(' ')}`,
+                  undo: `gcloud ${inverse.args.join(' ')}`,
+                  caveat: inverse.caveat,
+                  next: `After the user approves, call undo_last_change with {"index": ${entry.index}, "confirm": true}.`,
+                },
+                null,
+                2,
+              ),
+            );
+          }
+
+          toolLogger.info('Undoing change', { undo: inverse.args });
+          const lastIndex = history.list().at(-1)?.index;
+          const result = await run(inverse.args, entry.env, true);
+          // The runner records the undo command unless it was rejected before running.
+          const undoEntry = history.list().at(-1);
+          if (undoEntry && undoEntry.index !== lastIndex && undoEntry.exitCode === 0) {
+            undone.add(entry.index);
+            undone.add(undoEntry.index);
+          }
+          return result;
+        },
+      );
+    },
+  };
+};
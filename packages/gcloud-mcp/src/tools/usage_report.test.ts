@@ -0,0 +1,45 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createTelemetry } from '../telemetry.js';
+import { createUsageReport } from './usage_report.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+describe('createUsageReport', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('returns the usage of the session', async () => {
+    const telemetry = createTelemetry({ monitoringProject: 'ops' });
+    telemetry.record({ kind: 'tool', name: 'gcloud_context', latencyMs: 12, ok: true });
+    createUsageReport(telemetry).register(mockServer);
+    const tool = (mockServer.registerTool as Mock).mock.calls[0]![2];
+
+    const result = await tool({ days: 7 });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      source: 'session',
+      events: 1,
+      tools: [{ name: 'gcloud_context', calls: 1, errors: 0, p50LatencyMs: 12 }],
+    });
+  });
+});
@@ -0,0 +1,61 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { Telemetry } from '../telemetry.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+export const createUsageReport = (telemetry: Telemetry) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'usage_report',
+      {
+        title: 'Usage report',
+        inputSchema: {
+          days: z
+            .number()
+            .int()
+            .min(1)
+            .optional()
+            .describe(
+              'Only include usage from this many past days. Defaults to all recorded usage.',
+            ),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Summarizes the usage recorded by opted-in telemetry: calls, errors, and latency percentiles per tool and per gcloud command, the most common error classes, and the hits and misses of the read cache.
+
+## Instructions:
+- Telemetry records only tool and command names, latency, and error classes, never arguments or output.`,
+      },
+      async ({ days }) => {
+        const toolLogger = log.mcp('usage_report', { days });
+        try {
+          const since = days ? new Date(Date.now() - days * DAY_MS) : undefined;
+          return successfulTextResult(JSON.stringify(telemetry.report(since), null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('usage_report failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
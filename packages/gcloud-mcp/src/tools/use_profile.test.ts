@@ -0,0 +1,61 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createProfiles } from '../profiles.js';
+import { createSessionContext } from '../session_context.js';
+import { createUseProfile } from './use_profile.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const createTool = () => {
+  const profiles = createProfiles(
+    { dev: { projects: ['dev-project'], mutations: 'allow' } },
+    createSessionContext(),
+  );
+  createUseProfile(profiles).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createUseProfile', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('selects the profile and returns the session context', async () => {
+    const tool = createTool();
+
+    const result = await tool({ name: 'dev' });
+
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      profile: { name: 'dev', projects: ['dev-project'], mutations: 'allow' },
+      context: { project: 'dev-project' },
+    });
+  });
+
+  test('returns an error for unknown profiles', async () => {
+    const tool = createTool();
+
+    const result = await tool({ name: 'prod' });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('Unknown profile "prod"');
+  });
+});
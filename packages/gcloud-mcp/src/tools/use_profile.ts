@@ -0,0 +1,56 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { Profiles } from '../profiles.js';
+import { log } from '../utility/logger.js';
+import { ADDITIVE_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+export const createUseProfile = (profiles: Profiles) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'use_profile',
+      {
+        title: 'Use environment profile',
+        inputSchema: {
+          name: z.string().describe(`The profile to use. One of: ${profiles.names().join(', ')}.`),
+        },
+        annotations: ADDITIVE_TOOL,
+        description: `Switches the session to a named environment profile, such as dev, staging, or prod.
+
+A profile restricts commands to its projects and decides whether commands that change state run freely, require user confirmation, or are blocked. Selecting a profile also sets the session's default project to one of its projects.
+
+## Instructions:
+- Use this tool when the user asks to work in a different environment.
+- Once a profile is selected, it stays active for the rest of the session.`,
+      },
+      async ({ name }) => {
+        const toolLogger = log.mcp('use_profile', { name });
+        const result = profiles.use(name);
+        if (!result.success) {
+          toolLogger.warn('use_profile rejected', { error: result.error });
+          return errorTextResult(result.error);
+        }
+        toolLogger.info('Profile selected');
+        return successfulTextResult(
+          JSON.stringify({ profile: profiles.active(), context: result.context }, null, 2),
+        );
+      },
+    );
+  },
+});
@@ -0,0 +1,197 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import {
+  closestMatches,
+  createValidateGcloudCommand,
+  documentedFlags,
+  parseCommandTree,
+} from './validate_gcloud_command.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const LISTING = `gcloud
+gcloud compute
+gcloud compute instances
+gcloud compute instances create
+gcloud compute instances list
+gcloud beta compute instances list
+`;
+
+const HELP = `SYNOPSIS
+    gcloud compute instances create INSTANCE_NAMES [INSTANCE_NAMES ...]
+        [--machine-type=MACHINE_TYPE] [--zone=ZONE] [--[no-]async]
+
+GCLOUD WIDE FLAGS
+    These flags are available to all commands: --account, --project, --quiet.
+`;
+
+const createTool = (deny: string[] = []) => {
+  createValidateGcloudCommand(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+const mockInvoke = (listing = LISTING) => {
+  vi.mocked(mockedGcloud.invoke).mockImplementation(async (args) => {
+    if (args[0] === 'meta') {
+      return { code: 0, stdout: listing, stderr: '' };
+    }
+    return { code: 0, stdout: HELP, stderr: '' };
+  });
+};
+
+describe('parseCommandTree', () => {
+  test('knows commands and groups with their children', () => {
+    const tree = parseCommandTree(LISTING);
+
+    expect(tree.has('compute instances create')).toBe(true);
+    expect(tree.has('compute instances delete')).toBe(false);
+    expect(tree.children('compute instances')).toEqual(['create', 'list']);
+    expect(tree.children('compute instances list')).toEqual([]);
+  });
+});
+
+describe('documentedFlags', () => {
+  test('includes both forms of negatable flags', () => {
+    const flags = documentedFlags(HELP);
+
+    expect(flags).toContain('--zone');
+    expect(flags).toContain('--async');
+    expect(flags).toContain('--no-async');
+    expect(flags).toContain('--project');
+  });
+});
+
+describe('closestMatches', () => {
+  test('returns close candidates, closest first', () => {
+    expect(closestMatches('--zome', ['--zone', '--zones', '--project'])).toEqual([
+      '--zone',
+      '--zones',
+    ]);
+  });
+});
+
+describe('createValidateGcloudCommand', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn(),
+    };
+  });
+
+  test('validates a command without executing it', async () => {
+    const tool = createTool();
+    mockInvoke();
+
+    const result = await tool({
+      args: ['compute', 'instances', 'create', 'vm-1', '--zone', 'us-east1-b', '--no-async'],
+    });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledTimes(2);
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith(['compute', 'instances', 'create', '--help']);
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      valid: true,
+      command: 'compute instances create',
+      exists: true,
+      isGroup: false,
+      commandSuggestions: [],
+      unknownFlags: [],
+      mutatesState: true,
+      permitted: true,
+    });
+  });
+
+  test('reports unknown flags with suggestions', async () => {
+    const tool = createTool();
+    mockInvoke();
+
+    const result = await tool({
+      args: ['compute', 'instances', 'create', 'vm-1', '--zome=us-east1-b', '--frobnicate'],
+    });
+
+    const validation = JSON.parse(result.content[0].text);
+    expect(validation.valid).toBe(false);
+    expect(validation.unknownFlags).toEqual([
+      { flag: '--zome', suggestions: ['--zone'] },
+      { flag: '--frobnicate', suggestions: [] },
+    ]);
+  });
+
+  test('suggests commands for an unknown command', async () => {
+    const tool = createTool();
+    mockInvoke();
+
+    const result = await tool({ args: ['compute', '--project', 'p', 'instances', 'lst'] });
+
+    const validation = JSON.parse(result.content[0].text);
+    expect(validation).toMatchObject({
+      valid: false,
+      command: 'compute instances',
+      exists: false,
+      isGroup: true,
+      commandSuggestions: ['list'],
+      unknownFlags: null,
+      mutatesState: 'unknown',
+      permitted: false,
+    });
+  });
+
+  test('reports whether the command is permitted', async () => {
+    const tool = createTool(['compute instances create']);
+    mockInvoke();
+
+    const result = await tool({ args: ['compute', 'instances', 'create', 'vm-1'] });
+
+    const validation = JSON.parse(result.content[0].text);
+    expect(validation.valid).toBe(true);
+    expect(validation.permitted).toBe(false);
+  });
+
+  test('lists the commands only once', async () => {
+    const tool = createTool();
+    mockInvoke();
+
+    await tool({ args: ['compute', 'instances', 'list'] });
+    await tool({ args: ['beta', 'compute', 'instances', 'list'] });
+
+    const { calls } = vi.mocked(mockedGcloud.invoke).mock;
+    expect(calls.filter(([args]) => args[0] === 'meta')).toHaveLength(1);
+  });
+
+  test('returns an error when the commands cannot be listed', async () => {
+    const tool = createTool();
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 1, stdout: '', stderr: 'boom' });
+
+    const result = await tool({ args: ['compute', 'instances', 'list'] });
+
+    expect(result).toEqual({
+      content: [{ type: 'text', text: 'Unable to list the gcloud commands: boom' }],
+      isError: true,
+    });
+  });
+});
@@ -0,0 +1,234 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import { log } from '../utility/logger.js';
+import { classifyMutation } from './explain_command.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+// Unknown commands and flags are only matched with known ones this close.
+const MAX_SUGGESTION_DISTANCE = 3;
+const MAX_SUGGESTIONS = 3;
+
+export interface UnknownFlag {
+  flag: string;
+  suggestions: string[];
+}
+
+export interface CommandValidation {
+  valid: boolean;
+  /** The longest known command or group the arguments start with. */
+  command: string;
+  exists: boolean;
+  /** Set if the arguments name a command group instead of a command. */
+  isGroup: boolean;
+  /** Close matches of the first argument that is not a command, if any. */
+  commandSuggestions: string[];
+  /** Null if the flags of the command could not be determined. */
+  unknownFlags: UnknownFlag[] | null;
+  mutatesState: boolean | 'unknown';
+  permitted: boolean;
+}
+
+/** The command tree of the installed gcloud, e.g. `compute instances list`. */
+export interface CommandTree {
+  has: (command: string) => boolean;
+  /** Returns the names of the subcommands and subgroups of a group. */
+  children: (group: string) => string[];
+}
+
+/** Builds the command tree from the output of `gcloud meta list-commands`. */
+export const parseCommandTree = (listing: string): CommandTree => {
+  const children = new Map<string, Set<string>>();
+  const commands = new Set<string>();
+  for (const line of listing.split('\n')) {
+    const path = line.trim().split(/\s+/).filter(Boolean);
+    if (path[0] === 'gcloud') {
+      path.shift();
+    }
+    for (let i = 0; i < path.length; i++) {
+      const parent = path.slice(0, i).join(' ');
+      commands.add(path.slice(0, i + 1).join(' '));
+      if (!children.has(parent)) {
+        children.set(parent, new Set());
+      }
+      children.get(parent)!.add(path[i]!);
+    }
+  }
+  return {
+    has: (command) => commands.has(command),
+    children: (group) => [...(children.get(group) ?? [])],
+  };
+};
+
+/**
+ * Returns the flags documented in `gcloud ... --help` output, including the
+ * gcloud wide flags. `--[no-]async` documents both `--async` and `--no-async`.
+ */
+export const documentedFlags = (help: string): Set<string> => {
+  const flags = new Set<string>();
+  for (const [, negatable, name] of help.matchAll(/--(\[no-\])?([a-z0-9][a-z0-9-]*)/g)) {
+    flags.add(`--${name}`);
+    if (negatable) {
+      flags.add(`--no-${name}`);
+    }
+  }
+  return flags;
+};
+
+const editDistance = (a: string, b: string): number => {
+  let previous = Array.from({ length: b.length + 1 }, (_, j) => j);
+  for (let i = 1; i <= a.length; i++) {
+    const current = [i];
+    for (let j = 1; j <= b.length; j++) {
+      const substitution = previous[j - 1]! + (a[i - 1] === b[j - 1] ? 0 : 1);
+      current.push(Math.min(previous[j]! + 1, current[j - 1]! + 1, substitution));
+    }
+    previous = current;
+  }
+  return previous[b.length]!;
+};
+
+/** Returns the candidates closest to a misspelled name, closest first. */
+export const closestMatches = (name: string, candidates: Iterable<string>): string[] =>
+  [...candidates]
+    .map((candidate) => ({ candidate, distance: editDistance(name, candidate) }))
+    .filter(({ distance }) => distance <= MAX_SUGGESTION_DISTANCE)
+    .sort((a, b) => a.distance - b.distance || a.candidate.localeCompare(b.candidate))
+    .slice(0, MAX_SUGGESTIONS)
+    .map(({ candidate }) => candidate);
+
+/**
+ * Checks arguments against the command tree and the documented flags of the
+ * installed gcloud without running the command.
+ */
+export const validateCommand = async (
+  gcloud: GcloudExecutable,
+  acl: AccessControlList,
+  tree: CommandTree,
+  args: string[],
+): Promise<CommandValidation> => {
+  const path: string[] = [];
+  let next: string | undefined;
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]!;
+    if (arg.startsWith('-')) {
+      continue;
+    }
+    if (tree.has([...path, arg].join(' '))) {
+      path.push(arg);
+      continue;
+    }
+    // Flags may appear between the groups of a command, e.g.
+    // `compute --project my-project instances list`.
+    const previous = args[i - 1];
+    const inGroup = tree.children(path.join(' ')).length > 0;
+    if (inGroup && previous?.startsWith('--') && !previous.includes('=')) {
+      continue;
+    }
+    next = arg;
+    break;
+  }
+  const command = path.join(' ');
+  const isGroup = tree.children(command).length > 0;
+  const exists = path.length > 0 && !isGroup;
+  const commandSuggestions =
+    !exists && next !== undefined ? closestMatches(next, tree.children(command)) : [];
+
+  let unknownFlags: UnknownFlag[] | null = null;
+  if (exists) {
+    // --help prints the reference page and never calls the API.
+    const { code, stdout } = await gcloud.invoke([...path, '--help']);
+    if (code === 0) {
+      const known = documentedFlags(stdout);
+      const given = args.filter((arg) => arg.startsWith('--')).map((arg) => arg.split('=')[0]!);
+      unknownFlags = [...new Set(given)]
+        .filter((flag) => !known.has(flag))
+        .map((flag) => ({ flag, suggestions: closestMatches(flag, known) }));
+    }
+  }
+
+  return {
+    valid: exists && !unknownFlags?.length,
+    command,
+    exists,
+    isGroup,
+    commandSuggestions,
+    unknownFlags,
+    mutatesState: exists ? classifyMutation(path[path.length - 1]!) : 'unknown',
+    permitted: exists && acl.check(command, args).permitted,
+  };
+};
+
+export const createValidateGcloudCommand = (gcloud: GcloudExecutable, acl: AccessControlList) => {
+  // Listing every command takes seconds, so the tree is loaded once on first use.
+  let tree: Promise<CommandTree> | undefined;
+  const loadTree = () => {
+    if (!tree) {
+      tree = gcloud.invoke(['meta', 'list-commands']).then(({ code, stdout, stderr }) => {
+        if (code !== 0) {
+          throw new Error(`Unable to list the gcloud commands: ${stderr}`);
+        }
+        return parseCommandTree(stdout);
+      });
+      // A failed listing is retried on the next call.
+      tree.catch(() => {
+        tree = undefined;
+      });
+    }
+    return tree;
+  };
+
+  return {
+    register: (server: McpServer) => {
+      server.registerTool(
+        'validate_gcloud_command',
+        {
+          title: 'Validate gcloud command',
+          inputSchema: {
+            args: z.array(z.string()),
+          },
+          annotations: READ_ONLY_TOOL,
+          description: `Checks a proposed gcloud command against the command tree of the installed gcloud CLI without executing anything: whether the command exists, which flags are unknown, whether it changes state, and whether this server permits it.
+
+## Instructions:
+- Use this tool before run_gcloud_command when you are unsure the command or its flags exist.
+- Pass the same args you would pass to run_gcloud_command.
+- If "valid" is false, fix the command using "commandSuggestions" and the "suggestions" of each unknown flag, and validate it again.
+- "unknownFlags" is null if the flags could not be checked; the command may still fail on them.`,
+        },
+        async ({ args }) => {
+          const toolLogger = log.mcp('validate_gcloud_command', args);
+          try {
+            const validation = await validateCommand(gcloud, acl, await loadTree(), args);
+            return successfulTextResult(JSON.stringify(validation, null, 2));
+          } catch (e: unknown) {
+            toolLogger.error(
+              'validate_gcloud_command failed',
+              e instanceof Error ? e : new Error(String(e)),
+            );
+            const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+            return errorTextResult(msg);
+          }
+        },
+      );
+    },
+  };
+};
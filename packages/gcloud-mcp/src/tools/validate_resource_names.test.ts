@@ -0,0 +1,66 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import { createNamingPolicy } from '../naming_policy.js';
+import { createValidateResourceNames } from './validate_resource_names.js';
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const createTool = () => {
+  const policy = createNamingPolicy({
+    namePatterns: { 'storage buckets': '^acme-' },
+    requiredLabels: { 'storage buckets': ['cost-center'] },
+  });
+  createValidateResourceNames(policy).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return (mockServer.registerTool as Mock).mock.calls[0]![2];
+};
+
+describe('createValidateResourceNames', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('reports a compliant proposal', async () => {
+    const tool = createTool();
+
+    const result = await tool({
+      resourceType: 'storage buckets',
+      names: ['acme-logs'],
+      labels: { 'cost-center': '1234' },
+    });
+
+    expect(JSON.parse(result.content[0].text)).toEqual({
+      compliant: true,
+      enforced: false,
+      violations: [],
+    });
+  });
+
+  test('lists the violations of a non-compliant proposal', async () => {
+    const tool = createTool();
+
+    const result = await tool({ resourceType: 'storage buckets', names: ['logs'] });
+
+    const { compliant, violations } = JSON.parse(result.content[0].text);
+    expect(compliant).toBe(false);
+    expect(violations.map((v: { kind: string }) => v.kind)).toEqual(['name', 'missing-label']);
+  });
+});
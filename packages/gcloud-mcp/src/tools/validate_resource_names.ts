@@ -0,0 +1,65 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { NamingPolicy } from '../naming_policy.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { successfulTextResult } from './tool_result.js';
+
+export const createValidateResourceNames = (policy: NamingPolicy) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'validate_resource_names',
+      {
+        title: 'Validate resource names',
+        inputSchema: {
+          resourceType: z
+            .string()
+            .describe(
+              'The command group of the resource without the verb, e.g. "compute instances" or "storage buckets".',
+            ),
+          names: z.array(z.string()).describe('The proposed resource names.'),
+          labels: z
+            .record(z.string())
+            .optional()
+            .describe('The labels the resources will be created with.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Checks proposed resource names and labels against the organization's naming policy before anything is created.
+
+Returns whether the proposal is compliant and a list of violations: names that do not match the required pattern, required labels that are missing, and label values that do not match their pattern.
+
+## Instructions:
+- Use this tool before creating resources, and pick names and labels that produce no violations.
+- If the policy is enforced, run_gcloud_command rejects create commands that violate it.`,
+      },
+      async ({ resourceType, names, labels }) => {
+        const toolLogger = log.mcp('validate_resource_names', { resourceType, names });
+        const violations = policy.validate({ resourceType, names, labels: labels ?? {} });
+        toolLogger.info('Resource names validated', { violations: violations.length });
+        return successfulTextResult(
+          JSON.stringify(
+            { compliant: violations.length === 0, enforced: policy.enforced(), violations },
+            null,
+            2,
+          ),
+        );
+      },
+    );
+  },
+});
@@ -0,0 +1,168 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { BillingCatalog, Sku } from '../billing_catalog.js';
+import { createVmPricingTools } from './vm_pricing.js';
+
+vi.mock('../gcloud.js');
+
+const mockServer = {
+  registerTool: vi.fn(),
+} as unknown as McpServer;
+
+const mockedGcloud: gcloud.GcloudExecutable = {
+  lint: vi.fn(),
+  invoke: vi.fn(),
+};
+
+const sku = (description: string, price: number, usageUnit: string): Sku => ({
+  skuId: description,
+  description,
+  category: { resourceFamily: 'Compute', usageType: 'OnDemand' },
+  serviceRegions: ['us-central1'],
+  pricingInfo: [
+    {
+      pricingExpression: {
+        usageUnit,
+        tieredRates: [
+          {
+            startUsageAmount: 0,
+            unitPrice: { currencyCode: 'USD', units: '0', nanos: Math.round(price * 1e9) },
+          },
+        ],
+      },
+    },
+  ],
+});
+
+const catalog: BillingCatalog = {
+  skus: async () => [
+    sku('E2 Instance Core running in Americas', 0.02, 'h'),
+    sku('E2 Instance Ram running in Americas', 0.003, 'GiBy.h'),
+    sku('N2 Instance Core running in Americas', 0.03, 'h'),
+    sku('N2 Instance Ram running in Americas', 0.004, 'GiBy.h'),
+    sku('Balanced PD Capacity in Americas', 0.1, 'GiBy.mo'),
+  ],
+};
+
+const createTool = (name: string) => {
+  createVmPricingTools(mockedGcloud, catalog).register(mockServer);
+  return (mockServer.registerTool as Mock).mock.calls.find(([toolName]) => toolName === name)![2];
+};
+
+describe('createVmPricingTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('finds the cheapest machine types for a shape', async () => {
+    const tool = createTool('find_machine_types');
+
+    const result = await tool({
+      vcpus: 4,
+      memoryGb: 16,
+      region: 'us-central1',
+      families: ['e2', 'n2'],
+      commitment: 'on-demand',
+      limit: 3,
+    });
+
+    expect(result.structuredContent.machineTypes[0]).toEqual({
+      machineType: 'e2-standard-4',
+      family: 'e2',
+      fit: 'predefined',
+      vcpus: 4,
+      memoryGb: 16,
+      monthly: 93.44,
+      warnings: [],
+    });
+    expect(
+      result.structuredContent.machineTypes.map(
+        ({ machineType, monthly }: { machineType: string; monthly: number }) => [
+          machineType,
+          monthly,
+        ],
+      ),
+    ).toEqual([
+      ['e2-standard-4', 93.44],
+      ['e2-highmem-4', 128.48],
+      ['n2-standard-4', 134.32],
+    ]);
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+  });
+
+  test('returns a batch of VM estimates as CSV', async () => {
+    const tool = createTool('estimate_vm_costs');
+
+    const result = await tool({
+      vms: [
+        {
+          name: 'web',
+          region: 'us-central1',
+          vcpus: 4,
+          memoryGb: 16,
+          diskGb: 50,
+          diskType: 'pd-balanced',
+          commitment: 'on-demand',
+          count: 1,
+        },
+        {
+          name: 'batch',
+          region: 'us-central1',
+          machineType: 'e2-standard-4',
+          vcpus: 4,
+          memoryGb: 16,
+          diskGb: 0,
+          diskType: 'pd-balanced',
+          commitment: 'on-demand',
+          count: 3,
+        },
+      ],
+      format: 'csv',
+    });
+
+    expect(result.content[0].text.split('\n').slice(1)).toEqual([
+      'web,us-central1,n2-standard-4,4,16,on-demand,1,pd-balanced,50,134.32,5,139.32,',
+      'batch,us-central1,e2-standard-4,4,16,on-demand,3,pd-balanced,0,280.32,0,280.32,',
+    ]);
+    expect(result.structuredContent.totalMonthly).toBe(419.64);
+  });
+
+  test('returns an error for VMs without a shape', async () => {
+    const tool = createTool('estimate_vm_costs');
+
+    const result = await tool({
+      vms: [
+        {
+          region: 'us-central1',
+          diskGb: 10,
+          diskType: 'pd-balanced',
+          commitment: 'on-demand',
+          count: 1,
+        },
+      ],
+      format: 'json',
+    });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toBe(
+      'Set either machineType or both vcpus and memoryGb for a VM.',
+    );
+  });
+});
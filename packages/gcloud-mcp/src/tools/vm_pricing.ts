@@ -0,0 +1,143 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { BillingCatalog } from '../billing_catalog.js';
+import { MACHINE_FAMILIES } from '../migration_center.js';
+import {
+  MachineTypeQuoteSchema,
+  VmCostRowSchema,
+  VmSpecSchema,
+  estimateVmCosts,
+  findMachineTypes,
+  vmCostsToCsv,
+} from '../vm_pricing.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+/**
+ * High-level pricing tools for VMs, built on the list prices used by
+ * estimate_cost.
+ */
+export const createVmPricingTools = (gcloud: GcloudExecutable, catalog: BillingCatalog) => ({
+  register: (server: McpServer) => {
+    server.registerTool(
+      'find_machine_types',
+      {
+        title: 'Find machine types',
+        inputSchema: {
+          vcpus: z.number().positive().describe('The number of vCPUs needed, e.g. 4.'),
+          memoryGb: z.number().positive().describe('The memory needed in GB, e.g. 16.'),
+          region: z.string().describe('The region to price the machine types in.'),
+          families: z
+            .array(z.enum(MACHINE_FAMILIES))
+            .optional()
+            .describe('The machine families to consider. Defaults to all of them.'),
+          commitment: z.enum(['on-demand', 'spot', '1-year', '3-year']).default('on-demand'),
+          limit: z.number().int().positive().max(20).default(5),
+        },
+        outputSchema: {
+          currency: z.string(),
+          machineTypes: z.array(MachineTypeQuoteSchema),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Maps a number of vCPUs and amount of memory, e.g. "4 vCPU / 16 GB", to the machine types that provide them, with the monthly list price of each in a region, cheapest first.
+
+## Instructions:
+- Use this tool when the user describes a VM by its size instead of its machine type.
+- Custom machine types are included when no predefined type has the same shape.
+- Machine types with warnings could not be fully priced and are listed last.
+- Use estimate_vm_costs to price the chosen machine type with its disk.`,
+      },
+      async ({ vcpus, memoryGb, region, families, commitment, limit }) => {
+        const toolLogger = log.mcp('find_machine_types', { vcpus, memoryGb, region });
+        try {
+          const quotes = await findMachineTypes(gcloud, catalog, {
+            vcpus,
+            memoryGb,
+            region,
+            families,
+            commitment,
+          });
+          const structuredContent = { currency: 'USD', machineTypes: quotes.slice(0, limit) };
+          return {
+            ...successfulTextResult(JSON.stringify(structuredContent, null, 2)),
+            structuredContent,
+          };
+        } catch (e: unknown) {
+          toolLogger.error(
+            'find_machine_types failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+
+    server.registerTool(
+      'estimate_vm_costs',
+      {
+        title: 'Estimate VM costs',
+        inputSchema: {
+          vms: z
+            .array(VmSpecSchema)
+            .min(1)
+            .max(500)
+            .describe('The VMs to price. Each needs a machine type, or its vCPUs and memory.'),
+          format: z
+            .enum(['json', 'csv'])
+            .default('json')
+            .describe('The format of the text result. Structured content is always returned.'),
+        },
+        outputSchema: {
+          currency: z.string(),
+          totalMonthly: z.number(),
+          vms: z.array(VmCostRowSchema),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Estimates the monthly list price of one or many VMs in a single call: the machine type, its boot disk, and the region. Returns one row per VM with its compute, disk and total monthly cost, as JSON or CSV.
+
+## Instructions:
+- Use this tool to price a single VM, or a whole inventory of VM specs at once.
+- A VM given only by its vCPUs and memory is priced as the smallest machine type of its family that fits.
+- Use format "csv" when the user wants a spreadsheet.
+- Estimates are list prices in USD for 730 hours a month. Show the user the warnings along with the total.`,
+      },
+      async ({ vms, format }) => {
+        const toolLogger = log.mcp('estimate_vm_costs', { vms: vms.length, format });
+        try {
+          const structuredContent = await estimateVmCosts(gcloud, catalog, vms);
+          const text =
+            format === 'csv'
+              ? vmCostsToCsv(structuredContent.vms)
+              : JSON.stringify(structuredContent, null, 2);
+          return { ...successfulTextResult(text), structuredContent };
+        } catch (e: unknown) {
+          toolLogger.error(
+            'estimate_vm_costs failed',
+            e instanceof Error ? e : new Error(String(e)),
+          );
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,102 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from '../gcloud.js';
+import { createAccessControlList } from '../denylist.js';
+import { createWatchResource } from './watch_resource.js';
+
+vi.mock('../gcloud.js');
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let mockServer: McpServer;
+
+const register = (deny: string[] = []) => {
+  createWatchResource(mockedGcloud, createAccessControlList([], deny)).register(mockServer);
+  expect(mockServer.registerTool).toHaveBeenCalledOnce();
+  return {
+    tool: (mockServer.registerTool as Mock).mock.calls[0]![2],
+    read: (mockServer.registerResource as Mock).mock.calls[0]![3],
+  };
+};
+
+describe('createWatchResource', () => {
+  beforeEach(() => {
+    vi.useFakeTimers();
+    mockedGcloud = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: '{"status": "WORKING"}', stderr: '' }),
+    };
+    mockServer = {
+      registerTool: vi.fn(),
+      registerResource: vi.fn(),
+      sendLoggingMessage: vi.fn().mockResolvedValue(undefined),
+      server: { sendResourceUpdated: vi.fn().mockResolvedValue(undefined) },
+    } as unknown as McpServer;
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  test('watches a resource and notifies the client of transitions', async () => {
+    const { tool, read } = register();
+
+    const result = await tool({ kind: 'build', name: 'b-1', pollSeconds: 5, timeoutMinutes: 30 });
+
+    expect(JSON.parse(result.content[0].text)).toMatchObject({
+      uri: 'gcloud-watch://1',
+      state: 'WORKING',
+      terminal: false,
+    });
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 0,
+      stdout: '{"status": "SUCCESS"}',
+      stderr: '',
+    });
+    await vi.advanceTimersByTimeAsync(5000);
+
+    expect(mockServer.server.sendResourceUpdated).toHaveBeenCalledWith({ uri: 'gcloud-watch://1' });
+    expect(mockServer.sendLoggingMessage).toHaveBeenCalledWith(
+      expect.objectContaining({
+        level: 'info',
+        data: expect.objectContaining({ state: 'SUCCESS', succeeded: true }),
+      }),
+    );
+    const { contents } = read(new URL('gcloud-watch://1'), { id: '1' });
+    expect(JSON.parse(contents[0].text).transitions).toHaveLength(2);
+  });
+
+  test('returns an error if describing the resource is denied', async () => {
+    const { tool } = register(['builds']);
+
+    const result = await tool({ kind: 'build', name: 'b-1', pollSeconds: 5, timeoutMinutes: 30 });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('"gcloud builds describe"');
+  });
+
+  test('returns an error if the resource can not be described', async () => {
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({ code: 1, stdout: '', stderr: 'NOT_FOUND' });
+    const { tool } = register();
+
+    const result = await tool({ kind: 'build', name: 'b-1', pollSeconds: 5, timeoutMinutes: 30 });
+
+    expect(result.isError).toBe(true);
+    expect(result.content[0].text).toContain('NOT_FOUND');
+  });
+});
@@ -0,0 +1,158 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer, ResourceTemplate } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+import { GcloudExecutable } from '../gcloud.js';
+import { AccessControlList } from '../denylist.js';
+import {
+  RESOURCE_WATCH_SCHEME,
+  ResourceWatch,
+  WATCH_COMMANDS,
+  WATCH_KINDS,
+  createResourceWatcher,
+} from '../resource_watch.js';
+import { log } from '../utility/logger.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
+import { errorTextResult, successfulTextResult } from './tool_result.js';
+
+const SECOND_MS = 1000;
+const MINUTE_MS = 60 * SECOND_MS;
+
+export const createWatchResource = (gcloud: GcloudExecutable, acl: AccessControlList) => ({
+  register: (server: McpServer) => {
+    const notify = (watch: ResourceWatch) => {
+      const { id, uri, target, state, terminal, succeeded } = watch;
+      Promise.all([
+        server.server.sendResourceUpdated({ uri }),
+        server.sendLoggingMessage({
+          level: terminal && !succeeded ? 'warning' : 'info',
+          logger: 'watch_resource',
+          data: { id, uri, kind: target.kind, name: target.name, state, terminal, succeeded },
+        }),
+      ]).catch((e: unknown) =>
+        log.warn(`Unable to notify the client of watch ${id}`, { error: String(e) }),
+      );
+    };
+    const watcher = createResourceWatcher(gcloud, notify);
+    const onclose = server.server.onclose;
+    server.server.onclose = () => {
+      watcher.stopAll();
+      onclose?.();
+    };
+
+    server.registerResource(
+      'resource-watch',
+      new ResourceTemplate(`${RESOURCE_WATCH_SCHEME}://{id}`, {
+        list: () => ({
+          resources: watcher.list().map(({ uri, target }) => ({
+            uri,
+            name: `${target.kind} ${target.name}`,
+            mimeType: 'application/json',
+          })),
+        }),
+      }),
+      {
+        title: 'Resource watch',
+        description: 'The state and transitions of a resource watched with watch_resource.',
+        mimeType: 'application/json',
+      },
+      (uri, { id }) => {
+        const watch = watcher.get(String(id));
+        if (!watch) {
+          throw new Error(`Unknown watch: ${uri.href}`);
+        }
+        return {
+          contents: [
+            { uri: uri.href, mimeType: 'application/json', text: JSON.stringify(watch, null, 2) },
+          ],
+        };
+      },
+    );
+
+    server.registerTool(
+      'watch_resource',
+      {
+        title: 'Watch resource',
+        inputSchema: {
+          kind: z
+            .enum(WATCH_KINDS)
+            .describe(
+              'What to watch: a Cloud Build build, a Compute Engine or GKE operation, a Cloud Run service rollout, or a managed instance group update.',
+            ),
+          name: z.string().describe('The build ID, operation name, service name or group name.'),
+          project: z
+            .string()
+            .optional()
+            .describe('The project of the resource. Defaults to the session project.'),
+          location: z
+            .string()
+            .optional()
+            .describe(
+              'The zone or region of the resource. Omit it for global operations and builds.',
+            ),
+          pollSeconds: z
+            .number()
+            .int()
+            .min(5)
+            .default(15)
+            .describe('How often to check the resource.'),
+          timeoutMinutes: z
+            .number()
+            .int()
+            .min(1)
+            .max(240)
+            .default(30)
+            .describe('How long to watch the resource before giving up.'),
+        },
+        annotations: READ_ONLY_TOOL,
+        description: `Watches a long-running resource on the server, such as a build, an operation, a Cloud Run rollout or a managed instance group update, until it completes or the watch times out. Every state transition is pushed to the client as a log message notification and a resource updated notification for the watch URI.
+
+## Instructions:
+- Use this tool after starting a deploy or another long-running change instead of describing the resource repeatedly.
+- Read the returned watch URI to get the current state and the transitions so far.
+- A watch ends in a terminal state: succeeded is true for SUCCESS, DONE without an error, READY and STABLE. TIMED_OUT means the resource was still changing when the timeout passed.`,
+      },
+      async ({ kind, name, project, location, pollSeconds, timeoutMinutes }) => {
+        const toolLogger = log.mcp('watch_resource', {
+          kind,
+          name,
+          project,
+          location,
+          pollSeconds,
+          timeoutMinutes,
+        });
+        const command = WATCH_COMMANDS[kind];
+        if (!acl.check(command).permitted) {
+          return errorTextResult(
+            `Watching a ${kind} requires "gcloud ${command}", which is not permitted.`,
+          );
+        }
+        try {
+          const watch = await watcher.start(
+            { kind, name, ...(project && { project }), ...(location && { location }) },
+            { pollMs: pollSeconds * SECOND_MS, timeoutMs: timeoutMinutes * MINUTE_MS },
+          );
+          return successfulTextResult(JSON.stringify(watch, null, 2));
+        } catch (e: unknown) {
+          toolLogger.error('watch_resource failed', e instanceof Error ? e : new Error(String(e)));
+          const msg = e instanceof Error ? e.message : 'An unknown error occurred.';
+          return errorTextResult(msg);
+        }
+      },
+    );
+  },
+});
@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import {
+  auditPublicBucketsText,
+  createWorkflowPrompts,
+  investigateQuotaErrorsText,
+  triageCloudRun5xxText,
+} from './workflow_prompts.js';
+
+const mockServer = {
+  registerPrompt: vi.fn(),
+} as unknown as McpServer;
+
+describe('createWorkflowPrompts', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  test('registers a prompt per workflow', () => {
+    createWorkflowPrompts().register(mockServer);
+
+    const names = (mockServer.registerPrompt as Mock).mock.calls.map(([name]) => name);
+    expect(names).toEqual([
+      'triage_cloud_run_5xx',
+      'audit_public_buckets',
+      'investigate_quota_errors',
+    ]);
+  });
+
+  test('returns the workflow as a user message', () => {
+    createWorkflowPrompts().register(mockServer);
+    const [, , callback] = (mockServer.registerPrompt as Mock).mock.calls[1]!;
+
+    expect(callback({ project: 'web-prod' })).toEqual({
+      messages: [
+        {
+          role: 'user',
+          content: { type: 'text', text: auditPublicBucketsText({ project: 'web-prod' }) },
+        },
+      ],
+    });
+  });
+});
+
+describe('triageCloudRun5xxText', () => {
+  test('fills in the service, region and project', () => {
+    const text = triageCloudRun5xxText({
+      service: 'checkout',
+      region: 'us-central1',
+      project: 'shop-prod',
+    });
+
+    expect(text).toContain('over the last 1h');
+    expect(text).toContain(
+      '["run", "revisions", "list", "--service=checkout", "--region=us-central1", "--project=shop-prod"',
+    );
+    expect(text).toContain(
+      'resource.labels.service_name=checkout AND httpRequest.status>=500", "--freshness=1h"',
+    );
+    expect(text).toContain('until I approve it');
+  });
+});
+
+describe('auditPublicBucketsText', () => {
+  test('searches the IAM policies of the project', () => {
+    expect(auditPublicBucketsText({ project: 'web-prod' })).toContain(
+      'scope "projects/web-prod", searchIamPolicies true',
+    );
+    expect(auditPublicBucketsText({})).toContain('of the session project');
+  });
+});
+
+describe('investigateQuotaErrorsText', () => {
+  test('checks the quotas of the given service and region', () => {
+    const text = investigateQuotaErrorsText({ service: 'compute', region: 'europe-west1' });
+
+    expect(text).toContain('over the last 1d');
+    expect(text).toContain('check_quotas with service "compute" and region "europe-west1"');
+    expect(text).toContain('"protoPayload.status.code=8 OR \\"RESOURCE_EXHAUSTED\\"');
+  });
+});
@@ -0,0 +1,158 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { z } from 'zod';
+
+const projectArg = z
+  .string()
+  .optional()
+  .describe('The project to investigate. Defaults to the session project.');
+
+const windowArg = z
+  .string()
+  .optional()
+  .describe('How far back to look, as a gcloud --freshness duration, e.g. "1h" or "2d".');
+
+const userMessage = (text: string) => ({
+  messages: [{ role: 'user' as const, content: { type: 'text' as const, text } }],
+});
+
+// Every workflow ends the same way: nothing changes without the user's approval.
+const APPROVAL = 'Do not run any command that changes state until I approve it.';
+
+// Renders the arguments of a run_gcloud_command call, e.g. ["run", "services", "list"].
+const argv = (...args: string[]) => `[${args.map((arg) => JSON.stringify(arg)).join(', ')}]`;
+
+const projectFlags = (project: string | undefined) => (project ? [`--project=${project}`] : []);
+
+const inProject = (project: string | undefined) =>
+  project ? `the project "${project}"` : 'the session project';
+
+export const triageCloudRun5xxText = (args: {
+  service: string;
+  region: string;
+  project?: string | undefined;
+  window?: string | undefined;
+}) => {
+  const { service, region, project } = args;
+  const window = args.window ?? '1h';
+  const flags = [`--region=${region}`, ...projectFlags(project)];
+  const logFilter = `resource.type=cloud_run_revision AND resource.labels.service_name=${service}`;
+  return `Triage the spike of 5xx responses of the Cloud Run service "${service}" in ${region} of ${inProject(project)} over the last ${window}.
+
+1. Call gcloud_context to confirm the account and project.
+2. Call list_services with region "${region}" and check whether "${service}" is ready and which revision serves it.
+3. With run_gcloud_command, list the recent revisions: ${argv('run', 'revisions', 'list', `--service=${service}`, ...flags, '--limit=5', '--format=json(metadata.name,metadata.creationTimestamp,status.conditions,spec.containers[0].image)')}. Note whether a revision was deployed shortly before the spike.
+4. With run_gcloud_command, read the failed requests: ${argv('logging', 'read', `${logFilter} AND httpRequest.status>=500`, `--freshness=${window}`, '--limit=50', '--format=json(timestamp,httpRequest.status,httpRequest.requestUrl,httpRequest.latency,resource.labels.revision_name)', ...projectFlags(project))}.
+5. Read the application errors of the same period: ${argv('logging', 'read', `${logFilter} AND severity>=ERROR`, `--freshness=${window}`, '--limit=50', '--format=json(timestamp,textPayload,jsonPayload.message,resource.labels.revision_name)', ...projectFlags(project))}. Look for crashes, out of memory errors ("Memory limit of ... exceeded"), request timeouts and "no available instance" errors.
+6. If the errors point to capacity, describe the service with ${argv('run', 'services', 'describe', service, ...flags, '--format=json(spec.template.spec,spec.template.metadata.annotations)')} and compare its memory, CPU, concurrency, timeout and maximum instances with what the errors show.
+7. Summarize when the spike started, which revisions and URLs it affects, the most common errors, and the most likely cause. Propose a fix, e.g. routing all traffic back to the last good revision with ${argv('run', 'services', 'update-traffic', service, '--to-revisions=<REVISION>=100', ...flags)}, or raising a limit.
+
+${APPROVAL}`;
+};
+
+export const auditPublicBucketsText = (args: { project?: string | undefined }) => {
+  const { project } = args;
+  return `Audit the Cloud Storage buckets of ${inProject(project)} for public access.
+
+1. Call gcloud_context to confirm the account and project.
+2. Call search_assets with scope "projects/${project ?? '<the session project>'}", searchIamPolicies true, query "policy:(allUsers OR allAuthenticatedUsers)" and asset type "storage.googleapis.com/Bucket" to find buckets whose IAM policy grants access to everyone. If Cloud Asset Inventory is not enabled, run ${argv('storage', 'buckets', 'get-iam-policy', 'gs://<BUCKET>', '--format=json')} with run_gcloud_command for each bucket instead.
+3. With run_gcloud_command, list the access settings of every bucket: ${argv('storage', 'buckets', 'list', '--format=json(name,iamConfiguration.publicAccessPrevention,iamConfiguration.uniformBucketLevelAccess.enabled)', ...projectFlags(project))}.
+4. Buckets without uniform bucket-level access may also be public through ACLs. For each, run ${argv('storage', 'buckets', 'describe', 'gs://<BUCKET>', '--format=json(acl,default_acl)')} and look for the allUsers and allAuthenticatedUsers entities.
+5. Report a table of the public buckets, with the role granted to everyone, how it is granted (IAM or ACL), and whether public access prevention is enforced. Buckets serving a public website may be public on purpose, so ask me about each one.
+6. For each bucket I confirm should be private, propose removing the public binding and enforcing public access prevention with ${argv('storage', 'buckets', 'update', 'gs://<BUCKET>', '--public-access-prevention')}.
+
+${APPROVAL}`;
+};
+
+export const investigateQuotaErrorsText = (args: {
+  project?: string | undefined;
+  service?: string | undefined;
+  region?: string | undefined;
+  window?: string | undefined;
+}) => {
+  const { project, region } = args;
+  const window = args.window ?? '1d';
+  const service = args.service ?? 'compute';
+  return `Investigate the quota errors of ${inProject(project)} over the last ${window}.
+
+1. Call gcloud_context to confirm the account and project.
+2. With run_gcloud_command, find the requests that failed on quota: ${argv('logging', 'read', 'protoPayload.status.code=8 OR "RESOURCE_EXHAUSTED" OR "Quota exceeded"', `--freshness=${window}`, '--limit=50', '--format=json(timestamp,protoPayload.serviceName,protoPayload.methodName,protoPayload.status.message,protoPayload.authenticationInfo.principalEmail)', ...projectFlags(project))}. Group them by service, method and the quota metric named in the message.
+3. Call check_quotas with service "${service}"${region ? ` and region "${region}"` : ''}${project ? ` for project "${project}"` : ''}, and again for every other service the errors name. Note which quotas are at or near their limit.
+4. Tell apart allocation quotas (e.g. CPUs, IP addresses, disks), which stay exhausted until resources are deleted or the limit is raised, from rate quotas (requests per minute), which recover on their own. THROTTLED errors of this server are its own client-side limits, not Google Cloud quotas.
+5. Summarize which quotas were exceeded, when, by which principals, and whether they are still exhausted.
+6. Propose fixes: deleting idle resources (find_idle_resources finds them), spreading load across regions, retrying rate limited calls with backoff, or requesting a higher limit with the command check_quotas returns. Quota increase requests are reviewed by Google Cloud and may take time.
+
+${APPROVAL}`;
+};
+
+/**
+ * Prompts for common operational workflows. Clients that support prompts
+ * offer them to users, who fill in the arguments; each prompt lays out the
+ * tool calls of the workflow.
+ */
+export const createWorkflowPrompts = () => ({
+  register: (server: McpServer) => {
+    server.registerPrompt(
+      'triage_cloud_run_5xx',
+      {
+        title: 'Triage a Cloud Run 5xx spike',
+        description:
+          'Finds when and why a Cloud Run service started returning 5xx responses, from its revisions, request logs and limits.',
+        argsSchema: {
+          service: z.string().describe('The name of the Cloud Run service.'),
+          region: z.string().describe('The region of the service, e.g. "us-central1".'),
+          project: projectArg,
+          window: windowArg,
+        },
+      },
+      (args) => userMessage(triageCloudRun5xxText(args)),
+    );
+
+    server.registerPrompt(
+      'audit_public_buckets',
+      {
+        title: 'Audit public storage buckets',
+        description:
+          'Finds Cloud Storage buckets that grant access to everyone through IAM or ACLs, and proposes how to lock them down.',
+        argsSchema: { project: projectArg },
+      },
+      (args) => userMessage(auditPublicBucketsText(args)),
+    );
+
+    server.registerPrompt(
+      'investigate_quota_errors',
+      {
+        title: 'Investigate quota errors',
+        description:
+          'Finds requests that failed on quota, the quotas that are exhausted, and how to get below them.',
+        argsSchema: {
+          project: projectArg,
+          service: z
+            .string()
+            .optional()
+            .describe(
+              'The service whose quotas to check first, e.g. "compute". Defaults to compute.',
+            ),
+          region: z.string().optional().describe('For compute, the region to check.'),
+          window: windowArg,
+        },
+      },
+      (args) => userMessage(investigateQuotaErrorsText(args)),
+    );
+  },
+});
@@ -0,0 +1,318 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { Mock, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import {
+  createTracer,
+  parseTraceparent,
+  toOtlpRequest,
+  traceTools,
+  validateOpenTelemetry,
+  withApiTracing,
+  withGcloudTracing,
+} from './tracing.js';
+
+vi.mock('./gcloud.js');
+
+const TRACE_ID = '4bf92f3577b34da6a3ce929d0e0e4736';
+const PARENT_ID = '00f067aa0ba902b7';
+
+interface ExportedSpans {
+  spans: Array<{
+    name: string;
+    spanId: string;
+    parentSpanId?: string;
+    displayName: { value: string };
+    attributes: { attributeMap: Record<string, unknown> };
+    status: { code: number; message?: string };
+  }>;
+}
+
+let api: GoogleApiClient;
+
+const exportedSpans = () => {
+  const call = vi.mocked(api.post).mock.calls.find(([url]) => url.includes('batchWrite'));
+  return (call?.[1] as ExportedSpans | undefined)?.spans ?? [];
+};
+
+describe('validateOpenTelemetry', () => {
+  test('needs an exporter', () => {
+    expect(validateOpenTelemetry({})).toContain('OpenTelemetry needs');
+    expect(validateOpenTelemetry({ otlpEndpoint: 'https://otel.example.com' })).toBe(undefined);
+  });
+});
+
+describe('parseTraceparent', () => {
+  test('parses a W3C trace context', () => {
+    expect(parseTraceparent(`00-${TRACE_ID}-${PARENT_ID}-01`)).toEqual({
+      traceId: TRACE_ID,
+      spanId: PARENT_ID,
+      sampled: true,
+    });
+    expect(parseTraceparent(`00-${TRACE_ID}-${PARENT_ID}-00`)).toMatchObject({ sampled: false });
+  });
+
+  test('ignores invalid trace contexts', () => {
+    expect(parseTraceparent(`00-${'0'.repeat(32)}-${PARENT_ID}-01`)).toBeUndefined();
+    expect(parseTraceparent('not a trace context')).toBeUndefined();
+    expect(parseTraceparent(undefined)).toBeUndefined();
+  });
+});
+
+describe('createTracer', () => {
+  beforeEach(() => {
+    api = { get: vi.fn(), post: vi.fn().mockResolvedValue({}) };
+  });
+
+  test('exports nested spans to Cloud Trace', async () => {
+    const tracer = createTracer({ traceProject: 'ops' }, '1.0.0', api);
+
+    await tracer.inSpan('tools/call list_instances', { kind: 'SERVER', operation: 'tool' }, () =>
+      tracer.inSpan('gcloud compute', { kind: 'INTERNAL', operation: 'gcloud' }, async (span) => {
+        span.setAttributes({ 'process.exit.code': 1 });
+        span.setError('exit code 1');
+      }),
+    );
+    await tracer.flush();
+
+    const [child, parent] = exportedSpans();
+    expect(vi.mocked(api.post).mock.calls[0]![0]).toBe(
+      'https://cloudtrace.googleapis.com/v2/projects/ops/traces:batchWrite',
+    );
+    expect(parent).toMatchObject({
+      displayName: { value: 'tools/call list_instances' },
+      status: { code: 0 },
+    });
+    expect(parent?.parentSpanId).toBeUndefined();
+    expect(child).toMatchObject({
+      displayName: { value: 'gcloud compute' },
+      parentSpanId: parent?.spanId,
+      attributes: { attributeMap: { 'process.exit.code': { intValue: '1' } } },
+      status: { code: 2, message: 'exit code 1' },
+    });
+    expect(child?.name.split('/spans/')[0]).toBe(parent?.name.split('/spans/')[0]);
+  });
+
+  test('continues the trace of the client', async () => {
+    const tracer = createTracer({ traceProject: 'ops' }, '1.0.0', api);
+    const parent = parseTraceparent(`00-${TRACE_ID}-${PARENT_ID}-01`);
+    const options = { kind: 'SERVER' as const, operation: 'tool' as const, parent };
+
+    await tracer.inSpan('tools/call gcloud_context', options, () => Promise.resolve());
+    await tracer.flush();
+
+    expect(exportedSpans()[0]).toMatchObject({
+      name: expect.stringContaining(`projects/ops/traces/${TRACE_ID}/spans/`),
+      parentSpanId: PARENT_ID,
+    });
+  });
+
+  test('marks spans of exceptions as failed and rethrows', async () => {
+    const tracer = createTracer({ traceProject: 'ops' }, '1.0.0', api);
+
+    await expect(
+      tracer.inSpan('GET example.com', { kind: 'CLIENT', operation: 'api' }, async () => {
+        throw new Error('boom');
+      }),
+    ).rejects.toThrow('boom');
+    await tracer.flush();
+
+    expect(exportedSpans()[0]).toMatchObject({ status: { code: 2, message: 'boom' } });
+  });
+
+  test('counts unsampled operations in metrics without exporting their spans', async () => {
+    const tracer = createTracer(
+      { traceProject: 'ops', metricsProject: 'ops', sampleRatio: 0 },
+      '1.0.0',
+      api,
+    );
+
+    await tracer.inSpan('gcloud compute', { kind: 'INTERNAL', operation: 'gcloud' }, async (span) =>
+      span.setAttributes({ 'process.exit.code': 0, 'gcloud_mcp.output.bytes': 42 }),
+    );
+    await tracer.flush();
+
+    expect(exportedSpans()).toEqual([]);
+    expect(api.post).toHaveBeenCalledOnce();
+    const [url, body] = vi.mocked(api.post).mock.calls[0]!;
+    expect(url).toBe('https://monitoring.googleapis.com/v3/projects/ops/timeSeries');
+    expect((body as { timeSeries: unknown[] }).timeSeries).toMatchObject([
+      {
+        metric: {
+          type: 'custom.googleapis.com/gcloud_mcp/operation/count',
+          labels: { operation: 'gcloud', name: 'gcloud compute', status: 'OK', exit_code: '0' },
+        },
+        points: [{ value: { int64Value: '1' } }],
+      },
+      {
+        metric: { type: 'custom.googleapis.com/gcloud_mcp/operation/output_bytes' },
+        points: [{ value: { int64Value: '42' } }],
+      },
+      { metric: { type: 'custom.googleapis.com/gcloud_mcp/operation/latency' } },
+    ]);
+  });
+
+  test('exports spans to an OTLP collector', async () => {
+    const fetchFn = vi.fn().mockResolvedValue({ ok: true });
+    const tracer = createTracer(
+      { otlpEndpoint: 'http://localhost:4318/' },
+      '1.0.0',
+      undefined,
+      fetchFn,
+    );
+
+    await tracer.inSpan('tools/call gcloud_context', { kind: 'SERVER', operation: 'tool' }, () =>
+      Promise.resolve(),
+    );
+    await tracer.flush();
+
+    expect(fetchFn).toHaveBeenCalledWith('http://localhost:4318/v1/traces', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: expect.stringContaining('"name":"tools/call gcloud_context"'),
+    });
+  });
+
+  test('reports failed exports', async () => {
+    const fetchFn = vi.fn().mockResolvedValue({ ok: false, status: 503 });
+    const tracer = createTracer({ otlpEndpoint: 'http://localhost:4318' }, '1.0.0', api, fetchFn);
+    await tracer.inSpan('gcloud compute', { kind: 'INTERNAL', operation: 'gcloud' }, () =>
+      Promise.resolve(),
+    );
+
+    await expect(tracer.flush()).rejects.toThrow('failed with 503');
+  });
+});
+
+describe('toOtlpRequest', () => {
+  test('converts spans to the OTLP JSON encoding', () => {
+    const request = toOtlpRequest(
+      [
+        {
+          traceId: TRACE_ID,
+          spanId: PARENT_ID,
+          name: 'gcloud compute',
+          kind: 'INTERNAL',
+          operation: 'gcloud',
+          startTime: 1000,
+          endTime: 1500,
+          attributes: { 'process.exit.code': 0, 'gcloud.api_family': 'compute' },
+          status: { code: 'OK' },
+        },
+      ],
+      '1.0.0',
+    );
+
+    expect(request.resourceSpans[0]?.resource.attributes).toContainEqual({
+      key: 'service.name',
+      value: { stringValue: 'gcloud-mcp' },
+    });
+    expect(request.resourceSpans[0]?.scopeSpans[0]?.spans[0]).toEqual({
+      traceId: TRACE_ID,
+      spanId: PARENT_ID,
+      name: 'gcloud compute',
+      kind: 1,
+      startTimeUnixNano: '1000000000',
+      endTimeUnixNano: '1500000000',
+      attributes: [
+        { key: 'process.exit.code', value: { intValue: '0' } },
+        { key: 'gcloud.api_family', value: { stringValue: 'compute' } },
+      ],
+      status: { code: 1 },
+    });
+  });
+});
+
+describe('instrumentation', () => {
+  beforeEach(() => {
+    api = { get: vi.fn(), post: vi.fn().mockResolvedValue({}) };
+  });
+
+  test('traces gcloud processes by API family without their arguments', async () => {
+    const tracer = createTracer({ traceProject: 'ops' }, '1.0.0', api);
+    const mockedGcloud: gcloud.GcloudExecutable = {
+      lint: vi.fn(),
+      invoke: vi.fn().mockResolvedValue({ code: 0, stdout: 'ok', stderr: '' }),
+    };
+
+    await withGcloudTracing(mockedGcloud, tracer).invoke([
+      'beta',
+      'compute',
+      'instances',
+      'describe',
+      'secret-vm',
+    ]);
+    await tracer.flush();
+
+    const [span] = exportedSpans();
+    expect(span?.displayName.value).toBe('gcloud compute');
+    expect(span?.attributes.attributeMap).toEqual({
+      'process.executable.name': { stringValue: { value: 'gcloud', truncatedByteCount: 0 } },
+      'gcloud.api_family': { stringValue: { value: 'compute', truncatedByteCount: 0 } },
+      'process.exit.code': { intValue: '0' },
+      'gcloud_mcp.output.bytes': { intValue: '2' },
+    });
+  });
+
+  test('traces Google API requests', async () => {
+    const tracer = createTracer({ traceProject: 'ops' }, '1.0.0', api);
+    const client: GoogleApiClient = {
+      get: vi.fn().mockRejectedValue(new Error('Request to x failed with 403: denied')),
+      post: vi.fn(),
+    };
+
+    await expect(
+      withApiTracing(client, tracer).get('https://cloudbilling.googleapis.com/v1/services'),
+    ).rejects.toThrow('403');
+    await tracer.flush();
+
+    expect(exportedSpans()[0]).toMatchObject({
+      displayName: { value: 'GET cloudbilling.googleapis.com' },
+      attributes: { attributeMap: { 'http.response.status_code': { intValue: '403' } } },
+      status: { code: 2 },
+    });
+  });
+
+  test('traces tool calls and their errors', async () => {
+    const tracer = createTracer({ traceProject: 'ops' }, '1.0.0', api);
+    const registerTool = vi.fn();
+    const server = { registerTool } as unknown as McpServer;
+    traceTools(server, tracer);
+
+    server.registerTool('failing_tool', {}, async () => ({
+      content: [{ type: 'text' as const, text: JSON.stringify({ error: 'THROTTLED' }) }],
+      isError: true,
+    }));
+    const extra = { _meta: { traceparent: `00-${TRACE_ID}-${PARENT_ID}-01` } };
+    await (registerTool as Mock).mock.calls[0]![2]({}, extra);
+    await tracer.flush();
+
+    expect(exportedSpans()[0]).toMatchObject({
+      displayName: { value: 'tools/call failing_tool' },
+      parentSpanId: PARENT_ID,
+      attributes: {
+        attributeMap: {
+          'gen_ai.tool.name': { stringValue: { value: 'failing_tool' } },
+          'error.type': { stringValue: { value: 'THROTTLED' } },
+        },
+      },
+      status: { code: 2, message: 'THROTTLED' },
+    });
+  });
+});
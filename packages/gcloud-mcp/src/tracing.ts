@@ -0,0 +1,509 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { AsyncLocalStorage } from 'async_hooks';
+import crypto from 'crypto';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { GcloudExecutable } from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import { apiFamilyOf } from './rate_limiter.js';
+import { METRIC_PREFIX, MONITORING_URL, errorClassOf } from './telemetry.js';
+import { log } from './utility/logger.js';
+
+export const CLOUD_TRACE_URL = 'https://cloudtrace.googleapis.com/v2';
+export const SERVICE_NAME = 'gcloud-mcp';
+export const DEFAULT_EXPORT_INTERVAL_MS = 10 * 1000;
+
+// Spans kept for export at most; the oldest are dropped if an exporter falls behind.
+const MAX_PENDING_SPANS = 5000;
+// Cloud Monitoring accepts at most this many time series per request.
+const MAX_TIME_SERIES_PER_REQUEST = 200;
+
+export interface OpenTelemetryConfig {
+  /** A project spans are exported to with Cloud Trace. */
+  traceProject?: string;
+  /** A project the latency, exit codes and output size of operations are written to. */
+  metricsProject?: string;
+  /** A collector spans are exported to with OTLP/HTTP, e.g. `http://localhost:4318`. */
+  otlpEndpoint?: string;
+  /** The fraction of tool calls that are traced, between 0 and 1. Defaults to 1. */
+  sampleRatio?: number;
+}
+
+export type SpanKind = 'SERVER' | 'CLIENT' | 'INTERNAL';
+export type AttributeValue = string | number | boolean;
+
+/** What a span measures, which is the `operation` label of its metrics. */
+export type Operation = 'tool' | 'gcloud' | 'api';
+
+// Attributes read back into metrics.
+export const EXIT_CODE_ATTRIBUTE = 'process.exit.code';
+export const OUTPUT_BYTES_ATTRIBUTE = 'gcloud_mcp.output.bytes';
+
+/** A finished span. Times are in milliseconds since the epoch. */
+export interface Span {
+  traceId: string;
+  spanId: string;
+  parentSpanId?: string;
+  name: string;
+  kind: SpanKind;
+  operation: Operation;
+  startTime: number;
+  endTime: number;
+  attributes: Record<string, AttributeValue>;
+  status: { code: 'OK' | 'ERROR'; message?: string };
+}
+
+/** The span in progress, which its operation adds attributes and an error to. */
+export interface ActiveSpan {
+  setAttributes: (attributes: Record<string, AttributeValue | undefined>) => void;
+  setError: (message: string) => void;
+}
+
+interface SpanContext {
+  traceId: string;
+  spanId: string;
+  sampled: boolean;
+}
+
+export const validateOpenTelemetry = (config: OpenTelemetryConfig): string | undefined => {
+  if (!config.traceProject && !config.metricsProject && !config.otlpEndpoint) {
+    return 'OpenTelemetry needs a "traceProject", a "metricsProject", an "otlpEndpoint", or a combination.';
+  }
+  if (config.otlpEndpoint && !/^https?:\/\/[^\s/]+/.test(config.otlpEndpoint)) {
+    return `Invalid OTLP endpoint: "${config.otlpEndpoint}".`;
+  }
+  const { sampleRatio } = config;
+  if (sampleRatio !== undefined && !(sampleRatio >= 0 && sampleRatio <= 1)) {
+    return `Invalid sample ratio ${sampleRatio}: "sampleRatio" must be between 0 and 1.`;
+  }
+  return undefined;
+};
+
+/** Parses a W3C `traceparent`, e.g. `00-<trace id>-<parent span id>-01`. */
+export const parseTraceparent = (traceparent: unknown): SpanContext | undefined => {
+  const match =
+    typeof traceparent === 'string' &&
+    /^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$/.exec(traceparent.trim());
+  if (!match || /^0+$/.test(match[1] ?? '') || /^0+$/.test(match[2] ?? '')) {
+    return undefined;
+  }
+  return {
+    traceId: match[1] ?? '',
+    spanId: match[2] ?? '',
+    sampled: (parseInt(match[3] ?? '0', 16) & 1) === 1,
+  };
+};
+
+const randomId = (bytes: number) => crypto.randomBytes(bytes).toString('hex');
+
+const cloudTraceAttribute = (value: AttributeValue) => {
+  if (typeof value === 'boolean') {
+    return { boolValue: value };
+  }
+  if (typeof value === 'number' && Number.isInteger(value)) {
+    return { intValue: String(value) };
+  }
+  return { stringValue: { value: String(value), truncatedByteCount: 0 } };
+};
+
+/** Converts a span into the Cloud Trace v2 format. */
+export const toCloudTraceSpan = (span: Span, project: string) => ({
+  name: `projects/${project}/traces/${span.traceId}/spans/${span.spanId}`,
+  spanId: span.spanId,
+  ...(span.parentSpanId && { parentSpanId: span.parentSpanId }),
+  displayName: { value: span.name, truncatedByteCount: 0 },
+  startTime: new Date(span.startTime).toISOString(),
+  endTime: new Date(span.endTime).toISOString(),
+  spanKind: span.kind,
+  attributes: {
+    attributeMap: Object.fromEntries(
+      Object.entries(span.attributes).map(([key, value]) => [key, cloudTraceAttribute(value)]),
+    ),
+  },
+  // google.rpc.Code: OK or UNKNOWN.
+  status: {
+    code: span.status.code === 'OK' ? 0 : 2,
+    ...(span.status.message && { message: span.status.message }),
+  },
+});
+
+const otlpAttributes = (attributes: Record<string, AttributeValue>) =>
+  Object.entries(attributes).map(([key, value]) => {
+    if (typeof value === 'boolean') {
+      return { key, value: { boolValue: value } };
+    }
+    if (typeof value === 'number') {
+      return Number.isInteger(value)
+        ? { key, value: { intValue: String(value) } }
+        : { key, value: { doubleValue: value } };
+    }
+    return { key, value: { stringValue: value } };
+  });
+
+const OTLP_SPAN_KINDS: Record<SpanKind, number> = { INTERNAL: 1, SERVER: 2, CLIENT: 3 };
+
+const unixNano = (ms: number) => (BigInt(Math.round(ms * 1000)) * 1000n).toString();
+
+/** Converts spans into an OTLP/HTTP JSON export request. */
+export const toOtlpRequest = (spans: Span[], serviceVersion: string) => ({
+  resourceSpans: [
+    {
+      resource: {
+        attributes: otlpAttributes({
+          'service.name': SERVICE_NAME,
+          'service.version': serviceVersion,
+        }),
+      },
+      scopeSpans: [
+        {
+          scope: { name: SERVICE_NAME, version: serviceVersion },
+          spans: spans.map((span) => ({
+            traceId: span.traceId,
+            spanId: span.spanId,
+            ...(span.parentSpanId && { parentSpanId: span.parentSpanId }),
+            name: span.name,
+            kind: OTLP_SPAN_KINDS[span.kind],
+            startTimeUnixNano: unixNano(span.startTime),
+            endTimeUnixNano: unixNano(span.endTime),
+            attributes: otlpAttributes(span.attributes),
+            status: {
+              code: span.status.code === 'OK' ? 1 : 2,
+              ...(span.status.message && { message: span.status.message }),
+            },
+          })),
+        },
+      ],
+    },
+  ],
+});
+
+interface OperationTotals {
+  labels: { operation: Operation; name: string; status: string; exit_code: string };
+  count: number;
+  bytes: number;
+  /** Latency of the spans since the last export. */
+  pending: number[];
+}
+
+export type Tracer = ReturnType<typeof createTracer>;
+
+/**
+ * Creates the tracer of the server. Spans of tool calls, gcloud processes and
+ * Google API requests are exported to Cloud Trace and an OTLP collector, and
+ * aggregated into metrics written to Cloud Monitoring, every export interval.
+ *
+ * Sampling only applies to traces; metrics count every operation.
+ */
+export const createTracer = (
+  config: OpenTelemetryConfig,
+  serviceVersion: string,
+  api?: GoogleApiClient,
+  fetchFn: typeof fetch = fetch,
+  random: () => number = Math.random,
+) => {
+  const sampleRatio = config.sampleRatio ?? 1;
+  const active = new AsyncLocalStorage<SpanContext>();
+  const startTime = new Date().toISOString();
+  let pending: Span[] = [];
+  const operations = new Map<string, OperationTotals>();
+
+  const recordMetrics = (span: Span) => {
+    if (!config.metricsProject) {
+      return;
+    }
+    const exitCode = span.attributes[EXIT_CODE_ATTRIBUTE];
+    const labels = {
+      operation: span.operation,
+      name: span.name,
+      status: span.status.code,
+      exit_code: exitCode === undefined ? '' : String(exitCode),
+    };
+    const key = JSON.stringify(labels);
+    const totals = operations.get(key) ?? { labels, count: 0, bytes: 0, pending: [] };
+    totals.count += 1;
+    totals.bytes += Number(span.attributes[OUTPUT_BYTES_ATTRIBUTE] ?? 0);
+    totals.pending.push(span.endTime - span.startTime);
+    operations.set(key, totals);
+  };
+
+  /**
+   * Runs an operation in a new span, the child of the span in progress or of
+   * `parent`. The span ends when the operation settles; an exception marks it
+   * as failed and is rethrown.
+   */
+  const inSpan = async <T>(
+    name: string,
+    options: {
+      kind: SpanKind;
+      operation: Operation;
+      attributes?: Record<string, AttributeValue>;
+      parent?: SpanContext | undefined;
+    },
+    operation: (span: ActiveSpan) => Promise<T>,
+  ): Promise<T> => {
+    const parent = active.getStore() ?? options.parent;
+    const context = {
+      traceId: parent?.traceId ?? randomId(16),
+      spanId: randomId(8),
+      sampled: parent ? parent.sampled : random() < sampleRatio,
+    };
+    const span: Span = {
+      traceId: context.traceId,
+      spanId: context.spanId,
+      ...(parent && { parentSpanId: parent.spanId }),
+      name,
+      kind: options.kind,
+      operation: options.operation,
+      startTime: Date.now(),
+      endTime: 0,
+      attributes: { ...options.attributes },
+      status: { code: 'OK' },
+    };
+    const activeSpan: ActiveSpan = {
+      setAttributes: (attributes) => {
+        for (const [key, value] of Object.entries(attributes)) {
+          if (value !== undefined) {
+            span.attributes[key] = value;
+          }
+        }
+      },
+      setError: (message) => {
+        span.status = { code: 'ERROR', message };
+      },
+    };
+    try {
+      return await active.run(context, () => operation(activeSpan));
+    } catch (e: unknown) {
+      activeSpan.setError(e instanceof Error ? e.message : String(e));
+      throw e;
+    } finally {
+      span.endTime = Date.now();
+      recordMetrics(span);
+      if (context.sampled && (config.traceProject || config.otlpEndpoint)) {
+        pending.push(span);
+        if (pending.length > MAX_PENDING_SPANS) {
+          pending.shift();
+        }
+      }
+    }
+  };
+
+  const exportMetrics = async (project: string) => {
+    const changed = [...operations.values()].filter((totals) => totals.pending.length > 0);
+    if (!api || changed.length === 0) {
+      return;
+    }
+    const endTime = new Date().toISOString();
+    const resource = { type: 'global', labels: { project_id: project } };
+    const cumulative = (type: string, labels: OperationTotals['labels'], value: number) => ({
+      metric: { type: `${METRIC_PREFIX}/operation/${type}`, labels },
+      resource,
+      metricKind: 'CUMULATIVE',
+      valueType: 'INT64',
+      points: [{ interval: { startTime, endTime }, value: { int64Value: String(value) } }],
+    });
+    const timeSeries = changed.flatMap(({ labels, count, bytes, pending: latencies }) => [
+      cumulative('count', labels, count),
+      cumulative('output_bytes', labels, bytes),
+      {
+        metric: { type: `${METRIC_PREFIX}/operation/latency`, labels },
+        resource,
+        metricKind: 'GAUGE',
+        valueType: 'DOUBLE',
+        points: [
+          {
+            interval: { endTime },
+            value: { doubleValue: latencies.reduce((a, b) => a + b, 0) / latencies.length },
+          },
+        ],
+      },
+    ]);
+    for (const totals of changed) {
+      totals.pending = [];
+    }
+    for (let i = 0; i < timeSeries.length; i += MAX_TIME_SERIES_PER_REQUEST) {
+      await api.post(`${MONITORING_URL}/projects/${project}/timeSeries`, {
+        timeSeries: timeSeries.slice(i, i + MAX_TIME_SERIES_PER_REQUEST),
+      });
+    }
+  };
+
+  /** Exports the spans and metrics recorded since the last export. */
+  const flush = async () => {
+    const spans = pending;
+    pending = [];
+    const requests: Array<Promise<unknown>> = [];
+    if (spans.length > 0 && config.traceProject && api) {
+      const project = config.traceProject;
+      requests.push(
+        api.post(`${CLOUD_TRACE_URL}/projects/${project}/traces:batchWrite`, {
+          spans: spans.map((span) => toCloudTraceSpan(span, project)),
+        }),
+      );
+    }
+    if (spans.length > 0 && config.otlpEndpoint) {
+      const url = `${config.otlpEndpoint.replace(/\/+$/, '')}/v1/traces`;
+      requests.push(
+        fetchFn(url, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify(toOtlpRequest(spans, serviceVersion)),
+        }).then(async (response) => {
+          if (!response.ok) {
+            throw new Error(`Request to ${url} failed with ${response.status}`);
+          }
+        }),
+      );
+    }
+    if (config.metricsProject) {
+      requests.push(exportMetrics(config.metricsProject));
+    }
+    const failures = (await Promise.allSettled(requests)).filter(
+      (result): result is PromiseRejectedResult => result.status === 'rejected',
+    );
+    if (failures.length > 0) {
+      throw new Error(failures.map(({ reason }) => String(reason)).join('\n'));
+    }
+  };
+
+  return {
+    inSpan,
+    flush,
+    /** Exports periodically without keeping the process alive. */
+    start: (intervalMs: number = DEFAULT_EXPORT_INTERVAL_MS) => {
+      setInterval(() => {
+        flush().catch((e: unknown) =>
+          log.warn('Unable to export OpenTelemetry data', { error: String(e) }),
+        );
+      }, intervalMs).unref();
+    },
+  };
+};
+
+type ToolCallback = (...args: unknown[]) => unknown;
+
+/** Returns the trace context a client passed in the `_meta` of a tool call. */
+const clientTraceContext = (args: unknown[]) => {
+  const extra = args.at(-1) as { _meta?: { traceparent?: unknown } } | undefined;
+  return parseTraceparent(extra?._meta?.traceparent);
+};
+
+const resultBytes = (result: unknown) =>
+  Buffer.byteLength(JSON.stringify((result as { content?: unknown } | undefined)?.content ?? []));
+
+/**
+ * Traces every call of the tools registered on the server from now on. A
+ * `traceparent` in the `_meta` of a call continues the trace of the client.
+ */
+export const traceTools = (server: McpServer, tracer: Tracer): McpServer => {
+  const registerTool = server.registerTool.bind(server) as unknown as ToolCallback;
+  const traced = (name: string, config: unknown, callback: ToolCallback) =>
+    registerTool(name, config, (...args: unknown[]) =>
+      tracer.inSpan(
+        `tools/call ${name}`,
+        {
+          kind: 'SERVER',
+          operation: 'tool',
+          attributes: { 'mcp.method.name': 'tools/call', 'gen_ai.tool.name': name },
+          parent: clientTraceContext(args),
+        },
+        async (span) => {
+          const result = await callback(...args);
+          span.setAttributes({ [OUTPUT_BYTES_ATTRIBUTE]: resultBytes(result) });
+          if ((result as { isError?: boolean } | undefined)?.isError === true) {
+            const errorClass = errorClassOf(result);
+            span.setAttributes({ 'error.type': errorClass });
+            span.setError(errorClass);
+          }
+          return result;
+        },
+      ),
+    );
+  server.registerTool = traced as unknown as typeof server.registerTool;
+  return server;
+};
+
+/**
+ * Traces every gcloud process. Spans are named after the API family, e.g.
+ * `gcloud compute`; like usage telemetry, they never include the arguments.
+ */
+export const withGcloudTracing = (gcloud: GcloudExecutable, tracer: Tracer): GcloudExecutable => ({
+  ...gcloud,
+  invoke: async (args, env, options) => {
+    const command = args.filter((arg) => !arg.startsWith('-') && arg !== 'gcloud').join(' ');
+    const apiFamily = apiFamilyOf(command) || 'gcloud';
+    return tracer.inSpan(
+      `gcloud ${apiFamily}`,
+      {
+        kind: 'INTERNAL',
+        operation: 'gcloud',
+        attributes: { 'process.executable.name': 'gcloud', 'gcloud.api_family': apiFamily },
+      },
+      async (span) => {
+        const result = await (options
+          ? gcloud.invoke(args, env, options)
+          : gcloud.invoke(args, env));
+        span.setAttributes({
+          [EXIT_CODE_ATTRIBUTE]: result.code ?? undefined,
+          [OUTPUT_BYTES_ATTRIBUTE]: Buffer.byteLength(result.stdout),
+          ...(result.timedOut && { 'gcloud.timed_out': true }),
+        });
+        if (result.code !== 0) {
+          span.setError(result.code === null ? 'gcloud was stopped' : `exit code ${result.code}`);
+        }
+        return result;
+      },
+    );
+  },
+});
+
+/** Traces the requests of a Google API client. Spans are named after the method and host. */
+export const withApiTracing = <T extends GoogleApiClient>(api: T, tracer: Tracer): T => {
+  const traced = (method: 'GET' | 'POST', url: string, request: () => Promise<unknown>) => {
+    const { host, pathname } = new URL(url);
+    return tracer.inSpan(
+      `${method} ${host}`,
+      {
+        kind: 'CLIENT',
+        operation: 'api',
+        attributes: {
+          'http.request.method': method,
+          'server.address': host,
+          'url.path': pathname,
+        },
+      },
+      async (span) => {
+        try {
+          const result = await request();
+          span.setAttributes({
+            [OUTPUT_BYTES_ATTRIBUTE]: Buffer.byteLength(JSON.stringify(result ?? null)),
+          });
+          return result;
+        } catch (e: unknown) {
+          const status = /failed with (\d{3})/.exec(String(e))?.[1];
+          span.setAttributes({ 'http.response.status_code': status ? Number(status) : undefined });
+          throw e;
+        }
+      },
+    );
+  };
+  return {
+    ...api,
+    get: (url: string) => traced('GET', url, () => api.get(url)),
+    post: (url: string, body: unknown) => traced('POST', url, () => api.post(url, body)),
+  };
+};
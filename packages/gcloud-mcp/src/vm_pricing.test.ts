@@ -0,0 +1,148 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { BillingCatalog, CATALOG_SERVICES, Sku } from './billing_catalog.js';
+import { VmSpecSchema, estimateVmCosts, findMachineTypes, vmCostsToCsv } from './vm_pricing.js';
+
+vi.mock('./gcloud.js');
+
+let mockedGcloud: gcloud.GcloudExecutable;
+
+const sku = (description: string, price: number, usageUnit: string): Sku => ({
+  skuId: description,
+  description,
+  category: { resourceFamily: 'Compute', usageType: 'OnDemand' },
+  serviceRegions: ['us-central1'],
+  pricingInfo: [
+    {
+      pricingExpression: {
+        usageUnit,
+        tieredRates: [
+          {
+            startUsageAmount: 0,
+            unitPrice: { currencyCode: 'USD', units: '0', nanos: Math.round(price * 1e9) },
+          },
+        ],
+      },
+    },
+  ],
+});
+
+const catalog: BillingCatalog = {
+  skus: async (serviceId) =>
+    serviceId === CATALOG_SERVICES.compute
+      ? [
+          sku('N2 Instance Core running in Americas', 0.03, 'h'),
+          sku('N2 Custom Instance Core running in Americas', 0.05, 'h'),
+          sku('N2 Instance Ram running in Americas', 0.004, 'GiBy.h'),
+          sku('Balanced PD Capacity in Americas', 0.1, 'GiBy.mo'),
+        ]
+      : [],
+};
+
+beforeEach(() => {
+  vi.clearAllMocks();
+  mockedGcloud = {
+    lint: vi.fn(),
+    invoke: vi.fn(),
+  };
+});
+
+describe('estimateVmCosts', () => {
+  test('prices each VM with its boot disk', async () => {
+    const result = await estimateVmCosts(
+      mockedGcloud,
+      catalog,
+      VmSpecSchema.array().parse([
+        {
+          name: 'web',
+          region: 'us-central1',
+          machineType: 'n2-standard-4',
+          vcpus: 4,
+          memoryGb: 16,
+          diskGb: 100,
+          count: 2,
+        },
+        { name: 'worker', region: 'us-central1', vcpus: 4, memoryGb: 16 },
+      ]),
+    );
+
+    expect(mockedGcloud.invoke).not.toHaveBeenCalled();
+    expect(result.vms[0]).toMatchObject({
+      name: 'web',
+      computeMonthly: 268.64,
+      diskMonthly: 20,
+      monthly: 288.64,
+      warnings: [],
+    });
+    // The machine type of a VM given by its shape is picked from the N2 family.
+    expect(result.vms[1]).toMatchObject({
+      machineType: 'n2-standard-4',
+      computeMonthly: 134.32,
+      diskMonthly: 1,
+      monthly: 135.32,
+    });
+    expect(result.totalMonthly).toBe(423.96);
+  });
+
+  test('rejects VMs without a machine type or shape', async () => {
+    await expect(
+      estimateVmCosts(
+        mockedGcloud,
+        catalog,
+        VmSpecSchema.array().parse([{ name: 'web', region: 'us-central1', vcpus: 4 }]),
+      ),
+    ).rejects.toThrow('Set either machineType or both vcpus and memoryGb for web.');
+  });
+});
+
+describe('vmCostsToCsv', () => {
+  test('writes one row per VM', async () => {
+    const { vms } = await estimateVmCosts(
+      mockedGcloud,
+      catalog,
+      VmSpecSchema.array().parse([
+        { name: 'db', region: 'us-central1', vcpus: 6, memoryGb: 20, diskType: 'pd-ssd' },
+      ]),
+    );
+
+    expect(vmCostsToCsv(vms).split('\n')).toEqual([
+      'name,region,machineType,vcpus,memoryGb,commitment,count,diskType,diskGb,computeMonthly,diskMonthly,monthly,warnings',
+      'db,us-central1,n2-custom-6-20480,6,20,on-demand,1,pd-ssd,10,219,0,219,No price found for N2 memory; it is not included in the estimate.; No price found for pd-ssd disks; it is not included in the estimate.',
+    ]);
+  });
+});
+
+describe('findMachineTypes', () => {
+  test('lists priced candidates, cheapest first', async () => {
+    const quotes = await findMachineTypes(mockedGcloud, catalog, {
+      vcpus: 6,
+      memoryGb: 20,
+      region: 'us-central1',
+      families: ['n2'],
+      commitment: 'on-demand',
+    });
+
+    expect(quotes.map(({ machineType, monthly }) => [machineType, monthly])).toEqual([
+      ['n2-standard-8', 268.64],
+      ['n2-highmem-8', 362.08],
+      // Custom memory has no price, so the custom type sorts last.
+      ['n2-custom-6-20480', 219],
+    ]);
+  });
+});
@@ -0,0 +1,224 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { BillingCatalog } from './billing_catalog.js';
+import { ResourceSpec, estimateCosts } from './cost_estimate.js';
+import {
+  MACHINE_FAMILIES,
+  MachineFamily,
+  candidateMachineTypes,
+  recommendMachineType,
+} from './migration_center.js';
+import { toCsv } from './output_format.js';
+
+const CommitmentSchema = z.enum(['on-demand', 'spot', '1-year', '3-year']);
+const DiskTypeSchema = z.enum(['pd-standard', 'pd-balanced', 'pd-ssd']);
+
+export const VmSpecSchema = z.object({
+  name: z.string().optional().describe('A label for the VM in the estimate.'),
+  region: z.string().describe('The region, e.g. "us-central1".'),
+  machineType: z
+    .string()
+    .optional()
+    .describe('The machine type, e.g. "n2-standard-4". Picked from vcpus and memoryGb if unset.'),
+  vcpus: z.number().positive().optional(),
+  memoryGb: z.number().positive().optional(),
+  family: z.enum(MACHINE_FAMILIES).optional().describe('The family to pick a machine type from.'),
+  diskGb: z.number().nonnegative().default(10).describe('The size of the boot disk.'),
+  diskType: DiskTypeSchema.default('pd-balanced'),
+  commitment: CommitmentSchema.default('on-demand'),
+  count: z.number().int().positive().default(1),
+});
+export type VmSpec = z.infer<typeof VmSpecSchema>;
+
+export const VmCostRowSchema = z.object({
+  name: z.string().nullable(),
+  region: z.string(),
+  machineType: z.string(),
+  vcpus: z.number().nullable(),
+  memoryGb: z.number().nullable(),
+  commitment: CommitmentSchema,
+  count: z.number(),
+  diskType: DiskTypeSchema,
+  diskGb: z.number(),
+  computeMonthly: z.number(),
+  diskMonthly: z.number(),
+  monthly: z.number(),
+  warnings: z.array(z.string()),
+});
+export type VmCostRow = z.infer<typeof VmCostRowSchema>;
+
+export const VM_COST_COLUMNS: Array<keyof VmCostRow> = [
+  'name',
+  'region',
+  'machineType',
+  'vcpus',
+  'memoryGb',
+  'commitment',
+  'count',
+  'diskType',
+  'diskGb',
+  'computeMonthly',
+  'diskMonthly',
+  'monthly',
+  'warnings',
+];
+
+export const MachineTypeQuoteSchema = z.object({
+  machineType: z.string(),
+  family: z.enum(MACHINE_FAMILIES),
+  fit: z.enum(['predefined', 'custom']),
+  vcpus: z.number(),
+  memoryGb: z.number(),
+  monthly: z.number(),
+  warnings: z.array(z.string()),
+});
+export type MachineTypeQuote = z.infer<typeof MachineTypeQuoteSchema>;
+
+const cents = (value: number) => Math.round(value * 100) / 100;
+
+/**
+ * Resolves the machine type of a VM. A VM given only by its vCPUs and memory
+ * gets the smallest machine type of its family that provides them.
+ */
+const machineTypeOf = (vm: VmSpec) => {
+  if (vm.machineType) {
+    return { machineType: vm.machineType, vcpus: vm.vcpus, memoryGb: vm.memoryGb };
+  }
+  if (!vm.vcpus || !vm.memoryGb) {
+    throw new Error(`Set either machineType or both vcpus and memoryGb for ${vm.name ?? 'a VM'}.`);
+  }
+  const family = vm.family ?? 'n2';
+  const recommended = recommendMachineType({ vcpus: vm.vcpus, memoryGb: vm.memoryGb }, family);
+  if (!recommended.machineType) {
+    throw new Error(`No ${family} machine type provides ${vm.vcpus} vCPUs and ${vm.memoryGb} GB.`);
+  }
+  return {
+    machineType: recommended.machineType,
+    vcpus: recommended.vcpus ?? undefined,
+    memoryGb: recommended.memoryGb ?? undefined,
+  };
+};
+
+/** Estimates the monthly list price of VMs, each with its boot disk. */
+export const estimateVmCosts = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  vms: VmSpec[],
+): Promise<{ currency: string; totalMonthly: number; vms: VmCostRow[] }> => {
+  const rows = await Promise.all(
+    vms.map(async (vm): Promise<VmCostRow> => {
+      const shape = machineTypeOf(vm);
+      const specs: ResourceSpec[] = [
+        {
+          type: 'compute-instance',
+          region: vm.region,
+          machineType: shape.machineType,
+          vcpus: shape.vcpus,
+          memoryGb: shape.memoryGb,
+          commitment: vm.commitment,
+          count: vm.count,
+        },
+      ];
+      if (vm.diskGb > 0) {
+        specs.push({
+          type: 'disk',
+          region: vm.region,
+          diskType: vm.diskType,
+          sizeGb: vm.diskGb,
+          count: vm.count,
+        });
+      }
+      const { resources } = await estimateCosts(gcloud, catalog, specs);
+      const computeMonthly = resources[0]?.monthly ?? 0;
+      const diskMonthly = resources[1]?.monthly ?? 0;
+      return {
+        name: vm.name ?? null,
+        region: vm.region,
+        machineType: shape.machineType,
+        vcpus: shape.vcpus ?? null,
+        memoryGb: shape.memoryGb ?? null,
+        commitment: vm.commitment,
+        count: vm.count,
+        diskType: vm.diskType,
+        diskGb: vm.diskGb,
+        computeMonthly,
+        diskMonthly,
+        monthly: cents(computeMonthly + diskMonthly),
+        warnings: resources.flatMap((resource) => resource.warnings),
+      };
+    }),
+  );
+  return {
+    currency: 'USD',
+    totalMonthly: cents(rows.reduce((sum, row) => sum + row.monthly, 0)),
+    vms: rows,
+  };
+};
+
+/** Converts VM estimates into CSV with one row per VM. */
+export const vmCostsToCsv = (rows: VmCostRow[]): string =>
+  toCsv(
+    rows.map((row) => ({ ...row, warnings: row.warnings.join('; ') })),
+    VM_COST_COLUMNS,
+  );
+
+/**
+ * Lists the machine types that provide a number of vCPUs and amount of
+ * memory, priced in a region and cheapest first.
+ */
+export const findMachineTypes = async (
+  gcloud: GcloudExecutable,
+  catalog: BillingCatalog,
+  options: {
+    vcpus: number;
+    memoryGb: number;
+    region: string;
+    families?: MachineFamily[] | undefined;
+    commitment: VmSpec['commitment'];
+  },
+): Promise<MachineTypeQuote[]> => {
+  const candidates = candidateMachineTypes(
+    { vcpus: options.vcpus, memoryGb: options.memoryGb },
+    options.families?.length ? options.families : MACHINE_FAMILIES,
+  );
+  const { resources } = await estimateCosts(
+    gcloud,
+    catalog,
+    candidates.map((candidate) => ({
+      type: 'compute-instance',
+      region: options.region,
+      machineType: candidate.machineType,
+      vcpus: candidate.vcpus,
+      memoryGb: candidate.memoryGb,
+      commitment: options.commitment,
+      count: 1,
+    })),
+  );
+  // Candidates without a price sort last so that the cheapest are known prices.
+  return candidates
+    .map((candidate, i) => ({
+      ...candidate,
+      monthly: resources[i]?.monthly ?? 0,
+      warnings: resources[i]?.warnings ?? [],
+    }))
+    .sort(
+      (a, b) =>
+        Number(a.warnings.length > 0) - Number(b.warnings.length > 0) || a.monthly - b.monthly,
+    );
+};
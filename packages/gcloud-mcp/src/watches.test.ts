@@ -0,0 +1,211 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import {
+  SubscribeRequestSchema,
+  UnsubscribeRequestSchema,
+} from '@modelcontextprotocol/sdk/types.js';
+import { afterEach, beforeEach, describe, expect, test, vi } from 'vitest';
+import * as gcloud from './gcloud.js';
+import { createAccessControlList } from './denylist.js';
+import { GoogleApiClient } from './google_api.js';
+import {
+  createWatchResources,
+  parseWatchUri,
+  readLogWatch,
+  readMetricWatch,
+} from './watches.js';
+
+vi.mock('./gcloud.js');
+
+const LOG_URI = `gcloud-log-watch://p/${encodeURIComponent('severity>=ERROR')}`;
+const METRIC_FILTER = 'metric.type="run.googleapis.com/request_latencies"';
+const METRIC_URI = `gcloud-metric-watch://p/${encodeURIComponent(METRIC_FILTER)}?threshold=500`;
+
+const entries = (...insertIds: string[]) =>
+  JSON.stringify(insertIds.map((insertId) => ({ insertId, timestamp: '2025-01-01T00:00:00Z' })));
+
+let mockedGcloud: gcloud.GcloudExecutable;
+let api: GoogleApiClient;
+
+beforeEach(() => {
+  mockedGcloud = {
+    lint: vi.fn(),
+    invoke: vi.fn().mockResolvedValue({ code: 0, stdout: entries('a'), stderr: '' }),
+  };
+  api = { get: vi.fn(), post: vi.fn() };
+});
+
+describe('parseWatchUri', () => {
+  test('parses log and metric watches', () => {
+    expect(parseWatchUri(LOG_URI)).toEqual({
+      kind: 'log',
+      project: 'p',
+      filter: 'severity>=ERROR',
+    });
+    expect(parseWatchUri(METRIC_URI)).toEqual({
+      kind: 'metric',
+      project: 'p',
+      filter: METRIC_FILTER,
+      threshold: 500,
+      comparison: 'above',
+    });
+  });
+
+  test('rejects other URIs', () => {
+    expect(parseWatchUri('gcloud-log-watch://p/')).toBe(undefined);
+    expect(parseWatchUri(`gcloud-metric-watch://p/${encodeURIComponent(METRIC_FILTER)}`)).toBe(
+      undefined,
+    );
+    expect(parseWatchUri('https://example.com/x')).toBe(undefined);
+    expect(parseWatchUri('not a uri')).toBe(undefined);
+  });
+});
+
+describe('readLogWatch', () => {
+  test('returns the newest entries', async () => {
+    const snapshot = await readLogWatch(mockedGcloud, {
+      kind: 'log',
+      project: 'p',
+      filter: 'severity>=ERROR',
+    });
+
+    expect(mockedGcloud.invoke).toHaveBeenCalledWith([
+      'logging',
+      'read',
+      'severity>=ERROR',
+      '--project=p',
+      '--freshness=1d',
+      '--limit=20',
+      '--order=desc',
+      '--format=json',
+    ]);
+    expect(snapshot.version).toBe('2025-01-01T00:00:00Z/a');
+  });
+});
+
+describe('readMetricWatch', () => {
+  test('reports series that breach the threshold', async () => {
+    vi.mocked(api.get).mockResolvedValue({
+      timeSeries: [
+        {
+          metric: { type: 'run.googleapis.com/request_latencies' },
+          resource: { type: 'cloud_run_revision', labels: { service_name: 'web' } },
+          points: [{ interval: { endTime: '2025-01-01T00:00:00Z' }, value: { doubleValue: 750 } }],
+        },
+        {
+          metric: { type: 'run.googleapis.com/request_latencies' },
+          resource: { type: 'cloud_run_revision', labels: { service_name: 'api' } },
+          points: [{ interval: { endTime: '2025-01-01T00:00:00Z' }, value: { int64Value: '90' } }],
+        },
+      ],
+    });
+
+    const snapshot = await readMetricWatch(
+      api,
+      { kind: 'metric', project: 'p', filter: METRIC_FILTER, threshold: 500, comparison: 'above' },
+      new Date('2025-01-01T00:00:00Z'),
+    );
+
+    expect(vi.mocked(api.get).mock.calls[0]![0]).toContain(
+      'https://monitoring.googleapis.com/v3/projects/p/timeSeries?filter=',
+    );
+    expect(snapshot.body).toMatchObject({
+      breaching: 1,
+      series: [
+        { labels: { service_name: 'web' }, value: 750, breached: true },
+        { labels: { service_name: 'api' }, value: 90, breached: false },
+      ],
+    });
+  });
+});
+
+describe('createWatchResources', () => {
+  let handlers: Map<unknown, (request: unknown) => Promise<unknown>>;
+  let server: McpServer;
+
+  beforeEach(() => {
+    vi.useFakeTimers();
+    handlers = new Map();
+    server = {
+      registerResource: vi.fn(),
+      server: {
+        registerCapabilities: vi.fn(),
+        setRequestHandler: vi.fn((schema, handler) => handlers.set(schema, handler)),
+        sendResourceUpdated: vi.fn(),
+      },
+    } as unknown as McpServer;
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  const subscribe = (uri: string) =>
+    handlers.get(SubscribeRequestSchema)!({ method: 'resources/subscribe', params: { uri } });
+
+  test('registers the watch resources with subscriptions', () => {
+    createWatchResources(mockedGcloud, api, createAccessControlList()).register(server);
+
+    expect(server.registerResource).toHaveBeenCalledTimes(2);
+    expect(server.server.registerCapabilities).toHaveBeenCalledWith({
+      resources: { subscribe: true },
+    });
+  });
+
+  test('notifies subscribers of new log entries', async () => {
+    createWatchResources(mockedGcloud, api, createAccessControlList(), 1000).register(server);
+    await subscribe(LOG_URI);
+
+    await vi.advanceTimersByTimeAsync(1000);
+    expect(server.server.sendResourceUpdated).not.toHaveBeenCalled();
+
+    vi.mocked(mockedGcloud.invoke).mockResolvedValue({
+      code: 0,
+      stdout: entries('b', 'a'),
+      stderr: '',
+    });
+    await vi.advanceTimersByTimeAsync(1000);
+    expect(server.server.sendResourceUpdated).toHaveBeenCalledWith({ uri: LOG_URI });
+  });
+
+  test('stops polling after unsubscribing', async () => {
+    createWatchResources(mockedGcloud, api, createAccessControlList(), 1000).register(server);
+    await subscribe(LOG_URI);
+    await handlers.get(UnsubscribeRequestSchema)!({
+      method: 'resources/unsubscribe',
+      params: { uri: LOG_URI },
+    });
+
+    await vi.advanceTimersByTimeAsync(5000);
+    expect(mockedGcloud.invoke).toHaveBeenCalledOnce();
+  });
+
+  test('rejects log watches if reading logs is denied', async () => {
+    createWatchResources(mockedGcloud, api, createAccessControlList([], ['logging'])).register(
+      server,
+    );
+
+    await expect(subscribe(LOG_URI)).rejects.toThrow('"gcloud logging read"');
+  });
+
+  test('rejects invalid watch URIs', async () => {
+    createWatchResources(mockedGcloud, api, createAccessControlList()).register(server);
+
+    await expect(subscribe('gcloud-log-watch://p/')).rejects.toThrow('Invalid watch URI');
+  });
+});
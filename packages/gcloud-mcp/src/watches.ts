@@ -0,0 +1,312 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { McpServer, ResourceTemplate } from '@modelcontextprotocol/sdk/server/mcp.js';
+import {
+  ErrorCode,
+  McpError,
+  SubscribeRequestSchema,
+  UnsubscribeRequestSchema,
+} from '@modelcontextprotocol/sdk/types.js';
+import { z } from 'zod';
+import { GcloudExecutable } from './gcloud.js';
+import { GoogleApiClient } from './google_api.js';
+import { AccessControlList } from './denylist.js';
+import { MONITORING_URL } from './telemetry.js';
+import { RESOURCE_WATCH_SCHEME } from './resource_watch.js';
+import { SCHEDULE_SCHEME } from './scheduler.js';
+import { log } from './utility/logger.js';
+
+export const LOG_WATCH_SCHEME = 'gcloud-log-watch';
+export const METRIC_WATCH_SCHEME = 'gcloud-metric-watch';
+
+export const DEFAULT_POLL_INTERVAL_MS = 60 * 1000;
+
+// A log watch returns at most this many of the newest matching entries.
+export const MAX_WATCHED_ENTRIES = 20;
+
+const MINUTE_MS = 60 * 1000;
+
+export type Watch =
+  | { kind: 'log'; project: string; filter: string }
+  | {
+      kind: 'metric';
+      project: string;
+      filter: string;
+      threshold: number;
+      comparison: 'above' | 'below';
+    };
+
+export interface WatchSnapshot {
+  /** Changes whenever subscribers should read the resource again. */
+  version: string;
+  body: unknown;
+}
+
+/**
+ * Parses a watch URI, e.g. `gcloud-log-watch://my-project/severity%3E%3DERROR`
+ * or `gcloud-metric-watch://my-project/<filter>?threshold=0.9&comparison=above`.
+ * The filter is URL encoded. Returns undefined if the URI is not a watch.
+ */
+export const parseWatchUri = (uri: string): Watch | undefined => {
+  let url: URL;
+  try {
+    url = new URL(uri);
+  } catch {
+    return undefined;
+  }
+  const project = url.host;
+  const filter = decodeURIComponent(url.pathname.replace(/^\//, ''));
+  if (!project || !filter) {
+    return undefined;
+  }
+  if (url.protocol === `${LOG_WATCH_SCHEME}:`) {
+    return { kind: 'log', project, filter };
+  }
+  const threshold = Number(url.searchParams.get('threshold') ?? NaN);
+  const comparison = url.searchParams.get('comparison') ?? 'above';
+  if (
+    url.protocol === `${METRIC_WATCH_SCHEME}:` &&
+    Number.isFinite(threshold) &&
+    (comparison === 'above' || comparison === 'below')
+  ) {
+    return { kind: 'metric', project, filter, threshold, comparison };
+  }
+  return undefined;
+};
+
+const LogEntrySchema = z
+  .object({ insertId: z.string().nullish(), timestamp: z.string().nullish() })
+  .passthrough();
+
+/** Returns the newest log entries that match the filter of a log watch. */
+export const readLogWatch = async (
+  gcloud: GcloudExecutable,
+  watch: Extract<Watch, { kind: 'log' }>,
+): Promise<WatchSnapshot> => {
+  const args = [
+    'logging',
+    'read',
+    watch.filter,
+    `--project=${watch.project}`,
+    '--freshness=1d',
+    `--limit=${MAX_WATCHED_ENTRIES}`,
+    '--order=desc',
+    '--format=json',
+  ];
+  const { code, stdout, stderr } = await gcloud.invoke(args);
+  if (code !== 0) {
+    throw new Error(`gcloud ${args.join(' ')} failed: ${stderr}`);
+  }
+  const entries = z.array(LogEntrySchema).parse(JSON.parse(stdout || '[]'));
+  const newest = entries[0];
+  return {
+    version: newest ? `${newest.timestamp ?? ''}/${newest.insertId ?? ''}` : '',
+    body: { project: watch.project, filter: watch.filter, entries },
+  };
+};
+
+const PointSchema = z.object({
+  interval: z.object({ endTime: z.string() }),
+  value: z.object({
+    doubleValue: z.number().nullish(),
+    int64Value: z.string().nullish(),
+  }),
+});
+
+const TimeSeriesListSchema = z.object({
+  timeSeries: z
+    .array(
+      z.object({
+        metric: z.object({ type: z.string(), labels: z.record(z.string()).nullish() }),
+        resource: z.object({ type: z.string(), labels: z.record(z.string()).nullish() }),
+        points: z.array(PointSchema).default([]),
+      }),
+    )
+    .default([]),
+});
+
+/**
+ * Returns the latest value of every time series matching the filter of a
+ * metric watch and whether it breaches the threshold. The version covers the
+ * set of breaching series, so subscribers are notified when a breach starts
+ * or ends.
+ */
+export const readMetricWatch = async (
+  api: GoogleApiClient,
+  watch: Extract<Watch, { kind: 'metric' }>,
+  now: Date = new Date(),
+): Promise<WatchSnapshot> => {
+  const params = new URLSearchParams({
+    filter: watch.filter,
+    'interval.startTime': new Date(now.getTime() - 10 * MINUTE_MS).toISOString(),
+    'interval.endTime': now.toISOString(),
+    'aggregation.alignmentPeriod': '60s',
+    'aggregation.perSeriesAligner': 'ALIGN_MEAN',
+  });
+  const { timeSeries } = TimeSeriesListSchema.parse(
+    await api.get(`${MONITORING_URL}/projects/${watch.project}/timeSeries?${params.toString()}`),
+  );
+  const series = timeSeries.flatMap(({ metric, resource, points }) => {
+    // Points are returned newest first.
+    const latest = points[0];
+    if (!latest) {
+      return [];
+    }
+    const value = latest.value.doubleValue ?? Number(latest.value.int64Value ?? NaN);
+    const breached =
+      watch.comparison === 'above' ? value > watch.threshold : value < watch.threshold;
+    return [
+      {
+        metric: metric.type,
+        labels: { ...resource.labels, ...metric.labels },
+        value,
+        time: latest.interval.endTime,
+        breached,
+      },
+    ];
+  });
+  const breaching = series.filter(({ breached }) => breached);
+  return {
+    version: breaching
+      .map(({ metric, labels }) => JSON.stringify([metric, labels]))
+      .sort()
+      .join('\n'),
+    body: {
+      project: watch.project,
+      filter: watch.filter,
+      threshold: watch.threshold,
+      comparison: watch.comparison,
+      breaching: breaching.length,
+      series,
+    },
+  };
+};
+
+/**
+ * Creates the log and metric watch resources. Clients subscribe to a watch
+ * URI and are sent resource updated notifications when new matching log
+ * entries appear or the set of series breaching the threshold changes, so
+ * they can keep a live view without polling tools.
+ */
+export const createWatchResources = (
+  gcloud: GcloudExecutable,
+  api: GoogleApiClient,
+  acl: AccessControlList,
+  pollIntervalMs: number = DEFAULT_POLL_INTERVAL_MS,
+) => {
+  const read = async (watch: Watch): Promise<WatchSnapshot> => {
+    if (watch.kind === 'metric') {
+      return readMetricWatch(api, watch);
+    }
+    if (!acl.check('logging read').permitted) {
+      throw new McpError(
+        ErrorCode.InvalidRequest,
+        'Watching logs requires "gcloud logging read", which is not permitted.',
+      );
+    }
+    return readLogWatch(gcloud, watch);
+  };
+
+  const readResource = async (uri: URL) => {
+    const watch = parseWatchUri(uri.href);
+    if (!watch) {
+      throw new McpError(ErrorCode.InvalidParams, `Invalid watch URI: ${uri.href}`);
+    }
+    const { body } = await read(watch);
+    return {
+      contents: [
+        { uri: uri.href, mimeType: 'application/json', text: JSON.stringify(body, null, 2) },
+      ],
+    };
+  };
+
+  return {
+    register: (server: McpServer) => {
+      server.registerResource(
+        'log-watch',
+        new ResourceTemplate(`${LOG_WATCH_SCHEME}://{project}/{filter}`, { list: undefined }),
+        {
+          title: 'Log watch',
+          description:
+            'The newest log entries of a project that match a URL-encoded Cloud Logging filter. Subscribe to be notified when new entries match.',
+          mimeType: 'application/json',
+        },
+        readResource,
+      );
+      server.registerResource(
+        'metric-watch',
+        new ResourceTemplate(`${METRIC_WATCH_SCHEME}://{project}/{filter}{?threshold,comparison}`, {
+          list: undefined,
+        }),
+        {
+          title: 'Metric watch',
+          description:
+            'The latest values of the time series matching a URL-encoded Cloud Monitoring filter, compared with a threshold. Subscribe to be notified when a breach starts or ends.',
+          mimeType: 'application/json',
+        },
+        readResource,
+      );
+
+      const subscriptions = new Map<string, NodeJS.Timeout>();
+      const unsubscribe = (uri: string) => {
+        clearInterval(subscriptions.get(uri));
+        subscriptions.delete(uri);
+      };
+      server.server.registerCapabilities({ resources: { subscribe: true } });
+      server.server.setRequestHandler(SubscribeRequestSchema, async ({ params: { uri } }) => {
+        if (
+          uri.startsWith(`${RESOURCE_WATCH_SCHEME}://`) ||
+          uri.startsWith(`${SCHEDULE_SCHEME}://`)
+        ) {
+          // watch_resource and scheduled jobs notify without a subscription.
+          return {};
+        }
+        const watch = parseWatchUri(uri);
+        if (!watch) {
+          throw new McpError(ErrorCode.InvalidParams, `Invalid watch URI: ${uri}`);
+        }
+        if (subscriptions.has(uri)) {
+          return {};
+        }
+        // The first read sets the baseline, so only later changes are notified.
+        let version = (await read(watch)).version;
+        const timer = setInterval(() => {
+          read(watch)
+            .then(async (snapshot) => {
+              if (snapshot.version !== version) {
+                version = snapshot.version;
+                await server.server.sendResourceUpdated({ uri });
+              }
+            })
+            .catch((e: unknown) => log.warn(`Unable to poll ${uri}`, { error: String(e) }));
+        }, pollIntervalMs);
+        timer.unref();
+        subscriptions.set(uri, timer);
+        return {};
+      });
+      server.server.setRequestHandler(UnsubscribeRequestSchema, async ({ params: { uri } }) => {
+        unsubscribe(uri);
+        return {};
+      });
+      const onclose = server.server.onclose;
+      server.server.onclose = () => {
+        [...subscriptions.keys()].forEach(unsubscribe);
+        onclose?.();
+      };
+    },
+  };
+};
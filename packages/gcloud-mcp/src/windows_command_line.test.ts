@@ -0,0 +1,59 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, test, expect } from 'vitest';
+import { cmdShimCommandLine, quoteWindowsArg, unquotePath } from './windows_command_line.js';
+
+describe('quoteWindowsArg', () => {
+  test('leaves simple arguments unquoted', () => {
+    expect(quoteWindowsArg('--format=json')).toBe('--format=json');
+    expect(quoteWindowsArg('C:\\path\\to\\dir')).toBe('C:\\path\\to\\dir');
+  });
+
+  test('quotes filter expressions containing spaces and quotes', () => {
+    expect(quoteWindowsArg('--filter=status = RUNNING AND name ~ web')).toBe(
+      '"--filter=status = RUNNING AND name ~ web"',
+    );
+    expect(quoteWindowsArg('--filter=labels.team="data eng"')).toBe(
+      '"--filter=labels.team=\\"data eng\\""',
+    );
+  });
+
+  test('escapes backslashes only before quotes', () => {
+    expect(quoteWindowsArg('C:\\My Files\\')).toBe('"C:\\My Files\\\\"');
+    expect(quoteWindowsArg('a\\"b')).toBe('"a\\\\\\"b"');
+    expect(quoteWindowsArg('')).toBe('""');
+  });
+});
+
+describe('cmdShimCommandLine', () => {
+  test('escapes cmd.exe metacharacters for the batch file', () => {
+    expect(
+      cmdShimCommandLine('C:\\gcloud\\bin\\gcloud.cmd', ['--filter=name:web & tier=(a|b)']),
+    ).toBe(
+      '"chcp 65001 >NUL & C:\\gcloud\\bin\\gcloud.cmd ^^^"--filter=name:web^^^ ^^^&^^^ tier=^^^(a^^^|b^^^)^^^""',
+    );
+  });
+});
+
+describe('unquotePath', () => {
+  test('removes surrounding quotes', () => {
+    expect(unquotePath('"C:\\Program Files\\Python\\python.exe"')).toBe(
+      'C:\\Program Files\\Python\\python.exe',
+    );
+    expect(unquotePath('C:\\Python\\python.exe')).toBe('C:\\Python\\python.exe');
+  });
+});
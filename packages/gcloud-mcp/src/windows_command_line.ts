@@ -0,0 +1,60 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Characters cmd.exe interprets outside of quotes; each is escaped with `^`.
+const CMD_META_CHARS = /([()\][%!^"`<>&|;, *?])/g;
+
+// Python writes to pipes in the ANSI code page otherwise, which mangles
+// non-ASCII output such as resource descriptions.
+export const WINDOWS_UTF8_ENV: NodeJS.ProcessEnv = {
+  PYTHONIOENCODING: 'utf-8',
+  PYTHONUTF8: '1',
+};
+
+/**
+ * Quotes an argument so CommandLineToArgvW, and so Python, parses it back
+ * unchanged: backslashes are only special before a double quote.
+ */
+export const quoteWindowsArg = (arg: string, always = false): string => {
+  if (!always && arg !== '' && !/[\s"]/.test(arg)) {
+    return arg;
+  }
+  const escaped = arg
+    // Double the backslashes preceding a quote, and escape the quote.
+    .replace(/(\\*)"/g, '$1$1\\"')
+    // Double trailing backslashes, which would otherwise escape the closing quote.
+    .replace(/(\\+)$/, '$1$1');
+  return `"${escaped}"`;
+};
+
+const escapeCmdMetaChars = (value: string): string => value.replace(CMD_META_CHARS, '^$1');
+
+/**
+ * Returns the command line running a batch file such as gcloud.cmd through
+ * `cmd.exe /d /s /c`. cmd.exe parses the line once when running it and again
+ * when the batch file expands `%*`, so the arguments are escaped twice.
+ */
+export const cmdShimCommandLine = (batchFile: string, args: string[]): string => {
+  const command = [
+    escapeCmdMetaChars(quoteWindowsArg(batchFile)),
+    ...args.map((arg) => escapeCmdMetaChars(escapeCmdMetaChars(quoteWindowsArg(arg, true)))),
+  ].join(' ');
+  // Switch the console to UTF-8 before running, for the same reason as WINDOWS_UTF8_ENV.
+  return `"chcp 65001 >NUL & ${command}"`;
+};
+
+/** Removes the quotes users commonly put around paths in environment variables. */
+export const unquotePath = (value: string): string => value.trim().replace(/^"(.*)"$/, '$1');
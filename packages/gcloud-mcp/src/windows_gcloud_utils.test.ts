@@ -188,6 +188,19 @@ describe('windows_gcloud_utils', () => {
       expect(settings.noWorkingPythonFound).toBe(true);
     });
 
+    it('should fall back to gcloud.cmd if no working python is found', async () => {
+      vi.spyOn(fs, 'existsSync').mockReturnValue(true);
+      spawnSpy.mockReturnValue(createMockChildProcess('', 'whoops', 1));
+
+      const settings = await getWindowsCloudSDKSettingsAsync({
+        CLOUDSDK_ROOT_DIR: '"C:\\Program Files\\CloudSDK"',
+        CLOUDSDK_PYTHON: 'C:\\NonExistentPython\\python.exe',
+      });
+
+      expect(settings.cloudSdkRootDir).toBe('C:\\Program Files\\CloudSDK');
+      expect(settings.gcloudCmdPath).toBe('C:\\Program Files\\CloudSDK\\bin\\gcloud.cmd');
+    });
+
     it('should handle VIRTUAL_ENV for site packages', async () => {
       vi.spyOn(fs, 'existsSync').mockReturnValue(false);
       spawnSpy.mockReturnValue(createMockChildProcess('3.9.0'));
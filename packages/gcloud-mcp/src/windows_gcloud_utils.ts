@@ -18,6 +18,7 @@ import * as child_process from 'child_process';
 import * as fs from 'fs';
 import * as path from 'path';
 import { log } from './utility/logger.js';
+import { unquotePath } from './windows_command_line.js';
 
 export interface WindowsCloudSDKSettings {
   gcloudPyPath: string;
@@ -25,6 +26,11 @@ export interface WindowsCloudSDKSettings {
   cloudSdkPythonArgsList: string[];
   noWorkingPythonFound: boolean;
   cloudSdkRootDir: string;
+  /**
+   * The gcloud.cmd to run when gcloud.py can not be run directly, e.g.
+   * because no working Python was found for it.
+   */
+  gcloudCmdPath?: string;
   /** Environment variables to use when spawning gcloud.py */
   env: { [key: string]: string | undefined };
 }
@@ -149,13 +155,16 @@ export async function findWindowsPythonPathAsync(spawnEnv: {
 }
 
 export async function getSDKRootDirectoryAsync(env: NodeJS.ProcessEnv): Promise<string> {
-  const cloudSdkRootDir = env['CLOUDSDK_ROOT_DIR'] || '';
+  const cloudSdkRootDir = unquotePath(env['CLOUDSDK_ROOT_DIR'] || '');
   if (cloudSdkRootDir) {
     return path.win32.normalize(cloudSdkRootDir);
   }
 
-  // Use 'where gcloud' to find the gcloud executable on Windows
-  const gcloudPathOutput = (await spawnWhereAsync('gcloud', env))[0];
+  // Use 'where gcloud' to find the gcloud executable on Windows. It also lists
+  // the extensionless shell script, so prefer the batch file.
+  const gcloudPaths = await spawnWhereAsync('gcloud', env);
+  const gcloudPathOutput =
+    gcloudPaths.find((candidate) => candidate.toLowerCase().endsWith('.cmd')) ?? gcloudPaths[0];
 
   if (gcloudPathOutput) {
     // Assuming gcloud.cmd is in <SDK_ROOT>/bin/gcloud.cmd
@@ -177,7 +186,7 @@ export async function getWindowsCloudSDKSettingsAsync(
   const env = { ...currentEnv };
   const cloudSdkRootDir = await getSDKRootDirectoryAsync(env);
 
-  let cloudSdkPython = env['CLOUDSDK_PYTHON'] || '';
+  let cloudSdkPython = unquotePath(env['CLOUDSDK_PYTHON'] || '');
   // Find bundled python if no python is set in the environment.
   if (!cloudSdkPython) {
     const bundledPython = path.win32.join(
@@ -222,13 +231,20 @@ export async function getWindowsCloudSDKSettingsAsync(
   const gcloudPyPath = path.win32.join(cloudSdkRootDir, 'lib', 'gcloud.py');
 
   cloudSdkPython = path.win32.normalize(cloudSdkPython);
+  const noWorkingPythonFound = !(await getPythonVersionAsync(cloudSdkPython, env));
+
+  // gcloud.cmd locates its own Python, so it still works when gcloud.py can't be run directly.
+  const gcloudCmdPath = path.win32.join(cloudSdkRootDir, 'bin', 'gcloud.cmd');
+  const useCmdShim =
+    (noWorkingPythonFound || !fs.existsSync(gcloudPyPath)) && fs.existsSync(gcloudCmdPath);
 
   return {
     gcloudPyPath,
     cloudSdkPython,
     cloudSdkPythonArgsList,
-    noWorkingPythonFound: !(await getPythonVersionAsync(cloudSdkPython, env)),
+    noWorkingPythonFound,
     cloudSdkRootDir,
+    ...(useCmdShim && { gcloudCmdPath }),
     env,
   };
 }
@@ -18,6 +18,7 @@ import { test, expect, vi, beforeEach } from 'vitest';
 import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
 import { StdioServerTransport } from '@modelcontextprotocol/sdk/server/stdio.js';
 import { registerTools } from './tools/registration.js';
+import { registerWatchTools } from './tools/watch/watch_registration.js';
 import { init } from './commands/init.js';
 
 vi.mock('../package.json', () => ({
@@ -28,6 +29,7 @@ vi.mock('../package.json', () => ({
 vi.mock('@modelcontextprotocol/sdk/server/mcp.js');
 vi.mock('@modelcontextprotocol/sdk/server/stdio.js');
 vi.mock('./tools/registration.js');
+vi.mock('./tools/watch/watch_registration.js');
 vi.mock('./commands/init.js');
 
 beforeEach(() => {
@@ -49,14 +51,18 @@ test('should start the McpServer', async () => {
   process.argv = ['node', 'server.js'];
   await import('./server.js');
 
-  expect(McpServer).toHaveBeenCalledWith({
-    name: 'observability-mcp',
-    version: '1.2.3',
-    title: 'Cloud Observability MCP',
-  });
+  expect(McpServer).toHaveBeenCalledWith(
+    {
+      name: 'observability-mcp',
+      version: '1.2.3',
+      title: 'Cloud Observability MCP',
+    },
+    { capabilities: { logging: {} } },
+  );
 
   const serverInstance = vi.mocked(McpServer).mock.instances[0];
   expect(serverInstance).toBeDefined();
   expect(registerTools).toHaveBeenCalledWith(serverInstance);
+  expect(registerWatchTools).toHaveBeenCalledWith(serverInstance);
   expect(serverInstance!.connect).toHaveBeenCalledWith(expect.any(StdioServerTransport));
 });
@@ -19,18 +19,24 @@
 import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
 import { StdioServerTransport } from '@modelcontextprotocol/sdk/server/stdio.js';
 import { registerTools } from './tools/registration.js';
+import { registerWatchTools } from './tools/watch/watch_registration.js';
 import pkg from '../package.json' with { type: 'json' };
 import yargs, { ArgumentsCamelCase, CommandModule } from 'yargs';
 import { hideBin } from 'yargs/helpers';
 import { init } from './commands/init.js';
 
 const getServer = (): McpServer => {
-  const server = new McpServer({
-    name: 'observability-mcp',
-    version: pkg.version,
-    title: 'Cloud Observability MCP',
-  });
+  const server = new McpServer(
+    {
+      name: 'observability-mcp',
+      version: pkg.version,
+      title: 'Cloud Observability MCP',
+    },
+    // Logging is used to push watch notifications to the client.
+    { capabilities: { logging: {} } },
+  );
   registerTools(server);
+  registerWatchTools(server);
   return server;
 };
 
@@ -0,0 +1,69 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { describe, it, expect } from 'vitest';
+import { buildLogFilter, quoteFilterValue } from './log_filter.js';
+
+describe('quoteFilterValue', () => {
+  it('should quote values and escape quotes and backslashes', () => {
+    expect(quoteFilterValue('connection failed')).toBe('"connection failed"');
+    expect(quoteFilterValue('say "hi" \\o/')).toBe('"say \\"hi\\" \\\\o/"');
+  });
+});
+
+describe('buildLogFilter', () => {
+  const now = new Date('2025-01-01T12:00:00Z');
+
+  it('should return an empty filter when no fields are set', () => {
+    expect(buildLogFilter({}, now)).toBe('');
+  });
+
+  it('should match the service name on the label of the resource type', () => {
+    expect(buildLogFilter({ resourceType: 'k8s_container', serviceName: 'web' }, now)).toBe(
+      'resource.type="k8s_container" AND resource.labels.container_name="web"',
+    );
+  });
+
+  it('should match the service name on all known labels without a resource type', () => {
+    expect(buildLogFilter({ serviceName: 'web' }, now)).toBe(
+      '(resource.labels.service_name="web" OR resource.labels.job_name="web" OR ' +
+        'resource.labels.function_name="web" OR resource.labels.module_id="web" OR ' +
+        'resource.labels.container_name="web")',
+    );
+  });
+
+  it('should compute the start time from the freshness', () => {
+    expect(buildLogFilter({ freshness: '1h', severity: 'WARNING' }, now)).toBe(
+      'severity>=WARNING AND timestamp>="2025-01-01T11:00:00.000Z"',
+    );
+  });
+
+  it('should prefer the start time over the freshness', () => {
+    expect(
+      buildLogFilter(
+        { startTime: '2025-01-01T00:00:00Z', endTime: '2025-01-01T01:00:00Z', freshness: '1h' },
+        now,
+      ),
+    ).toBe('timestamp>="2025-01-01T00:00:00Z" AND timestamp<="2025-01-01T01:00:00Z"');
+  });
+
+  it('should search free text as a phrase and wrap the raw filter', () => {
+    expect(buildLogFilter({ text: 'out of memory', filter: 'a="1" OR b="2"' }, now)).toBe(
+      '"out of memory" AND (a="1" OR b="2")',
+    );
+  });
+});
@@ -0,0 +1,95 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { TimeRange, resolveStartTime } from '../../utils/timestamps.js';
+
+export const LOG_SEVERITIES = [
+  'DEFAULT',
+  'DEBUG',
+  'INFO',
+  'NOTICE',
+  'WARNING',
+  'ERROR',
+  'CRITICAL',
+  'ALERT',
+  'EMERGENCY',
+] as const;
+export type LogSeverity = (typeof LOG_SEVERITIES)[number];
+
+/** The structured fields of a log query, combined with AND. */
+export interface LogQueryFields extends TimeRange {
+  resourceType?: string;
+  serviceName?: string;
+  /** Minimum severity of the entries to return. */
+  severity?: LogSeverity;
+  /** Text searched for as a phrase in all fields of the entries. */
+  text?: string;
+  /** A raw filter expression for anything the other fields cannot express. */
+  filter?: string;
+}
+
+// The resource label that holds the service name of each resource type.
+const SERVICE_NAME_LABELS: Record<string, string> = {
+  cloud_run_revision: 'service_name',
+  cloud_run_job: 'job_name',
+  cloud_function: 'function_name',
+  gae_app: 'module_id',
+  k8s_container: 'container_name',
+};
+
+/**
+ * Quotes a value for the Logging query language. Unquoted values are split on
+ * spaces into separate terms, so `connection failed` would match entries that
+ * contain either word anywhere.
+ */
+export const quoteFilterValue = (value: string): string =>
+  `"${value.replace(/\\/g, '\\\\').replace(/"/g, '\\"')}"`;
+
+const serviceNameClause = (serviceName: string, resourceType?: string): string => {
+  const label = resourceType ? SERVICE_NAME_LABELS[resourceType] : undefined;
+  const labels = label ? [label] : [...new Set(Object.values(SERVICE_NAME_LABELS))];
+  const clauses = labels.map((name) => `resource.labels.${name}=${quoteFilterValue(serviceName)}`);
+  return clauses.length === 1 ? clauses[0]! : `(${clauses.join(' OR ')})`;
+};
+
+/** Builds a Logging query language filter from the structured fields. */
+export const buildLogFilter = (fields: LogQueryFields, now: Date = new Date()): string => {
+  const clauses: string[] = [];
+  if (fields.resourceType) {
+    clauses.push(`resource.type=${quoteFilterValue(fields.resourceType)}`);
+  }
+  if (fields.serviceName) {
+    clauses.push(serviceNameClause(fields.serviceName, fields.resourceType));
+  }
+  if (fields.severity) {
+    clauses.push(`severity>=${fields.severity}`);
+  }
+  const startTime = resolveStartTime(fields, now);
+  if (startTime) {
+    clauses.push(`timestamp>=${quoteFilterValue(startTime)}`);
+  }
+  if (fields.endTime) {
+    clauses.push(`timestamp<=${quoteFilterValue(fields.endTime)}`);
+  }
+  if (fields.text) {
+    clauses.push(quoteFilterValue(fields.text));
+  }
+  if (fields.filter?.trim()) {
+    clauses.push(`(${fields.filter.trim()})`);
+  }
+  return clauses.join(' AND ');
+};
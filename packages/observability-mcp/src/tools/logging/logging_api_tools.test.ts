@@ -19,6 +19,7 @@ import { GaxiosResponse } from 'gaxios';
 import { logging_v2 } from 'googleapis';
 import {
   listLogEntries,
+  queryLogs,
   listBuckets,
   listViews,
   listSinks,
@@ -165,6 +166,40 @@ describe('listLogEntries', () => {
     });
   });
 
+  it('should fetch pages sized from observed entries when autoPaginate is set', async () => {
+    const loggingClient = apiClientFactory.getLoggingClient();
+    (loggingClient.entries.list as Mock)
+      .mockResolvedValueOnce({
+        data: { entries: [{ textPayload: 'first' }], nextPageToken: 'page-2' },
+      })
+      .mockResolvedValueOnce({
+        data: { entries: [{ textPayload: 'second' }] },
+      });
+
+    const result = await listLogEntries(
+      [TEST_PROJECT_RESOURCE],
+      'test filter',
+      'timestamp desc',
+      1,
+      undefined,
+      true,
+    );
+
+    expect(JSON.parse(result)).toEqual({
+      entries: [{ textPayload: 'first' }, { textPayload: 'second' }],
+      pages: 2,
+    });
+    expect(loggingClient.entries.list).toHaveBeenLastCalledWith({
+      requestBody: {
+        resourceNames: [TEST_PROJECT_RESOURCE],
+        filter: 'test filter',
+        orderBy: 'timestamp desc',
+        pageSize: 1000,
+        pageToken: 'page-2',
+      },
+    });
+  });
+
   it('should throw an error if the API call fails', async () => {
     const errorMessage = 'API Error';
     const loggingClient = apiClientFactory.getLoggingClient();
@@ -373,6 +408,92 @@ describe('listLogScopes', () => {
   });
 });
 
+describe('queryLogs', () => {
+  it('should build the filter and return summarized entries', async () => {
+    const loggingClient = apiClientFactory.getLoggingClient();
+    (loggingClient.entries.list as Mock).mockResolvedValue({
+      data: {
+        entries: [
+          {
+            timestamp: '2025-01-01T00:00:00Z',
+            severity: 'ERROR',
+            resource: { type: 'cloud_run_revision', labels: { service_name: 'api' } },
+            jsonPayload: { message: 'database connection failed', attempt: 3 },
+            trace: 'projects/my-project/traces/abc',
+          },
+        ],
+        nextPageToken: 'page-2',
+      },
+    });
+
+    const result = await queryLogs({
+      projectId: TEST_PROJECT_ID,
+      resourceType: 'cloud_run_revision',
+      serviceName: 'api',
+      severity: 'ERROR',
+      text: 'connection failed',
+    });
+
+    const filter =
+      'resource.type="cloud_run_revision" AND resource.labels.service_name="api" AND ' +
+      'severity>=ERROR AND "connection failed"';
+    expect(result).toEqual({
+      filter,
+      entries: [
+        {
+          timestamp: '2025-01-01T00:00:00Z',
+          severity: 'ERROR',
+          resource: { type: 'cloud_run_revision', labels: { service_name: 'api' } },
+          message: 'database connection failed',
+          payload: { message: 'database connection failed', attempt: 3 },
+          trace: 'projects/my-project/traces/abc',
+        },
+      ],
+      nextPageToken: 'page-2',
+    });
+    expect(loggingClient.entries.list).toHaveBeenCalledWith({
+      requestBody: {
+        resourceNames: [TEST_PROJECT_RESOURCE],
+        filter,
+        orderBy: 'timestamp desc',
+        pageSize: 50,
+        pageToken: undefined,
+      },
+    });
+  });
+
+  it('should cap the page size and pass the page token', async () => {
+    const loggingClient = apiClientFactory.getLoggingClient();
+    (loggingClient.entries.list as Mock).mockResolvedValue({ data: {} });
+
+    const result = await queryLogs({
+      projectId: TEST_PROJECT_ID,
+      pageSize: 5000,
+      pageToken: 'page-2',
+    });
+
+    expect(result).toEqual({ filter: '', entries: [] });
+    expect(loggingClient.entries.list).toHaveBeenCalledWith({
+      requestBody: {
+        resourceNames: [TEST_PROJECT_RESOURCE],
+        filter: '',
+        orderBy: 'timestamp desc',
+        pageSize: 1000,
+        pageToken: 'page-2',
+      },
+    });
+  });
+
+  it('should throw an error if the API call fails', async () => {
+    const loggingClient = apiClientFactory.getLoggingClient();
+    (loggingClient.entries.list as Mock).mockRejectedValue(new Error('API Error'));
+
+    await expect(queryLogs({ projectId: TEST_PROJECT_ID })).rejects.toThrow(
+      'Failed to query log entries: API Error',
+    );
+  });
+});
+
 describe('listLogNames', () => {
   it('should return a JSON string of log names on success', async () => {
     const mockResponse = createMockListLogNamesResponse(['projects/my-project/logs/my-log']);
@@ -15,9 +15,15 @@
  */
 
 import { apiClientFactory } from '../../utils/api_client_factory.js';
+import { logging_v2 } from 'googleapis';
+import { paginateWithinBudget } from '../../utils/pagination.js';
+import { LogQueryFields, buildLogFilter } from './log_filter.js';
 
 const logging = apiClientFactory.getLoggingClient();
 
+// The Logging API accepts at most this many entries per page.
+const MAX_LOG_ENTRIES_PAGE_SIZE = 1000;
+
 /**
  * Lists log entries from the Google Cloud Logging API.
  * @param resourceNames The resource names to search for log entries (e.g.,
@@ -26,6 +32,8 @@ const logging = apiClientFactory.getLoggingClient();
  * @param orderBy How the results should be sorted.
  * @param pageSize The maximum number of results to return from this request.
  * @param pageToken If present, then retrieve the next batch of results.
+ * @param autoPaginate If true, keep fetching pages until the response size
+ *     budget is filled, sizing each page from the entries seen so far.
  * @returns A promise that resolves with a string containing the log entries in
  *     JSON format, or an error message.
  */
@@ -35,20 +43,32 @@ export async function listLogEntries(
   orderBy: 'timestamp asc' | 'timestamp desc' = 'timestamp asc',
   pageSize = 50,
   pageToken?: string,
+  autoPaginate = false,
 ): Promise<string> {
-  const request = {
-    requestBody: {
-      resourceNames,
-      filter,
-      orderBy,
-      pageSize,
-      pageToken,
-    },
+  const fetchPage = async (size: number, token?: string) => {
+    const response = await logging.entries.list({
+      requestBody: {
+        resourceNames,
+        filter,
+        orderBy,
+        pageSize: size,
+        pageToken: token,
+      },
+    });
+    return { items: response.data.entries || [], nextPageToken: response.data.nextPageToken };
   };
 
   try {
-    const response = await logging.entries.list(request);
-    return JSON.stringify(response.data.entries || [], null, 2);
+    if (autoPaginate) {
+      const { items, nextPageToken, pages } = await paginateWithinBudget(
+        fetchPage,
+        { initialPageSize: pageSize, maxPageSize: MAX_LOG_ENTRIES_PAGE_SIZE },
+        pageToken,
+      );
+      return JSON.stringify({ entries: items, nextPageToken, pages }, null, 2);
+    }
+    const { items } = await fetchPage(pageSize, pageToken);
+    return JSON.stringify(items, null, 2);
   } catch (error: unknown) {
     if (error instanceof Error) {
       throw new Error(`Failed to list log entries: ${error.message}`);
@@ -57,6 +77,92 @@ export async function listLogEntries(
   }
 }
 
+export interface LogQuery extends LogQueryFields {
+  projectId: string;
+  orderBy?: 'timestamp asc' | 'timestamp desc';
+  pageSize?: number;
+  pageToken?: string;
+}
+
+export interface LogQueryEntry {
+  timestamp?: string;
+  severity?: string;
+  logName?: string;
+  resource?: { type?: string; labels?: Record<string, string> };
+  /** The text payload, or the message of a JSON payload. */
+  message?: string;
+  payload?: unknown;
+  labels?: Record<string, string>;
+  trace?: string;
+  spanId?: string;
+  insertId?: string;
+}
+
+export interface LogQueryResult {
+  /** The filter built from the query, to refine or reuse with list_log_entries. */
+  filter: string;
+  entries: LogQueryEntry[];
+  nextPageToken?: string;
+}
+
+const messageOf = (entry: logging_v2.Schema$LogEntry): string | undefined => {
+  if (entry.textPayload) {
+    return entry.textPayload;
+  }
+  const message = entry.jsonPayload?.['message'];
+  return typeof message === 'string' ? message : undefined;
+};
+
+const toQueryEntry = (entry: logging_v2.Schema$LogEntry): LogQueryEntry => {
+  const result: LogQueryEntry = {
+    timestamp: entry.timestamp ?? undefined,
+    severity: entry.severity ?? undefined,
+    logName: entry.logName ?? undefined,
+    resource: entry.resource
+      ? { type: entry.resource.type ?? undefined, labels: entry.resource.labels ?? undefined }
+      : undefined,
+    message: messageOf(entry),
+    payload: entry.jsonPayload ?? entry.protoPayload ?? undefined,
+    labels: entry.labels ?? undefined,
+    trace: entry.trace ?? undefined,
+    spanId: entry.spanId ?? undefined,
+    insertId: entry.insertId ?? undefined,
+  };
+  // Drop unset fields so that they do not show up as nulls in the result.
+  return JSON.parse(JSON.stringify(result));
+};
+
+/**
+ * Queries log entries with a filter built from structured fields, so that
+ * callers do not have to write Logging query language themselves.
+ * @param query The project, the fields to filter on and the paging options.
+ * @param now The time that a relative freshness is measured from.
+ * @returns A promise that resolves with the filter that was used, the matching
+ *     entries and the token of the next page, if any.
+ */
+export async function queryLogs(query: LogQuery, now: Date = new Date()): Promise<LogQueryResult> {
+  const filter = buildLogFilter(query, now);
+  try {
+    const response = await logging.entries.list({
+      requestBody: {
+        resourceNames: [`projects/${query.projectId}`],
+        filter,
+        orderBy: query.orderBy ?? 'timestamp desc',
+        pageSize: Math.min(query.pageSize ?? 50, MAX_LOG_ENTRIES_PAGE_SIZE),
+        pageToken: query.pageToken,
+      },
+    });
+    const entries = (response.data.entries || []).map(toQueryEntry);
+    const nextPageToken = response.data.nextPageToken || undefined;
+    return nextPageToken ? { filter, entries, nextPageToken } : { filter, entries };
+  } catch (error: unknown) {
+    if (error instanceof Error) {
+      throw new Error(`Failed to query log entries: ${error.message}`);
+    }
+    throw new Error('An unknown error occurred while querying log entries.');
+  }
+}
+
 /**
  * Lists log names from the Google Cloud Logging API.
  * @param parent The parent resource whose logs are to be listed.
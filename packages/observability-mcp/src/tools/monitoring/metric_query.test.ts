@@ -0,0 +1,72 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { describe, it, expect } from 'vitest';
+import { alignmentPeriodFor, defaultAligner, downsample, typedValue } from './metric_query.js';
+
+describe('alignmentPeriodFor', () => {
+  it('should round up to whole minutes', () => {
+    expect(alignmentPeriodFor(60 * 60 * 1000, 60)).toBe('60s');
+    expect(alignmentPeriodFor(60 * 60 * 1000, 7)).toBe('540s');
+    expect(alignmentPeriodFor(60 * 1000, 100)).toBe('60s');
+  });
+});
+
+describe('defaultAligner', () => {
+  it('should pick an aligner that suits the metric', () => {
+    expect(defaultAligner('CUMULATIVE', 'INT64')).toBe('ALIGN_RATE');
+    expect(defaultAligner('GAUGE', 'DOUBLE')).toBe('ALIGN_MEAN');
+    expect(defaultAligner('DELTA', 'DISTRIBUTION')).toBe('ALIGN_PERCENTILE_99');
+    expect(defaultAligner('GAUGE', 'BOOL')).toBe('ALIGN_NEXT_OLDER');
+    expect(defaultAligner()).toBe('ALIGN_MEAN');
+  });
+});
+
+describe('typedValue', () => {
+  it('should convert typed values to plain values', () => {
+    expect(typedValue({ int64Value: '42' })).toBe(42);
+    expect(typedValue({ doubleValue: 0.5 })).toBe(0.5);
+    expect(typedValue({ boolValue: false })).toBe(false);
+    expect(typedValue({ stringValue: 'ok' })).toBe('ok');
+    expect(typedValue({ distributionValue: { mean: 12.5 } })).toBe(12.5);
+    expect(typedValue(undefined)).toBeNull();
+  });
+});
+
+describe('downsample', () => {
+  const points = (values: Array<number | string>) =>
+    values.map((value, i) => ({ time: `t${i}`, value }));
+
+  it('should leave short series unchanged', () => {
+    expect(downsample(points([1, 2]), 5)).toEqual(points([1, 2]));
+  });
+
+  it('should average runs of numeric points', () => {
+    expect(downsample(points([1, 3, 5, 7, 9, 11]), 3)).toEqual([
+      { time: 't1', value: 2 },
+      { time: 't3', value: 6 },
+      { time: 't5', value: 10 },
+    ]);
+  });
+
+  it('should keep the last value of runs that are not numeric', () => {
+    expect(downsample(points(['a', 'b', 'c', 'd']), 2)).toEqual([
+      { time: 't1', value: 'b' },
+      { time: 't3', value: 'd' },
+    ]);
+  });
+});
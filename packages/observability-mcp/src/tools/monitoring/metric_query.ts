@@ -0,0 +1,222 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { monitoring_v3 } from 'googleapis';
+
+export type PointValue = number | string | boolean | null;
+
+export interface MetricPoint {
+  /** The end of the interval the point covers. */
+  time: string;
+  value: PointValue;
+}
+
+export interface SeriesStats {
+  min: number | null;
+  max: number | null;
+  mean: number | null;
+  last: PointValue;
+}
+
+export interface MetricSeries {
+  metric: { type?: string; labels: Record<string, string> };
+  resource?: { type?: string; labels: Record<string, string> };
+  metricKind?: string;
+  valueType?: string;
+  unit?: string;
+  points: MetricPoint[];
+  /** The number of points before downsampling. */
+  pointCount: number;
+  stats: SeriesStats;
+}
+
+// Alignment periods are rounded up to whole minutes, the smallest the API accepts.
+const MIN_ALIGNMENT_SECONDS = 60;
+
+/**
+ * Returns the alignment period that yields at most `maxPoints` points per
+ * series over a time range, e.g. `300s`.
+ */
+export const alignmentPeriodFor = (rangeMs: number, maxPoints: number): string => {
+  const seconds = Math.ceil(rangeMs / 1000 / Math.max(1, maxPoints));
+  const minutes = Math.max(1, Math.ceil(seconds / MIN_ALIGNMENT_SECONDS));
+  return `${minutes * MIN_ALIGNMENT_SECONDS}s`;
+};
+
+/**
+ * Picks an aligner that works for the kind of a metric: counters are turned
+ * into rates, distributions into their 99th percentile and gauges averaged.
+ */
+export const defaultAligner = (metricKind?: string | null, valueType?: string | null): string => {
+  if (valueType === 'DISTRIBUTION') {
+    return 'ALIGN_PERCENTILE_99';
+  }
+  if (valueType === 'BOOL' || valueType === 'STRING') {
+    return 'ALIGN_NEXT_OLDER';
+  }
+  return metricKind === 'CUMULATIVE' ? 'ALIGN_RATE' : 'ALIGN_MEAN';
+};
+
+/** Converts a typed value of the Monitoring API to a plain value. */
+export const typedValue = (value?: monitoring_v3.Schema$TypedValue | null): PointValue => {
+  if (!value) {
+    return null;
+  }
+  if (value.doubleValue !== undefined && value.doubleValue !== null) {
+    return value.doubleValue;
+  }
+  if (value.int64Value !== undefined && value.int64Value !== null) {
+    return Number(value.int64Value);
+  }
+  if (value.boolValue !== undefined && value.boolValue !== null) {
+    return value.boolValue;
+  }
+  if (value.distributionValue) {
+    return value.distributionValue.mean ?? null;
+  }
+  return value.stringValue ?? null;
+};
+
+const isNumber = (value: PointValue): value is number => typeof value === 'number';
+
+/**
+ * Reduces the points of a series to at most `maxPoints` by averaging runs of
+ * consecutive points. Points that are not numbers keep the last value of the
+ * run. The points must be in chronological order.
+ */
+export const downsample = (points: MetricPoint[], maxPoints: number): MetricPoint[] => {
+  if (points.length <= maxPoints) {
+    return points;
+  }
+  const result: MetricPoint[] = [];
+  for (let i = 0; i < maxPoints; i++) {
+    const run = points.slice(
+      Math.floor((i * points.length) / maxPoints),
+      Math.floor(((i + 1) * points.length) / maxPoints),
+    );
+    const numbers = run.map((point) => point.value).filter(isNumber);
+    const last = run[run.length - 1]!;
+    result.push({
+      time: last.time,
+      value:
+        numbers.length === run.length
+          ? numbers.reduce((sum, v) => sum + v, 0) / numbers.length
+          : last.value,
+    });
+  }
+  return result;
+};
+
+const statsOf = (points: MetricPoint[]): SeriesStats => {
+  const numbers = points.map((point) => point.value).filter(isNumber);
+  return {
+    min: numbers.length ? Math.min(...numbers) : null,
+    max: numbers.length ? Math.max(...numbers) : null,
+    mean: numbers.length ? numbers.reduce((sum, v) => sum + v, 0) / numbers.length : null,
+    last: points.length ? points[points.length - 1]!.value : null,
+  };
+};
+
+/** Builds a series from chronological points, downsampled to `maxPoints`. */
+export const toSeries = (
+  base: Omit<MetricSeries, 'points' | 'pointCount' | 'stats'>,
+  points: MetricPoint[],
+  maxPoints: number,
+): MetricSeries => ({
+  ...base,
+  points: downsample(points, maxPoints),
+  pointCount: points.length,
+  // Computed over all points so that downsampling does not hide spikes.
+  stats: statsOf(points),
+});
+
+/** Converts a time series returned by `projects.timeSeries.list`. */
+export const fromTimeSeries = (
+  series: monitoring_v3.Schema$TimeSeries,
+  maxPoints: number,
+): MetricSeries => {
+  // The API returns the newest point first.
+  const points = (series.points ?? [])
+    .map((point) => ({
+      time: point.interval?.endTime ?? '',
+      value: typedValue(point.value),
+    }))
+    .reverse();
+  return toSeries(
+    {
+      metric: { type: series.metric?.type ?? undefined, labels: series.metric?.labels ?? {} },
+      resource: {
+        type: series.resource?.type ?? undefined,
+        labels: series.resource?.labels ?? {},
+      },
+      metricKind: series.metricKind ?? undefined,
+      valueType: series.valueType ?? undefined,
+      unit: series.unit || undefined,
+    },
+    points,
+    maxPoints,
+  );
+};
+
+/** Converts a time series returned by an MQL query. */
+export const fromTimeSeriesData = (
+  descriptor: monitoring_v3.Schema$TimeSeriesDescriptor,
+  data: monitoring_v3.Schema$TimeSeriesData,
+  maxPoints: number,
+): MetricSeries => {
+  const labels: Record<string, string> = {};
+  (descriptor.labelDescriptors ?? []).forEach((label, i) => {
+    const value = data.labelValues?.[i];
+    const text = value?.stringValue ?? value?.int64Value ?? value?.boolValue;
+    if (label.key && text !== undefined && text !== null) {
+      labels[label.key] = String(text);
+    }
+  });
+  const pointDescriptor = descriptor.pointDescriptors?.[0];
+  const points = (data.pointData ?? [])
+    .map((point) => ({
+      time: point.timeInterval?.endTime ?? '',
+      value: typedValue(point.values?.[0]),
+    }))
+    .sort((a, b) => a.time.localeCompare(b.time));
+  return toSeries(
+    {
+      metric: { labels },
+      metricKind: pointDescriptor?.metricKind ?? undefined,
+      valueType: pointDescriptor?.valueType ?? undefined,
+      unit: pointDescriptor?.unit || undefined,
+    },
+    points,
+    maxPoints,
+  );
+};
+
+/** A series of a Prometheus `matrix` result. */
+export interface PrometheusSeries {
+  metric?: Record<string, string>;
+  values?: Array<[number, string]>;
+}
+
+/** Converts a series returned by a PromQL range query. */
+export const fromPrometheusSeries = (series: PrometheusSeries, maxPoints: number): MetricSeries => {
+  const { __name__: name, ...labels } = series.metric ?? {};
+  const points = (series.values ?? []).map(([seconds, value]) => ({
+    time: new Date(seconds * 1000).toISOString(),
+    value: Number(value),
+  }));
+  return toSeries({ metric: { type: name, labels } }, points, maxPoints);
+};
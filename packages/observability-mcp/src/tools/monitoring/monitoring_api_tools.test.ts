@@ -14,7 +14,7 @@
  * limitations under the License.
  */
 
-import { describe, it, expect, vi, Mock } from 'vitest';
+import { describe, it, expect, vi, Mock, beforeEach } from 'vitest';
 import { GaxiosResponse } from 'gaxios';
 import { monitoring_v3 } from 'googleapis';
 import {
@@ -22,6 +22,7 @@ import {
   listTimeSeries,
   listAlertPolicies,
   listAlerts,
+  queryMetrics,
 } from './monitoring_api_tools.js';
 import { apiClientFactory } from '../../utils/api_client_factory.js';
 
@@ -34,9 +35,11 @@ vi.mock('../../utils/api_client_factory.js', () => {
     projects: {
       metricDescriptors: {
         list: vi.fn(),
+        get: vi.fn(),
       },
       timeSeries: {
         list: vi.fn(),
+        query: vi.fn(),
       },
       alertPolicies: {
         list: vi.fn(),
@@ -46,9 +49,23 @@ vi.mock('../../utils/api_client_factory.js', () => {
       },
     },
   };
+  const mockPrometheusClient = {
+    projects: {
+      location: {
+        prometheus: {
+          api: {
+            v1: {
+              query_range: vi.fn(),
+            },
+          },
+        },
+      },
+    },
+  };
   return {
     apiClientFactory: {
       getMonitoringClient: () => mockMonitoringClient,
+      getPrometheusClient: () => mockPrometheusClient,
     },
   };
 });
@@ -198,6 +215,198 @@ describe('listTimeSeries', () => {
   });
 });
 
+describe('queryMetrics', () => {
+  const now = new Date('2025-01-01T12:00:00Z');
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('should align by the metric kind and downsample the series', async () => {
+    const monitoringClient = apiClientFactory.getMonitoringClient();
+    (monitoringClient.projects.metricDescriptors.get as Mock).mockResolvedValue({
+      data: { metricKind: 'CUMULATIVE', valueType: 'INT64' },
+    });
+    // Newest point first, as returned by the API.
+    const points = [4, 3, 2, 1].map((value) => ({
+      interval: { endTime: `2025-01-01T11:5${value}:00Z` },
+      value: { int64Value: String(value) },
+    }));
+    (monitoringClient.projects.timeSeries.list as Mock).mockResolvedValue({
+      data: {
+        timeSeries: [
+          {
+            metric: { type: 'run.googleapis.com/request_count', labels: { code: '500' } },
+            resource: { type: 'cloud_run_revision', labels: { service_name: 'api' } },
+            metricKind: 'GAUGE',
+            valueType: 'DOUBLE',
+            points,
+          },
+        ],
+      },
+    });
+
+    const result = await queryMetrics(
+      {
+        projectId: TEST_PROJECT_ID,
+        metricType: 'run.googleapis.com/request_count',
+        resourceFilter: 'resource.labels.service_name="api"',
+        freshness: '1h',
+        maxPoints: 2,
+      },
+      now,
+    );
+
+    expect(monitoringClient.projects.timeSeries.list).toHaveBeenCalledWith({
+      name: TEST_PROJECT_RESOURCE,
+      filter:
+        'metric.type="run.googleapis.com/request_count" AND (resource.labels.service_name="api")',
+      'interval.startTime': '2025-01-01T11:00:00.000Z',
+      'interval.endTime': '2025-01-01T12:00:00.000Z',
+      'aggregation.alignmentPeriod': '1800s',
+      'aggregation.perSeriesAligner': 'ALIGN_RATE',
+      'aggregation.crossSeriesReducer': undefined,
+      'aggregation.groupByFields': undefined,
+      pageToken: undefined,
+    });
+    expect(result).toEqual({
+      series: [
+        {
+          metric: { type: 'run.googleapis.com/request_count', labels: { code: '500' } },
+          resource: { type: 'cloud_run_revision', labels: { service_name: 'api' } },
+          metricKind: 'GAUGE',
+          valueType: 'DOUBLE',
+          points: [
+            { time: '2025-01-01T11:52:00Z', value: 1.5 },
+            { time: '2025-01-01T11:54:00Z', value: 3.5 },
+          ],
+          pointCount: 4,
+          stats: { min: 1, max: 4, mean: 2.5, last: 4 },
+        },
+      ],
+      totalSeries: 1,
+      startTime: '2025-01-01T11:00:00.000Z',
+      endTime: '2025-01-01T12:00:00.000Z',
+      alignmentPeriod: '1800s',
+      perSeriesAligner: 'ALIGN_RATE',
+    });
+  });
+
+  it('should cap the number of series', async () => {
+    const monitoringClient = apiClientFactory.getMonitoringClient();
+    (monitoringClient.projects.timeSeries.list as Mock).mockResolvedValue({
+      data: { timeSeries: [{}, {}, {}], nextPageToken: 'page-2' },
+    });
+
+    const result = await queryMetrics(
+      {
+        projectId: TEST_PROJECT_ID,
+        metricType: 'custom.googleapis.com/queue_depth',
+        perSeriesAligner: 'ALIGN_MAX',
+        maxSeries: 2,
+      },
+      now,
+    );
+
+    expect(monitoringClient.projects.metricDescriptors.get).not.toHaveBeenCalled();
+    expect(result.series).toHaveLength(2);
+    expect(result.totalSeries).toBe(3);
+    expect(result.nextPageToken).toBe('page-2');
+  });
+
+  it('should run MQL queries', async () => {
+    const monitoringClient = apiClientFactory.getMonitoringClient();
+    (monitoringClient.projects.timeSeries.query as Mock).mockResolvedValue({
+      data: {
+        timeSeriesDescriptor: {
+          labelDescriptors: [{ key: 'resource.zone' }],
+          pointDescriptors: [{ key: 'value.utilization', valueType: 'DOUBLE' }],
+        },
+        timeSeriesData: [
+          {
+            labelValues: [{ stringValue: 'us-east1-b' }],
+            pointData: [
+              { values: [{ doubleValue: 0.5 }], timeInterval: { endTime: '2025-01-01T11:01:00Z' } },
+            ],
+          },
+        ],
+      },
+    });
+
+    const mql = 'fetch gce_instance | metric compute.googleapis.com/instance/cpu/utilization';
+    const result = await queryMetrics({ projectId: TEST_PROJECT_ID, mql }, now);
+
+    expect(monitoringClient.projects.timeSeries.query).toHaveBeenCalledWith({
+      name: TEST_PROJECT_RESOURCE,
+      requestBody: { query: mql, pageToken: undefined },
+    });
+    expect(result).toEqual({
+      series: [
+        {
+          metric: { labels: { 'resource.zone': 'us-east1-b' } },
+          valueType: 'DOUBLE',
+          points: [{ time: '2025-01-01T11:01:00Z', value: 0.5 }],
+          pointCount: 1,
+          stats: { min: 0.5, max: 0.5, mean: 0.5, last: 0.5 },
+        },
+      ],
+      totalSeries: 1,
+    });
+  });
+
+  it('should run PromQL range queries', async () => {
+    const prometheusClient = apiClientFactory.getPrometheusClient();
+    (prometheusClient.projects.location.prometheus.api.v1.query_range as Mock).mockResolvedValue({
+      data: {
+        status: 'success',
+        data: {
+          resultType: 'matrix',
+          result: [{ metric: { __name__: 'up', job: 'api' }, values: [[1735732800, '1']] }],
+        },
+      },
+    });
+
+    const result = await queryMetrics({ projectId: TEST_PROJECT_ID, promql: 'up' }, now);
+
+    expect(prometheusClient.projects.location.prometheus.api.v1.query_range).toHaveBeenCalledWith({
+      name: TEST_PROJECT_RESOURCE,
+      location: 'global',
+      requestBody: {
+        query: 'up',
+        start: '2025-01-01T11:00:00.000Z',
+        end: '2025-01-01T12:00:00.000Z',
+        step: '60s',
+      },
+    });
+    expect(result.series).toEqual([
+      {
+        metric: { type: 'up', labels: { job: 'api' } },
+        points: [{ time: '2025-01-01T12:00:00.000Z', value: 1 }],
+        pointCount: 1,
+        stats: { min: 1, max: 1, mean: 1, last: 1 },
+      },
+    ]);
+  });
+
+  it('should require exactly one of metricType, mql or promql', async () => {
+    await expect(queryMetrics({ projectId: TEST_PROJECT_ID })).rejects.toThrow(
+      'Exactly one of metricType, mql or promql must be set.',
+    );
+    await expect(
+      queryMetrics({ projectId: TEST_PROJECT_ID, metricType: 'a', promql: 'up' }),
+    ).rejects.toThrow('Exactly one of metricType, mql or promql must be set.');
+  });
+
+  it('should throw an error when the API call fails', async () => {
+    const monitoringClient = apiClientFactory.getMonitoringClient();
+    (monitoringClient.projects.timeSeries.query as Mock).mockRejectedValue(new Error('API Error'));
+
+    await expect(queryMetrics({ projectId: TEST_PROJECT_ID, mql: 'fetch x' })).rejects.toThrow(
+      'Failed to query metrics: API Error',
+    );
+  });
+});
+
 describe('listAlertPolicies', () => {
   it('should return a JSON string of alert policies on success', async () => {
     const mockResponse = createMockListAlertPoliciesResponse([{ name: 'test-policy' }]);
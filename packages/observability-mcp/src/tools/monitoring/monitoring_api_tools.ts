@@ -15,8 +15,24 @@
  */
 
 import { apiClientFactory } from '../../utils/api_client_factory.js';
+import { TimeRange, resolveStartTime } from '../../utils/timestamps.js';
+import {
+  MetricSeries,
+  PrometheusSeries,
+  alignmentPeriodFor,
+  defaultAligner,
+  fromPrometheusSeries,
+  fromTimeSeries,
+  fromTimeSeriesData,
+} from './metric_query.js';
 
 const monitoring = apiClientFactory.getMonitoringClient();
+const prometheus = apiClientFactory.getPrometheusClient();
+
+const DEFAULT_MAX_POINTS = 60;
+const DEFAULT_MAX_SERIES = 20;
+// The time range queried when neither a start time nor a freshness is given.
+const DEFAULT_RANGE_MS = 60 * 60 * 1000;
 
 /**
  * Lists metric descriptors from the Google Cloud Monitoring API.
@@ -104,6 +120,160 @@ export async function listTimeSeries(
   }
 }
 
+export interface MetricQuery extends TimeRange {
+  projectId: string;
+  /** The metric to query, e.g. `compute.googleapis.com/instance/cpu/utilization`. */
+  metricType?: string;
+  /** A monitoring filter on the resource and metric labels of the series. */
+  resourceFilter?: string;
+  /** The alignment period, e.g. `300s`. Derived from the range and maxPoints if not set. */
+  alignmentPeriod?: string;
+  perSeriesAligner?: string;
+  crossSeriesReducer?: string;
+  groupByFields?: string[];
+  /** A Monitoring Query Language query, used instead of metricType. */
+  mql?: string;
+  /** A PromQL query, used instead of metricType. */
+  promql?: string;
+  /** The maximum number of points returned per series. */
+  maxPoints?: number;
+  /** The maximum number of series returned. */
+  maxSeries?: number;
+  pageToken?: string;
+}
+
+export interface MetricQueryResult {
+  series: MetricSeries[];
+  /** The number of series that matched, including those beyond maxSeries. */
+  totalSeries: number;
+  startTime?: string;
+  endTime?: string;
+  alignmentPeriod?: string;
+  perSeriesAligner?: string;
+  nextPageToken?: string;
+}
+
+const withPageToken = (result: MetricQueryResult, nextPageToken?: string | null) =>
+  nextPageToken ? { ...result, nextPageToken } : result;
+
+const alignerFor = async (projectId: string, metricType: string): Promise<string> => {
+  try {
+    const response = await monitoring.projects.metricDescriptors.get({
+      name: `projects/${projectId}/metricDescriptors/${metricType}`,
+    });
+    return defaultAligner(response.data.metricKind, response.data.valueType);
+  } catch {
+    // The time series query reports a metric that does not exist more clearly.
+    return defaultAligner();
+  }
+};
+
+/**
+ * Queries time series from the Google Cloud Monitoring API by metric type, or
+ * with an MQL or PromQL query, and downsamples them so that each series has at
+ * most `maxPoints` points.
+ * @param query The metric or query, the time range and the result limits.
+ * @param now The time that the time range is measured from.
+ * @returns A promise that resolves with the downsampled series and summary
+ *     statistics of each series.
+ */
+export async function queryMetrics(
+  query: MetricQuery,
+  now: Date = new Date(),
+): Promise<MetricQueryResult> {
+  if ([query.metricType, query.mql, query.promql].filter(Boolean).length !== 1) {
+    throw new Error('Exactly one of metricType, mql or promql must be set.');
+  }
+  const maxPoints = query.maxPoints ?? DEFAULT_MAX_POINTS;
+  const maxSeries = query.maxSeries ?? DEFAULT_MAX_SERIES;
+  const endTime = query.endTime ?? now.toISOString();
+  const startTime =
+    resolveStartTime(query, now) ?? new Date(now.getTime() - DEFAULT_RANGE_MS).toISOString();
+  const alignmentPeriod =
+    query.alignmentPeriod ??
+    alignmentPeriodFor(Date.parse(endTime) - Date.parse(startTime), maxPoints);
+  const name = `projects/${query.projectId}`;
+
+  try {
+    if (query.mql) {
+      // MQL queries set their own time range and alignment.
+      const response = await monitoring.projects.timeSeries.query({
+        name,
+        requestBody: { query: query.mql, pageToken: query.pageToken },
+      });
+      const descriptor = response.data.timeSeriesDescriptor ?? {};
+      const data = response.data.timeSeriesData ?? [];
+      return withPageToken(
+        {
+          series: data
+            .slice(0, maxSeries)
+            .map((series) => fromTimeSeriesData(descriptor, series, maxPoints)),
+          totalSeries: data.length,
+        },
+        response.data.nextPageToken,
+      );
+    }
+
+    if (query.promql) {
+      const response = await prometheus.projects.location.prometheus.api.v1.query_range({
+        name,
+        location: 'global',
+        requestBody: {
+          query: query.promql,
+          start: startTime,
+          end: endTime,
+          step: alignmentPeriod,
+        },
+      });
+      // The response body is the JSON of the Prometheus HTTP API.
+      const body = response.data as { data?: { result?: PrometheusSeries[] } };
+      const result = body.data?.result ?? [];
+      return {
+        series: result.slice(0, maxSeries).map((series) => fromPrometheusSeries(series, maxPoints)),
+        totalSeries: result.length,
+        startTime,
+        endTime,
+        alignmentPeriod,
+      };
+    }
+
+    const metricType = query.metricType!;
+    const perSeriesAligner =
+      query.perSeriesAligner ?? (await alignerFor(query.projectId, metricType));
+    const filter = query.resourceFilter
+      ? `metric.type="${metricType}" AND (${query.resourceFilter})`
+      : `metric.type="${metricType}"`;
+    const response = await monitoring.projects.timeSeries.list({
+      name,
+      filter,
+      'interval.startTime': startTime,
+      'interval.endTime': endTime,
+      'aggregation.alignmentPeriod': alignmentPeriod,
+      'aggregation.perSeriesAligner': perSeriesAligner,
+      'aggregation.crossSeriesReducer': query.crossSeriesReducer,
+      'aggregation.groupByFields': query.groupByFields,
+      pageToken: query.pageToken,
+    });
+    const timeSeries = response.data.timeSeries ?? [];
+    return withPageToken(
+      {
+        series: timeSeries.slice(0, maxSeries).map((series) => fromTimeSeries(series, maxPoints)),
+        totalSeries: timeSeries.length,
+        startTime,
+        endTime,
+        alignmentPeriod,
+        perSeriesAligner,
+      },
+      response.data.nextPageToken,
+    );
+  } catch (error: unknown) {
+    if (error instanceof Error) {
+      throw new Error(`Failed to query metrics: ${error.message}`);
+    }
+    throw new Error('An unknown error occurred while querying metrics.');
+  }
+}
+
 /**
  * Lists alert policies from the Google Cloud Monitoring API.
  * @param name The project whose alert policies are to be listed.
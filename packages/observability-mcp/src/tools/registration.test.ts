@@ -24,6 +24,7 @@ import * as tools from './index.js';
 vi.mock('./index.js', () => ({
   listGroupStats: vi.fn(),
   listLogEntries: vi.fn(),
+  queryLogs: vi.fn(),
   listLogNames: vi.fn(),
   listBuckets: vi.fn(),
   listViews: vi.fn(),
@@ -31,6 +32,7 @@ vi.mock('./index.js', () => ({
   listLogScopes: vi.fn(),
   listMetricDescriptors: vi.fn(),
   listTimeSeries: vi.fn(),
+  queryMetrics: vi.fn(),
   listAlertPolicies: vi.fn(),
   listAlerts: vi.fn(),
   listTraces: vi.fn(),
@@ -40,6 +42,8 @@ vi.mock('./index.js', () => ({
 // Mock the toolWrapper
 vi.mock('../utils/index.js', () => ({
   toolWrapper: vi.fn((fn) => fn),
+  structuredToolWrapper: vi.fn((fn) => fn),
+  resolveStartTime: vi.fn(),
 }));
 
 describe('registerTools', () => {
@@ -64,10 +68,23 @@ describe('registerTools', () => {
       expect(listLogEntriesCall[0]).toBe('list_log_entries');
       expect(typeof listLogEntriesCall[1]).toBe('string'); // Description
       expect(listLogEntriesCall[2]).toBeTypeOf('object'); // Zod schema
-      expect(typeof listLogEntriesCall[3]).toBe('function'); // Handler
+      expect(listLogEntriesCall[3]).toEqual({ readOnlyHint: true }); // Annotations
+      expect(typeof listLogEntriesCall[4]).toBe('function'); // Handler
     }
   });
 
+  it('should annotate every tool as read-only', () => {
+    const mockServer = {
+      tool: vi.fn(),
+    } as unknown as McpServer;
+
+    registerTools(mockServer);
+
+    (mockServer.tool as Mock).mock.calls.forEach((call: unknown[]) => {
+      expect(call[3]).toEqual({ readOnlyHint: true });
+    });
+  });
+
   it('should create a valid Zod schema for each tool', () => {
     const mockServer = {
       tool: vi.fn(),
@@ -16,10 +16,13 @@
 
 import { z } from 'zod';
 import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
-import { toolWrapper } from '../utils/index.js';
+import { resolveStartTime, structuredToolWrapper, toolWrapper } from '../utils/index.js';
+import { LOG_SEVERITIES, LogSeverity } from './logging/log_filter.js';
+import { buildTraceFilter } from './trace/trace_filter.js';
 import {
   listGroupStats,
   listLogEntries,
+  queryLogs,
   listLogNames,
   listBuckets,
   listViews,
@@ -27,11 +30,13 @@ import {
   listLogScopes,
   listMetricDescriptors,
   listTimeSeries,
+  queryMetrics,
   listAlertPolicies,
   listAlerts,
   listTraces,
   getTrace,
 } from './index.js';
+import { READ_ONLY_TOOL } from './tool_annotations.js';
 
 export const registerTools = (server: McpServer): void => {
   server.tool(
@@ -92,13 +97,24 @@ export const registerTools = (server: McpServer): void => {
           page_token must be the value of next_page_token from the previous response.
           The values of other method parameters should be identical to those in the previous call.`,
         ),
+      autoPaginate: z
+        .boolean()
+        .optional()
+        .default(false)
+        .describe(
+          `Optional. If true, fetch as many pages as fit in the tool response size limit in a single call.
+          The first page uses pageSize; later pages are sized from the average size of the entries already returned.
+          The response is an object with the 'entries', and a 'nextPageToken' if more results are available.`,
+        ),
     },
+    READ_ONLY_TOOL,
     (params: {
       resourceNames: string[];
       filter?: string;
       orderBy?: 'timestamp asc' | 'timestamp desc';
       pageSize?: number;
       pageToken?: string;
+      autoPaginate?: boolean;
     }) =>
       toolWrapper(async () =>
         listLogEntries(
@@ -107,10 +123,96 @@ export const registerTools = (server: McpServer): void => {
           params.orderBy,
           params.pageSize,
           params.pageToken,
+          params.autoPaginate,
         ),
       ),
   );
 
+  server.tool(
+    'query_logs',
+    `Use this tool to search log entries in a Google Cloud project without writing a logging filter.
+    The filter is built from the given fields, which are combined with AND, and returned along with the entries.
+    Prefer this tool over list_log_entries unless you need filter syntax that these fields cannot express.`,
+    {
+      projectId: z.string().describe('Required. The Google Cloud project ID.'),
+      resourceType: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. The monitored resource type of the entries, e.g. 'cloud_run_revision', 'k8s_container', 'gce_instance' or 'cloud_function'.`,
+        ),
+      serviceName: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. The name of the Cloud Run service or job, Cloud Function, App Engine service or GKE container that wrote the entries.`,
+        ),
+      severity: z
+        .enum(LOG_SEVERITIES)
+        .optional()
+        .describe(
+          'Optional. The minimum severity of the entries, e.g. ERROR also returns CRITICAL.',
+        ),
+      startTime: z
+        .string()
+        .optional()
+        .describe('Optional. Only return entries at or after this time, in RFC 3339 format.'),
+      endTime: z
+        .string()
+        .optional()
+        .describe('Optional. Only return entries at or before this time, in RFC 3339 format.'),
+      freshness: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. Only return entries newer than this, e.g. '30m', '6h' or '2d'. Ignored if startTime is set.`,
+        ),
+      text: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. Text to search for in all fields of the entries. It is matched as a phrase, e.g. 'database connection failed'.`,
+        ),
+      filter: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. An additional Logging query language expression, e.g. 'labels.env="prod"'.`,
+        ),
+      orderBy: z
+        .enum(['timestamp asc', 'timestamp desc'])
+        .optional()
+        .default('timestamp desc')
+        .describe('Optional. How the results should be sorted. Defaults to newest first.'),
+      pageSize: z
+        .number()
+        .optional()
+        .default(50)
+        .describe('Optional. The maximum number of entries to return. At most 1000.'),
+      pageToken: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. The nextPageToken of a previous call with the same fields, to retrieve the next batch of results.`,
+        ),
+    },
+    READ_ONLY_TOOL,
+    (params: {
+      projectId: string;
+      resourceType?: string;
+      serviceName?: string;
+      severity?: LogSeverity;
+      startTime?: string;
+      endTime?: string;
+      freshness?: string;
+      text?: string;
+      filter?: string;
+      orderBy?: 'timestamp asc' | 'timestamp desc';
+      pageSize?: number;
+      pageToken?: string;
+    }) => structuredToolWrapper(async () => queryLogs(params)),
+  );
+
   server.tool(
     'list_log_names',
     `Use this as the primary tool to list the log names in a Google Cloud project.
@@ -139,6 +241,7 @@ export const registerTools = (server: McpServer): void => {
           The values of other method parameters should be identical to those in the previous call.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: { parent: string; pageSize?: number; pageToken?: string }) =>
       toolWrapper(async () => listLogNames(params.parent, params.pageSize, params.pageToken)),
   );
@@ -175,6 +278,7 @@ export const registerTools = (server: McpServer): void => {
           The values of other method parameters should be identical to those in the previous call.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: { parent: string; pageSize?: number; pageToken?: string }) =>
       toolWrapper(async () => listBuckets(params.parent, params.pageSize, params.pageToken)),
   );
@@ -208,6 +312,7 @@ export const registerTools = (server: McpServer): void => {
           The values of other method parameters should be identical to those in the previous call.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: { parent: string; pageSize?: number; pageToken?: string }) =>
       toolWrapper(async () => listViews(params.parent, params.pageSize, params.pageToken)),
   );
@@ -243,6 +348,7 @@ export const registerTools = (server: McpServer): void => {
           The values of other method parameters should be identical to those in the previous call.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: { parent: string; pageSize?: number; pageToken?: string }) =>
       toolWrapper(async () => listSinks(params.parent, params.pageSize, params.pageToken)),
   );
@@ -275,6 +381,7 @@ export const registerTools = (server: McpServer): void => {
           The values of other method parameters should be identical to those in the previous call.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: { parent: string; pageSize?: number; pageToken?: string }) =>
       toolWrapper(async () => listLogScopes(params.parent, params.pageSize, params.pageToken)),
   );
@@ -311,6 +418,7 @@ export const registerTools = (server: McpServer): void => {
           `Optional. If this field is not empty then it must contain the nextPageToken value returned by a previous call to this method.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: { name: string; filter?: string; pageSize?: number; pageToken?: string }) =>
       toolWrapper(async () =>
         listMetricDescriptors(params.name, params.filter, params.pageSize, params.pageToken),
@@ -407,6 +515,7 @@ export const registerTools = (server: McpServer): void => {
           `Optional. If this field is not empty then it must contain the nextPageToken value returned by a previous call to this method.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: {
       name: string;
       filter: string;
@@ -433,6 +542,109 @@ export const registerTools = (server: McpServer): void => {
       ),
   );
 
+  server.tool(
+    'query_metrics',
+    `Use this tool to get metric data that is ready to summarize, e.g. to answer how a metric behaved over the last hour.
+    Series are aligned and downsampled to at most maxPoints points, and each series includes its min, max, mean and last value over all of its points.
+    Query either by metricType, with an optional resourceFilter, or with a raw MQL or PromQL query.
+    Use list_metric_descriptors to find the metric type, and list_time_series when you need every raw data point.`,
+    {
+      projectId: z.string().describe('Required. The Google Cloud project ID.'),
+      metricType: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. The metric type to query, e.g. 'compute.googleapis.com/instance/cpu/utilization'. Exactly one of metricType, mql or promql must be set.`,
+        ),
+      resourceFilter: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. A monitoring filter on the resource and metric labels, e.g. 'resource.labels.zone="us-central1-a"'. Only used with metricType.`,
+        ),
+      mql: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. A Monitoring Query Language query. The query sets its own time range and alignment.`,
+        ),
+      promql: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. A PromQL query, evaluated as a range query over the time range with the alignment period as step.`,
+        ),
+      startTime: z
+        .string()
+        .optional()
+        .describe('Optional. The start of the time range, in RFC 3339 format.'),
+      endTime: z
+        .string()
+        .optional()
+        .describe('Optional. The end of the time range, in RFC 3339 format. Defaults to now.'),
+      freshness: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. How far back to query, e.g. '30m', '6h' or '2d'. Ignored if startTime is set. Defaults to 1h.`,
+        ),
+      alignmentPeriod: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. The alignment period in seconds, e.g. '300s'. By default it is chosen so that each series has at most maxPoints points.`,
+        ),
+      perSeriesAligner: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. The aligner, e.g. 'ALIGN_MEAN' or 'ALIGN_RATE'. By default, counters are aligned as rates, distributions to their 99th percentile and gauges to their mean.`,
+        ),
+      crossSeriesReducer: z
+        .string()
+        .optional()
+        .describe(`Optional. A reducer to combine series, e.g. 'REDUCE_SUM' or 'REDUCE_MEAN'.`),
+      groupByFields: z
+        .array(z.string())
+        .optional()
+        .describe(
+          `Optional. The labels to keep when combining series with crossSeriesReducer, e.g. ['resource.labels.zone'].`,
+        ),
+      maxPoints: z
+        .number()
+        .optional()
+        .default(60)
+        .describe('Optional. The maximum number of points returned per series.'),
+      maxSeries: z
+        .number()
+        .optional()
+        .default(20)
+        .describe('Optional. The maximum number of series returned.'),
+      pageToken: z
+        .string()
+        .optional()
+        .describe('Optional. The nextPageToken of a previous call with the same parameters.'),
+    },
+    READ_ONLY_TOOL,
+    (params: {
+      projectId: string;
+      metricType?: string;
+      resourceFilter?: string;
+      mql?: string;
+      promql?: string;
+      startTime?: string;
+      endTime?: string;
+      freshness?: string;
+      alignmentPeriod?: string;
+      perSeriesAligner?: string;
+      crossSeriesReducer?: string;
+      groupByFields?: string[];
+      maxPoints?: number;
+      maxSeries?: number;
+      pageToken?: string;
+    }) => structuredToolWrapper(async () => queryMetrics(params)),
+  );
+
   server.tool(
     'list_alert_policies',
     `Use this as the primary tool to list the alerting policies in a Google Cloud project.
@@ -478,6 +690,7 @@ export const registerTools = (server: McpServer): void => {
           `Optional. If this field is not empty then it must contain the nextPageToken value returned by a previous call to this method.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: {
       name: string;
       filter?: string;
@@ -540,6 +753,7 @@ export const registerTools = (server: McpServer): void => {
           The values of other method parameters should be identical to those in the previous call.`,
         ),
     },
+    READ_ONLY_TOOL,
     (params: {
       parent: string;
       filter?: string;
@@ -557,15 +771,30 @@ export const registerTools = (server: McpServer): void => {
     `Use this as the primary tool to retrieve and examine distributed traces from Google Cloud Trace.
     Traces provide a detailed view of the path of a request as it travels through your application's services.
     This is essential for understanding latency issues and debugging complex, multi-service workflows.
+    Use serviceName, minLatencyMs and freshness to find slow requests of a service without writing a filter.
     This will only return the root trace span, to gather full information call get_trace with that id.`,
     {
       projectId: z.string().describe('Required. The Google Cloud project ID.'),
+      serviceName: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. Only traces of this service, as set in the 'service.name' label by OpenTelemetry.`,
+        ),
+      rootSpanName: z
+        .string()
+        .optional()
+        .describe(`Optional. Only traces whose root span name starts with this, e.g. 'GET /api'.`),
+      minLatencyMs: z
+        .number()
+        .optional()
+        .describe('Optional. Only traces that took at least this many milliseconds.'),
       filter: z
         .string()
         .optional()
         .describe(
           `Optional.
-          The filter to apply when listing traces.
+          An additional filter to apply when listing traces, combined with the fields above.
           For more information on building trace filters see Cloud Trace Filters (https://cloud.google.com/trace/docs/trace-filters).
           Some examples:
             To filter for latency greater than or equal to a value, do latency:[DURATION] (e.g. 'latency:1s', 'latency:500ms')
@@ -605,24 +834,35 @@ export const registerTools = (server: McpServer): void => {
           `Optional. End of the time interval (inclusive) during which the trace data was collected from the application.
           Must be in RFC 3339 format.`,
         ),
+      freshness: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. Only traces newer than this, e.g. '30m', '6h' or '2d'. Ignored if startTime is set.`,
+        ),
     },
+    READ_ONLY_TOOL,
     (params: {
       projectId: string;
+      serviceName?: string;
+      rootSpanName?: string;
+      minLatencyMs?: number;
       filter?: string;
       orderBy?: string;
       pageSize?: number;
       pageToken?: string;
       startTime?: string;
       endTime?: string;
+      freshness?: string;
     }) =>
       toolWrapper(async () =>
         listTraces(
           params.projectId,
-          params.filter,
+          buildTraceFilter(params),
           params.orderBy,
           params.pageSize,
           params.pageToken,
-          params.startTime,
+          resolveStartTime(params),
           params.endTime,
         ),
       ),
@@ -633,13 +873,27 @@ export const registerTools = (server: McpServer): void => {
     `Use this as the primary tool to retrieve a single distributed trace from Google Cloud Trace.
     Traces provide a detailed view of the path of a request as it travels through your application's services.
     This is essential for understanding latency issues and debugging complex, multi-service workflows.
-    This is often used as a follow on to list_traces to get full details on a specific trace.`,
+    This is often used as a follow on to list_traces to get full details on a specific trace.
+    Each span includes its durationMs, its selfMs (the time not spent in child spans) and its depth, and the trace includes its total latencyMs.
+    The returned logsFilter selects the log entries of the trace in list_log_entries or query_logs.`,
     {
       projectId: z.string().describe('Required. The Google Cloud project ID.'),
-      traceId: z.string().describe('Required. The ID of the trace to retrieve.'),
+      traceId: z
+        .string()
+        .describe(
+          `Required. The ID of the trace to retrieve, or the trace field of a log entry, e.g. 'projects/[PROJECT_ID]/traces/[TRACE_ID]'.`,
+        ),
+      includeLogs: z
+        .boolean()
+        .optional()
+        .default(false)
+        .describe(
+          `Optional. If true, also return up to 50 log entries written while serving the trace, oldest first.`,
+        ),
     },
-    (params: { projectId: string; traceId: string }) =>
-      toolWrapper(async () => getTrace(params.projectId, params.traceId)),
+    READ_ONLY_TOOL,
+    (params: { projectId: string; traceId: string; includeLogs?: boolean }) =>
+      toolWrapper(async () => getTrace(params.projectId, params.traceId, params.includeLogs)),
   );
 
   server.tool(
@@ -681,6 +935,7 @@ export const registerTools = (server: McpServer): void => {
         .optional()
         .describe('Optional. A next_page_token provided by a previous response.'),
     },
+    READ_ONLY_TOOL,
     (params: {
       projectName: string;
       timeRangePeriod?: string;
@@ -19,6 +19,7 @@ import { GaxiosResponse } from 'gaxios';
 import { cloudtrace_v1 } from 'googleapis';
 import { listTraces, getTrace } from './trace_api_tools.js';
 import { apiClientFactory } from '../../utils/api_client_factory.js';
+import { queryLogs } from '../logging/index.js';
 
 const TEST_PROJECT_ID = 'my-project';
 
@@ -39,6 +40,10 @@ vi.mock('../../utils/api_client_factory.js', () => {
   };
 });
 
+vi.mock('../logging/index.js', () => ({
+  queryLogs: vi.fn(),
+}));
+
 const createMockListTracesResponse = (
   traces: cloudtrace_v1.Schema$Trace[],
 ): Partial<GaxiosResponse<cloudtrace_v1.Schema$ListTracesResponse>> => ({
@@ -117,6 +122,22 @@ describe('listTraces', () => {
 });
 
 describe('getTrace', () => {
+  const spans = [
+    {
+      spanId: '2',
+      parentSpanId: '1',
+      name: 'SELECT',
+      startTime: '2025-01-01T00:00:00.100Z',
+      endTime: '2025-01-01T00:00:00.400Z',
+    },
+    {
+      spanId: '1',
+      name: 'GET /api',
+      startTime: '2025-01-01T00:00:00.000Z',
+      endTime: '2025-01-01T00:00:00.500Z',
+    },
+  ];
+
   it('should return a JSON string of a trace on success', async () => {
     const mockResponse = {
       data: { traceId: 'test-trace' },
@@ -125,13 +146,78 @@ describe('getTrace', () => {
     (traceClient.projects.traces.get as Mock).mockResolvedValue(mockResponse);
 
     const result = await getTrace(TEST_PROJECT_ID, 'test-trace');
-    expect(JSON.parse(result)).toEqual(mockResponse.data);
+    expect(JSON.parse(result)).toEqual({
+      traceId: 'test-trace',
+      latencyMs: null,
+      spans: [],
+      logsFilter: 'trace="projects/my-project/traces/test-trace"',
+    });
     expect(traceClient.projects.traces.get).toHaveBeenCalledWith({
       projectId: TEST_PROJECT_ID,
       traceId: 'test-trace',
     });
   });
 
+  it('should accept the trace field of a log entry', async () => {
+    const traceClient = apiClientFactory.getTraceClient();
+    (traceClient.projects.traces.get as Mock).mockResolvedValue({ data: {} });
+
+    await getTrace(TEST_PROJECT_ID, 'projects/my-project/traces/abc123');
+    expect(traceClient.projects.traces.get).toHaveBeenCalledWith({
+      projectId: TEST_PROJECT_ID,
+      traceId: 'abc123',
+    });
+  });
+
+  it('should break down the latency of the spans', async () => {
+    const traceClient = apiClientFactory.getTraceClient();
+    (traceClient.projects.traces.get as Mock).mockResolvedValue({
+      data: { traceId: 'abc123', spans },
+    });
+
+    const result = JSON.parse(await getTrace(TEST_PROJECT_ID, 'abc123'));
+    expect(result.latencyMs).toBe(500);
+    expect(result.spans).toEqual([
+      { ...spans[1], durationMs: 500, selfMs: 200, depth: 0 },
+      { ...spans[0], durationMs: 300, selfMs: 300, depth: 1 },
+    ]);
+    expect(result.logs).toBeUndefined();
+  });
+
+  it('should include the log entries of the trace', async () => {
+    const traceClient = apiClientFactory.getTraceClient();
+    (traceClient.projects.traces.get as Mock).mockResolvedValue({
+      data: { traceId: 'abc123', spans },
+    });
+    vi.mocked(queryLogs).mockResolvedValue({
+      filter: '',
+      entries: [{ message: 'slow query' }],
+    });
+
+    const result = JSON.parse(await getTrace(TEST_PROJECT_ID, 'abc123', true));
+    expect(result.logs).toEqual([{ message: 'slow query' }]);
+    expect(queryLogs).toHaveBeenCalledWith({
+      projectId: TEST_PROJECT_ID,
+      filter: 'trace="projects/my-project/traces/abc123"',
+      startTime: '2024-12-31T23:59:00.000Z',
+      endTime: '2025-01-01T00:01:00.500Z',
+      orderBy: 'timestamp asc',
+      pageSize: 50,
+    });
+  });
+
+  it('should return the trace when its log entries cannot be read', async () => {
+    const traceClient = apiClientFactory.getTraceClient();
+    (traceClient.projects.traces.get as Mock).mockResolvedValue({
+      data: { traceId: 'abc123', spans },
+    });
+    vi.mocked(queryLogs).mockRejectedValue(new Error('Permission denied'));
+
+    const result = JSON.parse(await getTrace(TEST_PROJECT_ID, 'abc123', true));
+    expect(result.spans).toHaveLength(2);
+    expect(result.logsError).toBe('Permission denied');
+  });
+
   it('should throw an error if the API call fails', async () => {
     const errorMessage = 'API Error';
     const traceClient = apiClientFactory.getTraceClient();
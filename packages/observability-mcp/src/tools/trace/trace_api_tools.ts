@@ -14,7 +14,10 @@
  * limitations under the License.
  */
 
+import { cloudtrace_v1 } from 'googleapis';
 import { apiClientFactory } from '../../utils/api_client_factory.js';
+import { queryLogs } from '../logging/index.js';
+import { latencyBreakdown, normalizeTraceId, traceLogFilter } from './trace_filter.js';
 
 const trace = apiClientFactory.getTraceClient();
 
@@ -62,26 +65,73 @@ export async function listTraces(
   }
 }
 
+// The log entries of a trace are searched for slightly beyond its spans, since
+// clocks and log ingestion are not exact.
+const LOG_WINDOW_MARGIN_MS = 60 * 1000;
+const MAX_TRACE_LOG_ENTRIES = 50;
+
 /**
- * Retrieves a single trace from the Google Cloud Trace API.
+ * Retrieves a single trace from the Google Cloud Trace API, with the duration
+ * and self time of each span and, optionally, the log entries written while
+ * serving it.
  * @param projectId Required. The Google Cloud project ID.
- * @param traceId Required. The ID of the trace to retrieve.
+ * @param traceId Required. The ID of the trace to retrieve, or the trace field
+ *     of a log entry, e.g. `projects/my-project/traces/[TRACE_ID]`.
+ * @param includeLogs If true, also return the log entries of the trace.
  * @returns A promise that resolves with a string containing the trace in JSON
  *     format, or an error message.
  */
-export async function getTrace(projectId: string, traceId: string): Promise<string> {
+export async function getTrace(
+  projectId: string,
+  traceId: string,
+  includeLogs = false,
+): Promise<string> {
   const request = {
     projectId,
-    traceId,
+    traceId: normalizeTraceId(traceId),
   };
 
+  let data: cloudtrace_v1.Schema$Trace;
   try {
     const response = await trace.projects.traces.get(request);
-    return JSON.stringify(response.data || {}, null, 2);
+    data = response.data || {};
   } catch (error: unknown) {
     if (error instanceof Error) {
       throw new Error(`Failed to get trace: ${error.message}`);
     }
     throw new Error('An unknown error occurred while getting the trace.');
   }
+
+  const spans = latencyBreakdown(data.spans ?? []);
+  const roots = spans.filter((span) => span.depth === 0 && span.durationMs !== null);
+  const logsFilter = traceLogFilter(projectId, request.traceId);
+  const result: Record<string, unknown> = {
+    ...data,
+    latencyMs: roots.length ? Math.max(...roots.map((span) => span.durationMs!)) : null,
+    spans,
+    logsFilter,
+  };
+  if (includeLogs && spans.length) {
+    const starts = spans.map((span) => Date.parse(span.startTime ?? '')).filter((t) => !isNaN(t));
+    const ends = spans.map((span) => Date.parse(span.endTime ?? '')).filter((t) => !isNaN(t));
+    try {
+      const { entries } = await queryLogs({
+        projectId,
+        filter: logsFilter,
+        startTime: starts.length
+          ? new Date(Math.min(...starts) - LOG_WINDOW_MARGIN_MS).toISOString()
+          : undefined,
+        endTime: ends.length
+          ? new Date(Math.max(...ends) + LOG_WINDOW_MARGIN_MS).toISOString()
+          : undefined,
+        orderBy: 'timestamp asc',
+        pageSize: MAX_TRACE_LOG_ENTRIES,
+      });
+      result['logs'] = entries;
+    } catch (error: unknown) {
+      // The trace is still useful without its logs, e.g. without access to Logging.
+      result['logsError'] = error instanceof Error ? error.message : String(error);
+    }
+  }
+  return JSON.stringify(result, null, 2);
 }
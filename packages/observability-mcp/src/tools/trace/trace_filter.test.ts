@@ -0,0 +1,78 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { describe, it, expect } from 'vitest';
+import { buildTraceFilter, latencyBreakdown, normalizeTraceId } from './trace_filter.js';
+
+describe('buildTraceFilter', () => {
+  it('should return undefined when no fields are set', () => {
+    expect(buildTraceFilter({})).toBeUndefined();
+  });
+
+  it('should combine the fields into filter terms', () => {
+    expect(
+      buildTraceFilter({
+        serviceName: 'checkout',
+        rootSpanName: 'POST',
+        minLatencyMs: 750,
+        filter: 'http.status_code:500',
+      }),
+    ).toBe('+service.name:checkout root:POST latency:750ms http.status_code:500');
+  });
+});
+
+describe('normalizeTraceId', () => {
+  it('should strip the resource name prefix of a trace', () => {
+    expect(normalizeTraceId('projects/my-project/traces/abc123')).toBe('abc123');
+    expect(normalizeTraceId('abc123')).toBe('abc123');
+  });
+});
+
+describe('latencyBreakdown', () => {
+  it('should compute the self time and depth of nested spans', () => {
+    const spans = [
+      { spanId: '1', startTime: '2025-01-01T00:00:00Z', endTime: '2025-01-01T00:00:01Z' },
+      {
+        spanId: '2',
+        parentSpanId: '1',
+        startTime: '2025-01-01T00:00:00.100Z',
+        endTime: '2025-01-01T00:00:00.600Z',
+      },
+      {
+        spanId: '3',
+        parentSpanId: '2',
+        startTime: '2025-01-01T00:00:00.200Z',
+        endTime: '2025-01-01T00:00:00.300Z',
+      },
+      { spanId: '4', parentSpanId: '1' },
+    ];
+
+    const timings = latencyBreakdown(spans).map(({ spanId, durationMs, selfMs, depth }) => ({
+      spanId,
+      durationMs,
+      selfMs,
+      depth,
+    }));
+
+    expect(timings).toEqual([
+      { spanId: '4', durationMs: null, selfMs: null, depth: 1 },
+      { spanId: '1', durationMs: 1000, selfMs: 500, depth: 0 },
+      { spanId: '2', durationMs: 500, selfMs: 400, depth: 1 },
+      { spanId: '3', durationMs: 100, selfMs: 100, depth: 2 },
+    ]);
+  });
+});
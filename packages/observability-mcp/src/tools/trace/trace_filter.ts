@@ -0,0 +1,115 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { cloudtrace_v1 } from 'googleapis';
+
+/** The structured fields of a trace query, combined with AND. */
+export interface TraceQueryFields {
+  /** The service that handled the request, from the `service.name` label. */
+  serviceName?: string;
+  /** A prefix of the name of the root span, e.g. `GET /api`. */
+  rootSpanName?: string;
+  /** Only traces at least this slow, in milliseconds. */
+  minLatencyMs?: number;
+  /** A raw Cloud Trace filter for anything the other fields cannot express. */
+  filter?: string;
+}
+
+/** Builds a Cloud Trace filter, whose terms are separated by spaces. */
+export const buildTraceFilter = (fields: TraceQueryFields): string | undefined => {
+  const terms: string[] = [];
+  if (fields.serviceName) {
+    terms.push(`+service.name:${fields.serviceName}`);
+  }
+  if (fields.rootSpanName) {
+    terms.push(`root:${fields.rootSpanName}`);
+  }
+  if (fields.minLatencyMs !== undefined) {
+    terms.push(`latency:${fields.minLatencyMs}ms`);
+  }
+  if (fields.filter?.trim()) {
+    terms.push(fields.filter.trim());
+  }
+  return terms.length ? terms.join(' ') : undefined;
+};
+
+/**
+ * Returns the ID of a trace given either the ID itself or the trace field of a
+ * log entry, e.g. `projects/my-project/traces/0123456789abcdef`.
+ */
+export const normalizeTraceId = (trace: string): string => trace.trim().split('/').pop()!;
+
+/** The filter that selects the log entries written while serving a trace. */
+export const traceLogFilter = (projectId: string, traceId: string): string =>
+  `trace="projects/${projectId}/traces/${traceId}"`;
+
+export interface SpanTiming extends cloudtrace_v1.Schema$TraceSpan {
+  durationMs: number | null;
+  /** Time spent in the span itself rather than in its child spans. */
+  selfMs: number | null;
+  /** The number of ancestors of the span; root spans have depth 0. */
+  depth: number;
+}
+
+const durationOf = (span: cloudtrace_v1.Schema$TraceSpan): number | null => {
+  if (!span.startTime || !span.endTime) {
+    return null;
+  }
+  return Date.parse(span.endTime) - Date.parse(span.startTime);
+};
+
+/**
+ * Annotates the spans of a trace with their duration, self time and depth,
+ * ordered by start time.
+ */
+export const latencyBreakdown = (spans: cloudtrace_v1.Schema$TraceSpan[]): SpanTiming[] => {
+  const byId = new Map(spans.map((span) => [span.spanId, span]));
+  const childTime = new Map<string, number>();
+  for (const span of spans) {
+    if (span.parentSpanId && byId.has(span.parentSpanId)) {
+      const parent = span.parentSpanId;
+      childTime.set(parent, (childTime.get(parent) ?? 0) + (durationOf(span) ?? 0));
+    }
+  }
+  const depthOf = (span: cloudtrace_v1.Schema$TraceSpan): number => {
+    let depth = 0;
+    const seen = new Set<string>();
+    for (let parent = span.parentSpanId; parent && byId.has(parent) && !seen.has(parent); ) {
+      seen.add(parent);
+      depth++;
+      parent = byId.get(parent)!.parentSpanId;
+    }
+    return depth;
+  };
+  const startOf = (span: cloudtrace_v1.Schema$TraceSpan) =>
+    span.startTime ? Date.parse(span.startTime) : 0;
+  return [...spans]
+    .sort((a, b) => startOf(a) - startOf(b))
+    .map((span) => {
+      const durationMs = durationOf(span);
+      return {
+        ...span,
+        durationMs,
+        // Children of asynchronous work can outlast their parent.
+        selfMs:
+          durationMs === null
+            ? null
+            : Math.max(0, durationMs - (childTime.get(span.spanId ?? '') ?? 0)),
+        depth: depthOf(span),
+      };
+    });
+};
@@ -0,0 +1,88 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, it, expect, vi, beforeEach } from 'vitest';
+import { createLogEntryPoller, createTimeSeriesPoller } from './pollers.js';
+import { listLogEntries } from '../logging/index.js';
+import { listTimeSeries } from '../monitoring/index.js';
+
+vi.mock('../logging/index.js', () => ({
+  listLogEntries: vi.fn(),
+}));
+vi.mock('../monitoring/index.js', () => ({
+  listTimeSeries: vi.fn(),
+}));
+
+beforeEach(() => {
+  vi.clearAllMocks();
+});
+
+describe('createLogEntryPoller', () => {
+  it('should add a timestamp restriction to the filter', async () => {
+    vi.mocked(listLogEntries).mockResolvedValue('[]');
+    const poller = createLogEntryPoller(['projects/p1'], 'severity>=ERROR', '2025-01-01T00:00:00Z');
+
+    await poller();
+
+    expect(listLogEntries).toHaveBeenCalledWith(
+      ['projects/p1'],
+      '(severity>=ERROR) AND timestamp >= "2025-01-01T00:00:00Z"',
+      'timestamp asc',
+      1000,
+    );
+  });
+
+  it('should only return entries not seen in previous polls', async () => {
+    const first = { insertId: 'a', timestamp: '2025-01-01T00:00:05Z' };
+    const second = { insertId: 'b', timestamp: '2025-01-01T00:00:09Z' };
+    vi.mocked(listLogEntries)
+      .mockResolvedValueOnce(JSON.stringify([first]))
+      .mockResolvedValueOnce(JSON.stringify([first, second]));
+    const poller = createLogEntryPoller(['projects/p1'], undefined, '2025-01-01T00:00:00Z');
+
+    expect(await poller()).toEqual([first]);
+    expect(await poller()).toEqual([second]);
+    expect(listLogEntries).toHaveBeenLastCalledWith(
+      ['projects/p1'],
+      'timestamp >= "2025-01-01T00:00:05Z"',
+      'timestamp asc',
+      1000,
+    );
+  });
+});
+
+describe('createTimeSeriesPoller', () => {
+  const series = (value: number) => ({
+    metric: { type: 'run.googleapis.com/request_count' },
+    resource: { type: 'cloud_run_revision' },
+    points: [
+      { interval: { endTime: '2025-01-01T00:01:00Z' }, value: { int64Value: value } },
+      { interval: { endTime: '2025-01-01T00:00:00Z' }, value: { int64Value: 1 } },
+    ],
+  });
+
+  it('should return only series whose latest point changed', async () => {
+    vi.mocked(listTimeSeries)
+      .mockResolvedValueOnce(JSON.stringify([series(5)]))
+      .mockResolvedValueOnce(JSON.stringify([series(5)]))
+      .mockResolvedValueOnce(JSON.stringify([series(7)]));
+    const poller = createTimeSeriesPoller('projects/p1', 'metric.type="x"', 10);
+
+    expect(await poller()).toEqual([{ ...series(5), points: [series(5).points[0]] }]);
+    expect(await poller()).toEqual([]);
+    expect(await poller()).toEqual([{ ...series(7), points: [series(7).points[0]] }]);
+  });
+});
@@ -0,0 +1,125 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { listLogEntries } from '../logging/index.js';
+import { listTimeSeries } from '../monitoring/index.js';
+import { WatchPoller } from './watch_manager.js';
+
+interface LogEntry {
+  insertId?: string;
+  timestamp?: string;
+  [key: string]: unknown;
+}
+
+interface Point {
+  interval?: { endTime?: string };
+  value?: unknown;
+}
+
+interface TimeSeries {
+  metric?: unknown;
+  resource?: unknown;
+  points?: Point[];
+  [key: string]: unknown;
+}
+
+/**
+ * Creates a poller returning log entries written since the previous poll.
+ *
+ * Entries are requested from the newest timestamp already seen onwards, and
+ * entries sharing that timestamp are de-duplicated by insert ID.
+ */
+export const createLogEntryPoller = (
+  resourceNames: string[],
+  filter: string | undefined,
+  startTime: string,
+): WatchPoller => {
+  let cursor = startTime;
+  let seenAtCursor = new Set<string>();
+
+  return async () => {
+    const timeFilter = `timestamp >= "${cursor}"`;
+    const combinedFilter = filter ? `(${filter}) AND ${timeFilter}` : timeFilter;
+    const entries: LogEntry[] = JSON.parse(
+      await listLogEntries(resourceNames, combinedFilter, 'timestamp asc', 1000),
+    );
+
+    const fresh = entries.filter(
+      (entry) =>
+        !(entry.timestamp === cursor && entry.insertId && seenAtCursor.has(entry.insertId)),
+    );
+    for (const entry of fresh) {
+      if (!entry.timestamp) {
+        continue;
+      }
+      if (entry.timestamp > cursor) {
+        cursor = entry.timestamp;
+        seenAtCursor = new Set();
+      }
+      if (entry.timestamp === cursor && entry.insertId) {
+        seenAtCursor.add(entry.insertId);
+      }
+    }
+    return fresh;
+  };
+};
+
+const seriesKey = (series: TimeSeries) =>
+  JSON.stringify({ metric: series.metric, resource: series.resource });
+
+/**
+ * Creates a poller returning the time series whose latest point changed since
+ * the previous poll. Only the latest point of each changed series is returned.
+ */
+export const createTimeSeriesPoller = (
+  name: string,
+  filter: string,
+  windowMinutes: number,
+  aggregation?: { alignmentPeriod?: string; perSeriesAligner?: string },
+): WatchPoller => {
+  const latest = new Map<string, string>();
+
+  return async () => {
+    const now = Date.now();
+    const series: TimeSeries[] = JSON.parse(
+      await listTimeSeries(
+        name,
+        filter,
+        {
+          startTime: new Date(now - windowMinutes * 60 * 1000).toISOString(),
+          endTime: new Date(now).toISOString(),
+        },
+        aggregation,
+      ),
+    );
+
+    const changed: TimeSeries[] = [];
+    for (const s of series) {
+      // The Monitoring API returns points newest first.
+      const point = s.points?.[0];
+      if (!point) {
+        continue;
+      }
+      const key = seriesKey(s);
+      const fingerprint = JSON.stringify(point);
+      if (latest.get(key) !== fingerprint) {
+        latest.set(key, fingerprint);
+        changed.push({ ...s, points: [point] });
+      }
+    }
+    return changed;
+  };
+};
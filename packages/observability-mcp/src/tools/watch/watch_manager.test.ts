@@ -0,0 +1,97 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, it, expect, vi, beforeEach, afterEach } from 'vitest';
+import { createWatchManager, MIN_INTERVAL_SECONDS } from './watch_manager.js';
+
+describe('createWatchManager', () => {
+  beforeEach(() => {
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date('2025-01-01T00:00:00.000Z'));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  it('should notify only when the poller reports changes', async () => {
+    const notify = vi.fn().mockResolvedValue(undefined);
+    const poller = vi.fn().mockResolvedValueOnce([]).mockResolvedValueOnce([{ id: 1 }]);
+    const manager = createWatchManager(notify);
+
+    const info = manager.start('log_entries', 'test', poller, 30, 60);
+    await vi.advanceTimersByTimeAsync(30000);
+    expect(poller).toHaveBeenCalledTimes(1);
+    expect(notify).not.toHaveBeenCalled();
+
+    await vi.advanceTimersByTimeAsync(30000);
+    expect(notify).toHaveBeenCalledWith({
+      watchId: info.watchId,
+      kind: 'log_entries',
+      polledAt: '2025-01-01T00:01:00.000Z',
+      items: [{ id: 1 }],
+    });
+    expect(manager.list()[0]).toMatchObject({ polls: 2, notifications: 1 });
+    manager.stopAll();
+  });
+
+  it('should enforce the minimum interval', () => {
+    const manager = createWatchManager(vi.fn());
+
+    const info = manager.start('time_series', 'test', vi.fn().mockResolvedValue([]), 1, 60);
+
+    expect(info.intervalSeconds).toBe(MIN_INTERVAL_SECONDS);
+    manager.stopAll();
+  });
+
+  it('should stop polling once stopped', async () => {
+    const poller = vi.fn().mockResolvedValue([]);
+    const manager = createWatchManager(vi.fn());
+    const info = manager.start('log_entries', 'test', poller, 10, 60);
+
+    expect(manager.stop(info.watchId)).toBe(true);
+    await vi.advanceTimersByTimeAsync(60000);
+
+    expect(poller).not.toHaveBeenCalled();
+    expect(manager.list()).toEqual([]);
+    expect(manager.stop(info.watchId)).toBe(false);
+  });
+
+  it('should stop itself once expired', async () => {
+    const poller = vi.fn().mockResolvedValue([]);
+    const manager = createWatchManager(vi.fn());
+    manager.start('log_entries', 'test', poller, 30, 1);
+
+    await vi.advanceTimersByTimeAsync(120000);
+
+    expect(poller).toHaveBeenCalledTimes(1);
+    expect(manager.list()).toEqual([]);
+  });
+
+  it('should record poll errors and keep polling', async () => {
+    const poller = vi.fn().mockRejectedValueOnce(new Error('API Error')).mockResolvedValue([]);
+    const manager = createWatchManager(vi.fn());
+    manager.start('log_entries', 'test', poller, 10, 60);
+
+    await vi.advanceTimersByTimeAsync(10000);
+    expect(manager.list()[0]!.lastError).toBe('API Error');
+
+    await vi.advanceTimersByTimeAsync(10000);
+    expect(poller).toHaveBeenCalledTimes(2);
+    expect(manager.list()[0]!.lastError).toBeUndefined();
+    manager.stopAll();
+  });
+});
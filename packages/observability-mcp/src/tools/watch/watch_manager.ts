@@ -0,0 +1,135 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+export type WatchKind = 'log_entries' | 'time_series';
+
+export interface WatchInfo {
+  watchId: string;
+  kind: WatchKind;
+  description: string;
+  intervalSeconds: number;
+  startedAt: string;
+  expiresAt: string;
+  polls: number;
+  notifications: number;
+  lastError?: string;
+}
+
+export interface WatchDelta {
+  watchId: string;
+  kind: WatchKind;
+  polledAt: string;
+  items: unknown[];
+}
+
+/** Returns the items that changed since the previous call. */
+export type WatchPoller = () => Promise<unknown[]>;
+
+export const MIN_INTERVAL_SECONDS = 10;
+export const MAX_DURATION_MINUTES = 240;
+
+interface Watch {
+  info: WatchInfo;
+  timer?: NodeJS.Timeout;
+}
+
+/**
+ * Creates a manager for server-side poll loops.
+ *
+ * Each watch polls at its own interval and calls `notify` only when the
+ * poller reports changes, so clients receive deltas instead of re-issuing
+ * full queries. Watches stop themselves once they expire.
+ */
+export const createWatchManager = (notify: (delta: WatchDelta) => Promise<void>) => {
+  const watches = new Map<string, Watch>();
+  let nextId = 1;
+
+  const stop = (watchId: string): boolean => {
+    const watch = watches.get(watchId);
+    if (!watch) {
+      return false;
+    }
+    clearTimeout(watch.timer);
+    watches.delete(watchId);
+    return true;
+  };
+
+  const schedule = (watch: Watch, poller: WatchPoller) => {
+    watch.timer = setTimeout(async () => {
+      if (Date.now() >= Date.parse(watch.info.expiresAt)) {
+        stop(watch.info.watchId);
+        return;
+      }
+      try {
+        const items = await poller();
+        watch.info.polls++;
+        delete watch.info.lastError;
+        if (items.length > 0) {
+          watch.info.notifications++;
+          await notify({
+            watchId: watch.info.watchId,
+            kind: watch.info.kind,
+            polledAt: new Date().toISOString(),
+            items,
+          });
+        }
+      } catch (error: unknown) {
+        watch.info.lastError = error instanceof Error ? error.message : String(error);
+      }
+      if (watches.has(watch.info.watchId)) {
+        schedule(watch, poller);
+      }
+    }, watch.info.intervalSeconds * 1000);
+    watch.timer.unref?.();
+  };
+
+  return {
+    start: (
+      kind: WatchKind,
+      description: string,
+      poller: WatchPoller,
+      intervalSeconds: number,
+      durationMinutes: number,
+    ): WatchInfo => {
+      const now = Date.now();
+      const info: WatchInfo = {
+        watchId: `watch-${nextId++}`,
+        kind,
+        description,
+        intervalSeconds: Math.max(MIN_INTERVAL_SECONDS, intervalSeconds),
+        startedAt: new Date(now).toISOString(),
+        expiresAt: new Date(
+          now + Math.min(MAX_DURATION_MINUTES, durationMinutes) * 60 * 1000,
+        ).toISOString(),
+        polls: 0,
+        notifications: 0,
+      };
+      const watch: Watch = { info };
+      watches.set(info.watchId, watch);
+      schedule(watch, poller);
+      return { ...info };
+    },
+    stop,
+    list: (): WatchInfo[] => [...watches.values()].map((watch) => ({ ...watch.info })),
+    stopAll: () => {
+      for (const watchId of [...watches.keys()]) {
+        stop(watchId);
+      }
+    },
+  };
+};
+
+export type WatchManager = ReturnType<typeof createWatchManager>;
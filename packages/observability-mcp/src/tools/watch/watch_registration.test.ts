@@ -0,0 +1,101 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, it, expect, vi, Mock, beforeEach } from 'vitest';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { registerWatchTools, WATCH_LOGGER } from './watch_registration.js';
+import { createLogEntryPoller } from './pollers.js';
+
+vi.mock('./pollers.js', () => ({
+  createLogEntryPoller: vi.fn(() => vi.fn().mockResolvedValue([{ insertId: 'a' }])),
+  createTimeSeriesPoller: vi.fn(() => vi.fn().mockResolvedValue([])),
+}));
+
+const createMockServer = () =>
+  ({
+    tool: vi.fn(),
+    server: { sendLoggingMessage: vi.fn().mockResolvedValue(undefined) },
+  }) as unknown as McpServer;
+
+const getHandler = (server: McpServer, name: string) =>
+  (server.tool as Mock).mock.calls.find((call: unknown[]) => call[0] === name)![4];
+
+describe('registerWatchTools', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    vi.useFakeTimers();
+  });
+
+  it('should register the watch tools', () => {
+    const server = createMockServer();
+
+    registerWatchTools(server);
+
+    const names = (server.tool as Mock).mock.calls.map((call: unknown[]) => call[0]);
+    expect(names).toEqual(['watch_log_entries', 'watch_time_series', 'list_watches', 'stop_watch']);
+  });
+
+  it('should annotate the watch tools as changing only the session', () => {
+    const server = createMockServer();
+
+    registerWatchTools(server);
+
+    const annotations = Object.fromEntries(
+      (server.tool as Mock).mock.calls.map((call: unknown[]) => [call[0], call[3]]),
+    );
+    expect(annotations).toEqual({
+      watch_log_entries: { readOnlyHint: false, destructiveHint: false },
+      watch_time_series: { readOnlyHint: false, destructiveHint: false },
+      list_watches: { readOnlyHint: true },
+      stop_watch: { readOnlyHint: false, destructiveHint: false },
+    });
+  });
+
+  it('should push deltas as logging notifications', async () => {
+    const server = createMockServer();
+    const manager = registerWatchTools(server);
+
+    const result = await getHandler(server, 'watch_log_entries')({
+      resourceNames: ['projects/p1'],
+      filter: 'severity>=ERROR',
+      intervalSeconds: 10,
+      durationMinutes: 5,
+    });
+    const { watchId } = JSON.parse(result.content[0].text);
+    await vi.advanceTimersByTimeAsync(10000);
+
+    expect(createLogEntryPoller).toHaveBeenCalledWith(
+      ['projects/p1'],
+      'severity>=ERROR',
+      expect.any(String),
+    );
+    expect(server.server.sendLoggingMessage).toHaveBeenCalledWith({
+      level: 'info',
+      logger: WATCH_LOGGER,
+      data: expect.objectContaining({ watchId, items: [{ insertId: 'a' }] }),
+    });
+    manager.stopAll();
+  });
+
+  it('should return an error when stopping an unknown watch', async () => {
+    const server = createMockServer();
+    registerWatchTools(server);
+
+    const result = await getHandler(server, 'stop_watch')({ watchId: 'watch-404' });
+
+    expect(result.content[0].text).toContain('No active watch with ID watch-404.');
+  });
+});
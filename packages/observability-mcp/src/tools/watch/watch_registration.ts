@@ -0,0 +1,179 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { z } from 'zod';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { configuredTimeZone, normalizeTimestamps, toolWrapper } from '../../utils/index.js';
+import { createWatchManager, MAX_DURATION_MINUTES, MIN_INTERVAL_SECONDS } from './watch_manager.js';
+import { createLogEntryPoller, createTimeSeriesPoller } from './pollers.js';
+import { ADDITIVE_TOOL, READ_ONLY_TOOL } from '../tool_annotations.js';
+
+// Logger name used for watch notifications so clients can route them.
+export const WATCH_LOGGER = 'observability-watch';
+
+const intervalSecondsSchema = z
+  .number()
+  .optional()
+  .default(30)
+  .describe(
+    `Optional. How often the server polls, in seconds. Default is 30, minimum is ${MIN_INTERVAL_SECONDS}.
+    Notifications are only sent when a poll finds changes, so at most one notification is sent per interval.`,
+  );
+
+const durationMinutesSchema = z
+  .number()
+  .optional()
+  .default(60)
+  .describe(
+    `Optional. How long the watch runs before stopping itself, in minutes. Default is 60, maximum is ${MAX_DURATION_MINUTES}.`,
+  );
+
+/**
+ * Registers tools that start and stop server-side watches.
+ *
+ * Deltas found by a watch are pushed to the client as MCP logging message
+ * notifications from the `observability-watch` logger.
+ */
+export const registerWatchTools = (server: McpServer) => {
+  const manager = createWatchManager(async (delta) => {
+    await server.server.sendLoggingMessage({
+      level: 'info',
+      logger: WATCH_LOGGER,
+      data: normalizeTimestamps(delta, { timeZone: configuredTimeZone(), now: new Date() }),
+    });
+  });
+
+  server.tool(
+    'watch_log_entries',
+    `Use this tool to monitor Google Cloud Logging for new log entries, for example during an incident.
+    Instead of re-running list_log_entries every turn, the server polls in the background and pushes only newly written entries to the client as notifications.
+    Returns the watch ID, which can be passed to stop_watch.`,
+    {
+      resourceNames: z
+        .array(z.string())
+        .describe(
+          `Required. Names of one or more parent resources from which to watch log entries (e.g. 'projects/[PROJECT_ID]').`,
+        ),
+      filter: z
+        .string()
+        .optional()
+        .describe(
+          `Optional. A Logging query language filter selecting the entries to watch, e.g. 'severity>=ERROR'.
+          Do not add a timestamp restriction; the server adds one on every poll.`,
+        ),
+      intervalSeconds: intervalSecondsSchema,
+      durationMinutes: durationMinutesSchema,
+    },
+    ADDITIVE_TOOL,
+    (params: {
+      resourceNames: string[];
+      filter?: string;
+      intervalSeconds: number;
+      durationMinutes: number;
+    }) =>
+      toolWrapper(async () => {
+        const info = manager.start(
+          'log_entries',
+          `Log entries in ${params.resourceNames.join(', ')} matching: ${params.filter ?? '(all)'}`,
+          createLogEntryPoller(params.resourceNames, params.filter, new Date().toISOString()),
+          params.intervalSeconds,
+          params.durationMinutes,
+        );
+        return JSON.stringify(info, null, 2);
+      }),
+  );
+
+  server.tool(
+    'watch_time_series',
+    `Use this tool to monitor a Google Cloud Monitoring metric, for example to follow an error rate during an incident.
+    The server polls in the background and pushes the latest point of each time series whose value changed to the client as notifications.
+    Returns the watch ID, which can be passed to stop_watch.`,
+    {
+      name: z
+        .string()
+        .describe(`Required. The project to watch, in the form 'projects/[PROJECT_ID_OR_NUMBER]'.`),
+      filter: z
+        .string()
+        .describe(
+          `Required. A monitoring filter that specifies which time series should be watched, e.g. 'metric.type = "run.googleapis.com/request_count"'.`,
+        ),
+      windowMinutes: z
+        .number()
+        .optional()
+        .default(10)
+        .describe('Optional. How far back each poll looks for the latest points, in minutes.'),
+      aggregation: z
+        .object({
+          alignmentPeriod: z.string().optional(),
+          perSeriesAligner: z.string().optional(),
+        })
+        .optional()
+        .describe('Optional. The aggregation applied to the time series on every poll.'),
+      intervalSeconds: intervalSecondsSchema,
+      durationMinutes: durationMinutesSchema,
+    },
+    ADDITIVE_TOOL,
+    (params: {
+      name: string;
+      filter: string;
+      windowMinutes: number;
+      aggregation?: { alignmentPeriod?: string; perSeriesAligner?: string };
+      intervalSeconds: number;
+      durationMinutes: number;
+    }) =>
+      toolWrapper(async () => {
+        const info = manager.start(
+          'time_series',
+          `Time series in ${params.name} matching: ${params.filter}`,
+          createTimeSeriesPoller(
+            params.name,
+            params.filter,
+            params.windowMinutes,
+            params.aggregation,
+          ),
+          params.intervalSeconds,
+          params.durationMinutes,
+        );
+        return JSON.stringify(info, null, 2);
+      }),
+  );
+
+  server.tool(
+    'list_watches',
+    'Lists the active server-side watches, with their poll and notification counts.',
+    {},
+    READ_ONLY_TOOL,
+    () => toolWrapper(async () => JSON.stringify(manager.list(), null, 2)),
+  );
+
+  server.tool(
+    'stop_watch',
+    'Stops a server-side watch started with watch_log_entries or watch_time_series.',
+    {
+      watchId: z.string().describe('Required. The ID of the watch to stop.'),
+    },
+    ADDITIVE_TOOL,
+    (params: { watchId: string }) =>
+      toolWrapper(async () => {
+        if (!manager.stop(params.watchId)) {
+          throw new Error(`No active watch with ID ${params.watchId}.`);
+        }
+        return `Stopped watch ${params.watchId}.`;
+      }),
+  );
+
+  return manager;
+};
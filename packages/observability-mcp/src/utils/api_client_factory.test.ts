@@ -64,6 +64,14 @@ describe('ApiClientFactory', () => {
     expect(google.monitoring).toHaveBeenCalledTimes(1);
   });
 
+  it('should create and cache the prometheus client', () => {
+    const factory = ApiClientFactory.getInstance();
+    const client1 = factory.getPrometheusClient();
+    const client2 = factory.getPrometheusClient();
+    expect(client1).toBe(client2);
+    expect(google.monitoring).toHaveBeenCalledWith(expect.objectContaining({ version: 'v1' }));
+  });
+
   it('should create and cache the logging client', () => {
     const factory = ApiClientFactory.getInstance();
     const client1 = factory.getLoggingClient();
@@ -18,6 +18,7 @@ import {
   Auth,
   clouderrorreporting_v1beta1,
   logging_v2,
+  monitoring_v1,
   monitoring_v3,
   cloudtrace_v1,
   google,
@@ -28,6 +29,7 @@ export class ApiClientFactory {
   private static instance: ApiClientFactory;
   private readonly auth: Auth.GoogleAuth;
   private monitoringClient?: monitoring_v3.Monitoring;
+  private prometheusClient?: monitoring_v1.Monitoring;
   private loggingClient?: logging_v2.Logging;
   private errorReportingClient?: clouderrorreporting_v1beta1.Clouderrorreporting;
   private traceClient?: cloudtrace_v1.Cloudtrace;
@@ -55,6 +57,17 @@ export class ApiClientFactory {
     return this.monitoringClient;
   }
 
+  // The Prometheus query API of Managed Service for Prometheus is only in v1.
+  getPrometheusClient(): monitoring_v1.Monitoring {
+    if (!this.prometheusClient) {
+      this.prometheusClient = google.monitoring({
+        version: 'v1',
+        auth: this.auth,
+      });
+    }
+    return this.prometheusClient;
+  }
+
   getLoggingClient(): logging_v2.Logging {
     if (!this.loggingClient) {
       this.loggingClient = google.logging({
@@ -16,3 +16,5 @@
 
 export * from './api_client_factory.js';
 export * from './tool_wrapper.js';
+export * from './pagination.js';
+export * from './timestamps.js';
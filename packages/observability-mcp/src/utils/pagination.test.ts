@@ -0,0 +1,91 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, it, expect, vi } from 'vitest';
+import { nextPageSize, paginateWithinBudget } from './pagination.js';
+
+const item = (size: number) => ({ v: 'x'.repeat(size - '{"v":""}'.length) });
+
+describe('nextPageSize', () => {
+  it('should size the page to fill the remaining budget', () => {
+    expect(nextPageSize(100, 5000, 1000)).toBe(50);
+  });
+
+  it('should cap the page size at the API maximum', () => {
+    expect(nextPageSize(10, 1000000, 1000)).toBe(1000);
+  });
+
+  it('should request at least one item', () => {
+    expect(nextPageSize(1000, 10, 1000)).toBe(1);
+  });
+});
+
+describe('paginateWithinBudget', () => {
+  it('should return a single page when there is no next page token', async () => {
+    const fetchPage = vi.fn().mockResolvedValue({ items: [item(10), item(10)] });
+
+    const result = await paginateWithinBudget(fetchPage, {
+      initialPageSize: 50,
+      maxPageSize: 1000,
+    });
+
+    expect(fetchPage).toHaveBeenCalledOnce();
+    expect(fetchPage).toHaveBeenCalledWith(50, undefined);
+    expect(result).toEqual({ items: [item(10), item(10)], pages: 1 });
+  });
+
+  it('should grow the page size for small items', async () => {
+    const fetchPage = vi
+      .fn()
+      .mockResolvedValueOnce({ items: Array(10).fill(item(100)), nextPageToken: 'a' })
+      .mockResolvedValueOnce({ items: Array(40).fill(item(100)) });
+
+    const result = await paginateWithinBudget(fetchPage, {
+      initialPageSize: 10,
+      maxPageSize: 1000,
+      budget: 5000,
+    });
+
+    expect(fetchPage).toHaveBeenNthCalledWith(2, 40, 'a');
+    expect(result.items).toHaveLength(50);
+    expect(result.pages).toBe(2);
+    expect(result.nextPageToken).toBeUndefined();
+  });
+
+  it('should stop and return the next page token when the budget is spent', async () => {
+    const fetchPage = vi.fn().mockResolvedValue({
+      items: Array(10).fill(item(100)),
+      nextPageToken: 'more',
+    });
+
+    const result = await paginateWithinBudget(fetchPage, {
+      initialPageSize: 10,
+      maxPageSize: 1000,
+      budget: 1000,
+    });
+
+    expect(fetchPage).toHaveBeenCalledOnce();
+    expect(result).toEqual({ items: Array(10).fill(item(100)), nextPageToken: 'more', pages: 1 });
+  });
+
+  it('should start from the given page token', async () => {
+    const fetchPage = vi.fn().mockResolvedValue({ items: [] });
+
+    await paginateWithinBudget(fetchPage, { initialPageSize: 5, maxPageSize: 10 }, 'start');
+
+    expect(fetchPage).toHaveBeenCalledWith(5, 'start');
+  });
+});
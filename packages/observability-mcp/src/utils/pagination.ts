@@ -0,0 +1,92 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { MAX_CHAR_LIMIT } from './tool_wrapper.js';
+
+export interface Page<T> {
+  items: T[];
+  nextPageToken?: string | null | undefined;
+}
+
+export interface PaginationOptions {
+  /** Page size used for the first request, before any item sizes are known. */
+  initialPageSize: number;
+  /** Largest page size the API accepts. */
+  maxPageSize: number;
+  /** Serialized size budget for all collected items, in characters. */
+  budget?: number;
+}
+
+export interface PaginatedResult<T> {
+  items: T[];
+  nextPageToken?: string;
+  pages: number;
+}
+
+/**
+ * Computes the page size for the next request so the remaining response
+ * budget is filled in as few round trips as possible.
+ */
+export const nextPageSize = (
+  averageItemSize: number,
+  remainingBudget: number,
+  maxPageSize: number,
+): number => {
+  if (averageItemSize <= 0) {
+    return maxPageSize;
+  }
+  const fits = Math.floor(remainingBudget / averageItemSize);
+  return Math.max(1, Math.min(maxPageSize, fits));
+};
+
+/**
+ * Fetches pages until the response budget is exhausted or there are no more results.
+ *
+ * The average serialized item size observed so far is used to size each
+ * subsequent request, so small items are fetched in large pages and large
+ * items in small ones.
+ */
+export async function paginateWithinBudget<T>(
+  fetchPage: (pageSize: number, pageToken?: string) => Promise<Page<T>>,
+  options: PaginationOptions,
+  pageToken?: string,
+): Promise<PaginatedResult<T>> {
+  const budget = options.budget ?? MAX_CHAR_LIMIT;
+  const items: T[] = [];
+  let usedBudget = 0;
+  let pages = 0;
+  let pageSize = Math.min(options.initialPageSize, options.maxPageSize);
+  let token = pageToken;
+
+  for (;;) {
+    const page = await fetchPage(pageSize, token);
+    pages++;
+    for (const item of page.items) {
+      items.push(item);
+      usedBudget += JSON.stringify(item).length;
+    }
+    token = page.nextPageToken || undefined;
+
+    const averageItemSize = items.length > 0 ? usedBudget / items.length : 0;
+    const remainingBudget = budget - usedBudget;
+    if (!token || remainingBudget < averageItemSize || page.items.length === 0) {
+      break;
+    }
+    pageSize = nextPageSize(averageItemSize, remainingBudget, options.maxPageSize);
+  }
+
+  return token ? { items, nextPageToken: token, pages } : { items, pages };
+}
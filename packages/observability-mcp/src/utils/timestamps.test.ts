@@ -0,0 +1,149 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { describe, it, expect } from 'vitest';
+import {
+  configuredTimeZone,
+  formatRfc3339,
+  normalizeResultTimestamps,
+  normalizeTimestamps,
+  parseDuration,
+  relativeTime,
+  resolveStartTime,
+} from './timestamps.js';
+
+const now = new Date('2025-01-01T12:00:00.000Z');
+
+describe('configuredTimeZone', () => {
+  it('should default to UTC', () => {
+    expect(configuredTimeZone({})).toBe('UTC');
+  });
+
+  it('should use a valid configured time zone', () => {
+    expect(configuredTimeZone({ OBSERVABILITY_MCP_TIME_ZONE: 'Asia/Tokyo' })).toBe('Asia/Tokyo');
+  });
+
+  it('should fall back to UTC for invalid time zones', () => {
+    expect(configuredTimeZone({ OBSERVABILITY_MCP_TIME_ZONE: 'Mars/Olympus' })).toBe('UTC');
+  });
+});
+
+describe('formatRfc3339', () => {
+  it('should format UTC timestamps with Z', () => {
+    expect(formatRfc3339(now, 'UTC', '.000')).toBe('2025-01-01T12:00:00.000Z');
+  });
+
+  it('should format timestamps with the offset of the time zone', () => {
+    expect(formatRfc3339(now, 'Asia/Tokyo')).toBe('2025-01-01T21:00:00+09:00');
+    expect(formatRfc3339(now, 'America/New_York')).toBe('2025-01-01T07:00:00-05:00');
+  });
+});
+
+describe('relativeTime', () => {
+  it.each([
+    ['2025-01-01T12:00:00.000Z', 'just now'],
+    ['2025-01-01T11:59:30.000Z', '30s ago'],
+    ['2025-01-01T11:56:00.000Z', '4m ago'],
+    ['2025-01-01T09:00:00.000Z', '3h ago'],
+    ['2024-12-30T12:00:00.000Z', '2d ago'],
+    ['2025-01-01T12:05:00.000Z', 'in 5m'],
+  ])('should describe %s as %s', (timestamp, expected) => {
+    expect(relativeTime(new Date(timestamp), now)).toBe(expected);
+  });
+});
+
+describe('normalizeTimestamps', () => {
+  it('should convert timestamps and annotate their relative time', () => {
+    const value = {
+      entries: [
+        {
+          insertId: 'a',
+          timestamp: '2025-01-01T11:56:00.123456789Z',
+          receiveTimestamp: '2025-01-01T20:56:01+09:00',
+        },
+      ],
+    };
+
+    expect(normalizeTimestamps(value, { timeZone: 'UTC', now })).toEqual({
+      entries: [
+        {
+          insertId: 'a',
+          timestamp: '2025-01-01T11:56:00.123456789Z',
+          timestampRelative: '4m ago',
+          receiveTimestamp: '2025-01-01T11:56:01Z',
+          receiveTimestampRelative: '3m ago',
+        },
+      ],
+    });
+  });
+
+  it('should leave other strings unchanged', () => {
+    const value = { name: 'projects/p/logs/2025-01-01', date: '2025-01-01', count: 3 };
+    expect(normalizeTimestamps(value, { timeZone: 'UTC', now })).toEqual(value);
+  });
+
+  it('should convert timestamps in arrays without annotations', () => {
+    expect(normalizeTimestamps(['2025-01-01T12:00:00Z'], { timeZone: 'Asia/Tokyo', now })).toEqual([
+      '2025-01-01T21:00:00+09:00',
+    ]);
+  });
+});
+
+describe('normalizeResultTimestamps', () => {
+  it('should return non-JSON results unchanged', () => {
+    expect(normalizeResultTimestamps('2025-01-01T12:00:00Z')).toBe('2025-01-01T12:00:00Z');
+    expect(normalizeResultTimestamps('not json')).toBe('not json');
+  });
+
+  it('should normalize JSON results', () => {
+    const result = normalizeResultTimestamps(JSON.stringify({ endTime: '2025-01-01T12:00:00Z' }), {
+      timeZone: 'UTC',
+      now,
+    });
+    expect(JSON.parse(result)).toEqual({
+      endTime: '2025-01-01T12:00:00Z',
+      endTimeRelative: 'just now',
+    });
+  });
+});
+
+describe('parseDuration', () => {
+  it('should convert durations to milliseconds', () => {
+    expect(parseDuration('30s')).toBe(30 * 1000);
+    expect(parseDuration('2h')).toBe(2 * 60 * 60 * 1000);
+    expect(parseDuration('1d')).toBe(24 * 60 * 60 * 1000);
+  });
+
+  it('should reject invalid durations', () => {
+    expect(() => parseDuration('1 week')).toThrow('Invalid duration "1 week"');
+  });
+});
+
+describe('resolveStartTime', () => {
+  it('should prefer the start time over the freshness', () => {
+    expect(resolveStartTime({ startTime: '2025-01-01T00:00:00Z', freshness: '1h' }, now)).toBe(
+      '2025-01-01T00:00:00Z',
+    );
+  });
+
+  it('should compute the start time from the freshness', () => {
+    expect(resolveStartTime({ freshness: '1h' }, now)).toBe('2025-01-01T11:00:00.000Z');
+  });
+
+  it('should return undefined for an open range', () => {
+    expect(resolveStartTime({}, now)).toBeUndefined();
+  });
+});
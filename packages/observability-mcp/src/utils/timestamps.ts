@@ -0,0 +1,190 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Matches RFC 3339 timestamps, e.g. 2025-01-01T00:00:00.123456789Z.
+const RFC3339_PATTERN =
+  /^(\d{4}-\d{2}-\d{2})[Tt ](\d{2}:\d{2}:\d{2})(\.\d+)?([Zz]|[+-]\d{2}:?\d{2})$/;
+
+export const TIME_ZONE_ENV_VAR = 'OBSERVABILITY_MCP_TIME_ZONE';
+export const DEFAULT_TIME_ZONE = 'UTC';
+
+// Suffix of the key added next to each timestamp, e.g. `timestampRelative`.
+export const RELATIVE_SUFFIX = 'Relative';
+
+export interface TimestampOptions {
+  /** IANA time zone that timestamps are converted to. */
+  timeZone: string;
+  now: Date;
+}
+
+const isValidTimeZone = (timeZone: string): boolean => {
+  try {
+    new Intl.DateTimeFormat('en-US', { timeZone });
+    return true;
+  } catch {
+    return false;
+  }
+};
+
+/** Returns the configured time zone, falling back to UTC if it is not valid. */
+export const configuredTimeZone = (env: NodeJS.ProcessEnv = process.env): string => {
+  const timeZone = env[TIME_ZONE_ENV_VAR];
+  return timeZone && isValidTimeZone(timeZone) ? timeZone : DEFAULT_TIME_ZONE;
+};
+
+const offsetOf = (date: Date, timeZone: string): string => {
+  const offset = new Intl.DateTimeFormat('en-US', { timeZone, timeZoneName: 'longOffset' })
+    .formatToParts(date)
+    .find((part) => part.type === 'timeZoneName')?.value;
+  // Intl renders offsets as GMT+09:00, and UTC itself as GMT.
+  return !offset || offset === 'GMT' ? 'Z' : offset.replace('GMT', '');
+};
+
+/**
+ * Formats a date as an RFC 3339 timestamp in the given time zone.
+ *
+ * @param fraction Fractional seconds to keep, e.g. `.123456789`, since Date
+ *   only has millisecond precision.
+ */
+export const formatRfc3339 = (date: Date, timeZone: string, fraction: string = ''): string => {
+  const parts = Object.fromEntries(
+    new Intl.DateTimeFormat('en-US', {
+      timeZone,
+      year: 'numeric',
+      month: '2-digit',
+      day: '2-digit',
+      hour: '2-digit',
+      minute: '2-digit',
+      second: '2-digit',
+      hourCycle: 'h23',
+    })
+      .formatToParts(date)
+      .map((part) => [part.type, part.value]),
+  );
+  const { year, month, day, hour, minute, second } = parts;
+  const offset = offsetOf(date, timeZone);
+  return `${year}-${month}-${day}T${hour}:${minute}:${second}${fraction}${offset}`;
+};
+
+/** Describes the time between two dates, e.g. "4m ago" or "in 2h". */
+export const relativeTime = (date: Date, now: Date): string => {
+  const seconds = Math.round((now.getTime() - date.getTime()) / 1000);
+  const magnitude = Math.abs(seconds);
+  if (magnitude < 1) {
+    return 'just now';
+  }
+  const units: Array<[string, number]> = [
+    ['d', 86400],
+    ['h', 3600],
+    ['m', 60],
+    ['s', 1],
+  ];
+  const [unit, size] = units.find(([, size]) => magnitude >= size)!;
+  const amount = `${Math.floor(magnitude / size)}${unit}`;
+  return seconds > 0 ? `${amount} ago` : `in ${amount}`;
+};
+
+const normalizeTimestamp = (value: string, options: TimestampOptions): string | undefined => {
+  const match = RFC3339_PATTERN.exec(value);
+  const date = match ? new Date(value) : undefined;
+  if (!match || !date || isNaN(date.getTime())) {
+    return undefined;
+  }
+  return formatRfc3339(date, options.timeZone, match[3] ?? '');
+};
+
+/**
+ * Converts every RFC 3339 timestamp in a JSON value to the configured time
+ * zone, and annotates timestamps in objects with their relative time under a
+ * sibling key, e.g. `"timestampRelative": "4m ago"`.
+ */
+export const normalizeTimestamps = (value: unknown, options: TimestampOptions): unknown => {
+  if (typeof value === 'string') {
+    return normalizeTimestamp(value, options) ?? value;
+  }
+  if (Array.isArray(value)) {
+    return value.map((item) => normalizeTimestamps(item, options));
+  }
+  if (value === null || typeof value !== 'object') {
+    return value;
+  }
+  const result: Record<string, unknown> = {};
+  for (const [key, item] of Object.entries(value)) {
+    const normalized = typeof item === 'string' ? normalizeTimestamp(item, options) : undefined;
+    if (normalized === undefined) {
+      result[key] = normalizeTimestamps(item, options);
+      continue;
+    }
+    result[key] = normalized;
+    if (!(`${key}${RELATIVE_SUFFIX}` in value)) {
+      result[`${key}${RELATIVE_SUFFIX}`] = relativeTime(new Date(item as string), options.now);
+    }
+  }
+  return result;
+};
+
+/**
+ * Normalizes the timestamps of a JSON tool result. Results that are not JSON
+ * are returned unchanged.
+ */
+export const normalizeResultTimestamps = (
+  result: string,
+  options: TimestampOptions = { timeZone: configuredTimeZone(), now: new Date() },
+): string => {
+  let parsed: unknown;
+  try {
+    parsed = JSON.parse(result);
+  } catch {
+    return result;
+  }
+  if (parsed === null || typeof parsed !== 'object') {
+    return result;
+  }
+  return JSON.stringify(normalizeTimestamps(parsed, options), null, 2);
+};
+
+const DURATION_UNITS: Record<string, number> = {
+  s: 1000,
+  m: 60 * 1000,
+  h: 60 * 60 * 1000,
+  d: 24 * 60 * 60 * 1000,
+};
+
+/** Converts a duration such as `30m`, `6h` or `2d` to milliseconds. */
+export const parseDuration = (duration: string): number => {
+  const match = /^(\d+)([smhd])$/.exec(duration.trim());
+  if (!match) {
+    throw new Error(
+      `Invalid duration "${duration}": expected a number followed by s, m, h or d, e.g. "1h".`,
+    );
+  }
+  return Number(match[1]) * DURATION_UNITS[match[2]!]!;
+};
+
+/** A time range given either by its start and end, or by how far back it goes. */
+export interface TimeRange {
+  startTime?: string;
+  endTime?: string;
+  /** How far back to look when no start time is given, e.g. `30m` or `1d`. */
+  freshness?: string;
+}
+
+/** Returns the start of a time range as an RFC 3339 timestamp, if it has one. */
+export const resolveStartTime = (range: TimeRange, now: Date = new Date()): string | undefined =>
+  range.startTime ??
+  (range.freshness
+    ? new Date(now.getTime() - parseDuration(range.freshness)).toISOString()
+    : undefined);
@@ -15,7 +15,7 @@
  */
 
 import { describe, it, expect, assert } from 'vitest';
-import { toolWrapper, MAX_CHAR_LIMIT } from './tool_wrapper.js';
+import { toolWrapper, structuredToolWrapper, MAX_CHAR_LIMIT } from './tool_wrapper.js';
 
 describe('toolWrapper', () => {
   it('should return the result of the callback in a CallToolResult object', async () => {
@@ -98,3 +98,25 @@ describe('toolWrapper', () => {
     );
   });
 });
+
+describe('structuredToolWrapper', () => {
+  it('should return the object as text and as structured content', async () => {
+    const result = await structuredToolWrapper(async () => ({ entries: [{ id: 1 }] }));
+    expect(result.structuredContent).toEqual({ entries: [{ id: 1 }] });
+    expect(result.content).toEqual([
+      { type: 'text', text: JSON.stringify({ entries: [{ id: 1 }] }, null, 2) },
+    ]);
+  });
+
+  it('should not return structured content for errors', async () => {
+    const result = await structuredToolWrapper(async () => {
+      throw new Error('test error');
+    });
+    expect(result.structuredContent).toBeUndefined();
+    const content = result.content[0];
+    if (content?.type !== 'text') {
+      assert.fail('Result is of unexpected type');
+    }
+    expect(JSON.parse(content.text).error.message).toBe('test error');
+  });
+});
@@ -15,12 +15,14 @@
  */
 
 import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
+import { normalizeResultTimestamps } from './timestamps.js';
 
 export const MAX_CHAR_LIMIT = 100000;
 
 export async function toolWrapper(cb: () => Promise<string>): Promise<CallToolResult> {
   try {
-    let result = await cb();
+    // Mixed timestamp formats across APIs make event ordering easy to misread.
+    let result = normalizeResultTimestamps(await cb());
     // Enforce a tool response cap of 100,000 characters
     if (result.length > MAX_CHAR_LIMIT) {
       result =
@@ -76,3 +78,19 @@ export async function toolWrapper(cb: () => Promise<string>): Promise<CallToolRe
     };
   }
 }
+
+/**
+ * Like toolWrapper, but for callbacks that return an object, which is also
+ * returned as the structured content of the result.
+ */
+export async function structuredToolWrapper<T extends object>(
+  cb: () => Promise<T>,
+): Promise<CallToolResult> {
+  let structuredContent: Record<string, unknown> | undefined;
+  const result = await toolWrapper(async () => {
+    const text = normalizeResultTimestamps(JSON.stringify(await cb(), null, 2));
+    structuredContent = JSON.parse(text);
+    return text;
+  });
+  return structuredContent ? { ...result, structuredContent } : result;
+}
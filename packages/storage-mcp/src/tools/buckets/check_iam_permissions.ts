@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -87,6 +88,7 @@ export const registerCheckIamPermissionsTool = (server: McpServer) => {
     {
       description: 'Tests IAM permissions for a bucket.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     checkIamPermissions,
   );
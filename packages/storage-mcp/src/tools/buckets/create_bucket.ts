@@ -20,6 +20,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { logger } from '../../utility/logger.js';
 import { USER_AGENT } from '../../utility/user_agent.js';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().describe('The ID of the GCP project.'),
@@ -115,6 +116,7 @@ export const registerCreateBucketTool = (server: McpServer) => {
     {
       description: 'Creates a new bucket with specified configuration.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     createBucket,
   );
@@ -227,7 +227,7 @@ describe('registerDeleteBucketTool', () => {
 
     expect(mockServer.registerTool).toHaveBeenCalledWith(
       'delete_bucket',
-      expect.any(Object),
+      expect.objectContaining({ annotations: { readOnlyHint: false, destructiveHint: true } }),
       deleteBucket,
     );
   });
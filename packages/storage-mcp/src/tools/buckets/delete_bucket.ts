@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the bucket to delete.'),
@@ -113,6 +114,7 @@ export const registerDeleteBucketTool = (server: McpServer) => {
     {
       description: 'Deletes a bucket.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     deleteBucket,
   );
@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the bucket.'),
@@ -84,6 +85,7 @@ export const registerGetBucketLocationTool = (server: McpServer) => {
     {
       description: 'Gets the location and storage class of a bucket.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     getBucketLocation,
   );
@@ -42,6 +42,60 @@ describe('listBuckets', () => {
     expect(result.content).toEqual([{ type: 'text', text: 'bucket-1\nbucket-2' }]);
   });
 
+  it('should return the bucket details as structured content', async () => {
+    const mockBuckets = [
+      {
+        name: 'bucket-1',
+        metadata: { location: 'US', storageClass: 'STANDARD', timeCreated: '2025-01-01T00:00:00Z' },
+      },
+      { name: 'bucket-2', metadata: {} },
+    ];
+    const mockGetBuckets = vi.fn().mockResolvedValue([mockBuckets]);
+    (apiClientFactory.getStorageClient as vi.Mock).mockReturnValue({ getBuckets: mockGetBuckets });
+
+    const result = await listBuckets({ project_id: 'test-project' });
+
+    expect(result.content).toEqual([{ type: 'text', text: 'bucket-1\nbucket-2' }]);
+    expect(result.structuredContent).toEqual({
+      project: 'test-project',
+      bucket_count: 2,
+      buckets: [
+        {
+          name: 'bucket-1',
+          location: 'US',
+          storage_class: 'STANDARD',
+          time_created: '2025-01-01T00:00:00Z',
+        },
+        { name: 'bucket-2' },
+      ],
+      next_page_token: null,
+    });
+  });
+
+  it('should return one page if paging options are passed', async () => {
+    const mockGetBuckets = vi
+      .fn()
+      .mockResolvedValue([[{ name: 'logs-1', metadata: {} }], { pageToken: 'page-3' }]);
+    (apiClientFactory.getStorageClient as vi.Mock).mockReturnValue({ getBuckets: mockGetBuckets });
+
+    const result = await listBuckets({
+      project_id: 'test-project',
+      prefix: 'logs-',
+      max_results: 10,
+      page_token: 'page-2',
+    });
+
+    expect(mockGetBuckets).toHaveBeenCalledWith({
+      project: 'test-project',
+      autoPaginate: false,
+      prefix: 'logs-',
+      maxResults: 10,
+      pageToken: 'page-2',
+    });
+    expect(result.content).toEqual([{ type: 'text', text: 'logs-1\n\nnext_page_token: page-3' }]);
+    expect(result.structuredContent?.['next_page_token']).toBe('page-3');
+  });
+
   it('should return "No buckets found." if no buckets are returned', async () => {
     const mockGetBuckets = vi.fn().mockResolvedValue([[]]);
     const mockStorageClient = {
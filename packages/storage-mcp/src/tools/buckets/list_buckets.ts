@@ -14,13 +14,29 @@
  * limitations under the License.
  */
 
+import { GetBucketsRequest } from '@google-cloud/storage';
 import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
 import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   project_id: z.string().optional().describe('The project ID to list buckets for.'),
+  prefix: z
+    .string()
+    .optional()
+    .describe('Filters results to buckets whose names begin with this prefix.'),
+  max_results: z
+    .number()
+    .optional()
+    .describe('The maximum number of buckets to return in a single response.'),
+  page_token: z
+    .string()
+    .optional()
+    .describe(
+      'A token used to retrieve the next page of results. This is obtained from the `next_page_token` field of a previous `list_buckets` call.',
+    ),
 };
 
 type ListBucketsParams = z.infer<z.ZodObject<typeof inputSchema>>;
@@ -33,22 +49,60 @@ export async function listBuckets(params: ListBucketsParams): Promise<CallToolRe
       'Project ID not specified. Please specify via the project_id parameter or GOOGLE_CLOUD_PROJECT environment variable.',
     );
   }
-  const [buckets] = await storage.getBuckets({ project: projectId });
+  const options: GetBucketsRequest = { project: projectId };
+  if (params.prefix) {
+    options.prefix = params.prefix;
+  }
+  // Only read a single page if the caller pages through the buckets, so that a
+  // plain call still lists all of them.
+  if (params.max_results || params.page_token) {
+    options.autoPaginate = false;
+  }
+  if (params.max_results) {
+    options.maxResults = params.max_results;
+  }
+  if (params.page_token) {
+    options.pageToken = params.page_token;
+  }
+  const [buckets, nextQuery] = await storage.getBuckets(options);
 
   if (!buckets || buckets.length === 0) {
     return { content: [{ type: 'text', text: 'No buckets found.' }] };
   }
-  const bucketNames = buckets.map((bucket) => bucket.name).filter((name): name is string => !!name);
+  const bucketList = buckets
+    .filter((bucket) => !!bucket.name)
+    .map((bucket) => ({
+      name: bucket.name,
+      location: bucket.metadata?.location,
+      storage_class: bucket.metadata?.storageClass,
+      time_created: bucket.metadata?.timeCreated,
+    }));
+  const nextPageToken = (nextQuery as GetBucketsRequest | null | undefined)?.pageToken ?? null;
 
-  return { content: [{ type: 'text', text: bucketNames.join('\n') }] };
+  // The text stays one bucket name per line; the details are in the structured content.
+  const lines = bucketList.map((bucket) => bucket.name);
+  if (nextPageToken) {
+    lines.push('', `next_page_token: ${nextPageToken}`);
+  }
+  return {
+    content: [{ type: 'text', text: lines.join('\n') }],
+    structuredContent: {
+      project: projectId,
+      bucket_count: bucketList.length,
+      buckets: bucketList,
+      next_page_token: nextPageToken,
+    },
+  };
 }
 
 export const registerListBucketsTool = (server: McpServer) => {
   server.registerTool(
     'list_buckets',
     {
-      description: 'Lists all GCS buckets in the project.',
+      description:
+        'Lists the names of the GCS buckets in the project, one per line. The structured content also has their location, storage class and creation time. With `max_results` or `page_token`, returns one page; pass `next_page_token` as `page_token` to get the next one.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     listBuckets,
   );
@@ -20,6 +20,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the bucket.'),
@@ -88,6 +89,7 @@ export const registerUpdateBucketLabelsTool = (server: McpServer) => {
     {
       description: 'Updates labels for a bucket.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     updateBucketLabels,
   );
@@ -30,9 +30,13 @@ import {
   registerDeleteObjectTool,
   registerDownloadObjectTool,
   registerDownloadObjectSafeTool,
+  registerGenerateSignedUrlTool,
+  registerGenerateSignedUrlSafeTool,
+  registerGetObjectMetadataTool,
   registerListObjectsTool,
   registerMoveObjectTool,
   registerReadObjectContentTool,
+  registerReadObjectHeadTool,
   registerReadObjectMetadataTool,
   registerUpdateObjectMetadataTool,
   registerUploadObjectTool,
@@ -55,7 +59,9 @@ export const commonSafeTools = [
   registerCreateBucketTool,
   registerListObjectsTool,
   registerReadObjectContentTool,
+  registerReadObjectHeadTool,
   registerReadObjectMetadataTool,
+  registerGetObjectMetadataTool,
   registerDownloadObjectSafeTool,
   registerGetMetadataTableSchemaTool,
   registerExecuteInsightsQueryTool,
@@ -66,12 +72,14 @@ export const safeWriteTools = [
   registerWriteObjectSafeTool,
   registerUploadObjectSafeTool,
   registerCopyObjectSafeTool,
+  registerGenerateSignedUrlSafeTool,
 ];
 
 export const destructiveWriteTools = [
   registerWriteObjectTool,
   registerUploadObjectTool,
   registerCopyObjectTool,
+  registerGenerateSignedUrlTool,
 ];
 
 export const otherDestructiveTools = [
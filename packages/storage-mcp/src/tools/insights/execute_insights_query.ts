@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   config: z
@@ -167,6 +168,8 @@ export const registerExecuteInsightsQueryTool = (server: McpServer) => {
       description:
         'Executes a BigQuery SQL query against an insights dataset and returns the result.',
       inputSchema,
+      // Insights datasets are linked datasets, which BigQuery only allows to be read.
+      annotations: READ_ONLY_TOOL,
     },
     executeInsightsQuery,
   );
@@ -21,6 +21,7 @@ import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
 import { TableField } from '@google-cloud/bigquery';
 import { protos } from '@google-cloud/service-usage';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 type ServiceResource = protos.google.api.serviceusage.v1.IService;
 
@@ -296,6 +297,7 @@ export const registerGetMetadataTableSchemaTool = (server: McpServer) => {
       description:
         'Checks if GCS insights service is enabled and returns the BigQuery table schema for a given insights dataset configuration in JSON format. Also returns hints for each column in the table',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     getMetadataTableSchema,
   );
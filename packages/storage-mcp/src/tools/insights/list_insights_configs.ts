@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
 import { protos } from '@google-cloud/service-usage';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 type ServiceResource = protos.google.api.serviceusage.v1.IService;
 
@@ -106,6 +107,7 @@ export const registerListInsightsConfigsTool = (server: McpServer) => {
       description:
         'Lists the names of all Storage Insights dataset configurations for a given project.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     listInsightsConfigs,
   );
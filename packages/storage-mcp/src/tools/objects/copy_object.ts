@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   source_bucket_name: z.string().describe('The name of the source GCS bucket.'),
@@ -86,6 +87,7 @@ export const registerCopyObjectTool = (server: McpServer) => {
     {
       description: 'Copies an object from one bucket to another or within the same bucket.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     copyObject,
   );
@@ -20,6 +20,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   source_bucket_name: z.string().describe('The name of the source GCS bucket.'),
@@ -90,6 +91,7 @@ export const registerCopyObjectSafeTool = (server: McpServer) => {
       description:
         'Copies an object to a new destination. Fails if an object already exists at the destination.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     copyObjectSafe,
   );
@@ -132,7 +132,7 @@ describe('registerDeleteObjectTool', () => {
 
     expect(mockServer.registerTool).toHaveBeenCalledWith(
       'delete_object',
-      expect.any(Object),
+      expect.objectContaining({ annotations: { readOnlyHint: false, destructiveHint: true } }),
       deleteObject,
     );
   });
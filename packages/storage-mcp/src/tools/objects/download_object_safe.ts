@@ -20,6 +20,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -88,6 +89,7 @@ export const registerDownloadObjectSafeTool = (server: McpServer) => {
       description:
         'Downloads an object from GCS to a local file. Fails if the destination file already exists.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     downloadObjectSafe,
   );
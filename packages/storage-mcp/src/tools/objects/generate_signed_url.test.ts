@@ -0,0 +1,161 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+/// <reference types="vitest/globals" />
+import { describe, it, expect, vi, Mock } from 'vitest';
+import { generateSignedUrl, registerGenerateSignedUrlTool } from './generate_signed_url.js';
+import { apiClientFactory } from '../../utility/index.js';
+import { logger } from '../../utility/logger.js';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+
+vi.mock('../../utility/index.js');
+vi.mock('../../utility/logger.js');
+vi.mock('@modelcontextprotocol/sdk/server/mcp.js');
+
+const mockSigning = (getSignedUrl: Mock) => {
+  const file = vi.fn().mockReturnValue({ getSignedUrl });
+  const bucket = vi.fn().mockReturnValue({ file });
+  (apiClientFactory.getStorageClient as Mock).mockReturnValue({ bucket });
+  return { bucket, file };
+};
+
+const parse = (result: Awaited<ReturnType<typeof generateSignedUrl>>) =>
+  JSON.parse(result.content[0]!.text as string);
+
+describe('generateSignedUrl', () => {
+  beforeEach(() => {
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date('2025-01-01T00:00:00Z'));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+    vi.clearAllMocks();
+  });
+
+  it('should sign a download URL that expires after the default TTL', async () => {
+    const getSignedUrl = vi.fn().mockResolvedValue(['https://signed.example/get']);
+    const { bucket, file } = mockSigning(getSignedUrl);
+
+    const result = await generateSignedUrl({
+      bucket_name: 'test-bucket',
+      object_name: 'report.csv',
+      method: 'GET',
+    });
+
+    expect(bucket).toHaveBeenCalledWith('test-bucket');
+    expect(file).toHaveBeenCalledWith('report.csv');
+    expect(getSignedUrl).toHaveBeenCalledWith({
+      version: 'v4',
+      action: 'read',
+      expires: new Date('2025-01-01T00:15:00Z'),
+    });
+    expect(parse(result)).toEqual({
+      url: 'https://signed.example/get',
+      method: 'GET',
+      bucket: 'test-bucket',
+      object: 'report.csv',
+      expires_at: '2025-01-01T00:15:00.000Z',
+      expires_in_seconds: 900,
+      required_headers: {},
+    });
+  });
+
+  it('should sign the content type into an upload URL', async () => {
+    const getSignedUrl = vi.fn().mockResolvedValue(['https://signed.example/put']);
+    mockSigning(getSignedUrl);
+
+    const result = await generateSignedUrl({
+      bucket_name: 'test-bucket',
+      object_name: 'upload.json',
+      method: 'PUT',
+      expires_in_seconds: 60,
+      content_type: 'application/json',
+    });
+
+    expect(getSignedUrl).toHaveBeenCalledWith({
+      version: 'v4',
+      action: 'write',
+      expires: new Date('2025-01-01T00:01:00Z'),
+      contentType: 'application/json',
+    });
+    expect(parse(result).required_headers).toEqual({ 'Content-Type': 'application/json' });
+  });
+
+  it('should record a sensitive audit event without the URL', async () => {
+    mockSigning(vi.fn().mockResolvedValue(['https://signed.example/delete']));
+
+    await generateSignedUrl({
+      bucket_name: 'test-bucket',
+      object_name: 'old.txt',
+      method: 'DELETE',
+    });
+
+    expect(logger.audit).toHaveBeenCalledWith(
+      'generate_signed_url',
+      {
+        method: 'DELETE',
+        bucket: 'test-bucket',
+        object: 'old.txt',
+        expires_at: '2025-01-01T00:15:00.000Z',
+      },
+      true,
+    );
+    expect(JSON.stringify(vi.mocked(logger.audit).mock.calls)).not.toContain('signed.example');
+  });
+
+  it('should return a "SigningNotSupported" error without a signing identity', async () => {
+    mockSigning(vi.fn().mockRejectedValue(new Error('Cannot sign data without `client_email`.')));
+
+    const result = await generateSignedUrl({
+      bucket_name: 'test-bucket',
+      object_name: 'report.csv',
+      method: 'GET',
+    });
+
+    expect(parse(result).error_type).toBe('SigningNotSupported');
+    expect(logger.audit).not.toHaveBeenCalled();
+  });
+
+  it('should return a "NotFound" error if the bucket does not exist', async () => {
+    mockSigning(vi.fn().mockRejectedValue({ message: 'Not Found', code: 404 }));
+
+    const result = await generateSignedUrl({
+      bucket_name: 'missing-bucket',
+      object_name: 'report.csv',
+      method: 'GET',
+    });
+
+    expect(parse(result)).toEqual({
+      error: 'Error generating signed URL: Not Found',
+      error_type: 'NotFound',
+    });
+  });
+});
+
+describe('registerGenerateSignedUrlTool', () => {
+  it('should register the generate_signed_url tool with the server', () => {
+    const mockServer = new McpServer();
+    registerGenerateSignedUrlTool(mockServer);
+
+    expect(mockServer.registerTool).toHaveBeenCalledWith(
+      'generate_signed_url',
+      expect.objectContaining({ annotations: { readOnlyHint: false, destructiveHint: true } }),
+      generateSignedUrl,
+    );
+  });
+});
@@ -0,0 +1,185 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { ApiError, GetSignedUrlConfig } from '@google-cloud/storage';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
+import { z } from 'zod';
+import { apiClientFactory } from '../../utility/index.js';
+import { logger } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
+
+// V4 signed URLs are valid for at most seven days.
+const MAX_EXPIRES_IN_SECONDS = 7 * 24 * 60 * 60;
+const DEFAULT_EXPIRES_IN_SECONDS = 15 * 60;
+
+const ACTIONS = {
+  GET: 'read',
+  PUT: 'write',
+  DELETE: 'delete',
+} as const;
+
+export type SignedUrlMethod = keyof typeof ACTIONS;
+
+export const signedUrlInputSchema = {
+  bucket_name: z.string().describe('The name of the GCS bucket.'),
+  object_name: z.string().describe('The name of the object the URL gives access to.'),
+  expires_in_seconds: z
+    .number()
+    .int()
+    .positive()
+    .max(MAX_EXPIRES_IN_SECONDS)
+    .optional()
+    .describe(
+      `How long the URL stays valid, in seconds. Defaults to ${DEFAULT_EXPIRES_IN_SECONDS} and may be at most ${MAX_EXPIRES_IN_SECONDS} (seven days).`,
+    ),
+  content_type: z
+    .string()
+    .optional()
+    .describe('For PUT, the Content-Type the upload must be sent with.'),
+};
+
+const inputSchema = {
+  ...signedUrlInputSchema,
+  method: z
+    .enum(['GET', 'PUT', 'DELETE'])
+    .describe('GET to download the object, PUT to upload it, DELETE to delete it.'),
+};
+
+export interface SignedUrlParams {
+  bucket_name: string;
+  object_name: string;
+  method: SignedUrlMethod;
+  expires_in_seconds?: number | undefined;
+  content_type?: string | undefined;
+}
+
+function errorResult(error: string, errorType: string): CallToolResult {
+  return {
+    content: [
+      {
+        type: 'text',
+        text: JSON.stringify({ error, error_type: errorType }),
+      },
+    ],
+  };
+}
+
+/**
+ * Signs a V4 URL for a single object. The URL is signed with the credentials
+ * of the server, which never leave it. `extensionHeaders` are signed into the
+ * URL and must be sent as-is by whoever uses it.
+ */
+export async function signUrl(
+  toolName: string,
+  params: SignedUrlParams,
+  extensionHeaders: Record<string, string> = {},
+): Promise<CallToolResult> {
+  const expiresInSeconds = params.expires_in_seconds ?? DEFAULT_EXPIRES_IN_SECONDS;
+  try {
+    logger.info(
+      `Generating signed ${params.method} URL for object: ${params.object_name} in bucket: ${params.bucket_name}`,
+    );
+    const expiresAt = new Date(Date.now() + expiresInSeconds * 1000);
+    const config: GetSignedUrlConfig = {
+      version: 'v4',
+      action: ACTIONS[params.method],
+      expires: expiresAt,
+    };
+    if (params.content_type && params.method === 'PUT') {
+      config.contentType = params.content_type;
+    }
+    if (Object.keys(extensionHeaders).length > 0) {
+      config.extensionHeaders = extensionHeaders;
+    }
+
+    const storage = apiClientFactory.getStorageClient();
+    const [url] = await storage
+      .bucket(params.bucket_name)
+      .file(params.object_name)
+      .getSignedUrl(config);
+
+    const requiredHeaders: Record<string, string> = { ...extensionHeaders };
+    if (config.contentType) {
+      requiredHeaders['Content-Type'] = config.contentType;
+    }
+
+    // The URL is a bearer credential, so only the fact that one was handed
+    // out is recorded.
+    logger.audit(
+      toolName,
+      {
+        method: params.method,
+        bucket: params.bucket_name,
+        object: params.object_name,
+        expires_at: expiresAt.toISOString(),
+      },
+      true,
+    );
+
+    const result = {
+      url,
+      method: params.method,
+      bucket: params.bucket_name,
+      object: params.object_name,
+      expires_at: expiresAt.toISOString(),
+      expires_in_seconds: expiresInSeconds,
+      required_headers: requiredHeaders,
+    };
+    return {
+      content: [{ type: 'text', text: JSON.stringify(result, null, 2) }],
+    };
+  } catch (e: unknown) {
+    const error = e as ApiError;
+    const errorMsg = `Error generating signed URL: ${error.message}`;
+    logger.error(errorMsg);
+    // Signing needs a private key or the iam.serviceAccounts.signBlob
+    // permission, which user credentials from gcloud do not have.
+    if (/sign|client_email/i.test(error.message ?? '')) {
+      return errorResult(
+        `${errorMsg}. Signed URLs require service account credentials, or a service account to impersonate with the Service Account Token Creator role.`,
+        'SigningNotSupported',
+      );
+    }
+    let errorType = 'Unknown';
+    if (error.code === 404) {
+      errorType = 'NotFound';
+    } else if (error.code === 403) {
+      errorType = 'Forbidden';
+    } else if (error.code === 400) {
+      errorType = 'BadRequest';
+    }
+    return errorResult(errorMsg, errorType);
+  }
+}
+
+export async function generateSignedUrl(params: SignedUrlParams): Promise<CallToolResult> {
+  return signUrl('generate_signed_url', params);
+}
+
+export const registerGenerateSignedUrlTool = (server: McpServer) => {
+  server.registerTool(
+    'generate_signed_url',
+    {
+      description:
+        'Generates a time-limited signed URL to download (GET), upload (PUT) or delete (DELETE) an object without sharing credentials. Anyone holding the URL can use it until it expires, so only hand it to the intended user.',
+      inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
+    },
+    generateSignedUrl,
+  );
+};
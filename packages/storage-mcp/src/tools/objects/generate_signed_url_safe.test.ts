@@ -0,0 +1,89 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+/// <reference types="vitest/globals" />
+import { describe, it, expect, vi, Mock } from 'vitest';
+import {
+  generateSignedUrlSafe,
+  registerGenerateSignedUrlSafeTool,
+} from './generate_signed_url_safe.js';
+import { apiClientFactory } from '../../utility/index.js';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+
+vi.mock('../../utility/index.js');
+vi.mock('../../utility/logger.js');
+vi.mock('@modelcontextprotocol/sdk/server/mcp.js');
+
+const mockSigning = () => {
+  const getSignedUrl = vi.fn().mockResolvedValue(['https://signed.example/url']);
+  const file = vi.fn().mockReturnValue({ getSignedUrl });
+  (apiClientFactory.getStorageClient as Mock).mockReturnValue({
+    bucket: vi.fn().mockReturnValue({ file }),
+  });
+  return getSignedUrl;
+};
+
+describe('generateSignedUrlSafe', () => {
+  afterEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('should only allow uploads that create a new object', async () => {
+    const getSignedUrl = mockSigning();
+
+    const result = await generateSignedUrlSafe({
+      bucket_name: 'test-bucket',
+      object_name: 'new.txt',
+      method: 'PUT',
+    });
+
+    expect(getSignedUrl).toHaveBeenCalledWith(
+      expect.objectContaining({
+        action: 'write',
+        extensionHeaders: { 'x-goog-if-generation-match': '0' },
+      }),
+    );
+    expect(JSON.parse(result.content[0]!.text as string).required_headers).toEqual({
+      'x-goog-if-generation-match': '0',
+    });
+  });
+
+  it('should sign downloads without a precondition', async () => {
+    const getSignedUrl = mockSigning();
+
+    await generateSignedUrlSafe({
+      bucket_name: 'test-bucket',
+      object_name: 'report.csv',
+      method: 'GET',
+    });
+
+    expect(getSignedUrl.mock.calls[0]![0]).not.toHaveProperty('extensionHeaders');
+  });
+});
+
+describe('registerGenerateSignedUrlSafeTool', () => {
+  it('should register the generate_signed_url tool with the server', () => {
+    const mockServer = new McpServer();
+    registerGenerateSignedUrlSafeTool(mockServer);
+
+    expect(mockServer.registerTool).toHaveBeenCalledWith(
+      'generate_signed_url',
+      expect.objectContaining({ annotations: { readOnlyHint: false, destructiveHint: false } }),
+      generateSignedUrlSafe,
+    );
+  });
+});
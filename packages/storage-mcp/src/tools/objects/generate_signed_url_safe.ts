@@ -0,0 +1,54 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
+import { z } from 'zod';
+import { signUrl, signedUrlInputSchema } from './generate_signed_url.js';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
+
+const inputSchema = {
+  ...signedUrlInputSchema,
+  method: z
+    .enum(['GET', 'PUT'])
+    .describe('GET to download the object, PUT to upload it as a new object.'),
+};
+
+type GenerateSignedUrlSafeParams = z.infer<z.ZodObject<typeof inputSchema>>;
+
+export async function generateSignedUrlSafe(
+  params: GenerateSignedUrlSafeParams,
+): Promise<CallToolResult> {
+  // Like write_object_safe, uploads only create new objects: the precondition
+  // is signed into the URL, so an existing object cannot be overwritten.
+  const extensionHeaders: Record<string, string> =
+    params.method === 'PUT' ? { 'x-goog-if-generation-match': '0' } : {};
+  return signUrl('generate_signed_url', params, extensionHeaders);
+}
+
+export const registerGenerateSignedUrlSafeTool = (server: McpServer) => {
+  server.registerTool(
+    'generate_signed_url',
+    {
+      description:
+        'Generates a time-limited signed URL to download (GET) an object or upload (PUT) a new object without sharing credentials. Uploads fail if the object already exists. Anyone holding the URL can use it until it expires, so only hand it to the intended user.',
+      inputSchema,
+      annotations: ADDITIVE_TOOL,
+    },
+    generateSignedUrlSafe,
+  );
+};
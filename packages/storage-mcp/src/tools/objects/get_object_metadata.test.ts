@@ -0,0 +1,114 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+/// <reference types="vitest/globals" />
+import { describe, it, expect, vi, Mock } from 'vitest';
+import { getObjectMetadata, registerGetObjectMetadataTool } from './get_object_metadata.js';
+import { apiClientFactory } from '../../utility/index.js';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+
+vi.mock('../../utility/index.js');
+vi.mock('../../utility/logger.js');
+vi.mock('@modelcontextprotocol/sdk/server/mcp.js');
+
+const mockFile = (getMetadata: Mock) => {
+  const file = vi.fn().mockReturnValue({ getMetadata });
+  (apiClientFactory.getStorageClient as Mock).mockReturnValue({
+    bucket: vi.fn().mockReturnValue({ file }),
+  });
+  return file;
+};
+
+describe('getObjectMetadata', () => {
+  it('should return the full metadata of an object', async () => {
+    mockFile(
+      vi.fn().mockResolvedValue([
+        {
+          bucket: 'test-bucket',
+          name: 'test-object',
+          size: '42',
+          contentType: 'text/plain',
+          storageClass: 'STANDARD',
+          generation: 1700000000000000,
+          metageneration: '1',
+          md5Hash: 'md5',
+          crc32c: 'crc',
+          cacheControl: 'no-cache',
+          metadata: { owner: 'team-a' },
+        },
+      ]),
+    );
+
+    const result = await getObjectMetadata({
+      bucket_name: 'test-bucket',
+      object_name: 'test-object',
+    });
+
+    expect(JSON.parse(result.content[0]!.text as string)).toEqual({
+      bucket: 'test-bucket',
+      object: 'test-object',
+      size: 42,
+      content_type: 'text/plain',
+      storage_class: 'STANDARD',
+      metadata: { owner: 'team-a' },
+      generation: '1700000000000000',
+      metageneration: '1',
+      md5_hash: 'md5',
+      crc32c: 'crc',
+      cache_control: 'no-cache',
+    });
+  });
+
+  it('should describe a specific generation', async () => {
+    const file = mockFile(vi.fn().mockResolvedValue([{ name: 'test-object' }]));
+
+    await getObjectMetadata({
+      bucket_name: 'test-bucket',
+      object_name: 'test-object',
+      generation: '1700000000000000',
+    });
+
+    expect(file).toHaveBeenCalledWith('test-object', { generation: 1700000000000000 });
+  });
+
+  it('should return a "Not Found" error if the object does not exist', async () => {
+    mockFile(vi.fn().mockRejectedValue(new Error('No such object: test-bucket/test-object')));
+
+    const result = await getObjectMetadata({
+      bucket_name: 'test-bucket',
+      object_name: 'test-object',
+    });
+
+    expect(JSON.parse(result.content[0]!.text as string)).toEqual({
+      error: 'Error getting object metadata: No such object: test-bucket/test-object',
+      error_type: 'NotFound',
+    });
+  });
+});
+
+describe('registerGetObjectMetadataTool', () => {
+  it('should register the get_object_metadata tool with the server', () => {
+    const mockServer = new McpServer();
+    registerGetObjectMetadataTool(mockServer);
+
+    expect(mockServer.registerTool).toHaveBeenCalledWith(
+      'get_object_metadata',
+      expect.any(Object),
+      getObjectMetadata,
+    );
+  });
+});
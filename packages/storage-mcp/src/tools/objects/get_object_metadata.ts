@@ -0,0 +1,91 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
+import { z } from 'zod';
+import { apiClientFactory } from '../../utility/index.js';
+import { formatDetailedFileMetadataResponse } from '../../utility/gcs_helpers.js';
+import { logger } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
+
+const inputSchema = {
+  bucket_name: z.string().describe('The name of the GCS bucket.'),
+  object_name: z.string().describe('The name of the object.'),
+  generation: z
+    .string()
+    .optional()
+    .describe('The generation of the object to describe. Defaults to the live version.'),
+};
+
+type GetObjectMetadataParams = z.infer<z.ZodObject<typeof inputSchema>>;
+
+export async function getObjectMetadata(params: GetObjectMetadataParams): Promise<CallToolResult> {
+  try {
+    logger.info(
+      `Getting metadata for object: ${params.object_name} in bucket: ${params.bucket_name}, generation: ${params.generation}`,
+    );
+    const storage = apiClientFactory.getStorageClient();
+    const file = params.generation
+      ? storage.bucket(params.bucket_name).file(params.object_name, {
+          generation: Number(params.generation),
+        })
+      : storage.bucket(params.bucket_name).file(params.object_name);
+    const [metadata] = await file.getMetadata();
+
+    logger.info(`Successfully retrieved metadata for object ${params.object_name}`);
+    return {
+      content: [
+        {
+          type: 'text',
+          text: JSON.stringify(formatDetailedFileMetadataResponse(metadata), null, 2),
+        },
+      ],
+    };
+  } catch (e: unknown) {
+    const error = e as Error;
+    let errorType = 'Unknown';
+    if (error.message.includes('Not Found') || error.message.includes('No such object')) {
+      errorType = 'NotFound';
+    } else if (error.message.includes('Forbidden')) {
+      errorType = 'Forbidden';
+    }
+    const errorMsg = `Error getting object metadata: ${error.message}`;
+    logger.error(errorMsg);
+    return {
+      content: [
+        {
+          type: 'text',
+          text: JSON.stringify({ error: errorMsg, error_type: errorType }),
+        },
+      ],
+    };
+  }
+}
+
+export const registerGetObjectMetadataTool = (server: McpServer) => {
+  server.registerTool(
+    'get_object_metadata',
+    {
+      description:
+        'Gets the full metadata of an object, or of one of its generations: size, content type, storage class, checksums, generation, encoding, cache control, custom metadata, encryption key and holds.',
+      inputSchema,
+      annotations: READ_ONLY_TOOL,
+    },
+    getObjectMetadata,
+  );
+};
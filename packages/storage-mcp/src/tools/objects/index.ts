@@ -19,9 +19,13 @@ export * from './copy_object_safe.js';
 export * from './delete_object.js';
 export * from './download_object.js';
 export * from './download_object_safe.js';
+export * from './generate_signed_url.js';
+export * from './generate_signed_url_safe.js';
+export * from './get_object_metadata.js';
 export * from './list_objects.js';
 export * from './move_object.js';
 export * from './read_object_content.js';
+export * from './read_object_head.js';
 export * from './read_object_metadata.js';
 export * from './update_object_metadata.js';
 export * from './upload_object.js';
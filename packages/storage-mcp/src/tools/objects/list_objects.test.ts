@@ -50,6 +50,7 @@ describe('listObjects', () => {
       delimiter: undefined,
       object_count: 2,
       objects: ['object-1', 'object-2'],
+      prefixes: [],
       next_page_token: null,
     };
     expect(result.content).toEqual([
@@ -85,6 +86,7 @@ describe('listObjects', () => {
     expect(apiClientFactory.getStorageClient).toHaveBeenCalled();
     expect(mockStorageClient.bucket).toHaveBeenCalledWith('test-bucket');
     expect(mockGetFiles).toHaveBeenCalledWith({
+      autoPaginate: false,
       prefix: 'prefix',
       delimiter: '/',
       maxResults: 100,
@@ -97,6 +99,7 @@ describe('listObjects', () => {
       delimiter: '/',
       object_count: 2,
       objects: ['object-1', 'object-2'],
+      prefixes: [],
       next_page_token: 'next-page-token',
     };
     expect(result.content).toEqual([
@@ -107,6 +110,27 @@ describe('listObjects', () => {
     ]);
   });
 
+  it('should return the prefixes of a delimited listing', async () => {
+    const mockGetFiles = vi
+      .fn()
+      .mockResolvedValue([[{ name: 'logs/readme.txt' }], null, { prefixes: ['logs/2025/'] }]);
+    const mockStorageClient = {
+      bucket: vi.fn().mockReturnValue({ getFiles: mockGetFiles }),
+    };
+
+    (apiClientFactory.getStorageClient as vi.Mock).mockReturnValue(mockStorageClient);
+
+    const result = await listObjects({
+      bucket_name: 'test-bucket',
+      prefix: 'logs/',
+      delimiter: '/',
+    });
+
+    const json = JSON.parse(result.content[0].text as string);
+    expect(json.objects).toEqual(['logs/readme.txt']);
+    expect(json.prefixes).toEqual(['logs/2025/']);
+  });
+
   it('should return "No objects found." if no objects are returned', async () => {
     const mockGetFiles = vi.fn().mockResolvedValue([[], null]);
     const mockBucket = {
@@ -127,6 +151,7 @@ describe('listObjects', () => {
       delimiter: undefined,
       object_count: 0,
       objects: [],
+      prefixes: [],
       next_page_token: null,
     };
     expect(result.content).toEqual([
@@ -20,6 +20,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -59,7 +60,8 @@ export async function listObjects(params: ListObjectsParams): Promise<CallToolRe
       `Listing objects in bucket: ${params.bucket_name}, prefix: ${params.prefix}, delimiter: ${params.delimiter}, max_results: ${params.max_results}, page_token: ${params.page_token}, versions: ${params.versions}`,
     );
     const storage = apiClientFactory.getStorageClient();
-    const options: GetFilesOptions = {};
+    // Without autoPaginate the page token is honored and only one page is read.
+    const options: GetFilesOptions = { autoPaginate: false };
     if (params.prefix) {
       options.prefix = params.prefix;
     }
@@ -75,9 +77,13 @@ export async function listObjects(params: ListObjectsParams): Promise<CallToolRe
     if (params.versions) {
       options.versions = params.versions;
     }
-    const [files, nextQuery] = await storage.bucket(params.bucket_name).getFiles(options);
+    const [files, nextQuery, apiResponse] = await storage
+      .bucket(params.bucket_name)
+      .getFiles(options);
 
     const objectList = files.map((file: File) => file.name);
+    // With a delimiter, the "directories" below the prefix are returned separately.
+    const prefixes = (apiResponse as { prefixes?: string[] } | undefined)?.prefixes ?? [];
 
     const result = {
       bucket: params.bucket_name,
@@ -85,6 +91,7 @@ export async function listObjects(params: ListObjectsParams): Promise<CallToolRe
       delimiter: params.delimiter,
       object_count: objectList.length,
       objects: objectList,
+      prefixes,
       next_page_token: nextQuery?.pageToken ?? null,
     };
 
@@ -120,8 +127,9 @@ export const registerListObjectsTool = (server: McpServer) => {
     'list_objects',
     {
       description:
-        'Lists the names of objects in a Google Cloud Storage (GCS) bucket. Supports filtering by prefix, directory-like listing with a delimiter, pagination, and listing object versions.',
+        'Lists the names of objects in a Google Cloud Storage (GCS) bucket. Supports filtering by prefix, directory-like listing with a delimiter, pagination, and listing object versions. With a delimiter, the names of the "directories" are returned in `prefixes`; list them again with one of them as the prefix to descend. Returns at most one page; pass `next_page_token` as `page_token` to get the next one.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     listObjects,
   );
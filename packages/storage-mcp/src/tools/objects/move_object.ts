@@ -19,6 +19,7 @@ import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
 import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { logger } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   source_bucket_name: z.string().describe('The name of the source GCS bucket.'),
@@ -85,6 +86,7 @@ export const registerMoveObjectTool = (server: McpServer) => {
       description:
         'Moves an object from one bucket to another or renames it within the same bucket by copying and deleting the original.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     moveObject,
   );
@@ -24,6 +24,7 @@ import { apiClientFactory } from '../../utility/index.js';
 import { MAX_CONTENT_SIZE } from '../../utility/gcs_helpers.js';
 import { detectBufferType } from '../../utility/file_type_detector.js';
 import { logger } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -179,6 +180,7 @@ export const registerReadObjectContentTool = (server: McpServer) => {
     {
       description: 'Reads the content of a specific object.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     readObjectContent,
   );
@@ -0,0 +1,144 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+/// <reference types="vitest/globals" />
+import { describe, it, expect, vi, Mock } from 'vitest';
+import { readObjectHead, registerReadObjectHeadTool } from './read_object_head.js';
+import { apiClientFactory } from '../../utility/index.js';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+
+import chardet from 'chardet';
+
+vi.mock('chardet');
+vi.mock('../../utility/index.js');
+vi.mock('../../utility/logger.js');
+vi.mock('@modelcontextprotocol/sdk/server/mcp.js');
+
+// Serves byte ranges of the content like the storage client does.
+const mockObject = (content: Buffer, contentType: string) => {
+  const download = vi.fn(async ({ start, end }: { start: number; end: number }) => [
+    content.subarray(start, end + 1),
+  ]);
+  const get = vi.fn().mockResolvedValue([{ metadata: { size: content.length, contentType } }]);
+  const file = vi.fn().mockReturnValue({ download, get });
+  (apiClientFactory.getStorageClient as Mock).mockReturnValue({
+    bucket: vi.fn().mockReturnValue({ file }),
+  });
+  return download;
+};
+
+const parse = (result: Awaited<ReturnType<typeof readObjectHead>>) =>
+  JSON.parse(result.content[0]!.text as string);
+
+describe('readObjectHead', () => {
+  afterEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('should read the first bytes of an object', async () => {
+    const download = mockObject(Buffer.from('0123456789'), 'text/plain');
+    (chardet.detect as Mock).mockReturnValue('UTF-8');
+
+    const result = await readObjectHead({
+      bucket_name: 'test-bucket',
+      object_name: 'test.txt',
+      bytes: 4,
+    });
+
+    expect(download).toHaveBeenCalledWith({ start: 0, end: 3 });
+    expect(parse(result)).toEqual({
+      bucket: 'test-bucket',
+      object: 'test.txt',
+      size: 10,
+      content_type: 'text/plain',
+      bytes_read: 4,
+      truncated: true,
+      content: '0123',
+    });
+  });
+
+  it('should not read past the end of a small object', async () => {
+    const download = mockObject(Buffer.from('abc'), 'text/plain');
+    (chardet.detect as Mock).mockReturnValue('UTF-8');
+
+    const result = await readObjectHead({ bucket_name: 'test-bucket', object_name: 'test.txt' });
+
+    expect(download).toHaveBeenCalledWith({ start: 0, end: 2 });
+    expect(parse(result)).toMatchObject({ content: 'abc', truncated: false });
+  });
+
+  it('should read the first lines of an object', async () => {
+    mockObject(Buffer.from('a,b\n1,2\n3,4\n5,6\n'), 'text/csv');
+    (chardet.detect as Mock).mockReturnValue('UTF-8');
+
+    const result = await readObjectHead({
+      bucket_name: 'test-bucket',
+      object_name: 'data.csv',
+      lines: 2,
+    });
+
+    expect(parse(result)).toMatchObject({ content: 'a,b\n1,2\n', bytes_read: 8, truncated: true });
+  });
+
+  it('should return the whole object if it has fewer lines', async () => {
+    mockObject(Buffer.from('only line'), 'text/plain');
+    (chardet.detect as Mock).mockReturnValue('UTF-8');
+
+    const result = await readObjectHead({
+      bucket_name: 'test-bucket',
+      object_name: 'test.txt',
+      lines: 5,
+    });
+
+    expect(parse(result)).toMatchObject({ content: 'only line', truncated: false });
+  });
+
+  it('should base64 encode binary content', async () => {
+    mockObject(Buffer.from([0x89, 0x50, 0x4e, 0x47]), 'image/png');
+
+    const result = await readObjectHead({ bucket_name: 'test-bucket', object_name: 'a.png' });
+
+    expect(parse(result)).toMatchObject({ content_base64: 'iVBORw==', bytes_read: 4 });
+  });
+
+  it('should return a "Not Found" error if the object does not exist', async () => {
+    const get = vi.fn().mockRejectedValue(new Error('No such object: test-bucket/missing'));
+    (apiClientFactory.getStorageClient as Mock).mockReturnValue({
+      bucket: vi.fn().mockReturnValue({ file: vi.fn().mockReturnValue({ get }) }),
+    });
+
+    const result = await readObjectHead({ bucket_name: 'test-bucket', object_name: 'missing' });
+
+    expect(parse(result)).toEqual({
+      error: 'Error reading object head: No such object: test-bucket/missing',
+      error_type: 'NotFound',
+    });
+  });
+});
+
+describe('registerReadObjectHeadTool', () => {
+  it('should register the read_object_head tool with the server', () => {
+    const mockServer = new McpServer();
+    registerReadObjectHeadTool(mockServer);
+
+    expect(mockServer.registerTool).toHaveBeenCalledWith(
+      'read_object_head',
+      expect.any(Object),
+      readObjectHead,
+    );
+  });
+});
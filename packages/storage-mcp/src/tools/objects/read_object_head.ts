@@ -0,0 +1,161 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import chardet from 'chardet';
+import iconv from 'iconv-lite';
+const { decode } = iconv;
+import { File } from '@google-cloud/storage';
+import { McpServer } from '@modelcontextprotocol/sdk/server/mcp.js';
+import { CallToolResult } from '@modelcontextprotocol/sdk/types.js';
+import { z } from 'zod';
+import { apiClientFactory } from '../../utility/index.js';
+import { detectBufferType } from '../../utility/file_type_detector.js';
+import { logger } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
+
+// The number of bytes read when neither bytes nor lines are given.
+const DEFAULT_HEAD_BYTES = 4096;
+// The most that is ever read, however many lines are requested.
+export const MAX_HEAD_BYTES = 1024 * 1024; // 1MB
+// Lines are read in chunks of this size until enough have been found.
+const LINE_CHUNK_BYTES = 64 * 1024;
+
+const inputSchema = {
+  bucket_name: z.string().describe('The name of the GCS bucket.'),
+  object_name: z.string().describe('The name of the object.'),
+  bytes: z
+    .number()
+    .int()
+    .positive()
+    .max(MAX_HEAD_BYTES)
+    .optional()
+    .describe('The number of bytes to read from the start of the object. Defaults to 4096.'),
+  lines: z
+    .number()
+    .int()
+    .positive()
+    .optional()
+    .describe(
+      `The number of lines to read from the start of the object, instead of a number of bytes. At most ${MAX_HEAD_BYTES} bytes are read.`,
+    ),
+};
+
+type ReadObjectHeadParams = z.infer<z.ZodObject<typeof inputSchema>>;
+
+// Returns the offset just past the nth newline, if the buffer has that many.
+const endOfLine = (buffer: Buffer, lines: number): number | undefined => {
+  let offset = -1;
+  for (let i = 0; i < lines; i++) {
+    offset = buffer.indexOf(0x0a, offset + 1);
+    if (offset === -1) {
+      return undefined;
+    }
+  }
+  return offset + 1;
+};
+
+// Downloads the given byte range; `end` is exclusive.
+const downloadRange = async (file: File, start: number, end: number): Promise<Buffer> => {
+  const [buffer] = await file.download({ start, end: end - 1 });
+  return buffer;
+};
+
+const readLines = async (file: File, size: number, lines: number): Promise<Buffer> => {
+  const limit = Math.min(size, MAX_HEAD_BYTES);
+  let buffer = Buffer.alloc(0);
+  while (buffer.length < limit) {
+    const end = Math.min(buffer.length + LINE_CHUNK_BYTES, limit);
+    buffer = Buffer.concat([buffer, await downloadRange(file, buffer.length, end)]);
+    const lineEnd = endOfLine(buffer, lines);
+    if (lineEnd !== undefined) {
+      return buffer.subarray(0, lineEnd);
+    }
+  }
+  return buffer;
+};
+
+export async function readObjectHead(params: ReadObjectHeadParams): Promise<CallToolResult> {
+  try {
+    logger.info(
+      `Reading head of object: ${params.object_name} in bucket: ${params.bucket_name}, bytes: ${params.bytes}, lines: ${params.lines}`,
+    );
+    const storage = apiClientFactory.getStorageClient();
+    const file = storage.bucket(params.bucket_name).file(params.object_name);
+    const [metadata] = await file.get();
+    const size = Number(metadata.metadata.size);
+    const contentType = metadata.metadata.contentType || 'application/octet-stream';
+
+    let buffer: Buffer = Buffer.alloc(0);
+    if (size > 0) {
+      buffer = params.lines
+        ? await readLines(file, size, params.lines)
+        : await downloadRange(file, 0, Math.min(size, params.bytes ?? DEFAULT_HEAD_BYTES));
+    }
+
+    const result: Record<string, unknown> = {
+      bucket: params.bucket_name,
+      object: params.object_name,
+      size,
+      content_type: contentType,
+      bytes_read: buffer.length,
+      truncated: buffer.length < size,
+    };
+    const type = detectBufferType(buffer, contentType, params.object_name);
+    if (type === 'text' || type === 'svg') {
+      // The head may end inside a multi-byte character, which decodes to U+FFFD.
+      result['content'] = decode(buffer, chardet.detect(buffer) || 'utf-8');
+    } else {
+      result['content_base64'] = buffer.toString('base64');
+    }
+
+    logger.info(`Successfully read ${buffer.length} of ${size} bytes of ${params.object_name}`);
+    return {
+      content: [{ type: 'text', text: JSON.stringify(result, null, 2) }],
+    };
+  } catch (e: unknown) {
+    const error = e as Error;
+    let errorType = 'Unknown';
+    if (error.message.includes('Not Found') || error.message.includes('No such object')) {
+      errorType = 'NotFound';
+    } else if (error.message.includes('Forbidden')) {
+      errorType = 'Forbidden';
+    }
+    const errorMsg = `Error reading object head: ${error.message}`;
+    logger.error(errorMsg);
+    return {
+      content: [
+        {
+          type: 'text',
+          text: JSON.stringify({ error: errorMsg, error_type: errorType }),
+        },
+      ],
+    };
+  }
+}
+
+export const registerReadObjectHeadTool = (server: McpServer) => {
+  server.registerTool(
+    'read_object_head',
+    {
+      description:
+        'Reads the first bytes or lines of an object without downloading all of it, e.g. to preview a large log or CSV file. Text is returned in `content` and anything else base64-encoded in `content_base64`; `truncated` tells whether the object has more data.',
+      inputSchema,
+      annotations: READ_ONLY_TOOL,
+    },
+    readObjectHead,
+  );
+};
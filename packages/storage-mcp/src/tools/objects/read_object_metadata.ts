@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { formatFileMetadataResponse } from '../../utility/gcs_helpers.js';
 import { logger } from '../../utility/logger.js';
+import { READ_ONLY_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -87,6 +88,7 @@ export const registerReadObjectMetadataTool = (server: McpServer) => {
     {
       description: 'Reads metadata for a specific object.',
       inputSchema,
+      annotations: READ_ONLY_TOOL,
     },
     readObjectMetadata,
   );
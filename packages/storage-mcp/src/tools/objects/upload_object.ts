@@ -22,6 +22,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { getContentType } from '../../utility/gcs_helpers.js';
 import { logger } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -110,6 +111,7 @@ export const registerUploadObjectTool = (server: McpServer) => {
     {
       description: 'Uploads a file to a GCS bucket.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     uploadObject,
   );
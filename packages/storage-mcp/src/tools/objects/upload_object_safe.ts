@@ -22,6 +22,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { getContentType } from '../../utility/gcs_helpers.js';
 import { logger } from '../../utility/logger.js';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -116,6 +117,7 @@ export const registerUploadObjectSafeTool = (server: McpServer) => {
     {
       description: 'Uploads a file to a GCS bucket. Fails if the object already exists.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     uploadObjectSafe,
   );
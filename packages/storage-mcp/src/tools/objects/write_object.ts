@@ -20,6 +20,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { getContentType, validateBase64Content } from '../../utility/gcs_helpers.js';
 import { logger } from '../../utility/logger.js';
+import { DESTRUCTIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -106,6 +107,7 @@ export const registerWriteObjectTool = (server: McpServer) => {
     {
       description: 'Writes a new object to the bucket.',
       inputSchema,
+      annotations: DESTRUCTIVE_TOOL,
     },
     writeObject,
   );
@@ -21,6 +21,7 @@ import { z } from 'zod';
 import { apiClientFactory } from '../../utility/index.js';
 import { getContentType, validateBase64Content } from '../../utility/gcs_helpers.js';
 import { logger } from '../../utility/logger.js';
+import { ADDITIVE_TOOL } from '../tool_annotations.js';
 
 const inputSchema = {
   bucket_name: z.string().describe('The name of the GCS bucket.'),
@@ -114,6 +115,7 @@ export const registerWriteObjectSafeTool = (server: McpServer) => {
     {
       description: 'Writes a new object to the bucket. Fails if the object already exists.',
       inputSchema,
+      annotations: ADDITIVE_TOOL,
     },
     writeObjectSafe,
   );
@@ -0,0 +1,29 @@
+/**
+ * Copyright 2025 Google LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { ToolAnnotations } from '@modelcontextprotocol/sdk/types.js';
+
+// Clients use the hints to decide which tool calls to confirm with the user.
+// They describe what a tool may do at most, not what a particular call does.
+
+/** Only reads buckets, objects or their metadata. */
+export const READ_ONLY_TOOL: ToolAnnotations = { readOnlyHint: true };
+
+/** Creates buckets, objects or local files, but never overwrites or deletes anything. */
+export const ADDITIVE_TOOL: ToolAnnotations = { readOnlyHint: false, destructiveHint: false };
+
+/** May overwrite or delete buckets, objects, their metadata or local files. */
+export const DESTRUCTIVE_TOOL: ToolAnnotations = { readOnlyHint: false, destructiveHint: true };
@@ -77,6 +77,30 @@ export const formatFileMetadataResponse = (metadata: FileMetadata) => ({
   metadata: metadata.metadata,
 });
 
+/**
+ * Formats the full metadata of an object, including its checksums, version,
+ * encoding, encryption and retention settings.
+ */
+export const formatDetailedFileMetadataResponse = (metadata: FileMetadata) => ({
+  ...formatFileMetadataResponse(metadata),
+  generation: metadata.generation !== undefined ? String(metadata.generation) : undefined,
+  metageneration:
+    metadata.metageneration !== undefined ? String(metadata.metageneration) : undefined,
+  etag: metadata.etag,
+  md5_hash: metadata.md5Hash,
+  crc32c: metadata.crc32c,
+  content_encoding: metadata.contentEncoding,
+  content_disposition: metadata.contentDisposition,
+  content_language: metadata.contentLanguage,
+  cache_control: metadata.cacheControl,
+  custom_time: metadata.customTime,
+  kms_key_name: metadata.kmsKeyName,
+  temporary_hold: metadata.temporaryHold,
+  event_based_hold: metadata.eventBasedHold,
+  retention_expiration_time: metadata.retentionExpirationTime,
+  component_count: metadata.componentCount,
+});
+
 export const validateBase64Content = (content: string) => {
   // Basic check for base64 validity
   if (!/^[A-Za-z0-9+/=]*$/.test(content)) {
@@ -70,13 +70,23 @@ export class Logger {
     this.write('error', message, data, error);
   }
 
+  /**
+   * Records an audit event for a tool call, at any log level. Sensitive events,
+   * such as handing out a signed URL, are flagged so that they can be reviewed
+   * separately. Never pass the secret itself.
+   */
+  audit(toolName: string, data: Record<string, unknown>, sensitive = false): void {
+    this.write('info', `Audit: ${toolName}`, { audit: true, sensitive, ...data }, undefined, true);
+  }
+
   private write(
     severity: LogSeverity,
     message: string,
     context?: Record<string, unknown>,
     error?: Error,
+    always = false,
   ): void {
-    if (SeverityLevels[severity] < this.minSeverity) {
+    if (!always && SeverityLevels[severity] < this.minSeverity) {
       return;
     }
 
@@ -128,6 +138,7 @@ export const log = {
   info: logger.info.bind(logger),
   warn: logger.warn.bind(logger),
   error: logger.error.bind(logger),
+  audit: logger.audit.bind(logger),
   timer: logger.startTimer.bind(logger),
   mcp: logger.mcpTool.bind(logger),
 };
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"integration/client"
+)
+
+// benchCase is a single tool call measured by the benchmark suite.
+type benchCase struct {
+	Name     string
+	ToolCall client.ToolCall
+}
+
+// benchResult is the per-case section of the benchmark report. The latencies
+// are of calls in a session that is already open.
+type benchResult struct {
+	Name       string  `json:"name"`
+	Tool       string  `json:"tool"`
+	Args       any     `json:"args"`
+	Iterations int     `json:"iterations"`
+	Errors     int     `json:"errors"`
+	P50Ms      float64 `json:"p50_ms"`
+	P95Ms      float64 `json:"p95_ms"`
+	P99Ms      float64 `json:"p99_ms"`
+	// CallsPerSec is the number of successful calls per second of the case,
+	// which makes one call at a time.
+	CallsPerSec   float64 `json:"calls_per_sec"`
+	RequestBytes  int     `json:"request_bytes"`
+	MeanOutputLen int     `json:"mean_output_bytes"`
+	MaxOutputLen  int     `json:"max_output_bytes"`
+}
+
+// handshakeResult is the time it takes to start, or connect to, the server and
+// complete the MCP handshake.
+type handshakeResult struct {
+	Iterations int     `json:"iterations"`
+	Errors     int     `json:"errors"`
+	P50Ms      float64 `json:"p50_ms"`
+	P95Ms      float64 `json:"p95_ms"`
+	P99Ms      float64 `json:"p99_ms"`
+}
+
+// benchRegression is a metric of a case that is worse than in the baseline by
+// more than the threshold.
+type benchRegression struct {
+	Case          string  `json:"case"`
+	Metric        string  `json:"metric"`
+	Baseline      float64 `json:"baseline"`
+	Current       float64 `json:"current"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// benchReport is the JSON document emitted by bench mode. Reports from
+// different releases can be compared field by field.
+type benchReport struct {
+	Timestamp  string          `json:"timestamp"`
+	Project    string          `json:"project"`
+	Iterations int             `json:"iterations"`
+	Handshake  handshakeResult `json:"handshake"`
+	Results    []benchResult   `json:"results"`
+	// Baseline and Regressions are set if the report was compared with the
+	// report of an earlier run.
+	Baseline    string            `json:"baseline,omitempty"`
+	Regressions []benchRegression `json:"regressions,omitempty"`
+}
+
+// benchOptions configure bench mode.
+type benchOptions struct {
+	project    string
+	iterations int
+	// outPath is the path of the JSON report, or empty for stdout.
+	outPath string
+	remote  *client.Remote
+	// cases, if set, are the names of the standard cases to run.
+	cases []string
+	// baselinePath, if set, is a report of an earlier run to compare with.
+	baselinePath string
+	// thresholdPercent is how much worse than the baseline a metric may be
+	// before it is a regression.
+	thresholdPercent float64
+}
+
+// standardBenchCases returns the fixed set of tool calls measured against the
+// designated test project, on the remote server if one is given.
+func standardBenchCases(project string, remote *client.Remote) []benchCase {
+	gcloud := func(name string, args ...string) benchCase {
+		return benchCase{
+			Name: name,
+			ToolCall: client.ToolCall{
+				ServerCmd: []string{"gcloud-mcp"},
+				Remote:    remote,
+				ToolName:  "run_gcloud_command",
+				// Every call runs gcloud instead of returning the cached result
+				// of the first.
+				ToolArgs: map[string]any{"args": args, "cache": false},
+			},
+		}
+	}
+	return []benchCase{
+		gcloud("config_list", "config", "list", "--format=json"),
+		gcloud("projects_describe", "projects", "describe", project, "--format=json"),
+		gcloud("services_list", "services", "list", "--project", project, "--limit=20", "--format=json"),
+		gcloud("logging_read", "logging", "read", "severity>=ERROR", "--project", project, "--limit=10", "--format=json"),
+	}
+}
+
+// percentile returns the p-th percentile (0-100) of sorted durations using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func toMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// latencies returns the p50, p95 and p99 of durations in milliseconds.
+func latencies(durations []time.Duration) (p50, p95, p99 float64) {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return toMillis(percentile(durations, 50)), toMillis(percentile(durations, 95)), toMillis(percentile(durations, 99))
+}
+
+// runHandshakeBench starts a session as often as the cases call their tool,
+// and closes it right after the handshake.
+func runHandshakeBench(serverCmd []string, remote *client.Remote, iterations int) handshakeResult {
+	ctx := context.Background()
+	result := handshakeResult{Iterations: iterations}
+	var durations []time.Duration
+	for i := 0; i < iterations; i++ {
+		start := time.Now()
+		session, err := client.NewSession(ctx, serverCmd, remote)
+		elapsed := time.Since(start)
+		if err != nil {
+			result.Errors++
+			fmt.Printf("⚠️  handshake iteration %d failed: %v\n", i+1, err)
+			continue
+		}
+		session.Close()
+		durations = append(durations, elapsed)
+	}
+	result.P50Ms, result.P95Ms, result.P99Ms = latencies(durations)
+	return result
+}
+
+// runBenchCase calls the tool of the case repeatedly in one session.
+func runBenchCase(bc benchCase, iterations int) benchResult {
+	result := benchResult{
+		Name:       bc.Name,
+		Tool:       bc.ToolCall.ToolName,
+		Args:       bc.ToolCall.ToolArgs,
+		Iterations: iterations,
+	}
+	if request, err := json.Marshal(bc.ToolCall.ToolArgs); err == nil {
+		result.RequestBytes = len(request)
+	}
+	ctx := context.Background()
+	session, err := client.NewSession(ctx, bc.ToolCall.ServerCmd, bc.ToolCall.Remote)
+	if err != nil {
+		result.Errors = iterations
+		fmt.Printf("⚠️  %s failed to start a session: %v\n", bc.Name, err)
+		return result
+	}
+	defer session.Close()
+
+	var (
+		durations []time.Duration
+		totalLen  int
+	)
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		callStart := time.Now()
+		output, err := session.CallTool(ctx, bc.ToolCall.ToolName, bc.ToolCall.ToolArgs)
+		elapsed := time.Since(callStart)
+		if err != nil {
+			result.Errors++
+			fmt.Printf("⚠️  %s iteration %d failed: %v\n", bc.Name, i+1, err)
+			continue
+		}
+		durations = append(durations, elapsed)
+		totalLen += len(output)
+		result.MaxOutputLen = max(result.MaxOutputLen, len(output))
+	}
+	if len(durations) > 0 {
+		result.MeanOutputLen = totalLen / len(durations)
+		result.CallsPerSec = float64(len(durations)) / time.Since(start).Seconds()
+	}
+	result.P50Ms, result.P95Ms, result.P99Ms = latencies(durations)
+	return result
+}
+
+// compareWithBaseline returns the latencies and output sizes of report that
+// are worse than in baseline by more than thresholdPercent. Cases and metrics
+// the baseline does not have are skipped. p99 is not compared, as it is
+// mostly noise with few iterations.
+func compareWithBaseline(baseline, report benchReport, thresholdPercent float64) []benchRegression {
+	var regressions []benchRegression
+	compare := func(name, metric string, was, is float64) {
+		if was <= 0 {
+			return
+		}
+		if change := (is - was) / was * 100; change > thresholdPercent {
+			regressions = append(regressions, benchRegression{Case: name, Metric: metric, Baseline: was, Current: is, ChangePercent: change})
+		}
+	}
+	compare("handshake", "p50_ms", baseline.Handshake.P50Ms, report.Handshake.P50Ms)
+	compare("handshake", "p95_ms", baseline.Handshake.P95Ms, report.Handshake.P95Ms)
+	for _, result := range report.Results {
+		i := slices.IndexFunc(baseline.Results, func(r benchResult) bool { return r.Name == result.Name })
+		if i < 0 || result.Errors == result.Iterations {
+			continue
+		}
+		was := baseline.Results[i]
+		compare(result.Name, "p50_ms", was.P50Ms, result.P50Ms)
+		compare(result.Name, "p95_ms", was.P95Ms, result.P95Ms)
+		compare(result.Name, "mean_output_bytes", float64(was.MeanOutputLen), float64(result.MeanOutputLen))
+	}
+	return regressions
+}
+
+func loadBenchReport(path string) (benchReport, error) {
+	var report benchReport
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return report, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// runBench measures the handshake and the selected standard cases, and
+// writes the JSON report to outPath, or to stdout when outPath is empty. It
+// fails if a case failed on every iteration or regressed from the baseline.
+func runBench(opts benchOptions) error {
+	var baseline benchReport
+	if opts.baselinePath != "" {
+		var err error
+		if baseline, err = loadBenchReport(opts.baselinePath); err != nil {
+			return err
+		}
+	}
+	cases := standardBenchCases(opts.project, opts.remote)
+	if len(opts.cases) > 0 {
+		for _, name := range opts.cases {
+			if !slices.ContainsFunc(cases, func(bc benchCase) bool { return bc.Name == name }) {
+				return fmt.Errorf("unknown benchmark case %q", name)
+			}
+		}
+		cases = slices.DeleteFunc(cases, func(bc benchCase) bool { return !slices.Contains(opts.cases, bc.Name) })
+	}
+	fmt.Printf("🚀 Starting gcloud-mcp benchmark against project %s (%d iterations)...\n", opts.project, opts.iterations)
+
+	report := benchReport{
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Project:    opts.project,
+		Iterations: opts.iterations,
+		Handshake:  runHandshakeBench([]string{"gcloud-mcp"}, opts.remote, opts.iterations),
+	}
+	failed := report.Handshake.Errors == opts.iterations
+	fmt.Printf("🤝 handshake: p50=%.1fms p95=%.1fms p99=%.1fms errors=%d\n",
+		report.Handshake.P50Ms, report.Handshake.P95Ms, report.Handshake.P99Ms, report.Handshake.Errors)
+	for _, bc := range cases {
+		result := runBenchCase(bc, opts.iterations)
+		if result.Errors == opts.iterations {
+			failed = true
+		}
+		fmt.Printf("⏱️  %s: p50=%.1fms p95=%.1fms p99=%.1fms %.1f calls/s request=%dB mean_output=%dB errors=%d\n",
+			result.Name, result.P50Ms, result.P95Ms, result.P99Ms, result.CallsPerSec, result.RequestBytes, result.MeanOutputLen, result.Errors)
+		report.Results = append(report.Results, result)
+	}
+	if opts.baselinePath != "" {
+		report.Baseline = opts.baselinePath
+		report.Regressions = compareWithBaseline(baseline, report, opts.thresholdPercent)
+		for _, r := range report.Regressions {
+			fmt.Printf("📉 %s %s regressed by %.0f%%: %.1f -> %.1f\n", r.Case, r.Metric, r.ChangePercent, r.Baseline, r.Current)
+		}
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format benchmark report: %w", err)
+	}
+	if opts.outPath == "" {
+		fmt.Println(string(reportJSON))
+	} else {
+		if err := os.WriteFile(opts.outPath, reportJSON, 0o644); err != nil {
+			return fmt.Errorf("failed to write benchmark report: %w", err)
+		}
+		fmt.Printf("📄 Benchmark report written to %s\n", opts.outPath)
+	}
+
+	if failed {
+		return fmt.Errorf("one or more benchmark cases failed on every iteration")
+	}
+	if len(report.Regressions) > 0 {
+		return fmt.Errorf("%d metrics regressed by more than %.0f%% from baseline %s", len(report.Regressions), opts.thresholdPercent, opts.baselinePath)
+	}
+	return nil
+}
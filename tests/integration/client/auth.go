@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// IdentityToken obtains a Google-signed ID token with the gcloud credentials
+// of the caller. audience, if set, is the audience of the token, e.g. the URL
+// of a Cloud Run service; gcloud only supports it for service accounts.
+func IdentityToken(ctx context.Context, audience string) (string, error) {
+	args := []string{"auth", "print-identity-token"}
+	if audience != "" {
+		args = append(args, "--audiences="+audience)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gcloud", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("gcloud auth print-identity-token failed: %v: %s", err, stderr.String())
+	}
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("gcloud auth print-identity-token returned no token")
+	}
+	return token, nil
+}
+
+// ProtectedResource is the OAuth 2.0 protected resource metadata (RFC 9728)
+// a server advertises for its MCP endpoint.
+type ProtectedResource struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported"`
+}
+
+var resourceMetadataPattern = regexp.MustCompile(`resource_metadata="([^"]+)"`)
+
+// DiscoverProtectedResource sends an unauthenticated request to endpoint,
+// which must be rejected with a challenge that points to the protected
+// resource metadata, and returns the metadata.
+func DiscoverProtectedResource(ctx context.Context, endpoint string) (*ProtectedResource, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader("{}"))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthenticated request returned %s, want 401", resp.Status)
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	match := resourceMetadataPattern.FindStringSubmatch(challenge)
+	if match == nil {
+		return nil, fmt.Errorf("the 401 response has no resource_metadata challenge: %q", challenge)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, match[1], nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned %s", match[1], resp.Status)
+	}
+	var metadata ProtectedResource
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse the protected resource metadata: %w", err)
+	}
+	return &metadata, nil
+}
@@ -4,49 +4,325 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os/exec"
+	"sync"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// Transports supported for remote servers.
+const (
+	TransportStreamable = "streamable"
+	TransportSSE        = "sse"
+)
+
+// Remote describes a gcloud-mcp server that is reached over HTTP instead of
+// being started as a local process, e.g. a deployment on Cloud Run.
+type Remote struct {
+	// URL is the MCP endpoint, e.g. https://gcloud-mcp-abc.a.run.app/mcp.
+	URL string
+	// Transport is TransportStreamable (the default) or TransportSSE.
+	Transport string
+	// Headers are added to every request.
+	Headers map[string]string
+	// AuthToken, if set, is sent as a bearer token, e.g. an identity token
+	// for a Cloud Run service that requires authentication.
+	AuthToken string
+	// OAuth is set if the server verifies bearer tokens itself and advertises
+	// its OAuth protected resource metadata, as gcloud-mcp does with
+	// "auth": "oidc".
+	OAuth bool
+}
+
 type ToolCall struct {
 	ServerCmd []string
-	ToolName  string
-	ToolArgs  any
+	// Remote, if set, is used instead of starting ServerCmd.
+	Remote   *Remote
+	ToolName string
+	ToolArgs any
+	// ResourceURI, if set, is read instead of calling a tool.
+	ResourceURI string
+	// ValidateArgs checks ToolArgs against the input schema of the tool before
+	// calling it.
+	ValidateArgs bool
+	// Options, e.g. a recording to write or replay, apply to the session of
+	// the call.
+	Options SessionOptions
 }
 
-func InvokeMCPTool(toolCall ToolCall) (string, error) {
-	if len(toolCall.ServerCmd) == 0 {
-		return "", fmt.Errorf("no server args provided. Usage: server_name [<args>]")
+// headerTransport adds fixed headers to every request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
 	}
+	return t.base.RoundTrip(req)
+}
 
-	var (
-		ctx       = context.Background()
-		transport mcp.Transport
-	)
+func (r *Remote) transport() (mcp.Transport, error) {
+	if r.URL == "" {
+		return nil, fmt.Errorf("no remote server URL provided")
+	}
+	headers := map[string]string{}
+	for key, value := range r.Headers {
+		headers[key] = value
+	}
+	if r.AuthToken != "" {
+		headers["Authorization"] = "Bearer " + r.AuthToken
+	}
+	httpClient := &http.Client{
+		Transport: &headerTransport{base: http.DefaultTransport, headers: headers},
+	}
+	switch r.Transport {
+	case "", TransportStreamable:
+		return &mcp.StreamableClientTransport{Endpoint: r.URL, HTTPClient: httpClient}, nil
+	case TransportSSE:
+		return &mcp.SSEClientTransport{Endpoint: r.URL, HTTPClient: httpClient}, nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q: use %q or %q", r.Transport, TransportStreamable, TransportSSE)
+	}
+}
+
+func newTransport(serverCmd []string, remote *Remote, stderr io.Writer) (mcp.Transport, error) {
+	if remote != nil {
+		return remote.transport()
+	}
+	if len(serverCmd) == 0 {
+		return nil, fmt.Errorf("no server args provided. Usage: server_name [<args>]")
+	}
+	cmd := exec.Command(serverCmd[0], serverCmd[1:]...)
+	cmd.Stderr = stderr
+	return &mcp.CommandTransport{Command: cmd}, nil
+}
+
+// Session is an MCP connection that is kept open across tool calls, so that
+// later calls see the state left by earlier ones, e.g. the project selected
+// with set_context. Callers must Close it.
+type Session struct {
+	cs *mcp.ClientSession
+
+	mu sync.Mutex
+	// progress holds the progress notifications received per progress token.
+	progress map[string][]ProgressEvent
+	calls    int
+	// schemas are the resolved input schemas of the tools, by name, once
+	// ValidateToolArgs has listed them.
+	schemas map[string]*jsonschema.Resolved
+}
 
-	cmd := exec.Command(toolCall.ServerCmd[0], toolCall.ServerCmd[1:]...)
-	transport = &mcp.CommandTransport{Command: cmd}
-	client := mcp.NewClient(&mcp.Implementation{Name: "mcp-client", Version: "v1.0.0"}, nil)
+// ProgressEvent is a progress notification sent by the server while a tool
+// call runs.
+type ProgressEvent struct {
+	Progress float64
+	// Total is zero if the total is unknown.
+	Total   float64
+	Message string
+}
+
+// ElicitationHandler answers an elicitation request of the server, e.g. to
+// confirm a command that changes state.
+type ElicitationHandler func(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error)
+
+// SessionOptions configure how a Session answers requests of the server.
+type SessionOptions struct {
+	// ElicitationHandler, if set, is called for every elicitation request.
+	// The client only declares the elicitation capability if it is set.
+	ElicitationHandler ElicitationHandler
+	// Record, if set, is the path of a recording of every message of the
+	// session. See RecordingTransport.
+	Record string
+	// Replay, if set, is the path of a recording that is replayed instead of
+	// starting or connecting to a server. See ReplayTransport.
+	Replay string
+	// Stderr, if set, receives the standard error of a server started by the
+	// session.
+	Stderr io.Writer
+}
+
+// NewSession starts serverCmd, or connects to remote if it is set, and
+// completes the MCP handshake.
+func NewSession(ctx context.Context, serverCmd []string, remote *Remote) (*Session, error) {
+	return NewSessionWithOptions(ctx, serverCmd, remote, SessionOptions{})
+}
+
+// NewSessionWithOptions is NewSession with handlers for server requests and
+// recording or replay of the traffic.
+func NewSessionWithOptions(ctx context.Context, serverCmd []string, remote *Remote, opts SessionOptions) (*Session, error) {
+	var transport mcp.Transport = &ReplayTransport{Path: opts.Replay}
+	if opts.Replay == "" {
+		var err error
+		if transport, err = newTransport(serverCmd, remote, opts.Stderr); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Record != "" {
+		transport = &RecordingTransport{Transport: transport, Path: opts.Record}
+	}
+	session := &Session{progress: map[string][]ProgressEvent{}}
+	clientOpts := &mcp.ClientOptions{ProgressNotificationHandler: session.recordProgress}
+	if opts.ElicitationHandler != nil {
+		clientOpts.ElicitationHandler = opts.ElicitationHandler
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "mcp-client", Version: "v1.0.0"}, clientOpts)
 	cs, err := client.Connect(ctx, transport, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to connect: %w", err)
+		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
-	defer cs.Close()
+	session.cs = cs
+	return session, nil
+}
 
-	if toolCall.ToolName != "" {
-		result, err := cs.CallTool(ctx, &mcp.CallToolParams{
-			Name:      toolCall.ToolName,
-			Arguments: toolCall.ToolArgs,
-		})
+func (s *Session) recordProgress(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+	token, ok := req.Params.ProgressToken.(string)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress[token] = append(s.progress[token], ProgressEvent{
+		Progress: req.Params.Progress,
+		Total:    req.Params.Total,
+		Message:  req.Params.Message,
+	})
+}
+
+// CallTool calls a tool and returns its result as indented JSON.
+func (s *Session) CallTool(ctx context.Context, toolName string, toolArgs any) (string, error) {
+	return s.callTool(ctx, &mcp.CallToolParams{Name: toolName, Arguments: toolArgs})
+}
+
+// CallToolWithProgress calls a tool with a progress token and returns its
+// result as indented JSON, with the progress notifications sent for the call.
+func (s *Session) CallToolWithProgress(ctx context.Context, toolName string, toolArgs any) (string, []ProgressEvent, error) {
+	s.mu.Lock()
+	s.calls++
+	token := fmt.Sprintf("progress-%d", s.calls)
+	s.mu.Unlock()
+
+	params := &mcp.CallToolParams{Name: toolName, Arguments: toolArgs}
+	params.SetProgressToken(token)
+	output, err := s.callTool(ctx, params)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.progress[token]
+	delete(s.progress, token)
+	return output, events, err
+}
+
+func (s *Session) callTool(ctx context.Context, params *mcp.CallToolParams) (string, error) {
+	result, err := s.cs.CallTool(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("tool execution failed: %w", err)
+	}
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to format tool result: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// ListTools returns every tool the server lists, across all pages.
+func (s *Session) ListTools(ctx context.Context) ([]*mcp.Tool, error) {
+	var tools []*mcp.Tool
+	for tool, err := range s.cs.Tools(ctx, nil) {
 		if err != nil {
-			return "", fmt.Errorf("tool execution failed: %w", err)
+			return nil, fmt.Errorf("failed to list tools: %w", err)
 		}
-		resultJSON, err := json.MarshalIndent(result, "", "  ")
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// ListResources returns every resource the server lists, across all pages.
+func (s *Session) ListResources(ctx context.Context) ([]*mcp.Resource, error) {
+	var resources []*mcp.Resource
+	for resource, err := range s.cs.Resources(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// ListResourceTemplates returns every resource template the server lists,
+// across all pages.
+func (s *Session) ListResourceTemplates(ctx context.Context) ([]*mcp.ResourceTemplate, error) {
+	var templates []*mcp.ResourceTemplate
+	for template, err := range s.cs.ResourceTemplates(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resource templates: %w", err)
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+// ListPrompts returns every prompt the server lists, across all pages.
+func (s *Session) ListPrompts(ctx context.Context) ([]*mcp.Prompt, error) {
+	var prompts []*mcp.Prompt
+	for prompt, err := range s.cs.Prompts(ctx, nil) {
 		if err != nil {
-			return "", fmt.Errorf("failed to format tool result: %w", err)
+			return nil, fmt.Errorf("failed to list prompts: %w", err)
+		}
+		prompts = append(prompts, prompt)
+	}
+	return prompts, nil
+}
+
+// ServerCapabilities returns the capabilities the server declared in the
+// handshake.
+func (s *Session) ServerCapabilities() *mcp.ServerCapabilities {
+	return s.cs.InitializeResult().Capabilities
+}
+
+// ReadResource reads a resource and returns the result as indented JSON.
+func (s *Session) ReadResource(ctx context.Context, uri string) (string, error) {
+	result, err := s.cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		return "", fmt.Errorf("failed to read resource %s: %w", uri, err)
+	}
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to format resource: %w", err)
+	}
+	return string(resultJSON), nil
+}
+
+// Close ends the session and stops the server process, if one was started.
+func (s *Session) Close() error {
+	return s.cs.Close()
+}
+
+// InvokeMCPTool calls a single tool, or reads a single resource, in a new
+// session.
+func InvokeMCPTool(toolCall ToolCall) (string, error) {
+	ctx := context.Background()
+	session, err := NewSessionWithOptions(ctx, toolCall.ServerCmd, toolCall.Remote, toolCall.Options)
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	if toolCall.ResourceURI != "" {
+		return session.ReadResource(ctx, toolCall.ResourceURI)
+	}
+	if toolCall.ToolName != "" {
+		if toolCall.ValidateArgs {
+			if err := session.ValidateToolArgs(ctx, toolCall.ToolName, toolCall.ToolArgs); err != nil {
+				return "", err
+			}
 		}
-		return string(resultJSON), nil
+		return session.CallTool(ctx, toolCall.ToolName, toolCall.ToolArgs)
 	}
 	return "", nil
 }
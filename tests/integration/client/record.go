@@ -0,0 +1,257 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Directions of a recorded frame.
+const (
+	// FrameSent is a message the client sent to the server.
+	FrameSent = "sent"
+	// FrameReceived is a message the client received from the server.
+	FrameReceived = "received"
+)
+
+// Frame is a JSON-RPC message of a recording. A recording is a file with one
+// frame per line in the order the messages were sent or received, so
+// recordings of two server versions can be compared with diff.
+type Frame struct {
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// RecordingTransport is a transport that writes every message sent and
+// received over Transport to the file at Path.
+type RecordingTransport struct {
+	Transport mcp.Transport
+	Path      string
+}
+
+// Connect connects Transport and creates the recording, replacing any
+// recording already at Path.
+func (t *RecordingTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	file, err := os.Create(t.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	conn, err := t.Transport.Connect(ctx)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &recordingConn{Connection: conn, file: file}, nil
+}
+
+type recordingConn struct {
+	mcp.Connection
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (c *recordingConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	msg, err := c.Connection.Read(ctx)
+	if err == nil {
+		err = c.record(FrameReceived, msg)
+	}
+	return msg, err
+}
+
+func (c *recordingConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	if err := c.record(FrameSent, msg); err != nil {
+		return err
+	}
+	return c.Connection.Write(ctx, msg)
+}
+
+func (c *recordingConn) record(direction string, msg jsonrpc.Message) error {
+	data, err := jsonrpc.EncodeMessage(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	line, err := json.Marshal(Frame{Direction: direction, Message: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+func (c *recordingConn) Close() error {
+	err := c.Connection.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if closeErr := c.file.Close(); err == nil && !errors.Is(closeErr, os.ErrClosed) {
+		err = closeErr
+	}
+	return err
+}
+
+// ReplayTransport is a transport that serves the received messages of the
+// recording at Path instead of connecting to a server. A received message is
+// served once the client has sent the messages recorded before it, and every
+// message the client sends must have the method and ID of the next sent
+// message of the recording.
+type ReplayTransport struct {
+	Path string
+}
+
+// Connect reads the recording.
+func (t *ReplayTransport) Connect(context.Context) (mcp.Connection, error) {
+	frames, err := ReadRecording(t.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &replayConn{frames: frames, changed: make(chan struct{}), closed: make(chan struct{})}, nil
+}
+
+// ReadRecording reads the frames of the recording at path.
+func ReadRecording(path string) ([]Frame, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var frames []Frame
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		var frame Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d of recording %s: %w", line, path, err)
+		}
+		if frame.Direction != FrameSent && frame.Direction != FrameReceived {
+			return nil, fmt.Errorf("line %d of recording %s has unknown direction %q", line, path, frame.Direction)
+		}
+		frames = append(frames, frame)
+	}
+	return frames, scanner.Err()
+}
+
+type replayConn struct {
+	frames []Frame
+
+	mu sync.Mutex
+	// sent is the number of messages the client has sent.
+	sent int
+	// next is the index after the last received frame served, and nextSent
+	// the index of the next sent frame to match.
+	next     int
+	nextSent int
+	// changed is closed and replaced whenever the client sends a message.
+	changed   chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *replayConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	for {
+		c.mu.Lock()
+		frame, ready := c.nextReceived()
+		changed := c.changed
+		c.mu.Unlock()
+		if ready {
+			return jsonrpc.DecodeMessage(frame.Message)
+		}
+		select {
+		case <-changed:
+		case <-c.closed:
+			return nil, io.EOF
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// nextReceived returns the next received frame if all frames sent before it
+// have been matched, and marks it as served.
+func (c *replayConn) nextReceived() (Frame, bool) {
+	sentBefore := 0
+	for i, frame := range c.frames {
+		if frame.Direction == FrameSent {
+			sentBefore++
+			continue
+		}
+		if i < c.next {
+			continue
+		}
+		if sentBefore > c.sent {
+			return Frame{}, false
+		}
+		c.next = i + 1
+		return frame, true
+	}
+	return Frame{}, false
+}
+
+func (c *replayConn) Write(_ context.Context, msg jsonrpc.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	got, err := messageKey(msg)
+	if err != nil {
+		return err
+	}
+	for ; c.nextSent < len(c.frames); c.nextSent++ {
+		if c.frames[c.nextSent].Direction == FrameSent {
+			break
+		}
+	}
+	if c.nextSent == len(c.frames) {
+		return fmt.Errorf("replay: unexpected message %s after the end of the recording", got)
+	}
+	recorded, err := jsonrpc.DecodeMessage(c.frames[c.nextSent].Message)
+	if err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	want, err := messageKey(recorded)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("replay: client sent %s, recording has %s", got, want)
+	}
+	c.nextSent++
+	c.sent++
+	close(c.changed)
+	c.changed = make(chan struct{})
+	return nil
+}
+
+// messageKey identifies a message by its method and ID, which do not change
+// between runs unlike e.g. the client version in the parameters.
+func messageKey(msg jsonrpc.Message) (string, error) {
+	switch msg := msg.(type) {
+	case *jsonrpc.Request:
+		if msg.ID.IsValid() {
+			return fmt.Sprintf("request %s (id %v)", msg.Method, msg.ID.Raw()), nil
+		}
+		return fmt.Sprintf("notification %s", msg.Method), nil
+	case *jsonrpc.Response:
+		return fmt.Sprintf("response (id %v)", msg.ID.Raw()), nil
+	default:
+		return "", fmt.Errorf("replay: unknown message type %T", msg)
+	}
+}
+
+func (c *replayConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *replayConn) SessionID() string {
+	return ""
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Dialects a tool schema may declare with $schema. MCP clients read schemas
+// without $schema as draft 2020-12. The TypeScript SDK declares draft-07 when
+// it converts zod 3 schemas, which is accepted as long as the schema only
+// uses keywords that mean the same in draft 2020-12.
+const (
+	draft202012 = "https://json-schema.org/draft/2020-12/schema"
+	draft07     = "http://json-schema.org/draft-07/schema#"
+)
+
+// CheckToolSchemas checks the input schema, and the output schema if it has
+// one, of the tool, and returns the resolved input schema.
+func CheckToolSchemas(tool *mcp.Tool) (*jsonschema.Resolved, error) {
+	input, err := checkSchema(tool.InputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("input schema of tool %s: %w", tool.Name, err)
+	}
+	if tool.OutputSchema != nil {
+		if _, err := checkSchema(tool.OutputSchema); err != nil {
+			return nil, fmt.Errorf("output schema of tool %s: %w", tool.Name, err)
+		}
+	}
+	return input, nil
+}
+
+// checkSchema checks that a tool schema is a well-formed draft 2020-12 schema
+// of an object and resolves it.
+func checkSchema(value any) (*jsonschema.Resolved, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("not a JSON schema: %w", err)
+	}
+	if schema.Schema != "" && schema.Schema != draft202012 && schema.Schema != draft07 {
+		return nil, fmt.Errorf("unsupported $schema %q", schema.Schema)
+	}
+	if schema.Type != "object" {
+		return nil, fmt.Errorf("type is %q, want \"object\"", schema.Type)
+	}
+	if problems := checkKeywords(&schema, "#"); len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// checkKeywords returns a problem per keyword of the schema, or of its
+// subschemas, that draft 2020-12 does not define or defines differently.
+func checkKeywords(s *jsonschema.Schema, at string) []string {
+	if s == nil {
+		return nil
+	}
+	var problems []string
+	for keyword := range s.Extra {
+		problems = append(problems, fmt.Sprintf("%s: unknown keyword %q", at, keyword))
+	}
+	if s.Definitions != nil {
+		problems = append(problems, fmt.Sprintf("%s: \"definitions\" was replaced by \"$defs\"", at))
+	}
+	if s.DependencySchemas != nil || s.DependencyStrings != nil {
+		problems = append(problems, fmt.Sprintf("%s: \"dependencies\" was replaced by \"dependentSchemas\" and \"dependentRequired\"", at))
+	}
+	if s.ItemsArray != nil {
+		problems = append(problems, fmt.Sprintf("%s: an array of \"items\" was replaced by \"prefixItems\"", at))
+	}
+	if s.AdditionalItems != nil {
+		problems = append(problems, fmt.Sprintf("%s: \"additionalItems\" was replaced by \"items\"", at))
+	}
+	for keyword, schemas := range map[string]map[string]*jsonschema.Schema{
+		"$defs":             s.Defs,
+		"properties":        s.Properties,
+		"patternProperties": s.PatternProperties,
+		"dependentSchemas":  s.DependentSchemas,
+	} {
+		for name, sub := range schemas {
+			problems = append(problems, checkKeywords(sub, at+"/"+keyword+"/"+name)...)
+		}
+	}
+	for keyword, schemas := range map[string][]*jsonschema.Schema{
+		"prefixItems": s.PrefixItems,
+		"allOf":       s.AllOf,
+		"anyOf":       s.AnyOf,
+		"oneOf":       s.OneOf,
+	} {
+		for i, sub := range schemas {
+			problems = append(problems, checkKeywords(sub, fmt.Sprintf("%s/%s/%d", at, keyword, i))...)
+		}
+	}
+	for keyword, sub := range map[string]*jsonschema.Schema{
+		"items":                 s.Items,
+		"contains":              s.Contains,
+		"unevaluatedItems":      s.UnevaluatedItems,
+		"additionalProperties":  s.AdditionalProperties,
+		"propertyNames":         s.PropertyNames,
+		"unevaluatedProperties": s.UnevaluatedProperties,
+		"not":                   s.Not,
+		"if":                    s.If,
+		"then":                  s.Then,
+		"else":                  s.Else,
+		"contentSchema":         s.ContentSchema,
+	} {
+		problems = append(problems, checkKeywords(sub, at+"/"+keyword)...)
+	}
+	return problems
+}
+
+// ValidateToolArgs checks the input schema of the tool and that args match
+// it. The schemas of the server are listed and checked once per session.
+func (s *Session) ValidateToolArgs(ctx context.Context, toolName string, args any) error {
+	schemas, err := s.inputSchemas(ctx)
+	if err != nil {
+		return err
+	}
+	schema, ok := schemas[toolName]
+	if !ok {
+		return fmt.Errorf("tool %s is not listed", toolName)
+	}
+	// The schema validates JSON values, e.g. map[string]any rather than a
+	// struct.
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return err
+	}
+	if instance == nil {
+		instance = map[string]any{}
+	}
+	if err := schema.Validate(instance); err != nil {
+		return fmt.Errorf("arguments of tool %s do not match its input schema: %w", toolName, err)
+	}
+	return nil
+}
+
+func (s *Session) inputSchemas(ctx context.Context) (map[string]*jsonschema.Resolved, error) {
+	s.mu.Lock()
+	schemas := s.schemas
+	s.mu.Unlock()
+	if schemas != nil {
+		return schemas, nil
+	}
+	tools, err := s.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schemas = make(map[string]*jsonschema.Resolved, len(tools))
+	for _, tool := range tools {
+		if schemas[tool.Name], err = CheckToolSchemas(tool); err != nil {
+			return nil, err
+		}
+	}
+	s.mu.Lock()
+	s.schemas = schemas
+	s.mu.Unlock()
+	return schemas, nil
+}
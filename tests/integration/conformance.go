@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"integration/client"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// toolKind is what the annotations of a tool say it may do at most.
+type toolKind string
+
+const (
+	readOnlyTool    toolKind = "read-only"
+	additiveTool    toolKind = "additive"
+	destructiveTool toolKind = "destructive"
+)
+
+// kindOf returns what annotations that are otherwise correct say a tool may do.
+func kindOf(annotations *mcp.ToolAnnotations) toolKind {
+	switch {
+	case annotations.ReadOnlyHint:
+		return readOnlyTool
+	case annotations.DestructiveHint != nil && !*annotations.DestructiveHint:
+		return additiveTool
+	default:
+		return destructiveTool
+	}
+}
+
+// annotatedServer is a server whose tool annotations are checked, with the
+// tools whose annotations clients rely on the most to skip or require a
+// confirmation.
+type annotatedServer struct {
+	cmd    []string
+	pinned map[string]toolKind
+}
+
+// annotatedServers returns the servers whose tool annotations are checked. A
+// remote server is a gcloud-mcp deployment, which annotates
+// run_gcloud_command as read-only if it was started with --read-only.
+func annotatedServers(remote *client.Remote, readOnly bool) []annotatedServer {
+	runGcloudCommand := destructiveTool
+	if readOnly {
+		runGcloudCommand = readOnlyTool
+	}
+	servers := []annotatedServer{
+		{[]string{"gcloud-mcp"}, map[string]toolKind{
+			"gcloud_context":     readOnlyTool,
+			"get_context":        readOnlyTool,
+			"list_instances":     readOnlyTool,
+			"explain_command":    readOnlyTool,
+			"search_assets":      readOnlyTool,
+			"run_gcloud_command": runGcloudCommand,
+			"cleanup_resources":  destructiveTool,
+			"undo_last_change":   destructiveTool,
+		}},
+	}
+	if remote != nil {
+		return servers
+	}
+	return append(servers,
+		annotatedServer{[]string{"storage-mcp"}, map[string]toolKind{
+			"list_buckets":        readOnlyTool,
+			"read_object_content": readOnlyTool,
+			"write_object_safe":   additiveTool,
+			"generate_signed_url": additiveTool,
+		}},
+		annotatedServer{[]string{"storage-mcp", "--enable-destructive-tools"}, map[string]toolKind{
+			"list_buckets":        readOnlyTool,
+			"delete_object":       destructiveTool,
+			"delete_bucket":       destructiveTool,
+			"write_object":        destructiveTool,
+			"generate_signed_url": destructiveTool,
+		}},
+		annotatedServer{[]string{"observability-mcp"}, map[string]toolKind{
+			"list_log_entries":  readOnlyTool,
+			"list_time_series":  readOnlyTool,
+			"watch_log_entries": additiveTool,
+		}},
+	)
+}
+
+// checkToolAnnotations returns a problem per tool without correct
+// annotations. Every tool must set readOnlyHint, a tool that is not read-only
+// must also set destructiveHint, and a read-only tool can not be destructive.
+func checkToolAnnotations(tools []*mcp.Tool, pinned map[string]toolKind) []string {
+	var problems []string
+	listed := map[string]bool{}
+	for _, tool := range tools {
+		listed[tool.Name] = true
+		annotations := tool.Annotations
+		switch {
+		case annotations == nil:
+			problems = append(problems, fmt.Sprintf("%s has no annotations", tool.Name))
+			continue
+		case annotations.ReadOnlyHint && annotations.DestructiveHint != nil && *annotations.DestructiveHint:
+			problems = append(problems, fmt.Sprintf("%s is both read-only and destructive", tool.Name))
+			continue
+		case !annotations.ReadOnlyHint && annotations.DestructiveHint == nil:
+			problems = append(problems, fmt.Sprintf("%s is not read-only and does not set destructiveHint", tool.Name))
+			continue
+		}
+		if want, ok := pinned[tool.Name]; ok && kindOf(annotations) != want {
+			problems = append(problems, fmt.Sprintf("%s is annotated as %s, want %s", tool.Name, kindOf(annotations), want))
+		}
+	}
+	for name := range pinned {
+		if !listed[name] {
+			problems = append(problems, fmt.Sprintf("%s is not listed", name))
+		}
+	}
+	return problems
+}
+
+// testToolAnnotations checks that every tool of every server carries
+// annotations that tell clients whether it is read-only or destructive.
+func testToolAnnotations(remote *client.Remote, readOnly bool, out, stderr io.Writer) error {
+	fmt.Fprintln(out, "🚀 Starting tool annotation conformance test...")
+	ctx := context.Background()
+	var failures []string
+	for _, server := range annotatedServers(remote, readOnly) {
+		name := strings.Join(server.cmd, " ")
+		session, err := client.NewSessionWithOptions(ctx, server.cmd, remote, client.SessionOptions{Stderr: stderr})
+		if err != nil {
+			return fmt.Errorf("error starting session of %s: %v", name, err)
+		}
+		tools, err := session.ListTools(ctx)
+		session.Close()
+		if err != nil {
+			return fmt.Errorf("error listing the tools of %s: %v", name, err)
+		}
+		if problems := checkToolAnnotations(tools, server.pinned); len(problems) > 0 {
+			failures = append(failures, fmt.Sprintf("%s: %d of %d tools are not annotated correctly:\n%s", name, len(problems), len(tools), strings.Join(problems, "\n")))
+			continue
+		}
+		fmt.Fprintf(out, "✅ Assertion passed: All %d tools of %s are annotated\n", len(tools), name)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("assertion failed: %s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// schemaSamples are arguments of the tools clients call the most that their
+// input schemas must accept, or reject.
+var schemaSamples = []struct {
+	tool  string
+	args  map[string]any
+	valid bool
+}{
+	{"run_gcloud_command", map[string]any{"args": []string{"config", "list", "--format=json"}}, true},
+	{"run_gcloud_command", map[string]any{"args": []string{"logging", "read", "severity>=ERROR AND resource.type=\"gce_instance\""}}, true},
+	{"run_gcloud_command", map[string]any{"args": "config list"}, false},
+	{"run_gcloud_command", map[string]any{}, false},
+	{"gcloud_context", map[string]any{}, true},
+}
+
+// testToolSchemas checks that the input and output schemas of every tool of
+// the server are well-formed draft 2020-12 schemas, and that they accept and
+// reject the sample arguments.
+func testToolSchemas(remote *client.Remote, out, stderr io.Writer) error {
+	fmt.Fprintln(out, "🚀 Starting gcloud-mcp tool schema conformance test...")
+	ctx := context.Background()
+	session, err := client.NewSessionWithOptions(ctx, []string{"gcloud-mcp"}, remote, client.SessionOptions{Stderr: stderr})
+	if err != nil {
+		return fmt.Errorf("error starting session: %v", err)
+	}
+	defer session.Close()
+
+	tools, err := session.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, tool := range tools {
+		if _, err := client.CheckToolSchemas(tool); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("assertion failed: %d of %d tools have invalid schemas:\n%s", len(problems), len(tools), strings.Join(problems, "\n"))
+	}
+	for _, sample := range schemaSamples {
+		err := session.ValidateToolArgs(ctx, sample.tool, sample.args)
+		switch {
+		case sample.valid && err != nil:
+			problems = append(problems, err.Error())
+		case !sample.valid && err == nil:
+			problems = append(problems, fmt.Sprintf("input schema of tool %s accepts invalid arguments %v", sample.tool, sample.args))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("assertion failed: %d of %d sample arguments were not validated correctly:\n%s", len(problems), len(schemaSamples), strings.Join(problems, "\n"))
+	}
+	fmt.Fprintf(out, "✅ Assertion passed: The schemas of all %d tools are valid\n", len(tools))
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// fakeFixturesEnv names the fixture file the fake gcloud replays. The harness
+// sets it for the servers it starts with -fake-gcloud.
+const fakeFixturesEnv = "GCLOUD_MCP_FAKE_FIXTURES"
+
+// fixtureFile is a JSON file of canned gcloud responses.
+type fixtureFile struct {
+	Fixtures []fixture `json:"fixtures"`
+}
+
+// fixture is the response of the fake gcloud to one exact argv, without the
+// gcloud binary itself. The lint of every command the server runs is a gcloud
+// call too, e.g. ["meta", "lint-gcloud-commands", "--command-string",
+// "gcloud config list --format=json"].
+type fixture struct {
+	Args     []string `json:"args"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exit_code,omitempty"`
+	// DelayMs makes the fake gcloud wait before it responds, e.g. to test
+	// that the server stops commands that run longer than their timeout.
+	DelayMs int `json:"delay_ms,omitempty"`
+	// Prefix matches every argv that starts with Args.
+	Prefix bool `json:"prefix,omitempty"`
+	// Echo writes the argv the fake gcloud received, as a JSON array, to
+	// stdout instead of Stdout, so that tests can compare it byte for byte
+	// with the arguments they sent.
+	Echo bool `json:"echo,omitempty"`
+}
+
+func (f fixture) matches(args []string) bool {
+	if f.Prefix {
+		return len(args) >= len(f.Args) && slices.Equal(f.Args, args[:len(f.Args)])
+	}
+	return slices.Equal(f.Args, args)
+}
+
+func loadFixtures(path string) ([]fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+	var file fixtureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures %s: %w", path, err)
+	}
+	return file.Fixtures, nil
+}
+
+// isFakeGcloud reports whether the harness was started as the fake gcloud,
+// i.e. through the gcloud link that installFakeGcloud creates.
+func isFakeGcloud() bool {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == "gcloud"
+}
+
+// runFakeGcloud replays the fixture of args and returns its exit code. Calls
+// without a fixture fail like an unknown gcloud command, so that tests notice
+// when the server passes different arguments than expected.
+func runFakeGcloud(args []string) int {
+	fixtures, err := loadFixtures(os.Getenv(fakeFixturesEnv))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: (gcloud) %v\n", err)
+		return 2
+	}
+	for _, f := range fixtures {
+		if f.matches(args) {
+			time.Sleep(time.Duration(f.DelayMs) * time.Millisecond)
+			if f.Echo {
+				received, _ := json.Marshal(args)
+				fmt.Fprintln(os.Stdout, string(received))
+			} else {
+				fmt.Fprint(os.Stdout, f.Stdout)
+			}
+			fmt.Fprint(os.Stderr, f.Stderr)
+			return f.ExitCode
+		}
+	}
+	quoted, _ := json.Marshal(args)
+	fmt.Fprintf(os.Stderr, "ERROR: (gcloud) no fixture for arguments %s\n", quoted)
+	return 2
+}
+
+// installFakeGcloud links the harness binary as gcloud into a temporary
+// directory and puts that directory first on the PATH, so that the servers
+// the harness starts from then on replay the fixtures at fixturesPath instead
+// of calling the real gcloud. The returned function removes the directory.
+func installFakeGcloud(fixturesPath string) (func(), error) {
+	fixturesPath, err := filepath.Abs(fixturesPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := loadFixtures(fixturesPath); err != nil {
+		return nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the harness binary: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "fake-gcloud-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	if err := os.Symlink(self, filepath.Join(dir, "gcloud"+filepath.Ext(self))); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to link the fake gcloud: %w", err)
+	}
+	os.Setenv(fakeFixturesEnv, fixturesPath)
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return cleanup, nil
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"integration/client"
+)
+
+// fuzzCommands are the read-only commands fuzzed argv start with. Everything
+// after them is generated.
+var fuzzCommands = [][]string{
+	{"logging", "read"},
+	{"compute", "instances", "list"},
+	{"config", "list"},
+}
+
+// fuzzFragments are the pieces generated arguments are made of: whitespace,
+// quotes, shell metacharacters, unicode and the --format and --filter
+// expressions gcloud users write.
+var fuzzFragments = []string{
+	"", " ", "  ", "\t", "\n", "'", "\"", "`", "\\", `\"`, "''",
+	"$(whoami)", "${HOME}", "$PATH", ";", "|", "&&", "||", ">", "<", ">>", "2>&1", "&",
+	"*", "?", "~", "#", "!", "%", "^", "=", ",", ":", "[", "]", "{", "}", "(", ")",
+	"é", "日本語", "🚀", "\u200b", "\u202e", "ß", "Ω", "\u00a0",
+	"--format=json(name,labels.env)",
+	`--format=value[separator=" "](name,zone.basename())`,
+	"--format=table[box](name:label=NAME)",
+	"--filter=labels.env:prod AND name~^vm-",
+	`--filter=name:"my vm" OR name:'other vm'`,
+	"severity>=ERROR",
+	`severity>=ERROR AND jsonPayload.message="disk full"`,
+	"--limit=1", "--", "--flag=", "-", "name", "vm-1",
+}
+
+// fuzzCorpus is a JSON file of argv that fuzzing always runs and mutates,
+// e.g. the argv of bugs found earlier.
+type fuzzCorpus struct {
+	Corpus [][]string `json:"corpus"`
+}
+
+func loadFuzzCorpus(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fuzz corpus: %w", err)
+	}
+	var corpus fuzzCorpus
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		return nil, fmt.Errorf("failed to parse fuzz corpus %s: %w", path, err)
+	}
+	for i, argv := range corpus.Corpus {
+		if fuzzCommandOf(argv) == nil {
+			return nil, fmt.Errorf("entry %d of fuzz corpus %s does not start with a fuzzed command", i+1, path)
+		}
+	}
+	return corpus.Corpus, nil
+}
+
+// fuzzCommandOf returns the fuzzed command argv starts with, or nil.
+func fuzzCommandOf(argv []string) []string {
+	for _, command := range fuzzCommands {
+		if len(argv) >= len(command) && slices.Equal(command, argv[:len(command)]) {
+			return command
+		}
+	}
+	return nil
+}
+
+// fuzzArgument returns one to three random fragments.
+func fuzzArgument(r *rand.Rand) string {
+	var arg strings.Builder
+	for range 1 + r.Intn(3) {
+		arg.WriteString(fuzzFragments[r.Intn(len(fuzzFragments))])
+	}
+	return arg.String()
+}
+
+// fuzzArgv returns a new argv: a corpus entry with a fragment inserted into
+// one of its arguments, or a fuzzed command with one to four random
+// arguments.
+func fuzzArgv(r *rand.Rand, corpus [][]string) []string {
+	if len(corpus) > 0 && r.Intn(2) == 0 {
+		argv := slices.Clone(corpus[r.Intn(len(corpus))])
+		i := len(fuzzCommandOf(argv))
+		if i == len(argv) {
+			return append(argv, fuzzArgument(r))
+		}
+		i += r.Intn(len(argv) - i)
+		// Insert at a rune boundary, so that the argument stays valid UTF-8 as
+		// JSON-RPC requires.
+		runes := []rune(argv[i])
+		at := r.Intn(len(runes) + 1)
+		argv[i] = string(runes[:at]) + fuzzArgument(r) + string(runes[at:])
+		return argv
+	}
+	argv := slices.Clone(fuzzCommands[r.Intn(len(fuzzCommands))])
+	for range 1 + r.Intn(4) {
+		argv = append(argv, fuzzArgument(r))
+	}
+	return argv
+}
+
+// fuzzFixtures returns fixtures under which every argv lints successfully and
+// gcloud echoes the argv it received.
+func fuzzFixtures(cases [][]string) fixtureFile {
+	file := fixtureFile{Fixtures: []fixture{
+		{Args: []string{"version", "--format=json"}, Stdout: `{"Google Cloud SDK": "499.0.0"}`},
+	}}
+	for _, command := range fuzzCommands {
+		file.Fixtures = append(file.Fixtures, fixture{Args: command, Prefix: true, Echo: true})
+	}
+	for _, argv := range cases {
+		lint, _ := json.Marshal([]map[string]any{{
+			"command_string_no_args": "gcloud " + strings.Join(fuzzCommandOf(argv), " "),
+			"success":                true,
+			"error_message":          nil,
+			"error_type":             nil,
+		}})
+		file.Fixtures = append(file.Fixtures, fixture{
+			Args:   []string{"meta", "lint-gcloud-commands", "--command-string", "gcloud " + strings.Join(argv, " ")},
+			Stdout: string(lint),
+		})
+	}
+	return file
+}
+
+// checkFuzzResult returns why the result of running argv does not show that
+// gcloud received exactly argv, if it does not.
+func checkFuzzResult(argv []string, output string) error {
+	var result toolResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return fmt.Errorf("error parsing MCP output: %v", err)
+	}
+	if result.IsError || len(result.Content) == 0 {
+		return fmt.Errorf("the command failed: %s", output)
+	}
+	// The echo comes first. Blocks the server appends, if any, follow it.
+	var received []string
+	if err := json.NewDecoder(strings.NewReader(result.Content[0].Text)).Decode(&received); err != nil {
+		return fmt.Errorf("gcloud did not echo its argv: %v\nOutput: %s", err, result.Content[0].Text)
+	}
+	if diff := argvDifference(argv, received); diff != "" {
+		return fmt.Errorf("gcloud received a different argv: %s", diff)
+	}
+	reported, err := jsonField(result.StructuredContent, "execution.argv")
+	if err != nil {
+		return fmt.Errorf("the result has no execution argv: %v", err)
+	}
+	if diff := argvDifference(argv, jsonStrings(reported)); diff != "" {
+		return fmt.Errorf("the execution metadata reports a different argv: %s", diff)
+	}
+	return nil
+}
+
+// argvDifference describes the first difference between two argv.
+func argvDifference(want, got []string) string {
+	for i := range max(len(want), len(got)) {
+		if i >= len(want) || i >= len(got) || want[i] != got[i] {
+			return fmt.Sprintf("argument %d differs\nsent:     %s\nreceived: %s", i, quoteArgv(want), quoteArgv(got))
+		}
+	}
+	return ""
+}
+
+// quoteArgv returns argv as a JSON array, with characters such as < and &
+// left as they are for readability.
+func quoteArgv(argv []string) string {
+	var quoted strings.Builder
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(argv)
+	return strings.TrimSuffix(quoted.String(), "\n")
+}
+
+// jsonStrings returns the strings of a JSON array, or nil if it is not one.
+func jsonStrings(value any) []string {
+	values, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil
+		}
+		strs = append(strs, s)
+	}
+	return strs
+}
+
+// runFuzz runs the corpus and the given number of generated argv through
+// run_gcloud_command of a server backed by a fake gcloud that echoes its
+// argv, and checks that gcloud received every argv unchanged. The seed makes
+// a run reproducible.
+func runFuzz(corpusPath string, count int, seed int64) error {
+	corpus, err := loadFuzzCorpus(corpusPath)
+	if err != nil {
+		return err
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	fmt.Printf("🚀 Fuzzing run_gcloud_command with %d corpus entries and %d generated argv (-seed=%d)...\n", len(corpus), count, seed)
+	r := rand.New(rand.NewSource(seed))
+	// The server caches read commands, so every argv must be new to run.
+	var cases [][]string
+	seen := map[string]bool{}
+	add := func(argv []string) {
+		if key := strings.Join(argv, "\x00"); !seen[key] {
+			seen[key] = true
+			cases = append(cases, argv)
+		}
+	}
+	for _, argv := range corpus {
+		add(argv)
+	}
+	for attempts := 0; len(cases) < len(corpus)+count && attempts < 100*count; attempts++ {
+		add(fuzzArgv(r, corpus))
+	}
+
+	dir, err := os.MkdirTemp("", "fuzz-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	fixtures, err := json.Marshal(fuzzFixtures(cases))
+	if err != nil {
+		return err
+	}
+	fixturesPath := filepath.Join(dir, "fixtures.json")
+	if err := os.WriteFile(fixturesPath, fixtures, 0o644); err != nil {
+		return err
+	}
+	cleanup, err := installFakeGcloud(fixturesPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	ctx := context.Background()
+	session, err := client.NewSession(ctx, []string{"gcloud-mcp"}, nil)
+	if err != nil {
+		return fmt.Errorf("error starting session: %v", err)
+	}
+	defer session.Close()
+
+	var failures [][]string
+	for _, argv := range cases {
+		output, err := session.CallTool(ctx, "run_gcloud_command", map[string]any{"args": argv})
+		if err == nil {
+			err = checkFuzzResult(argv, output)
+		}
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", quoteArgv(argv), err)
+			failures = append(failures, argv)
+		}
+	}
+	if len(failures) > 0 {
+		reproduce, _ := json.MarshalIndent(fuzzCorpus{Corpus: failures}, "", "  ")
+		fmt.Printf("Add the failing argv to %s to keep them as regression cases:\n%s\n", corpusPath, reproduce)
+		return fmt.Errorf("%d of %d argv were not passed to gcloud unchanged", len(failures), len(cases))
+	}
+	fmt.Printf("✅ Assertion passed: gcloud received all %d argv unchanged\n", len(cases))
+	return nil
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// runLabel is the label of the resources a run creates. Its value is the ID of
+// the run, which starts with the Unix time the run started, so resources left
+// over by a crashed run can be told apart from those of a run in progress.
+const runLabel = "gcloud-mcp-test-run"
+
+// resourcePrefix starts the names of the resources the harness creates, e.g.
+// gcloud-mcp-it-1760000000-0a1b2c3d-data for the resource named data.
+const resourcePrefix = "gcloud-mcp-it-"
+
+// resourceNamePattern keeps the full names within the 63 characters of a
+// bucket name.
+var resourceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,28}$`)
+
+// resourceSpec is a resource the cases of a spec file need to exist while
+// they run. Cases refer to its full name as {{<name>}} in their args and
+// assertions.
+type resourceSpec struct {
+	// Kind is bucket, topic or log_entry.
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Message is the text payload of a log_entry.
+	Message string `json:"message,omitempty"`
+}
+
+func (r resourceSpec) validate() error {
+	if _, ok := resourceKinds[r.Kind]; !ok {
+		return fmt.Errorf("unknown resource kind %q: use bucket, topic or log_entry", r.Kind)
+	}
+	if !resourceNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid resource name %q: use up to 29 lowercase letters, digits and dashes", r.Name)
+	}
+	if r.Kind == "log_entry" && r.Message == "" {
+		return fmt.Errorf("log_entry %q needs a message", r.Name)
+	}
+	return nil
+}
+
+// labeledResource is a resource of a run, found when looking for orphans.
+type labeledResource struct {
+	name  string
+	runID string
+}
+
+// resourceKind creates, deletes and lists the resources of a kind with gcloud.
+type resourceKind struct {
+	create func(ctx context.Context, project, name, runID string, spec resourceSpec) error
+	delete func(ctx context.Context, project, name string) error
+	// list returns the resources of every run in the project.
+	list func(ctx context.Context, project string) ([]labeledResource, error)
+}
+
+var resourceKinds = map[string]resourceKind{
+	"bucket": {
+		create: func(ctx context.Context, project, name, runID string, _ resourceSpec) error {
+			if _, err := gcloud(ctx, "storage", "buckets", "create", "gs://"+name, "--project="+project, "--location=us-central1", "--uniform-bucket-level-access"); err != nil {
+				return err
+			}
+			_, err := gcloud(ctx, "storage", "buckets", "update", "gs://"+name, "--update-labels="+runLabel+"="+runID)
+			return err
+		},
+		delete: func(ctx context.Context, _, name string) error {
+			_, err := gcloud(ctx, "storage", "rm", "--recursive", "gs://"+name)
+			return err
+		},
+		list: func(ctx context.Context, project string) ([]labeledResource, error) {
+			return listLabeled(ctx, "storage", "buckets", "list", "--project="+project, "--format=json(name,labels)")
+		},
+	},
+	"topic": {
+		create: func(ctx context.Context, project, name, runID string, _ resourceSpec) error {
+			_, err := gcloud(ctx, "pubsub", "topics", "create", name, "--project="+project, "--labels="+runLabel+"="+runID)
+			return err
+		},
+		delete: func(ctx context.Context, project, name string) error {
+			_, err := gcloud(ctx, "pubsub", "topics", "delete", name, "--project="+project, "--quiet")
+			return err
+		},
+		list: func(ctx context.Context, project string) ([]labeledResource, error) {
+			return listLabeled(ctx, "pubsub", "topics", "list", "--project="+project, "--format=json(name,labels)")
+		},
+	},
+	// Log entries can not be labeled or deleted one by one, so each gets a log
+	// of its own, whose name holds the run ID.
+	"log_entry": {
+		create: func(ctx context.Context, project, name, _ string, spec resourceSpec) error {
+			_, err := gcloud(ctx, "logging", "write", name, spec.Message, "--project="+project)
+			return err
+		},
+		delete: func(ctx context.Context, project, name string) error {
+			_, err := gcloud(ctx, "logging", "logs", "delete", name, "--project="+project, "--quiet")
+			return err
+		},
+		list: func(ctx context.Context, project string) ([]labeledResource, error) {
+			out, err := gcloud(ctx, "logging", "logs", "list", "--project="+project, "--format=json")
+			if err != nil {
+				return nil, err
+			}
+			var logs []string
+			if err := json.Unmarshal(out, &logs); err != nil {
+				return nil, fmt.Errorf("failed to parse the logs: %w", err)
+			}
+			var resources []labeledResource
+			for _, log := range logs {
+				name := path.Base(log)
+				if runID, ok := runIDOfName(name); ok {
+					resources = append(resources, labeledResource{name: name, runID: runID})
+				}
+			}
+			return resources, nil
+		},
+	},
+}
+
+// gcloud runs a gcloud command of the harness itself and returns its stdout.
+func gcloud(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gcloud", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("gcloud %s failed: %v: %s", strings.Join(args, " "), err, stderr.String())
+	}
+	return out, nil
+}
+
+// listLabeled lists the resources whose JSON has the run label.
+func listLabeled(ctx context.Context, args ...string) ([]labeledResource, error) {
+	out, err := gcloud(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var items []struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.Unmarshal(out, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse the resources: %w", err)
+	}
+	var resources []labeledResource
+	for _, item := range items {
+		if runID := item.Labels[runLabel]; runID != "" {
+			resources = append(resources, labeledResource{name: path.Base(item.Name), runID: runID})
+		}
+	}
+	return resources, nil
+}
+
+// newRunID returns the ID of a run that starts at now.
+func newRunID(now time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%d-%s", now.Unix(), hex.EncodeToString(suffix))
+}
+
+// runStart returns the time the run with the ID started.
+func runStart(runID string) (time.Time, bool) {
+	seconds, _, ok := strings.Cut(runID, "-")
+	if !ok {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+// runIDOfName returns the run ID in the name of a resource the harness created.
+func runIDOfName(name string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, resourcePrefix)
+	if !ok {
+		return "", false
+	}
+	parts := strings.SplitN(rest, "-", 3)
+	if len(parts) < 3 {
+		return "", false
+	}
+	runID := parts[0] + "-" + parts[1]
+	_, ok = runStart(runID)
+	return runID, ok
+}
+
+type createdResource struct {
+	kind string
+	name string
+}
+
+// gcpResources are the resources of one run in a project. Teardown deletes
+// them in reverse order of creation, at most once.
+type gcpResources struct {
+	project string
+	runID   string
+	// names maps the names of the specs to the full names of the resources.
+	names map[string]string
+
+	mu       sync.Mutex
+	created  []createdResource
+	tornDown bool
+}
+
+func newGCPResources(project string, now time.Time) *gcpResources {
+	return &gcpResources{project: project, runID: newRunID(now), names: map[string]string{}}
+}
+
+// create creates the resources in order. On failure, the caller still has to
+// tear down the resources that were created.
+func (r *gcpResources) create(ctx context.Context, specs []resourceSpec, out io.Writer) error {
+	for _, spec := range specs {
+		name := resourcePrefix + r.runID + "-" + spec.Name
+		if err := resourceKinds[spec.Kind].create(ctx, r.project, name, r.runID, spec); err != nil {
+			return fmt.Errorf("failed to create %s %s: %w", spec.Kind, name, err)
+		}
+		r.mu.Lock()
+		r.created = append(r.created, createdResource{kind: spec.Kind, name: name})
+		r.mu.Unlock()
+		r.names[spec.Name] = name
+		fmt.Fprintf(out, "🧱 Created %s %s\n", spec.Kind, name)
+	}
+	return nil
+}
+
+// teardown deletes the resources that were created and returns the errors of
+// those it could not delete. They are left to the orphan cleanup of a later
+// run.
+func (r *gcpResources) teardown(ctx context.Context, out io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tornDown {
+		return nil
+	}
+	r.tornDown = true
+	var errs []error
+	for i := len(r.created) - 1; i >= 0; i-- {
+		resource := r.created[i]
+		if err := resourceKinds[resource.kind].delete(ctx, r.project, resource.name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s %s: %w", resource.kind, resource.name, err))
+			continue
+		}
+		fmt.Fprintf(out, "🧹 Deleted %s %s\n", resource.kind, resource.name)
+	}
+	return errors.Join(errs...)
+}
+
+// substitutable are the fields of a case that can refer to resources.
+type substitutable struct {
+	Args        map[string]any `json:"args"`
+	Expect      []assertion    `json:"expect"`
+	ExpectError *expectedError `json:"expect_error"`
+}
+
+// substitute replaces the {{<name>}} references to resources in the args,
+// resource URI and assertions of a case with the full names of the resources.
+func (r *gcpResources) substitute(sc specCase) (specCase, error) {
+	replacements := make([]string, 0, 2*len(r.names))
+	for name, full := range r.names {
+		replacements = append(replacements, "{{"+name+"}}", full)
+	}
+	replacer := strings.NewReplacer(replacements...)
+	data, err := json.Marshal(substitutable{sc.Args, sc.Expect, sc.ExpectError})
+	if err != nil {
+		return sc, err
+	}
+	// Decoding into new values leaves the case that was passed in unchanged.
+	var fields substitutable
+	if err := json.Unmarshal([]byte(replacer.Replace(string(data))), &fields); err != nil {
+		return sc, fmt.Errorf("failed to substitute the resources of case %q: %w", sc.Name, err)
+	}
+	sc.Resource = replacer.Replace(sc.Resource)
+	sc.Args, sc.Expect, sc.ExpectError = fields.Args, fields.Expect, fields.ExpectError
+	return sc, nil
+}
+
+// cleanupOrphans deletes the resources of runs that started more than maxAge
+// before now, which a crashed run left behind. Younger resources may belong to
+// a run in progress and are kept.
+func cleanupOrphans(ctx context.Context, project string, maxAge time.Duration, now time.Time, out io.Writer) error {
+	var errs []error
+	for _, kind := range []string{"bucket", "topic", "log_entry"} {
+		resources, err := resourceKinds[kind].list(ctx, project)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list the %s resources of earlier runs: %w", kind, err))
+			continue
+		}
+		for _, resource := range resources {
+			start, ok := runStart(resource.runID)
+			if !ok || now.Sub(start) < maxAge {
+				continue
+			}
+			if err := resourceKinds[kind].delete(ctx, project, resource.name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete orphaned %s %s: %w", kind, resource.name, err))
+				continue
+			}
+			fmt.Fprintf(out, "🧹 Deleted orphaned %s %s of run %s\n", kind, resource.name, resource.runID)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// withResources deletes the orphans of earlier runs, creates the resources
+// of the specs and substitutes their names into the cases. The returned
+// teardown deletes the resources. They are also deleted if the run is
+// interrupted.
+func withResources(cases []specCase, specs []resourceSpec, opts specOptions) ([]specCase, func(), error) {
+	ctx := context.Background()
+	now := time.Now()
+	fmt.Printf("🧹 Deleting the resources of runs older than %s in project %s\n", opts.orphanAge, opts.project)
+	if err := cleanupOrphans(ctx, opts.project, opts.orphanAge, now, os.Stdout); err != nil {
+		// The orphans do not affect this run, so the next one can retry.
+		fmt.Printf("⚠️  %v\n", err)
+	}
+
+	resources := newGCPResources(opts.project, now)
+	teardown := func() {
+		if err := resources.teardown(ctx, os.Stdout); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Printf("🛑 %v: deleting the resources of run %s\n", sig, resources.runID)
+			teardown()
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	stop := func() {
+		signal.Stop(signals)
+		close(done)
+		teardown()
+	}
+
+	fmt.Printf("🧱 Creating the resources of run %s in project %s\n", resources.runID, opts.project)
+	if err := resources.create(ctx, specs, os.Stdout); err != nil {
+		stop()
+		return nil, nil, err
+	}
+	substituted := make([]specCase, 0, len(cases))
+	for _, sc := range cases {
+		sc, err := resources.substitute(sc)
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		substituted = append(substituted, sc)
+	}
+	return substituted, stop, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// goldenDir holds the golden files of spec cases that set golden.
+const goldenDir = "testdata"
+
+// volatileFields are replaced before results are compared, because they
+// differ on every run.
+var volatileFields = []struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}{
+	// RFC 3339 timestamps, e.g. 2025-01-01T00:00:00.123Z or 2025-01-01T00:00:00+00:00.
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?`), "<TIMESTAMP>"},
+	// Long-running operation IDs, e.g. operation-1700000000000-5f1a2b3c-... .
+	{regexp.MustCompile(`operation-\d+-[0-9a-f]+(-[0-9a-f]+)*`), "<OPERATION_ID>"},
+	// The run ID in the names of the resources created for the specs.
+	{regexp.MustCompile(`gcloud-mcp-it-\d+-[0-9a-f]{8}`), "gcloud-mcp-it-<RUN_ID>"},
+	{regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`), "<UUID>"},
+	// The execution time of gcloud in the execution metadata of run_gcloud_command.
+	{regexp.MustCompile(`"durationMs": \d+`), `"durationMs": "<DURATION>"`},
+}
+
+func goldenPath(name string) string {
+	return filepath.Join(goldenDir, name+".golden.json")
+}
+
+// normalizeResult re-indents a tool result and replaces its volatile fields.
+func normalizeResult(output string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(output), "", "  "); err != nil {
+		return "", fmt.Errorf("tool result is not JSON: %v", err)
+	}
+	normalized := buf.String()
+	for _, field := range volatileFields {
+		normalized = field.pattern.ReplaceAllString(normalized, field.placeholder)
+	}
+	return normalized + "\n", nil
+}
+
+// firstDifference describes the first line where two texts differ.
+func firstDifference(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return ""
+}
+
+// checkGolden compares a tool result with the golden file of the case, or
+// writes the golden file if update is set or the file does not exist yet.
+func checkGolden(name, output string, update bool, out io.Writer) error {
+	got, err := normalizeResult(output)
+	if err != nil {
+		return err
+	}
+	path := goldenPath(name)
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !update {
+		return fmt.Errorf("golden file %s does not exist. Run with -update to create it", path)
+	}
+	if update {
+		if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", goldenDir, err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			return fmt.Errorf("failed to write golden file %s: %w", path, err)
+		}
+		fmt.Fprintf(out, "📝 Updated %s\n", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read golden file %s: %w", path, err)
+	}
+	if diff := firstDifference(string(want), got); diff != "" {
+		return fmt.Errorf("result differs from %s at %s\nRun with -update to accept the new result", path, diff)
+	}
+	return nil
+}
@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"integration/client"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-func testGeminiMcpList() error {
-	fmt.Println("🚀 Starting gcloud-mcp integration test...")
+func testGeminiMcpList(out, _ io.Writer) error {
+	fmt.Fprintln(out, "🚀 Starting gcloud-mcp integration test...")
 
 	cmd := exec.Command("gemini", "--debug", "mcp", "list")
 	output, err := cmd.CombinedOutput()
@@ -19,8 +25,8 @@ func testGeminiMcpList() error {
 		return fmt.Errorf("error executing command: %v\nOutput:\n%s", err, string(output))
 	}
 
-	fmt.Println("Command output:")
-	fmt.Println(string(output))
+	fmt.Fprintln(out, "Command output:")
+	fmt.Fprintln(out, string(output))
 
 	expectedMCPServers := map[string]string{
 		"gcloud":        "gcloud-mcp",
@@ -37,32 +43,28 @@ func testGeminiMcpList() error {
 		if !matched {
 			return fmt.Errorf("assertion failed: output did not contain the connected %s server line. Expected regex: %s, Output: %s", serverName, expectedRegexMatch, string(output))
 		}
-		fmt.Printf("✅ Assertion passed: Output regex matched the connected %s server line.\n", serverName)
+		fmt.Fprintf(out, "✅ Assertion passed: Output regex matched the connected %s server line.\n", serverName)
 	}
 	return nil
 }
 
-func testCallGcloudMCPTool() error {
-	fmt.Println("🚀 Starting gcloud-mcp tool call integration test...")
+func testCallGcloudMCPTool(remote *client.Remote, out, stderr io.Writer) error {
+	fmt.Fprintln(out, "🚀 Starting gcloud-mcp tool call integration test...")
 	gcloudToolCall := client.ToolCall{
 		ServerCmd: []string{"gcloud-mcp"},
+		Remote:    remote,
 		ToolName:  "run_gcloud_command",
 		ToolArgs: map[string]any{
 			"args": []string{"config", "list", "--format=json"},
 		},
+		Options: client.SessionOptions{Stderr: stderr},
 	}
 
 	output, err := client.InvokeMCPTool(gcloudToolCall)
 	if err != nil {
 		return fmt.Errorf("error executing command: %v\nOutput:\n%s", err, string(output))
 	}
-	type mcpOutput struct {
-		Content []struct {
-			Text string `json:"text"`
-		} `json:"content"`
-	}
-
-	var parsedOutput mcpOutput
+	var parsedOutput toolResult
 	if err := json.Unmarshal([]byte(output), &parsedOutput); err != nil {
 		return fmt.Errorf("error parsing MCP output: %v\nOutput: %s", err, output)
 	}
@@ -88,26 +90,411 @@ func testCallGcloudMCPTool() error {
 		return fmt.Errorf("error parsing gcloud config from MCP output: %v\nOutput: %s", err, parsedText)
 	}
 
-	if config.Core.Project == "gcloud-mcp-testing" {
-		fmt.Printf("✅ Assertion passed: Tool call was successful\n")
-		return nil
+	if config.Core.Project != "gcloud-mcp-testing" {
+		return fmt.Errorf("assertion failed: Tool call was not successful. Tool call content: %s", output)
+	}
+	fmt.Fprintf(out, "✅ Assertion passed: Tool call was successful\n")
+
+	// The structured content holds the same JSON, already parsed.
+	if err := fieldEquals(parsedOutput.StructuredContent, "json.core.project", json.RawMessage(`"gcloud-mcp-testing"`)); err != nil {
+		return fmt.Errorf("assertion failed: structured content: %v. Tool call content: %s", err, output)
+	}
+	fmt.Fprintf(out, "✅ Assertion passed: Structured content matched the text content\n")
+	return nil
+}
+
+// testSessionContext checks that the project selected with set_context applies
+// to later commands of the same session.
+func testSessionContext(remote *client.Remote, out, stderr io.Writer) error {
+	fmt.Fprintln(out, "🚀 Starting gcloud-mcp session context integration test...")
+	const project = "gcloud-mcp-session-test"
+	ctx := context.Background()
+	session, err := client.NewSessionWithOptions(ctx, []string{"gcloud-mcp"}, remote, client.SessionOptions{Stderr: stderr})
+	if err != nil {
+		return fmt.Errorf("error starting session: %v", err)
+	}
+	defer session.Close()
+
+	if _, err := session.CallTool(ctx, "set_context", map[string]any{"project": project}); err != nil {
+		return fmt.Errorf("error setting the session project: %v", err)
+	}
+	output, err := session.CallTool(ctx, "run_gcloud_command", map[string]any{
+		"args": []string{"config", "get-value", "project"},
+	})
+	if err != nil {
+		return fmt.Errorf("error executing command: %v\nOutput:\n%s", err, output)
+	}
+	var result toolResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return fmt.Errorf("error parsing MCP output: %v\nOutput: %s", err, output)
+	}
+	if len(result.Content) == 0 || !strings.HasPrefix(strings.TrimSpace(result.Content[0].Text), project) {
+		return fmt.Errorf("assertion failed: the session project was not used. Tool call content: %s", output)
+	}
+	fmt.Fprintf(out, "✅ Assertion passed: The session project applied to the next command\n")
+	return nil
+}
+
+// testProgressNotifications checks that gcloud's stderr lines are streamed as
+// progress notifications when the client passes a progress token.
+func testProgressNotifications(remote *client.Remote, out, stderr io.Writer) error {
+	fmt.Fprintln(out, "🚀 Starting gcloud-mcp progress notification integration test...")
+	ctx := context.Background()
+	session, err := client.NewSessionWithOptions(ctx, []string{"gcloud-mcp"}, remote, client.SessionOptions{Stderr: stderr})
+	if err != nil {
+		return fmt.Errorf("error starting session: %v", err)
+	}
+	defer session.Close()
+
+	// gcloud config list writes the active configuration to stderr.
+	output, events, err := session.CallToolWithProgress(ctx, "run_gcloud_command", map[string]any{
+		"args": []string{"config", "list"},
+	})
+	if err != nil {
+		return fmt.Errorf("error executing command: %v\nOutput:\n%s", err, output)
+	}
+	for i, event := range events {
+		if i > 0 && event.Progress <= events[i-1].Progress {
+			return fmt.Errorf("assertion failed: progress did not increase: %+v", events)
+		}
+	}
+	for _, event := range events {
+		if strings.Contains(event.Message, "active configuration") {
+			fmt.Fprintf(out, "✅ Assertion passed: Received %d progress notifications\n", len(events))
+			return nil
+		}
+	}
+	return fmt.Errorf("assertion failed: no progress notification reported the active configuration. Events: %+v", events)
+}
+
+// testMutationConfirmation checks that a server started with
+// --confirm-mutations asks the client to confirm a command that changes state,
+// and only runs it if the user accepts.
+func testMutationConfirmation(out, stderr io.Writer) error {
+	fmt.Fprintln(out, "🚀 Starting gcloud-mcp mutation confirmation integration test...")
+	ctx := context.Background()
+	accept := false
+	var messages []string
+	session, err := client.NewSessionWithOptions(ctx, []string{"gcloud-mcp", "--confirm-mutations"}, nil, client.SessionOptions{
+		Stderr: stderr,
+		ElicitationHandler: func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			messages = append(messages, req.Params.Message)
+			if !accept {
+				return &mcp.ElicitResult{Action: "decline"}, nil
+			}
+			return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"confirm": true}}, nil
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error starting session: %v", err)
+	}
+	defer session.Close()
+
+	callSet := func(project string) (toolResult, error) {
+		output, err := session.CallTool(ctx, "run_gcloud_command", map[string]any{
+			"args": []string{"config", "set", "core/project", project},
+		})
+		if err != nil {
+			return toolResult{}, fmt.Errorf("error executing command: %v\nOutput:\n%s", err, output)
+		}
+		var result toolResult
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			return toolResult{}, fmt.Errorf("error parsing MCP output: %v\nOutput: %s", err, output)
+		}
+		return result, nil
+	}
+
+	declined, err := callSet("gcloud-mcp-declined")
+	if err != nil {
+		return err
+	}
+	if !declined.IsError || len(declined.Content) == 0 || !strings.Contains(declined.Content[0].Text, "MUTATION_DECLINED") {
+		return fmt.Errorf("assertion failed: the declined command was not rejected: %+v", declined)
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "config set core/project gcloud-mcp-declined") {
+		return fmt.Errorf("assertion failed: expected one elicitation for the command, got %q", messages)
+	}
+	fmt.Fprintf(out, "✅ Assertion passed: The declined command was not run\n")
+
+	// Setting the project the tests already use leaves the configuration unchanged.
+	accept = true
+	accepted, err := callSet("gcloud-mcp-testing")
+	if err != nil {
+		return err
+	}
+	if accepted.IsError || len(messages) != 2 {
+		return fmt.Errorf("assertion failed: the confirmed command did not run: %+v", accepted)
+	}
+	fmt.Fprintf(out, "✅ Assertion passed: The confirmed command ran\n")
+	return nil
+}
+
+// testRemoteAuthentication checks the OAuth flow of a remote server: an
+// unauthenticated request is challenged with the protected resource metadata,
+// a forged token is rejected, and the token of the harness opens a session
+// whose commands run.
+func testRemoteAuthentication(remote *client.Remote, out, stderr io.Writer) error {
+	fmt.Fprintln(out, "🚀 Starting gcloud-mcp remote authentication integration test...")
+	ctx := context.Background()
+	metadata, err := client.DiscoverProtectedResource(ctx, remote.URL)
+	if err != nil {
+		return fmt.Errorf("assertion failed: %v", err)
+	}
+	if len(metadata.AuthorizationServers) == 0 {
+		return fmt.Errorf("assertion failed: the protected resource metadata names no authorization server: %+v", metadata)
+	}
+	fmt.Fprintf(out, "✅ Assertion passed: Tokens are issued by %s\n", metadata.AuthorizationServers[0])
+
+	forged := *remote
+	forged.AuthToken = "forged"
+	if session, err := client.NewSessionWithOptions(ctx, nil, &forged, client.SessionOptions{Stderr: stderr}); err == nil {
+		session.Close()
+		return fmt.Errorf("assertion failed: a session was opened with a forged token")
 	}
+	fmt.Fprintf(out, "✅ Assertion passed: A forged token was rejected\n")
 
-	return fmt.Errorf("assertion failed: Tool call was not successful. Tool call content: %s", output)
+	session, err := client.NewSessionWithOptions(ctx, nil, remote, client.SessionOptions{Stderr: stderr})
+	if err != nil {
+		return fmt.Errorf("error starting an authenticated session: %v", err)
+	}
+	defer session.Close()
+	output, err := session.CallTool(ctx, "gcloud_context", map[string]any{})
+	if err != nil {
+		return fmt.Errorf("error executing command: %v\nOutput:\n%s", err, output)
+	}
+	var result toolResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return fmt.Errorf("error parsing MCP output: %v\nOutput: %s", err, output)
+	}
+	if result.IsError {
+		return fmt.Errorf("assertion failed: gcloud_context failed in the authenticated session. Tool call content: %s", output)
+	}
+	fmt.Fprintf(out, "✅ Assertion passed: The authenticated session ran a command\n")
+	return nil
+}
+
+// headerFlags collects repeated -header "Name: value" flags.
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	return fmt.Sprint(map[string]string(h))
+}
+
+func (h headerFlags) Set(value string) error {
+	name, headerValue, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid header %q: use \"Name: value\"", value)
+	}
+	h[strings.TrimSpace(name)] = strings.TrimSpace(headerValue)
+	return nil
+}
+
+func run(specsPath string, opts specOptions, ropts runnerOptions) int {
+	remote := opts.remote
+	tests := []testCase{
+		{name: "call_gcloud_mcp_tool", run: func(out, stderr io.Writer) error { return testCallGcloudMCPTool(remote, out, stderr) }},
+		{name: "session_context", run: func(out, stderr io.Writer) error { return testSessionContext(remote, out, stderr) }},
+		{name: "progress_notifications", run: func(out, stderr io.Writer) error { return testProgressNotifications(remote, out, stderr) }},
+		{name: "tool_annotations", run: func(out, stderr io.Writer) error { return testToolAnnotations(remote, opts.readOnly, out, stderr) }},
+		{name: "tool_schemas", run: func(out, stderr io.Writer) error { return testToolSchemas(remote, out, stderr) }},
+	}
+	if remote != nil {
+		// The Gemini CLI configuration only lists local servers, and the flags
+		// of a remote server are set by its deployment.
+		fmt.Printf("⏭️  Skipping the gemini mcp list and mutation confirmation tests for remote server %s\n", remote.URL)
+		if remote.OAuth && remote.AuthToken != "" {
+			tests = append(tests, testCase{name: "remote_authentication", run: func(out, stderr io.Writer) error { return testRemoteAuthentication(remote, out, stderr) }})
+		}
+	} else {
+		tests = append([]testCase{{name: "gemini_mcp_list", run: testGeminiMcpList}}, tests...)
+		tests = append(tests, testCase{name: "mutation_confirmation", run: testMutationConfirmation})
+	}
+	if specsPath != "" {
+		cases, resources, err := loadSpecs(specsPath)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		if len(resources) > 0 {
+			var teardown func()
+			if cases, teardown, err = withResources(cases, resources, opts); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				return 1
+			}
+			defer teardown()
+		}
+		tests = append(tests, specTests(cases, opts)...)
+	}
+	if !runTests(tests, ropts) {
+		return 1
+	}
+	return 0
 }
 
-func run() int {
-	if err := testGeminiMcpList(); err != nil {
+// runFake runs the spec cases against servers that call a fake gcloud, which
+// replays the fixtures at fixturesPath. The built-in tests are skipped because
+// they need a real gcloud and test project, except for the conformance checks
+// of the tool annotations and schemas, which do not run gcloud.
+func runFake(fixturesPath, specsPath string, opts specOptions, ropts runnerOptions) int {
+	cleanup, err := installFakeGcloud(fixturesPath)
+	if err != nil {
 		fmt.Printf("❌ %v\n", err)
 		return 1
 	}
-	if err := testCallGcloudMCPTool(); err != nil {
+	defer cleanup()
+	fmt.Printf("🚀 Using the fake gcloud with fixtures %s\n", fixturesPath)
+	conformance := []testCase{
+		{name: "tool_annotations", run: func(out, stderr io.Writer) error { return testToolAnnotations(nil, false, out, stderr) }},
+		{name: "tool_schemas", run: func(out, stderr io.Writer) error { return testToolSchemas(nil, out, stderr) }},
+	}
+	return runSpecsOnly(specsPath, opts, ropts, conformance...)
+}
+
+// runSpecsOnly runs the spec cases, after the given tests, without the
+// built-in tests.
+func runSpecsOnly(specsPath string, opts specOptions, ropts runnerOptions, tests ...testCase) int {
+	if specsPath == "" {
+		fmt.Println("❌ -fake-gcloud and -replay need -specs")
+		return 1
+	}
+	cases, resources, err := loadSpecs(specsPath)
+	if err != nil {
 		fmt.Printf("❌ %v\n", err)
 		return 1
 	}
+	if len(resources) > 0 {
+		fmt.Println("❌ specs with resources need a real gcloud and test project: they can not run with -fake-gcloud or -replay")
+		return 1
+	}
+	if !runTests(append(tests, specTests(cases, opts)...), ropts) {
+		return 1
+	}
 	return 0
 }
 
 func main() {
-	os.Exit(run())
+	if isFakeGcloud() {
+		os.Exit(runFakeGcloud(os.Args[1:]))
+	}
+
+	mode := flag.String("mode", "test", "Harness mode: test, bench, diff, fuzz, stress or cleanup.")
+	project := flag.String("project", "gcloud-mcp-testing", "Designated test project used by bench mode and for the resources of the specs.")
+	orphanAge := flag.Duration("orphan-age", 3*time.Hour, "Age after which the resources of an earlier run are deleted as orphans, in test and cleanup mode.")
+	iterations := flag.Int("iterations", 10, "Number of calls per benchmark case, and of handshakes.")
+	benchCases := flag.String("bench-cases", "", "Comma-separated names of the benchmark cases to run, e.g. config_list,logging_read. Defaults to all.")
+	baseline := flag.String("baseline", "", "Bench mode JSON report of an earlier run to compare with. Regressions fail the run.")
+	threshold := flag.Float64("threshold", 20, "Percent by which a latency or output size may exceed the baseline before it is a regression.")
+	out := flag.String("out", "", "Path of the bench mode JSON report, or of the diff mode JSON diff. Defaults to stdout.")
+	base := flag.String("base", "", "Command of the base server of diff mode, e.g. \"npx -y @google-cloud/gcloud-mcp\" for the released version.")
+	head := flag.String("head", "gcloud-mcp", "Command of the head server of diff mode, unless -url is set.")
+	url := flag.String("url", "", "MCP endpoint of a remote server to test instead of starting gcloud-mcp locally.")
+	transport := flag.String("transport", client.TransportStreamable, "Transport of the remote server: streamable or sse.")
+	token := flag.String("token", os.Getenv("GCLOUD_MCP_AUTH_TOKEN"), "Bearer token for the remote server. Defaults to $GCLOUD_MCP_AUTH_TOKEN.")
+	obtainToken := flag.Bool("obtain-token", false, "Obtain an identity token for the remote server with gcloud auth print-identity-token if -token is not set.")
+	oauth := flag.Bool("oauth", false, "The remote server verifies OAuth bearer tokens itself (\"auth\": \"oidc\"). Also runs the authentication flow test.")
+	readOnly := flag.Bool("read-only", false, "The remote server was started with --read-only, so tools that run any gcloud command are annotated as read-only.")
+	tokenAudience := flag.String("token-audience", "", "Audience of the identity token obtained with -obtain-token. Only supported for service accounts.")
+	specs := flag.String("specs", "", "JSON spec file, or directory of spec files, of extra test cases to run in test mode.")
+	update := flag.Bool("update", false, "Rewrite the golden files of spec cases instead of comparing results with them.")
+	parallel := flag.Int("parallel", 1, "Number of tests to run at the same time in test mode.")
+	fakeGcloud := flag.String("fake-gcloud", "", "Fixture file of canned gcloud responses. If set, test mode starts the servers with a fake gcloud that replays them and only runs the specs.")
+	report := flag.String("report", "", "Path of a JUnit XML report of the test mode results.")
+	reportJSON := flag.String("report-json", "", "Path of a JSON report of the test mode results.")
+	record := flag.String("record", "", "Directory to record the MCP traffic of each spec case to, as <name>.jsonl.")
+	replay := flag.String("replay", "", "Directory of recordings to replay the spec cases from instead of starting servers. Only runs the specs.")
+	corpus := flag.String("corpus", "testdata/fuzz_corpus.json", "Corpus of argv that fuzz mode always runs and mutates.")
+	fuzzCases := flag.Int("fuzz-cases", 100, "Number of argv fuzz mode generates in addition to the corpus.")
+	seed := flag.Int64("seed", 0, "Seed of the argv fuzz mode generates, to reproduce a run. Random if 0.")
+	calls := flag.Int("calls", 20, "Number of concurrent calls per session in stress mode.")
+	sessions := flag.Int("sessions", 5, "Number of parallel sessions in stress mode.")
+	callTimeout := flag.Duration("call-timeout", time.Minute, "Time after which stress mode counts the response to a call as dropped.")
+	headers := headerFlags{}
+	flag.Var(headers, "header", "Header to send to the remote server, as \"Name: value\". May be repeated.")
+	flag.Parse()
+
+	var remote *client.Remote
+	if *url != "" {
+		if *token == "" && *obtainToken {
+			obtained, err := client.IdentityToken(context.Background(), *tokenAudience)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			*token = obtained
+		}
+		remote = &client.Remote{URL: *url, Transport: *transport, Headers: headers, AuthToken: *token, OAuth: *oauth}
+	}
+
+	if *readOnly && remote == nil {
+		fmt.Println("❌ -read-only needs -url: local servers are started without --read-only")
+		os.Exit(2)
+	}
+
+	switch *mode {
+	case "test":
+		opts := specOptions{remote: remote, update: *update, recordDir: *record, replayDir: *replay, project: *project, orphanAge: *orphanAge, readOnly: *readOnly}
+		ropts := runnerOptions{parallel: *parallel, junitPath: *report, jsonPath: *reportJSON}
+		if *record != "" {
+			if err := os.MkdirAll(*record, 0o755); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+		}
+		switch {
+		case *replay != "":
+			if *fakeGcloud != "" || remote != nil || *record != "" {
+				fmt.Println("❌ -replay can not be used with -fake-gcloud, -url or -record")
+				os.Exit(2)
+			}
+			os.Exit(runSpecsOnly(*specs, opts, ropts))
+		case *fakeGcloud != "":
+			if remote != nil {
+				fmt.Println("❌ -fake-gcloud can not be used with a remote server")
+				os.Exit(2)
+			}
+			os.Exit(runFake(*fakeGcloud, *specs, opts, ropts))
+		default:
+			os.Exit(run(*specs, opts, ropts))
+		}
+	case "bench":
+		opts := benchOptions{
+			project:          *project,
+			iterations:       *iterations,
+			outPath:          *out,
+			remote:           remote,
+			baselinePath:     *baseline,
+			thresholdPercent: *threshold,
+		}
+		if *benchCases != "" {
+			opts.cases = strings.Split(*benchCases, ",")
+		}
+		if err := runBench(opts); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	case "diff":
+		if err := runDiff(*base, *head, remote, *out); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	case "fuzz":
+		if err := runFuzz(*corpus, *fuzzCases, *seed); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	case "stress":
+		opts := stressOptions{calls: *calls, sessions: *sessions, timeout: *callTimeout, remote: remote}
+		if err := runStress(opts); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	case "cleanup":
+		if err := cleanupOrphans(context.Background(), *project, *orphanAge, time.Now(), os.Stdout); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("❌ unknown mode %q\n", *mode)
+		os.Exit(2)
+	}
 }
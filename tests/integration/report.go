@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+// junitTestSuites is the root element of a JUnit XML report, in the format
+// read by CI systems such as Jenkins and GitLab.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	// FlakyFailures are the failed attempts of a test that passed on retry,
+	// in the rerun format of Maven Surefire that Jenkins understands.
+	FlakyFailures []junitFailure `xml:"flakyFailure,omitempty"`
+	// Skipped is set for quarantined tests that failed.
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// jsonReport is the JSON report of a test run. Unlike the JUnit report, its
+// shape is owned by this harness, so dashboards can rely on every field.
+type jsonReport struct {
+	Timestamp  string  `json:"timestamp"`
+	DurationMs float64 `json:"duration_ms"`
+	Passed     int     `json:"passed"`
+	// PassedOnRetry counts the passed tests that failed before they passed.
+	PassedOnRetry int `json:"passed_on_retry"`
+	// Failed counts the failed tests that are not quarantined.
+	Failed int `json:"failed"`
+	// Quarantined counts the failed tests that are quarantined.
+	Quarantined int              `json:"quarantined"`
+	Tests       []jsonTestResult `json:"tests"`
+}
+
+type jsonTestResult struct {
+	Name       string  `json:"name"`
+	Passed     bool    `json:"passed"`
+	Start      string  `json:"start"`
+	DurationMs float64 `json:"duration_ms"`
+	Failure    string  `json:"failure,omitempty"`
+	// Attempts is the number of times the test ran.
+	Attempts      int  `json:"attempts"`
+	PassedOnRetry bool `json:"passed_on_retry"`
+	// RetriedFailures are the errors of the attempts before the last one.
+	RetriedFailures []string `json:"retried_failures,omitempty"`
+	Quarantine      string   `json:"quarantine,omitempty"`
+	Stdout          string   `json:"stdout"`
+	Stderr          string   `json:"stderr"`
+}
+
+// writeReports writes the reports selected in opts. The stdout of a test is
+// its log and its stderr is that of the servers it started.
+func writeReports(results []*testResult, start time.Time, elapsed time.Duration, opts runnerOptions) error {
+	if opts.junitPath != "" {
+		if err := writeJUnitReport(opts.junitPath, results, start, elapsed); err != nil {
+			return err
+		}
+		fmt.Printf("📝 Wrote JUnit report to %s\n", opts.junitPath)
+	}
+	if opts.jsonPath != "" {
+		if err := writeJSONReport(opts.jsonPath, results, start, elapsed); err != nil {
+			return err
+		}
+		fmt.Printf("📝 Wrote JSON report to %s\n", opts.jsonPath)
+	}
+	return nil
+}
+
+func writeJUnitReport(path string, results []*testResult, start time.Time, elapsed time.Duration) error {
+	suite := junitTestSuite{
+		Name:      "integration",
+		Tests:     len(results),
+		Time:      seconds(elapsed),
+		Timestamp: start.UTC().Format(time.RFC3339),
+	}
+	for _, result := range results {
+		tc := junitTestCase{
+			Name:      result.name,
+			ClassName: "integration",
+			Time:      seconds(result.duration),
+			SystemOut: result.output.String(),
+			SystemErr: result.stderr.String(),
+		}
+		switch {
+		case result.failsRun():
+			suite.Failures++
+			tc.Failure = &junitFailure{Message: result.err.Error(), Text: result.err.Error()}
+		case result.err != nil:
+			suite.Skipped++
+			tc.Skipped = &junitSkipped{Message: fmt.Sprintf("quarantined (%s): %v", result.quarantine, result.err)}
+		}
+		if result.passedOnRetry() {
+			for _, err := range result.retried {
+				tc.FlakyFailures = append(tc.FlakyFailures, junitFailure{Message: err.Error(), Text: err.Error()})
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
+
+func writeJSONReport(path string, results []*testResult, start time.Time, elapsed time.Duration) error {
+	report := jsonReport{
+		Timestamp:  start.UTC().Format(time.RFC3339),
+		DurationMs: toMillis(elapsed),
+		Tests:      make([]jsonTestResult, 0, len(results)),
+	}
+	for _, result := range results {
+		tr := jsonTestResult{
+			Name:          result.name,
+			Passed:        result.err == nil,
+			Start:         result.start.UTC().Format(time.RFC3339Nano),
+			DurationMs:    toMillis(result.duration),
+			Attempts:      len(result.retried) + 1,
+			PassedOnRetry: result.passedOnRetry(),
+			Quarantine:    result.quarantine,
+			Stdout:        result.output.String(),
+			Stderr:        result.stderr.String(),
+		}
+		for _, err := range result.retried {
+			tr.RetriedFailures = append(tr.RetriedFailures, err.Error())
+		}
+		switch {
+		case result.failsRun():
+			report.Failed++
+		case result.err != nil:
+			report.Quarantined++
+		default:
+			report.Passed++
+		}
+		if result.err != nil {
+			tr.Failure = result.err.Error()
+		}
+		if result.passedOnRetry() {
+			report.PassedOnRetry++
+		}
+		report.Tests = append(report.Tests, tr)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+	return nil
+}
+
+// seconds formats d as the decimal seconds used by JUnit reports.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
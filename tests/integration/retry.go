@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// defaultRetryOn matches the transient failures of real GCP projects: quota
+// and rate limit errors and unavailable backends.
+var defaultRetryOn = []string{
+	`RESOURCE_EXHAUSTED`,
+	`(?i)quota exceeded`,
+	`rateLimitExceeded`,
+	`UNAVAILABLE`,
+	`\b(429|503)\b`,
+}
+
+// defaultBackoff is the wait before the second attempt of a test whose policy
+// sets none.
+const defaultBackoff = time.Second
+
+// retryPolicy reruns a test whose failure looks transient, e.g. a quota error
+// or an IAM change that has not propagated yet. Tests that pass on a later
+// attempt are reported as passed on retry, so flakes stay visible.
+type retryPolicy struct {
+	// MaxAttempts is the most times the test runs, including the first.
+	MaxAttempts int `json:"max_attempts"`
+	// BackoffMs is the wait before the second attempt. It doubles before
+	// every further attempt.
+	BackoffMs int `json:"backoff_ms,omitempty"`
+	// RetryOn are regular expressions over the error of a failed attempt.
+	// Only failures that match one are retried. Defaults to defaultRetryOn.
+	RetryOn []string `json:"retry_on,omitempty"`
+}
+
+func (p *retryPolicy) validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be at least 1, got %d", p.MaxAttempts)
+	}
+	if p.BackoffMs < 0 {
+		return fmt.Errorf("backoff_ms must not be negative, got %d", p.BackoffMs)
+	}
+	for _, pattern := range p.RetryOn {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid retry_on regex %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// retries reports whether a test that failed with err on the given attempt,
+// counting from 1, runs again. A nil policy never retries.
+func (p *retryPolicy) retries(attempt int, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	patterns := p.RetryOn
+	if len(patterns) == 0 {
+		patterns = defaultRetryOn
+	}
+	for _, pattern := range patterns {
+		if regexp.MustCompile(pattern).MatchString(err.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait after the given failed attempt, counting from 1.
+func (p *retryPolicy) backoff(attempt int) time.Duration {
+	wait := defaultBackoff
+	if p.BackoffMs > 0 {
+		wait = time.Duration(p.BackoffMs) * time.Millisecond
+	}
+	return wait << (attempt - 1)
+}
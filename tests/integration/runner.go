@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// testCase is a test of the runner. It writes its progress to out and passes
+// stderr as the standard error of the servers it starts. Both are buffered so
+// that the output of tests running at the same time is not interleaved.
+type testCase struct {
+	name string
+	run  func(out, stderr io.Writer) error
+	// retry, if set, reruns the test when it fails transiently.
+	retry *retryPolicy
+	// quarantine, if set, is why the test is known to be flaky. It still runs
+	// and is reported, but its failure does not fail the run.
+	quarantine string
+}
+
+type testResult struct {
+	name     string
+	output   bytes.Buffer
+	stderr   bytes.Buffer
+	err      error
+	start    time.Time
+	duration time.Duration
+	// retried are the errors of the failed attempts before the last one.
+	retried    []error
+	quarantine string
+}
+
+// passedOnRetry reports whether the test failed before it passed.
+func (r *testResult) passedOnRetry() bool {
+	return r.err == nil && len(r.retried) > 0
+}
+
+// failsRun reports whether the test failed and is not quarantined.
+func (r *testResult) failsRun() bool {
+	return r.err != nil && r.quarantine == ""
+}
+
+// runTest runs a test, and runs it again while its retry policy allows. The
+// output and server stderr of every attempt are kept.
+func runTest(test testCase) *testResult {
+	result := &testResult{name: test.name, start: time.Now(), quarantine: test.quarantine}
+	for attempt := 1; ; attempt++ {
+		result.err = test.run(&result.output, &result.stderr)
+		if result.err == nil || !test.retry.retries(attempt, result.err) {
+			break
+		}
+		wait := test.retry.backoff(attempt)
+		fmt.Fprintf(&result.output, "🔁 Attempt %d of %d failed, retrying in %s: %v\n", attempt, test.retry.MaxAttempts, wait, result.err)
+		result.retried = append(result.retried, result.err)
+		time.Sleep(wait)
+	}
+	result.duration = time.Since(result.start)
+	return result
+}
+
+// runnerOptions configure how the tests run and where results are reported.
+type runnerOptions struct {
+	// parallel is the number of tests to run at the same time.
+	parallel int
+	// junitPath, if set, is the path of a JUnit XML report of the run.
+	junitPath string
+	// jsonPath, if set, is the path of a JSON report of the run.
+	jsonPath string
+}
+
+// runTests runs the tests with up to opts.parallel of them at the same time.
+// The output of each test is printed when it finishes, followed by a summary,
+// and the reports in opts are written. It returns false if any test that is
+// not quarantined failed or a report could not be written.
+func runTests(tests []testCase, opts runnerOptions) bool {
+	parallel := opts.parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	fmt.Printf("🚀 Running %d tests with %d workers...\n", len(tests), parallel)
+	start := time.Now()
+
+	indexes := make(chan int)
+	results := make([]*testResult, len(tests))
+	var printMu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := runTest(tests[i])
+				results[i] = result
+
+				printMu.Lock()
+				printResult(result)
+				printMu.Unlock()
+			}
+		}()
+	}
+	for i := range tests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	var failed, onRetry, quarantined []*testResult
+	for _, result := range results {
+		switch {
+		case result.failsRun():
+			failed = append(failed, result)
+		case result.err != nil:
+			quarantined = append(quarantined, result)
+		case result.passedOnRetry():
+			onRetry = append(onRetry, result)
+		}
+	}
+	passed := len(tests) - len(failed) - len(quarantined)
+	fmt.Printf("📋 %d passed (%d first try, %d on retry), %d failed, %d quarantined in %s\n",
+		passed, passed-len(onRetry), len(onRetry), len(failed), len(quarantined), elapsed.Round(time.Millisecond))
+	for _, result := range onRetry {
+		fmt.Printf("⚠️  %s passed on attempt %d after: %v\n", result.name, len(result.retried)+1, result.retried[len(result.retried)-1])
+	}
+	for _, result := range quarantined {
+		fmt.Printf("🔒 %s failed but is quarantined (%s): %v\n", result.name, result.quarantine, result.err)
+	}
+	for _, result := range failed {
+		fmt.Printf("❌ %s\n", result.name)
+	}
+	if err := writeReports(results, start, elapsed, opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+	return len(failed) == 0
+}
+
+func printResult(result *testResult) {
+	fmt.Printf("=== %s (%s)\n", result.name, result.duration.Round(time.Millisecond))
+	fmt.Print(result.output.String())
+	if result.err != nil {
+		if result.stderr.Len() > 0 {
+			fmt.Printf("--- server stderr:\n%s", result.stderr.String())
+		}
+		if result.quarantine != "" {
+			fmt.Printf("🔒 %s (quarantined: %s): %v\n", result.name, result.quarantine, result.err)
+			return
+		}
+		fmt.Printf("❌ %s: %v\n", result.name, result.err)
+		return
+	}
+	if result.passedOnRetry() {
+		fmt.Printf("⚠️  %s passed on attempt %d\n", result.name, len(result.retried)+1)
+		return
+	}
+	fmt.Printf("✅ %s\n", result.name)
+}
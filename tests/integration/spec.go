@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"integration/client"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// specFile is a JSON file of tool call test cases. Cases without a server
+// command use the command of the file.
+type specFile struct {
+	ServerCmd []string `json:"server_cmd"`
+	// Resources are created in the test project before the cases run and
+	// deleted after them.
+	Resources []resourceSpec `json:"resources,omitempty"`
+	Cases     []specCase     `json:"cases"`
+}
+
+// specCase is a single tool call, or a single resource read, and the
+// assertions on its result.
+type specCase struct {
+	Name      string         `json:"name"`
+	ServerCmd []string       `json:"server_cmd,omitempty"`
+	Tool      string         `json:"tool,omitempty"`
+	Args      map[string]any `json:"args"`
+	// Resource is the URI of a resource to read instead of calling a tool.
+	// Assertions look at the text of its contents.
+	Resource string      `json:"resource,omitempty"`
+	Expect   []assertion `json:"expect"`
+	// ExpectError makes the case pass only if the call fails in this way.
+	// Expect may still check the result of a call that failed with one.
+	ExpectError *expectedError `json:"expect_error,omitempty"`
+	// Golden compares the whole result with testdata/<name>.golden.json.
+	Golden bool `json:"golden,omitempty"`
+	// Retry reruns the case when it fails transiently.
+	Retry *retryPolicy `json:"retry,omitempty"`
+	// Quarantine is why the case is known to be flaky. Its failures are
+	// reported but do not fail the run.
+	Quarantine string `json:"quarantine,omitempty"`
+}
+
+// assertion checks one property of a tool result. Exactly one of IsError,
+// Contains, NotContains, Matches, JSONField, StructuredField and Argv is set.
+type assertion struct {
+	IsError     *bool  `json:"is_error,omitempty"`
+	Contains    string `json:"contains,omitempty"`
+	NotContains string `json:"not_contains,omitempty"`
+	Matches     string `json:"matches,omitempty"`
+	// JSONField is a dotted path, e.g. "core.project" or "items.0.name", into
+	// the result text parsed as JSON. Its value must equal Equals.
+	JSONField string `json:"json_field,omitempty"`
+	// StructuredField is a dotted path into the structured content of the
+	// result, e.g. "json.core.project". Its value must equal Equals.
+	StructuredField string          `json:"structured_field,omitempty"`
+	Equals          json.RawMessage `json:"equals,omitempty"`
+	// Argv is the exact arguments gcloud must have run with, as reported in
+	// the execution metadata of run_gcloud_command, including the ones the
+	// server added, e.g. --project.
+	Argv []string `json:"argv,omitempty"`
+}
+
+// expectedError is how a call must fail. At least one field is set, and every
+// field that is set must match. A call fails if it returns an MCP error, an
+// error result, or a result whose execution metadata reports that gcloud
+// exited with a non-zero code or was stopped.
+type expectedError struct {
+	// Code is the JSON-RPC code of the MCP error, e.g. -32602 for invalid
+	// params. Errors that the SDK of the server turns into error results,
+	// whose text starts with "MCP error <code>:", match as well.
+	Code *int64 `json:"code,omitempty"`
+	// Error is the "error" field of the JSON text of an error result, e.g.
+	// "READ_ONLY" or "TIMEOUT".
+	Error string `json:"error,omitempty"`
+	// Stderr is a substring of the stderr of gcloud in the result: the text
+	// after its STDERR marker, or the "stderr" field of a JSON error result.
+	Stderr string `json:"stderr,omitempty"`
+}
+
+func (e expectedError) validate() error {
+	if e.Code == nil && e.Error == "" && e.Stderr == "" {
+		return fmt.Errorf("expect_error must set at least one of code, error and stderr")
+	}
+	return nil
+}
+
+// checkMCPError returns an error unless err is an MCP error response that
+// matches. Other errors, e.g. of a server that does not start, are returned
+// as they are.
+func (e expectedError) checkMCPError(err error) error {
+	var wireErr *jsonrpc.Error
+	if !errors.As(err, &wireErr) {
+		return err
+	}
+	if e.Code == nil || *e.Code != wireErr.Code || e.Error != "" || e.Stderr != "" {
+		return fmt.Errorf("the call failed with MCP error %d: %s, not as expected", wireErr.Code, wireErr.Message)
+	}
+	return nil
+}
+
+// check returns an error unless the result is a failure that matches.
+func (e expectedError) check(result toolResult) error {
+	var texts []string
+	for _, content := range result.Content {
+		texts = append(texts, content.Text)
+	}
+	text := strings.Join(texts, "\n")
+
+	exitCode, err := jsonField(result.StructuredContent, "execution.exitCode")
+	if !result.IsError && (err != nil || exitCode == 0.0) {
+		return fmt.Errorf("expected the call to fail. Result text: %s", text)
+	}
+	if e.Code != nil && !strings.HasPrefix(text, fmt.Sprintf("MCP error %d:", *e.Code)) {
+		return fmt.Errorf("expected MCP error %d. Result text: %s", *e.Code, text)
+	}
+	// Error results that are not JSON have no error or stderr field.
+	var document map[string]any
+	json.Unmarshal([]byte(text), &document)
+	if e.Error != "" && document["error"] != e.Error {
+		return fmt.Errorf("expected a %s error. Result text: %s", e.Error, text)
+	}
+	if e.Stderr != "" {
+		stderr, _ := document["stderr"].(string)
+		if _, after, found := strings.Cut(text, "STDERR:\n"); found {
+			stderr = after
+		}
+		if !strings.Contains(stderr, e.Stderr) {
+			return fmt.Errorf("expected the stderr of gcloud to contain %q. Result text: %s", e.Stderr, text)
+		}
+	}
+	return nil
+}
+
+// textContent is the text of a content block of a tool result or of the
+// contents of a resource.
+type textContent struct {
+	Text string `json:"text"`
+}
+
+// toolResult is the part of an MCP tool result that assertions look at.
+type toolResult struct {
+	Content           []textContent `json:"content"`
+	StructuredContent any           `json:"structuredContent"`
+	IsError           bool          `json:"isError"`
+}
+
+// resourceResult is the part of an MCP resource read result that assertions
+// look at.
+type resourceResult struct {
+	Contents []textContent `json:"contents"`
+}
+
+func (a assertion) validate() error {
+	set := 0
+	for _, isSet := range []bool{a.IsError != nil, a.Contains != "", a.NotContains != "", a.Matches != "", a.JSONField != "", a.StructuredField != "", a.Argv != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("an assertion must set exactly one of is_error, contains, not_contains, matches, json_field, structured_field and argv")
+	}
+	if a.Matches != "" {
+		if _, err := regexp.Compile(a.Matches); err != nil {
+			return fmt.Errorf("invalid regex %q: %v", a.Matches, err)
+		}
+	}
+	if a.JSONField != "" && len(a.Equals) == 0 {
+		return fmt.Errorf("json_field %q needs an equals value", a.JSONField)
+	}
+	if a.StructuredField != "" && len(a.Equals) == 0 {
+		return fmt.Errorf("structured_field %q needs an equals value", a.StructuredField)
+	}
+	return nil
+}
+
+// loadSpecs reads the spec file at path, or every .json file in it if it is a
+// directory, and returns the cases in file order with the resources they need.
+func loadSpecs(path string) ([]specCase, []resourceSpec, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read specs: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return nil, nil, fmt.Errorf("failed to list specs: %w", err)
+		}
+		sort.Strings(files)
+	}
+
+	var cases []specCase
+	var resources []resourceSpec
+	names := map[string]string{}
+	resourceFiles := map[string]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read spec %s: %w", file, err)
+		}
+		var spec specFile
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&spec); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse spec %s: %w", file, err)
+		}
+		for _, r := range spec.Resources {
+			if err := r.validate(); err != nil {
+				return nil, nil, fmt.Errorf("resource of spec %s: %w", file, err)
+			}
+			if other, ok := resourceFiles[r.Name]; ok {
+				return nil, nil, fmt.Errorf("resource %q of spec %s is also defined in %s", r.Name, file, other)
+			}
+			resourceFiles[r.Name] = file
+			resources = append(resources, r)
+		}
+		for i, sc := range spec.Cases {
+			if sc.Name == "" {
+				return nil, nil, fmt.Errorf("case %d of spec %s has no name", i+1, file)
+			}
+			if other, ok := names[sc.Name]; ok {
+				return nil, nil, fmt.Errorf("case %q of spec %s is also defined in %s", sc.Name, file, other)
+			}
+			names[sc.Name] = file
+			if (sc.Tool == "") == (sc.Resource == "") {
+				return nil, nil, fmt.Errorf("case %q of spec %s must set exactly one of tool and resource", sc.Name, file)
+			}
+			if sc.ExpectError != nil {
+				if err := sc.ExpectError.validate(); err != nil {
+					return nil, nil, fmt.Errorf("case %q of spec %s: %w", sc.Name, file, err)
+				}
+			}
+			if sc.Retry != nil {
+				if err := sc.Retry.validate(); err != nil {
+					return nil, nil, fmt.Errorf("retry of case %q in spec %s: %w", sc.Name, file, err)
+				}
+			}
+			if sc.Golden && strings.ContainsAny(sc.Name, `/\`) {
+				return nil, nil, fmt.Errorf("case %q of spec %s can not have a golden file: its name contains a path separator", sc.Name, file)
+			}
+			if len(sc.ServerCmd) == 0 {
+				sc.ServerCmd = spec.ServerCmd
+			}
+			for j, a := range sc.Expect {
+				if err := a.validate(); err != nil {
+					return nil, nil, fmt.Errorf("assertion %d of case %q in spec %s: %w", j+1, sc.Name, file, err)
+				}
+			}
+			cases = append(cases, sc)
+		}
+	}
+	return cases, resources, nil
+}
+
+// jsonField returns the value at a dotted path of a JSON document.
+func jsonField(document any, path string) (any, error) {
+	value := document
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			field, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("no field %q", path)
+			}
+			value = field
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("no field %q", path)
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("no field %q", path)
+		}
+	}
+	return value, nil
+}
+
+// fieldEquals returns an error unless the value at path of document equals the
+// JSON value equals.
+func fieldEquals(document any, path string, equals json.RawMessage) error {
+	value, err := jsonField(document, path)
+	if err != nil {
+		return err
+	}
+	var expected any
+	if err := json.Unmarshal(equals, &expected); err != nil {
+		return fmt.Errorf("invalid equals value for %q: %v", path, err)
+	}
+	if !reflect.DeepEqual(value, expected) {
+		return fmt.Errorf("expected %s to equal %s, got %v", path, string(equals), value)
+	}
+	return nil
+}
+
+// check returns an error describing how the result fails the assertion.
+func (a assertion) check(result toolResult) error {
+	var texts []string
+	for _, content := range result.Content {
+		texts = append(texts, content.Text)
+	}
+	text := strings.Join(texts, "\n")
+
+	switch {
+	case a.IsError != nil:
+		if result.IsError != *a.IsError {
+			return fmt.Errorf("expected isError to be %t. Result text: %s", *a.IsError, text)
+		}
+	case a.Contains != "":
+		if !strings.Contains(text, a.Contains) {
+			return fmt.Errorf("expected the result to contain %q. Result text: %s", a.Contains, text)
+		}
+	case a.NotContains != "":
+		if strings.Contains(text, a.NotContains) {
+			return fmt.Errorf("expected the result not to contain %q. Result text: %s", a.NotContains, text)
+		}
+	case a.Matches != "":
+		if !regexp.MustCompile(a.Matches).MatchString(text) {
+			return fmt.Errorf("expected the result to match %q. Result text: %s", a.Matches, text)
+		}
+	case a.JSONField != "":
+		// gcloud warnings follow the output after a STDERR marker.
+		if stderrIndex := strings.Index(text, "STDERR"); stderrIndex != -1 {
+			text = text[:stderrIndex]
+		}
+		var document any
+		if err := json.Unmarshal([]byte(text), &document); err != nil {
+			return fmt.Errorf("expected the result to be JSON: %v. Result text: %s", err, text)
+		}
+		return fieldEquals(document, a.JSONField, a.Equals)
+	case a.StructuredField != "":
+		if result.StructuredContent == nil {
+			return fmt.Errorf("expected the result to have structured content. Result text: %s", text)
+		}
+		return fieldEquals(result.StructuredContent, a.StructuredField, a.Equals)
+	case a.Argv != nil:
+		argv, err := jsonField(result.StructuredContent, "execution.argv")
+		if err != nil {
+			return fmt.Errorf("expected the result to report the execution of gcloud. Result text: %s", text)
+		}
+		expected, _ := json.Marshal(a.Argv)
+		actual, _ := json.Marshal(argv)
+		if !bytes.Equal(expected, actual) {
+			return fmt.Errorf("expected gcloud to run with %s, got %s", expected, actual)
+		}
+	}
+	return nil
+}
+
+// specOptions configure how spec cases run.
+type specOptions struct {
+	// remote, if set, is called instead of starting the server of a case.
+	remote *client.Remote
+	// update rewrites the golden files instead of comparing results with them.
+	update bool
+	// recordDir, if set, is the directory the MCP traffic of each case is
+	// recorded to, as <name>.jsonl.
+	recordDir string
+	// replayDir, if set, is the directory of recordings that are replayed
+	// instead of starting a server.
+	replayDir string
+	// project is the test project the resources of the specs are created in.
+	project string
+	// orphanAge is how old the resources of an earlier run must be for the
+	// run to delete them as orphans.
+	orphanAge time.Duration
+	// readOnly is set if the remote server was started with --read-only.
+	readOnly bool
+}
+
+// recordingPath returns the path of the recording of the case in dir.
+func recordingPath(dir, name string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name+".jsonl")
+}
+
+// runSpecCase calls the tool of the case, or reads its resource, and returns
+// the first failed assertion.
+func runSpecCase(sc specCase, opts specOptions, out, stderr io.Writer) error {
+	output, err := client.InvokeMCPTool(client.ToolCall{
+		ServerCmd:   sc.ServerCmd,
+		Remote:      opts.remote,
+		ToolName:    sc.Tool,
+		ToolArgs:    sc.Args,
+		ResourceURI: sc.Resource,
+		// Cases that expect an error may send invalid arguments on purpose.
+		ValidateArgs: sc.ExpectError == nil,
+		Options: client.SessionOptions{
+			Record: recordingPath(opts.recordDir, sc.Name),
+			Replay: recordingPath(opts.replayDir, sc.Name),
+			Stderr: stderr,
+		},
+	})
+	if err != nil {
+		if sc.ExpectError != nil {
+			return sc.ExpectError.checkMCPError(err)
+		}
+		return err
+	}
+	var result toolResult
+	if sc.Resource != "" {
+		var resource resourceResult
+		err = json.Unmarshal([]byte(output), &resource)
+		result.Content = resource.Contents
+	} else {
+		err = json.Unmarshal([]byte(output), &result)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing MCP output: %v\nOutput: %s", err, output)
+	}
+	if sc.ExpectError != nil {
+		if err := sc.ExpectError.check(result); err != nil {
+			return err
+		}
+	}
+	for i, a := range sc.Expect {
+		if err := a.check(result); err != nil {
+			return fmt.Errorf("assertion %d failed: %w", i+1, err)
+		}
+	}
+	if sc.Golden {
+		return checkGolden(sc.Name, output, opts.update, out)
+	}
+	return nil
+}
+
+// specTests returns a test per spec case.
+func specTests(cases []specCase, opts specOptions) []testCase {
+	tests := make([]testCase, 0, len(cases))
+	for _, sc := range cases {
+		tests = append(tests, testCase{
+			name:       sc.Name,
+			run:        func(out, stderr io.Writer) error { return runSpecCase(sc, opts, out, stderr) },
+			retry:      sc.Retry,
+			quarantine: sc.Quarantine,
+		})
+	}
+	return tests
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"integration/client"
+)
+
+// stressOptions configure stress mode.
+type stressOptions struct {
+	// calls is the number of concurrent tool calls per session.
+	calls int
+	// sessions is the number of parallel sessions of the second scenario.
+	sessions int
+	// timeout is how long a call may take before its response counts as
+	// dropped.
+	timeout time.Duration
+	// remote, if set, is stressed with the real gcloud of its deployment
+	// instead of a local server with a fake gcloud.
+	remote *client.Remote
+}
+
+// stressScenario makes calls concurrent calls in each of sessions parallel
+// sessions.
+type stressScenario struct {
+	name     string
+	sessions int
+}
+
+func stressScenarios(opts stressOptions) []stressScenario {
+	return []stressScenario{{"session", 1}, {"sessions", opts.sessions}}
+}
+
+// stressCall is one tool call of a stress scenario and its outcome.
+type stressCall struct {
+	session int
+	argv    []string
+	err     error
+}
+
+// errDropped is the error of a call that got no response in time.
+var errDropped = errors.New("dropped")
+
+// stressArgv returns an argv that no other call of the run has, so that a
+// response can be traced back to its call by the argv gcloud ran with.
+func stressArgv(scenario string, session, call int) []string {
+	return []string{"logging", "read", fmt.Sprintf("insertId=stress-%s-%d-%d", scenario, session, call), "--limit=1", "--format=json"}
+}
+
+// checkStressResult returns why a result is not the response to the call of
+// argv, naming the call it belongs to if it is the response to another call.
+// With the fake gcloud, which echoes its argv, both the output and the
+// execution metadata identify the call, otherwise only the latter.
+func checkStressResult(argv []string, output string, echo bool, owners map[string]string) error {
+	var result toolResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return fmt.Errorf("error parsing MCP output: %v\nOutput: %s", err, output)
+	}
+	if result.IsError || len(result.Content) == 0 {
+		return fmt.Errorf("the call failed: %s", output)
+	}
+	mismatch := func(source string, got []string) error {
+		diff := argvDifference(argv, got)
+		if diff == "" {
+			return nil
+		}
+		if owner, ok := owners[strings.Join(got, "\x00")]; ok {
+			return fmt.Errorf("%s is that of %s: %s", source, owner, diff)
+		}
+		return fmt.Errorf("%s is that of no call: %s", source, diff)
+	}
+	if echo {
+		var received []string
+		if err := json.NewDecoder(strings.NewReader(result.Content[0].Text)).Decode(&received); err != nil {
+			return fmt.Errorf("the output is not an echoed argv: %v\nOutput: %s", err, result.Content[0].Text)
+		}
+		if err := mismatch("the output", received); err != nil {
+			return err
+		}
+	}
+	reported, err := jsonField(result.StructuredContent, "execution.argv")
+	if err != nil {
+		return fmt.Errorf("the result has no execution argv: %v", err)
+	}
+	return mismatch("the execution argv", jsonStrings(reported))
+}
+
+// runStressSession makes all calls in the session at the same time.
+func runStressSession(session *client.Session, calls []*stressCall, owners map[string]string, opts stressOptions) {
+	var wg sync.WaitGroup
+	for _, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+			defer cancel()
+			output, err := session.CallTool(ctx, "run_gcloud_command", map[string]any{"args": call.argv, "cache": false})
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				call.err = fmt.Errorf("%w: no response within %s", errDropped, opts.timeout)
+			case err != nil:
+				call.err = err
+			default:
+				call.err = checkStressResult(call.argv, output, opts.remote == nil, owners)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runStressScenario opens the sessions of the scenario in parallel, makes
+// their calls and returns the calls with their outcome.
+func runStressScenario(scenario stressScenario, opts stressOptions) []*stressCall {
+	var calls []*stressCall
+	perSession := make([][]*stressCall, scenario.sessions)
+	for s := range scenario.sessions {
+		for c := range opts.calls {
+			call := &stressCall{session: s, argv: stressArgv(scenario.name, s, c)}
+			perSession[s] = append(perSession[s], call)
+			calls = append(calls, call)
+		}
+	}
+	owners := map[string]string{}
+	for _, call := range calls {
+		owners[strings.Join(call.argv, "\x00")] = fmt.Sprintf("session %d call %s", call.session, quoteArgv(call.argv))
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for s := range scenario.sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, err := client.NewSession(ctx, []string{"gcloud-mcp"}, opts.remote)
+			if err != nil {
+				for _, call := range perSession[s] {
+					call.err = fmt.Errorf("error starting session: %v", err)
+				}
+				return
+			}
+			defer session.Close()
+			runStressSession(session, perSession[s], owners, opts)
+		}()
+	}
+	wg.Wait()
+	return calls
+}
+
+// installStressGcloud installs a fake gcloud under which the argv of every
+// call lints and is echoed. The returned function removes it.
+func installStressGcloud(opts stressOptions) (func(), error) {
+	var argvs [][]string
+	for _, scenario := range stressScenarios(opts) {
+		for s := range scenario.sessions {
+			for c := range opts.calls {
+				argvs = append(argvs, stressArgv(scenario.name, s, c))
+			}
+		}
+	}
+	dir, err := os.MkdirTemp("", "stress-")
+	if err != nil {
+		return nil, err
+	}
+	fixtures, err := json.Marshal(fuzzFixtures(argvs))
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "fixtures.json"), fixtures, 0o644)
+	}
+	var cleanup func()
+	if err == nil {
+		cleanup, err = installFakeGcloud(filepath.Join(dir, "fixtures.json"))
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return func() {
+		cleanup()
+		os.RemoveAll(dir)
+	}, nil
+}
+
+// runStress makes many concurrent tool calls in one session, and then in many
+// parallel sessions, and checks that every call gets exactly its own
+// response: none is dropped, and none is the response to another call.
+// Without a remote server, each session starts a server of its own, with a
+// fake gcloud that echoes its argv.
+func runStress(opts stressOptions) error {
+	if opts.remote == nil {
+		cleanup, err := installStressGcloud(opts)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+
+	var failed, total int
+	for _, scenario := range stressScenarios(opts) {
+		fmt.Printf("🔥 %d sessions with %d concurrent calls each\n", scenario.sessions, opts.calls)
+		start := time.Now()
+		calls := runStressScenario(scenario, opts)
+		var dropped, mismatched int
+		for _, call := range calls {
+			if call.err == nil {
+				continue
+			}
+			if errors.Is(call.err, errDropped) {
+				dropped++
+			} else {
+				mismatched++
+			}
+			fmt.Printf("❌ session %d call %s: %v\n", call.session, quoteArgv(call.argv), call.err)
+		}
+		fmt.Printf("📋 %d of %d calls got their own response, %d dropped, %d failed or mismatched in %s\n",
+			len(calls)-dropped-mismatched, len(calls), dropped, mismatched, time.Since(start).Round(time.Millisecond))
+		failed += dropped + mismatched
+		total += len(calls)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d calls did not get their own response", failed, total)
+	}
+	fmt.Printf("✅ Assertion passed: All %d calls got their own response\n", total)
+	return nil
+}
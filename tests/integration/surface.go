@@ -0,0 +1,448 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"integration/client"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// toolSurface is what a server exposes to clients: its tools and prompts by
+// name, its resources by URI and its resource templates by URI template.
+type toolSurface struct {
+	tools     map[string]*mcp.Tool
+	prompts   map[string]*mcp.Prompt
+	resources map[string]*mcp.Resource
+	templates map[string]*mcp.ResourceTemplate
+}
+
+// surfaceChange is a difference between the same tool, prompt or resource of
+// two servers. A breaking change can make a client that works with the base
+// server fail with the head server.
+type surfaceChange struct {
+	Name string `json:"name"`
+	// Field is where the two differ, e.g. "description" or
+	// "inputSchema/properties/args/items/type".
+	Field    string `json:"field"`
+	Detail   string `json:"detail"`
+	Breaking bool   `json:"breaking"`
+}
+
+// surfaceSectionDiff is the diff of one kind of item of the two surfaces.
+type surfaceSectionDiff struct {
+	Added   []string        `json:"added"`
+	Removed []string        `json:"removed"`
+	Changed []surfaceChange `json:"changed"`
+}
+
+// surfaceDiff is the JSON document emitted by diff mode. Removed items are
+// always breaking.
+type surfaceDiff struct {
+	Base      string             `json:"base"`
+	Head      string             `json:"head"`
+	Tools     surfaceSectionDiff `json:"tools"`
+	Prompts   surfaceSectionDiff `json:"prompts"`
+	Resources surfaceSectionDiff `json:"resources"`
+	// ResourceTemplates are keyed by URI template.
+	ResourceTemplates surfaceSectionDiff `json:"resource_templates"`
+	Breaking          bool               `json:"breaking"`
+}
+
+// listSurface lists everything the server declares a capability for.
+func listSurface(ctx context.Context, session *client.Session) (*toolSurface, error) {
+	surface := &toolSurface{
+		tools:     map[string]*mcp.Tool{},
+		prompts:   map[string]*mcp.Prompt{},
+		resources: map[string]*mcp.Resource{},
+		templates: map[string]*mcp.ResourceTemplate{},
+	}
+	capabilities := session.ServerCapabilities()
+	if capabilities.Tools != nil {
+		tools, err := session.ListTools(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, tool := range tools {
+			surface.tools[tool.Name] = tool
+		}
+	}
+	if capabilities.Prompts != nil {
+		prompts, err := session.ListPrompts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, prompt := range prompts {
+			surface.prompts[prompt.Name] = prompt
+		}
+	}
+	if capabilities.Resources != nil {
+		resources, err := session.ListResources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			surface.resources[resource.URI] = resource
+		}
+		templates, err := session.ListResourceTemplates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range templates {
+			surface.templates[template.URITemplate] = template
+		}
+	}
+	return surface, nil
+}
+
+// diffSection returns the names only in base, the names only in head and
+// the changes between the items in both.
+func diffSection[T any](base, head map[string]T, changes func(name string, base, head T) []surfaceChange) surfaceSectionDiff {
+	diff := surfaceSectionDiff{Added: []string{}, Removed: []string{}, Changed: []surfaceChange{}}
+	for name, b := range base {
+		h, ok := head[name]
+		if !ok {
+			diff.Removed = append(diff.Removed, name)
+			continue
+		}
+		diff.Changed = append(diff.Changed, changes(name, b, h)...)
+	}
+	for name := range head {
+		if _, ok := base[name]; !ok {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		if diff.Changed[i].Name != diff.Changed[j].Name {
+			return diff.Changed[i].Name < diff.Changed[j].Name
+		}
+		return diff.Changed[i].Field < diff.Changed[j].Field
+	})
+	return diff
+}
+
+func (d surfaceSectionDiff) breaking() bool {
+	return len(d.Removed) > 0 || slices.ContainsFunc(d.Changed, func(c surfaceChange) bool { return c.Breaking })
+}
+
+// diffSurfaces compares the surface of the head server with that of the
+// base server.
+func diffSurfaces(base, head *toolSurface) surfaceDiff {
+	diff := surfaceDiff{
+		Tools:             diffSection(base.tools, head.tools, toolChanges),
+		Prompts:           diffSection(base.prompts, head.prompts, promptChanges),
+		Resources:         diffSection(base.resources, head.resources, resourceChanges),
+		ResourceTemplates: diffSection(base.templates, head.templates, templateChanges),
+	}
+	diff.Breaking = diff.Tools.breaking() || diff.Prompts.breaking() || diff.Resources.breaking() || diff.ResourceTemplates.breaking()
+	return diff
+}
+
+// fieldChange returns a non-breaking change if a field of an item differs.
+func fieldChange(name, field string, base, head any) []surfaceChange {
+	if reflect.DeepEqual(base, head) {
+		return nil
+	}
+	return []surfaceChange{{Name: name, Field: field, Detail: fmt.Sprintf("%s → %s", compactJSON(base), compactJSON(head))}}
+}
+
+func toolChanges(name string, base, head *mcp.Tool) []surfaceChange {
+	var changes []surfaceChange
+	changes = append(changes, fieldChange(name, "description", base.Description, head.Description)...)
+	changes = append(changes, fieldChange(name, "annotations", base.Annotations, head.Annotations)...)
+	changes = append(changes, schemaChanges(name, "inputSchema", jsonValue(base.InputSchema), jsonValue(head.InputSchema), true)...)
+	changes = append(changes, schemaChanges(name, "outputSchema", jsonValue(base.OutputSchema), jsonValue(head.OutputSchema), false)...)
+	return changes
+}
+
+func promptChanges(name string, base, head *mcp.Prompt) []surfaceChange {
+	changes := fieldChange(name, "description", base.Description, head.Description)
+	arguments := map[string]*mcp.PromptArgument{}
+	for _, argument := range base.Arguments {
+		arguments[argument.Name] = argument
+	}
+	for _, h := range head.Arguments {
+		field := "arguments/" + h.Name
+		b, ok := arguments[h.Name]
+		delete(arguments, h.Name)
+		switch {
+		case !ok:
+			changes = append(changes, surfaceChange{Name: name, Field: field, Detail: fmt.Sprintf("added, required: %t", h.Required), Breaking: h.Required})
+		case !b.Required && h.Required:
+			changes = append(changes, surfaceChange{Name: name, Field: field, Detail: "now required", Breaking: true})
+		case b.Required && !h.Required:
+			changes = append(changes, surfaceChange{Name: name, Field: field, Detail: "no longer required"})
+		}
+		if ok {
+			changes = append(changes, fieldChange(name, field+"/description", b.Description, h.Description)...)
+		}
+	}
+	for argument := range arguments {
+		changes = append(changes, surfaceChange{Name: name, Field: "arguments/" + argument, Detail: "removed", Breaking: true})
+	}
+	return changes
+}
+
+func resourceChanges(uri string, base, head *mcp.Resource) []surfaceChange {
+	changes := fieldChange(uri, "description", base.Description, head.Description)
+	changes = append(changes, mimeTypeChange(uri, base.MIMEType, head.MIMEType)...)
+	return changes
+}
+
+func templateChanges(uriTemplate string, base, head *mcp.ResourceTemplate) []surfaceChange {
+	changes := fieldChange(uriTemplate, "description", base.Description, head.Description)
+	changes = append(changes, mimeTypeChange(uriTemplate, base.MIMEType, head.MIMEType)...)
+	return changes
+}
+
+// mimeTypeChange is breaking: clients parse the contents by their MIME type.
+func mimeTypeChange(name, base, head string) []surfaceChange {
+	changes := fieldChange(name, "mimeType", base, head)
+	for i := range changes {
+		changes[i].Breaking = true
+	}
+	return changes
+}
+
+// schemaChanges compares two JSON schemas. A change of an input schema is
+// breaking if it can reject arguments the base schema accepted, and a change
+// of an output schema if it can allow results the base schema did not.
+func schemaChanges(name, at string, base, head any, input bool) []surfaceChange {
+	if reflect.DeepEqual(base, head) {
+		return nil
+	}
+	change := func(field, detail string, breaking bool) surfaceChange {
+		return surfaceChange{Name: name, Field: field, Detail: detail, Breaking: breaking}
+	}
+	b, bOK := base.(map[string]any)
+	h, hOK := head.(map[string]any)
+	if !bOK || !hOK {
+		return []surfaceChange{change(at, fmt.Sprintf("%s → %s", compactJSON(base), compactJSON(head)), true)}
+	}
+
+	var changes []surfaceChange
+	for _, keyword := range sortedKeys(b, h) {
+		field := at + "/" + keyword
+		bv, hv := b[keyword], h[keyword]
+		if reflect.DeepEqual(bv, hv) {
+			continue
+		}
+		switch keyword {
+		case "properties":
+			bProps, _ := bv.(map[string]any)
+			hProps, _ := hv.(map[string]any)
+			required := stringSet(h["required"])
+			for _, property := range sortedKeys(bProps, hProps) {
+				bp, inBase := bProps[property]
+				hp, inHead := hProps[property]
+				switch {
+				case !inHead:
+					changes = append(changes, change(field+"/"+property, "removed", true))
+				case !inBase:
+					changes = append(changes, change(field+"/"+property, fmt.Sprintf("added, required: %t", required[property]), input && required[property]))
+				default:
+					changes = append(changes, schemaChanges(name, field+"/"+property, bp, hp, input)...)
+				}
+			}
+		case "required":
+			bRequired, hRequired := stringSet(bv), stringSet(hv)
+			hProps, _ := h["properties"].(map[string]any)
+			bProps, _ := b["properties"].(map[string]any)
+			for _, property := range sortedKeys(bRequired, hRequired) {
+				_, inBase := bProps[property]
+				_, inHead := hProps[property]
+				switch {
+				case hRequired[property] && !bRequired[property] && (inBase || !inHead):
+					changes = append(changes, change(field+"/"+property, "now required", input))
+				case bRequired[property] && !hRequired[property] && inHead:
+					changes = append(changes, change(field+"/"+property, "no longer required", !input))
+				}
+			}
+		case "type", "enum":
+			bValues, hValues := valueSet(bv), valueSet(hv)
+			narrowed := bv == nil || !subset(bValues, hValues)
+			widened := hv == nil || !subset(hValues, bValues)
+			breaking := (input && narrowed) || (!input && widened)
+			changes = append(changes, change(field, fmt.Sprintf("%s → %s", compactJSON(bv), compactJSON(hv)), breaking))
+		case "items", "additionalProperties":
+			if _, ok := bv.(map[string]any); ok {
+				if _, ok := hv.(map[string]any); ok {
+					changes = append(changes, schemaChanges(name, field, bv, hv, input)...)
+					continue
+				}
+			}
+			// Closing an input object to unknown properties rejects arguments
+			// the base schema accepted.
+			breaking := !input || hv == false
+			changes = append(changes, change(field, fmt.Sprintf("%s → %s", compactJSON(bv), compactJSON(hv)), breaking))
+		case "description", "title", "default", "examples", "$schema":
+			changes = append(changes, change(field, fmt.Sprintf("%s → %s", compactJSON(bv), compactJSON(hv)), false))
+		default:
+			// Other constraints, e.g. minItems or pattern, may reject values
+			// either way.
+			changes = append(changes, change(field, fmt.Sprintf("%s → %s", compactJSON(bv), compactJSON(hv)), true))
+		}
+	}
+	return changes
+}
+
+func sortedKeys[T any](maps ...map[string]T) []string {
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringSet returns the strings of a JSON array.
+func stringSet(value any) map[string]bool {
+	set := map[string]bool{}
+	values, _ := value.([]any)
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// valueSet returns the values of a "type" or "enum" keyword as JSON text.
+func valueSet(value any) map[string]bool {
+	set := map[string]bool{}
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	for _, v := range values {
+		set[compactJSON(v)] = true
+	}
+	return set
+}
+
+func subset(a, b map[string]bool) bool {
+	for value := range a {
+		if !b[value] {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonValue converts a value to its generic JSON form, e.g. a schema to a
+// map[string]any.
+func jsonValue(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+	return generic
+}
+
+func compactJSON(value any) string {
+	if value == nil {
+		return "none"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// connectSurface starts the server, or connects to remote if it is set, and
+// lists its surface.
+func connectSurface(ctx context.Context, serverCmd []string, remote *client.Remote) (*toolSurface, error) {
+	session, err := client.NewSessionWithOptions(ctx, serverCmd, remote, client.SessionOptions{Stderr: os.Stderr})
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	return listSurface(ctx, session)
+}
+
+// runDiff compares the surface of the head server, or of remote if it is
+// set, with that of the base server, and writes the JSON diff to outPath, or
+// to stdout when outPath is empty. It fails if the head server has breaking
+// changes.
+func runDiff(baseCmd, headCmd string, remote *client.Remote, outPath string) error {
+	if baseCmd == "" || (headCmd == "" && remote == nil) {
+		return fmt.Errorf("diff mode needs -base and -head or -url")
+	}
+	headName := headCmd
+	if remote != nil {
+		headName = remote.URL
+	}
+	fmt.Printf("🚀 Comparing the tool surface of %s with %s...\n", headName, baseCmd)
+	ctx := context.Background()
+	base, err := connectSurface(ctx, strings.Fields(baseCmd), nil)
+	if err != nil {
+		return fmt.Errorf("base server: %w", err)
+	}
+	head, err := connectSurface(ctx, strings.Fields(headCmd), remote)
+	if err != nil {
+		return fmt.Errorf("head server: %w", err)
+	}
+
+	diff := diffSurfaces(base, head)
+	diff.Base, diff.Head = baseCmd, headName
+	for _, section := range []struct {
+		kind string
+		diff surfaceSectionDiff
+	}{
+		{"tool", diff.Tools},
+		{"prompt", diff.Prompts},
+		{"resource", diff.Resources},
+		{"resource template", diff.ResourceTemplates},
+	} {
+		for _, name := range section.diff.Added {
+			fmt.Printf("➕ Added %s %s\n", section.kind, name)
+		}
+		for _, name := range section.diff.Removed {
+			fmt.Printf("💥 Removed %s %s\n", section.kind, name)
+		}
+		for _, c := range section.diff.Changed {
+			marker := "✏️ "
+			if c.Breaking {
+				marker = "💥"
+			}
+			fmt.Printf("%s Changed %s %s %s: %s\n", marker, section.kind, c.Name, c.Field, c.Detail)
+		}
+	}
+
+	diffJSON, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format surface diff: %w", err)
+	}
+	if outPath == "" {
+		fmt.Println(string(diffJSON))
+	} else {
+		if err := os.WriteFile(outPath, diffJSON, 0o644); err != nil {
+			return fmt.Errorf("failed to write surface diff: %w", err)
+		}
+		fmt.Printf("📄 Surface diff written to %s\n", outPath)
+	}
+
+	if diff.Breaking {
+		return fmt.Errorf("the head server has breaking changes")
+	}
+	fmt.Println("✅ No breaking changes")
+	return nil
+}